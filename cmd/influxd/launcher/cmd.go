@@ -588,6 +588,16 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "influxql-max-select-buckets",
 			Desc:  "The maximum number of group by time bucket a SELECT can create. A value of zero will max the maximum number of buckets unlimited.",
 		},
+		{
+			DestP: &o.CoordinatorConfig.MaxRegexComplexity,
+			Flag:  "influxql-max-regex-complexity",
+			Desc:  "The maximum number of instructions a regular expression in a SELECT can compile to. A value of 0 will make the complexity unlimited.",
+		},
+		{
+			DestP: &o.CoordinatorConfig.Rollups,
+			Flag:  "influxql-rollups",
+			Desc:  "Rollup measurements in the form source:rollup:threshold. A SELECT with auto_rollup=true reads the rollup measurement instead of the source when its time range is wider than the threshold.",
+		},
 
 		// NATS config
 		{
@@ -518,17 +518,28 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	m.log.Info("Configuring InfluxQL statement executor (zeros indicate unlimited).",
 		zap.Int("max_select_point", opts.CoordinatorConfig.MaxSelectPointN),
 		zap.Int("max_select_series", opts.CoordinatorConfig.MaxSelectSeriesN),
-		zap.Int("max_select_buckets", opts.CoordinatorConfig.MaxSelectBucketsN))
+		zap.Int("max_select_buckets", opts.CoordinatorConfig.MaxSelectBucketsN),
+		zap.Int("max_regex_complexity", opts.CoordinatorConfig.MaxRegexComplexity),
+		zap.Strings("rollups", opts.CoordinatorConfig.Rollups))
+
+	rollups, err := iqlcoordinator.ParseRollups(opts.CoordinatorConfig.Rollups)
+	if err != nil {
+		m.log.Error("Failed parsing InfluxQL rollups", zap.Error(err))
+		return err
+	}
 
 	qe := iqlquery.NewExecutor(m.log, cm)
 	se := &iqlcoordinator.StatementExecutor{
-		MetaClient:        metaClient,
-		TSDBStore:         m.engine.TSDBStore(),
-		ShardMapper:       mapper,
-		DBRP:              dbrpSvc,
-		MaxSelectPointN:   opts.CoordinatorConfig.MaxSelectPointN,
-		MaxSelectSeriesN:  opts.CoordinatorConfig.MaxSelectSeriesN,
-		MaxSelectBucketsN: opts.CoordinatorConfig.MaxSelectBucketsN,
+		MetaClient:         metaClient,
+		TSDBStore:          m.engine.TSDBStore(),
+		ShardMapper:        mapper,
+		DBRP:               dbrpSvc,
+		MaxSelectPointN:    opts.CoordinatorConfig.MaxSelectPointN,
+		MaxSelectSeriesN:   opts.CoordinatorConfig.MaxSelectSeriesN,
+		MaxSelectBucketsN:  opts.CoordinatorConfig.MaxSelectBucketsN,
+		MaxRegexComplexity: opts.CoordinatorConfig.MaxRegexComplexity,
+		Rollups:            rollups,
+		IntoWriter:         pointsWriter,
 	}
 	qe.StatementExecutor = se
 	qe.StatementNormalizer = se
@@ -24,6 +24,7 @@ type InfluxQLBackend struct {
 	Logger                *zap.Logger
 	AuthorizationService  platform.AuthorizationService
 	OrganizationService   platform.OrganizationService
+	BucketService         platform.BucketService
 	ProxyQueryService     query.ProxyQueryService
 	InfluxqldQueryService influxqld.ProxyQueryService
 }
@@ -35,6 +36,7 @@ func NewInfluxQLBackend(b *Backend) *InfluxQLBackend {
 		Logger:                b.Logger.With(zap.String("handler", "influxql")),
 		AuthorizationService:  b.AuthorizationService,
 		OrganizationService:   b.OrganizationService,
+		BucketService:         b.BucketService,
 		InfluxqldQueryService: b.InfluxqldQueryService,
 	}
 }
@@ -1,18 +1,25 @@
 package legacy
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/influxdata/flux/iocounter"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/influxql"
+	iqlquery "github.com/influxdata/influxdb/v2/influxql/query"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	iql "github.com/influxdata/influxql"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
@@ -134,6 +141,82 @@ func (h *InfluxqlHandler) handleInfluxqldQuery(w http.ResponseWriter, r *http.Re
 		}
 	}
 
+	// Validate the time zone so an unknown zone is reported before the query runs.
+	tz := r.FormValue("tz")
+	if tz != "" {
+		if _, err := iqlquery.LoadLocation(tz); err != nil {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "error parsing tz parameter",
+				Err:  err,
+			}, w)
+			return
+		}
+	}
+
+	// Validate the layout used to format the time column. A layout must
+	// contain at least one time element and be able to parse its own output.
+	timeFormat := r.FormValue("time_format")
+	if timeFormat != "" {
+		if r.FormValue("epoch") != "" {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "time_format parameter cannot be combined with epoch",
+			}, w)
+			return
+		}
+		if err := validateTimeFormat(timeFormat); err != nil {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "error parsing time_format parameter",
+				Err:  err,
+			}, w)
+			return
+		}
+	}
+
+	// Validate the side of each GROUP BY time() bucket used as its label.
+	label := r.FormValue("label")
+	switch label {
+	case "", "left", "right":
+	default:
+		h.HandleHTTPError(ctx, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("label must be left or right, got %q", label),
+		}, w)
+		return
+	}
+
+	// Parse the sentinel value that should be read as null.
+	var treatAsNull *float64
+	if s := r.FormValue("treat_as_null"); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "error parsing treat_as_null parameter",
+				Err:  err,
+			}, w)
+			return
+		}
+		treatAsNull = &v
+	}
+
+	// Parse the maximum number of rows returned by each statement.
+	var maxRows int
+	if s := r.FormValue("max_rows"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "max_rows must be a non-negative integer",
+				Err:  err,
+			}, w)
+			return
+		}
+		maxRows = n
+	}
+
 	// Parse chunk size. Use default if not provided or cannot be parsed
 	chunked := r.FormValue("chunked") == "true"
 	chunkSize := DefaultChunkSize
@@ -143,22 +226,70 @@ func (h *InfluxqlHandler) handleInfluxqldQuery(w http.ResponseWriter, r *http.Re
 		}
 	}
 
+	// Resolve a bucket named by the request directly rather than through
+	// the DBRP mapping of a database and retention policy.
+	db, rp := r.FormValue("db"), r.FormValue("rp")
+	var bucketID platform.ID
+	if name := r.FormValue("bucket"); name != "" {
+		if db != "" || rp != "" {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "bucket parameter cannot be combined with db or rp",
+			}, w)
+			return
+		}
+
+		b, err := h.BucketService.FindBucketByName(ctx, o.ID, name)
+		if err != nil {
+			h.HandleHTTPError(ctx, err, w)
+			return
+		}
+		if err := checkBucketReadPermissions(auth, b.OrgID, b.ID); err != nil {
+			h.HandleHTTPError(ctx, err, w)
+			return
+		}
+		db, rp = bucketDBRP(b.Name)
+		bucketID = b.ID
+	}
+
 	formatString := r.Header.Get("Accept")
 	encodingFormat := influxql.EncodingFormatFromMimeType(formatString)
 	w.Header().Set("Content-Type", encodingFormat.ContentType())
 
 	req := &influxql.QueryRequest{
-		DB:             r.FormValue("db"),
-		RP:             r.FormValue("rp"),
-		Epoch:          r.FormValue("epoch"),
-		EncodingFormat: encodingFormat,
-		OrganizationID: o.ID,
-		Query:          query,
-		Params:         params,
-		Source:         r.Header.Get("User-Agent"),
-		Authorization:  auth,
-		Chunked:        chunked,
-		ChunkSize:      chunkSize,
+		DB:                 db,
+		RP:                 rp,
+		Epoch:              r.FormValue("epoch"),
+		TimeFormat:         timeFormat,
+		EncodingFormat:     encodingFormat,
+		OrganizationID:     o.ID,
+		Query:              query,
+		Params:             params,
+		Source:             r.Header.Get("User-Agent"),
+		Authorization:      auth,
+		Chunked:            chunked,
+		ChunkSize:          chunkSize,
+		CoerceNumeric:      r.FormValue("coerce_numeric") == "true",
+		TimeZone:           tz,
+		OrderValues:        r.FormValue("order_values") == "true",
+		ColumnsOnly:        r.FormValue("columns_only") == "true",
+		Interleave:         r.FormValue("interleave") == "true",
+		TreatAsNull:        treatAsNull,
+		LocalTime:          r.FormValue("local_time") == "true",
+		Pivot:              r.FormValue("pivot"),
+		TagsAsJSON:         r.FormValue("tags_as_json") == "true",
+		UnionColumns:       r.FormValue("union_columns") == "true",
+		MaxRows:            maxRows,
+		EchoRange:          r.FormValue("echo_range") == "true",
+		OrderByCardinality: r.FormValue("order_by_cardinality") == "true",
+		HoltWintersBounds:  r.FormValue("holt_winters_bounds") == "true",
+		LimitSeriesFirst:   r.FormValue("limit_series_first") == "true",
+		Label:              label,
+		AutoRollup:         r.FormValue("auto_rollup") == "true",
+		DropEmptyTags:      r.FormValue("drop_empty_tags") == "true",
+		Estimate:           r.FormValue("estimate") == "true",
+		SeriesID:           r.FormValue("series_id") == "true",
+		BucketID:           bucketID,
 	}
 
 	var respSize int64
@@ -169,6 +300,10 @@ func (h *InfluxqlHandler) handleInfluxqldQuery(w http.ResponseWriter, r *http.Re
 	if err != nil {
 		if respSize == 0 {
 			// Only record the error headers IFF nothing has been written to w.
+			if perr, ok := parseError(err); ok {
+				writeParseErrorResponse(ctx, w, err, perr)
+				return
+			}
 			h.HandleHTTPError(ctx, err, w)
 			return
 		}
@@ -179,3 +314,82 @@ func (h *InfluxqlHandler) handleInfluxqldQuery(w http.ResponseWriter, r *http.Re
 		)
 	}
 }
+
+// validateTimeFormat returns an error if layout is not a usable Go time layout.
+func validateTimeFormat(layout string) error {
+	ts := time.Date(2000, time.March, 4, 5, 6, 7, 0, time.UTC)
+	s := ts.Format(layout)
+	if s == layout {
+		return fmt.Errorf("layout %q does not contain any time elements", layout)
+	}
+	if _, err := time.Parse(layout, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseError returns the influxql parse error wrapped by err, if any.
+func parseError(err error) (*iql.ParseError, bool) {
+	e, ok := err.(*errors.Error)
+	if !ok {
+		return nil, false
+	}
+	perr, ok := e.Err.(*iql.ParseError)
+	return perr, ok
+}
+
+// writeParseErrorResponse writes err in the same format as HandleHTTPError
+// along with the one-based position in the query at which parsing failed.
+func writeParseErrorResponse(ctx context.Context, w http.ResponseWriter, err error, perr *iql.ParseError) {
+	code := errors.ErrorCode(err)
+	w.Header().Set(kithttp.PlatformErrorCodeHeader, code)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(kithttp.ErrorCodeToStatusCode(ctx, code))
+	type position struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	}
+	e := struct {
+		Code     string   `json:"code"`
+		Message  string   `json:"message"`
+		Position position `json:"error_position"`
+	}{
+		Code:     code,
+		Message:  err.Error(),
+		Position: position{Line: perr.Pos.Line + 1, Column: perr.Pos.Char + 1},
+	}
+	b, _ := json.Marshal(e)
+	_, _ = w.Write(b)
+}
+
+// bucketDBRP derives the database and retention policy of a bucket from its
+// name. A bucket named "db/rp" maps to that database and retention policy and
+// any other bucket maps to the autogen retention policy of a database with the
+// same name as the bucket.
+func bucketDBRP(name string) (db, rp string) {
+	if i := strings.LastIndex(name, "/"); i > 0 && i < len(name)-1 {
+		return name[:i], name[i+1:]
+	}
+	return name, "autogen"
+}
+
+// checkBucketReadPermissions checks an Authorizer for read permissions to a
+// specific Bucket.
+func checkBucketReadPermissions(auth influxdb.Authorizer, orgID, bucketID platform.ID) error {
+	p, err := influxdb.NewPermissionAtID(bucketID, influxdb.ReadAction, influxdb.BucketsResourceType, orgID)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Msg:  fmt.Sprintf("unable to create permission for bucket: %v", err),
+			Err:  err,
+		}
+	}
+	if pset, err := auth.PermissionSet(); err != nil || !pset.Allowed(*p) {
+		return &errors.Error{
+			Code: errors.EForbidden,
+			Msg:  "insufficient permissions for read",
+			Err:  err,
+		}
+	}
+	return nil
+}
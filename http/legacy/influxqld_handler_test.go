@@ -13,9 +13,12 @@ import (
 	pcontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/influxql"
 	imock "github.com/influxdata/influxdb/v2/influxql/mock"
+	iqlquery "github.com/influxdata/influxdb/v2/influxql/query"
+	platform2 "github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
+	iql "github.com/influxdata/influxql"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -45,8 +48,26 @@ var cmpOpts = []cmp.Option{
 func TestInfluxQLdHandler_HandleQuery(t *testing.T) {
 	ctx := context.Background()
 
+	orgID, bucketID := platform2.ID(1), platform2.ID(2)
+	readBucket, err := platform.NewPermissionAtID(bucketID, platform.ReadAction, platform.BucketsResourceType, orgID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucketService := &mock.BucketService{
+		FindBucketByNameFn: func(ctx context.Context, id platform2.ID, name string) (*platform.Bucket, error) {
+			if name != "telegraf/weekly" {
+				return nil, &errors.Error{
+					Code: errors.ENotFound,
+					Msg:  "bucket not found",
+				}
+			}
+			return &platform.Bucket{ID: bucketID, OrgID: orgID, Name: name}, nil
+		},
+	}
+
 	type fields struct {
 		OrganizationService platform.OrganizationService
+		BucketService       platform.BucketService
 		ProxyQueryService   influxql.ProxyQueryService
 	}
 	type args struct {
@@ -142,6 +163,299 @@ func TestInfluxQLdHandler_HandleQuery(t *testing.T) {
 			},
 			wantBody: []byte(`{"code":"unprocessable entity","message":"bad query"}`),
 		},
+		{
+			name:    "parse error position",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{}, nil
+					},
+				},
+				ProxyQueryService: &imock.ProxyQueryService{
+					QueryF: func(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+						_, err := iql.ParseQuery(req.Query)
+						return influxql.Statistics{}, &errors.Error{
+							Code: errors.EInvalid,
+							Msg:  "failed to parse query",
+							Err:  err,
+						}
+					},
+				},
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?q=SELECT%20value%20FROM%20cpu%0AWHERE%20AND", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantCode: http.StatusBadRequest,
+			wantHeader: http.Header{
+				"X-Platform-Error-Code": {"invalid"},
+				"Content-Type":          {"application/json; charset=utf-8"},
+			},
+			wantBody: []byte(`{"code":"invalid","message":"failed to parse query: found AND, expected identifier, string, number, bool at line 2, char 7","error_position":{"line":2,"column":7}}`),
+		},
+		{
+			name:    "parse error position after IN list",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{}, nil
+					},
+				},
+				ProxyQueryService: &imock.ProxyQueryService{
+					QueryF: func(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+						_, err := iqlquery.ParseQuery(req.Query, req.Params)
+						return influxql.Statistics{}, &errors.Error{
+							Code: errors.EInvalid,
+							Msg:  "failed to parse query",
+							Err:  err,
+						}
+					},
+				},
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?q=SELECT%20value%20FROM%20cpu%20WHERE%20host%20IN%20('a'%2C%20'b')%20AND%20AND", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantCode: http.StatusBadRequest,
+			wantHeader: http.Header{
+				"X-Platform-Error-Code": {"invalid"},
+				"Content-Type":          {"application/json; charset=utf-8"},
+			},
+			wantBody: []byte(`{"code":"invalid","message":"failed to parse query: found AND, expected identifier, string, number, bool at line 1, char 52","error_position":{"line":1,"column":52}}`),
+		},
+		{
+			name:    "unknown time zone",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{}, nil
+					},
+				},
+				ProxyQueryService: &imock.ProxyQueryService{
+					QueryF: func(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+						_, err := io.WriteString(w, "good")
+						return influxql.Statistics{}, err
+					},
+				},
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?tz=Mars/Olympus_Mons", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantCode: http.StatusBadRequest,
+			wantHeader: http.Header{
+				"X-Platform-Error-Code": {"invalid"},
+				"Content-Type":          {"application/json; charset=utf-8"},
+			},
+			wantBody: []byte(`{"code":"invalid","message":"error parsing tz parameter: unknown time zone Mars/Olympus_Mons"}`),
+		},
+		{
+			name:    "invalid fixed offset time zone",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{}, nil
+					},
+				},
+				ProxyQueryService: &imock.ProxyQueryService{
+					QueryF: func(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+						_, err := io.WriteString(w, "good")
+						return influxql.Statistics{}, err
+					},
+				},
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?tz=%2B25:00", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantCode: http.StatusBadRequest,
+			wantHeader: http.Header{
+				"X-Platform-Error-Code": {"invalid"},
+				"Content-Type":          {"application/json; charset=utf-8"},
+			},
+			wantBody: []byte(`{"code":"invalid","message":"error parsing tz parameter: invalid timezone"}`),
+		},
+		{
+			name:    "invalid treat_as_null value",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{}, nil
+					},
+				},
+				ProxyQueryService: &imock.ProxyQueryService{
+					QueryF: func(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+						_, err := io.WriteString(w, "good")
+						return influxql.Statistics{}, err
+					},
+				},
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?treat_as_null=none", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantCode: http.StatusBadRequest,
+			wantHeader: http.Header{
+				"X-Platform-Error-Code": {"invalid"},
+				"Content-Type":          {"application/json; charset=utf-8"},
+			},
+			wantBody: []byte(`{"code":"invalid","message":"error parsing treat_as_null parameter: strconv.ParseFloat: parsing \"none\": invalid syntax"}`),
+		},
+		{
+			name:    "invalid time_format layout",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{}, nil
+					},
+				},
+				ProxyQueryService: &imock.ProxyQueryService{
+					QueryF: func(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+						_, err := io.WriteString(w, "good")
+						return influxql.Statistics{}, err
+					},
+				},
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?time_format=yyyy-mm-dd", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantCode: http.StatusBadRequest,
+			wantHeader: http.Header{
+				"X-Platform-Error-Code": {"invalid"},
+				"Content-Type":          {"application/json; charset=utf-8"},
+			},
+			wantBody: []byte(`{"code":"invalid","message":"error parsing time_format parameter: layout \"yyyy-mm-dd\" does not contain any time elements"}`),
+		},
+		{
+			name:    "invalid label",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{}, nil
+					},
+				},
+				ProxyQueryService: &imock.ProxyQueryService{
+					QueryF: func(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+						_, err := io.WriteString(w, "good")
+						return influxql.Statistics{}, err
+					},
+				},
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?label=center", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantCode: http.StatusBadRequest,
+			wantHeader: http.Header{
+				"X-Platform-Error-Code": {"invalid"},
+				"Content-Type":          {"application/json; charset=utf-8"},
+			},
+			wantBody: []byte(`{"code":"invalid","message":"label must be left or right, got \"center\""}`),
+		},
+		{
+			name:    "bucket combined with db",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{}, nil
+					},
+				},
+				BucketService: bucketService,
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?bucket=telegraf/weekly&db=telegraf", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantCode: http.StatusBadRequest,
+			wantHeader: http.Header{
+				"X-Platform-Error-Code": {"invalid"},
+				"Content-Type":          {"application/json; charset=utf-8"},
+			},
+			wantBody: []byte(`{"code":"invalid","message":"bucket parameter cannot be combined with db or rp"}`),
+		},
+		{
+			name:    "unknown bucket",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{ID: orgID}, nil
+					},
+				},
+				BucketService: bucketService,
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?bucket=missing", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantCode: http.StatusNotFound,
+			wantHeader: http.Header{
+				"X-Platform-Error-Code": {"not found"},
+				"Content-Type":          {"application/json; charset=utf-8"},
+			},
+			wantBody: []byte(`{"code":"not found","message":"bucket not found"}`),
+		},
+		{
+			name:    "bucket without read permission",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active, OrgID: orgID}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{ID: orgID}, nil
+					},
+				},
+				BucketService: bucketService,
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?bucket=telegraf/weekly", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantCode: http.StatusForbidden,
+			wantHeader: http.Header{
+				"X-Platform-Error-Code": {"forbidden"},
+				"Content-Type":          {"application/json; charset=utf-8"},
+			},
+			wantBody: []byte(`{"code":"forbidden","message":"insufficient permissions for read"}`),
+		},
+		{
+			name:    "query by bucket",
+			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active, OrgID: orgID, Permissions: []platform.Permission{*readBucket}}),
+			fields: fields{
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, filter platform.OrganizationFilter) (*platform.Organization, error) {
+						return &platform.Organization{ID: orgID}, nil
+					},
+				},
+				BucketService: bucketService,
+				ProxyQueryService: &imock.ProxyQueryService{
+					QueryF: func(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+						if req.DB != "telegraf" || req.RP != "weekly" || req.BucketID != bucketID {
+							t.Errorf("unexpected request: db=%q rp=%q bucket=%s", req.DB, req.RP, req.BucketID)
+						}
+						_, err := io.WriteString(w, "good")
+						return influxql.Statistics{}, err
+					},
+				},
+			},
+			args: args{
+				r: httptest.NewRequest("POST", "/query?bucket=telegraf/weekly", nil).WithContext(ctx),
+				w: httptest.NewRecorder(),
+			},
+			wantBody: []byte("good"),
+			wantCode: http.StatusOK,
+			wantHeader: http.Header{
+				"Content-Type": {"application/json"},
+			},
+		},
 		{
 			name:    "query fails during write",
 			context: pcontext.SetAuthorizer(ctx, &platform.Authorization{Status: platform.Active}),
@@ -229,6 +543,7 @@ func TestInfluxQLdHandler_HandleQuery(t *testing.T) {
 			b := &InfluxQLBackend{
 				HTTPErrorHandler:      kithttp.NewErrorHandler(zaptest.NewLogger(t)),
 				OrganizationService:   tt.fields.OrganizationService,
+				BucketService:         tt.fields.BucketService,
 				InfluxqldQueryService: tt.fields.ProxyQueryService,
 			}
 
@@ -0,0 +1,53 @@
+package query
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxMonthDuration is the longest a calendar month can be.
+const maxMonthDuration = 31 * 24 * time.Hour
+
+// rewriteCalendarIntervals quotes the calendar intervals of time() calls,
+// such as time(1mo) or time(1y), so they can be parsed by influxql which
+// only accepts fixed durations. The compiler reads the quoted interval.
+func rewriteCalendarIntervals(q string) *queryRewriter {
+	tokens := scanQueryTokens(q)
+
+	r := &queryRewriter{q: q}
+	for i := 0; i+2 < len(tokens); i++ {
+		if !tokens[i].isKeyword(q, "time") || !tokens[i+1].is('(') || tokens[i+2].kind != queryNumber {
+			continue
+		}
+
+		arg := tokens[i+2]
+		if _, ok := parseCalendarInterval(q[arg.start:arg.end]); !ok {
+			continue
+		}
+		r.replace(arg.start, arg.end, "'"+q[arg.start:arg.end]+"'")
+	}
+	return r
+}
+
+// parseCalendarInterval returns the number of months in a calendar interval
+// written as a number of months (mo) or years (y).
+func parseCalendarInterval(s string) (int, bool) {
+	var n string
+	var months int
+	if strings.HasSuffix(s, "mo") {
+		n, months = strings.TrimSuffix(s, "mo"), 1
+	} else if strings.HasSuffix(s, "y") {
+		n, months = strings.TrimSuffix(s, "y"), 12
+	} else {
+		return 0, false
+	}
+
+	// Limit the interval so its longest duration does not overflow.
+	v, err := strconv.Atoi(n)
+	if err != nil || v <= 0 || v > int(math.MaxInt64/int64(maxMonthDuration))/months {
+		return 0, false
+	}
+	return v * months, true
+}
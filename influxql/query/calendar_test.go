@@ -0,0 +1,35 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteCalendarIntervals(t *testing.T) {
+	for _, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT count(value) FROM cpu GROUP BY time(1mo)`,
+			exp: `SELECT count(value) FROM cpu GROUP BY time('1mo')`,
+		},
+		{
+			q:   `SELECT count(value) FROM cpu GROUP BY host, TIME(2y, 1d) fill(0)`,
+			exp: `SELECT count(value) FROM cpu GROUP BY host, TIME('2y', 1d) fill(0)`,
+		},
+		{
+			q:   `SELECT count(value) FROM cpu WHERE msg = 'time(1mo)' GROUP BY time(1m)`,
+			exp: `SELECT count(value) FROM cpu WHERE msg = 'time(1mo)' GROUP BY time(1m)`,
+		},
+		{
+			q:   `SELECT count(value) FROM cpu GROUP BY time(0mo)`,
+			exp: `SELECT count(value) FROM cpu GROUP BY time(0mo)`,
+		},
+	} {
+		t.Run(tt.q, func(t *testing.T) {
+			require.Equal(t, tt.exp, rewriteCalendarIntervals(tt.q).String())
+		})
+	}
+}
@@ -163,6 +163,12 @@ func newMinIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 			return fn, fn
 		}
 		return newUnsignedReduceUnsignedIterator(input, opt, createFn), nil
+	case StringIterator:
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewStringFuncReducer(StringMinReduce, nil)
+			return fn, fn
+		}
+		return newStringReduceStringIterator(input, opt, createFn), nil
 	case BooleanIterator:
 		createFn := func() (BooleanPointAggregator, BooleanPointEmitter) {
 			fn := NewBooleanFuncReducer(BooleanMinReduce, nil)
@@ -198,6 +204,14 @@ func UnsignedMinReduce(prev, curr *UnsignedPoint) (int64, uint64, []interface{})
 	return prev.Time, prev.Value, prev.Aux
 }
 
+// StringMinReduce returns the lexicographically smallest value between prev & curr.
+func StringMinReduce(prev, curr *StringPoint) (int64, string, []interface{}) {
+	if prev == nil || curr.Value < prev.Value || (curr.Value == prev.Value && curr.Time < prev.Time) {
+		return curr.Time, curr.Value, cloneAux(curr.Aux)
+	}
+	return prev.Time, prev.Value, prev.Aux
+}
+
 // BooleanMinReduce returns the minimum value between prev & curr.
 func BooleanMinReduce(prev, curr *BooleanPoint) (int64, bool, []interface{}) {
 	if prev == nil || (curr.Value != prev.Value && !curr.Value) || (curr.Value == prev.Value && curr.Time < prev.Time) {
@@ -227,6 +241,12 @@ func newMaxIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 			return fn, fn
 		}
 		return newUnsignedReduceUnsignedIterator(input, opt, createFn), nil
+	case StringIterator:
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewStringFuncReducer(StringMaxReduce, nil)
+			return fn, fn
+		}
+		return newStringReduceStringIterator(input, opt, createFn), nil
 	case BooleanIterator:
 		createFn := func() (BooleanPointAggregator, BooleanPointEmitter) {
 			fn := NewBooleanFuncReducer(BooleanMaxReduce, nil)
@@ -262,6 +282,14 @@ func UnsignedMaxReduce(prev, curr *UnsignedPoint) (int64, uint64, []interface{})
 	return prev.Time, prev.Value, prev.Aux
 }
 
+// StringMaxReduce returns the lexicographically largest value between prev & curr.
+func StringMaxReduce(prev, curr *StringPoint) (int64, string, []interface{}) {
+	if prev == nil || curr.Value > prev.Value || (curr.Value == prev.Value && curr.Time < prev.Time) {
+		return curr.Time, curr.Value, cloneAux(curr.Aux)
+	}
+	return prev.Time, prev.Value, prev.Aux
+}
+
 // BooleanMaxReduce returns the minimum value between prev & curr.
 func BooleanMaxReduce(prev, curr *BooleanPoint) (int64, bool, []interface{}) {
 	if prev == nil || (curr.Value != prev.Value && curr.Value) || (curr.Value == prev.Value && curr.Time < prev.Time) {
@@ -514,6 +542,45 @@ func NewDistinctIterator(input Iterator, opt IteratorOptions) (Iterator, error)
 	}
 }
 
+// newDistinctCountIterator returns an iterator for operating on a distinct()
+// call that also reports the number of distinct values in each interval.
+func newDistinctCountIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
+			fn := NewFloatDistinctCountReducer()
+			return fn, fn
+		}
+		return newFloatReduceFloatIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, IntegerPointEmitter) {
+			fn := NewIntegerDistinctCountReducer()
+			return fn, fn
+		}
+		return newIntegerReduceIntegerIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, UnsignedPointEmitter) {
+			fn := NewUnsignedDistinctCountReducer()
+			return fn, fn
+		}
+		return newUnsignedReduceUnsignedIterator(input, opt, createFn), nil
+	case StringIterator:
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewStringDistinctCountReducer()
+			return fn, fn
+		}
+		return newStringReduceStringIterator(input, opt, createFn), nil
+	case BooleanIterator:
+		createFn := func() (BooleanPointAggregator, BooleanPointEmitter) {
+			fn := NewBooleanDistinctCountReducer()
+			return fn, fn
+		}
+		return newBooleanReduceBooleanIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported distinct iterator type: %T", input)
+	}
+}
+
 // newMeanIterator returns an iterator for operating on a mean() call.
 func newMeanIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 	switch input := input.(type) {
@@ -764,6 +831,7 @@ func UnsignedModeReduceSlice(a []UnsignedPoint) []UnsignedPoint {
 }
 
 // StringModeReduceSlice returns the mode value within a window.
+// Ties go to the value that was seen first.
 func StringModeReduceSlice(a []StringPoint) []StringPoint {
 	if len(a) == 1 {
 		return a
@@ -771,27 +839,23 @@ func StringModeReduceSlice(a []StringPoint) []StringPoint {
 
 	sort.Sort(stringPointsByValue(a))
 
-	mostFreq := 0
-	currFreq := 0
-	currMode := a[0].Value
-	mostMode := a[0].Value
-	mostTime := a[0].Time
-	currTime := a[0].Time
-
-	for _, p := range a {
-		if p.Value != currMode {
-			currFreq = 1
-			currMode = p.Value
-			currTime = p.Time
-			continue
+	var mostMode string
+	mostFreq, mostTime := 0, int64(0)
+	for i := 0; i < len(a); {
+		// Count the run of equal values and find when the value was first seen.
+		j, firstTime := i, a[i].Time
+		for ; j < len(a) && a[j].Value == a[i].Value; j++ {
+			if a[j].Time < firstTime {
+				firstTime = a[j].Time
+			}
 		}
-		currFreq++
-		if mostFreq > currFreq || (mostFreq == currFreq && currTime > mostTime) {
-			continue
+
+		if freq := j - i; freq > mostFreq || (freq == mostFreq && firstTime < mostTime) {
+			mostFreq = freq
+			mostMode = a[i].Value
+			mostTime = firstTime
 		}
-		mostFreq = currFreq
-		mostMode = p.Value
-		mostTime = p.Time
+		i = j
 	}
 
 	return []StringPoint{{Time: ZeroTime, Value: mostMode}}
@@ -823,24 +887,193 @@ func BooleanModeReduceSlice(a []BooleanPoint) []BooleanPoint {
 	return []BooleanPoint{{Time: ZeroTime, Value: mostMode}}
 }
 
+// NewModesIterator returns an iterator for operating on a modes() call.
+func NewModesIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
+			fn := NewFloatSliceFuncReducer(FloatModesReduceSlice)
+			return fn, fn
+		}
+		return newFloatReduceFloatIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, IntegerPointEmitter) {
+			fn := NewIntegerSliceFuncReducer(IntegerModesReduceSlice)
+			return fn, fn
+		}
+		return newIntegerReduceIntegerIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, UnsignedPointEmitter) {
+			fn := NewUnsignedSliceFuncReducer(UnsignedModesReduceSlice)
+			return fn, fn
+		}
+		return newUnsignedReduceUnsignedIterator(input, opt, createFn), nil
+	case StringIterator:
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewStringSliceFuncReducer(StringModesReduceSlice)
+			return fn, fn
+		}
+		return newStringReduceStringIterator(input, opt, createFn), nil
+	case BooleanIterator:
+		createFn := func() (BooleanPointAggregator, BooleanPointEmitter) {
+			fn := NewBooleanSliceFuncReducer(BooleanModesReduceSlice)
+			return fn, fn
+		}
+		return newBooleanReduceBooleanIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported modes iterator type: %T", input)
+	}
+}
+
+// FloatModesReduceSlice returns every value tied for the highest frequency
+// within a window, ordered by value.
+func FloatModesReduceSlice(a []FloatPoint) []FloatPoint {
+	sort.Sort(floatPointsByValue(a))
+
+	var modes []FloatPoint
+	mostFreq := 0
+	for i := 0; i < len(a); {
+		j := i + 1
+		for j < len(a) && a[j].Value == a[i].Value {
+			j++
+		}
+		if freq := j - i; freq > mostFreq {
+			mostFreq = freq
+			modes = modes[:0]
+			modes = append(modes, FloatPoint{Time: ZeroTime, Value: a[i].Value})
+		} else if freq == mostFreq {
+			modes = append(modes, FloatPoint{Time: ZeroTime, Value: a[i].Value})
+		}
+		i = j
+	}
+	return modes
+}
+
+// IntegerModesReduceSlice returns every value tied for the highest frequency
+// within a window, ordered by value.
+func IntegerModesReduceSlice(a []IntegerPoint) []IntegerPoint {
+	sort.Sort(integerPointsByValue(a))
+
+	var modes []IntegerPoint
+	mostFreq := 0
+	for i := 0; i < len(a); {
+		j := i + 1
+		for j < len(a) && a[j].Value == a[i].Value {
+			j++
+		}
+		if freq := j - i; freq > mostFreq {
+			mostFreq = freq
+			modes = modes[:0]
+			modes = append(modes, IntegerPoint{Time: ZeroTime, Value: a[i].Value})
+		} else if freq == mostFreq {
+			modes = append(modes, IntegerPoint{Time: ZeroTime, Value: a[i].Value})
+		}
+		i = j
+	}
+	return modes
+}
+
+// UnsignedModesReduceSlice returns every value tied for the highest frequency
+// within a window, ordered by value.
+func UnsignedModesReduceSlice(a []UnsignedPoint) []UnsignedPoint {
+	sort.Sort(unsignedPointsByValue(a))
+
+	var modes []UnsignedPoint
+	mostFreq := 0
+	for i := 0; i < len(a); {
+		j := i + 1
+		for j < len(a) && a[j].Value == a[i].Value {
+			j++
+		}
+		if freq := j - i; freq > mostFreq {
+			mostFreq = freq
+			modes = modes[:0]
+			modes = append(modes, UnsignedPoint{Time: ZeroTime, Value: a[i].Value})
+		} else if freq == mostFreq {
+			modes = append(modes, UnsignedPoint{Time: ZeroTime, Value: a[i].Value})
+		}
+		i = j
+	}
+	return modes
+}
+
+// StringModesReduceSlice returns every value tied for the highest frequency
+// within a window, ordered by value.
+func StringModesReduceSlice(a []StringPoint) []StringPoint {
+	sort.Sort(stringPointsByValue(a))
+
+	var modes []StringPoint
+	mostFreq := 0
+	for i := 0; i < len(a); {
+		j := i + 1
+		for j < len(a) && a[j].Value == a[i].Value {
+			j++
+		}
+		if freq := j - i; freq > mostFreq {
+			mostFreq = freq
+			modes = modes[:0]
+			modes = append(modes, StringPoint{Time: ZeroTime, Value: a[i].Value})
+		} else if freq == mostFreq {
+			modes = append(modes, StringPoint{Time: ZeroTime, Value: a[i].Value})
+		}
+		i = j
+	}
+	return modes
+}
+
+// BooleanModesReduceSlice returns every value tied for the highest frequency
+// within a window, with false ordered before true.
+func BooleanModesReduceSlice(a []BooleanPoint) []BooleanPoint {
+	trueFreq, falseFreq := 0, 0
+	for _, p := range a {
+		if p.Value {
+			trueFreq++
+		} else {
+			falseFreq++
+		}
+	}
+
+	var modes []BooleanPoint
+	if falseFreq >= trueFreq {
+		modes = append(modes, BooleanPoint{Time: ZeroTime, Value: false})
+	}
+	if trueFreq >= falseFreq {
+		modes = append(modes, BooleanPoint{Time: ZeroTime, Value: true})
+	}
+	return modes
+}
+
 // newStddevIterator returns an iterator for operating on a stddev() call.
-func newStddevIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+// The population standard deviation is returned for stddev_pop().
+func newStddevIterator(input Iterator, opt IteratorOptions, population bool) (Iterator, error) {
 	switch input := input.(type) {
 	case FloatIterator:
+		reduceSlice := FloatStddevReduceSlice
+		if population {
+			reduceSlice = FloatStddevPopReduceSlice
+		}
 		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
-			fn := NewFloatSliceFuncReducer(FloatStddevReduceSlice)
+			fn := NewFloatSliceFuncReducer(reduceSlice)
 			return fn, fn
 		}
 		return newFloatReduceFloatIterator(input, opt, createFn), nil
 	case IntegerIterator:
+		reduceSlice := IntegerStddevReduceSlice
+		if population {
+			reduceSlice = IntegerStddevPopReduceSlice
+		}
 		createFn := func() (IntegerPointAggregator, FloatPointEmitter) {
-			fn := NewIntegerSliceFuncFloatReducer(IntegerStddevReduceSlice)
+			fn := NewIntegerSliceFuncFloatReducer(reduceSlice)
 			return fn, fn
 		}
 		return newIntegerReduceFloatIterator(input, opt, createFn), nil
 	case UnsignedIterator:
+		reduceSlice := UnsignedStddevReduceSlice
+		if population {
+			reduceSlice = UnsignedStddevPopReduceSlice
+		}
 		createFn := func() (UnsignedPointAggregator, FloatPointEmitter) {
-			fn := NewUnsignedSliceFuncFloatReducer(UnsignedStddevReduceSlice)
+			fn := NewUnsignedSliceFuncFloatReducer(reduceSlice)
 			return fn, fn
 		}
 		return newUnsignedReduceFloatIterator(input, opt, createFn), nil
@@ -849,90 +1082,125 @@ func newStddevIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 	}
 }
 
-// FloatStddevReduceSlice returns the stddev value within a window.
+// FloatStddevReduceSlice returns the sample stddev value within a window.
 func FloatStddevReduceSlice(a []FloatPoint) []FloatPoint {
-	// If there is only one point then return NaN.
-	if len(a) < 2 {
+	return floatStddevReduceSlice(a, false)
+}
+
+// FloatStddevPopReduceSlice returns the population stddev value within a window.
+func FloatStddevPopReduceSlice(a []FloatPoint) []FloatPoint {
+	return floatStddevReduceSlice(a, true)
+}
+
+func floatStddevReduceSlice(a []FloatPoint, population bool) []FloatPoint {
+	// If there is only one point then return NaN for the sample stddev.
+	if len(a) < 2 && !population {
 		return []FloatPoint{{Time: ZeroTime, Value: math.NaN()}}
 	}
 
 	// Calculate the mean.
-	var mean float64
+	var sum compensatedSum
 	var count int
 	for _, p := range a {
 		if math.IsNaN(p.Value) {
 			continue
 		}
 		count++
-		mean += (p.Value - mean) / float64(count)
+		sum.add(p.Value)
 	}
+	mean := sum.value() / float64(count)
 
 	// Calculate the variance.
-	var variance float64
+	var variance compensatedSum
 	for _, p := range a {
 		if math.IsNaN(p.Value) {
 			continue
 		}
-		variance += math.Pow(p.Value-mean, 2)
+		variance.add(math.Pow(p.Value-mean, 2))
 	}
 	return []FloatPoint{{
 		Time:  ZeroTime,
-		Value: math.Sqrt(variance / float64(count-1)),
+		Value: math.Sqrt(variance.value() / stddevDivisor(count, population)),
 	}}
 }
 
-// IntegerStddevReduceSlice returns the stddev value within a window.
+// IntegerStddevReduceSlice returns the sample stddev value within a window.
 func IntegerStddevReduceSlice(a []IntegerPoint) []FloatPoint {
-	// If there is only one point then return NaN.
-	if len(a) < 2 {
+	return integerStddevReduceSlice(a, false)
+}
+
+// IntegerStddevPopReduceSlice returns the population stddev value within a window.
+func IntegerStddevPopReduceSlice(a []IntegerPoint) []FloatPoint {
+	return integerStddevReduceSlice(a, true)
+}
+
+func integerStddevReduceSlice(a []IntegerPoint, population bool) []FloatPoint {
+	// If there is only one point then return NaN for the sample stddev.
+	if len(a) < 2 && !population {
 		return []FloatPoint{{Time: ZeroTime, Value: math.NaN()}}
 	}
 
 	// Calculate the mean.
-	var mean float64
-	var count int
+	var sum compensatedSum
 	for _, p := range a {
-		count++
-		mean += (float64(p.Value) - mean) / float64(count)
+		sum.add(float64(p.Value))
 	}
+	mean := sum.value() / float64(len(a))
 
 	// Calculate the variance.
-	var variance float64
+	var variance compensatedSum
 	for _, p := range a {
-		variance += math.Pow(float64(p.Value)-mean, 2)
+		variance.add(math.Pow(float64(p.Value)-mean, 2))
 	}
 	return []FloatPoint{{
 		Time:  ZeroTime,
-		Value: math.Sqrt(variance / float64(count-1)),
+		Value: math.Sqrt(variance.value() / stddevDivisor(len(a), population)),
 	}}
 }
 
-// UnsignedStddevReduceSlice returns the stddev value within a window.
+// UnsignedStddevReduceSlice returns the sample stddev value within a window.
 func UnsignedStddevReduceSlice(a []UnsignedPoint) []FloatPoint {
-	// If there is only one point then return NaN.
-	if len(a) < 2 {
+	return unsignedStddevReduceSlice(a, false)
+}
+
+// UnsignedStddevPopReduceSlice returns the population stddev value within a window.
+func UnsignedStddevPopReduceSlice(a []UnsignedPoint) []FloatPoint {
+	return unsignedStddevReduceSlice(a, true)
+}
+
+func unsignedStddevReduceSlice(a []UnsignedPoint, population bool) []FloatPoint {
+	// If there is only one point then return NaN for the sample stddev.
+	if len(a) < 2 && !population {
 		return []FloatPoint{{Time: ZeroTime, Value: math.NaN()}}
 	}
 
 	// Calculate the mean.
-	var mean float64
-	var count int
+	var sum compensatedSum
 	for _, p := range a {
-		count++
-		mean += (float64(p.Value) - mean) / float64(count)
+		sum.add(float64(p.Value))
 	}
+	mean := sum.value() / float64(len(a))
 
 	// Calculate the variance.
-	var variance float64
+	var variance compensatedSum
 	for _, p := range a {
-		variance += math.Pow(float64(p.Value)-mean, 2)
+		variance.add(math.Pow(float64(p.Value)-mean, 2))
 	}
 	return []FloatPoint{{
 		Time:  ZeroTime,
-		Value: math.Sqrt(variance / float64(count-1)),
+		Value: math.Sqrt(variance.value() / stddevDivisor(len(a), population)),
 	}}
 }
 
+// stddevDivisor returns the number the sum of squared deviations of n points
+// is divided by: n for the population variance and n-1 for the sample variance.
+func stddevDivisor(n int, population bool) float64 {
+	if population {
+		return float64(n)
+	}
+	return float64(n - 1)
+}
+
 // newSpreadIterator returns an iterator for operating on a spread() call.
 func newSpreadIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 	switch input := input.(type) {
@@ -1050,11 +1318,17 @@ func newPercentileIterator(input Iterator, opt IteratorOptions, percentile float
 	}
 }
 
+// percentileIndex returns the index of the percentile within length sorted
+// points. The index is out of range if there are too few points.
+func percentileIndex(length int, percentile float64) int {
+	return int(math.Floor(float64(length)*percentile/100.0+0.5)) - 1
+}
+
 // NewFloatPercentileReduceSliceFunc returns the percentile value within a window.
 func NewFloatPercentileReduceSliceFunc(percentile float64) FloatReduceSliceFunc {
 	return func(a []FloatPoint) []FloatPoint {
 		length := len(a)
-		i := int(math.Floor(float64(length)*percentile/100.0+0.5)) - 1
+		i := percentileIndex(length, percentile)
 
 		if i < 0 || i >= length {
 			return nil
@@ -1069,7 +1343,7 @@ func NewFloatPercentileReduceSliceFunc(percentile float64) FloatReduceSliceFunc
 func NewIntegerPercentileReduceSliceFunc(percentile float64) IntegerReduceSliceFunc {
 	return func(a []IntegerPoint) []IntegerPoint {
 		length := len(a)
-		i := int(math.Floor(float64(length)*percentile/100.0+0.5)) - 1
+		i := percentileIndex(length, percentile)
 
 		if i < 0 || i >= length {
 			return nil
@@ -1084,7 +1358,7 @@ func NewIntegerPercentileReduceSliceFunc(percentile float64) IntegerReduceSliceF
 func NewUnsignedPercentileReduceSliceFunc(percentile float64) UnsignedReduceSliceFunc {
 	return func(a []UnsignedPoint) []UnsignedPoint {
 		length := len(a)
-		i := int(math.Floor(float64(length)*percentile/100.0+0.5)) - 1
+		i := percentileIndex(length, percentile)
 
 		if i < 0 || i >= length {
 			return nil
@@ -1095,6 +1369,256 @@ func NewUnsignedPercentileReduceSliceFunc(percentile float64) UnsignedReduceSlic
 	}
 }
 
+// newIQRIterator returns an iterator for operating on an iqr() call.
+func newIQRIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
+			fn := NewFloatSliceFuncReducer(FloatIQRReduceSlice)
+			return fn, fn
+		}
+		return newFloatReduceFloatIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, IntegerPointEmitter) {
+			fn := NewIntegerSliceFuncReducer(IntegerIQRReduceSlice)
+			return fn, fn
+		}
+		return newIntegerReduceIntegerIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, UnsignedPointEmitter) {
+			fn := NewUnsignedSliceFuncReducer(UnsignedIQRReduceSlice)
+			return fn, fn
+		}
+		return newUnsignedReduceUnsignedIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported iqr iterator type: %T", input)
+	}
+}
+
+// FloatIQRReduceSlice returns the interquartile range within a window.
+func FloatIQRReduceSlice(a []FloatPoint) []FloatPoint {
+	lo, hi := percentileIndex(len(a), 25), percentileIndex(len(a), 75)
+	if lo < 0 || hi >= len(a) {
+		return nil
+	}
+
+	sort.Sort(floatPointsByValue(a))
+	return []FloatPoint{{Time: ZeroTime, Value: a[hi].Value - a[lo].Value}}
+}
+
+// IntegerIQRReduceSlice returns the interquartile range within a window.
+func IntegerIQRReduceSlice(a []IntegerPoint) []IntegerPoint {
+	lo, hi := percentileIndex(len(a), 25), percentileIndex(len(a), 75)
+	if lo < 0 || hi >= len(a) {
+		return nil
+	}
+
+	sort.Sort(integerPointsByValue(a))
+	return []IntegerPoint{{Time: ZeroTime, Value: a[hi].Value - a[lo].Value}}
+}
+
+// UnsignedIQRReduceSlice returns the interquartile range within a window.
+func UnsignedIQRReduceSlice(a []UnsignedPoint) []UnsignedPoint {
+	lo, hi := percentileIndex(len(a), 25), percentileIndex(len(a), 75)
+	if lo < 0 || hi >= len(a) {
+		return nil
+	}
+
+	sort.Sort(unsignedPointsByValue(a))
+	return []UnsignedPoint{{Time: ZeroTime, Value: a[hi].Value - a[lo].Value}}
+}
+
+// newTrimmedMeanIterator returns an iterator for operating on a trimmed_mean() call.
+func newTrimmedMeanIterator(input Iterator, opt IteratorOptions, percent float64) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		floatTrimmedMeanReduceSlice := NewFloatTrimmedMeanReduceSliceFunc(percent)
+		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
+			fn := NewFloatSliceFuncReducer(floatTrimmedMeanReduceSlice)
+			return fn, fn
+		}
+		return newFloatReduceFloatIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		integerTrimmedMeanReduceSlice := NewIntegerTrimmedMeanReduceSliceFunc(percent)
+		createFn := func() (IntegerPointAggregator, FloatPointEmitter) {
+			fn := NewIntegerSliceFuncFloatReducer(integerTrimmedMeanReduceSlice)
+			return fn, fn
+		}
+		return newIntegerReduceFloatIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		unsignedTrimmedMeanReduceSlice := NewUnsignedTrimmedMeanReduceSliceFunc(percent)
+		createFn := func() (UnsignedPointAggregator, FloatPointEmitter) {
+			fn := NewUnsignedSliceFuncFloatReducer(unsignedTrimmedMeanReduceSlice)
+			return fn, fn
+		}
+		return newUnsignedReduceFloatIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported trimmed_mean iterator type: %T", input)
+	}
+}
+
+// trimCount returns the number of points to drop from each end of a window
+// of n points when trimming percent of them. Windows too small to lose a
+// point from each end are not trimmed.
+func trimCount(n int, percent float64) int {
+	return int(math.Floor(float64(n) * percent / 100.0))
+}
+
+// NewFloatTrimmedMeanReduceSliceFunc returns the mean value within a window
+// after dropping percent of the points from each end of the sorted values.
+func NewFloatTrimmedMeanReduceSliceFunc(percent float64) FloatReduceSliceFunc {
+	return func(a []FloatPoint) []FloatPoint {
+		if len(a) == 0 {
+			return nil
+		}
+
+		sort.Sort(floatPointsByValue(a))
+		k := trimCount(len(a), percent)
+		a = a[k : len(a)-k]
+
+		var sum float64
+		for _, p := range a {
+			sum += p.Value
+		}
+		return []FloatPoint{{Time: ZeroTime, Value: sum / float64(len(a))}}
+	}
+}
+
+// NewIntegerTrimmedMeanReduceSliceFunc returns the mean value within a window
+// after dropping percent of the points from each end of the sorted values.
+func NewIntegerTrimmedMeanReduceSliceFunc(percent float64) IntegerReduceFloatSliceFunc {
+	return func(a []IntegerPoint) []FloatPoint {
+		if len(a) == 0 {
+			return nil
+		}
+
+		sort.Sort(integerPointsByValue(a))
+		k := trimCount(len(a), percent)
+		a = a[k : len(a)-k]
+
+		var sum float64
+		for _, p := range a {
+			sum += float64(p.Value)
+		}
+		return []FloatPoint{{Time: ZeroTime, Value: sum / float64(len(a))}}
+	}
+}
+
+// NewUnsignedTrimmedMeanReduceSliceFunc returns the mean value within a window
+// after dropping percent of the points from each end of the sorted values.
+func NewUnsignedTrimmedMeanReduceSliceFunc(percent float64) UnsignedReduceFloatSliceFunc {
+	return func(a []UnsignedPoint) []FloatPoint {
+		if len(a) == 0 {
+			return nil
+		}
+
+		sort.Sort(unsignedPointsByValue(a))
+		k := trimCount(len(a), percent)
+		a = a[k : len(a)-k]
+
+		var sum float64
+		for _, p := range a {
+			sum += float64(p.Value)
+		}
+		return []FloatPoint{{Time: ZeroTime, Value: sum / float64(len(a))}}
+	}
+}
+
+// newPrecisionIterator returns an iterator for operating on a precision() call.
+func newPrecisionIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, StringPointEmitter) {
+			fn := NewFloatFuncStringReducer(FloatPrecisionReduce, nil)
+			return fn, fn
+		}
+		return newFloatReduceStringIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, StringPointEmitter) {
+			fn := NewIntegerFuncStringReducer(IntegerPrecisionReduce, nil)
+			return fn, fn
+		}
+		return newIntegerReduceStringIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, StringPointEmitter) {
+			fn := NewUnsignedFuncStringReducer(UnsignedPrecisionReduce, nil)
+			return fn, fn
+		}
+		return newUnsignedReduceStringIterator(input, opt, createFn), nil
+	case StringIterator:
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewStringFuncReducer(StringPrecisionReduce, nil)
+			return fn, fn
+		}
+		return newStringReduceStringIterator(input, opt, createFn), nil
+	case BooleanIterator:
+		createFn := func() (BooleanPointAggregator, StringPointEmitter) {
+			fn := NewBooleanFuncStringReducer(BooleanPrecisionReduce, nil)
+			return fn, fn
+		}
+		return newBooleanReduceStringIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported precision iterator type: %T", input)
+	}
+}
+
+// timestampPrecisions are the timestamp precisions from finest to coarsest.
+var timestampPrecisions = []struct {
+	name string
+	unit int64
+}{
+	{name: "ns", unit: 1},
+	{name: "us", unit: int64(time.Microsecond)},
+	{name: "ms", unit: int64(time.Millisecond)},
+	{name: "s", unit: int64(time.Second)},
+}
+
+// finestPrecision returns the finer of the precision reported by prev and the
+// coarsest precision that can represent the timestamp t.
+func finestPrecision(prev *StringPoint, t int64) string {
+	i := len(timestampPrecisions) - 1
+	for ; i > 0; i-- {
+		if t%timestampPrecisions[i].unit == 0 {
+			break
+		}
+	}
+
+	if prev != nil {
+		for j := 0; j < i; j++ {
+			if timestampPrecisions[j].name == prev.Value {
+				i = j
+				break
+			}
+		}
+	}
+	return timestampPrecisions[i].name
+}
+
+// FloatPrecisionReduce returns the finest timestamp precision of the points.
+func FloatPrecisionReduce(prev *StringPoint, curr *FloatPoint) (int64, string, []interface{}) {
+	return ZeroTime, finestPrecision(prev, curr.Time), nil
+}
+
+// IntegerPrecisionReduce returns the finest timestamp precision of the points.
+func IntegerPrecisionReduce(prev *StringPoint, curr *IntegerPoint) (int64, string, []interface{}) {
+	return ZeroTime, finestPrecision(prev, curr.Time), nil
+}
+
+// UnsignedPrecisionReduce returns the finest timestamp precision of the points.
+func UnsignedPrecisionReduce(prev *StringPoint, curr *UnsignedPoint) (int64, string, []interface{}) {
+	return ZeroTime, finestPrecision(prev, curr.Time), nil
+}
+
+// StringPrecisionReduce returns the finest timestamp precision of the points.
+func StringPrecisionReduce(prev, curr *StringPoint) (int64, string, []interface{}) {
+	return ZeroTime, finestPrecision(prev, curr.Time), nil
+}
+
+// BooleanPrecisionReduce returns the finest timestamp precision of the points.
+func BooleanPrecisionReduce(prev *StringPoint, curr *BooleanPoint) (int64, string, []interface{}) {
+	return ZeroTime, finestPrecision(prev, curr.Time), nil
+}
+
 // newDerivativeIterator returns an iterator for operating on a derivative() call.
 func newDerivativeIterator(input Iterator, opt IteratorOptions, interval Interval, isNonNegative bool) (Iterator, error) {
 	switch input := input.(type) {
@@ -1148,35 +1672,41 @@ func newDifferenceIterator(input Iterator, opt IteratorOptions, isNonNegative bo
 }
 
 // newElapsedIterator returns an iterator for operating on a elapsed() call.
-func newElapsedIterator(input Iterator, opt IteratorOptions, interval Interval) (Iterator, error) {
+// If window is set, the elapsed time is restarted in each window.
+func newElapsedIterator(input Iterator, opt IteratorOptions, interval Interval, window func(t int64) (start, end int64)) (Iterator, error) {
 	switch input := input.(type) {
 	case FloatIterator:
 		createFn := func() (FloatPointAggregator, IntegerPointEmitter) {
 			fn := NewFloatElapsedReducer(interval)
+			fn.window = window
 			return fn, fn
 		}
 		return newFloatStreamIntegerIterator(input, createFn, opt), nil
 	case IntegerIterator:
 		createFn := func() (IntegerPointAggregator, IntegerPointEmitter) {
 			fn := NewIntegerElapsedReducer(interval)
+			fn.window = window
 			return fn, fn
 		}
 		return newIntegerStreamIntegerIterator(input, createFn, opt), nil
 	case UnsignedIterator:
 		createFn := func() (UnsignedPointAggregator, IntegerPointEmitter) {
 			fn := NewUnsignedElapsedReducer(interval)
+			fn.window = window
 			return fn, fn
 		}
 		return newUnsignedStreamIntegerIterator(input, createFn, opt), nil
 	case BooleanIterator:
 		createFn := func() (BooleanPointAggregator, IntegerPointEmitter) {
 			fn := NewBooleanElapsedReducer(interval)
+			fn.window = window
 			return fn, fn
 		}
 		return newBooleanStreamIntegerIterator(input, createFn, opt), nil
 	case StringIterator:
 		createFn := func() (StringPointAggregator, IntegerPointEmitter) {
 			fn := NewStringElapsedReducer(interval)
+			fn.window = window
 			return fn, fn
 		}
 		return newStringStreamIntegerIterator(input, createFn, opt), nil
@@ -1445,18 +1975,101 @@ func newCumulativeSumIterator(input Iterator, opt IteratorOptions) (Iterator, er
 	}
 }
 
+// newPercentOfTotalIterator returns an iterator for operating on a percent_of_total() call.
+func newPercentOfTotalIterator(input Iterator) (Iterator, error) {
+	itr := &percentOfTotalIterator{input: input, totals: make(map[int64]float64)}
+	switch input := input.(type) {
+	case FloatIterator:
+		itr.read = input.Next
+	case IntegerIterator:
+		itr.read = func() (*FloatPoint, error) {
+			p, err := input.Next()
+			if p == nil || err != nil {
+				return nil, err
+			}
+			return &FloatPoint{Name: p.Name, Tags: p.Tags, Time: p.Time, Nil: p.Nil, Value: float64(p.Value), Aux: p.Aux}, nil
+		}
+	case UnsignedIterator:
+		itr.read = func() (*FloatPoint, error) {
+			p, err := input.Next()
+			if p == nil || err != nil {
+				return nil, err
+			}
+			return &FloatPoint{Name: p.Name, Tags: p.Tags, Time: p.Time, Nil: p.Nil, Value: float64(p.Value), Aux: p.Aux}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported percent_of_total iterator type: %T", input)
+	}
+	return itr, nil
+}
+
+// percentOfTotalIterator emits each point as a percentage of the sum of the
+// points of every series with the same time. All points are read from the
+// input before the first point is emitted.
+type percentOfTotalIterator struct {
+	input  Iterator
+	read   func() (*FloatPoint, error)
+	points []FloatPoint
+	totals map[int64]float64
+	init   bool
+}
+
+// Stats returns stats from the input iterator.
+func (itr *percentOfTotalIterator) Stats() IteratorStats { return itr.input.Stats() }
+
+// Close closes the iterator and all child iterators.
+func (itr *percentOfTotalIterator) Close() error { return itr.input.Close() }
+
+// Next returns the next point as a percentage of the total at its time.
+// A point is null if the total is zero.
+func (itr *percentOfTotalIterator) Next() (*FloatPoint, error) {
+	if !itr.init {
+		for {
+			p, err := itr.read()
+			if err != nil {
+				return nil, err
+			} else if p == nil {
+				break
+			}
+			if !p.Nil {
+				itr.totals[p.Time] += p.Value
+			}
+			itr.points = append(itr.points, *p.Clone())
+		}
+		itr.init = true
+	}
+
+	if len(itr.points) == 0 {
+		return nil, nil
+	}
+	p := &itr.points[0]
+	itr.points = itr.points[1:]
+
+	if !p.Nil {
+		if total := itr.totals[p.Time]; total != 0 {
+			p.Value = p.Value * 100 / total
+		} else {
+			p.Value, p.Nil = 0, true
+		}
+	}
+	return p, nil
+}
+
 // newHoltWintersIterator returns an iterator for operating on a holt_winters() call.
-func newHoltWintersIterator(input Iterator, opt IteratorOptions, h, m int, includeFitData bool, interval time.Duration) (Iterator, error) {
+// A non-zero z offsets the forecast by that many residual standard deviations.
+func newHoltWintersIterator(input Iterator, opt IteratorOptions, h, m int, includeFitData bool, interval time.Duration, z float64) (Iterator, error) {
 	switch input := input.(type) {
 	case FloatIterator:
 		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
 			fn := NewFloatHoltWintersReducer(h, m, includeFitData, interval)
+			fn.z = z
 			return fn, fn
 		}
 		return newFloatReduceFloatIterator(input, opt, createFn), nil
 	case IntegerIterator:
 		createFn := func() (IntegerPointAggregator, FloatPointEmitter) {
 			fn := NewFloatHoltWintersReducer(h, m, includeFitData, interval)
+			fn.z = z
 			return fn, fn
 		}
 		return newIntegerReduceFloatIterator(input, opt, createFn), nil
@@ -1475,31 +2088,31 @@ func newSampleIterator(input Iterator, opt IteratorOptions, size int) (Iterator,
 	switch input := input.(type) {
 	case FloatIterator:
 		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
-			fn := NewFloatSampleReducer(size)
+			fn := NewFloatSampleReducerWithSeed(size, opt.Seed)
 			return fn, fn
 		}
 		return newFloatReduceFloatIterator(input, opt, createFn), nil
 	case IntegerIterator:
 		createFn := func() (IntegerPointAggregator, IntegerPointEmitter) {
-			fn := NewIntegerSampleReducer(size)
+			fn := NewIntegerSampleReducerWithSeed(size, opt.Seed)
 			return fn, fn
 		}
 		return newIntegerReduceIntegerIterator(input, opt, createFn), nil
 	case UnsignedIterator:
 		createFn := func() (UnsignedPointAggregator, UnsignedPointEmitter) {
-			fn := NewUnsignedSampleReducer(size)
+			fn := NewUnsignedSampleReducerWithSeed(size, opt.Seed)
 			return fn, fn
 		}
 		return newUnsignedReduceUnsignedIterator(input, opt, createFn), nil
 	case StringIterator:
 		createFn := func() (StringPointAggregator, StringPointEmitter) {
-			fn := NewStringSampleReducer(size)
+			fn := NewStringSampleReducerWithSeed(size, opt.Seed)
 			return fn, fn
 		}
 		return newStringReduceStringIterator(input, opt, createFn), nil
 	case BooleanIterator:
 		createFn := func() (BooleanPointAggregator, BooleanPointEmitter) {
-			fn := NewBooleanSampleReducer(size)
+			fn := NewBooleanSampleReducerWithSeed(size, opt.Seed)
 			return fn, fn
 		}
 		return newBooleanReduceBooleanIterator(input, opt, createFn), nil
@@ -1508,6 +2121,265 @@ func newSampleIterator(input Iterator, opt IteratorOptions, size int) (Iterator,
 	}
 }
 
+// newRateIterator returns an iterator for operating on a rate() call. The
+// increase of each interval is divided by the length of its trailing window
+// in seconds, or by the length of the interval itself if window is zero. The
+// length of a calendar interval depends on the months it spans.
+func newRateIterator(input Iterator, opt IteratorOptions, window time.Duration) (Iterator, error) {
+	seconds := func(t int64) float64 {
+		if window > 0 {
+			return window.Seconds()
+		}
+		start, end := opt.Window(t)
+		return time.Duration(end - start).Seconds()
+	}
+
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
+			fn := NewFloatSliceFuncReducer(func(a []FloatPoint) []FloatPoint {
+				return FloatRateReduceSlice(a, seconds)
+			})
+			return fn, fn
+		}
+		return newFloatReduceFloatIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, FloatPointEmitter) {
+			fn := NewIntegerSliceFuncFloatReducer(func(a []IntegerPoint) []FloatPoint {
+				return IntegerRateReduceSlice(a, seconds)
+			})
+			return fn, fn
+		}
+		return newIntegerReduceFloatIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, FloatPointEmitter) {
+			fn := NewUnsignedSliceFuncFloatReducer(func(a []UnsignedPoint) []FloatPoint {
+				return UnsignedRateReduceSlice(a, seconds)
+			})
+			return fn, fn
+		}
+		return newUnsignedReduceFloatIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate iterator type: %T", input)
+	}
+}
+
+// FloatRateReduceSlice returns the per-second rate of increase of a counter
+// within a window. The seconds function returns the length of the window
+// the points were read from.
+func FloatRateReduceSlice(a []FloatPoint, seconds func(t int64) float64) []FloatPoint {
+	if len(a) < 2 {
+		// Emit a null instead of nothing so the following windows are
+		// still read.
+		return []FloatPoint{{Time: ZeroTime, Nil: true}}
+	}
+	sort.Stable(floatPointsByTime(a))
+
+	values := make([]float64, len(a))
+	for i, p := range a {
+		values[i] = p.Value
+	}
+	return counterRate(values, seconds(a[0].Time))
+}
+
+// IntegerRateReduceSlice returns the per-second rate of increase of a
+// counter within a window.
+func IntegerRateReduceSlice(a []IntegerPoint, seconds func(t int64) float64) []FloatPoint {
+	if len(a) < 2 {
+		// Emit a null instead of nothing so the following windows are
+		// still read.
+		return []FloatPoint{{Time: ZeroTime, Nil: true}}
+	}
+	sort.Stable(integerPointsByTime(a))
+
+	values := make([]float64, len(a))
+	for i, p := range a {
+		values[i] = float64(p.Value)
+	}
+	return counterRate(values, seconds(a[0].Time))
+}
+
+// UnsignedRateReduceSlice returns the per-second rate of increase of a
+// counter within a window.
+func UnsignedRateReduceSlice(a []UnsignedPoint, seconds func(t int64) float64) []FloatPoint {
+	if len(a) < 2 {
+		// Emit a null instead of nothing so the following windows are
+		// still read.
+		return []FloatPoint{{Time: ZeroTime, Nil: true}}
+	}
+	sort.Stable(unsignedPointsByTime(a))
+
+	values := make([]float64, len(a))
+	for i, p := range a {
+		values[i] = float64(p.Value)
+	}
+	return counterRate(values, seconds(a[0].Time))
+}
+
+// counterRate returns the increase of the counter values, which are in time
+// order, divided by the number of seconds. A decrease in value is treated
+// as a counter reset so the new value is counted as the increase since the
+// reset, like non_negative_derivative() would.
+func counterRate(values []float64, seconds float64) []FloatPoint {
+	var increase float64
+	for i := 1; i < len(values); i++ {
+		if values[i] >= values[i-1] {
+			increase += values[i] - values[i-1]
+		} else {
+			increase += values[i]
+		}
+	}
+	return []FloatPoint{{Time: ZeroTime, Value: increase / seconds}}
+}
+
+// newResetsIterator returns an iterator that counts the number of times the
+// value decreases within each interval.
+func newResetsIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, IntegerPointEmitter) {
+			fn := NewFloatSliceFuncIntegerReducer(FloatResetsReduceSlice)
+			return fn, fn
+		}
+		return newFloatReduceIntegerIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, IntegerPointEmitter) {
+			fn := NewIntegerSliceFuncReducer(IntegerResetsReduceSlice)
+			return fn, fn
+		}
+		return newIntegerReduceIntegerIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, IntegerPointEmitter) {
+			fn := NewUnsignedSliceFuncIntegerReducer(UnsignedResetsReduceSlice)
+			return fn, fn
+		}
+		return newUnsignedReduceIntegerIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported resets iterator type: %T", input)
+	}
+}
+
+// FloatResetsReduceSlice returns the number of counter resets within a window.
+// A reset is any decrease in value from one point to the next.
+func FloatResetsReduceSlice(a []FloatPoint) []IntegerPoint {
+	sort.Stable(floatPointsByTime(a))
+
+	var resets int64
+	for i := 1; i < len(a); i++ {
+		if a[i].Value < a[i-1].Value {
+			resets++
+		}
+	}
+	return []IntegerPoint{{Time: ZeroTime, Value: resets}}
+}
+
+// IntegerResetsReduceSlice returns the number of counter resets within a window.
+func IntegerResetsReduceSlice(a []IntegerPoint) []IntegerPoint {
+	sort.Stable(integerPointsByTime(a))
+
+	var resets int64
+	for i := 1; i < len(a); i++ {
+		if a[i].Value < a[i-1].Value {
+			resets++
+		}
+	}
+	return []IntegerPoint{{Time: ZeroTime, Value: resets}}
+}
+
+// UnsignedResetsReduceSlice returns the number of counter resets within a window.
+func UnsignedResetsReduceSlice(a []UnsignedPoint) []IntegerPoint {
+	sort.Stable(unsignedPointsByTime(a))
+
+	var resets int64
+	for i := 1; i < len(a); i++ {
+		if a[i].Value < a[i-1].Value {
+			resets++
+		}
+	}
+	return []IntegerPoint{{Time: ZeroTime, Value: resets}}
+}
+
+// newCountRateIterator returns an iterator that divides the counts of each
+// interval by the length of the interval in seconds. Calendar intervals are
+// divided by the length of the months they span.
+func newCountRateIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, FloatPointEmitter) {
+			fn := NewIntegerSliceFuncFloatReducer(func(a []IntegerPoint) []FloatPoint {
+				if len(a) == 0 {
+					return nil
+				}
+				start, end := opt.Window(a[0].Time)
+				return IntegerCountRateReduceSlice(a, time.Duration(end-start).Seconds())
+			})
+			return fn, fn
+		}
+		return newIntegerReduceFloatIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported count_rate iterator type: %T", input)
+	}
+}
+
+// IntegerCountRateReduceSlice returns the sum of the counts in a window
+// divided by the length of the window in seconds.
+func IntegerCountRateReduceSlice(a []IntegerPoint, seconds float64) []FloatPoint {
+	var count int64
+	for _, p := range a {
+		count += p.Value
+	}
+	return []FloatPoint{{Time: ZeroTime, Value: float64(count) / seconds}}
+}
+
+// newLastValueIterator returns an iterator for operating on a last_value() call.
+func newLastValueIterator(input Iterator, startTime int64, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		return newFloatLastValueIterator(input, startTime, opt), nil
+	case IntegerIterator:
+		return newIntegerLastValueIterator(input, startTime, opt), nil
+	case UnsignedIterator:
+		return newUnsignedLastValueIterator(input, startTime, opt), nil
+	case StringIterator:
+		return newStringLastValueIterator(input, startTime, opt), nil
+	case BooleanIterator:
+		return newBooleanLastValueIterator(input, startTime, opt), nil
+	default:
+		return nil, fmt.Errorf("unsupported last_value iterator type: %T", input)
+	}
+}
+
+// newSlidingWindowIterator returns an iterator that copies each point into
+// the intervals whose trailing window contains it.
+func newSlidingWindowIterator(input Iterator, window time.Duration, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		return newFloatSlidingWindowIterator(input, window, opt), nil
+	case IntegerIterator:
+		return newIntegerSlidingWindowIterator(input, window, opt), nil
+	case UnsignedIterator:
+		return newUnsignedSlidingWindowIterator(input, window, opt), nil
+	default:
+		return nil, fmt.Errorf("unsupported sliding window iterator type: %T", input)
+	}
+}
+
+// newCarryWindowIterator returns an iterator that keeps each point in its
+// own interval and also copies the last point of each interval into the
+// next interval.
+func newCarryWindowIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		return newFloatCarryWindowIterator(input, opt), nil
+	case IntegerIterator:
+		return newIntegerCarryWindowIterator(input, opt), nil
+	case UnsignedIterator:
+		return newUnsignedCarryWindowIterator(input, opt), nil
+	default:
+		return nil, fmt.Errorf("unsupported carry window iterator type: %T", input)
+	}
+}
+
 // newIntegralIterator returns an iterator for operating on a integral() call.
 func newIntegralIterator(input Iterator, opt IteratorOptions, interval Interval) (Iterator, error) {
 	switch input := input.(type) {
@@ -286,6 +286,40 @@ func TestCallIterator_Min_Unsigned(t *testing.T) {
 	}
 }
 
+// Ensure that a string iterator can be created for a min() call.
+func TestCallIterator_Min_String(t *testing.T) {
+	itr, _ := query.NewCallIterator(
+		&StringIterator{Points: []query.StringPoint{
+			{Time: 0, Value: "ok", Tags: ParseTags("region=us-east,host=hostA")},
+			{Time: 2, Value: "error", Tags: ParseTags("region=us-east,host=hostA")},
+			{Time: 1, Value: "warn", Tags: ParseTags("region=us-west,host=hostA")},
+			{Time: 4, Value: "error", Tags: ParseTags("region=us-east,host=hostA")},
+			{Time: 5, Value: "ok", Tags: ParseTags("region=us-east,host=hostA")},
+
+			{Time: 1, Value: "warn", Tags: ParseTags("region=us-west,host=hostB")},
+			{Time: 23, Value: "ok", Tags: ParseTags("region=us-west,host=hostB")},
+		}},
+		query.IteratorOptions{
+			Expr:       MustParseExpr(`min("value")`),
+			Dimensions: []string{"host"},
+			Interval:   query.Interval{Duration: 5 * time.Nanosecond},
+			Ordered:    true,
+			Ascending:  true,
+		},
+	)
+
+	if a, err := Iterators([]query.Iterator{itr}).ReadAll(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if diff := cmp.Diff(a, [][]query.Point{
+		{&query.StringPoint{Time: 2, Value: "error", Tags: ParseTags("host=hostA"), Aggregated: 4}},
+		{&query.StringPoint{Time: 5, Value: "ok", Tags: ParseTags("host=hostA"), Aggregated: 1}},
+		{&query.StringPoint{Time: 1, Value: "warn", Tags: ParseTags("host=hostB"), Aggregated: 1}},
+		{&query.StringPoint{Time: 23, Value: "ok", Tags: ParseTags("host=hostB"), Aggregated: 1}},
+	}); diff != "" {
+		t.Fatalf("unexpected points:\n%s", diff)
+	}
+}
+
 // Ensure that a boolean iterator can be created for a min() call.
 func TestCallIterator_Min_Boolean(t *testing.T) {
 	itr, _ := query.NewCallIterator(
@@ -418,6 +452,40 @@ func TestCallIterator_Max_Unsigned(t *testing.T) {
 	}
 }
 
+// Ensure that a string iterator can be created for a max() call.
+func TestCallIterator_Max_String(t *testing.T) {
+	itr, _ := query.NewCallIterator(
+		&StringIterator{Points: []query.StringPoint{
+			{Time: 0, Value: "ok", Tags: ParseTags("region=us-east,host=hostA")},
+			{Time: 2, Value: "error", Tags: ParseTags("region=us-east,host=hostA")},
+			{Time: 1, Value: "warn", Tags: ParseTags("region=us-west,host=hostA")},
+			{Time: 4, Value: "error", Tags: ParseTags("region=us-east,host=hostA")},
+			{Time: 5, Value: "ok", Tags: ParseTags("region=us-east,host=hostA")},
+
+			{Time: 1, Value: "warn", Tags: ParseTags("region=us-west,host=hostB")},
+			{Time: 23, Value: "ok", Tags: ParseTags("region=us-west,host=hostB")},
+		}},
+		query.IteratorOptions{
+			Expr:       MustParseExpr(`max("value")`),
+			Dimensions: []string{"host"},
+			Interval:   query.Interval{Duration: 5 * time.Nanosecond},
+			Ordered:    true,
+			Ascending:  true,
+		},
+	)
+
+	if a, err := Iterators([]query.Iterator{itr}).ReadAll(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if diff := cmp.Diff(a, [][]query.Point{
+		{&query.StringPoint{Time: 1, Value: "warn", Tags: ParseTags("host=hostA"), Aggregated: 4}},
+		{&query.StringPoint{Time: 5, Value: "ok", Tags: ParseTags("host=hostA"), Aggregated: 1}},
+		{&query.StringPoint{Time: 1, Value: "warn", Tags: ParseTags("host=hostB"), Aggregated: 1}},
+		{&query.StringPoint{Time: 23, Value: "ok", Tags: ParseTags("host=hostB"), Aggregated: 1}},
+	}); diff != "" {
+		t.Fatalf("unexpected points:\n%s", diff)
+	}
+}
+
 // Ensure that a boolean iterator can be created for a max() call.
 func TestCallIterator_Max_Boolean(t *testing.T) {
 	itr, _ := query.NewCallIterator(
@@ -1030,6 +1098,32 @@ func TestCallIterator_Mode_String(t *testing.T) {
 	}
 }
 
+// Ensure that ties of a mode() call on strings go to the value seen first.
+func TestCallIterator_Mode_String_Tie(t *testing.T) {
+	itr, _ := query.NewModeIterator(&StringIterator{Points: []query.StringPoint{
+		{Time: 1, Value: "ok", Tags: ParseTags("host=hostA")},
+		{Time: 2, Value: "error", Tags: ParseTags("host=hostA")},
+		{Time: 3, Value: "error", Tags: ParseTags("host=hostA")},
+		{Time: 4, Value: "ok", Tags: ParseTags("host=hostA")},
+	}},
+		query.IteratorOptions{
+			Expr:       MustParseExpr(`mode("value")`),
+			Dimensions: []string{"host"},
+			Interval:   query.Interval{Duration: 5 * time.Nanosecond},
+			Ordered:    true,
+			Ascending:  true,
+		},
+	)
+
+	if a, err := Iterators([]query.Iterator{itr}).ReadAll(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if diff := cmp.Diff(a, [][]query.Point{
+		{&query.StringPoint{Time: 0, Value: "ok", Tags: ParseTags("host=hostA")}},
+	}); diff != "" {
+		t.Fatalf("unexpected points:\n%s", diff)
+	}
+}
+
 // Ensure that a boolean iterator can be created for a modBooleanl.
 func TestCallIterator_Mode_Boolean(t *testing.T) {
 	itr, _ := query.NewModeIterator(&BooleanIterator{Points: []query.BooleanPoint{
@@ -1068,6 +1162,78 @@ func TestCallIterator_Mode_Boolean(t *testing.T) {
 	}
 }
 
+// Ensure that a float iterator can be created for a modes() call.
+func TestCallIterator_Modes_Float(t *testing.T) {
+	itr, _ := query.NewModesIterator(&FloatIterator{Points: []query.FloatPoint{
+		{Time: 0, Value: 15, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 1, Value: 10, Tags: ParseTags("region=us-west,host=hostA")},
+		{Time: 2, Value: 10, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 3, Value: 15, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 4, Value: 12, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 6, Value: 20, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 7, Value: 21, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 8, Value: 21, Tags: ParseTags("region=us-east,host=hostA")},
+
+		{Time: 1, Value: 11, Tags: ParseTags("region=us-west,host=hostB")},
+		{Time: 22, Value: 8, Tags: ParseTags("region=us-west,host=hostB")},
+		{Time: 23, Value: 25, Tags: ParseTags("region=us-west,host=hostB")},
+		{Time: 24, Value: 3, Tags: ParseTags("region=us-west,host=hostB")},
+	}},
+		query.IteratorOptions{
+			Expr:       MustParseExpr(`modes("value")`),
+			Dimensions: []string{"host"},
+			Interval:   query.Interval{Duration: 5 * time.Nanosecond},
+			Ordered:    true,
+			Ascending:  true,
+		},
+	)
+
+	if a, err := Iterators([]query.Iterator{itr}).ReadAll(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if diff := cmp.Diff(a, [][]query.Point{
+		{&query.FloatPoint{Time: 0, Value: 10, Tags: ParseTags("host=hostA")}},
+		{&query.FloatPoint{Time: 0, Value: 15, Tags: ParseTags("host=hostA")}},
+		{&query.FloatPoint{Time: 5, Value: 21, Tags: ParseTags("host=hostA")}},
+		{&query.FloatPoint{Time: 0, Value: 11, Tags: ParseTags("host=hostB")}},
+		{&query.FloatPoint{Time: 20, Value: 3, Tags: ParseTags("host=hostB")}},
+		{&query.FloatPoint{Time: 20, Value: 8, Tags: ParseTags("host=hostB")}},
+		{&query.FloatPoint{Time: 20, Value: 25, Tags: ParseTags("host=hostB")}},
+	}); diff != "" {
+		t.Fatalf("unexpected points:\n%s", diff)
+	}
+}
+
+// Ensure that a boolean iterator can be created for a modes() call.
+func TestCallIterator_Modes_Boolean(t *testing.T) {
+	itr, _ := query.NewModesIterator(&BooleanIterator{Points: []query.BooleanPoint{
+		{Time: 0, Value: true, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 1, Value: true, Tags: ParseTags("region=us-west,host=hostA")},
+		{Time: 2, Value: false, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 3, Value: false, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 6, Value: false, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 7, Value: false, Tags: ParseTags("region=us-east,host=hostA")},
+		{Time: 8, Value: true, Tags: ParseTags("region=us-east,host=hostA")},
+	}},
+		query.IteratorOptions{
+			Expr:       MustParseExpr(`modes("value")`),
+			Dimensions: []string{"host"},
+			Interval:   query.Interval{Duration: 5 * time.Nanosecond},
+			Ordered:    true,
+			Ascending:  true,
+		},
+	)
+
+	if a, err := Iterators([]query.Iterator{itr}).ReadAll(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if diff := cmp.Diff(a, [][]query.Point{
+		{&query.BooleanPoint{Time: 0, Value: false, Tags: ParseTags("host=hostA")}},
+		{&query.BooleanPoint{Time: 0, Value: true, Tags: ParseTags("host=hostA")}},
+		{&query.BooleanPoint{Time: 5, Value: false, Tags: ParseTags("host=hostA")}},
+	}); diff != "" {
+		t.Fatalf("unexpected points:\n%s", diff)
+	}
+}
+
 func TestNewCallIterator_UnsupportedExprName(t *testing.T) {
 	_, err := query.NewCallIterator(
 		&FloatIterator{},
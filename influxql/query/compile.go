@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"regexp/syntax"
 	"strings"
 	"time"
 
@@ -32,6 +34,10 @@ type compiledStatement struct {
 	// TimeRange is the TimeRange for selecting data.
 	TimeRange influxql.TimeRange
 
+	// WindowCondition filters the buckets of a query grouped by time by
+	// the time of each bucket instead of filtering the points that are read.
+	WindowCondition influxql.Expr
+
 	// Interval holds the time grouping interval.
 	Interval Interval
 
@@ -50,6 +56,16 @@ type compiledStatement struct {
 	// compiled query.
 	ExtraIntervals int
 
+	// UnboundedLookback is set when a function needs to read points from before
+	// the start of the TimeRange, such as last_value(). Shards are mapped from the
+	// beginning of time so the value in effect at the start of the range is known.
+	UnboundedLookback bool
+
+	// Lookback is the duration before the start of the TimeRange that is read
+	// by functions computing a statistic over a trailing window, such as
+	// mean_over_time(). It is the largest window of any of those functions.
+	Lookback time.Duration
+
 	// Ascending is true if the time ordering is ascending.
 	Ascending bool
 
@@ -63,6 +79,9 @@ type compiledStatement struct {
 	// HasDistinct is set when the distinct() function is encountered.
 	HasDistinct bool
 
+	// HasModes is set when the modes() function is encountered.
+	HasModes bool
+
 	// FillOption contains the fill option for aggregates.
 	FillOption influxql.FillOption
 
@@ -81,6 +100,9 @@ type compiledStatement struct {
 	// this name.
 	TimeFieldName string
 
+	// HasTimeField is true when the time field is explicitly selected.
+	HasTimeField bool
+
 	// Limit is the number of rows per series this query should be limited to.
 	Limit int
 
@@ -135,15 +157,58 @@ func Compile(stmt *influxql.SelectStatement, opt CompileOptions) (_ Statement, e
 
 	// Rewrite any regex conditions that could make use of the index.
 	c.stmt.RewriteRegexConditions()
+
+	// Name fill() columns after the call they wrap.
+	rewriteFillFields(c.stmt)
 	return c, nil
 }
 
+// rewriteFillFields aliases each fill() field without an alias to the name
+// of the call it wraps so fill(mean(value), 0) is returned as mean instead
+// of fill.
+func rewriteFillFields(stmt *influxql.SelectStatement) {
+	for _, f := range stmt.Fields {
+		if f.Alias != "" {
+			continue
+		}
+		expr := f.Expr
+		for {
+			call, ok := expr.(*influxql.Call)
+			if !ok || call.Name != "fill" || len(call.Args) == 0 {
+				break
+			}
+			expr = call.Args[0]
+		}
+		if expr != f.Expr {
+			f.Alias = (&influxql.Field{Expr: expr}).Name()
+		}
+	}
+}
+
 // preprocess retrieves and records the global attributes of the current statement.
 func (c *compiledStatement) preprocess(stmt *influxql.SelectStatement) error {
 	c.Ascending = stmt.TimeAscending()
 	c.Limit = stmt.Limit
 	c.HasTarget = stmt.Target != nil
 
+	if err := validateLocation(stmt.Location); err != nil {
+		return err
+	}
+
+	// Evaluate truncate() before extracting the time range so the aligned
+	// boundary can be used as a time condition.
+	cond, err := rewriteTruncate(stmt.Condition, c.Options.Now, stmt.Location)
+	if err != nil {
+		return err
+	}
+	stmt.Condition = cond
+
+	cond, c.WindowCondition, err = splitWindowCondition(stmt.Condition)
+	if err != nil {
+		return err
+	}
+	stmt.Condition = cond
+
 	valuer := influxql.NowValuer{Now: c.Options.Now, Location: stmt.Location}
 	cond, t, err := influxql.ConditionExpr(stmt.Condition, &valuer)
 	if err != nil {
@@ -160,6 +225,8 @@ func (c *compiledStatement) preprocess(stmt *influxql.SelectStatement) error {
 	// if it exists.
 	if err := c.compileDimensions(stmt); err != nil {
 		return err
+	} else if c.WindowCondition != nil && c.Interval.IsZero() {
+		return errors.New("window time conditions require GROUP BY time()")
 	}
 
 	// Retrieve the fill option for the statement.
@@ -220,11 +287,16 @@ func (c *compiledStatement) compileFields(stmt *influxql.SelectStatement) error
 			if f.Alias != "" {
 				c.TimeFieldName = f.Alias
 			}
+			c.HasTimeField = true
 			continue
 		}
 
 		// Append this field to the list of processed fields and compile it.
-		f.Expr = influxql.Reduce(f.Expr, &valuer)
+		expr, err := rewriteTruncate(influxql.Reduce(f.Expr, &valuer), c.Options.Now, stmt.Location)
+		if err != nil {
+			return err
+		}
+		f.Expr = expr
 		field := &compiledField{
 			global:        c,
 			Field:         f,
@@ -282,10 +354,14 @@ func (c *compiledField) compileExpr(expr influxql.Expr) error {
 		switch expr.Name {
 		case "percentile":
 			return c.compilePercentile(expr.Args)
+		case "trimmed_mean":
+			return c.compileTrimmedMean(expr.Args)
 		case "sample":
 			return c.compileSample(expr.Args)
 		case "distinct":
 			return c.compileDistinct(expr.Args, false)
+		case "modes":
+			return c.compileModes(expr.Args)
 		case "top", "bottom":
 			return c.compileTopBottom(expr)
 		case "derivative", "non_negative_derivative":
@@ -296,6 +372,8 @@ func (c *compiledField) compileExpr(expr influxql.Expr) error {
 			return c.compileDifference(expr.Args, isNonNegative)
 		case "cumulative_sum":
 			return c.compileCumulativeSum(expr.Args)
+		case "percent_of_total":
+			return c.compilePercentOfTotal(expr.Args)
 		case "moving_average":
 			return c.compileMovingAverage(expr.Args)
 		case "exponential_moving_average", "double_exponential_moving_average", "triple_exponential_moving_average", "relative_strength_index", "triple_exponential_derivative":
@@ -308,11 +386,22 @@ func (c *compiledField) compileExpr(expr influxql.Expr) error {
 			return c.compileElapsed(expr.Args)
 		case "integral":
 			return c.compileIntegral(expr.Args)
+		case "last_value":
+			return c.compileLastValue(expr.Args)
+		case "rate":
+			return c.compileRate(expr.Args)
+		case "count_rate":
+			return c.compileCountRate(expr.Args)
+		case "resets":
+			return c.compileResets(expr.Args)
+		case "mean_over_time", "stddev_over_time", "percentile_over_time":
+			return c.compileOverTime(expr.Name, expr.Args)
+		case "fill":
+			return c.compileFill(expr.Args)
 		case "count_hll":
 			return c.compileCountHll(expr.Args)
-		case "holt_winters", "holt_winters_with_fit":
-			withFit := expr.Name == "holt_winters_with_fit"
-			return c.compileHoltWinters(expr.Args, withFit)
+		case "holt_winters", "holt_winters_with_fit", "holt_winters_upper", "holt_winters_lower":
+			return c.compileHoltWinters(expr.Name, expr.Args)
 		default:
 			return c.compileFunction(expr)
 		}
@@ -369,7 +458,7 @@ func (c *compiledField) compileNestedExpr(expr influxql.Expr) error {
 
 func (c *compiledField) compileSymbol(name string, field influxql.Expr) error {
 	// Must be a variable reference, wildcard, or regexp.
-	switch field.(type) {
+	switch field := field.(type) {
 	case *influxql.VarRef:
 		return nil
 	case *influxql.Wildcard:
@@ -384,6 +473,11 @@ func (c *compiledField) compileSymbol(name string, field influxql.Expr) error {
 		}
 		c.global.OnlySelectors = false
 		return nil
+	case *influxql.Call:
+		if !isMathFunction(field) {
+			return errors.New("aggregate function cannot be used as an argument to another aggregate; use a subquery")
+		}
+		return fmt.Errorf("expected field argument in %s()", name)
 	default:
 		return fmt.Errorf("expected field argument in %s()", name)
 	}
@@ -395,7 +489,7 @@ func (c *compiledField) compileFunction(expr *influxql.Call) error {
 	switch expr.Name {
 	case "max", "min", "first", "last":
 		// top/bottom are not included here since they are not typical functions.
-	case "count", "sum", "mean", "median", "mode", "stddev", "spread", "sum_hll":
+	case "count", "sum", "mean", "median", "mode", "stddev", "stddev_pop", "spread", "iqr", "sum_hll", "precision":
 		// These functions are not considered selectors.
 		c.global.OnlySelectors = false
 	default:
@@ -433,6 +527,28 @@ func (c *compiledField) compilePercentile(args []influxql.Expr) error {
 	return c.compileSymbol("percentile", args[0])
 }
 
+func (c *compiledField) compileTrimmedMean(args []influxql.Expr) error {
+	if exp, got := 2, len(args); got != exp {
+		return fmt.Errorf("invalid number of arguments for trimmed_mean, expected %d, got %d", exp, got)
+	}
+
+	var percent float64
+	switch arg1 := args[1].(type) {
+	case *influxql.IntegerLiteral:
+		percent = float64(arg1.Val)
+	case *influxql.NumberLiteral:
+		percent = arg1.Val
+	default:
+		return fmt.Errorf("expected float argument in trimmed_mean()")
+	}
+	if percent < 0 || percent >= 50 {
+		return fmt.Errorf("trimmed_mean percentage must be at least 0 and less than 50, got %v", percent)
+	}
+	c.global.OnlySelectors = false
+
+	return c.compileSymbol("trimmed_mean", args[0])
+}
+
 func (c *compiledField) compileSample(args []influxql.Expr) error {
 	if exp, got := 2, len(args); got != exp {
 		return fmt.Errorf("invalid number of arguments for sample, expected %d, got %d", exp, got)
@@ -480,6 +596,9 @@ func (c *compiledField) compileDerivative(args []influxql.Expr, isNonNegative bo
 	case *influxql.Call:
 		if c.global.Interval.IsZero() {
 			return fmt.Errorf("%s aggregate requires a GROUP BY interval", name)
+		} else if c.global.Interval.Months > 0 && len(args) == 1 {
+			// The unit defaults to the interval, which has no fixed length.
+			return fmt.Errorf("%s of a calendar interval requires a unit", name)
 		}
 		return c.compileNestedExpr(arg0)
 	default:
@@ -507,9 +626,6 @@ func (c *compiledField) compileElapsed(args []influxql.Expr) error {
 		}
 	}
 	c.global.OnlySelectors = false
-	if c.global.ExtraIntervals < 1 {
-		c.global.ExtraIntervals = 1
-	}
 
 	// Must be a variable reference, function, wildcard, or regexp.
 	switch arg0 := args[0].(type) {
@@ -517,11 +633,12 @@ func (c *compiledField) compileElapsed(args []influxql.Expr) error {
 		if c.global.Interval.IsZero() {
 			return fmt.Errorf("elapsed aggregate requires a GROUP BY interval")
 		}
+		if c.global.ExtraIntervals < 1 {
+			c.global.ExtraIntervals = 1
+		}
 		return c.compileNestedExpr(arg0)
 	default:
-		if !c.global.Interval.IsZero() && !c.global.InheritedInterval {
-			return fmt.Errorf("aggregate function required inside the call to elapsed")
-		}
+		// A GROUP BY interval on raw values restarts elapsed() in each interval.
 		return c.compileSymbol("elapsed", arg0)
 	}
 }
@@ -579,6 +696,20 @@ func (c *compiledField) compileCumulativeSum(args []influxql.Expr) error {
 	}
 }
 
+func (c *compiledField) compilePercentOfTotal(args []influxql.Expr) error {
+	if got := len(args); got != 1 {
+		return fmt.Errorf("invalid number of arguments for percent_of_total, expected 1, got %d", got)
+	}
+	c.global.OnlySelectors = false
+
+	// Must be an aggregate so there is one value per group to compare.
+	arg0, ok := args[0].(*influxql.Call)
+	if !ok {
+		return fmt.Errorf("aggregate function required inside the call to percent_of_total")
+	}
+	return c.compileNestedExpr(arg0)
+}
+
 func (c *compiledField) compileMovingAverage(args []influxql.Expr) error {
 	if got := len(args); got != 2 {
 		return fmt.Errorf("invalid number of arguments for moving_average, expected 2, got %d", got)
@@ -786,6 +917,195 @@ func (c *compiledField) compileIntegral(args []influxql.Expr) error {
 	return c.compileSymbol("integral", args[0])
 }
 
+func (c *compiledField) compileLastValue(args []influxql.Expr) error {
+	if exp, got := 1, len(args); exp != got {
+		return fmt.Errorf("invalid number of arguments for last_value, expected %d, got %d", exp, got)
+	}
+	if c.global.Interval.IsZero() {
+		return fmt.Errorf("last_value aggregate requires a GROUP BY interval")
+	} else if !c.global.Ascending {
+		return fmt.Errorf("last_value aggregate does not support ORDER BY time DESC")
+	}
+	c.global.OnlySelectors = false
+	c.global.UnboundedLookback = true
+
+	// Must be a variable reference, wildcard, or regexp.
+	return c.compileSymbol("last_value", args[0])
+}
+
+func (c *compiledField) compileRate(args []influxql.Expr) error {
+	if min, max, got := 1, 2, len(args); got > max || got < min {
+		return fmt.Errorf("invalid number of arguments for rate, expected at least %d but no more than %d, got %d", min, max, got)
+	}
+	if c.global.Interval.IsZero() {
+		return fmt.Errorf("rate aggregate requires a GROUP BY interval")
+	}
+
+	// The second argument is the length of the trailing window the increase
+	// is measured over.
+	if len(args) == 2 {
+		switch window := args[1].(type) {
+		case *influxql.DurationLiteral:
+			if window.Val <= 0 {
+				return fmt.Errorf("duration argument must be positive, got %s", influxql.FormatDuration(window.Val))
+			}
+			if c.global.Lookback < window.Val {
+				c.global.Lookback = window.Val
+			}
+		default:
+			return errors.New("second argument must be a duration")
+		}
+	} else if c.global.Lookback < c.global.Interval.Duration {
+		// Without a window the last point of the previous interval is read.
+		c.global.Lookback = c.global.Interval.Duration
+	}
+	c.global.OnlySelectors = false
+
+	// Must be a variable reference, wildcard, or regexp.
+	return c.compileSymbol("rate", args[0])
+}
+
+func (c *compiledField) compileCountRate(args []influxql.Expr) error {
+	if exp, got := 1, len(args); exp != got {
+		return fmt.Errorf("invalid number of arguments for count_rate, expected %d, got %d", exp, got)
+	}
+	if c.global.Interval.IsZero() {
+		return fmt.Errorf("count_rate aggregate requires a GROUP BY interval")
+	}
+	c.global.OnlySelectors = false
+
+	// Must be a variable reference, wildcard, or regexp.
+	return c.compileSymbol("count_rate", args[0])
+}
+
+func (c *compiledField) compileResets(args []influxql.Expr) error {
+	if exp, got := 1, len(args); exp != got {
+		return fmt.Errorf("invalid number of arguments for resets, expected %d, got %d", exp, got)
+	}
+	c.global.OnlySelectors = false
+
+	// Must be a variable reference, wildcard, or regexp.
+	return c.compileSymbol("resets", args[0])
+}
+
+func (c *compiledField) compileOverTime(name string, args []influxql.Expr) error {
+	nargs := 2
+	if name == "percentile_over_time" {
+		nargs = 3
+	}
+	if got := len(args); got != nargs {
+		return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", name, nargs, got)
+	}
+	if c.global.Interval.IsZero() {
+		return fmt.Errorf("%s aggregate requires a GROUP BY interval", name)
+	}
+
+	if name == "percentile_over_time" {
+		switch args[1].(type) {
+		case *influxql.IntegerLiteral:
+		case *influxql.NumberLiteral:
+		default:
+			return fmt.Errorf("expected float argument in percentile_over_time()")
+		}
+	}
+
+	// The last argument is the length of the trailing window.
+	switch window := args[nargs-1].(type) {
+	case *influxql.DurationLiteral:
+		if window.Val <= 0 {
+			return fmt.Errorf("duration argument must be positive, got %s", influxql.FormatDuration(window.Val))
+		}
+		if c.global.Lookback < window.Val {
+			c.global.Lookback = window.Val
+		}
+	default:
+		return fmt.Errorf("last argument to %s must be a duration, got %T", name, args[nargs-1])
+	}
+	c.global.OnlySelectors = false
+
+	// Must be a variable reference, wildcard, or regexp.
+	return c.compileSymbol(name, args[0])
+}
+
+func (c *compiledField) compileFill(args []influxql.Expr) error {
+	if exp, got := 2, len(args); exp != got {
+		return fmt.Errorf("invalid number of arguments for fill, expected %d, got %d", exp, got)
+	}
+	if c.global.Interval.IsZero() {
+		return fmt.Errorf("fill requires a GROUP BY interval")
+	}
+
+	arg0, ok := args[0].(*influxql.Call)
+	if !ok {
+		return fmt.Errorf("aggregate function required inside the call to fill")
+	}
+	if _, _, ok := fillOptionFromExpr(args[1]); !ok {
+		return fmt.Errorf("invalid fill option for fill: %s", args[1])
+	}
+	return c.compileNestedExpr(arg0)
+}
+
+// NextFill fills an empty interval with the value of the next interval that
+// has one. It can only be requested through the fill() function since the
+// FILL clause is limited to the options known to the parser.
+const NextFill = influxql.LinearFill + 1
+
+// ExprFill fills an empty interval by evaluating an expression against the
+// value of the previous interval, referenced as previous. Successive empty
+// intervals are evaluated against the value filled before them so an
+// expression such as previous * 0.9 decays across a gap.
+const ExprFill = NextFill + 1
+
+// fillOptionFromExpr returns the fill option and fill value for the second
+// argument of a fill() call. It accepts the same options as the FILL clause
+// along with next and expressions that reference previous.
+func fillOptionFromExpr(expr influxql.Expr) (influxql.FillOption, interface{}, bool) {
+	switch expr := expr.(type) {
+	case *influxql.VarRef:
+		switch strings.ToLower(expr.Val) {
+		case "null":
+			return influxql.NullFill, nil, true
+		case "none":
+			return influxql.NoFill, nil, true
+		case "previous":
+			return influxql.PreviousFill, nil, true
+		case "linear":
+			return influxql.LinearFill, nil, true
+		case "next":
+			return NextFill, nil, true
+		}
+	case *influxql.NumberLiteral:
+		return influxql.NumberFill, expr.Val, true
+	case *influxql.IntegerLiteral:
+		return influxql.NumberFill, expr.Val, true
+	case *influxql.UnsignedLiteral:
+		return influxql.NumberFill, expr.Val, true
+	case *influxql.BinaryExpr, *influxql.ParenExpr:
+		if isFillExpr(expr) {
+			return ExprFill, expr, true
+		}
+	}
+	return influxql.NullFill, nil, false
+}
+
+// isFillExpr returns true if the only variable referenced by expr is previous.
+func isFillExpr(expr influxql.Expr) bool {
+	var hasPrevious, valid = false, true
+	influxql.WalkFunc(expr, func(n influxql.Node) {
+		switch n := n.(type) {
+		case *influxql.VarRef:
+			if strings.ToLower(n.Val) == "previous" {
+				hasPrevious = true
+			} else {
+				valid = false
+			}
+		case *influxql.Call, *influxql.Wildcard, *influxql.RegexLiteral:
+			valid = false
+		}
+	})
+	return valid && hasPrevious
+}
+
 func (c *compiledField) compileCountHll(args []influxql.Expr) error {
 	if exp, got := 1, len(args); exp != got {
 		return fmt.Errorf("invalid number of arguments for count_hll, expected %d, got %d", exp, got)
@@ -799,12 +1119,7 @@ func (c *compiledField) compileCountHll(args []influxql.Expr) error {
 	}
 }
 
-func (c *compiledField) compileHoltWinters(args []influxql.Expr, withFit bool) error {
-	name := "holt_winters"
-	if withFit {
-		name = "holt_winters_with_fit"
-	}
-
+func (c *compiledField) compileHoltWinters(name string, args []influxql.Expr) error {
 	if exp, got := 3, len(args); got != exp {
 		return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", name, exp, got)
 	}
@@ -829,6 +1144,9 @@ func (c *compiledField) compileHoltWinters(args []influxql.Expr, withFit bool) e
 		return fmt.Errorf("must use aggregate function with %s", name)
 	} else if c.global.Interval.IsZero() {
 		return fmt.Errorf("%s aggregate requires a GROUP BY interval", name)
+	} else if c.global.Interval.Months > 0 {
+		// Forecasts are spaced by a fixed duration.
+		return fmt.Errorf("%s does not support calendar intervals", name)
 	}
 	return c.compileNestedExpr(call)
 }
@@ -850,6 +1168,18 @@ func (c *compiledField) compileDistinct(args []influxql.Expr, nested bool) error
 	return nil
 }
 
+func (c *compiledField) compileModes(args []influxql.Expr) error {
+	if exp, got := 1, len(args); exp != got {
+		return fmt.Errorf("invalid number of arguments for modes, expected %d, got %d", exp, got)
+	}
+	if _, ok := args[0].(*influxql.VarRef); !ok {
+		return errors.New("expected field argument in modes()")
+	}
+	c.global.HasModes = true
+	c.global.OnlySelectors = false
+	return nil
+}
+
 func (c *compiledField) compileTopBottom(call *influxql.Call) error {
 	if c.global.TopBottomFunction != "" {
 		return fmt.Errorf("selector function %s() cannot be combined with other functions", c.global.TopBottomFunction)
@@ -900,7 +1230,7 @@ func (c *compiledField) compileMathFunction(expr *influxql.Call) error {
 	// How many arguments are we expecting?
 	nargs := 1
 	switch expr.Name {
-	case "atan2", "pow", "log":
+	case "atan2", "pow", "log", "div", "truncate":
 		nargs = 2
 	}
 
@@ -938,13 +1268,31 @@ func (c *compiledStatement) compileDimensions(stmt *influxql.SelectStatement) er
 				return errors.New("only time() calls allowed in dimensions")
 			} else if got := len(expr.Args); got < 1 || got > 2 {
 				return errors.New("time dimension expected 1 or 2 arguments")
-			} else if lit, ok := expr.Args[0].(*influxql.DurationLiteral); !ok {
+			}
+
+			// A calendar interval such as '1mo' is replaced with the longest
+			// duration it can span and the number of months is kept.
+			if lit, ok := expr.Args[0].(*influxql.StringLiteral); ok {
+				if months, ok := parseCalendarInterval(lit.Val); ok {
+					c.Interval.Months = months
+					expr.Args[0] = &influxql.DurationLiteral{Val: time.Duration(months) * maxMonthDuration}
+				}
+			}
+
+			if lit, ok := expr.Args[0].(*influxql.DurationLiteral); !ok {
 				return errors.New("time dimension must have duration argument")
+			} else if lit.Val <= 0 && !isDurationLiteralArg(d.Expr) {
+				// Duration arithmetic that results in an empty interval is most
+				// likely a mistake so do not silently drop the grouping.
+				return fmt.Errorf("time dimension duration expression must be positive, got %s", lit)
 			} else if c.Interval.Duration != 0 {
 				return errors.New("multiple time dimensions not allowed")
 			} else {
 				c.Interval.Duration = lit.Val
 				if len(expr.Args) == 2 {
+					if _, ok := expr.Args[1].(*influxql.DurationLiteral); !ok && c.Interval.Months > 0 {
+						return errors.New("time dimension offset of a calendar interval must be a duration")
+					}
 					switch lit := expr.Args[1].(type) {
 					case *influxql.DurationLiteral:
 						c.Interval.Offset = lit.Val % c.Interval.Duration
@@ -991,6 +1339,17 @@ func (c *compiledStatement) compileDimensions(stmt *influxql.SelectStatement) er
 	return nil
 }
 
+// isDurationLiteralArg returns true if the first argument of the time
+// dimension was written as a duration literal rather than an expression.
+func isDurationLiteralArg(expr influxql.Expr) bool {
+	call, ok := expr.(*influxql.Call)
+	if !ok || len(call.Args) == 0 {
+		return false
+	}
+	_, ok = call.Args[0].(*influxql.DurationLiteral)
+	return ok
+}
+
 // validateFields validates that the fields are mutually compatible with each other.
 // This runs at the end of compilation but before linking.
 func (c *compiledStatement) validateFields() error {
@@ -1012,11 +1371,21 @@ func (c *compiledStatement) validateFields() error {
 			return errors.New("GROUP BY requires at least one aggregate function")
 		}
 	}
-	// If a distinct() call is present, ensure there is exactly one function.
+	// If a distinct() call is present, ensure there is exactly one function
+	// unless the other is a count() of the same distinct() call.
 	if c.HasDistinct && (len(c.FunctionCalls) != 1 || c.HasAuxiliaryFields) {
-		return errors.New("aggregate function distinct() cannot be combined with other functions or fields")
+		if _, _, ok := distinctCountCalls(c.FunctionCalls); !ok || c.HasAuxiliaryFields {
+			return errors.New("aggregate function distinct() cannot be combined with other functions or fields")
+		}
+	}
+	// modes() may emit several rows per interval so it must be the only field.
+	if c.HasModes && (len(c.FunctionCalls) != 1 || c.HasAuxiliaryFields) {
+		return errors.New("aggregate function modes() cannot be combined with other functions or fields")
 	}
 	// Validate we are using a selector or raw query if auxiliary fields are required.
+	// Each selector picks its own point, so multiple selectors such as
+	// last(a), last(b) may not agree on one point. Only a single selector
+	// can carry the other fields of the point it selected.
 	if c.HasAuxiliaryFields {
 		if !c.OnlySelectors {
 			return fmt.Errorf("mixing aggregate and non-aggregate queries is not supported")
@@ -1027,6 +1396,30 @@ func (c *compiledStatement) validateFields() error {
 	return nil
 }
 
+// distinctCountCall is the internal name of a distinct() call that also
+// reports the number of distinct values of each interval.
+const distinctCountCall = "distinct_count"
+
+// distinctCountCalls returns the calls when they are a distinct() call and a
+// count() of the same distinct() call. Both are answered by one iterator.
+func distinctCountCalls(calls []*influxql.Call) (distinct, count *influxql.Call, ok bool) {
+	if len(calls) != 2 {
+		return nil, nil, false
+	}
+	distinct, count = calls[0], calls[1]
+	if distinct.Name == "count" {
+		distinct, count = count, distinct
+	}
+	if distinct.Name != "distinct" || count.Name != "count" || len(count.Args) != 1 {
+		return nil, nil, false
+	}
+	arg0, ok := count.Args[0].(*influxql.Call)
+	if !ok || arg0.String() != distinct.String() {
+		return nil, nil, false
+	}
+	return distinct, count, true
+}
+
 // validateCondition verifies that all elements in the condition are appropriate.
 // For example, aggregate calls don't work in the condition and should throw an
 // error as an invalid expression.
@@ -1051,7 +1444,7 @@ func (c *compiledStatement) validateCondition(expr influxql.Expr) error {
 		// How many arguments are we expecting?
 		nargs := 1
 		switch expr.Name {
-		case "atan2", "pow":
+		case "atan2", "pow", "div", "truncate":
 			nargs = 2
 		}
 
@@ -1079,6 +1472,11 @@ func (c *compiledStatement) subquery(stmt *influxql.SelectStatement) error {
 	if err := subquery.preprocess(stmt); err != nil {
 		return err
 	}
+	if subquery.Interval.Months > 0 {
+		return errors.New("calendar intervals are not supported in subqueries")
+	} else if subquery.WindowCondition != nil {
+		return errors.New("window time conditions are not supported in subqueries")
+	}
 
 	// Substitute now() into the subquery condition. Then use ConditionExpr to
 	// validate the expression. Do not store the results. We have no way to store
@@ -1112,10 +1510,32 @@ func (c *compiledStatement) subquery(stmt *influxql.SelectStatement) error {
 		subquery.Interval = c.Interval
 		subquery.InheritedInterval = true
 	}
-	return subquery.compile(stmt)
+	if err := subquery.compile(stmt); err != nil {
+		return err
+	}
+
+	// The shards are mapped by the outermost statement so it needs to know
+	// if any subquery reads from before its time range.
+	if subquery.UnboundedLookback {
+		c.UnboundedLookback = true
+	}
+	if c.Lookback < subquery.Lookback {
+		c.Lookback = subquery.Lookback
+	}
+	return nil
 }
 
 func (c *compiledStatement) Prepare(ctx context.Context, shardMapper ShardMapper, sopt SelectOptions) (PreparedStatement, error) {
+	if sopt.MaxRegexComplexity > 0 {
+		if err := validateRegexComplexity(c.stmt, sopt.MaxRegexComplexity); err != nil {
+			return nil, err
+		}
+	}
+
+	if sopt.EchoRange {
+		addMessage(ctx, TimeRangeMessage(c.TimeRange))
+	}
+
 	// If this is a query with a grouping, there is a bucket limit, and the minimum time has not been specified,
 	// we need to limit the possible time range that can be used when mapping shards but not when actually executing
 	// the select statement. Determine the shard time range here.
@@ -1148,6 +1568,7 @@ func (c *compiledStatement) Prepare(ctx context.Context, shardMapper ShardMapper
 				timeRange.Min = time.Unix(0, models.MinNanoTime)
 			} else {
 				timeRange.Min = time.Unix(0, last-int64(interval)*int64(sopt.MaxBucketsN-1))
+				addMessage(ctx, ImplicitTimeRangeWarning(sopt.MaxBucketsN))
 			}
 		}
 	}
@@ -1171,6 +1592,21 @@ func (c *compiledStatement) Prepare(ctx context.Context, shardMapper ShardMapper
 		}
 	}
 
+	// Read the trailing window before the first interval for functions such
+	// as mean_over_time().
+	if c.Lookback > 0 {
+		newTime := timeRange.Min.Add(-c.Lookback)
+		if !newTime.Before(time.Unix(0, influxql.MinTime).UTC()) {
+			timeRange.Min = newTime
+		} else {
+			timeRange.Min = time.Unix(0, influxql.MinTime).UTC()
+		}
+	}
+
+	if c.UnboundedLookback {
+		timeRange.Min = time.Unix(0, influxql.MinTime).UTC()
+	}
+
 	// Create an iterator creator based on the shards in the cluster.
 	shards, err := shardMapper.MapShards(ctx, c.stmt.Sources, timeRange, sopt)
 	if err != nil {
@@ -1190,6 +1626,10 @@ func (c *compiledStatement) Prepare(ctx context.Context, shardMapper ShardMapper
 		shards.Close()
 		return nil, err
 	}
+	if err := validateRegexTypes(stmt, mapper); err != nil {
+		shards.Close()
+		return nil, err
+	}
 
 	// Determine base options for iterators.
 	opt, err := newIteratorOptionsStmt(stmt, sopt)
@@ -1198,7 +1638,17 @@ func (c *compiledStatement) Prepare(ctx context.Context, shardMapper ShardMapper
 		return nil, err
 	}
 	opt.StartTime, opt.EndTime = c.TimeRange.MinTimeNano(), c.TimeRange.MaxTimeNano()
+	opt.Interval.Months = c.Interval.Months
 	opt.Ascending = c.Ascending
+	opt.PointTime = c.HasTimeField
+
+	// The buckets dropped by a window condition do not count towards the
+	// LIMIT and OFFSET, so they are applied after the buckets are filtered.
+	var limit, offset int
+	if c.WindowCondition != nil {
+		limit, offset = opt.Limit, opt.Offset
+		opt.Limit, opt.Offset = 0, 0
+	}
 
 	if sopt.MaxBucketsN > 0 && !stmt.IsRawQuery && c.TimeRange.MinTimeNano() > influxql.MinTime {
 		interval, err := stmt.GroupByInterval()
@@ -1223,11 +1673,108 @@ func (c *compiledStatement) Prepare(ctx context.Context, shardMapper ShardMapper
 
 	columns := stmt.ColumnNames()
 	return &preparedStatement{
-		stmt:      stmt,
-		opt:       opt,
-		ic:        shards,
-		columns:   columns,
-		maxPointN: sopt.MaxPointN,
-		now:       c.Options.Now,
+		stmt:           stmt,
+		opt:            opt,
+		ic:             shards,
+		columns:        columns,
+		maxPointN:      sopt.MaxPointN,
+		now:            c.Options.Now,
+		limitStartTime: c.limitStartTime(stmt, opt),
+		labelRight:     sopt.LabelRight,
+		dropEmptyTags:  sopt.DropEmptyTags,
+		window:         c.WindowCondition,
+		limit:          limit,
+		offset:         offset,
 	}, nil
 }
+
+// limitStartTime returns the start of the earliest bucket that can be returned
+// by a query ordered by time descending with a LIMIT so only the latest buckets
+// need to be computed. It returns zero if the time range cannot be narrowed.
+//
+// This is only done when every bucket produces exactly one row so the LIMIT
+// counts buckets. This requires a single series, a fill option that does not
+// depend on other buckets, and aggregates that return a single value.
+func (c *compiledStatement) limitStartTime(stmt *influxql.SelectStatement, opt IteratorOptions) int64 {
+	if opt.Ascending || opt.Limit <= 0 || opt.Interval.IsZero() || opt.EndTime >= influxql.MaxTime {
+		return 0
+	} else if opt.Fill != influxql.NullFill && opt.Fill != influxql.NumberFill {
+		return 0
+	} else if len(opt.Dimensions) > 0 || c.ExtraIntervals > 0 || c.UnboundedLookback {
+		return 0
+	} else if len(stmt.Sources) != 1 {
+		return 0
+	} else if m, ok := stmt.Sources[0].(*influxql.Measurement); !ok || m.Regex != nil {
+		return 0
+	}
+
+	for _, call := range c.FunctionCalls {
+		switch call.Name {
+		case "count", "sum", "mean", "median", "mode", "stddev", "stddev_pop", "spread", "iqr",
+			"min", "max", "first", "last", "percentile", "trimmed_mean", "precision", "count_rate", "resets":
+		default:
+			return 0
+		}
+	}
+
+	n := opt.Limit + opt.Offset
+	start, _ := opt.Window(opt.EndTime)
+	if opt.Interval.Months == 0 && opt.Location == nil {
+		// Without a time zone every bucket has the same length so the
+		// earliest bucket within the limit can be computed directly.
+		d := int64(opt.Interval.Duration)
+		if uint64(n-1) > uint64(start-opt.StartTime)/uint64(d) {
+			return 0
+		}
+		start -= int64(n-1) * d
+	} else {
+		// Walk back from the last bucket to the earliest bucket within the
+		// limit, stopping at the start of the time range.
+		for i := 1; i < n && start > opt.StartTime; i++ {
+			start, _ = opt.Window(start - 1)
+		}
+	}
+	if start <= opt.StartTime {
+		return 0
+	}
+	return start
+}
+
+// validateRegexComplexity ensures that no regular expression in the statement,
+// its sources, or its subqueries compiles to more than max instructions.
+func validateRegexComplexity(stmt *influxql.SelectStatement, max int) error {
+	var err error
+	influxql.WalkFunc(stmt, func(n influxql.Node) {
+		if err != nil {
+			return
+		}
+		var re *regexp.Regexp
+		switch n := n.(type) {
+		case *influxql.RegexLiteral:
+			re = n.Val
+		case *influxql.Measurement:
+			if n.Regex != nil {
+				re = n.Regex.Val
+			}
+		}
+		if re != nil && regexComplexity(re) > max {
+			err = ErrRegexTooComplex
+		}
+	})
+	return err
+}
+
+// regexComplexity returns the number of instructions of the program compiled
+// for a regular expression. Nested repetitions are expanded by the compiler
+// so this grows with the work needed to match the expression.
+func regexComplexity(re *regexp.Regexp) int {
+	expr, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return 0
+	}
+	prog, err := syntax.Compile(expr.Simplify())
+	if err != nil {
+		return 0
+	}
+	return len(prog.Inst)
+}
@@ -66,13 +66,30 @@ func TestCompile_Success(t *testing.T) {
 		`SELECT max(bottom) FROM (SELECT bottom(value, host, 1) FROM cpu) GROUP BY region`,
 		`SELECT percentile(value, 75) FROM cpu`,
 		`SELECT percentile(value, 75.0) FROM cpu`,
+		`SELECT trimmed_mean(value, 10) FROM cpu`,
+		`SELECT precision(value) FROM cpu GROUP BY *`,
+		`SELECT iqr(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m), host`,
+		`SELECT trimmed_mean(value, 12.5) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
 		`SELECT sample(value, 2) FROM cpu`,
 		`SELECT sample(*, 2) FROM cpu`,
 		`SELECT sample(/val/, 2) FROM cpu`,
 		`SELECT elapsed(value) FROM cpu`,
 		`SELECT elapsed(value, 10s) FROM cpu`,
+		`SELECT elapsed(value, 1s) FROM cpu WHERE time >= now() - 5m GROUP BY time(1m)`,
 		`SELECT integral(value) FROM cpu`,
 		`SELECT integral(value, 10s) FROM cpu`,
+		`SELECT last_value(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+		`SELECT rate(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+		`SELECT rate(value, 1m) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+		`SELECT count_rate(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+		`SELECT resets(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+		`SELECT resets(value) FROM cpu`,
+		`SELECT mean_over_time(value, 30m), stddev_over_time(value, 30m) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+		`SELECT percentile_over_time(value, 90, 30m) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m), host`,
+		`SELECT fill(mean(value), none), fill(count(value), 0) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+		`SELECT fill(max(value), previous) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m) fill(none)`,
+		`SELECT fill(mean(value), next) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+		`SELECT fill(mean(value), previous * 0.9) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
 		`SELECT max(value) FROM cpu WHERE time >= now() - 1m GROUP BY time(10s, 5s)`,
 		`SELECT max(value) FROM cpu WHERE time >= now() - 1m GROUP BY time(10s, '2000-01-01T00:00:05Z')`,
 		`SELECT max(value) FROM cpu WHERE time >= now() - 1m GROUP BY time(10s, now())`,
@@ -83,10 +100,18 @@ func TestCompile_Success(t *testing.T) {
 		`SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T01:00:00Z'`,
 		`SELECT value FROM (SELECT value FROM cpu) ORDER BY time DESC`,
 		`SELECT count(distinct(value)), max(value) FROM cpu`,
+		`SELECT distinct(value), count(distinct(value)) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
 		`SELECT derivative(distinct(value)), difference(distinct(value)) FROM cpu WHERE time >= now() - 1m GROUP BY time(5s)`,
+		`SELECT count(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1h/4)`,
+		`SELECT sum(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(5m*3, 1m+30s)`,
 		`SELECT moving_average(distinct(value), 3) FROM cpu WHERE time >= now() - 5m GROUP BY time(1m)`,
+		`SELECT kaufmans_efficiency_ratio(value, 3) FROM cpu`,
+		`SELECT kaufmans_adaptive_moving_average(value, 3, 0) FROM cpu`,
+		`SELECT kaufmans_adaptive_moving_average(mean(value), 3) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
 		`SELECT elapsed(distinct(value)) FROM cpu WHERE time >= now() - 5m GROUP BY time(1m)`,
 		`SELECT cumulative_sum(distinct(value)) FROM cpu WHERE time >= now() - 5m GROUP BY time(1m)`,
+		`SELECT sum(value), percent_of_total(sum(value)) FROM cpu GROUP BY host`,
+		`SELECT percent_of_total(count(value)) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m), host`,
 		`SELECT last(value) / (1 - 0) FROM cpu`,
 		`SELECT abs(value) FROM cpu`,
 		`SELECT sin(value) FROM cpu`,
@@ -111,6 +136,9 @@ func TestCompile_Success(t *testing.T) {
 		`SELECT log10(value) FROM cpu`,
 		`SELECT sin(value) - sin(1.3) FROM cpu`,
 		`SELECT value FROM cpu WHERE sin(value) > 0.5`,
+		`SELECT value FROM cpu WHERE time >= truncate(now(), 1h)`,
+		`SELECT value FROM cpu WHERE time >= truncate('2000-01-01T00:35:00Z', 1d) TZ('America/Los_Angeles')`,
+		`SELECT truncate(time, 1h), value FROM cpu`,
 		`SELECT sum("out")/sum("in") FROM (SELECT derivative("out") AS "out", derivative("in") AS "in" FROM "m0" WHERE time >= now() - 5m GROUP BY "index") GROUP BY time(1m) fill(none)`,
 	} {
 		t.Run(tt, func(t *testing.T) {
@@ -153,6 +181,7 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT mean() FROM cpu`, err: `invalid number of arguments for mean, expected 1, got 0`},
 		{s: `SELECT mean(value, host) FROM cpu`, err: `invalid number of arguments for mean, expected 1, got 2`},
 		{s: `SELECT distinct(value), max(value) FROM cpu`, err: `aggregate function distinct() cannot be combined with other functions or fields`},
+		{s: `SELECT distinct(value), count(distinct(host)) FROM cpu`, err: `aggregate function distinct() cannot be combined with other functions or fields`},
 		{s: `SELECT count(distinct()) FROM cpu`, err: `distinct function requires at least one argument`},
 		{s: `SELECT count(distinct(value, host)) FROM cpu`, err: `distinct function can only have one argument`},
 		{s: `SELECT count(distinct(2)) FROM cpu`, err: `expected field argument in distinct()`},
@@ -160,6 +189,8 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT value FROM cpu GROUP BY time()`, err: `time dimension expected 1 or 2 arguments`},
 		{s: `SELECT value FROM cpu GROUP BY time(5m, 30s, 1ms)`, err: `time dimension expected 1 or 2 arguments`},
 		{s: `SELECT value FROM cpu GROUP BY time('unexpected')`, err: `time dimension must have duration argument`},
+		{s: `SELECT count(value) FROM cpu GROUP BY time(1h/0)`, err: `time dimension duration expression must be positive, got 0s`},
+		{s: `SELECT count(value) FROM cpu GROUP BY time(5m-10m)`, err: `time dimension duration expression must be positive, got -5m`},
 		{s: `SELECT value FROM cpu GROUP BY time(5m), time(1m)`, err: `multiple time dimensions not allowed`},
 		{s: `SELECT value FROM cpu GROUP BY time(5m, unexpected())`, err: `time dimension offset function must be now()`},
 		{s: `SELECT value FROM cpu GROUP BY time(5m, now(1m))`, err: `time dimension offset now() function requires no arguments`},
@@ -185,20 +216,22 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT count(value), /ho/ FROM cpu`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		{s: `SELECT max(/val/), * FROM cpu`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		{s: `SELECT a(value) FROM cpu`, err: `undefined function a()`},
-		{s: `SELECT count(max(value)) FROM myseries`, err: `expected field argument in count()`},
+		{s: `SELECT count(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
 		{s: `SELECT count(distinct('value')) FROM myseries`, err: `expected field argument in distinct()`},
 		{s: `SELECT distinct('value') FROM myseries`, err: `expected field argument in distinct()`},
-		{s: `SELECT min(max(value)) FROM myseries`, err: `expected field argument in min()`},
-		{s: `SELECT min(distinct(value)) FROM myseries`, err: `expected field argument in min()`},
-		{s: `SELECT max(max(value)) FROM myseries`, err: `expected field argument in max()`},
-		{s: `SELECT sum(max(value)) FROM myseries`, err: `expected field argument in sum()`},
-		{s: `SELECT first(max(value)) FROM myseries`, err: `expected field argument in first()`},
-		{s: `SELECT last(max(value)) FROM myseries`, err: `expected field argument in last()`},
-		{s: `SELECT mean(max(value)) FROM myseries`, err: `expected field argument in mean()`},
-		{s: `SELECT median(max(value)) FROM myseries`, err: `expected field argument in median()`},
-		{s: `SELECT mode(max(value)) FROM myseries`, err: `expected field argument in mode()`},
-		{s: `SELECT stddev(max(value)) FROM myseries`, err: `expected field argument in stddev()`},
-		{s: `SELECT spread(max(value)) FROM myseries`, err: `expected field argument in spread()`},
+		{s: `SELECT min(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT min(distinct(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT max(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT sum(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT first(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT last(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT mean(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT median(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT mode(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT stddev(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT spread(max(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT mean(sum(value)) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT mean(abs(value)) FROM myseries`, err: `expected field argument in mean()`},
 		{s: `SELECT top() FROM myseries`, err: `invalid number of arguments for top, expected at least 2, got 0`},
 		{s: `SELECT top(field1) FROM myseries`, err: `invalid number of arguments for top, expected at least 2, got 1`},
 		{s: `SELECT top(field1,foo) FROM myseries`, err: `expected integer as last argument in top(), found foo`},
@@ -224,7 +257,11 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT percentile() FROM myseries`, err: `invalid number of arguments for percentile, expected 2, got 0`},
 		{s: `SELECT percentile(field1) FROM myseries`, err: `invalid number of arguments for percentile, expected 2, got 1`},
 		{s: `SELECT percentile(field1, foo) FROM myseries`, err: `expected float argument in percentile()`},
-		{s: `SELECT percentile(max(field1), 75) FROM myseries`, err: `expected field argument in percentile()`},
+		{s: `SELECT percentile(max(field1), 75) FROM myseries`, err: `aggregate function cannot be used as an argument to another aggregate; use a subquery`},
+		{s: `SELECT trimmed_mean(field1) FROM myseries`, err: `invalid number of arguments for trimmed_mean, expected 2, got 1`},
+		{s: `SELECT trimmed_mean(field1, foo) FROM myseries`, err: `expected float argument in trimmed_mean()`},
+		{s: `SELECT trimmed_mean(field1, 50) FROM myseries`, err: `trimmed_mean percentage must be at least 0 and less than 50, got 50`},
+		{s: `SELECT trimmed_mean(field1, -1) FROM myseries`, err: `trimmed_mean percentage must be at least 0 and less than 50, got -1`},
 		{s: `SELECT field1 FROM foo group by time(1s)`, err: `GROUP BY requires at least one aggregate function`},
 		{s: `SELECT field1 FROM foo fill(none)`, err: `fill(none) must be used with a function`},
 		{s: `SELECT field1 FROM foo fill(linear)`, err: `fill(linear) must be used with a function`},
@@ -236,10 +273,17 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT count(value) FROM foo where time > now() and time < now() group by time(1s), time(2s)`, err: `multiple time dimensions not allowed`},
 		{s: `SELECT count(value) FROM foo where time > now() and time < now() group by time(1s, b)`, err: `time dimension offset must be duration or now()`},
 		{s: `SELECT count(value) FROM foo where time > now() and time < now() group by time(1s, '5s')`, err: `time dimension offset must be duration or now()`},
+		{s: `SELECT count(value) FROM foo where time % 2h = 1h`, err: `window time conditions require GROUP BY time()`},
+		{s: `SELECT count(value) FROM foo where time > now() - 1d and time % 2h = value group by time(1h)`, err: `window time conditions may only reference time`},
+		{s: `SELECT max(count) FROM (SELECT count(value) FROM foo where time > now() - 1d and time % 2h = 1h group by time(1h))`, err: `window time conditions are not supported in subqueries`},
 		{s: `SELECT distinct(field1), sum(field1) FROM myseries`, err: `aggregate function distinct() cannot be combined with other functions or fields`},
 		{s: `SELECT distinct(field1), field2 FROM myseries`, err: `aggregate function distinct() cannot be combined with other functions or fields`},
 		{s: `SELECT distinct(field1, field2) FROM myseries`, err: `distinct function can only have one argument`},
 		{s: `SELECT distinct() FROM myseries`, err: `distinct function requires at least one argument`},
+		{s: `SELECT modes(field1), sum(field1) FROM myseries`, err: `aggregate function modes() cannot be combined with other functions or fields`},
+		{s: `SELECT modes(field1), field2 FROM myseries`, err: `aggregate function modes() cannot be combined with other functions or fields`},
+		{s: `SELECT modes(field1, field2) FROM myseries`, err: `invalid number of arguments for modes, expected 1, got 2`},
+		{s: `SELECT modes(*) FROM myseries`, err: `expected field argument in modes()`},
 		{s: `SELECT distinct field1, field2 FROM myseries`, err: `aggregate function distinct() cannot be combined with other functions or fields`},
 		{s: `SELECT count(distinct field1, field2) FROM myseries`, err: `invalid number of arguments for count, expected 1, got 2`},
 		{s: `select count(distinct(too, many, arguments)) from myseries`, err: `distinct function can only have one argument`},
@@ -253,6 +297,7 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT derivative(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
 		{s: `SELECT derivative(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
 		{s: `SELECT derivative(mean(value), 1h) FROM myseries where time < now() and time > now() - 1d`, err: `derivative aggregate requires a GROUP BY interval`},
+		{s: `SELECT derivative(mean(value)) FROM myseries where time < now() and time > now() - 365d group by time('1mo')`, err: `derivative of a calendar interval requires a unit`},
 		{s: `SELECT derivative(value, -2h) FROM myseries`, err: `duration argument must be positive, got -2h`},
 		{s: `SELECT derivative(value, 10) FROM myseries`, err: `second argument to derivative must be a duration, got *influxql.IntegerLiteral`},
 		{s: `SELECT derivative(f, true) FROM myseries`, err: `second argument to derivative must be a duration, got *influxql.BooleanLiteral`},
@@ -284,7 +329,6 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT non_negative_difference(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
 		{s: `SELECT non_negative_difference(mean(value)) FROM myseries where time < now() and time > now() - 1d`, err: `non_negative_difference aggregate requires a GROUP BY interval`},
 		{s: `SELECT elapsed() FROM myseries`, err: `invalid number of arguments for elapsed, expected at least 1 but no more than 2, got 0`},
-		{s: `SELECT elapsed(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to elapsed`},
 		{s: `SELECT elapsed(value, 1s, host) FROM myseries`, err: `invalid number of arguments for elapsed, expected at least 1 but no more than 2, got 3`},
 		{s: `SELECT elapsed(value, 0s) FROM myseries`, err: `duration argument must be positive, got 0s`},
 		{s: `SELECT elapsed(value, -10s) FROM myseries`, err: `duration argument must be positive, got -10s`},
@@ -307,6 +351,13 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT moving_average(max(), 2) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
 		{s: `SELECT moving_average(percentile(value), 2) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
 		{s: `SELECT moving_average(mean(value), 2) FROM myseries where time < now() and time > now() - 1d`, err: `moving_average aggregate requires a GROUP BY interval`},
+		{s: `SELECT kaufmans_efficiency_ratio(value) FROM myseries`, err: `invalid number of arguments for kaufmans_efficiency_ratio, expected at least 2 but no more than 3, got 1`},
+		{s: `SELECT kaufmans_efficiency_ratio(value, 2.5) FROM myseries`, err: `kaufmans_efficiency_ratio period must be an integer`},
+		{s: `SELECT kaufmans_efficiency_ratio(value, 0) FROM myseries`, err: `kaufmans_efficiency_ratio period must be greater than or equal to 1`},
+		{s: `SELECT kaufmans_adaptive_moving_average(value, 3, -2) FROM myseries`, err: `kaufmans_adaptive_moving_average hold period must be greater than or equal to 0`},
+		{s: `SELECT kaufmans_adaptive_moving_average(value, 3, 'x') FROM myseries`, err: `kaufmans_adaptive_moving_average hold period must be an integer`},
+		{s: `SELECT kaufmans_adaptive_moving_average(mean(value), 3) FROM myseries`, err: `kaufmans_adaptive_moving_average aggregate requires a GROUP BY interval`},
+		{s: `SELECT kaufmans_adaptive_moving_average(value, 3) FROM myseries WHERE time > now() - 1d GROUP BY time(1h)`, err: `aggregate function required inside the call to kaufmans_adaptive_moving_average`},
 		{s: `SELECT cumulative_sum(field1), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		{s: `SELECT cumulative_sum() from myseries`, err: `invalid number of arguments for cumulative_sum, expected 1, got 0`},
 		{s: `SELECT cumulative_sum(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to cumulative_sum`},
@@ -315,10 +366,37 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT cumulative_sum(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
 		{s: `SELECT cumulative_sum(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
 		{s: `SELECT cumulative_sum(mean(value)) FROM myseries where time < now() and time > now() - 1d`, err: `cumulative_sum aggregate requires a GROUP BY interval`},
+		{s: `SELECT percent_of_total() FROM myseries`, err: `invalid number of arguments for percent_of_total, expected 1, got 0`},
+		{s: `SELECT percent_of_total(value) FROM myseries GROUP BY host`, err: `aggregate function required inside the call to percent_of_total`},
+		{s: `SELECT percent_of_total(sum(value)), value FROM myseries GROUP BY host`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		{s: `SELECT integral() FROM myseries`, err: `invalid number of arguments for integral, expected at least 1 but no more than 2, got 0`},
 		{s: `SELECT integral(value, 10s, host) FROM myseries`, err: `invalid number of arguments for integral, expected at least 1 but no more than 2, got 3`},
 		{s: `SELECT integral(value, -10s) FROM myseries`, err: `duration argument must be positive, got -10s`},
 		{s: `SELECT integral(value, 10) FROM myseries`, err: `second argument must be a duration`},
+		{s: `SELECT last_value() FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `invalid number of arguments for last_value, expected 1, got 0`},
+		{s: `SELECT last_value(value) FROM myseries`, err: `last_value aggregate requires a GROUP BY interval`},
+		{s: `SELECT last_value(value) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m) ORDER BY time DESC`, err: `last_value aggregate does not support ORDER BY time DESC`},
+		{s: `SELECT rate() FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `invalid number of arguments for rate, expected at least 1 but no more than 2, got 0`},
+		{s: `SELECT rate(value) FROM myseries`, err: `rate aggregate requires a GROUP BY interval`},
+		{s: `SELECT rate(value, 10) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `second argument must be a duration`},
+		{s: `SELECT rate(value, -1s) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `duration argument must be positive, got -1s`},
+		{s: `SELECT count_rate(value, 1s) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `invalid number of arguments for count_rate, expected 1, got 2`},
+		{s: `SELECT count_rate(value) FROM myseries`, err: `count_rate aggregate requires a GROUP BY interval`},
+		{s: `SELECT resets(value, 1) FROM myseries`, err: `invalid number of arguments for resets, expected 1, got 2`},
+		{s: `SELECT resets(1) FROM myseries`, err: `expected field argument in resets()`},
+		{s: `SELECT mean_over_time(value) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `invalid number of arguments for mean_over_time, expected 2, got 1`},
+		{s: `SELECT mean_over_time(value, 30m) FROM myseries`, err: `mean_over_time aggregate requires a GROUP BY interval`},
+		{s: `SELECT stddev_over_time(value, 0s) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `duration argument must be positive, got 0s`},
+		{s: `SELECT stddev_over_time(value, 10) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `last argument to stddev_over_time must be a duration, got *influxql.IntegerLiteral`},
+		{s: `SELECT percentile_over_time(value, 'a', 30m) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `expected float argument in percentile_over_time()`},
+		{s: `SELECT value FROM myseries WHERE time >= truncate(now())`, err: `invalid number of arguments for truncate, expected 2, got 1`},
+		{s: `SELECT value FROM myseries WHERE time >= truncate(now(), 0s)`, err: `duration argument must be positive, got 0s`},
+		{s: `SELECT truncate(time, 'x'), value FROM myseries`, err: `second argument to truncate must be a duration, got *influxql.StringLiteral`},
+		{s: `SELECT fill(mean(value)) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `invalid number of arguments for fill, expected 2, got 1`},
+		{s: `SELECT fill(mean(value), 0) FROM myseries`, err: `fill requires a GROUP BY interval`},
+		{s: `SELECT fill(value, 0) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `aggregate function required inside the call to fill`},
+		{s: `SELECT fill(mean(value), 'x') FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `invalid fill option for fill: 'x'`},
+		{s: `SELECT fill(mean(value), previous * value) FROM myseries WHERE time >= now() - 1h GROUP BY time(10m)`, err: `invalid fill option for fill: previous * value`},
 		{s: `SELECT holt_winters(value) FROM myseries where time < now() and time > now() - 1d`, err: `invalid number of arguments for holt_winters, expected 3, got 1`},
 		{s: `SELECT holt_winters(value, 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `must use aggregate function with holt_winters`},
 		{s: `SELECT holt_winters(min(value), 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `holt_winters aggregate requires a GROUP BY interval`},
@@ -326,6 +404,7 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT holt_winters(min(value), false, 2) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as second arg in holt_winters`},
 		{s: `SELECT holt_winters(min(value), 10, 'string') FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as third arg in holt_winters`},
 		{s: `SELECT holt_winters(min(value), 10, -1) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `third arg to holt_winters cannot be negative, got -1`},
+		{s: `SELECT holt_winters(min(value), 10, 2) FROM myseries where time < now() and time > now() - 365d GROUP BY time('1mo')`, err: `holt_winters does not support calendar intervals`},
 		{s: `SELECT holt_winters_with_fit(value) FROM myseries where time < now() and time > now() - 1d`, err: `invalid number of arguments for holt_winters_with_fit, expected 3, got 1`},
 		{s: `SELECT holt_winters_with_fit(value, 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `must use aggregate function with holt_winters_with_fit`},
 		{s: `SELECT holt_winters_with_fit(min(value), 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `holt_winters_with_fit aggregate requires a GROUP BY interval`},
@@ -333,6 +412,8 @@ func TestCompile_Failures(t *testing.T) {
 		{s: `SELECT holt_winters_with_fit(min(value), false, 2) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as second arg in holt_winters_with_fit`},
 		{s: `SELECT holt_winters_with_fit(min(value), 10, 'string') FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as third arg in holt_winters_with_fit`},
 		{s: `SELECT holt_winters_with_fit(min(value), 10, -1) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `third arg to holt_winters_with_fit cannot be negative, got -1`},
+		{s: `SELECT holt_winters_upper(value, 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `must use aggregate function with holt_winters_upper`},
+		{s: `SELECT holt_winters_lower(min(value), 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `holt_winters_lower aggregate requires a GROUP BY interval`},
 		{s: `SELECT mean(value) + value FROM cpu WHERE time < now() and time > now() - 1h GROUP BY time(10m)`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		// TODO: Remove this restriction in the future: https://github.com/influxdata/influxdb/issues/5968
 		{s: `SELECT mean(cpu_total - cpu_idle) FROM cpu`, err: `expected field argument in mean()`},
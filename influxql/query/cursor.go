@@ -144,6 +144,9 @@ type scannerCursorBase struct {
 	columns []influxql.VarRef
 	loc     *time.Location
 
+	// needsTime is set when an expression references the time of the point.
+	needsTime bool
+
 	scan   scannerFunc
 	valuer influxql.ValuerEval
 }
@@ -152,12 +155,16 @@ func newScannerCursorBase(scan scannerFunc, fields []*influxql.Field, loc *time.
 	typmap := FunctionTypeMapper{}
 	exprs := make([]influxql.Expr, len(fields))
 	columns := make([]influxql.VarRef, len(fields))
+	needsTime := false
 	for i, f := range fields {
-		exprs[i] = f.Expr
+		exprs[i] = rewriteMod(f.Expr)
 		columns[i] = influxql.VarRef{
 			Val:  f.Name(),
 			Type: influxql.EvalType(f.Expr, nil, typmap),
 		}
+		if _, ok := f.Expr.(*influxql.VarRef); !ok && referencesTime(f.Expr) {
+			needsTime = true
+		}
 	}
 	if loc == nil {
 		loc = time.UTC
@@ -165,14 +172,16 @@ func newScannerCursorBase(scan scannerFunc, fields []*influxql.Field, loc *time.
 
 	m := make(map[string]interface{})
 	return scannerCursorBase{
-		fields:  exprs,
-		m:       m,
-		columns: columns,
-		loc:     loc,
-		scan:    scan,
+		fields:    exprs,
+		m:         m,
+		columns:   columns,
+		loc:       loc,
+		needsTime: needsTime,
+		scan:      scan,
 		valuer: influxql.ValuerEval{
 			Valuer: influxql.MultiValuer(
 				MathValuer{},
+				TruncateValuer{Location: loc},
 				influxql.MapValuer(m),
 			),
 			IntegerFloatDivision: true,
@@ -197,6 +206,9 @@ func (cur *scannerCursorBase) Scan(row *Row) bool {
 	if len(cur.columns) > len(row.Values) {
 		row.Values = make([]interface{}, len(cur.columns))
 	}
+	if cur.needsTime {
+		cur.m["time"] = time.Unix(0, row.Time).In(cur.loc)
+	}
 
 	for i, expr := range cur.fields {
 		// A special case if the field is time to reduce memory allocations.
@@ -216,6 +228,17 @@ func (cur *scannerCursorBase) Scan(row *Row) bool {
 	return true
 }
 
+// referencesTime returns true if the expression refers to the time of the point.
+func referencesTime(expr influxql.Expr) bool {
+	found := false
+	influxql.WalkFunc(expr, func(n influxql.Node) {
+		if ref, ok := n.(*influxql.VarRef); ok && ref.Val == "time" {
+			found = true
+		}
+	})
+	return found
+}
+
 func (cur *scannerCursorBase) Columns() []influxql.VarRef {
 	return cur.columns
 }
@@ -404,6 +427,72 @@ func (cur *filterCursor) Scan(row *Row) bool {
 	return false
 }
 
+// peekCursor returns a row that was already scanned from the cursor before
+// scanning the rest of the rows.
+type peekCursor struct {
+	Cursor
+	row    Row
+	peeked bool
+}
+
+func (cur *peekCursor) Scan(row *Row) bool {
+	if cur.peeked {
+		*row, cur.peeked = cur.row, false
+		return true
+	}
+	return cur.Cursor.Scan(row)
+}
+
+// rightLabelCursor sets the time of each row to the end of the interval
+// the row was grouped into instead of its start.
+type rightLabelCursor struct {
+	Cursor
+	opt IteratorOptions
+}
+
+func (cur *rightLabelCursor) Scan(row *Row) bool {
+	if !cur.Cursor.Scan(row) {
+		return false
+	}
+
+	_, end := cur.opt.Window(row.Time)
+	row.Time = end
+	if t, ok := row.Values[0].(time.Time); ok {
+		row.Values[0] = time.Unix(0, end).In(t.Location())
+	}
+	return true
+}
+
+// dropEmptyTagsCursor drops the rows of every series whose tags all have
+// empty values. Rows of a series without tags are kept.
+type dropEmptyTagsCursor struct {
+	Cursor
+}
+
+func (cur *dropEmptyTagsCursor) Scan(row *Row) bool {
+	for cur.Cursor.Scan(row) {
+		if !hasOnlyEmptyTags(row.Series.Tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasOnlyEmptyTags returns true if tags has at least one tag and every
+// tag has an empty value.
+func hasOnlyEmptyTags(tags Tags) bool {
+	m := tags.KeyValues()
+	if len(m) == 0 {
+		return false
+	}
+	for _, v := range m {
+		if v != "" {
+			return false
+		}
+	}
+	return true
+}
+
 type nullCursor struct {
 	columns []influxql.VarRef
 }
@@ -29,6 +29,10 @@ var (
 
 	// ErrQueryInterrupted is an error returned when the query is interrupted.
 	ErrQueryInterrupted = errors.New("query interrupted")
+
+	// ErrRegexTooComplex is returned when a regular expression in a statement
+	// exceeds the configured complexity.
+	ErrRegexTooComplex = errors.New("regular expression too complex")
 )
 
 const (
@@ -126,10 +130,100 @@ type ExecutionOptions struct {
 
 	// Quiet suppresses non-essential output from the query executor.
 	Quiet bool
+
+	// CoerceNumeric parses string values as numbers when they are used
+	// by a transformation such as derivative().
+	CoerceNumeric bool
+
+	// OrderValues orders the output of top() and bottom() by value
+	// instead of by time.
+	OrderValues bool
+
+	// ColumnsOnly plans SELECT statements and returns the columns of the
+	// result without reading any data.
+	ColumnsOnly bool
+
+	// Interleave merges the series of a SELECT statement into a single
+	// series ordered by time with a column for the tag set of each row.
+	Interleave bool
+
+	// TreatAsNull reads numeric field values equal to this value as null.
+	TreatAsNull *float64
+
+	// LocalTime returns the time column of SELECT statements with a time
+	// zone in UTC and adds a _local_time column with the time in that zone.
+	LocalTime bool
+
+	// Pivot turns each value of this tag into its own column of the
+	// results of a SELECT statement with time as the row key.
+	Pivot string
+
+	// TagsAsJSON adds a _tags column to raw SELECT statements with the
+	// full tag set of each point encoded as a JSON object.
+	TagsAsJSON bool
+
+	// UnionColumns merges the series of each tag set of a SELECT statement
+	// across measurements into a single series with the union of their
+	// columns and a name column.
+	UnionColumns bool
+
+	// EchoRange adds a message with the resolved time range to the results
+	// of each SELECT statement.
+	EchoRange bool
+
+	// OrderByCardinality orders the keys returned by SHOW TAG KEYS by the
+	// number of distinct values of each key, most values first.
+	OrderByCardinality bool
+
+	// HoltWintersBounds adds the upper and lower confidence bounds of each
+	// holt_winters() forecast as columns.
+	HoltWintersBounds bool
+
+	// LimitSeriesFirst applies SLIMIT and SOFFSET while the series are
+	// read from the index so only the selected series are kept in memory.
+	// The series are picked in index order instead of tag set order.
+	LimitSeriesFirst bool
+
+	// LabelRight labels each GROUP BY time() bucket of a SELECT statement
+	// by its end time instead of its start time.
+	LabelRight bool
+
+	// AutoRollup reads SELECT statements over a wide time range from the
+	// rollup measurement configured for each source measurement.
+	AutoRollup bool
+
+	// DropEmptyTags drops the series of a SELECT statement whose GROUP BY
+	// tags all have empty values.
+	DropEmptyTags bool
+
+	// SeriesID adds a _series_id column to the results of SELECT statements
+	// with a hash of the measurement and tag set of each series that stays
+	// the same across queries.
+	SeriesID bool
+
+	// Estimate plans SELECT statements and returns the number of shards and
+	// series they would read, as reported by the index, without reading any
+	// data.
+	Estimate bool
+
+	// MaxRows is the maximum number of rows returned across all series of
+	// a SELECT statement. The result is marked as truncated when rows were
+	// dropped. Zero means no limit.
+	MaxRows int
+
+	// BucketID scopes the query to a bucket when it is valid. The database
+	// and retention policy resolve to this bucket directly instead of
+	// through the DBRP mapping service.
+	BucketID platform.ID
+
+	// Now is the time now() resolves to in the statements of the query.
+	// The time the query starts executing is used when it is zero.
+	Now time.Time
 }
 
 type (
 	iteratorsContextKey struct{}
+	messagesContextKey  struct{}
 )
 
 // NewContextWithIterators returns a new context.Context with the *Iterators slice added.
@@ -138,6 +232,19 @@ func NewContextWithIterators(ctx context.Context, itr *Iterators) context.Contex
 	return context.WithValue(ctx, iteratorsContextKey{}, itr)
 }
 
+// NewContextWithMessages returns a new context.Context with the *[]*Message slice added.
+// The query planner will append non-fatal messages, such as warnings, to the slice.
+func NewContextWithMessages(ctx context.Context, messages *[]*Message) context.Context {
+	return context.WithValue(ctx, messagesContextKey{}, messages)
+}
+
+// addMessage appends a message to the slice in the context, if one exists.
+func addMessage(ctx context.Context, m *Message) {
+	if messages, ok := ctx.Value(messagesContextKey{}).(*[]*Message); ok {
+		*messages = append(*messages, m)
+	}
+}
+
 // StatementExecutor executes a statement within the Executor.
 type StatementExecutor interface {
 	// ExecuteStatement executes a statement. Results should be sent to the
@@ -214,6 +321,9 @@ func (e *Executor) executeQuery(ctx context.Context, query *influxql.Query, opt
 		e.Metrics.ExecutingDuration.WithLabelValues(statusLabel).Observe(dur.Seconds())
 	}(time.Now())
 
+	if opt.Now.IsZero() {
+		opt.Now = time.Now().UTC()
+	}
 	ectx := &ExecutionContext{StatisticsGatherer: gatherer, ExecutionOptions: opt}
 
 	// Setup the execution context that will be used when executing statements.
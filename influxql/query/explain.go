@@ -50,6 +50,47 @@ func (p *preparedStatement) Explain(ctx context.Context) (string, error) {
 	return buf.String(), nil
 }
 
+func (p *preparedStatement) Estimate(ctx context.Context) (IteratorCost, error) {
+	// Only ask for the series of each measurement. Without an expression
+	// the cost is read from the index and no blocks are inspected.
+	opt := p.opt
+	opt.Expr, opt.Aux = nil, nil
+
+	// The cost counts a shard once for every measurement read from it.
+	// Count the distinct shards instead if the shards can be listed.
+	mapping, ok := p.ic.(shardIDer)
+	shards := make(map[uint64]struct{})
+
+	var cost IteratorCost
+	for _, m := range p.stmt.Sources.Measurements() {
+		c, err := p.ic.IteratorCost(ctx, m, opt)
+		if err != nil {
+			return IteratorCost{}, err
+		}
+		cost = cost.Combine(c)
+
+		if ok {
+			ids, err := mapping.ShardIDs(ctx, m)
+			if err != nil {
+				return IteratorCost{}, err
+			}
+			for _, id := range ids {
+				shards[id] = struct{}{}
+			}
+		}
+	}
+	if ok {
+		cost.NumShards = int64(len(shards))
+	}
+	return cost, nil
+}
+
+// shardIDer is implemented by shard mappings that can list the shards
+// that contain a measurement.
+type shardIDer interface {
+	ShardIDs(ctx context.Context, m *influxql.Measurement) ([]uint64, error)
+}
+
 type planNode struct {
 	Expr influxql.Expr
 	Aux  []influxql.VarRef
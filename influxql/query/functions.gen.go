@@ -435,9 +435,30 @@ func (r *FloatDistinctReducer) Emit() []FloatPoint {
 	return points
 }
 
+// FloatDistinctCountReducer returns the distinct points in a series along
+// with the number of distinct points as the first auxiliary value of each.
+type FloatDistinctCountReducer struct {
+	*FloatDistinctReducer
+}
+
+// NewFloatDistinctCountReducer creates a new FloatDistinctCountReducer.
+func NewFloatDistinctCountReducer() *FloatDistinctCountReducer {
+	return &FloatDistinctCountReducer{NewFloatDistinctReducer()}
+}
+
+// Emit emits the distinct points that have been aggregated into the reducer.
+func (r *FloatDistinctCountReducer) Emit() []FloatPoint {
+	points := r.FloatDistinctReducer.Emit()
+	for i := range points {
+		points[i].Aux = []interface{}{int64(len(points))}
+	}
+	return points
+}
+
 // FloatElapsedReducer calculates the elapsed of the aggregated points.
 type FloatElapsedReducer struct {
 	unitConversion int64
+	window         func(t int64) (start, end int64)
 	prev           FloatPoint
 	curr           FloatPoint
 }
@@ -455,6 +476,14 @@ func NewFloatElapsedReducer(interval Interval) *FloatElapsedReducer {
 func (r *FloatElapsedReducer) AggregateFloat(p *FloatPoint) {
 	r.prev = r.curr
 	r.curr = *p
+
+	// Do not measure the gap across a window boundary.
+	if r.window != nil && !r.prev.Nil {
+		prevStart, _ := r.window(r.prev.Time)
+		if currStart, _ := r.window(r.curr.Time); prevStart != currStart {
+			r.prev = FloatPoint{Nil: true}
+		}
+	}
 }
 
 // Emit emits the elapsed of the reducer at the current point.
@@ -478,8 +507,14 @@ type FloatSampleReducer struct {
 
 // NewFloatSampleReducer creates a new FloatSampleReducer
 func NewFloatSampleReducer(size int) *FloatSampleReducer {
+	return NewFloatSampleReducerWithSeed(size, time.Now().UnixNano()) // seed with current time as suggested by https://golang.org/pkg/math/rand/
+}
+
+// NewFloatSampleReducerWithSeed creates a new FloatSampleReducer
+// that makes the same random choices for the same seed.
+func NewFloatSampleReducerWithSeed(size int, seed int64) *FloatSampleReducer {
 	return &FloatSampleReducer{
-		rng:    rand.New(rand.NewSource(time.Now().UnixNano())), // seed with current time as suggested by https://golang.org/pkg/math/rand/
+		rng:    rand.New(rand.NewSource(seed)),
 		points: make(floatPoints, size),
 	}
 }
@@ -932,9 +967,30 @@ func (r *IntegerDistinctReducer) Emit() []IntegerPoint {
 	return points
 }
 
+// IntegerDistinctCountReducer returns the distinct points in a series along
+// with the number of distinct points as the first auxiliary value of each.
+type IntegerDistinctCountReducer struct {
+	*IntegerDistinctReducer
+}
+
+// NewIntegerDistinctCountReducer creates a new IntegerDistinctCountReducer.
+func NewIntegerDistinctCountReducer() *IntegerDistinctCountReducer {
+	return &IntegerDistinctCountReducer{NewIntegerDistinctReducer()}
+}
+
+// Emit emits the distinct points that have been aggregated into the reducer.
+func (r *IntegerDistinctCountReducer) Emit() []IntegerPoint {
+	points := r.IntegerDistinctReducer.Emit()
+	for i := range points {
+		points[i].Aux = []interface{}{int64(len(points))}
+	}
+	return points
+}
+
 // IntegerElapsedReducer calculates the elapsed of the aggregated points.
 type IntegerElapsedReducer struct {
 	unitConversion int64
+	window         func(t int64) (start, end int64)
 	prev           IntegerPoint
 	curr           IntegerPoint
 }
@@ -952,6 +1008,14 @@ func NewIntegerElapsedReducer(interval Interval) *IntegerElapsedReducer {
 func (r *IntegerElapsedReducer) AggregateInteger(p *IntegerPoint) {
 	r.prev = r.curr
 	r.curr = *p
+
+	// Do not measure the gap across a window boundary.
+	if r.window != nil && !r.prev.Nil {
+		prevStart, _ := r.window(r.prev.Time)
+		if currStart, _ := r.window(r.curr.Time); prevStart != currStart {
+			r.prev = IntegerPoint{Nil: true}
+		}
+	}
 }
 
 // Emit emits the elapsed of the reducer at the current point.
@@ -975,8 +1039,14 @@ type IntegerSampleReducer struct {
 
 // NewIntegerSampleReducer creates a new IntegerSampleReducer
 func NewIntegerSampleReducer(size int) *IntegerSampleReducer {
+	return NewIntegerSampleReducerWithSeed(size, time.Now().UnixNano()) // seed with current time as suggested by https://golang.org/pkg/math/rand/
+}
+
+// NewIntegerSampleReducerWithSeed creates a new IntegerSampleReducer
+// that makes the same random choices for the same seed.
+func NewIntegerSampleReducerWithSeed(size int, seed int64) *IntegerSampleReducer {
 	return &IntegerSampleReducer{
-		rng:    rand.New(rand.NewSource(time.Now().UnixNano())), // seed with current time as suggested by https://golang.org/pkg/math/rand/
+		rng:    rand.New(rand.NewSource(seed)),
 		points: make(integerPoints, size),
 	}
 }
@@ -1429,9 +1499,30 @@ func (r *UnsignedDistinctReducer) Emit() []UnsignedPoint {
 	return points
 }
 
+// UnsignedDistinctCountReducer returns the distinct points in a series along
+// with the number of distinct points as the first auxiliary value of each.
+type UnsignedDistinctCountReducer struct {
+	*UnsignedDistinctReducer
+}
+
+// NewUnsignedDistinctCountReducer creates a new UnsignedDistinctCountReducer.
+func NewUnsignedDistinctCountReducer() *UnsignedDistinctCountReducer {
+	return &UnsignedDistinctCountReducer{NewUnsignedDistinctReducer()}
+}
+
+// Emit emits the distinct points that have been aggregated into the reducer.
+func (r *UnsignedDistinctCountReducer) Emit() []UnsignedPoint {
+	points := r.UnsignedDistinctReducer.Emit()
+	for i := range points {
+		points[i].Aux = []interface{}{int64(len(points))}
+	}
+	return points
+}
+
 // UnsignedElapsedReducer calculates the elapsed of the aggregated points.
 type UnsignedElapsedReducer struct {
 	unitConversion int64
+	window         func(t int64) (start, end int64)
 	prev           UnsignedPoint
 	curr           UnsignedPoint
 }
@@ -1449,6 +1540,14 @@ func NewUnsignedElapsedReducer(interval Interval) *UnsignedElapsedReducer {
 func (r *UnsignedElapsedReducer) AggregateUnsigned(p *UnsignedPoint) {
 	r.prev = r.curr
 	r.curr = *p
+
+	// Do not measure the gap across a window boundary.
+	if r.window != nil && !r.prev.Nil {
+		prevStart, _ := r.window(r.prev.Time)
+		if currStart, _ := r.window(r.curr.Time); prevStart != currStart {
+			r.prev = UnsignedPoint{Nil: true}
+		}
+	}
 }
 
 // Emit emits the elapsed of the reducer at the current point.
@@ -1472,8 +1571,14 @@ type UnsignedSampleReducer struct {
 
 // NewUnsignedSampleReducer creates a new UnsignedSampleReducer
 func NewUnsignedSampleReducer(size int) *UnsignedSampleReducer {
+	return NewUnsignedSampleReducerWithSeed(size, time.Now().UnixNano()) // seed with current time as suggested by https://golang.org/pkg/math/rand/
+}
+
+// NewUnsignedSampleReducerWithSeed creates a new UnsignedSampleReducer
+// that makes the same random choices for the same seed.
+func NewUnsignedSampleReducerWithSeed(size int, seed int64) *UnsignedSampleReducer {
 	return &UnsignedSampleReducer{
-		rng:    rand.New(rand.NewSource(time.Now().UnixNano())), // seed with current time as suggested by https://golang.org/pkg/math/rand/
+		rng:    rand.New(rand.NewSource(seed)),
 		points: make(unsignedPoints, size),
 	}
 }
@@ -1924,9 +2029,30 @@ func (r *StringDistinctReducer) Emit() []StringPoint {
 	return points
 }
 
+// StringDistinctCountReducer returns the distinct points in a series along
+// with the number of distinct points as the first auxiliary value of each.
+type StringDistinctCountReducer struct {
+	*StringDistinctReducer
+}
+
+// NewStringDistinctCountReducer creates a new StringDistinctCountReducer.
+func NewStringDistinctCountReducer() *StringDistinctCountReducer {
+	return &StringDistinctCountReducer{NewStringDistinctReducer()}
+}
+
+// Emit emits the distinct points that have been aggregated into the reducer.
+func (r *StringDistinctCountReducer) Emit() []StringPoint {
+	points := r.StringDistinctReducer.Emit()
+	for i := range points {
+		points[i].Aux = []interface{}{int64(len(points))}
+	}
+	return points
+}
+
 // StringElapsedReducer calculates the elapsed of the aggregated points.
 type StringElapsedReducer struct {
 	unitConversion int64
+	window         func(t int64) (start, end int64)
 	prev           StringPoint
 	curr           StringPoint
 }
@@ -1944,6 +2070,14 @@ func NewStringElapsedReducer(interval Interval) *StringElapsedReducer {
 func (r *StringElapsedReducer) AggregateString(p *StringPoint) {
 	r.prev = r.curr
 	r.curr = *p
+
+	// Do not measure the gap across a window boundary.
+	if r.window != nil && !r.prev.Nil {
+		prevStart, _ := r.window(r.prev.Time)
+		if currStart, _ := r.window(r.curr.Time); prevStart != currStart {
+			r.prev = StringPoint{Nil: true}
+		}
+	}
 }
 
 // Emit emits the elapsed of the reducer at the current point.
@@ -1967,8 +2101,14 @@ type StringSampleReducer struct {
 
 // NewStringSampleReducer creates a new StringSampleReducer
 func NewStringSampleReducer(size int) *StringSampleReducer {
+	return NewStringSampleReducerWithSeed(size, time.Now().UnixNano()) // seed with current time as suggested by https://golang.org/pkg/math/rand/
+}
+
+// NewStringSampleReducerWithSeed creates a new StringSampleReducer
+// that makes the same random choices for the same seed.
+func NewStringSampleReducerWithSeed(size int, seed int64) *StringSampleReducer {
 	return &StringSampleReducer{
-		rng:    rand.New(rand.NewSource(time.Now().UnixNano())), // seed with current time as suggested by https://golang.org/pkg/math/rand/
+		rng:    rand.New(rand.NewSource(seed)),
 		points: make(stringPoints, size),
 	}
 }
@@ -2421,9 +2561,30 @@ func (r *BooleanDistinctReducer) Emit() []BooleanPoint {
 	return points
 }
 
+// BooleanDistinctCountReducer returns the distinct points in a series along
+// with the number of distinct points as the first auxiliary value of each.
+type BooleanDistinctCountReducer struct {
+	*BooleanDistinctReducer
+}
+
+// NewBooleanDistinctCountReducer creates a new BooleanDistinctCountReducer.
+func NewBooleanDistinctCountReducer() *BooleanDistinctCountReducer {
+	return &BooleanDistinctCountReducer{NewBooleanDistinctReducer()}
+}
+
+// Emit emits the distinct points that have been aggregated into the reducer.
+func (r *BooleanDistinctCountReducer) Emit() []BooleanPoint {
+	points := r.BooleanDistinctReducer.Emit()
+	for i := range points {
+		points[i].Aux = []interface{}{int64(len(points))}
+	}
+	return points
+}
+
 // BooleanElapsedReducer calculates the elapsed of the aggregated points.
 type BooleanElapsedReducer struct {
 	unitConversion int64
+	window         func(t int64) (start, end int64)
 	prev           BooleanPoint
 	curr           BooleanPoint
 }
@@ -2441,6 +2602,14 @@ func NewBooleanElapsedReducer(interval Interval) *BooleanElapsedReducer {
 func (r *BooleanElapsedReducer) AggregateBoolean(p *BooleanPoint) {
 	r.prev = r.curr
 	r.curr = *p
+
+	// Do not measure the gap across a window boundary.
+	if r.window != nil && !r.prev.Nil {
+		prevStart, _ := r.window(r.prev.Time)
+		if currStart, _ := r.window(r.curr.Time); prevStart != currStart {
+			r.prev = BooleanPoint{Nil: true}
+		}
+	}
 }
 
 // Emit emits the elapsed of the reducer at the current point.
@@ -2464,8 +2633,14 @@ type BooleanSampleReducer struct {
 
 // NewBooleanSampleReducer creates a new BooleanSampleReducer
 func NewBooleanSampleReducer(size int) *BooleanSampleReducer {
+	return NewBooleanSampleReducerWithSeed(size, time.Now().UnixNano()) // seed with current time as suggested by https://golang.org/pkg/math/rand/
+}
+
+// NewBooleanSampleReducerWithSeed creates a new BooleanSampleReducer
+// that makes the same random choices for the same seed.
+func NewBooleanSampleReducerWithSeed(size int, seed int64) *BooleanSampleReducer {
 	return &BooleanSampleReducer{
-		rng:    rand.New(rand.NewSource(time.Now().UnixNano())), // seed with current time as suggested by https://golang.org/pkg/math/rand/
+		rng:    rand.New(rand.NewSource(seed)),
 		points: make(booleanPoints, size),
 	}
 }
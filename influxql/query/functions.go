@@ -79,9 +79,10 @@ func (m FunctionTypeMapper) CallType(name string, args []influxql.DataType) (inf
 
 	// Handle functions implemented by the query engine.
 	switch name {
-	case "median", "integral", "stddev",
+	case "median", "integral", "stddev", "stddev_pop", "rate", "count_rate", "trimmed_mean",
+		"mean_over_time", "stddev_over_time",
 		"derivative", "non_negative_derivative",
-		"moving_average",
+		"moving_average", "percent_of_total",
 		"exponential_moving_average",
 		"double_exponential_moving_average",
 		"triple_exponential_moving_average",
@@ -90,19 +91,48 @@ func (m FunctionTypeMapper) CallType(name string, args []influxql.DataType) (inf
 		"kaufmans_efficiency_ratio",
 		"kaufmans_adaptive_moving_average",
 		"chande_momentum_oscillator",
-		"holt_winters", "holt_winters_with_fit":
+		"holt_winters", "holt_winters_with_fit", "holt_winters_upper", "holt_winters_lower":
 		return influxql.Float, nil
-	case "elapsed":
+	case "elapsed", "resets":
 		return influxql.Integer, nil
+	case "precision":
+		return influxql.String, nil
 	default:
 		// TODO(jsternberg): Do not use default for this.
 		return args[0], nil
 	}
 }
 
+// compensatedSum accumulates float64 values using Neumaier's variant of
+// Kahan summation so that small values are not lost when they are added
+// to a much larger running total.
+type compensatedSum struct {
+	sum float64
+	c   float64
+}
+
+// add adds v to the sum.
+func (s *compensatedSum) add(v float64) {
+	t := s.sum + v
+	if math.Abs(s.sum) >= math.Abs(v) {
+		s.c += (s.sum - t) + v
+	} else {
+		s.c += (v - t) + s.sum
+	}
+	s.sum = t
+}
+
+// value returns the compensated sum.
+func (s *compensatedSum) value() float64 {
+	if math.IsInf(s.sum, 0) || math.IsNaN(s.sum) {
+		return s.sum
+	}
+	return s.sum + s.c
+}
+
 // FloatMeanReducer calculates the mean of the aggregated points.
 type FloatMeanReducer struct {
-	sum   float64
+	sum   compensatedSum
 	count uint32
 }
 
@@ -114,10 +144,10 @@ func NewFloatMeanReducer() *FloatMeanReducer {
 // AggregateFloat aggregates a point into the reducer.
 func (r *FloatMeanReducer) AggregateFloat(p *FloatPoint) {
 	if p.Aggregated >= 2 {
-		r.sum += p.Value * float64(p.Aggregated)
+		r.sum.add(p.Value * float64(p.Aggregated))
 		r.count += p.Aggregated
 	} else {
-		r.sum += p.Value
+		r.sum.add(p.Value)
 		r.count++
 	}
 }
@@ -126,7 +156,7 @@ func (r *FloatMeanReducer) AggregateFloat(p *FloatPoint) {
 func (r *FloatMeanReducer) Emit() []FloatPoint {
 	return []FloatPoint{{
 		Time:       ZeroTime,
-		Value:      r.sum / float64(r.count),
+		Value:      r.sum.value() / float64(r.count),
 		Aggregated: r.count,
 	}}
 }
@@ -1230,6 +1260,10 @@ type FloatHoltWintersReducer struct {
 	// Whether to include all data or only future values
 	includeFitData bool
 
+	// Number of residual standard deviations added to each value. It is
+	// non-zero for the confidence bounds of the forecast.
+	z float64
+
 	// NelderMead optimizer
 	optim *neldermead.Optimizer
 	// Small difference bound for the optimizer
@@ -1253,6 +1287,9 @@ const (
 	hwGuessUpper = 1.0
 	// The step between guesses
 	hwGuessStep = 0.4
+	// Number of standard deviations of the confidence bounds, which
+	// gives a 95% interval for normally distributed residuals.
+	hwConfidenceZ = 1.96
 )
 
 // NewFloatHoltWintersReducer creates a new FloatHoltWintersReducer.
@@ -1406,6 +1443,9 @@ func (r *FloatHoltWintersReducer) Emit() []FloatPoint {
 
 	// Forecast
 	forecasted := r.forecast(r.h, bestParams)
+	if r.z != 0 {
+		r.addBounds(forecasted, minSSE)
+	}
 	var points []FloatPoint
 	if r.includeFitData {
 		start := r.points[0].Time
@@ -1437,6 +1477,26 @@ func (r *FloatHoltWintersReducer) Emit() []FloatPoint {
 	return points
 }
 
+// addBounds offsets the forecasted values by z times the standard deviation
+// of the fit residuals. The deviation grows with the square root of the
+// number of steps into the future.
+func (r *FloatHoltWintersReducer) addBounds(forecasted []float64, sse float64) {
+	n := 0
+	for _, v := range r.y {
+		if !math.IsNaN(v) {
+			n++
+		}
+	}
+	dev := math.Sqrt(sse / float64(n))
+	for i := range forecasted {
+		steps := i - len(r.y) + 1
+		if steps < 1 {
+			steps = 1
+		}
+		forecasted[i] += r.z * dev * math.Sqrt(float64(steps))
+	}
+}
+
 // Using the recursive relations compute the next values
 func (r *FloatHoltWintersReducer) next(alpha, beta, gamma, phi, phiH, yT, lTp, bTp, sTm, sTmh float64) (yTh, lT, bT, sT float64) {
 	lT = alpha*(yT/sTm) + (1-alpha)*(lTp+phi*bTp)
@@ -1619,6 +1679,7 @@ func (r *FloatIntegralReducer) AggregateFloat(p *FloatPoint) {
 		}
 
 		// Emit the current point through the channel and then clear it.
+		end := r.window.end
 		r.ch <- FloatPoint{Time: r.window.start, Value: r.sum}
 		if r.opt.Ascending {
 			r.window.start, r.window.end = r.opt.Window(p.Time)
@@ -1626,6 +1687,13 @@ func (r *FloatIntegralReducer) AggregateFloat(p *FloatPoint) {
 			r.window.end, r.window.start = r.opt.Window(p.Time)
 		}
 		r.sum = 0.0
+
+		// If there are empty intervals between the previous point and this
+		// one, their area is not added to the interval of this point.
+		if r.window.start != end {
+			r.prev = *p
+			return
+		}
 	}
 
 	// Normal operation: update the sum using the trapezium rule
@@ -1653,10 +1721,9 @@ func (r *FloatIntegralReducer) Emit() []FloatPoint {
 // Close flushes any in progress points to ensure any remaining points are
 // emitted.
 func (r *FloatIntegralReducer) Close() error {
-	// If our last point is at the start time, then discard this point since
-	// there is no area within this bucket. Otherwise, send off what we
-	// currently have as the final point.
-	if !r.prev.Nil && r.prev.Time != r.window.start {
+	// Send off what we currently have as the final point. An interval with
+	// a single point has no area and returns zero.
+	if !r.prev.Nil {
 		r.ch <- FloatPoint{Time: r.window.start, Value: r.sum}
 	}
 	close(r.ch)
@@ -1712,7 +1779,7 @@ func (r *IntegerIntegralReducer) AggregateInteger(p *IntegerPoint) {
 	// If this point has the same timestamp as the previous one,
 	// skip the point. Points sent into this reducer are expected
 	// to be fed in order.
-	value := float64(p.Value)
+	value, prev := float64(p.Value), float64(r.prev.Value)
 	if r.prev.Time == p.Time {
 		r.prev = *p
 		return
@@ -1720,14 +1787,16 @@ func (r *IntegerIntegralReducer) AggregateInteger(p *IntegerPoint) {
 		// If our previous time is not equal to the window, we need to
 		// interpolate the area at the end of this interval.
 		if r.prev.Time != r.window.end {
-			value = linearFloat(r.window.end, r.prev.Time, p.Time, float64(r.prev.Value), value)
+			end := linearFloat(r.window.end, r.prev.Time, p.Time, prev, value)
 			elapsed := float64(r.window.end-r.prev.Time) / float64(r.interval.Duration)
-			r.sum += 0.5 * (value + float64(r.prev.Value)) * elapsed
+			r.sum += 0.5 * (end + prev) * elapsed
 
+			prev = end
 			r.prev.Time = r.window.end
 		}
 
 		// Emit the current point through the channel and then clear it.
+		end := r.window.end
 		r.ch <- FloatPoint{Time: r.window.start, Value: r.sum}
 		if r.opt.Ascending {
 			r.window.start, r.window.end = r.opt.Window(p.Time)
@@ -1735,11 +1804,18 @@ func (r *IntegerIntegralReducer) AggregateInteger(p *IntegerPoint) {
 			r.window.end, r.window.start = r.opt.Window(p.Time)
 		}
 		r.sum = 0.0
+
+		// If there are empty intervals between the previous point and this
+		// one, their area is not added to the interval of this point.
+		if r.window.start != end {
+			r.prev = *p
+			return
+		}
 	}
 
 	// Normal operation: update the sum using the trapezium rule
 	elapsed := float64(p.Time-r.prev.Time) / float64(r.interval.Duration)
-	r.sum += 0.5 * (value + float64(r.prev.Value)) * elapsed
+	r.sum += 0.5 * (value + prev) * elapsed
 	r.prev = *p
 }
 
@@ -1762,10 +1838,9 @@ func (r *IntegerIntegralReducer) Emit() []FloatPoint {
 // Close flushes any in progress points to ensure any remaining points are
 // emitted.
 func (r *IntegerIntegralReducer) Close() error {
-	// If our last point is at the start time, then discard this point since
-	// there is no area within this bucket. Otherwise, send off what we
-	// currently have as the final point.
-	if !r.prev.Nil && r.prev.Time != r.window.start {
+	// Send off what we currently have as the final point. An interval with
+	// a single point has no area and returns zero.
+	if !r.prev.Nil {
 		r.ch <- FloatPoint{Time: r.window.start, Value: r.sum}
 	}
 	close(r.ch)
@@ -1821,7 +1896,7 @@ func (r *UnsignedIntegralReducer) AggregateUnsigned(p *UnsignedPoint) {
 	// If this point has the same timestamp as the previous one,
 	// skip the point. Points sent into this reducer are expected
 	// to be fed in order.
-	value := float64(p.Value)
+	value, prev := float64(p.Value), float64(r.prev.Value)
 	if r.prev.Time == p.Time {
 		r.prev = *p
 		return
@@ -1829,14 +1904,16 @@ func (r *UnsignedIntegralReducer) AggregateUnsigned(p *UnsignedPoint) {
 		// If our previous time is not equal to the window, we need to
 		// interpolate the area at the end of this interval.
 		if r.prev.Time != r.window.end {
-			value = linearFloat(r.window.end, r.prev.Time, p.Time, float64(r.prev.Value), value)
+			end := linearFloat(r.window.end, r.prev.Time, p.Time, prev, value)
 			elapsed := float64(r.window.end-r.prev.Time) / float64(r.interval.Duration)
-			r.sum += 0.5 * (value + float64(r.prev.Value)) * elapsed
+			r.sum += 0.5 * (end + prev) * elapsed
 
+			prev = end
 			r.prev.Time = r.window.end
 		}
 
 		// Emit the current point through the channel and then clear it.
+		end := r.window.end
 		r.ch <- FloatPoint{Time: r.window.start, Value: r.sum}
 		if r.opt.Ascending {
 			r.window.start, r.window.end = r.opt.Window(p.Time)
@@ -1844,11 +1921,18 @@ func (r *UnsignedIntegralReducer) AggregateUnsigned(p *UnsignedPoint) {
 			r.window.end, r.window.start = r.opt.Window(p.Time)
 		}
 		r.sum = 0.0
+
+		// If there are empty intervals between the previous point and this
+		// one, their area is not added to the interval of this point.
+		if r.window.start != end {
+			r.prev = *p
+			return
+		}
 	}
 
 	// Normal operation: update the sum using the trapezium rule
 	elapsed := float64(p.Time-r.prev.Time) / float64(r.interval.Duration)
-	r.sum += 0.5 * (value + float64(r.prev.Value)) * elapsed
+	r.sum += 0.5 * (value + prev) * elapsed
 	r.prev = *p
 }
 
@@ -1871,10 +1955,9 @@ func (r *UnsignedIntegralReducer) Emit() []FloatPoint {
 // Close flushes any in progress points to ensure any remaining points are
 // emitted.
 func (r *UnsignedIntegralReducer) Close() error {
-	// If our last point is at the start time, then discard this point since
-	// there is no area within this bucket. Otherwise, send off what we
-	// currently have as the final point.
-	if !r.prev.Nil && r.prev.Time != r.window.start {
+	// Send off what we currently have as the final point. An interval with
+	// a single point has no area and returns zero.
+	if !r.prev.Nil {
 		r.ch <- FloatPoint{Time: r.window.start, Value: r.sum}
 	}
 	close(r.ch)
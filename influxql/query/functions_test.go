@@ -419,6 +419,32 @@ func TestHoltWinters_MaxTime(t *testing.T) {
 	}
 }
 
+// TestMean_PrecisionLoss verifies that small values are not lost when they
+// are added to a running total that is many orders of magnitude larger.
+func TestMean_PrecisionLoss(t *testing.T) {
+	r := query.NewFloatMeanReducer()
+	for _, v := range []float64{1e16, 1, 1, 1, 1, -1e16} {
+		r.AggregateFloat(&query.FloatPoint{Value: v})
+	}
+
+	points := r.Emit()
+	if exp, got := 4.0/6.0, points[0].Value; got != exp {
+		t.Fatalf("unexpected mean: got %v exp %v", got, exp)
+	}
+}
+
+func TestStddev_PrecisionLoss(t *testing.T) {
+	var ps []query.FloatPoint
+	for _, v := range []float64{4, 7, 13, 16} {
+		ps = append(ps, query.FloatPoint{Value: 1e9 + v})
+	}
+
+	points := query.FloatStddevReduceSlice(ps)
+	if exp, got := math.Sqrt(30), points[0].Value; got != exp {
+		t.Fatalf("unexpected stddev: got %v exp %v", got, exp)
+	}
+}
+
 // TestSample_AllSamplesSeen attempts to verify that it is possible
 // to get every subsample in a reasonable number of iterations.
 //
@@ -485,6 +511,25 @@ func TestSample_AllSamplesSeen(t *testing.T) {
 	}
 }
 
+func TestSample_SameSeed(t *testing.T) {
+	var ps []query.FloatPoint
+	for i := int64(0); i < 100; i++ {
+		ps = append(ps, query.FloatPoint{Time: i, Value: float64(i)})
+	}
+
+	sample := func() []query.FloatPoint {
+		s := query.NewFloatSampleReducerWithSeed(5, 42)
+		for _, p := range ps {
+			s.AggregateFloat(&p)
+		}
+		return s.Emit()
+	}
+
+	if a, b := sample(), sample(); !deep.Equal(a, b) {
+		t.Fatalf("expected the same sample for the same seed: %s != %s", spew.Sdump(a), spew.Sdump(b))
+	}
+}
+
 func TestSample_SampleSizeLessThanNumPoints(t *testing.T) {
 	s := query.NewFloatSampleReducer(2)
 
@@ -0,0 +1,78 @@
+package query
+
+import (
+	"strings"
+)
+
+// rewriteInLists expands every `ref IN (v1, v2, ...)` predicate of a query
+// into `(ref = v1 OR ref = v2 ...)` so it can be parsed by influxql, which
+// has no IN operator for expressions. The equalities are then matched
+// against the index like any other tag condition. An empty list is
+// rewritten to false. The rest of the query text is left untouched.
+func rewriteInLists(q string) *queryRewriter {
+	tokens := scanQueryTokens(q)
+
+	r := &queryRewriter{q: q}
+	for i := 0; i < len(tokens); i++ {
+		refEnd, end, vals, ok := matchInList(q, tokens, i)
+		if !ok {
+			continue
+		}
+
+		ref := q[tokens[i].start:refEnd]
+		var buf strings.Builder
+		if len(vals) == 0 {
+			buf.WriteString("false")
+		} else {
+			buf.WriteString("(")
+			for j, v := range vals {
+				if j > 0 {
+					buf.WriteString(" OR ")
+				}
+				buf.WriteString(ref)
+				buf.WriteString(" = ")
+				buf.WriteString(v)
+			}
+			buf.WriteString(")")
+		}
+		r.replace(tokens[i].start, tokens[end].end, buf.String())
+		i = end
+	}
+	return r
+}
+
+// matchInList reports whether an IN list starts at the reference in
+// tokens[i]. It returns the offset after the reference, the index of the
+// closing parenthesis and the text of each value in the list.
+func matchInList(q string, tokens []queryToken, i int) (refEnd, end int, vals []string, ok bool) {
+	if !tokens[i].isRef(q) {
+		return 0, 0, nil, false
+	}
+
+	// Skip over a type cast such as host::tag.
+	j := i + 1
+	if j+2 < len(tokens) && tokens[j].is(':') && tokens[j+1].is(':') && tokens[j+2].kind == queryIdent {
+		j += 3
+	}
+	if j+1 >= len(tokens) || !tokens[j].isKeyword(q, "IN") || !tokens[j+1].is('(') {
+		return 0, 0, nil, false
+	}
+	refEnd = tokens[j-1].end
+
+	for k := j + 2; k < len(tokens); k += 2 {
+		if len(vals) == 0 && tokens[k].is(')') {
+			return refEnd, k, vals, true
+		}
+		if !tokens[k].isLiteral(q) || k+1 >= len(tokens) {
+			return 0, 0, nil, false
+		}
+		vals = append(vals, q[tokens[k].start:tokens[k].end])
+
+		if tokens[k+1].is(')') {
+			return refEnd, k + 1, vals, true
+		} else if !tokens[k+1].is(',') {
+			return 0, 0, nil, false
+		}
+	}
+	return 0, 0, nil, false
+}
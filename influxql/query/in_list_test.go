@@ -0,0 +1,82 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteInLists(t *testing.T) {
+	for _, tt := range []struct {
+		q   string
+		exp string
+	}{
+		{
+			q:   `SELECT value FROM cpu WHERE host IN ('server01', 'server02')`,
+			exp: `SELECT value FROM cpu WHERE (host = 'server01' OR host = 'server02')`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE "host"::tag in ('server01') AND time > now() - 1h`,
+			exp: `SELECT value FROM cpu WHERE ("host"::tag = 'server01') AND time > now() - 1h`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE host IN () OR region IN ('uswest',$region)`,
+			exp: `SELECT value FROM cpu WHERE false OR (region = 'uswest' OR region = $region)`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE core IN (1, 2.5, true)`,
+			exp: `SELECT value FROM cpu WHERE (core = 1 OR core = 2.5 OR core = true)`,
+		},
+		{
+			q:   `SELECT value / 2 FROM /cpu/ WHERE host =~ /a IN ('b')/ AND msg = 'c IN (\'d\')' AND host IN ('e')`,
+			exp: `SELECT value / 2 FROM /cpu/ WHERE host =~ /a IN ('b')/ AND msg = 'c IN (\'d\')' AND (host = 'e')`,
+		},
+		{
+			q:   `SHOW TAG VALUES WITH KEY IN ("host", "region")`,
+			exp: `SHOW TAG VALUES WITH KEY IN ("host", "region")`,
+		},
+		{
+			q:   `SELECT value FROM cpu WHERE host IN (region)`,
+			exp: `SELECT value FROM cpu WHERE host IN (region)`,
+		},
+	} {
+		t.Run(tt.q, func(t *testing.T) {
+			require.Equal(t, tt.exp, rewriteInLists(tt.q).String())
+		})
+	}
+}
+
+func TestRewriteInLists_Parse(t *testing.T) {
+	q := `SELECT value FROM cpu WHERE host IN ('server01', 'server02') AND value > 1`
+	stmt, err := influxql.ParseStatement(rewriteInLists(q).String())
+	require.NoError(t, err)
+	require.Equal(t, `SELECT value FROM cpu WHERE (host = 'server01' OR host = 'server02') AND value > 1`, stmt.String())
+}
+
+func TestParseQuery_InListParseError(t *testing.T) {
+	q := "SELECT value FROM cpu WHERE host IN ('server01', 'server02') AND region IN ('uswest') AND AND\nGROUP BY time(1mo)"
+	_, err := ParseQuery(q, nil)
+	require.EqualError(t, err, `found AND, expected identifier, string, number, bool at line 1, char 91`)
+
+	// The position refers to the text of the query as it was written.
+	perr, ok := err.(*influxql.ParseError)
+	require.True(t, ok)
+	require.Equal(t, influxql.Pos{Line: 0, Char: 90}, perr.Pos)
+	require.Equal(t, "AND", q[90:93])
+}
+
+func TestParseQuery_RewrittenParseError(t *testing.T) {
+	// The error follows every kind of rewritten text.
+	q := "SELECT count(value) FROM cpu WHERE host IN ('server01')\nGROUP BY time(1mo) TZ('+05:30') LIMIT 1"
+	_, err := ParseQuery(q, nil)
+	perr, ok := err.(*influxql.ParseError)
+	require.True(t, ok)
+	require.Equal(t, influxql.Pos{Line: 1, Char: 32}, perr.Pos)
+
+	// The end of the query is at the same position as in a query of the
+	// same length that needs no rewriting.
+	_, err = ParseQuery("SELECT value FROM cpu WHERE host IN ('server01') AND", nil)
+	_, exp := influxql.ParseQuery("SELECT value FROM cpu WHERE host = 'server01'    AND")
+	require.EqualError(t, err, exp.Error())
+}
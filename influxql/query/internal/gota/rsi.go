@@ -5,11 +5,12 @@ type RSI struct {
 	emaUp   EMA
 	emaDown EMA
 	lastV   float64
+	seeded  bool
 }
 
 // NewRSI constructs a new RSI.
 func NewRSI(inTimePeriod int, warmType WarmupType) *RSI {
-	ema := NewEMA(inTimePeriod+1, warmType)
+	ema := NewEMA(inTimePeriod, warmType)
 	ema.alpha = float64(1) / float64(inTimePeriod)
 	return &RSI{
 		emaUp:   *ema,
@@ -19,7 +20,9 @@ func NewRSI(inTimePeriod int, warmType WarmupType) *RSI {
 
 // WarmCount returns the number of samples that must be provided for the algorithm to be fully "warmed".
 func (rsi RSI) WarmCount() int {
-	return rsi.emaUp.WarmCount()
+	// The first sample only seeds the previous value, so one extra sample is
+	// needed before the averages hold inTimePeriod deltas.
+	return rsi.emaUp.WarmCount() + 1
 }
 
 // Warmed indicates whether the algorithm has enough data to generate accurate results.
@@ -28,12 +31,27 @@ func (rsi RSI) Warmed() bool {
 }
 
 // Last returns the last output value.
+//
+// A series that only moves up yields 100, one that only moves down yields 0,
+// and a flat series (no movement in either direction) yields 50.
 func (rsi RSI) Last() float64 {
-	return 100 - (100 / (1 + rsi.emaUp.Last()/rsi.emaDown.Last()))
+	up, down := rsi.emaUp.Last(), rsi.emaDown.Last()
+	if up == 0 && down == 0 {
+		return 50
+	} else if down == 0 {
+		return 100
+	}
+	return 100 - (100 / (1 + up/down))
 }
 
 // Add adds a new sample value to the algorithm and returns the computed value.
 func (rsi *RSI) Add(v float64) float64 {
+	if !rsi.seeded {
+		rsi.lastV = v
+		rsi.seeded = true
+		return rsi.Last()
+	}
+
 	var up float64
 	var down float64
 	if v > rsi.lastV {
@@ -21,3 +21,27 @@ func TestRSI(t *testing.T) {
 		t.Errorf("unexpected floats:\n%s", diff)
 	}
 }
+
+func TestRSI_Bounds(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		list []float64
+		exp  float64
+	}{
+		{name: "Up", list: []float64{100, 110, 120, 130, 140}, exp: 100},
+		{name: "Down", list: []float64{100, 90, 80, 70, 60}, exp: 0},
+		{name: "Flat", list: []float64{5, 5, 5, 5, 5}, exp: 50},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rsi := NewRSI(3, WarmSMA)
+			for _, v := range tt.list {
+				if vOut := rsi.Add(v); rsi.Warmed() && vOut != tt.exp {
+					t.Errorf("unexpected value: got=%v exp=%v", vOut, tt.exp)
+				}
+			}
+			if !rsi.Warmed() {
+				t.Error("expected rsi to be warmed")
+			}
+		})
+	}
+}
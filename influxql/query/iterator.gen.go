@@ -318,22 +318,34 @@ type floatSortedMergeIterator struct {
 	inputs []FloatIterator
 	heap   *floatSortedMergeHeap
 	init   bool
+
+	// The points left to return from the last name, dimensions and time
+	// read. Only used when deduplicating points across inputs.
+	buf []*FloatPoint
 }
 
 // newFloatSortedMergeIterator returns an instance of floatSortedMergeIterator.
 func newFloatSortedMergeIterator(inputs []FloatIterator, opt IteratorOptions) Iterator {
+	return newFloatSortedMergeDedupeIterator(inputs, opt, false)
+}
+
+// newFloatSortedMergeDedupeIterator returns an instance of floatSortedMergeIterator.
+// If dedupe is set, points that are equal to a point already emitted from a
+// later input are dropped.
+func newFloatSortedMergeDedupeIterator(inputs []FloatIterator, opt IteratorOptions, dedupe bool) Iterator {
 	itr := &floatSortedMergeIterator{
 		inputs: inputs,
 		heap: &floatSortedMergeHeap{
-			items: make([]*floatSortedMergeHeapItem, 0, len(inputs)),
-			opt:   opt,
+			items:  make([]*floatSortedMergeHeapItem, 0, len(inputs)),
+			opt:    opt,
+			dedupe: dedupe,
 		},
 	}
 
 	// Initialize heap items.
-	for _, input := range inputs {
+	for i, input := range inputs {
 		// Append to the heap.
-		itr.heap.items = append(itr.heap.items, &floatSortedMergeHeapItem{itr: input})
+		itr.heap.items = append(itr.heap.items, &floatSortedMergeHeapItem{itr: input, index: i})
 	}
 
 	return itr
@@ -379,26 +391,78 @@ func (itr *floatSortedMergeIterator) pop() (*FloatPoint, error) {
 		itr.init = true
 	}
 
+	if itr.heap.dedupe {
+		return itr.popDedupe()
+	}
+	p, _, err := itr.read()
+	return p, err
+}
+
+// read returns the next point from the heap and the index of its input.
+// Reads the next point from item's cursor and puts it back on the heap.
+func (itr *floatSortedMergeIterator) read() (*FloatPoint, int, error) {
 	if len(itr.heap.items) == 0 {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Read the next item from the heap.
 	item := heap.Pop(itr.heap).(*floatSortedMergeHeapItem)
 	if item.err != nil {
-		return nil, item.err
+		return nil, 0, item.err
 	} else if item.point == nil {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Copy the point for return.
 	p := item.point.Clone()
+	index := item.index
 
 	// Read the next item from the cursor. Push back to heap if one exists.
 	if item.point, item.err = item.itr.Next(); item.point != nil {
 		heap.Push(itr.heap, item)
 	}
+	return p, index, nil
+}
+
+// popDedupe returns the next point from the heap. A series read from more
+// than one input at the same time is only returned once, from the last input.
+func (itr *floatSortedMergeIterator) popDedupe() (*FloatPoint, error) {
+	if len(itr.buf) == 0 {
+		p, index, err := itr.read()
+		if p == nil || err != nil {
+			return nil, err
+		}
+
+		// The points with the same name, dimensions and time are next on the
+		// heap, but the points of a series are not always next to each other.
+		// Keep the point of each series from the last input in heap order.
+		seen := map[string]int{p.Tags.ID(): 0}
+		indexes := []int{index}
+		itr.buf = append(itr.buf, p)
+		for len(itr.heap.items) > 0 && itr.heap.sameTime(itr.heap.items[0].point, p) {
+			next, index, err := itr.read()
+			if err != nil {
+				return nil, err
+			}
+
+			if i, ok := seen[next.Tags.ID()]; !ok {
+				seen[next.Tags.ID()] = len(itr.buf)
+				itr.buf, indexes = append(itr.buf, next), append(indexes, index)
+			} else if index > indexes[i] {
+				itr.buf[i], indexes[i] = next, index
+			}
+		}
+
+		// Only the dimensions are returned once the series are told apart.
+		if itr.heap.opt.SeriesTags {
+			for _, p := range itr.buf {
+				p.Tags = p.Tags.Subset(itr.heap.opt.Dimensions)
+			}
+		}
+	}
 
+	p := itr.buf[0]
+	itr.buf = itr.buf[1:]
 	return p, nil
 }
 
@@ -410,15 +474,27 @@ func (itr *floatSortedMergeIterator) pop() (*FloatPoint, error) {
 //     - By their Aux field values.
 //
 type floatSortedMergeHeap struct {
-	opt   IteratorOptions
-	items []*floatSortedMergeHeapItem
+	opt    IteratorOptions
+	items  []*floatSortedMergeHeapItem
+	dedupe bool
 }
 
 func (h *floatSortedMergeHeap) Len() int      { return len(h.items) }
 func (h *floatSortedMergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
 func (h *floatSortedMergeHeap) Less(i, j int) bool {
-	x, y := h.items[i].point, h.items[j].point
+	return h.less(h.items[i].point, h.items[j].point)
+}
+
+// sameTime returns true if both points have the same name, dimensions and time.
+func (h *floatSortedMergeHeap) sameTime(x, y *FloatPoint) bool {
+	if x.Name != y.Name || x.Time != y.Time {
+		return false
+	}
+	xTags, yTags := x.Tags.Subset(h.opt.Dimensions), y.Tags.Subset(h.opt.Dimensions)
+	return xTags.Equals(&yTags)
+}
 
+func (h *floatSortedMergeHeap) less(x, y *FloatPoint) bool {
 	if h.opt.Ascending {
 		if x.Name != y.Name {
 			return x.Name < y.Name
@@ -490,6 +566,7 @@ type floatSortedMergeHeapItem struct {
 	point *FloatPoint
 	err   error
 	itr   FloatIterator
+	index int
 }
 
 // floatIteratorScanner scans the results of a FloatIterator into a map.
@@ -724,7 +801,7 @@ type floatFillIterator struct {
 
 func newFloatFillIterator(input FloatIterator, expr influxql.Expr, opt IteratorOptions) *floatFillIterator {
 	if opt.Fill == influxql.NullFill {
-		if expr, ok := expr.(*influxql.Call); ok && expr.Name == "count" {
+		if expr, ok := expr.(*influxql.Call); ok && (expr.Name == "count" || expr.Name == "count_rate") {
 			opt.Fill = influxql.NumberFill
 			opt.FillValue = float64(0)
 		}
@@ -813,7 +890,7 @@ func (itr *floatFillIterator) Next() (*FloatPoint, error) {
 
 	// Check if the point is our next expected point.
 CONSTRUCT:
-	if p == nil || (itr.opt.Ascending && p.Time > itr.window.time) || (!itr.opt.Ascending && p.Time < itr.window.time) {
+	if p == nil || (itr.opt.Ascending && itr.windowTime(p) > itr.window.time) || (!itr.opt.Ascending && itr.windowTime(p) < itr.window.time) {
 		if p != nil {
 			itr.input.unread(p)
 		}
@@ -853,6 +930,28 @@ CONSTRUCT:
 			} else {
 				p.Nil = true
 			}
+		case NextFill:
+			next, err := itr.input.peek()
+			if err != nil {
+				return nil, err
+			} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
+				p.Value = next.Value
+				p.Nil = next.Nil
+			} else {
+				p.Nil = true
+			}
+		case ExprFill:
+			if !itr.prev.Nil {
+				if v, ok := castToFloat(evalFillExpr(itr.opt.FillValue, itr.prev.Value)); ok {
+					p.Value = v
+					// The next empty interval is filled from this one.
+					itr.prev = *p
+				} else {
+					p.Nil = true
+				}
+			} else {
+				p.Nil = true
+			}
 		}
 	} else {
 		itr.prev = *p
@@ -861,7 +960,16 @@ CONSTRUCT:
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
 	// window.
-	if itr.opt.Ascending {
+	if itr.opt.Interval.Months > 0 {
+		// Calendar intervals vary in length and their windows already
+		// account for the zone offset.
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+		return p, nil
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
@@ -881,6 +989,16 @@ CONSTRUCT:
 	return p, nil
 }
 
+// windowTime returns the interval time of the point. Points keep their own
+// time when PointTime is set so the start of their interval is used.
+func (itr *floatFillIterator) windowTime(p *FloatPoint) int64 {
+	if itr.opt.PointTime {
+		t, _ := itr.opt.Window(p.Time)
+		return t
+	}
+	return p.Time
+}
+
 // floatIntervalIterator represents a float implementation of IntervalIterator.
 type floatIntervalIterator struct {
 	input FloatIterator
@@ -908,6 +1026,268 @@ func (itr *floatIntervalIterator) Next() (*FloatPoint, error) {
 	return p, nil
 }
 
+// floatLastValueIterator emits, for every interval of a series, the last
+// value that was observed at or before the start of that interval.
+//
+// The input is expected to be sorted by name, tags, and then ascending time
+// and may contain points from before the start time so that the value in
+// effect at the first interval can be carried forward.
+type floatLastValueIterator struct {
+	input     *bufFloatIterator
+	prev      FloatPoint
+	startTime int64
+	endTime   int64
+	dims      []string
+	opt       IteratorOptions
+
+	window struct {
+		name string
+		tags Tags
+		time int64
+		init bool
+		done bool
+	}
+}
+
+func newFloatLastValueIterator(input FloatIterator, startTime int64, opt IteratorOptions) *floatLastValueIterator {
+	itr := &floatLastValueIterator{
+		input:     newBufFloatIterator(input),
+		prev:      FloatPoint{Nil: true},
+		startTime: startTime,
+		endTime:   opt.EndTime,
+		dims:      opt.GetDimensions(),
+		opt:       opt,
+	}
+	itr.window.done = true
+	return itr
+}
+
+func (itr *floatLastValueIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *floatLastValueIterator) Close() error         { return itr.input.Close() }
+
+func (itr *floatLastValueIterator) Next() (*FloatPoint, error) {
+	for {
+		if itr.window.done {
+			// Discard anything remaining from the previous series and
+			// start at the first interval of the next one.
+			p, err := itr.next()
+			for p != nil && itr.window.init && p.Name == itr.window.name && p.Tags.Subset(itr.dims).ID() == itr.window.tags.ID() {
+				p, err = itr.next()
+			}
+			if p == nil || err != nil {
+				return nil, err
+			}
+			itr.window.name, itr.window.tags = p.Name, p.Tags.Subset(itr.dims)
+			itr.window.time, _ = itr.opt.Window(itr.startTime)
+			if itr.startTime == influxql.MinTime {
+				itr.window.time, _ = itr.opt.Window(p.Time)
+			}
+			itr.window.done, itr.window.init = false, true
+			itr.prev = FloatPoint{Nil: true}
+			itr.input.unread(p)
+		}
+
+		// Read every point in this series up to and including the start
+		// of the current interval and remember the last one.
+		more := false
+		for {
+			p, err := itr.next()
+			if err != nil {
+				return nil, err
+			} else if p == nil {
+				break
+			} else if p.Name != itr.window.name || p.Tags.Subset(itr.dims).ID() != itr.window.tags.ID() {
+				itr.input.unread(p)
+				break
+			} else if p.Time > itr.window.time {
+				itr.input.unread(p)
+				more = true
+				break
+			}
+			itr.prev = *p
+		}
+
+		t := itr.window.time
+		_, next := itr.opt.Window(t)
+		itr.window.time = next
+		if next > itr.endTime || (!more && itr.endTime == influxql.MaxTime) {
+			itr.window.done = true
+		}
+
+		if itr.prev.Nil {
+			continue
+		}
+		return &FloatPoint{
+			Name:  itr.window.name,
+			Tags:  itr.window.tags,
+			Time:  t,
+			Value: itr.prev.Value,
+		}, nil
+	}
+}
+
+// next returns the next non-nil point from the input.
+func (itr *floatLastValueIterator) next() (*FloatPoint, error) {
+	for {
+		p, err := itr.input.Next()
+		if p == nil || err != nil {
+			return nil, err
+		} else if p.Nil {
+			continue
+		}
+		return p, nil
+	}
+}
+
+// floatSlidingWindowIterator copies each point into every interval
+// whose trailing window contains the point. The window of an interval ends
+// at the end of the interval, so reducing the copies by interval computes
+// a statistic over overlapping windows.
+//
+// If carry is set, each point is kept in its own interval and the last point
+// of an interval is also copied to the start of the next interval, so the
+// next interval sees the change from the previous one.
+//
+// The input is expected to be sorted by name, tags, and then time and to
+// contain points from up to one window before the start time.
+type floatSlidingWindowIterator struct {
+	input  *bufFloatIterator
+	window int64
+	carry  bool
+	opt    IteratorOptions
+	points []FloatPoint
+}
+
+func newFloatSlidingWindowIterator(input FloatIterator, window time.Duration, opt IteratorOptions) *floatSlidingWindowIterator {
+	return &floatSlidingWindowIterator{
+		input:  newBufFloatIterator(input),
+		window: int64(window),
+		opt:    opt,
+	}
+}
+
+func newFloatCarryWindowIterator(input FloatIterator, opt IteratorOptions) *floatSlidingWindowIterator {
+	return &floatSlidingWindowIterator{
+		input: newBufFloatIterator(input),
+		carry: true,
+		opt:   opt,
+	}
+}
+
+func (itr *floatSlidingWindowIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *floatSlidingWindowIterator) Close() error         { return itr.input.Close() }
+
+func (itr *floatSlidingWindowIterator) Next() (*FloatPoint, error) {
+	for len(itr.points) == 0 {
+		if more, err := itr.readSeries(); err != nil || !more {
+			return nil, err
+		}
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return &p, nil
+}
+
+// readSeries reads every point of the next series and copies each one into
+// the intervals it belongs to. It returns false when the input is exhausted.
+func (itr *floatSlidingWindowIterator) readSeries() (bool, error) {
+	var name, tags string
+	var points []FloatPoint
+	more := false
+	for {
+		p, err := itr.input.Next()
+		if err != nil {
+			return false, err
+		} else if p == nil {
+			break
+		}
+
+		id := p.Tags.Subset(itr.opt.Dimensions).ID()
+		if !more {
+			name, tags, more = p.Name, id, true
+		} else if p.Name != name || id != tags {
+			itr.input.unread(p)
+			break
+		}
+
+		if p.Nil {
+			continue
+		} else if itr.carry {
+			points = append(points, *p)
+			continue
+		}
+		start, end := itr.opt.Window(p.Time)
+		for end-itr.window <= p.Time {
+			if end > itr.opt.StartTime && start <= itr.opt.EndTime {
+				c := *p
+				c.Time = start
+				itr.points = append(itr.points, c)
+			}
+			if end >= influxql.MaxTime {
+				break
+			}
+			start, end = itr.opt.Window(end)
+		}
+	}
+
+	if itr.carry {
+		itr.carryPoints(points)
+	}
+	if itr.opt.Ascending {
+		sort.SliceStable(itr.points, func(i, j int) bool { return itr.points[i].Time < itr.points[j].Time })
+	} else {
+		sort.SliceStable(itr.points, func(i, j int) bool { return itr.points[i].Time > itr.points[j].Time })
+	}
+	return more, nil
+}
+
+// carryPoints keeps the points of a series that are within the time range
+// and copies the last point of each interval to the start of the next one.
+// The copy is added before the points of the next interval so it stays
+// first when they are sorted by time.
+func (itr *floatSlidingWindowIterator) carryPoints(points []FloatPoint) {
+	if !itr.opt.Ascending {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+
+	for i, p := range points {
+		start, end := itr.opt.Window(p.Time)
+		if end > itr.opt.StartTime && start <= itr.opt.EndTime {
+			itr.points = append(itr.points, p)
+		}
+		if i+1 < len(points) && points[i+1].Time < end {
+			continue
+		} else if end >= influxql.MaxTime || end > itr.opt.EndTime {
+			continue
+		}
+		if _, next := itr.opt.Window(end); next > itr.opt.StartTime {
+			c := p
+			c.Time = end
+			itr.points = append(itr.points, c)
+		}
+	}
+}
+
+// floatNilPointIterator emits a single nil point for a series.
+type floatNilPointIterator struct {
+	point *FloatPoint
+}
+
+// Stats returns stats from the input iterator.
+func (itr *floatNilPointIterator) Stats() IteratorStats { return IteratorStats{} }
+
+// Close closes the iterator.
+func (itr *floatNilPointIterator) Close() error { return nil }
+
+// Next returns the nil point once.
+func (itr *floatNilPointIterator) Next() (*FloatPoint, error) {
+	p := itr.point
+	itr.point = nil
+	return p, nil
+}
+
 // floatInterruptIterator represents a float implementation of InterruptIterator.
 type floatInterruptIterator struct {
 	input   FloatIterator
@@ -1042,6 +1422,7 @@ type floatReduceFloatPoint struct {
 	Tags       Tags
 	Aggregator FloatPointAggregator
 	Emitter    FloatPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -1059,7 +1440,7 @@ func (itr *floatReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -1079,7 +1460,7 @@ func (itr *floatReduceFloatIterator) reduce() ([]FloatPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -1112,7 +1493,13 @@ func (itr *floatReduceFloatIterator) reduce() ([]FloatPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateFloat(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -1136,7 +1523,12 @@ func (itr *floatReduceFloatIterator) reduce() ([]FloatPoint, error) {
 	a := make([]FloatPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []FloatPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -1330,6 +1722,7 @@ type floatReduceIntegerPoint struct {
 	Tags       Tags
 	Aggregator FloatPointAggregator
 	Emitter    IntegerPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -1347,7 +1740,7 @@ func (itr *floatReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -1367,7 +1760,7 @@ func (itr *floatReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -1400,7 +1793,13 @@ func (itr *floatReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateFloat(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -1424,7 +1823,12 @@ func (itr *floatReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 	a := make([]IntegerPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []IntegerPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -1618,6 +2022,7 @@ type floatReduceUnsignedPoint struct {
 	Tags       Tags
 	Aggregator FloatPointAggregator
 	Emitter    UnsignedPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -1635,7 +2040,7 @@ func (itr *floatReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -1655,7 +2060,7 @@ func (itr *floatReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -1688,7 +2093,13 @@ func (itr *floatReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateFloat(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -1712,7 +2123,12 @@ func (itr *floatReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 	a := make([]UnsignedPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []UnsignedPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -1906,6 +2322,7 @@ type floatReduceStringPoint struct {
 	Tags       Tags
 	Aggregator FloatPointAggregator
 	Emitter    StringPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -1923,7 +2340,7 @@ func (itr *floatReduceStringIterator) reduce() ([]StringPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -1943,7 +2360,7 @@ func (itr *floatReduceStringIterator) reduce() ([]StringPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -1976,7 +2393,13 @@ func (itr *floatReduceStringIterator) reduce() ([]StringPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateFloat(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -2000,7 +2423,12 @@ func (itr *floatReduceStringIterator) reduce() ([]StringPoint, error) {
 	a := make([]StringPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []StringPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -2194,6 +2622,7 @@ type floatReduceBooleanPoint struct {
 	Tags       Tags
 	Aggregator FloatPointAggregator
 	Emitter    BooleanPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -2211,7 +2640,7 @@ func (itr *floatReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -2231,7 +2660,7 @@ func (itr *floatReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -2264,7 +2693,13 @@ func (itr *floatReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateFloat(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -2288,7 +2723,12 @@ func (itr *floatReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 	a := make([]BooleanPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []BooleanPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -2982,22 +3422,34 @@ type integerSortedMergeIterator struct {
 	inputs []IntegerIterator
 	heap   *integerSortedMergeHeap
 	init   bool
+
+	// The points left to return from the last name, dimensions and time
+	// read. Only used when deduplicating points across inputs.
+	buf []*IntegerPoint
 }
 
 // newIntegerSortedMergeIterator returns an instance of integerSortedMergeIterator.
 func newIntegerSortedMergeIterator(inputs []IntegerIterator, opt IteratorOptions) Iterator {
+	return newIntegerSortedMergeDedupeIterator(inputs, opt, false)
+}
+
+// newIntegerSortedMergeDedupeIterator returns an instance of integerSortedMergeIterator.
+// If dedupe is set, points that are equal to a point already emitted from a
+// later input are dropped.
+func newIntegerSortedMergeDedupeIterator(inputs []IntegerIterator, opt IteratorOptions, dedupe bool) Iterator {
 	itr := &integerSortedMergeIterator{
 		inputs: inputs,
 		heap: &integerSortedMergeHeap{
-			items: make([]*integerSortedMergeHeapItem, 0, len(inputs)),
-			opt:   opt,
+			items:  make([]*integerSortedMergeHeapItem, 0, len(inputs)),
+			opt:    opt,
+			dedupe: dedupe,
 		},
 	}
 
 	// Initialize heap items.
-	for _, input := range inputs {
+	for i, input := range inputs {
 		// Append to the heap.
-		itr.heap.items = append(itr.heap.items, &integerSortedMergeHeapItem{itr: input})
+		itr.heap.items = append(itr.heap.items, &integerSortedMergeHeapItem{itr: input, index: i})
 	}
 
 	return itr
@@ -3043,26 +3495,78 @@ func (itr *integerSortedMergeIterator) pop() (*IntegerPoint, error) {
 		itr.init = true
 	}
 
+	if itr.heap.dedupe {
+		return itr.popDedupe()
+	}
+	p, _, err := itr.read()
+	return p, err
+}
+
+// read returns the next point from the heap and the index of its input.
+// Reads the next point from item's cursor and puts it back on the heap.
+func (itr *integerSortedMergeIterator) read() (*IntegerPoint, int, error) {
 	if len(itr.heap.items) == 0 {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Read the next item from the heap.
 	item := heap.Pop(itr.heap).(*integerSortedMergeHeapItem)
 	if item.err != nil {
-		return nil, item.err
+		return nil, 0, item.err
 	} else if item.point == nil {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Copy the point for return.
 	p := item.point.Clone()
+	index := item.index
 
 	// Read the next item from the cursor. Push back to heap if one exists.
 	if item.point, item.err = item.itr.Next(); item.point != nil {
 		heap.Push(itr.heap, item)
 	}
+	return p, index, nil
+}
+
+// popDedupe returns the next point from the heap. A series read from more
+// than one input at the same time is only returned once, from the last input.
+func (itr *integerSortedMergeIterator) popDedupe() (*IntegerPoint, error) {
+	if len(itr.buf) == 0 {
+		p, index, err := itr.read()
+		if p == nil || err != nil {
+			return nil, err
+		}
+
+		// The points with the same name, dimensions and time are next on the
+		// heap, but the points of a series are not always next to each other.
+		// Keep the point of each series from the last input in heap order.
+		seen := map[string]int{p.Tags.ID(): 0}
+		indexes := []int{index}
+		itr.buf = append(itr.buf, p)
+		for len(itr.heap.items) > 0 && itr.heap.sameTime(itr.heap.items[0].point, p) {
+			next, index, err := itr.read()
+			if err != nil {
+				return nil, err
+			}
+
+			if i, ok := seen[next.Tags.ID()]; !ok {
+				seen[next.Tags.ID()] = len(itr.buf)
+				itr.buf, indexes = append(itr.buf, next), append(indexes, index)
+			} else if index > indexes[i] {
+				itr.buf[i], indexes[i] = next, index
+			}
+		}
+
+		// Only the dimensions are returned once the series are told apart.
+		if itr.heap.opt.SeriesTags {
+			for _, p := range itr.buf {
+				p.Tags = p.Tags.Subset(itr.heap.opt.Dimensions)
+			}
+		}
+	}
 
+	p := itr.buf[0]
+	itr.buf = itr.buf[1:]
 	return p, nil
 }
 
@@ -3074,15 +3578,27 @@ func (itr *integerSortedMergeIterator) pop() (*IntegerPoint, error) {
 //     - By their Aux field values.
 //
 type integerSortedMergeHeap struct {
-	opt   IteratorOptions
-	items []*integerSortedMergeHeapItem
+	opt    IteratorOptions
+	items  []*integerSortedMergeHeapItem
+	dedupe bool
 }
 
 func (h *integerSortedMergeHeap) Len() int      { return len(h.items) }
 func (h *integerSortedMergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
 func (h *integerSortedMergeHeap) Less(i, j int) bool {
-	x, y := h.items[i].point, h.items[j].point
+	return h.less(h.items[i].point, h.items[j].point)
+}
+
+// sameTime returns true if both points have the same name, dimensions and time.
+func (h *integerSortedMergeHeap) sameTime(x, y *IntegerPoint) bool {
+	if x.Name != y.Name || x.Time != y.Time {
+		return false
+	}
+	xTags, yTags := x.Tags.Subset(h.opt.Dimensions), y.Tags.Subset(h.opt.Dimensions)
+	return xTags.Equals(&yTags)
+}
 
+func (h *integerSortedMergeHeap) less(x, y *IntegerPoint) bool {
 	if h.opt.Ascending {
 		if x.Name != y.Name {
 			return x.Name < y.Name
@@ -3154,6 +3670,7 @@ type integerSortedMergeHeapItem struct {
 	point *IntegerPoint
 	err   error
 	itr   IntegerIterator
+	index int
 }
 
 // integerIteratorScanner scans the results of a IntegerIterator into a map.
@@ -3388,7 +3905,7 @@ type integerFillIterator struct {
 
 func newIntegerFillIterator(input IntegerIterator, expr influxql.Expr, opt IteratorOptions) *integerFillIterator {
 	if opt.Fill == influxql.NullFill {
-		if expr, ok := expr.(*influxql.Call); ok && expr.Name == "count" {
+		if expr, ok := expr.(*influxql.Call); ok && (expr.Name == "count" || expr.Name == "count_rate") {
 			opt.Fill = influxql.NumberFill
 			opt.FillValue = int64(0)
 		}
@@ -3477,7 +3994,7 @@ func (itr *integerFillIterator) Next() (*IntegerPoint, error) {
 
 	// Check if the point is our next expected point.
 CONSTRUCT:
-	if p == nil || (itr.opt.Ascending && p.Time > itr.window.time) || (!itr.opt.Ascending && p.Time < itr.window.time) {
+	if p == nil || (itr.opt.Ascending && itr.windowTime(p) > itr.window.time) || (!itr.opt.Ascending && itr.windowTime(p) < itr.window.time) {
 		if p != nil {
 			itr.input.unread(p)
 		}
@@ -3517,15 +4034,46 @@ CONSTRUCT:
 			} else {
 				p.Nil = true
 			}
-		}
-	} else {
-		itr.prev = *p
-	}
-
-	// Advance the expected time. Do not advance to a new window here
-	// as there may be lingering points with the same timestamp in the previous
+		case NextFill:
+			next, err := itr.input.peek()
+			if err != nil {
+				return nil, err
+			} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
+				p.Value = next.Value
+				p.Nil = next.Nil
+			} else {
+				p.Nil = true
+			}
+		case ExprFill:
+			if !itr.prev.Nil {
+				if v, ok := castToInteger(evalFillExpr(itr.opt.FillValue, itr.prev.Value)); ok {
+					p.Value = v
+					// The next empty interval is filled from this one.
+					itr.prev = *p
+				} else {
+					p.Nil = true
+				}
+			} else {
+				p.Nil = true
+			}
+		}
+	} else {
+		itr.prev = *p
+	}
+
+	// Advance the expected time. Do not advance to a new window here
+	// as there may be lingering points with the same timestamp in the previous
 	// window.
-	if itr.opt.Ascending {
+	if itr.opt.Interval.Months > 0 {
+		// Calendar intervals vary in length and their windows already
+		// account for the zone offset.
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+		return p, nil
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
@@ -3545,6 +4093,16 @@ CONSTRUCT:
 	return p, nil
 }
 
+// windowTime returns the interval time of the point. Points keep their own
+// time when PointTime is set so the start of their interval is used.
+func (itr *integerFillIterator) windowTime(p *IntegerPoint) int64 {
+	if itr.opt.PointTime {
+		t, _ := itr.opt.Window(p.Time)
+		return t
+	}
+	return p.Time
+}
+
 // integerIntervalIterator represents a integer implementation of IntervalIterator.
 type integerIntervalIterator struct {
 	input IntegerIterator
@@ -3572,6 +4130,268 @@ func (itr *integerIntervalIterator) Next() (*IntegerPoint, error) {
 	return p, nil
 }
 
+// integerLastValueIterator emits, for every interval of a series, the last
+// value that was observed at or before the start of that interval.
+//
+// The input is expected to be sorted by name, tags, and then ascending time
+// and may contain points from before the start time so that the value in
+// effect at the first interval can be carried forward.
+type integerLastValueIterator struct {
+	input     *bufIntegerIterator
+	prev      IntegerPoint
+	startTime int64
+	endTime   int64
+	dims      []string
+	opt       IteratorOptions
+
+	window struct {
+		name string
+		tags Tags
+		time int64
+		init bool
+		done bool
+	}
+}
+
+func newIntegerLastValueIterator(input IntegerIterator, startTime int64, opt IteratorOptions) *integerLastValueIterator {
+	itr := &integerLastValueIterator{
+		input:     newBufIntegerIterator(input),
+		prev:      IntegerPoint{Nil: true},
+		startTime: startTime,
+		endTime:   opt.EndTime,
+		dims:      opt.GetDimensions(),
+		opt:       opt,
+	}
+	itr.window.done = true
+	return itr
+}
+
+func (itr *integerLastValueIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *integerLastValueIterator) Close() error         { return itr.input.Close() }
+
+func (itr *integerLastValueIterator) Next() (*IntegerPoint, error) {
+	for {
+		if itr.window.done {
+			// Discard anything remaining from the previous series and
+			// start at the first interval of the next one.
+			p, err := itr.next()
+			for p != nil && itr.window.init && p.Name == itr.window.name && p.Tags.Subset(itr.dims).ID() == itr.window.tags.ID() {
+				p, err = itr.next()
+			}
+			if p == nil || err != nil {
+				return nil, err
+			}
+			itr.window.name, itr.window.tags = p.Name, p.Tags.Subset(itr.dims)
+			itr.window.time, _ = itr.opt.Window(itr.startTime)
+			if itr.startTime == influxql.MinTime {
+				itr.window.time, _ = itr.opt.Window(p.Time)
+			}
+			itr.window.done, itr.window.init = false, true
+			itr.prev = IntegerPoint{Nil: true}
+			itr.input.unread(p)
+		}
+
+		// Read every point in this series up to and including the start
+		// of the current interval and remember the last one.
+		more := false
+		for {
+			p, err := itr.next()
+			if err != nil {
+				return nil, err
+			} else if p == nil {
+				break
+			} else if p.Name != itr.window.name || p.Tags.Subset(itr.dims).ID() != itr.window.tags.ID() {
+				itr.input.unread(p)
+				break
+			} else if p.Time > itr.window.time {
+				itr.input.unread(p)
+				more = true
+				break
+			}
+			itr.prev = *p
+		}
+
+		t := itr.window.time
+		_, next := itr.opt.Window(t)
+		itr.window.time = next
+		if next > itr.endTime || (!more && itr.endTime == influxql.MaxTime) {
+			itr.window.done = true
+		}
+
+		if itr.prev.Nil {
+			continue
+		}
+		return &IntegerPoint{
+			Name:  itr.window.name,
+			Tags:  itr.window.tags,
+			Time:  t,
+			Value: itr.prev.Value,
+		}, nil
+	}
+}
+
+// next returns the next non-nil point from the input.
+func (itr *integerLastValueIterator) next() (*IntegerPoint, error) {
+	for {
+		p, err := itr.input.Next()
+		if p == nil || err != nil {
+			return nil, err
+		} else if p.Nil {
+			continue
+		}
+		return p, nil
+	}
+}
+
+// integerSlidingWindowIterator copies each point into every interval
+// whose trailing window contains the point. The window of an interval ends
+// at the end of the interval, so reducing the copies by interval computes
+// a statistic over overlapping windows.
+//
+// If carry is set, each point is kept in its own interval and the last point
+// of an interval is also copied to the start of the next interval, so the
+// next interval sees the change from the previous one.
+//
+// The input is expected to be sorted by name, tags, and then time and to
+// contain points from up to one window before the start time.
+type integerSlidingWindowIterator struct {
+	input  *bufIntegerIterator
+	window int64
+	carry  bool
+	opt    IteratorOptions
+	points []IntegerPoint
+}
+
+func newIntegerSlidingWindowIterator(input IntegerIterator, window time.Duration, opt IteratorOptions) *integerSlidingWindowIterator {
+	return &integerSlidingWindowIterator{
+		input:  newBufIntegerIterator(input),
+		window: int64(window),
+		opt:    opt,
+	}
+}
+
+func newIntegerCarryWindowIterator(input IntegerIterator, opt IteratorOptions) *integerSlidingWindowIterator {
+	return &integerSlidingWindowIterator{
+		input: newBufIntegerIterator(input),
+		carry: true,
+		opt:   opt,
+	}
+}
+
+func (itr *integerSlidingWindowIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *integerSlidingWindowIterator) Close() error         { return itr.input.Close() }
+
+func (itr *integerSlidingWindowIterator) Next() (*IntegerPoint, error) {
+	for len(itr.points) == 0 {
+		if more, err := itr.readSeries(); err != nil || !more {
+			return nil, err
+		}
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return &p, nil
+}
+
+// readSeries reads every point of the next series and copies each one into
+// the intervals it belongs to. It returns false when the input is exhausted.
+func (itr *integerSlidingWindowIterator) readSeries() (bool, error) {
+	var name, tags string
+	var points []IntegerPoint
+	more := false
+	for {
+		p, err := itr.input.Next()
+		if err != nil {
+			return false, err
+		} else if p == nil {
+			break
+		}
+
+		id := p.Tags.Subset(itr.opt.Dimensions).ID()
+		if !more {
+			name, tags, more = p.Name, id, true
+		} else if p.Name != name || id != tags {
+			itr.input.unread(p)
+			break
+		}
+
+		if p.Nil {
+			continue
+		} else if itr.carry {
+			points = append(points, *p)
+			continue
+		}
+		start, end := itr.opt.Window(p.Time)
+		for end-itr.window <= p.Time {
+			if end > itr.opt.StartTime && start <= itr.opt.EndTime {
+				c := *p
+				c.Time = start
+				itr.points = append(itr.points, c)
+			}
+			if end >= influxql.MaxTime {
+				break
+			}
+			start, end = itr.opt.Window(end)
+		}
+	}
+
+	if itr.carry {
+		itr.carryPoints(points)
+	}
+	if itr.opt.Ascending {
+		sort.SliceStable(itr.points, func(i, j int) bool { return itr.points[i].Time < itr.points[j].Time })
+	} else {
+		sort.SliceStable(itr.points, func(i, j int) bool { return itr.points[i].Time > itr.points[j].Time })
+	}
+	return more, nil
+}
+
+// carryPoints keeps the points of a series that are within the time range
+// and copies the last point of each interval to the start of the next one.
+// The copy is added before the points of the next interval so it stays
+// first when they are sorted by time.
+func (itr *integerSlidingWindowIterator) carryPoints(points []IntegerPoint) {
+	if !itr.opt.Ascending {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+
+	for i, p := range points {
+		start, end := itr.opt.Window(p.Time)
+		if end > itr.opt.StartTime && start <= itr.opt.EndTime {
+			itr.points = append(itr.points, p)
+		}
+		if i+1 < len(points) && points[i+1].Time < end {
+			continue
+		} else if end >= influxql.MaxTime || end > itr.opt.EndTime {
+			continue
+		}
+		if _, next := itr.opt.Window(end); next > itr.opt.StartTime {
+			c := p
+			c.Time = end
+			itr.points = append(itr.points, c)
+		}
+	}
+}
+
+// integerNilPointIterator emits a single nil point for a series.
+type integerNilPointIterator struct {
+	point *IntegerPoint
+}
+
+// Stats returns stats from the input iterator.
+func (itr *integerNilPointIterator) Stats() IteratorStats { return IteratorStats{} }
+
+// Close closes the iterator.
+func (itr *integerNilPointIterator) Close() error { return nil }
+
+// Next returns the nil point once.
+func (itr *integerNilPointIterator) Next() (*IntegerPoint, error) {
+	p := itr.point
+	itr.point = nil
+	return p, nil
+}
+
 // integerInterruptIterator represents a integer implementation of InterruptIterator.
 type integerInterruptIterator struct {
 	input   IntegerIterator
@@ -3706,6 +4526,7 @@ type integerReduceFloatPoint struct {
 	Tags       Tags
 	Aggregator IntegerPointAggregator
 	Emitter    FloatPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -3723,7 +4544,7 @@ func (itr *integerReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -3743,7 +4564,7 @@ func (itr *integerReduceFloatIterator) reduce() ([]FloatPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -3776,7 +4597,13 @@ func (itr *integerReduceFloatIterator) reduce() ([]FloatPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateInteger(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -3800,7 +4627,12 @@ func (itr *integerReduceFloatIterator) reduce() ([]FloatPoint, error) {
 	a := make([]FloatPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []FloatPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -3994,6 +4826,7 @@ type integerReduceIntegerPoint struct {
 	Tags       Tags
 	Aggregator IntegerPointAggregator
 	Emitter    IntegerPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -4011,7 +4844,7 @@ func (itr *integerReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -4031,7 +4864,7 @@ func (itr *integerReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -4064,7 +4897,13 @@ func (itr *integerReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateInteger(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -4088,7 +4927,12 @@ func (itr *integerReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 	a := make([]IntegerPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []IntegerPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -4282,6 +5126,7 @@ type integerReduceUnsignedPoint struct {
 	Tags       Tags
 	Aggregator IntegerPointAggregator
 	Emitter    UnsignedPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -4299,7 +5144,7 @@ func (itr *integerReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -4319,7 +5164,7 @@ func (itr *integerReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -4352,7 +5197,13 @@ func (itr *integerReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateInteger(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -4376,7 +5227,12 @@ func (itr *integerReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 	a := make([]UnsignedPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []UnsignedPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -4570,6 +5426,7 @@ type integerReduceStringPoint struct {
 	Tags       Tags
 	Aggregator IntegerPointAggregator
 	Emitter    StringPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -4587,7 +5444,7 @@ func (itr *integerReduceStringIterator) reduce() ([]StringPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -4607,7 +5464,7 @@ func (itr *integerReduceStringIterator) reduce() ([]StringPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -4640,7 +5497,13 @@ func (itr *integerReduceStringIterator) reduce() ([]StringPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateInteger(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -4664,7 +5527,12 @@ func (itr *integerReduceStringIterator) reduce() ([]StringPoint, error) {
 	a := make([]StringPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []StringPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -4858,6 +5726,7 @@ type integerReduceBooleanPoint struct {
 	Tags       Tags
 	Aggregator IntegerPointAggregator
 	Emitter    BooleanPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -4875,7 +5744,7 @@ func (itr *integerReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -4895,7 +5764,7 @@ func (itr *integerReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -4928,7 +5797,13 @@ func (itr *integerReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateInteger(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -4952,7 +5827,12 @@ func (itr *integerReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 	a := make([]BooleanPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []BooleanPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -5646,22 +6526,34 @@ type unsignedSortedMergeIterator struct {
 	inputs []UnsignedIterator
 	heap   *unsignedSortedMergeHeap
 	init   bool
+
+	// The points left to return from the last name, dimensions and time
+	// read. Only used when deduplicating points across inputs.
+	buf []*UnsignedPoint
 }
 
 // newUnsignedSortedMergeIterator returns an instance of unsignedSortedMergeIterator.
 func newUnsignedSortedMergeIterator(inputs []UnsignedIterator, opt IteratorOptions) Iterator {
+	return newUnsignedSortedMergeDedupeIterator(inputs, opt, false)
+}
+
+// newUnsignedSortedMergeDedupeIterator returns an instance of unsignedSortedMergeIterator.
+// If dedupe is set, points that are equal to a point already emitted from a
+// later input are dropped.
+func newUnsignedSortedMergeDedupeIterator(inputs []UnsignedIterator, opt IteratorOptions, dedupe bool) Iterator {
 	itr := &unsignedSortedMergeIterator{
 		inputs: inputs,
 		heap: &unsignedSortedMergeHeap{
-			items: make([]*unsignedSortedMergeHeapItem, 0, len(inputs)),
-			opt:   opt,
+			items:  make([]*unsignedSortedMergeHeapItem, 0, len(inputs)),
+			opt:    opt,
+			dedupe: dedupe,
 		},
 	}
 
 	// Initialize heap items.
-	for _, input := range inputs {
+	for i, input := range inputs {
 		// Append to the heap.
-		itr.heap.items = append(itr.heap.items, &unsignedSortedMergeHeapItem{itr: input})
+		itr.heap.items = append(itr.heap.items, &unsignedSortedMergeHeapItem{itr: input, index: i})
 	}
 
 	return itr
@@ -5707,26 +6599,78 @@ func (itr *unsignedSortedMergeIterator) pop() (*UnsignedPoint, error) {
 		itr.init = true
 	}
 
+	if itr.heap.dedupe {
+		return itr.popDedupe()
+	}
+	p, _, err := itr.read()
+	return p, err
+}
+
+// read returns the next point from the heap and the index of its input.
+// Reads the next point from item's cursor and puts it back on the heap.
+func (itr *unsignedSortedMergeIterator) read() (*UnsignedPoint, int, error) {
 	if len(itr.heap.items) == 0 {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Read the next item from the heap.
 	item := heap.Pop(itr.heap).(*unsignedSortedMergeHeapItem)
 	if item.err != nil {
-		return nil, item.err
+		return nil, 0, item.err
 	} else if item.point == nil {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Copy the point for return.
 	p := item.point.Clone()
+	index := item.index
 
 	// Read the next item from the cursor. Push back to heap if one exists.
 	if item.point, item.err = item.itr.Next(); item.point != nil {
 		heap.Push(itr.heap, item)
 	}
+	return p, index, nil
+}
+
+// popDedupe returns the next point from the heap. A series read from more
+// than one input at the same time is only returned once, from the last input.
+func (itr *unsignedSortedMergeIterator) popDedupe() (*UnsignedPoint, error) {
+	if len(itr.buf) == 0 {
+		p, index, err := itr.read()
+		if p == nil || err != nil {
+			return nil, err
+		}
+
+		// The points with the same name, dimensions and time are next on the
+		// heap, but the points of a series are not always next to each other.
+		// Keep the point of each series from the last input in heap order.
+		seen := map[string]int{p.Tags.ID(): 0}
+		indexes := []int{index}
+		itr.buf = append(itr.buf, p)
+		for len(itr.heap.items) > 0 && itr.heap.sameTime(itr.heap.items[0].point, p) {
+			next, index, err := itr.read()
+			if err != nil {
+				return nil, err
+			}
+
+			if i, ok := seen[next.Tags.ID()]; !ok {
+				seen[next.Tags.ID()] = len(itr.buf)
+				itr.buf, indexes = append(itr.buf, next), append(indexes, index)
+			} else if index > indexes[i] {
+				itr.buf[i], indexes[i] = next, index
+			}
+		}
+
+		// Only the dimensions are returned once the series are told apart.
+		if itr.heap.opt.SeriesTags {
+			for _, p := range itr.buf {
+				p.Tags = p.Tags.Subset(itr.heap.opt.Dimensions)
+			}
+		}
+	}
 
+	p := itr.buf[0]
+	itr.buf = itr.buf[1:]
 	return p, nil
 }
 
@@ -5738,15 +6682,27 @@ func (itr *unsignedSortedMergeIterator) pop() (*UnsignedPoint, error) {
 //     - By their Aux field values.
 //
 type unsignedSortedMergeHeap struct {
-	opt   IteratorOptions
-	items []*unsignedSortedMergeHeapItem
+	opt    IteratorOptions
+	items  []*unsignedSortedMergeHeapItem
+	dedupe bool
 }
 
 func (h *unsignedSortedMergeHeap) Len() int      { return len(h.items) }
 func (h *unsignedSortedMergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
 func (h *unsignedSortedMergeHeap) Less(i, j int) bool {
-	x, y := h.items[i].point, h.items[j].point
+	return h.less(h.items[i].point, h.items[j].point)
+}
+
+// sameTime returns true if both points have the same name, dimensions and time.
+func (h *unsignedSortedMergeHeap) sameTime(x, y *UnsignedPoint) bool {
+	if x.Name != y.Name || x.Time != y.Time {
+		return false
+	}
+	xTags, yTags := x.Tags.Subset(h.opt.Dimensions), y.Tags.Subset(h.opt.Dimensions)
+	return xTags.Equals(&yTags)
+}
 
+func (h *unsignedSortedMergeHeap) less(x, y *UnsignedPoint) bool {
 	if h.opt.Ascending {
 		if x.Name != y.Name {
 			return x.Name < y.Name
@@ -5818,6 +6774,7 @@ type unsignedSortedMergeHeapItem struct {
 	point *UnsignedPoint
 	err   error
 	itr   UnsignedIterator
+	index int
 }
 
 // unsignedIteratorScanner scans the results of a UnsignedIterator into a map.
@@ -6052,7 +7009,7 @@ type unsignedFillIterator struct {
 
 func newUnsignedFillIterator(input UnsignedIterator, expr influxql.Expr, opt IteratorOptions) *unsignedFillIterator {
 	if opt.Fill == influxql.NullFill {
-		if expr, ok := expr.(*influxql.Call); ok && expr.Name == "count" {
+		if expr, ok := expr.(*influxql.Call); ok && (expr.Name == "count" || expr.Name == "count_rate") {
 			opt.Fill = influxql.NumberFill
 			opt.FillValue = uint64(0)
 		}
@@ -6141,7 +7098,7 @@ func (itr *unsignedFillIterator) Next() (*UnsignedPoint, error) {
 
 	// Check if the point is our next expected point.
 CONSTRUCT:
-	if p == nil || (itr.opt.Ascending && p.Time > itr.window.time) || (!itr.opt.Ascending && p.Time < itr.window.time) {
+	if p == nil || (itr.opt.Ascending && itr.windowTime(p) > itr.window.time) || (!itr.opt.Ascending && itr.windowTime(p) < itr.window.time) {
 		if p != nil {
 			itr.input.unread(p)
 		}
@@ -6181,6 +7138,28 @@ CONSTRUCT:
 			} else {
 				p.Nil = true
 			}
+		case NextFill:
+			next, err := itr.input.peek()
+			if err != nil {
+				return nil, err
+			} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
+				p.Value = next.Value
+				p.Nil = next.Nil
+			} else {
+				p.Nil = true
+			}
+		case ExprFill:
+			if !itr.prev.Nil {
+				if v, ok := castToUnsigned(evalFillExpr(itr.opt.FillValue, itr.prev.Value)); ok {
+					p.Value = v
+					// The next empty interval is filled from this one.
+					itr.prev = *p
+				} else {
+					p.Nil = true
+				}
+			} else {
+				p.Nil = true
+			}
 		}
 	} else {
 		itr.prev = *p
@@ -6189,7 +7168,16 @@ CONSTRUCT:
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
 	// window.
-	if itr.opt.Ascending {
+	if itr.opt.Interval.Months > 0 {
+		// Calendar intervals vary in length and their windows already
+		// account for the zone offset.
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+		return p, nil
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
@@ -6209,6 +7197,16 @@ CONSTRUCT:
 	return p, nil
 }
 
+// windowTime returns the interval time of the point. Points keep their own
+// time when PointTime is set so the start of their interval is used.
+func (itr *unsignedFillIterator) windowTime(p *UnsignedPoint) int64 {
+	if itr.opt.PointTime {
+		t, _ := itr.opt.Window(p.Time)
+		return t
+	}
+	return p.Time
+}
+
 // unsignedIntervalIterator represents a unsigned implementation of IntervalIterator.
 type unsignedIntervalIterator struct {
 	input UnsignedIterator
@@ -6236,15 +7234,277 @@ func (itr *unsignedIntervalIterator) Next() (*UnsignedPoint, error) {
 	return p, nil
 }
 
-// unsignedInterruptIterator represents a unsigned implementation of InterruptIterator.
-type unsignedInterruptIterator struct {
-	input   UnsignedIterator
-	closing <-chan struct{}
-	count   int
-}
+// unsignedLastValueIterator emits, for every interval of a series, the last
+// value that was observed at or before the start of that interval.
+//
+// The input is expected to be sorted by name, tags, and then ascending time
+// and may contain points from before the start time so that the value in
+// effect at the first interval can be carried forward.
+type unsignedLastValueIterator struct {
+	input     *bufUnsignedIterator
+	prev      UnsignedPoint
+	startTime int64
+	endTime   int64
+	dims      []string
+	opt       IteratorOptions
 
-func newUnsignedInterruptIterator(input UnsignedIterator, closing <-chan struct{}) *unsignedInterruptIterator {
-	return &unsignedInterruptIterator{input: input, closing: closing}
+	window struct {
+		name string
+		tags Tags
+		time int64
+		init bool
+		done bool
+	}
+}
+
+func newUnsignedLastValueIterator(input UnsignedIterator, startTime int64, opt IteratorOptions) *unsignedLastValueIterator {
+	itr := &unsignedLastValueIterator{
+		input:     newBufUnsignedIterator(input),
+		prev:      UnsignedPoint{Nil: true},
+		startTime: startTime,
+		endTime:   opt.EndTime,
+		dims:      opt.GetDimensions(),
+		opt:       opt,
+	}
+	itr.window.done = true
+	return itr
+}
+
+func (itr *unsignedLastValueIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *unsignedLastValueIterator) Close() error         { return itr.input.Close() }
+
+func (itr *unsignedLastValueIterator) Next() (*UnsignedPoint, error) {
+	for {
+		if itr.window.done {
+			// Discard anything remaining from the previous series and
+			// start at the first interval of the next one.
+			p, err := itr.next()
+			for p != nil && itr.window.init && p.Name == itr.window.name && p.Tags.Subset(itr.dims).ID() == itr.window.tags.ID() {
+				p, err = itr.next()
+			}
+			if p == nil || err != nil {
+				return nil, err
+			}
+			itr.window.name, itr.window.tags = p.Name, p.Tags.Subset(itr.dims)
+			itr.window.time, _ = itr.opt.Window(itr.startTime)
+			if itr.startTime == influxql.MinTime {
+				itr.window.time, _ = itr.opt.Window(p.Time)
+			}
+			itr.window.done, itr.window.init = false, true
+			itr.prev = UnsignedPoint{Nil: true}
+			itr.input.unread(p)
+		}
+
+		// Read every point in this series up to and including the start
+		// of the current interval and remember the last one.
+		more := false
+		for {
+			p, err := itr.next()
+			if err != nil {
+				return nil, err
+			} else if p == nil {
+				break
+			} else if p.Name != itr.window.name || p.Tags.Subset(itr.dims).ID() != itr.window.tags.ID() {
+				itr.input.unread(p)
+				break
+			} else if p.Time > itr.window.time {
+				itr.input.unread(p)
+				more = true
+				break
+			}
+			itr.prev = *p
+		}
+
+		t := itr.window.time
+		_, next := itr.opt.Window(t)
+		itr.window.time = next
+		if next > itr.endTime || (!more && itr.endTime == influxql.MaxTime) {
+			itr.window.done = true
+		}
+
+		if itr.prev.Nil {
+			continue
+		}
+		return &UnsignedPoint{
+			Name:  itr.window.name,
+			Tags:  itr.window.tags,
+			Time:  t,
+			Value: itr.prev.Value,
+		}, nil
+	}
+}
+
+// next returns the next non-nil point from the input.
+func (itr *unsignedLastValueIterator) next() (*UnsignedPoint, error) {
+	for {
+		p, err := itr.input.Next()
+		if p == nil || err != nil {
+			return nil, err
+		} else if p.Nil {
+			continue
+		}
+		return p, nil
+	}
+}
+
+// unsignedSlidingWindowIterator copies each point into every interval
+// whose trailing window contains the point. The window of an interval ends
+// at the end of the interval, so reducing the copies by interval computes
+// a statistic over overlapping windows.
+//
+// If carry is set, each point is kept in its own interval and the last point
+// of an interval is also copied to the start of the next interval, so the
+// next interval sees the change from the previous one.
+//
+// The input is expected to be sorted by name, tags, and then time and to
+// contain points from up to one window before the start time.
+type unsignedSlidingWindowIterator struct {
+	input  *bufUnsignedIterator
+	window int64
+	carry  bool
+	opt    IteratorOptions
+	points []UnsignedPoint
+}
+
+func newUnsignedSlidingWindowIterator(input UnsignedIterator, window time.Duration, opt IteratorOptions) *unsignedSlidingWindowIterator {
+	return &unsignedSlidingWindowIterator{
+		input:  newBufUnsignedIterator(input),
+		window: int64(window),
+		opt:    opt,
+	}
+}
+
+func newUnsignedCarryWindowIterator(input UnsignedIterator, opt IteratorOptions) *unsignedSlidingWindowIterator {
+	return &unsignedSlidingWindowIterator{
+		input: newBufUnsignedIterator(input),
+		carry: true,
+		opt:   opt,
+	}
+}
+
+func (itr *unsignedSlidingWindowIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *unsignedSlidingWindowIterator) Close() error         { return itr.input.Close() }
+
+func (itr *unsignedSlidingWindowIterator) Next() (*UnsignedPoint, error) {
+	for len(itr.points) == 0 {
+		if more, err := itr.readSeries(); err != nil || !more {
+			return nil, err
+		}
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return &p, nil
+}
+
+// readSeries reads every point of the next series and copies each one into
+// the intervals it belongs to. It returns false when the input is exhausted.
+func (itr *unsignedSlidingWindowIterator) readSeries() (bool, error) {
+	var name, tags string
+	var points []UnsignedPoint
+	more := false
+	for {
+		p, err := itr.input.Next()
+		if err != nil {
+			return false, err
+		} else if p == nil {
+			break
+		}
+
+		id := p.Tags.Subset(itr.opt.Dimensions).ID()
+		if !more {
+			name, tags, more = p.Name, id, true
+		} else if p.Name != name || id != tags {
+			itr.input.unread(p)
+			break
+		}
+
+		if p.Nil {
+			continue
+		} else if itr.carry {
+			points = append(points, *p)
+			continue
+		}
+		start, end := itr.opt.Window(p.Time)
+		for end-itr.window <= p.Time {
+			if end > itr.opt.StartTime && start <= itr.opt.EndTime {
+				c := *p
+				c.Time = start
+				itr.points = append(itr.points, c)
+			}
+			if end >= influxql.MaxTime {
+				break
+			}
+			start, end = itr.opt.Window(end)
+		}
+	}
+
+	if itr.carry {
+		itr.carryPoints(points)
+	}
+	if itr.opt.Ascending {
+		sort.SliceStable(itr.points, func(i, j int) bool { return itr.points[i].Time < itr.points[j].Time })
+	} else {
+		sort.SliceStable(itr.points, func(i, j int) bool { return itr.points[i].Time > itr.points[j].Time })
+	}
+	return more, nil
+}
+
+// carryPoints keeps the points of a series that are within the time range
+// and copies the last point of each interval to the start of the next one.
+// The copy is added before the points of the next interval so it stays
+// first when they are sorted by time.
+func (itr *unsignedSlidingWindowIterator) carryPoints(points []UnsignedPoint) {
+	if !itr.opt.Ascending {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+
+	for i, p := range points {
+		start, end := itr.opt.Window(p.Time)
+		if end > itr.opt.StartTime && start <= itr.opt.EndTime {
+			itr.points = append(itr.points, p)
+		}
+		if i+1 < len(points) && points[i+1].Time < end {
+			continue
+		} else if end >= influxql.MaxTime || end > itr.opt.EndTime {
+			continue
+		}
+		if _, next := itr.opt.Window(end); next > itr.opt.StartTime {
+			c := p
+			c.Time = end
+			itr.points = append(itr.points, c)
+		}
+	}
+}
+
+// unsignedNilPointIterator emits a single nil point for a series.
+type unsignedNilPointIterator struct {
+	point *UnsignedPoint
+}
+
+// Stats returns stats from the input iterator.
+func (itr *unsignedNilPointIterator) Stats() IteratorStats { return IteratorStats{} }
+
+// Close closes the iterator.
+func (itr *unsignedNilPointIterator) Close() error { return nil }
+
+// Next returns the nil point once.
+func (itr *unsignedNilPointIterator) Next() (*UnsignedPoint, error) {
+	p := itr.point
+	itr.point = nil
+	return p, nil
+}
+
+// unsignedInterruptIterator represents a unsigned implementation of InterruptIterator.
+type unsignedInterruptIterator struct {
+	input   UnsignedIterator
+	closing <-chan struct{}
+	count   int
+}
+
+func newUnsignedInterruptIterator(input UnsignedIterator, closing <-chan struct{}) *unsignedInterruptIterator {
+	return &unsignedInterruptIterator{input: input, closing: closing}
 }
 
 func (itr *unsignedInterruptIterator) Stats() IteratorStats { return itr.input.Stats() }
@@ -6370,6 +7630,7 @@ type unsignedReduceFloatPoint struct {
 	Tags       Tags
 	Aggregator UnsignedPointAggregator
 	Emitter    FloatPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -6387,7 +7648,7 @@ func (itr *unsignedReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -6407,7 +7668,7 @@ func (itr *unsignedReduceFloatIterator) reduce() ([]FloatPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -6440,7 +7701,13 @@ func (itr *unsignedReduceFloatIterator) reduce() ([]FloatPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateUnsigned(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -6464,7 +7731,12 @@ func (itr *unsignedReduceFloatIterator) reduce() ([]FloatPoint, error) {
 	a := make([]FloatPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []FloatPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -6658,6 +7930,7 @@ type unsignedReduceIntegerPoint struct {
 	Tags       Tags
 	Aggregator UnsignedPointAggregator
 	Emitter    IntegerPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -6675,7 +7948,7 @@ func (itr *unsignedReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -6695,7 +7968,7 @@ func (itr *unsignedReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -6728,7 +8001,13 @@ func (itr *unsignedReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateUnsigned(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -6752,7 +8031,12 @@ func (itr *unsignedReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 	a := make([]IntegerPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []IntegerPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -6946,6 +8230,7 @@ type unsignedReduceUnsignedPoint struct {
 	Tags       Tags
 	Aggregator UnsignedPointAggregator
 	Emitter    UnsignedPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -6963,7 +8248,7 @@ func (itr *unsignedReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -6983,7 +8268,7 @@ func (itr *unsignedReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -7016,7 +8301,13 @@ func (itr *unsignedReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateUnsigned(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -7040,7 +8331,12 @@ func (itr *unsignedReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 	a := make([]UnsignedPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []UnsignedPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -7234,6 +8530,7 @@ type unsignedReduceStringPoint struct {
 	Tags       Tags
 	Aggregator UnsignedPointAggregator
 	Emitter    StringPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -7251,7 +8548,7 @@ func (itr *unsignedReduceStringIterator) reduce() ([]StringPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -7271,7 +8568,7 @@ func (itr *unsignedReduceStringIterator) reduce() ([]StringPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -7304,7 +8601,13 @@ func (itr *unsignedReduceStringIterator) reduce() ([]StringPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateUnsigned(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -7328,7 +8631,12 @@ func (itr *unsignedReduceStringIterator) reduce() ([]StringPoint, error) {
 	a := make([]StringPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []StringPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -7522,6 +8830,7 @@ type unsignedReduceBooleanPoint struct {
 	Tags       Tags
 	Aggregator UnsignedPointAggregator
 	Emitter    BooleanPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -7539,7 +8848,7 @@ func (itr *unsignedReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -7559,7 +8868,7 @@ func (itr *unsignedReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -7592,7 +8901,13 @@ func (itr *unsignedReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateUnsigned(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -7616,7 +8931,12 @@ func (itr *unsignedReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 	a := make([]BooleanPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []BooleanPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -8310,22 +9630,34 @@ type stringSortedMergeIterator struct {
 	inputs []StringIterator
 	heap   *stringSortedMergeHeap
 	init   bool
+
+	// The points left to return from the last name, dimensions and time
+	// read. Only used when deduplicating points across inputs.
+	buf []*StringPoint
 }
 
 // newStringSortedMergeIterator returns an instance of stringSortedMergeIterator.
 func newStringSortedMergeIterator(inputs []StringIterator, opt IteratorOptions) Iterator {
+	return newStringSortedMergeDedupeIterator(inputs, opt, false)
+}
+
+// newStringSortedMergeDedupeIterator returns an instance of stringSortedMergeIterator.
+// If dedupe is set, points that are equal to a point already emitted from a
+// later input are dropped.
+func newStringSortedMergeDedupeIterator(inputs []StringIterator, opt IteratorOptions, dedupe bool) Iterator {
 	itr := &stringSortedMergeIterator{
 		inputs: inputs,
 		heap: &stringSortedMergeHeap{
-			items: make([]*stringSortedMergeHeapItem, 0, len(inputs)),
-			opt:   opt,
+			items:  make([]*stringSortedMergeHeapItem, 0, len(inputs)),
+			opt:    opt,
+			dedupe: dedupe,
 		},
 	}
 
 	// Initialize heap items.
-	for _, input := range inputs {
+	for i, input := range inputs {
 		// Append to the heap.
-		itr.heap.items = append(itr.heap.items, &stringSortedMergeHeapItem{itr: input})
+		itr.heap.items = append(itr.heap.items, &stringSortedMergeHeapItem{itr: input, index: i})
 	}
 
 	return itr
@@ -8371,26 +9703,78 @@ func (itr *stringSortedMergeIterator) pop() (*StringPoint, error) {
 		itr.init = true
 	}
 
+	if itr.heap.dedupe {
+		return itr.popDedupe()
+	}
+	p, _, err := itr.read()
+	return p, err
+}
+
+// read returns the next point from the heap and the index of its input.
+// Reads the next point from item's cursor and puts it back on the heap.
+func (itr *stringSortedMergeIterator) read() (*StringPoint, int, error) {
 	if len(itr.heap.items) == 0 {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Read the next item from the heap.
 	item := heap.Pop(itr.heap).(*stringSortedMergeHeapItem)
 	if item.err != nil {
-		return nil, item.err
+		return nil, 0, item.err
 	} else if item.point == nil {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Copy the point for return.
 	p := item.point.Clone()
+	index := item.index
 
 	// Read the next item from the cursor. Push back to heap if one exists.
 	if item.point, item.err = item.itr.Next(); item.point != nil {
 		heap.Push(itr.heap, item)
 	}
+	return p, index, nil
+}
+
+// popDedupe returns the next point from the heap. A series read from more
+// than one input at the same time is only returned once, from the last input.
+func (itr *stringSortedMergeIterator) popDedupe() (*StringPoint, error) {
+	if len(itr.buf) == 0 {
+		p, index, err := itr.read()
+		if p == nil || err != nil {
+			return nil, err
+		}
+
+		// The points with the same name, dimensions and time are next on the
+		// heap, but the points of a series are not always next to each other.
+		// Keep the point of each series from the last input in heap order.
+		seen := map[string]int{p.Tags.ID(): 0}
+		indexes := []int{index}
+		itr.buf = append(itr.buf, p)
+		for len(itr.heap.items) > 0 && itr.heap.sameTime(itr.heap.items[0].point, p) {
+			next, index, err := itr.read()
+			if err != nil {
+				return nil, err
+			}
+
+			if i, ok := seen[next.Tags.ID()]; !ok {
+				seen[next.Tags.ID()] = len(itr.buf)
+				itr.buf, indexes = append(itr.buf, next), append(indexes, index)
+			} else if index > indexes[i] {
+				itr.buf[i], indexes[i] = next, index
+			}
+		}
+
+		// Only the dimensions are returned once the series are told apart.
+		if itr.heap.opt.SeriesTags {
+			for _, p := range itr.buf {
+				p.Tags = p.Tags.Subset(itr.heap.opt.Dimensions)
+			}
+		}
+	}
 
+	p := itr.buf[0]
+	itr.buf = itr.buf[1:]
 	return p, nil
 }
 
@@ -8402,15 +9786,27 @@ func (itr *stringSortedMergeIterator) pop() (*StringPoint, error) {
 //     - By their Aux field values.
 //
 type stringSortedMergeHeap struct {
-	opt   IteratorOptions
-	items []*stringSortedMergeHeapItem
+	opt    IteratorOptions
+	items  []*stringSortedMergeHeapItem
+	dedupe bool
 }
 
 func (h *stringSortedMergeHeap) Len() int      { return len(h.items) }
 func (h *stringSortedMergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
 func (h *stringSortedMergeHeap) Less(i, j int) bool {
-	x, y := h.items[i].point, h.items[j].point
+	return h.less(h.items[i].point, h.items[j].point)
+}
 
+// sameTime returns true if both points have the same name, dimensions and time.
+func (h *stringSortedMergeHeap) sameTime(x, y *StringPoint) bool {
+	if x.Name != y.Name || x.Time != y.Time {
+		return false
+	}
+	xTags, yTags := x.Tags.Subset(h.opt.Dimensions), y.Tags.Subset(h.opt.Dimensions)
+	return xTags.Equals(&yTags)
+}
+
+func (h *stringSortedMergeHeap) less(x, y *StringPoint) bool {
 	if h.opt.Ascending {
 		if x.Name != y.Name {
 			return x.Name < y.Name
@@ -8482,6 +9878,7 @@ type stringSortedMergeHeapItem struct {
 	point *StringPoint
 	err   error
 	itr   StringIterator
+	index int
 }
 
 // stringIteratorScanner scans the results of a StringIterator into a map.
@@ -8716,7 +10113,7 @@ type stringFillIterator struct {
 
 func newStringFillIterator(input StringIterator, expr influxql.Expr, opt IteratorOptions) *stringFillIterator {
 	if opt.Fill == influxql.NullFill {
-		if expr, ok := expr.(*influxql.Call); ok && expr.Name == "count" {
+		if expr, ok := expr.(*influxql.Call); ok && (expr.Name == "count" || expr.Name == "count_rate") {
 			opt.Fill = influxql.NumberFill
 			opt.FillValue = ""
 		}
@@ -8805,7 +10202,7 @@ func (itr *stringFillIterator) Next() (*StringPoint, error) {
 
 	// Check if the point is our next expected point.
 CONSTRUCT:
-	if p == nil || (itr.opt.Ascending && p.Time > itr.window.time) || (!itr.opt.Ascending && p.Time < itr.window.time) {
+	if p == nil || (itr.opt.Ascending && itr.windowTime(p) > itr.window.time) || (!itr.opt.Ascending && itr.windowTime(p) < itr.window.time) {
 		if p != nil {
 			itr.input.unread(p)
 		}
@@ -8831,6 +10228,28 @@ CONSTRUCT:
 			} else {
 				p.Nil = true
 			}
+		case NextFill:
+			next, err := itr.input.peek()
+			if err != nil {
+				return nil, err
+			} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
+				p.Value = next.Value
+				p.Nil = next.Nil
+			} else {
+				p.Nil = true
+			}
+		case ExprFill:
+			if !itr.prev.Nil {
+				if v, ok := castToString(evalFillExpr(itr.opt.FillValue, itr.prev.Value)); ok {
+					p.Value = v
+					// The next empty interval is filled from this one.
+					itr.prev = *p
+				} else {
+					p.Nil = true
+				}
+			} else {
+				p.Nil = true
+			}
 		}
 	} else {
 		itr.prev = *p
@@ -8839,7 +10258,16 @@ CONSTRUCT:
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
 	// window.
-	if itr.opt.Ascending {
+	if itr.opt.Interval.Months > 0 {
+		// Calendar intervals vary in length and their windows already
+		// account for the zone offset.
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+		return p, nil
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
@@ -8859,6 +10287,16 @@ CONSTRUCT:
 	return p, nil
 }
 
+// windowTime returns the interval time of the point. Points keep their own
+// time when PointTime is set so the start of their interval is used.
+func (itr *stringFillIterator) windowTime(p *StringPoint) int64 {
+	if itr.opt.PointTime {
+		t, _ := itr.opt.Window(p.Time)
+		return t
+	}
+	return p.Time
+}
+
 // stringIntervalIterator represents a string implementation of IntervalIterator.
 type stringIntervalIterator struct {
 	input StringIterator
@@ -8886,6 +10324,268 @@ func (itr *stringIntervalIterator) Next() (*StringPoint, error) {
 	return p, nil
 }
 
+// stringLastValueIterator emits, for every interval of a series, the last
+// value that was observed at or before the start of that interval.
+//
+// The input is expected to be sorted by name, tags, and then ascending time
+// and may contain points from before the start time so that the value in
+// effect at the first interval can be carried forward.
+type stringLastValueIterator struct {
+	input     *bufStringIterator
+	prev      StringPoint
+	startTime int64
+	endTime   int64
+	dims      []string
+	opt       IteratorOptions
+
+	window struct {
+		name string
+		tags Tags
+		time int64
+		init bool
+		done bool
+	}
+}
+
+func newStringLastValueIterator(input StringIterator, startTime int64, opt IteratorOptions) *stringLastValueIterator {
+	itr := &stringLastValueIterator{
+		input:     newBufStringIterator(input),
+		prev:      StringPoint{Nil: true},
+		startTime: startTime,
+		endTime:   opt.EndTime,
+		dims:      opt.GetDimensions(),
+		opt:       opt,
+	}
+	itr.window.done = true
+	return itr
+}
+
+func (itr *stringLastValueIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *stringLastValueIterator) Close() error         { return itr.input.Close() }
+
+func (itr *stringLastValueIterator) Next() (*StringPoint, error) {
+	for {
+		if itr.window.done {
+			// Discard anything remaining from the previous series and
+			// start at the first interval of the next one.
+			p, err := itr.next()
+			for p != nil && itr.window.init && p.Name == itr.window.name && p.Tags.Subset(itr.dims).ID() == itr.window.tags.ID() {
+				p, err = itr.next()
+			}
+			if p == nil || err != nil {
+				return nil, err
+			}
+			itr.window.name, itr.window.tags = p.Name, p.Tags.Subset(itr.dims)
+			itr.window.time, _ = itr.opt.Window(itr.startTime)
+			if itr.startTime == influxql.MinTime {
+				itr.window.time, _ = itr.opt.Window(p.Time)
+			}
+			itr.window.done, itr.window.init = false, true
+			itr.prev = StringPoint{Nil: true}
+			itr.input.unread(p)
+		}
+
+		// Read every point in this series up to and including the start
+		// of the current interval and remember the last one.
+		more := false
+		for {
+			p, err := itr.next()
+			if err != nil {
+				return nil, err
+			} else if p == nil {
+				break
+			} else if p.Name != itr.window.name || p.Tags.Subset(itr.dims).ID() != itr.window.tags.ID() {
+				itr.input.unread(p)
+				break
+			} else if p.Time > itr.window.time {
+				itr.input.unread(p)
+				more = true
+				break
+			}
+			itr.prev = *p
+		}
+
+		t := itr.window.time
+		_, next := itr.opt.Window(t)
+		itr.window.time = next
+		if next > itr.endTime || (!more && itr.endTime == influxql.MaxTime) {
+			itr.window.done = true
+		}
+
+		if itr.prev.Nil {
+			continue
+		}
+		return &StringPoint{
+			Name:  itr.window.name,
+			Tags:  itr.window.tags,
+			Time:  t,
+			Value: itr.prev.Value,
+		}, nil
+	}
+}
+
+// next returns the next non-nil point from the input.
+func (itr *stringLastValueIterator) next() (*StringPoint, error) {
+	for {
+		p, err := itr.input.Next()
+		if p == nil || err != nil {
+			return nil, err
+		} else if p.Nil {
+			continue
+		}
+		return p, nil
+	}
+}
+
+// stringSlidingWindowIterator copies each point into every interval
+// whose trailing window contains the point. The window of an interval ends
+// at the end of the interval, so reducing the copies by interval computes
+// a statistic over overlapping windows.
+//
+// If carry is set, each point is kept in its own interval and the last point
+// of an interval is also copied to the start of the next interval, so the
+// next interval sees the change from the previous one.
+//
+// The input is expected to be sorted by name, tags, and then time and to
+// contain points from up to one window before the start time.
+type stringSlidingWindowIterator struct {
+	input  *bufStringIterator
+	window int64
+	carry  bool
+	opt    IteratorOptions
+	points []StringPoint
+}
+
+func newStringSlidingWindowIterator(input StringIterator, window time.Duration, opt IteratorOptions) *stringSlidingWindowIterator {
+	return &stringSlidingWindowIterator{
+		input:  newBufStringIterator(input),
+		window: int64(window),
+		opt:    opt,
+	}
+}
+
+func newStringCarryWindowIterator(input StringIterator, opt IteratorOptions) *stringSlidingWindowIterator {
+	return &stringSlidingWindowIterator{
+		input: newBufStringIterator(input),
+		carry: true,
+		opt:   opt,
+	}
+}
+
+func (itr *stringSlidingWindowIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *stringSlidingWindowIterator) Close() error         { return itr.input.Close() }
+
+func (itr *stringSlidingWindowIterator) Next() (*StringPoint, error) {
+	for len(itr.points) == 0 {
+		if more, err := itr.readSeries(); err != nil || !more {
+			return nil, err
+		}
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return &p, nil
+}
+
+// readSeries reads every point of the next series and copies each one into
+// the intervals it belongs to. It returns false when the input is exhausted.
+func (itr *stringSlidingWindowIterator) readSeries() (bool, error) {
+	var name, tags string
+	var points []StringPoint
+	more := false
+	for {
+		p, err := itr.input.Next()
+		if err != nil {
+			return false, err
+		} else if p == nil {
+			break
+		}
+
+		id := p.Tags.Subset(itr.opt.Dimensions).ID()
+		if !more {
+			name, tags, more = p.Name, id, true
+		} else if p.Name != name || id != tags {
+			itr.input.unread(p)
+			break
+		}
+
+		if p.Nil {
+			continue
+		} else if itr.carry {
+			points = append(points, *p)
+			continue
+		}
+		start, end := itr.opt.Window(p.Time)
+		for end-itr.window <= p.Time {
+			if end > itr.opt.StartTime && start <= itr.opt.EndTime {
+				c := *p
+				c.Time = start
+				itr.points = append(itr.points, c)
+			}
+			if end >= influxql.MaxTime {
+				break
+			}
+			start, end = itr.opt.Window(end)
+		}
+	}
+
+	if itr.carry {
+		itr.carryPoints(points)
+	}
+	if itr.opt.Ascending {
+		sort.SliceStable(itr.points, func(i, j int) bool { return itr.points[i].Time < itr.points[j].Time })
+	} else {
+		sort.SliceStable(itr.points, func(i, j int) bool { return itr.points[i].Time > itr.points[j].Time })
+	}
+	return more, nil
+}
+
+// carryPoints keeps the points of a series that are within the time range
+// and copies the last point of each interval to the start of the next one.
+// The copy is added before the points of the next interval so it stays
+// first when they are sorted by time.
+func (itr *stringSlidingWindowIterator) carryPoints(points []StringPoint) {
+	if !itr.opt.Ascending {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+
+	for i, p := range points {
+		start, end := itr.opt.Window(p.Time)
+		if end > itr.opt.StartTime && start <= itr.opt.EndTime {
+			itr.points = append(itr.points, p)
+		}
+		if i+1 < len(points) && points[i+1].Time < end {
+			continue
+		} else if end >= influxql.MaxTime || end > itr.opt.EndTime {
+			continue
+		}
+		if _, next := itr.opt.Window(end); next > itr.opt.StartTime {
+			c := p
+			c.Time = end
+			itr.points = append(itr.points, c)
+		}
+	}
+}
+
+// stringNilPointIterator emits a single nil point for a series.
+type stringNilPointIterator struct {
+	point *StringPoint
+}
+
+// Stats returns stats from the input iterator.
+func (itr *stringNilPointIterator) Stats() IteratorStats { return IteratorStats{} }
+
+// Close closes the iterator.
+func (itr *stringNilPointIterator) Close() error { return nil }
+
+// Next returns the nil point once.
+func (itr *stringNilPointIterator) Next() (*StringPoint, error) {
+	p := itr.point
+	itr.point = nil
+	return p, nil
+}
+
 // stringInterruptIterator represents a string implementation of InterruptIterator.
 type stringInterruptIterator struct {
 	input   StringIterator
@@ -9020,6 +10720,7 @@ type stringReduceFloatPoint struct {
 	Tags       Tags
 	Aggregator StringPointAggregator
 	Emitter    FloatPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -9037,7 +10738,7 @@ func (itr *stringReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -9057,7 +10758,7 @@ func (itr *stringReduceFloatIterator) reduce() ([]FloatPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -9090,7 +10791,13 @@ func (itr *stringReduceFloatIterator) reduce() ([]FloatPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateString(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -9114,7 +10821,12 @@ func (itr *stringReduceFloatIterator) reduce() ([]FloatPoint, error) {
 	a := make([]FloatPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []FloatPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -9308,6 +11020,7 @@ type stringReduceIntegerPoint struct {
 	Tags       Tags
 	Aggregator StringPointAggregator
 	Emitter    IntegerPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -9325,7 +11038,7 @@ func (itr *stringReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -9345,7 +11058,7 @@ func (itr *stringReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -9378,7 +11091,13 @@ func (itr *stringReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateString(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -9402,7 +11121,12 @@ func (itr *stringReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 	a := make([]IntegerPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []IntegerPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -9596,6 +11320,7 @@ type stringReduceUnsignedPoint struct {
 	Tags       Tags
 	Aggregator StringPointAggregator
 	Emitter    UnsignedPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -9613,7 +11338,7 @@ func (itr *stringReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -9633,7 +11358,7 @@ func (itr *stringReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -9666,7 +11391,13 @@ func (itr *stringReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateString(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -9690,7 +11421,12 @@ func (itr *stringReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 	a := make([]UnsignedPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []UnsignedPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -9884,6 +11620,7 @@ type stringReduceStringPoint struct {
 	Tags       Tags
 	Aggregator StringPointAggregator
 	Emitter    StringPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -9901,7 +11638,7 @@ func (itr *stringReduceStringIterator) reduce() ([]StringPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -9921,7 +11658,7 @@ func (itr *stringReduceStringIterator) reduce() ([]StringPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -9954,7 +11691,13 @@ func (itr *stringReduceStringIterator) reduce() ([]StringPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateString(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -9978,7 +11721,12 @@ func (itr *stringReduceStringIterator) reduce() ([]StringPoint, error) {
 	a := make([]StringPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []StringPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -10172,6 +11920,7 @@ type stringReduceBooleanPoint struct {
 	Tags       Tags
 	Aggregator StringPointAggregator
 	Emitter    BooleanPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -10189,7 +11938,7 @@ func (itr *stringReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -10209,7 +11958,7 @@ func (itr *stringReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -10242,7 +11991,13 @@ func (itr *stringReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateString(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -10266,7 +12021,12 @@ func (itr *stringReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 	a := make([]BooleanPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []BooleanPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -10960,22 +12720,34 @@ type booleanSortedMergeIterator struct {
 	inputs []BooleanIterator
 	heap   *booleanSortedMergeHeap
 	init   bool
+
+	// The points left to return from the last name, dimensions and time
+	// read. Only used when deduplicating points across inputs.
+	buf []*BooleanPoint
 }
 
 // newBooleanSortedMergeIterator returns an instance of booleanSortedMergeIterator.
 func newBooleanSortedMergeIterator(inputs []BooleanIterator, opt IteratorOptions) Iterator {
+	return newBooleanSortedMergeDedupeIterator(inputs, opt, false)
+}
+
+// newBooleanSortedMergeDedupeIterator returns an instance of booleanSortedMergeIterator.
+// If dedupe is set, points that are equal to a point already emitted from a
+// later input are dropped.
+func newBooleanSortedMergeDedupeIterator(inputs []BooleanIterator, opt IteratorOptions, dedupe bool) Iterator {
 	itr := &booleanSortedMergeIterator{
 		inputs: inputs,
 		heap: &booleanSortedMergeHeap{
-			items: make([]*booleanSortedMergeHeapItem, 0, len(inputs)),
-			opt:   opt,
+			items:  make([]*booleanSortedMergeHeapItem, 0, len(inputs)),
+			opt:    opt,
+			dedupe: dedupe,
 		},
 	}
 
 	// Initialize heap items.
-	for _, input := range inputs {
+	for i, input := range inputs {
 		// Append to the heap.
-		itr.heap.items = append(itr.heap.items, &booleanSortedMergeHeapItem{itr: input})
+		itr.heap.items = append(itr.heap.items, &booleanSortedMergeHeapItem{itr: input, index: i})
 	}
 
 	return itr
@@ -11021,26 +12793,78 @@ func (itr *booleanSortedMergeIterator) pop() (*BooleanPoint, error) {
 		itr.init = true
 	}
 
+	if itr.heap.dedupe {
+		return itr.popDedupe()
+	}
+	p, _, err := itr.read()
+	return p, err
+}
+
+// read returns the next point from the heap and the index of its input.
+// Reads the next point from item's cursor and puts it back on the heap.
+func (itr *booleanSortedMergeIterator) read() (*BooleanPoint, int, error) {
 	if len(itr.heap.items) == 0 {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Read the next item from the heap.
 	item := heap.Pop(itr.heap).(*booleanSortedMergeHeapItem)
 	if item.err != nil {
-		return nil, item.err
+		return nil, 0, item.err
 	} else if item.point == nil {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	// Copy the point for return.
 	p := item.point.Clone()
+	index := item.index
 
 	// Read the next item from the cursor. Push back to heap if one exists.
 	if item.point, item.err = item.itr.Next(); item.point != nil {
 		heap.Push(itr.heap, item)
 	}
+	return p, index, nil
+}
+
+// popDedupe returns the next point from the heap. A series read from more
+// than one input at the same time is only returned once, from the last input.
+func (itr *booleanSortedMergeIterator) popDedupe() (*BooleanPoint, error) {
+	if len(itr.buf) == 0 {
+		p, index, err := itr.read()
+		if p == nil || err != nil {
+			return nil, err
+		}
+
+		// The points with the same name, dimensions and time are next on the
+		// heap, but the points of a series are not always next to each other.
+		// Keep the point of each series from the last input in heap order.
+		seen := map[string]int{p.Tags.ID(): 0}
+		indexes := []int{index}
+		itr.buf = append(itr.buf, p)
+		for len(itr.heap.items) > 0 && itr.heap.sameTime(itr.heap.items[0].point, p) {
+			next, index, err := itr.read()
+			if err != nil {
+				return nil, err
+			}
+
+			if i, ok := seen[next.Tags.ID()]; !ok {
+				seen[next.Tags.ID()] = len(itr.buf)
+				itr.buf, indexes = append(itr.buf, next), append(indexes, index)
+			} else if index > indexes[i] {
+				itr.buf[i], indexes[i] = next, index
+			}
+		}
+
+		// Only the dimensions are returned once the series are told apart.
+		if itr.heap.opt.SeriesTags {
+			for _, p := range itr.buf {
+				p.Tags = p.Tags.Subset(itr.heap.opt.Dimensions)
+			}
+		}
+	}
 
+	p := itr.buf[0]
+	itr.buf = itr.buf[1:]
 	return p, nil
 }
 
@@ -11052,15 +12876,27 @@ func (itr *booleanSortedMergeIterator) pop() (*BooleanPoint, error) {
 //     - By their Aux field values.
 //
 type booleanSortedMergeHeap struct {
-	opt   IteratorOptions
-	items []*booleanSortedMergeHeapItem
+	opt    IteratorOptions
+	items  []*booleanSortedMergeHeapItem
+	dedupe bool
 }
 
 func (h *booleanSortedMergeHeap) Len() int      { return len(h.items) }
 func (h *booleanSortedMergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
 func (h *booleanSortedMergeHeap) Less(i, j int) bool {
-	x, y := h.items[i].point, h.items[j].point
+	return h.less(h.items[i].point, h.items[j].point)
+}
+
+// sameTime returns true if both points have the same name, dimensions and time.
+func (h *booleanSortedMergeHeap) sameTime(x, y *BooleanPoint) bool {
+	if x.Name != y.Name || x.Time != y.Time {
+		return false
+	}
+	xTags, yTags := x.Tags.Subset(h.opt.Dimensions), y.Tags.Subset(h.opt.Dimensions)
+	return xTags.Equals(&yTags)
+}
 
+func (h *booleanSortedMergeHeap) less(x, y *BooleanPoint) bool {
 	if h.opt.Ascending {
 		if x.Name != y.Name {
 			return x.Name < y.Name
@@ -11132,6 +12968,7 @@ type booleanSortedMergeHeapItem struct {
 	point *BooleanPoint
 	err   error
 	itr   BooleanIterator
+	index int
 }
 
 // booleanIteratorScanner scans the results of a BooleanIterator into a map.
@@ -11366,7 +13203,7 @@ type booleanFillIterator struct {
 
 func newBooleanFillIterator(input BooleanIterator, expr influxql.Expr, opt IteratorOptions) *booleanFillIterator {
 	if opt.Fill == influxql.NullFill {
-		if expr, ok := expr.(*influxql.Call); ok && expr.Name == "count" {
+		if expr, ok := expr.(*influxql.Call); ok && (expr.Name == "count" || expr.Name == "count_rate") {
 			opt.Fill = influxql.NumberFill
 			opt.FillValue = false
 		}
@@ -11455,7 +13292,7 @@ func (itr *booleanFillIterator) Next() (*BooleanPoint, error) {
 
 	// Check if the point is our next expected point.
 CONSTRUCT:
-	if p == nil || (itr.opt.Ascending && p.Time > itr.window.time) || (!itr.opt.Ascending && p.Time < itr.window.time) {
+	if p == nil || (itr.opt.Ascending && itr.windowTime(p) > itr.window.time) || (!itr.opt.Ascending && itr.windowTime(p) < itr.window.time) {
 		if p != nil {
 			itr.input.unread(p)
 		}
@@ -11481,6 +13318,28 @@ CONSTRUCT:
 			} else {
 				p.Nil = true
 			}
+		case NextFill:
+			next, err := itr.input.peek()
+			if err != nil {
+				return nil, err
+			} else if next != nil && next.Name == itr.window.name && next.Tags.ID() == itr.window.tags.ID() {
+				p.Value = next.Value
+				p.Nil = next.Nil
+			} else {
+				p.Nil = true
+			}
+		case ExprFill:
+			if !itr.prev.Nil {
+				if v, ok := castToBoolean(evalFillExpr(itr.opt.FillValue, itr.prev.Value)); ok {
+					p.Value = v
+					// The next empty interval is filled from this one.
+					itr.prev = *p
+				} else {
+					p.Nil = true
+				}
+			} else {
+				p.Nil = true
+			}
 		}
 	} else {
 		itr.prev = *p
@@ -11489,7 +13348,16 @@ CONSTRUCT:
 	// Advance the expected time. Do not advance to a new window here
 	// as there may be lingering points with the same timestamp in the previous
 	// window.
-	if itr.opt.Ascending {
+	if itr.opt.Interval.Months > 0 {
+		// Calendar intervals vary in length and their windows already
+		// account for the zone offset.
+		if itr.opt.Ascending {
+			_, itr.window.time = itr.opt.Window(itr.window.time)
+		} else {
+			itr.window.time, _ = itr.opt.Window(itr.window.time - 1)
+		}
+		return p, nil
+	} else if itr.opt.Ascending {
 		itr.window.time += int64(itr.opt.Interval.Duration)
 	} else {
 		itr.window.time -= int64(itr.opt.Interval.Duration)
@@ -11509,6 +13377,16 @@ CONSTRUCT:
 	return p, nil
 }
 
+// windowTime returns the interval time of the point. Points keep their own
+// time when PointTime is set so the start of their interval is used.
+func (itr *booleanFillIterator) windowTime(p *BooleanPoint) int64 {
+	if itr.opt.PointTime {
+		t, _ := itr.opt.Window(p.Time)
+		return t
+	}
+	return p.Time
+}
+
 // booleanIntervalIterator represents a boolean implementation of IntervalIterator.
 type booleanIntervalIterator struct {
 	input BooleanIterator
@@ -11536,6 +13414,268 @@ func (itr *booleanIntervalIterator) Next() (*BooleanPoint, error) {
 	return p, nil
 }
 
+// booleanLastValueIterator emits, for every interval of a series, the last
+// value that was observed at or before the start of that interval.
+//
+// The input is expected to be sorted by name, tags, and then ascending time
+// and may contain points from before the start time so that the value in
+// effect at the first interval can be carried forward.
+type booleanLastValueIterator struct {
+	input     *bufBooleanIterator
+	prev      BooleanPoint
+	startTime int64
+	endTime   int64
+	dims      []string
+	opt       IteratorOptions
+
+	window struct {
+		name string
+		tags Tags
+		time int64
+		init bool
+		done bool
+	}
+}
+
+func newBooleanLastValueIterator(input BooleanIterator, startTime int64, opt IteratorOptions) *booleanLastValueIterator {
+	itr := &booleanLastValueIterator{
+		input:     newBufBooleanIterator(input),
+		prev:      BooleanPoint{Nil: true},
+		startTime: startTime,
+		endTime:   opt.EndTime,
+		dims:      opt.GetDimensions(),
+		opt:       opt,
+	}
+	itr.window.done = true
+	return itr
+}
+
+func (itr *booleanLastValueIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *booleanLastValueIterator) Close() error         { return itr.input.Close() }
+
+func (itr *booleanLastValueIterator) Next() (*BooleanPoint, error) {
+	for {
+		if itr.window.done {
+			// Discard anything remaining from the previous series and
+			// start at the first interval of the next one.
+			p, err := itr.next()
+			for p != nil && itr.window.init && p.Name == itr.window.name && p.Tags.Subset(itr.dims).ID() == itr.window.tags.ID() {
+				p, err = itr.next()
+			}
+			if p == nil || err != nil {
+				return nil, err
+			}
+			itr.window.name, itr.window.tags = p.Name, p.Tags.Subset(itr.dims)
+			itr.window.time, _ = itr.opt.Window(itr.startTime)
+			if itr.startTime == influxql.MinTime {
+				itr.window.time, _ = itr.opt.Window(p.Time)
+			}
+			itr.window.done, itr.window.init = false, true
+			itr.prev = BooleanPoint{Nil: true}
+			itr.input.unread(p)
+		}
+
+		// Read every point in this series up to and including the start
+		// of the current interval and remember the last one.
+		more := false
+		for {
+			p, err := itr.next()
+			if err != nil {
+				return nil, err
+			} else if p == nil {
+				break
+			} else if p.Name != itr.window.name || p.Tags.Subset(itr.dims).ID() != itr.window.tags.ID() {
+				itr.input.unread(p)
+				break
+			} else if p.Time > itr.window.time {
+				itr.input.unread(p)
+				more = true
+				break
+			}
+			itr.prev = *p
+		}
+
+		t := itr.window.time
+		_, next := itr.opt.Window(t)
+		itr.window.time = next
+		if next > itr.endTime || (!more && itr.endTime == influxql.MaxTime) {
+			itr.window.done = true
+		}
+
+		if itr.prev.Nil {
+			continue
+		}
+		return &BooleanPoint{
+			Name:  itr.window.name,
+			Tags:  itr.window.tags,
+			Time:  t,
+			Value: itr.prev.Value,
+		}, nil
+	}
+}
+
+// next returns the next non-nil point from the input.
+func (itr *booleanLastValueIterator) next() (*BooleanPoint, error) {
+	for {
+		p, err := itr.input.Next()
+		if p == nil || err != nil {
+			return nil, err
+		} else if p.Nil {
+			continue
+		}
+		return p, nil
+	}
+}
+
+// booleanSlidingWindowIterator copies each point into every interval
+// whose trailing window contains the point. The window of an interval ends
+// at the end of the interval, so reducing the copies by interval computes
+// a statistic over overlapping windows.
+//
+// If carry is set, each point is kept in its own interval and the last point
+// of an interval is also copied to the start of the next interval, so the
+// next interval sees the change from the previous one.
+//
+// The input is expected to be sorted by name, tags, and then time and to
+// contain points from up to one window before the start time.
+type booleanSlidingWindowIterator struct {
+	input  *bufBooleanIterator
+	window int64
+	carry  bool
+	opt    IteratorOptions
+	points []BooleanPoint
+}
+
+func newBooleanSlidingWindowIterator(input BooleanIterator, window time.Duration, opt IteratorOptions) *booleanSlidingWindowIterator {
+	return &booleanSlidingWindowIterator{
+		input:  newBufBooleanIterator(input),
+		window: int64(window),
+		opt:    opt,
+	}
+}
+
+func newBooleanCarryWindowIterator(input BooleanIterator, opt IteratorOptions) *booleanSlidingWindowIterator {
+	return &booleanSlidingWindowIterator{
+		input: newBufBooleanIterator(input),
+		carry: true,
+		opt:   opt,
+	}
+}
+
+func (itr *booleanSlidingWindowIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *booleanSlidingWindowIterator) Close() error         { return itr.input.Close() }
+
+func (itr *booleanSlidingWindowIterator) Next() (*BooleanPoint, error) {
+	for len(itr.points) == 0 {
+		if more, err := itr.readSeries(); err != nil || !more {
+			return nil, err
+		}
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return &p, nil
+}
+
+// readSeries reads every point of the next series and copies each one into
+// the intervals it belongs to. It returns false when the input is exhausted.
+func (itr *booleanSlidingWindowIterator) readSeries() (bool, error) {
+	var name, tags string
+	var points []BooleanPoint
+	more := false
+	for {
+		p, err := itr.input.Next()
+		if err != nil {
+			return false, err
+		} else if p == nil {
+			break
+		}
+
+		id := p.Tags.Subset(itr.opt.Dimensions).ID()
+		if !more {
+			name, tags, more = p.Name, id, true
+		} else if p.Name != name || id != tags {
+			itr.input.unread(p)
+			break
+		}
+
+		if p.Nil {
+			continue
+		} else if itr.carry {
+			points = append(points, *p)
+			continue
+		}
+		start, end := itr.opt.Window(p.Time)
+		for end-itr.window <= p.Time {
+			if end > itr.opt.StartTime && start <= itr.opt.EndTime {
+				c := *p
+				c.Time = start
+				itr.points = append(itr.points, c)
+			}
+			if end >= influxql.MaxTime {
+				break
+			}
+			start, end = itr.opt.Window(end)
+		}
+	}
+
+	if itr.carry {
+		itr.carryPoints(points)
+	}
+	if itr.opt.Ascending {
+		sort.SliceStable(itr.points, func(i, j int) bool { return itr.points[i].Time < itr.points[j].Time })
+	} else {
+		sort.SliceStable(itr.points, func(i, j int) bool { return itr.points[i].Time > itr.points[j].Time })
+	}
+	return more, nil
+}
+
+// carryPoints keeps the points of a series that are within the time range
+// and copies the last point of each interval to the start of the next one.
+// The copy is added before the points of the next interval so it stays
+// first when they are sorted by time.
+func (itr *booleanSlidingWindowIterator) carryPoints(points []BooleanPoint) {
+	if !itr.opt.Ascending {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+
+	for i, p := range points {
+		start, end := itr.opt.Window(p.Time)
+		if end > itr.opt.StartTime && start <= itr.opt.EndTime {
+			itr.points = append(itr.points, p)
+		}
+		if i+1 < len(points) && points[i+1].Time < end {
+			continue
+		} else if end >= influxql.MaxTime || end > itr.opt.EndTime {
+			continue
+		}
+		if _, next := itr.opt.Window(end); next > itr.opt.StartTime {
+			c := p
+			c.Time = end
+			itr.points = append(itr.points, c)
+		}
+	}
+}
+
+// booleanNilPointIterator emits a single nil point for a series.
+type booleanNilPointIterator struct {
+	point *BooleanPoint
+}
+
+// Stats returns stats from the input iterator.
+func (itr *booleanNilPointIterator) Stats() IteratorStats { return IteratorStats{} }
+
+// Close closes the iterator.
+func (itr *booleanNilPointIterator) Close() error { return nil }
+
+// Next returns the nil point once.
+func (itr *booleanNilPointIterator) Next() (*BooleanPoint, error) {
+	p := itr.point
+	itr.point = nil
+	return p, nil
+}
+
 // booleanInterruptIterator represents a boolean implementation of InterruptIterator.
 type booleanInterruptIterator struct {
 	input   BooleanIterator
@@ -11670,6 +13810,7 @@ type booleanReduceFloatPoint struct {
 	Tags       Tags
 	Aggregator BooleanPointAggregator
 	Emitter    FloatPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -11687,7 +13828,7 @@ func (itr *booleanReduceFloatIterator) reduce() ([]FloatPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -11707,7 +13848,7 @@ func (itr *booleanReduceFloatIterator) reduce() ([]FloatPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -11740,7 +13881,13 @@ func (itr *booleanReduceFloatIterator) reduce() ([]FloatPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateBoolean(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -11764,7 +13911,12 @@ func (itr *booleanReduceFloatIterator) reduce() ([]FloatPoint, error) {
 	a := make([]FloatPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []FloatPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -11958,6 +14110,7 @@ type booleanReduceIntegerPoint struct {
 	Tags       Tags
 	Aggregator BooleanPointAggregator
 	Emitter    IntegerPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -11975,7 +14128,7 @@ func (itr *booleanReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -11995,7 +14148,7 @@ func (itr *booleanReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -12028,7 +14181,13 @@ func (itr *booleanReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateBoolean(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -12052,7 +14211,12 @@ func (itr *booleanReduceIntegerIterator) reduce() ([]IntegerPoint, error) {
 	a := make([]IntegerPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []IntegerPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -12246,6 +14410,7 @@ type booleanReduceUnsignedPoint struct {
 	Tags       Tags
 	Aggregator BooleanPointAggregator
 	Emitter    UnsignedPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -12263,7 +14428,7 @@ func (itr *booleanReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -12283,7 +14448,7 @@ func (itr *booleanReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -12316,7 +14481,13 @@ func (itr *booleanReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateBoolean(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -12340,7 +14511,12 @@ func (itr *booleanReduceUnsignedIterator) reduce() ([]UnsignedPoint, error) {
 	a := make([]UnsignedPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []UnsignedPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -12534,6 +14710,7 @@ type booleanReduceStringPoint struct {
 	Tags       Tags
 	Aggregator BooleanPointAggregator
 	Emitter    StringPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -12551,7 +14728,7 @@ func (itr *booleanReduceStringIterator) reduce() ([]StringPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -12571,7 +14748,7 @@ func (itr *booleanReduceStringIterator) reduce() ([]StringPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -12604,7 +14781,13 @@ func (itr *booleanReduceStringIterator) reduce() ([]StringPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateBoolean(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -12628,7 +14811,12 @@ func (itr *booleanReduceStringIterator) reduce() ([]StringPoint, error) {
 	a := make([]StringPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []StringPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -12822,6 +15010,7 @@ type booleanReduceBooleanPoint struct {
 	Tags       Tags
 	Aggregator BooleanPointAggregator
 	Emitter    BooleanPointEmitter
+	Aggregated bool
 }
 
 // reduce executes fn once for every point in the next window.
@@ -12839,7 +15028,7 @@ func (itr *booleanReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 		p, err := itr.input.Next()
 		if err != nil || p == nil {
 			return nil, err
-		} else if p.Nil {
+		} else if p.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		}
 
@@ -12859,7 +15048,7 @@ func (itr *booleanReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 			return nil, err
 		} else if curr == nil {
 			break
-		} else if curr.Nil {
+		} else if curr.Nil && !itr.opt.KeepEmptySeries {
 			continue
 		} else if curr.Name != window.name {
 			itr.input.unread(curr)
@@ -12892,7 +15081,13 @@ func (itr *booleanReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 			}
 			m[id] = rp
 		}
+
+		// A nil point only registers the series so it is emitted as null.
+		if curr.Nil {
+			continue
+		}
 		rp.Aggregator.AggregateBoolean(curr)
+		rp.Aggregated = true
 	}
 
 	keys := make([]string, 0, len(m))
@@ -12916,7 +15111,12 @@ func (itr *booleanReduceBooleanIterator) reduce() ([]BooleanPoint, error) {
 	a := make([]BooleanPoint, 0, len(m))
 	for _, k := range keys {
 		rp := m[k]
-		points := rp.Emitter.Emit()
+		points := []BooleanPoint{
+			{Time: ZeroTime, Nil: true},
+		}
+		if rp.Aggregated {
+			points = rp.Emitter.Emit()
+		}
 		for i := len(points) - 1; i >= 0; i-- {
 			points[i].Name = rp.Name
 			if !itr.keepTags {
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	internal "github.com/influxdata/influxdb/v2/influxql/query/internal"
@@ -153,6 +155,32 @@ func (a Iterators) Merge(opt IteratorOptions) (Iterator, error) {
 	return NewCallIterator(itr, opt)
 }
 
+// MergeDedupe merges a set of iterators in the same way as Merge. When
+// the points are merged in sorted order, a point that is equal to a point
+// from a later iterator in the set is dropped so the last iterator wins.
+//
+// This is used when merging the iterators of multiple shards where the same
+// series and timestamp may have been written into overlapping shards. Points
+// are equal if they belong to the same series and time, whatever their
+// values. Only raw points are deduplicated: the points of an aggregate have
+// already been reduced by each shard, and unordered points are merged as is.
+// An aggregate over overlapping shards is reduced from the deduplicated raw
+// points instead, see Overlapping.
+//
+// The iterators should be created with SeriesTags set so the points of
+// different series can be told apart when they are not grouped by every tag.
+func (a Iterators) MergeDedupe(opt IteratorOptions) (Iterator, error) {
+	if !opt.Dedupes() {
+		return a.Merge(opt)
+	}
+
+	itr := NewSortedMergeDedupeIterator(a, opt)
+	if itr != nil && opt.InterruptCh != nil {
+		itr = NewInterruptIterator(itr, opt.InterruptCh)
+	}
+	return itr, nil
+}
+
 // NewMergeIterator returns an iterator to merge itrs into one.
 // Inputs must either be merge iterators or only contain a single name/tag in
 // sorted order. The iterator will output all points by window, name/tag, then
@@ -248,6 +276,34 @@ func NewSortedMergeIterator(inputs []Iterator, opt IteratorOptions) Iterator {
 	}
 }
 
+// NewSortedMergeDedupeIterator returns an iterator to merge itrs into one
+// like NewSortedMergeIterator. Points that compare equal to a point from a
+// later input are dropped. A single input is still wrapped when SeriesTags
+// is set so its tags are limited to the dimensions.
+func NewSortedMergeDedupeIterator(inputs []Iterator, opt IteratorOptions) Iterator {
+	inputs = Iterators(inputs).filterNonNil()
+	if len(inputs) == 0 {
+		return nil
+	} else if len(inputs) == 1 && !opt.SeriesTags {
+		return inputs[0]
+	}
+
+	switch inputs := Iterators(inputs).coerce().(type) {
+	case []FloatIterator:
+		return newFloatSortedMergeDedupeIterator(inputs, opt, true)
+	case []IntegerIterator:
+		return newIntegerSortedMergeDedupeIterator(inputs, opt, true)
+	case []UnsignedIterator:
+		return newUnsignedSortedMergeDedupeIterator(inputs, opt, true)
+	case []StringIterator:
+		return newStringSortedMergeDedupeIterator(inputs, opt, true)
+	case []BooleanIterator:
+		return newBooleanSortedMergeDedupeIterator(inputs, opt, true)
+	default:
+		panic(fmt.Sprintf("unsupported sorted merge iterator type: %T", inputs))
+	}
+}
+
 // newParallelIterator returns an iterator that runs in a separate goroutine.
 func newParallelIterator(input Iterator) Iterator {
 	if input == nil {
@@ -377,6 +433,23 @@ func NewFillIterator(input Iterator, expr influxql.Expr, opt IteratorOptions) It
 	}
 }
 
+// evalFillExpr evaluates the expression of an ExprFill with previous bound
+// to the value of the previous interval.
+func evalFillExpr(expr interface{}, previous interface{}) interface{} {
+	e, ok := expr.(influxql.Expr)
+	if !ok {
+		return nil
+	}
+	valuer := influxql.ValuerEval{
+		Valuer: influxql.MultiValuer(
+			MathValuer{},
+			influxql.MapValuer{"previous": previous},
+		),
+		IntegerFloatDivision: true,
+	}
+	return valuer.Eval(e)
+}
+
 // NewIntervalIterator returns an iterator that sets the time on each point to the interval.
 func NewIntervalIterator(input Iterator, opt IteratorOptions) Iterator {
 	switch input := input.(type) {
@@ -536,6 +609,22 @@ func NewReaderIterator(ctx context.Context, r io.Reader, typ influxql.DataType,
 	}
 }
 
+// NewNilPointIterator returns an iterator that emits a single nil point.
+func NewNilPointIterator(name string, tags Tags, t int64, typ influxql.DataType) Iterator {
+	switch typ {
+	case influxql.Integer:
+		return &integerNilPointIterator{point: &IntegerPoint{Name: name, Tags: tags, Time: t, Nil: true}}
+	case influxql.Unsigned:
+		return &unsignedNilPointIterator{point: &UnsignedPoint{Name: name, Tags: tags, Time: t, Nil: true}}
+	case influxql.String:
+		return &stringNilPointIterator{point: &StringPoint{Name: name, Tags: tags, Time: t, Nil: true}}
+	case influxql.Boolean:
+		return &booleanNilPointIterator{point: &BooleanPoint{Name: name, Tags: tags, Time: t, Nil: true}}
+	default:
+		return &floatNilPointIterator{point: &FloatPoint{Name: name, Tags: tags, Time: t, Nil: true}}
+	}
+}
+
 // IteratorCreator is an interface to create Iterators.
 type IteratorCreator interface {
 	// Creates a simple iterator for use in an InfluxQL query.
@@ -581,12 +670,22 @@ type IteratorOptions struct {
 	// Sorted in time ascending order if true.
 	Ascending bool
 
-	// Limits the number of points per series.
+	// Limits the number of points per series after fill() is applied.
 	Limit, Offset int
 
-	// Limits the number of series.
+	// Limits the number of series. Series are selected in ascending tag set
+	// order even when the points are sorted in descending order.
 	SLimit, SOffset int
 
+	// Restricts the series to the tag sets with these keys. This is set
+	// when SLIMIT and SOFFSET were applied across all shards at once.
+	TagSetKeys map[string]struct{}
+
+	// Applies SLIMIT and SOFFSET while the series are read from the index
+	// instead of after every tag set has been collected. The tag sets are
+	// chosen in index order rather than ascending tag set order.
+	LimitSeriesFirst bool
+
 	// Removes the measurement name. Useful for meta queries.
 	StripName bool
 
@@ -599,6 +698,38 @@ type IteratorOptions struct {
 	// Limits on the creation of iterators.
 	MaxSeriesN int
 
+	// Parse string values as numbers for transformations.
+	CoerceNumeric bool
+
+	// Order top() and bottom() output by value instead of by time.
+	OrderValues bool
+
+	// Read numeric field values equal to this value as null.
+	TreatAsNull *float64
+
+	// Return the time of the selected point instead of the start of the
+	// interval for selectors.
+	PointTime bool
+
+	// Emit a nil point for series that have no values for the field so
+	// grouped aggregates report them with null values.
+	KeepEmptySeries bool
+
+	// Keep every tag of a series on its points instead of only the
+	// dimensions so MergeDedupe can tell the series apart. MergeDedupe
+	// limits the tags to the dimensions again.
+	SeriesTags bool
+
+	// Set when the shards read may hold the same points, such as the shards
+	// of shard groups with overlapping time ranges. Aggregates are then
+	// reduced once from the deduplicated points of every shard instead of
+	// by each shard.
+	Overlapping bool
+
+	// Seed for the random choices made by sample(). It is shared by every
+	// iterator of a query so the same points are sampled for each call.
+	Seed int64
+
 	// If this channel is set and is closed, the iterator should try to exit
 	// and close as soon as possible.
 	InterruptCh <-chan struct{}
@@ -671,16 +802,25 @@ func newIteratorOptionsStmt(stmt *influxql.SelectStatement, sopt SelectOptions)
 	}
 	opt.Limit, opt.Offset = stmt.Limit, stmt.Offset
 	opt.SLimit, opt.SOffset = stmt.SLimit, stmt.SOffset
+	opt.LimitSeriesFirst = sopt.LimitSeriesFirst
 	opt.MaxSeriesN = sopt.MaxSeriesN
+	opt.CoerceNumeric = sopt.CoerceNumeric
+	opt.OrderValues = sopt.OrderValues
+	opt.TreatAsNull = sopt.TreatAsNull
 	opt.OrgID = sopt.OrgID
+	opt.Seed = time.Now().UnixNano()
 
 	return opt, nil
 }
 
 func newIteratorOptionsSubstatement(ctx context.Context, stmt *influxql.SelectStatement, opt IteratorOptions) (IteratorOptions, error) {
 	subOpt, err := newIteratorOptionsStmt(stmt, SelectOptions{
-		OrgID:      opt.OrgID,
-		MaxSeriesN: opt.MaxSeriesN,
+		OrgID:            opt.OrgID,
+		MaxSeriesN:       opt.MaxSeriesN,
+		CoerceNumeric:    opt.CoerceNumeric,
+		OrderValues:      opt.OrderValues,
+		TreatAsNull:      opt.TreatAsNull,
+		LimitSeriesFirst: opt.LimitSeriesFirst,
 	})
 	if err != nil {
 		return IteratorOptions{}, err
@@ -703,6 +843,7 @@ func newIteratorOptionsSubstatement(ctx context.Context, stmt *influxql.SelectSt
 		subOpt.GroupBy[d] = struct{}{}
 	}
 	subOpt.InterruptCh = opt.InterruptCh
+	subOpt.Seed = opt.Seed
 
 	// Extract the time range and condition from the condition.
 	valuer := &influxql.NowValuer{Location: stmt.Location}
@@ -758,6 +899,13 @@ func (opt IteratorOptions) MergeSorted() bool {
 	return opt.Ordered
 }
 
+// Dedupes returns true if MergeDedupe deduplicates the points of iterators
+// created with these options.
+func (opt IteratorOptions) Dedupes() bool {
+	_, ok := opt.Expr.(*influxql.Call)
+	return !ok && opt.MergeSorted()
+}
+
 // SeekTime returns the time the iterator should start from.
 // For ascending iterators this is the start time, for descending iterators it's the end time.
 func (opt IteratorOptions) SeekTime() int64 {
@@ -780,6 +928,8 @@ func (opt IteratorOptions) StopTime() int64 {
 func (opt IteratorOptions) Window(t int64) (start, end int64) {
 	if opt.Interval.IsZero() {
 		return opt.StartTime, opt.EndTime + 1
+	} else if opt.Interval.Months > 0 {
+		return opt.calendarWindow(t)
 	}
 
 	// Subtract the offset to the time so we calculate the correct base interval.
@@ -856,6 +1006,59 @@ func (opt IteratorOptions) Window(t int64) (start, end int64) {
 	return
 }
 
+// calendarWindow returns the window of calendar months that t falls within.
+// The months are counted in the location of the query.
+func (opt IteratorOptions) calendarWindow(t int64) (start, end int64) {
+	loc := opt.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	// Find the first month of the interval by counting months since year zero.
+	tm := time.Unix(0, t-int64(opt.Interval.Offset)).In(loc)
+	months := tm.Year()*12 + int(tm.Month()) - 1
+	if m := months % opt.Interval.Months; m < 0 {
+		months -= m + opt.Interval.Months
+	} else {
+		months -= m
+	}
+	first := time.Date(months/12, time.Month(months%12+1), 1, 0, 0, 0, 0, loc).Add(opt.Interval.Offset)
+	next := first.AddDate(0, opt.Interval.Months, 0)
+
+	if min := time.Unix(0, influxql.MinTime); first.Before(min) {
+		start = influxql.MinTime
+	} else {
+		start = first.UnixNano()
+	}
+	if max := time.Unix(0, influxql.MaxTime); next.After(max) {
+		end = influxql.MaxTime
+	} else {
+		end = next.UnixNano()
+	}
+	return start, end
+}
+
+// addIntervals returns t moved by n intervals. It is used to read the
+// intervals before or after the time range that functions such as
+// derivative() need. A calendar interval is moved by whole windows so the
+// months that are read are read completely.
+func (opt IteratorOptions) addIntervals(t int64, n int) int64 {
+	if opt.Interval.Months == 0 || n == 0 {
+		return t + int64(n)*int64(opt.Interval.Duration)
+	}
+
+	loc := opt.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	start, end := opt.calendarWindow(t)
+	if n < 0 {
+		return time.Unix(0, start).In(loc).AddDate(0, n*opt.Interval.Months, 0).UnixNano()
+	}
+	// The end time is inclusive so stop before the window that follows.
+	return time.Unix(0, end).In(loc).AddDate(0, (n-1)*opt.Interval.Months, 0).UnixNano() - 1
+}
+
 // DerivativeInterval returns the time interval for the derivative function.
 func (opt IteratorOptions) DerivativeInterval() Interval {
 	// Use the interval on the derivative() call, if specified.
@@ -1130,6 +1333,11 @@ func decodeMeasurement(pb *internal.Measurement) (*influxql.Measurement, error)
 type Interval struct {
 	Duration time.Duration
 	Offset   time.Duration
+
+	// Months is the number of calendar months in each interval. When it is
+	// set, each interval starts on the first day of a month and Duration is
+	// only the longest the interval can be.
+	Months int
 }
 
 // IsZero returns true if the interval has no duration.
@@ -1303,6 +1511,54 @@ type fastDedupeKey struct {
 	values [2]interface{}
 }
 
+// stringCoerceFloatIterator parses the value of each string point as a float.
+// Values that cannot be parsed are returned as nil points.
+type stringCoerceFloatIterator struct {
+	input StringIterator
+}
+
+// newStringCoerceFloatIterator returns a new instance of stringCoerceFloatIterator.
+func newStringCoerceFloatIterator(input StringIterator) *stringCoerceFloatIterator {
+	return &stringCoerceFloatIterator{input: input}
+}
+
+// Stats returns stats from the input iterator.
+func (itr *stringCoerceFloatIterator) Stats() IteratorStats { return itr.input.Stats() }
+
+// Close closes the iterator and all child iterators.
+func (itr *stringCoerceFloatIterator) Close() error { return itr.input.Close() }
+
+// Next returns the next point with its value parsed as a float.
+func (itr *stringCoerceFloatIterator) Next() (*FloatPoint, error) {
+	p, err := itr.input.Next()
+	if p == nil || err != nil {
+		return nil, err
+	}
+
+	v, err := strconv.ParseFloat(strings.TrimSpace(p.Value), 64)
+	return &FloatPoint{
+		Name:       p.Name,
+		Tags:       p.Tags,
+		Time:       p.Time,
+		Nil:        p.Nil || err != nil,
+		Value:      v,
+		Aux:        p.Aux,
+		Aggregated: p.Aggregated,
+	}, nil
+}
+
+// coerceNumeric wraps a string iterator so its values are parsed as floats
+// when numeric coercion has been requested. Other iterators are returned as-is.
+func coerceNumeric(input Iterator, opt IteratorOptions) Iterator {
+	if !opt.CoerceNumeric {
+		return input
+	}
+	if itr, ok := input.(StringIterator); ok {
+		return newStringCoerceFloatIterator(itr)
+	}
+	return input
+}
+
 func abs(v int64) int64 {
 	sign := v >> 63
 	return (v ^ sign) - sign
@@ -564,6 +564,67 @@ func TestSortedMergeIterator_Boolean(t *testing.T) {
 	}
 }
 
+// Ensure that equal points from multiple iterators are only emitted from the last input.
+func TestSortedMergeDedupeIterator_Float(t *testing.T) {
+	inputs := []*FloatIterator{
+		{Points: []query.FloatPoint{
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 0, Value: 1},
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 10, Value: 2},
+			{Name: "cpu", Tags: ParseTags("host=B"), Time: 10, Value: 3},
+		}},
+		{Points: []query.FloatPoint{
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 10, Value: 4},
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 20, Value: 5},
+		}},
+		{Points: []query.FloatPoint{
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 20, Value: 6},
+		}},
+	}
+	itr := query.NewSortedMergeDedupeIterator(FloatIterators(inputs), query.IteratorOptions{
+		Dimensions: []string{"host"},
+		Ascending:  true,
+	})
+	if a, err := Iterators([]query.Iterator{itr}).ReadAll(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !deep.Equal(a, [][]query.Point{
+		{&query.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 0, Value: 1}},
+		{&query.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 10, Value: 4}},
+		{&query.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 20, Value: 6}},
+		{&query.FloatPoint{Name: "cpu", Tags: ParseTags("host=B"), Time: 10, Value: 3}},
+	}) {
+		t.Errorf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure that points of different series at the same time are not deduplicated
+// when the dimensions do not include every tag.
+func TestSortedMergeDedupeIterator_SeriesWithoutDimensions(t *testing.T) {
+	inputs := []*FloatIterator{
+		{Points: []query.FloatPoint{
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 10, Value: 1},
+			{Name: "cpu", Tags: ParseTags("host=B"), Time: 10, Value: 2},
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 20, Value: 3, Aux: []interface{}{"a", int64(1)}},
+		}},
+		{Points: []query.FloatPoint{
+			{Name: "cpu", Tags: ParseTags("host=B"), Time: 10, Value: 4},
+			{Name: "cpu", Tags: ParseTags("host=A"), Time: 20, Value: 5, Aux: []interface{}{"b", int64(2)}},
+		}},
+	}
+	itr := query.NewSortedMergeDedupeIterator(FloatIterators(inputs), query.IteratorOptions{
+		Ascending: true,
+		Ordered:   true,
+	})
+	if a, err := Iterators([]query.Iterator{itr}).ReadAll(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if !deep.Equal(a, [][]query.Point{
+		{&query.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 10, Value: 1}},
+		{&query.FloatPoint{Name: "cpu", Tags: ParseTags("host=B"), Time: 10, Value: 4}},
+		{&query.FloatPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: 20, Value: 5, Aux: []interface{}{"b", int64(2)}}},
+	}) {
+		t.Errorf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
 func TestSortedMergeIterator_Nil(t *testing.T) {
 	itr := query.NewSortedMergeIterator([]query.Iterator{nil}, query.IteratorOptions{})
 	if itr != nil {
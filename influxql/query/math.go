@@ -3,13 +3,14 @@ package query
 import (
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/influxdata/influxql"
 )
 
 func isMathFunction(call *influxql.Call) bool {
 	switch call.Name {
-	case "abs", "sin", "cos", "tan", "asin", "acos", "atan", "atan2", "exp", "log", "ln", "log2", "log10", "sqrt", "pow", "floor", "ceil", "round":
+	case "abs", "sin", "cos", "tan", "asin", "acos", "atan", "atan2", "exp", "log", "ln", "log2", "log10", "sqrt", "pow", "floor", "ceil", "round", "div", "truncate":
 		return true
 	}
 	return false
@@ -63,7 +64,59 @@ func (MathTypeMapper) CallType(name string, args []influxql.DataType) (influxql.
 
 		switch arg1 {
 		case influxql.Float, influxql.Integer, influxql.Unsigned, influxql.Unknown:
-			return influxql.Float, nil
+		default:
+			return influxql.Unknown, fmt.Errorf("invalid argument type for the second argument in %s(): %s", name, arg1)
+		}
+
+		if name == "pow" && arg0 == influxql.Integer && arg1 == influxql.Integer {
+			return influxql.Integer, nil
+		}
+		return influxql.Float, nil
+	case "div":
+		var arg0, arg1 influxql.DataType
+		if len(args) > 0 {
+			arg0 = args[0]
+		}
+		if len(args) > 1 {
+			arg1 = args[1]
+		}
+
+		switch arg0 {
+		case influxql.Integer, influxql.Unsigned, influxql.Unknown:
+			// Pass through to verify the second argument.
+		default:
+			return influxql.Unknown, fmt.Errorf("invalid argument type for the first argument in %s(): %s", name, arg0)
+		}
+
+		switch arg1 {
+		case influxql.Integer, influxql.Unsigned, influxql.Unknown:
+		default:
+			return influxql.Unknown, fmt.Errorf("invalid argument type for the second argument in %s(): %s", name, arg1)
+		}
+
+		if arg0 == influxql.Unsigned && arg1 == influxql.Unsigned {
+			return influxql.Unsigned, nil
+		}
+		return influxql.Integer, nil
+	case "truncate":
+		var arg0, arg1 influxql.DataType
+		if len(args) > 0 {
+			arg0 = args[0]
+		}
+		if len(args) > 1 {
+			arg1 = args[1]
+		}
+
+		switch arg0 {
+		case influxql.Time, influxql.Integer, influxql.String, influxql.Unknown:
+			// Pass through to verify the second argument.
+		default:
+			return influxql.Unknown, fmt.Errorf("invalid argument type for the first argument in %s(): %s", name, arg0)
+		}
+
+		switch arg1 {
+		case influxql.Duration, influxql.Integer, influxql.Unknown:
+			return influxql.Time, nil
 		default:
 			return influxql.Unknown, fmt.Errorf("invalid argument type for the second argument in %s(): %s", name, arg1)
 		}
@@ -149,12 +202,13 @@ func (v MathValuer) Call(name string, args []interface{}) (interface{}, bool) {
 				return nil, true
 			}
 		case "asin":
-			if arg0, ok := asFloat(arg0); ok {
+			// Values outside of the domain of asin() are null rather than NaN.
+			if arg0, ok := asFloat(arg0); ok && arg0 >= -1 && arg0 <= 1 {
 				return math.Asin(arg0), true
 			}
 			return nil, true
 		case "acos":
-			if arg0, ok := asFloat(arg0); ok {
+			if arg0, ok := asFloat(arg0); ok && arg0 >= -1 && arg0 <= 1 {
 				return math.Acos(arg0), true
 			}
 			return nil, true
@@ -169,22 +223,23 @@ func (v MathValuer) Call(name string, args []interface{}) (interface{}, bool) {
 			}
 			return nil, true
 		case "ln":
-			if arg0, ok := asFloat(arg0); ok {
+			// The logarithm of a non-positive value is null rather than NaN or -Inf.
+			if arg0, ok := asFloat(arg0); ok && arg0 > 0 {
 				return math.Log(arg0), true
 			}
 			return nil, true
 		case "log2":
-			if arg0, ok := asFloat(arg0); ok {
+			if arg0, ok := asFloat(arg0); ok && arg0 > 0 {
 				return math.Log2(arg0), true
 			}
 			return nil, true
 		case "log10":
-			if arg0, ok := asFloat(arg0); ok {
+			if arg0, ok := asFloat(arg0); ok && arg0 > 0 {
 				return math.Log10(arg0), true
 			}
 			return nil, true
 		case "sqrt":
-			if arg0, ok := asFloat(arg0); ok {
+			if arg0, ok := asFloat(arg0); ok && arg0 >= 0 {
 				return math.Sqrt(arg0), true
 			}
 			return nil, true
@@ -198,20 +253,127 @@ func (v MathValuer) Call(name string, args []interface{}) (interface{}, bool) {
 			}
 			return nil, true
 		case "log":
-			if arg0, arg1, ok := asFloats(arg0, arg1); ok {
+			if arg0, arg1, ok := asFloats(arg0, arg1); ok && arg0 > 0 && arg1 > 0 && arg1 != 1 {
 				return math.Log(arg0) / math.Log(arg1), true
 			}
 			return nil, true
 		case "pow":
+			if v, ok := powInteger(arg0, arg1); ok {
+				return v, true
+			}
 			if arg0, arg1, ok := asFloats(arg0, arg1); ok {
 				return math.Pow(arg0, arg1), true
 			}
 			return nil, true
+		case "div":
+			return div(arg0, arg1), true
+		case "mod":
+			return mod(arg0, arg1), true
 		}
 	}
 	return nil, false
 }
 
+// TruncateValuer evaluates truncate() for each point by flooring a time
+// to a multiple of a duration in Location. The duration must have been
+// converted to nanoseconds by rewriteTruncate since duration literals
+// cannot be evaluated as arguments.
+type TruncateValuer struct {
+	Location *time.Location
+}
+
+var _ influxql.CallValuer = TruncateValuer{}
+
+func (TruncateValuer) Value(key string) (interface{}, bool) {
+	return nil, false
+}
+
+func (v TruncateValuer) Call(name string, args []interface{}) (interface{}, bool) {
+	if name != "truncate" || len(args) != 2 {
+		return nil, false
+	}
+
+	var t time.Time
+	switch arg0 := args[0].(type) {
+	case time.Time:
+		t = arg0
+	case int64:
+		t = time.Unix(0, arg0)
+	default:
+		return nil, true
+	}
+
+	d, ok := args[1].(int64)
+	if !ok || d <= 0 {
+		return nil, true
+	}
+	return truncateTime(t, time.Duration(d), v.Location), true
+}
+
+// rewriteTruncate replaces calls to truncate() with a constant time argument
+// by the truncated time literal. Any now() call within the arguments is
+// evaluated first. Calls that depend on the time of each point keep the call
+// and have their duration converted to nanoseconds for TruncateValuer.
+func rewriteTruncate(expr influxql.Expr, now time.Time, loc *time.Location) (influxql.Expr, error) {
+	var err error
+	expr = influxql.RewriteExpr(expr, func(expr influxql.Expr) influxql.Expr {
+		call, ok := expr.(*influxql.Call)
+		if !ok || call.Name != "truncate" || err != nil {
+			return expr
+		}
+
+		if got := len(call.Args); got != 2 {
+			err = fmt.Errorf("invalid number of arguments for truncate, expected 2, got %d", got)
+			return expr
+		}
+		d, ok := call.Args[1].(*influxql.DurationLiteral)
+		if !ok {
+			err = fmt.Errorf("second argument to truncate must be a duration, got %T", call.Args[1])
+			return expr
+		} else if d.Val <= 0 {
+			err = fmt.Errorf("duration argument must be positive, got %s", influxql.FormatDuration(d.Val))
+			return expr
+		}
+
+		valuer := influxql.NowValuer{Now: now, Location: loc}
+		var t time.Time
+		switch arg0 := influxql.Reduce(call.Args[0], &valuer).(type) {
+		case *influxql.TimeLiteral:
+			t = arg0.Val
+		case *influxql.IntegerLiteral:
+			t = time.Unix(0, arg0.Val)
+		case *influxql.StringLiteral:
+			lit, e := arg0.ToTimeLiteral(loc)
+			if e != nil {
+				err = e
+				return expr
+			}
+			t = lit.Val
+		default:
+			return &influxql.Call{
+				Name: call.Name,
+				Args: []influxql.Expr{arg0, &influxql.IntegerLiteral{Val: int64(d.Val)}},
+			}
+		}
+		return &influxql.TimeLiteral{Val: truncateTime(t, d.Val, loc)}
+	})
+	return expr, err
+}
+
+// truncateTime floors t to a multiple of d in the wall clock of loc.
+func truncateTime(t time.Time, d time.Duration, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	_, offset := t.In(loc).Zone()
+	ns := t.UnixNano()
+	r := (ns + int64(offset)*int64(time.Second)) % int64(d)
+	if r < 0 {
+		r += int64(d)
+	}
+	return time.Unix(0, ns-r).In(loc)
+}
+
 func asFloat(x interface{}) (float64, bool) {
 	switch arg0 := x.(type) {
 	case float64:
@@ -237,6 +399,111 @@ func asFloats(x, y interface{}) (float64, float64, bool) {
 	return arg0, arg1, true
 }
 
+// div performs integer floor division. It returns nil when either argument
+// is not an integer or when the divisor is zero.
+func div(x, y interface{}) interface{} {
+	if x, ok := x.(uint64); ok {
+		if y, ok := y.(uint64); ok {
+			if y == 0 {
+				return nil
+			}
+			return x / y
+		}
+	}
+
+	arg0, ok := asInteger(x)
+	if !ok {
+		return nil
+	}
+	arg1, ok := asInteger(y)
+	if !ok || arg1 == 0 {
+		return nil
+	}
+
+	q := arg0 / arg1
+	if r := arg0 % arg1; r != 0 && (r < 0) != (arg1 < 0) {
+		q--
+	}
+	return q
+}
+
+// mod returns the remainder of x divided by y. Integer operands return an
+// integer remainder with the sign of x and any float operand uses math.Mod.
+// It returns nil when the divisor is zero.
+func mod(x, y interface{}) interface{} {
+	if x, ok := x.(uint64); ok {
+		if y, ok := y.(uint64); ok {
+			if y == 0 {
+				return nil
+			}
+			return x % y
+		}
+	}
+
+	if arg0, ok := asInteger(x); ok {
+		if arg1, ok := asInteger(y); ok {
+			if arg1 == 0 {
+				return nil
+			}
+			return arg0 % arg1
+		}
+	}
+
+	arg0, arg1, ok := asFloats(x, y)
+	if !ok || arg1 == 0 {
+		return nil
+	}
+	return math.Mod(arg0, arg1)
+}
+
+// rewriteMod replaces the modulo operator with a call to mod() so it is
+// evaluated by MathValuer, which returns null for a zero divisor instead of
+// the zero the expression evaluator returns for integers.
+func rewriteMod(expr influxql.Expr) influxql.Expr {
+	return influxql.RewriteExpr(influxql.CloneExpr(expr), func(expr influxql.Expr) influxql.Expr {
+		if expr, ok := expr.(*influxql.BinaryExpr); ok && expr.Op == influxql.MOD {
+			return &influxql.Call{Name: "mod", Args: []influxql.Expr{expr.LHS, expr.RHS}}
+		}
+		return expr
+	})
+}
+
+// powInteger raises an integer to a non-negative integer power so the result
+// keeps the integer type. It returns false for any other arguments.
+func powInteger(x, y interface{}) (int64, bool) {
+	base, ok := x.(int64)
+	if !ok {
+		return 0, false
+	}
+	exp, ok := y.(int64)
+	if !ok || exp < 0 {
+		return 0, false
+	}
+
+	v := int64(1)
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			v *= base
+		}
+		base *= base
+	}
+	return v, true
+}
+
+func asInteger(x interface{}) (int64, bool) {
+	switch arg0 := x.(type) {
+	case int64:
+		return arg0, true
+	case uint64:
+		if arg0 > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(arg0), true
+	default:
+		return 0, false
+	}
+}
+
 func round(x float64) float64 {
 	t := math.Trunc(x)
 	if math.Abs(x-t) >= 0.5 {
@@ -3,6 +3,7 @@ package query_test
 import (
 	"math"
 	"testing"
+	"time"
 
 	"github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxql"
@@ -97,8 +98,17 @@ func TestMath_TypeMapper(t *testing.T) {
 		{s: `pow(y::float, x::float)`, typ: influxql.Float},
 		{s: `pow(y::float, x::integer)`, typ: influxql.Float},
 		{s: `pow(y::float, x::unsigned)`, typ: influxql.Float},
+		{s: `pow(y::integer, x::integer)`, typ: influxql.Integer},
+		{s: `pow(y::integer, x::unsigned)`, typ: influxql.Float},
 		{s: `pow(y::float, x::string)`, err: true},
 		{s: `pow(y::float, x::boolean)`, err: true},
+		{s: `div(y::integer, x::integer)`, typ: influxql.Integer},
+		{s: `div(y::integer, x::unsigned)`, typ: influxql.Integer},
+		{s: `div(y::unsigned, x::unsigned)`, typ: influxql.Unsigned},
+		{s: `div(y::float, x::integer)`, err: true},
+		{s: `div(y::integer, x::float)`, err: true},
+		{s: `div(y::string, x::integer)`, err: true},
+		{s: `div(y::integer, x::boolean)`, err: true},
 		{s: `floor(f::float)`, typ: influxql.Float},
 		{s: `floor(i::integer)`, typ: influxql.Integer},
 		{s: `floor(u::unsigned)`, typ: influxql.Unsigned},
@@ -114,6 +124,10 @@ func TestMath_TypeMapper(t *testing.T) {
 		{s: `round(u::unsigned)`, typ: influxql.Unsigned},
 		{s: `round(s::string)`, err: true},
 		{s: `round(b::boolean)`, err: true},
+		{s: `truncate(i::integer, 1h)`, typ: influxql.Time},
+		{s: `truncate(s::string, 1h)`, typ: influxql.Time},
+		{s: `truncate(f::float, 1h)`, err: true},
+		{s: `truncate(i::integer, s::string)`, err: true},
 	} {
 		t.Run(tt.s, func(t *testing.T) {
 			expr := MustParseExpr(tt.s)
@@ -134,6 +148,44 @@ func TestMath_TypeMapper(t *testing.T) {
 	}
 }
 
+func TestTruncateValuer_Call(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip("unable to load time zone")
+	}
+
+	for _, tt := range []struct {
+		name string
+		loc  *time.Location
+		t    interface{}
+		d    interface{}
+		exp  interface{}
+	}{
+		{name: "hour", t: mustParseTime("2000-01-01T00:35:00Z"), d: int64(time.Hour), exp: mustParseTime("2000-01-01T00:00:00Z")},
+		{name: "boundary", t: mustParseTime("2000-01-01T01:00:00Z"), d: int64(time.Hour), exp: mustParseTime("2000-01-01T01:00:00Z")},
+		{name: "integer", t: mustParseTime("2000-01-01T00:35:00Z").UnixNano(), d: int64(10 * time.Minute), exp: mustParseTime("2000-01-01T00:30:00Z")},
+		{name: "before epoch", t: mustParseTime("1969-12-31T23:35:00Z"), d: int64(time.Hour), exp: mustParseTime("1969-12-31T23:00:00Z")},
+		{name: "time zone", loc: kolkata, t: mustParseTime("2000-01-01T00:35:00Z"), d: int64(time.Hour), exp: mustParseTime("2000-01-01T00:30:00Z")},
+		{name: "time zone day", loc: kolkata, t: mustParseTime("2000-01-01T20:00:00Z"), d: int64(24 * time.Hour), exp: mustParseTime("2000-01-01T18:30:00Z")},
+		{name: "invalid duration", t: mustParseTime("2000-01-01T00:35:00Z"), d: int64(0), exp: nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v := query.TruncateValuer{Location: tt.loc}
+			got, ok := v.Call("truncate", []interface{}{tt.t, tt.d})
+			if !ok {
+				t.Fatal("expected truncate to be evaluated")
+			}
+			if ts, ok := got.(time.Time); ok {
+				if exp := tt.exp.(time.Time); !ts.Equal(exp) {
+					t.Errorf("unexpected value: %s != %s", exp, ts)
+				}
+			} else if got != tt.exp {
+				t.Errorf("unexpected value: %v != %v", tt.exp, got)
+			}
+		})
+	}
+}
+
 func TestMathValuer_Call(t *testing.T) {
 	type values map[string]interface{}
 	for _, tt := range []struct {
@@ -150,10 +202,13 @@ func TestMathValuer_Call(t *testing.T) {
 		{s: `sin(i)`, values: values{"i": int64(2)}, exp: math.Sin(2)},
 		{s: `sin(u)`, values: values{"u": uint64(2)}, exp: math.Sin(2)},
 		{s: `asin(f)`, values: values{"f": float64(0.5)}, exp: math.Asin(0.5)},
+		{s: `asin(f)`, values: values{"f": float64(2)}, exp: nil},
+		{s: `asin(f)`, values: values{"f": float64(-1.5)}, exp: nil},
 		{s: `cos(f)`, values: values{"f": math.Pi / 2}, exp: math.Cos(math.Pi / 2)},
 		{s: `cos(i)`, values: values{"i": int64(2)}, exp: math.Cos(2)},
 		{s: `cos(u)`, values: values{"u": uint64(2)}, exp: math.Cos(2)},
 		{s: `acos(f)`, values: values{"f": float64(0.5)}, exp: math.Acos(0.5)},
+		{s: `acos(f)`, values: values{"f": float64(2)}, exp: nil},
 		{s: `tan(f)`, values: values{"f": math.Pi / 2}, exp: math.Tan(math.Pi / 2)},
 		{s: `tan(i)`, values: values{"i": int64(2)}, exp: math.Tan(2)},
 		{s: `tan(u)`, values: values{"u": uint64(2)}, exp: math.Tan(2)},
@@ -188,12 +243,38 @@ func TestMathValuer_Call(t *testing.T) {
 		{s: `log10(f)`, values: values{"f": float64(3)}, exp: math.Log10(3)},
 		{s: `log10(i)`, values: values{"i": int64(3)}, exp: math.Log10(3)},
 		{s: `log10(u)`, values: values{"u": uint64(3)}, exp: math.Log10(3)},
+		{s: `ln(f)`, values: values{"f": float64(0)}, exp: nil},
+		{s: `ln(i)`, values: values{"i": int64(-3)}, exp: nil},
+		{s: `log2(f)`, values: values{"f": float64(0)}, exp: nil},
+		{s: `log10(f)`, values: values{"f": float64(-3)}, exp: nil},
+		{s: `log(f, 8)`, values: values{"f": float64(0)}, exp: nil},
+		{s: `log(f, 1)`, values: values{"f": float64(3)}, exp: nil},
+		{s: `log(f, b)`, values: values{"f": float64(3), "b": float64(-2)}, exp: nil},
 		{s: `sqrt(f)`, values: values{"f": float64(3)}, exp: math.Sqrt(3)},
 		{s: `sqrt(i)`, values: values{"i": int64(3)}, exp: math.Sqrt(3)},
 		{s: `sqrt(u)`, values: values{"u": uint64(3)}, exp: math.Sqrt(3)},
+		{s: `sqrt(f)`, values: values{"f": float64(-4)}, exp: nil},
 		{s: `pow(f, 2)`, values: values{"f": float64(4)}, exp: math.Pow(4, 2)},
-		{s: `pow(i, 2)`, values: values{"i": int64(4)}, exp: math.Pow(4, 2)},
+		{s: `pow(i, 2)`, values: values{"i": int64(4)}, exp: int64(16)},
+		{s: `pow(i, 0)`, values: values{"i": int64(4)}, exp: int64(1)},
+		{s: `pow(i, -2)`, values: values{"i": int64(4)}, exp: math.Pow(4, -2)},
+		{s: `pow(i, e)`, values: values{"i": int64(-3), "e": int64(3)}, exp: int64(-27)},
+		{s: `pow(i, 0.5)`, values: values{"i": int64(4)}, exp: float64(2)},
 		{s: `pow(u, 2)`, values: values{"u": uint64(4)}, exp: math.Pow(4, 2)},
+		{s: `div(i, 2)`, values: values{"i": int64(7)}, exp: int64(3)},
+		{s: `div(i, 2)`, values: values{"i": int64(-7)}, exp: int64(-4)},
+		{s: `div(i, -2)`, values: values{"i": int64(7)}, exp: int64(-4)},
+		{s: `div(i, 0)`, values: values{"i": int64(7)}, exp: nil},
+		{s: `div(u, d)`, values: values{"u": uint64(7), "d": uint64(2)}, exp: uint64(3)},
+		{s: `div(u, d)`, values: values{"u": uint64(7), "d": uint64(0)}, exp: nil},
+		{s: `div(f, 2)`, values: values{"f": float64(7)}, exp: nil},
+		{s: `mod(i, 3)`, values: values{"i": int64(7)}, exp: int64(1)},
+		{s: `mod(i, 3)`, values: values{"i": int64(-7)}, exp: int64(-1)},
+		{s: `mod(i, 0)`, values: values{"i": int64(7)}, exp: nil},
+		{s: `mod(u, d)`, values: values{"u": uint64(7), "d": uint64(3)}, exp: uint64(1)},
+		{s: `mod(u, d)`, values: values{"u": uint64(7), "d": uint64(0)}, exp: nil},
+		{s: `mod(f, 2)`, values: values{"f": float64(7.5)}, exp: float64(1.5)},
+		{s: `mod(f, 0)`, values: values{"f": float64(7.5)}, exp: nil},
 	} {
 		t.Run(tt.s, func(t *testing.T) {
 			expr := MustParseExpr(tt.s)
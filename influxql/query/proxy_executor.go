@@ -3,7 +3,6 @@ package query
 import (
 	"context"
 	"io"
-	"strings"
 	"time"
 
 	iql "github.com/influxdata/influxdb/v2/influxql"
@@ -36,9 +35,7 @@ func (s *ProxyExecutor) Query(ctx context.Context, w io.Writer, req *iql.QueryRe
 	logger := s.log.With(influxlogger.TraceFields(ctx)...)
 	logger.Info("executing new query", zap.String("query", req.Query))
 
-	p := influxql.NewParser(strings.NewReader(req.Query))
-	p.SetParams(req.Params)
-	q, err := p.ParseQuery()
+	q, err := ParseQuery(req.Query, req.Params)
 	if err != nil {
 		return iql.Statistics{}, &errors.Error{
 			Code: errors.EInvalid,
@@ -47,18 +44,50 @@ func (s *ProxyExecutor) Query(ctx context.Context, w io.Writer, req *iql.QueryRe
 		}
 	}
 
+	if req.TimeZone != "" {
+		loc, err := LoadLocation(req.TimeZone)
+		if err != nil {
+			return iql.Statistics{}, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "error parsing tz parameter",
+				Err:  err,
+			}
+		}
+		setDefaultLocation(q, loc)
+	}
+
 	span.LogFields(log.String("query", q.String()))
 
 	opts := ExecutionOptions{
-		OrgID:           req.OrganizationID,
-		Database:        req.DB,
-		RetentionPolicy: req.RP,
-		ChunkSize:       req.ChunkSize,
-		ReadOnly:        true,
-		Authorizer:      OpenAuthorizer,
+		OrgID:              req.OrganizationID,
+		Database:           req.DB,
+		RetentionPolicy:    req.RP,
+		ChunkSize:          req.ChunkSize,
+		ReadOnly:           true,
+		Authorizer:         OpenAuthorizer,
+		CoerceNumeric:      req.CoerceNumeric,
+		OrderValues:        req.OrderValues,
+		ColumnsOnly:        req.ColumnsOnly,
+		Interleave:         req.Interleave,
+		TreatAsNull:        req.TreatAsNull,
+		LocalTime:          req.LocalTime,
+		Pivot:              req.Pivot,
+		TagsAsJSON:         req.TagsAsJSON,
+		UnionColumns:       req.UnionColumns,
+		MaxRows:            req.MaxRows,
+		EchoRange:          req.EchoRange,
+		OrderByCardinality: req.OrderByCardinality,
+		HoltWintersBounds:  req.HoltWintersBounds,
+		LimitSeriesFirst:   req.LimitSeriesFirst,
+		LabelRight:         req.Label == "right",
+		AutoRollup:         req.AutoRollup,
+		DropEmptyTags:      req.DropEmptyTags,
+		Estimate:           req.Estimate,
+		SeriesID:           req.SeriesID,
+		BucketID:           req.BucketID,
 	}
 
-	epoch := req.Epoch
+	epoch, timeFormat := req.Epoch, req.TimeFormat
 	rw := NewResponseWriter(req.EncodingFormat)
 
 	results, stats := s.executor.ExecuteQuery(ctx, q, opts)
@@ -74,6 +103,11 @@ func (s *ProxyExecutor) Query(ctx context.Context, w io.Writer, req *iql.QueryRe
 				convertToEpoch(r, epoch)
 			}
 
+			// if requested, format result timestamps with the given layout
+			if timeFormat != "" {
+				convertToTimeFormat(r, timeFormat)
+			}
+
 			err = rw.WriteResponse(ctx, w, Response{Results: []*Result{r}})
 			if err != nil {
 				break
@@ -81,12 +115,27 @@ func (s *ProxyExecutor) Query(ctx context.Context, w io.Writer, req *iql.QueryRe
 		}
 	} else {
 		resp := Response{Results: GatherResults(results, epoch)}
+		if timeFormat != "" {
+			for _, r := range resp.Results {
+				convertToTimeFormat(r, timeFormat)
+			}
+		}
 		err = rw.WriteResponse(ctx, w, resp)
 	}
 
 	return *stats, err
 }
 
+// setDefaultLocation sets the location of every SELECT statement that does not
+// already specify one with a TZ() clause.
+func setDefaultLocation(q *influxql.Query, loc *time.Location) {
+	for _, stmt := range q.Statements {
+		if stmt, ok := stmt.(*influxql.SelectStatement); ok && stmt.Location == nil {
+			stmt.Location = loc
+		}
+	}
+}
+
 // GatherResults consumes the results from the given channel and organizes them correctly.
 // Results for various statements need to be combined together.
 func GatherResults(ch <-chan *Result, epoch string) []*Result {
@@ -134,6 +183,7 @@ func GatherResults(ch <-chan *Result, epoch string) []*Result {
 			cr.Series = append(cr.Series, r.Series...)
 			cr.Messages = append(cr.Messages, r.Messages...)
 			cr.Partial = r.Partial
+			cr.Truncated = cr.Truncated || r.Truncated
 		} else {
 			results = append(results, r)
 		}
@@ -166,3 +216,15 @@ func convertToEpoch(r *Result, epoch string) {
 		}
 	}
 }
+
+// convertToTimeFormat converts result timestamps from time.Time to strings
+// formatted with the given Go time layout.
+func convertToTimeFormat(r *Result, layout string) {
+	for _, s := range r.Series {
+		for _, v := range s.Values {
+			if ts, ok := v[0].(time.Time); ok {
+				v[0] = ts.Format(layout)
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/influxdata/influxql"
+)
+
+// ParseQuery parses the text of a query. The syntax that influxql cannot
+// parse, such as IN lists, calendar intervals and fixed TZ() offsets, is
+// rewritten into equivalent syntax that it can before the query is parsed.
+// The position of a parse error refers to the original text.
+func ParseQuery(q string, params map[string]interface{}) (*influxql.Query, error) {
+	inLists := rewriteInLists(q)
+	calendar := rewriteCalendarIntervals(inLists.String())
+	fixedZones, zones := rewriteFixedZones(calendar.String())
+	text := fixedZones.String()
+
+	p := influxql.NewParser(strings.NewReader(text))
+	p.SetParams(params)
+	query, err := p.ParseQuery()
+	if err != nil {
+		if perr, ok := err.(*influxql.ParseError); ok {
+			i := queryOffset(text, perr.Pos)
+			for _, r := range []*queryRewriter{fixedZones, calendar, inLists} {
+				i = r.origin(i)
+			}
+			e := *perr
+			e.Pos = queryPos(q, i)
+			return nil, &e
+		}
+		return nil, err
+	}
+	if err := setFixedZones(query, zones); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// queryOffset returns the byte offset of pos in q.
+func queryOffset(q string, pos influxql.Pos) int {
+	i := 0
+	for line := 0; line < pos.Line; line++ {
+		n := strings.IndexByte(q[i:], '\n')
+		if n < 0 {
+			return len(q)
+		}
+		i += n + 1
+	}
+	for char := 0; char < pos.Char; char++ {
+		// The end of the query is reported past its last character.
+		if i >= len(q) {
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(q[i:])
+		i += size
+	}
+	return i
+}
+
+// queryPos returns the position of the byte offset i in q.
+func queryPos(q string, i int) influxql.Pos {
+	var past int
+	if i > len(q) {
+		past, i = i-len(q), len(q)
+	}
+	start := strings.LastIndexByte(q[:i], '\n') + 1
+	return influxql.Pos{
+		Line: strings.Count(q[:start], "\n"),
+		Char: utf8.RuneCountInString(q[start:i]) + past,
+	}
+}
+
+// queryEdit records that the text of a query between start and end was
+// replaced by n bytes.
+type queryEdit struct {
+	start, end, n int
+}
+
+// queryRewriter builds the rewritten text of a query. The edits are kept so
+// offsets in the rewritten text can be mapped back to the original text.
+type queryRewriter struct {
+	q     string
+	buf   strings.Builder
+	last  int
+	edits []queryEdit
+}
+
+// replace replaces the text of the query between start and end with s.
+// The text must be replaced in order.
+func (r *queryRewriter) replace(start, end int, s string) {
+	r.buf.WriteString(r.q[r.last:start])
+	r.buf.WriteString(s)
+	r.last = end
+	r.edits = append(r.edits, queryEdit{start: start, end: end, n: len(s)})
+}
+
+// String returns the rewritten text of the query.
+func (r *queryRewriter) String() string {
+	if len(r.edits) == 0 {
+		return r.q
+	}
+	return r.buf.String() + r.q[r.last:]
+}
+
+// origin returns the offset in the original text of the offset i in the
+// rewritten text. An offset within replaced text maps to the start of the
+// text it replaced.
+func (r *queryRewriter) origin(i int) int {
+	delta := 0
+	for _, e := range r.edits {
+		if start := e.start + delta; i < start {
+			break
+		} else if i < start+e.n {
+			return e.start
+		}
+		delta += e.n - (e.end - e.start)
+	}
+	return i - delta
+}
+
+type queryTokenKind int
+
+const (
+	queryIdent queryTokenKind = iota
+	queryQuotedIdent
+	queryString
+	queryNumber
+	queryParam
+	queryRegex
+	queryPunct
+)
+
+// queryToken is a token of the query text found by scanQueryTokens.
+type queryToken struct {
+	kind       queryTokenKind
+	start, end int
+	ch         byte
+}
+
+func (t queryToken) is(ch byte) bool {
+	return t.kind == queryPunct && t.ch == ch
+}
+
+func (t queryToken) isKeyword(q, keyword string) bool {
+	return t.kind == queryIdent && strings.EqualFold(q[t.start:t.end], keyword)
+}
+
+// isRef reports whether the token can be a reference to a field or tag.
+func (t queryToken) isRef(q string) bool {
+	switch t.kind {
+	case queryQuotedIdent:
+		return true
+	case queryIdent:
+		return influxql.Lookup(q[t.start:t.end]) == influxql.IDENT
+	}
+	return false
+}
+
+func (t queryToken) isLiteral(q string) bool {
+	switch t.kind {
+	case queryString, queryNumber, queryParam:
+		return true
+	case queryIdent:
+		return t.isKeyword(q, "true") || t.isKeyword(q, "false")
+	}
+	return false
+}
+
+// isOperand reports whether the token ends an operand, in which case a
+// following slash is a division instead of the start of a regex.
+func (t queryToken) isOperand(q string) bool {
+	switch t.kind {
+	case queryIdent:
+		return influxql.Lookup(q[t.start:t.end]) == influxql.IDENT
+	case queryPunct:
+		return t.ch == ')'
+	case queryRegex:
+		return false
+	}
+	return true
+}
+
+// scanQueryTokens splits the query into the tokens needed to rewrite its
+// text. Strings, quoted identifiers, regexes and comments are scanned as a
+// whole so their contents are never mistaken for other syntax.
+func scanQueryTokens(q string) []queryToken {
+	var tokens []queryToken
+	for i := 0; i < len(q); {
+		ch := q[i]
+		start := i
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+			continue
+		case ch == '-' && strings.HasPrefix(q[i:], "--"):
+			if n := strings.IndexByte(q[i:], '\n'); n >= 0 {
+				i += n
+			} else {
+				i = len(q)
+			}
+			continue
+		case ch == '/' && strings.HasPrefix(q[i:], "/*"):
+			if n := strings.Index(q[i+2:], "*/"); n >= 0 {
+				i += n + 4
+			} else {
+				i = len(q)
+			}
+			continue
+		case ch == '\'':
+			i = scanQueryDelimited(q, i, '\'')
+			tokens = append(tokens, queryToken{kind: queryString, start: start, end: i})
+		case ch == '"':
+			i = scanQueryDelimited(q, i, '"')
+			tokens = append(tokens, queryToken{kind: queryQuotedIdent, start: start, end: i})
+		case ch == '/' && (len(tokens) == 0 || !tokens[len(tokens)-1].isOperand(q)):
+			i = scanQueryDelimited(q, i, '/')
+			tokens = append(tokens, queryToken{kind: queryRegex, start: start, end: i})
+		case ch == '$':
+			i++
+			if i < len(q) && q[i] == '"' {
+				i = scanQueryDelimited(q, i, '"')
+			} else {
+				i = scanQueryWord(q, i)
+			}
+			tokens = append(tokens, queryToken{kind: queryParam, start: start, end: i})
+		case ch >= '0' && ch <= '9' || ch == '.' && i+1 < len(q) && q[i+1] >= '0' && q[i+1] <= '9':
+			i = scanQueryWord(q, i+1)
+			tokens = append(tokens, queryToken{kind: queryNumber, start: start, end: i})
+		case ch == '_' || ch >= 0x80 || unicode.IsLetter(rune(ch)):
+			i = scanQueryWord(q, i)
+			tokens = append(tokens, queryToken{kind: queryIdent, start: start, end: i})
+		default:
+			i++
+			tokens = append(tokens, queryToken{kind: queryPunct, start: start, end: i, ch: ch})
+		}
+	}
+	return tokens
+}
+
+// scanQueryDelimited returns the offset after the closing delimiter of the
+// text starting at q[i]. A backslash escapes the character after it.
+func scanQueryDelimited(q string, i int, delim byte) int {
+	for i++; i < len(q); i++ {
+		switch q[i] {
+		case '\\':
+			i++
+		case delim:
+			return i + 1
+		}
+	}
+	return len(q)
+}
+
+// scanQueryWord returns the offset after the run of letters, digits,
+// underscores and dots starting at q[i].
+func scanQueryWord(q string, i int) int {
+	for ; i < len(q); i++ {
+		ch := q[i]
+		if ch != '_' && ch != '.' && ch < 0x80 && !unicode.IsLetter(rune(ch)) && !unicode.IsDigit(rune(ch)) {
+			break
+		}
+	}
+	return i
+}
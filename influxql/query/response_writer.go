@@ -7,9 +7,14 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"io"
+	"sort"
 	"strconv"
 	"time"
 
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/ipc"
+	"github.com/apache/arrow/go/v7/arrow/memory"
 	"github.com/influxdata/influxdb/v2/influxql"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/models"
@@ -30,6 +35,8 @@ func NewResponseWriter(encoding influxql.EncodingFormat) ResponseWriter {
 		return &csvFormatter{statementID: -1}
 	case influxql.EncodingFormatMessagePack:
 		return &msgpFormatter{}
+	case influxql.EncodingFormatArrow:
+		return &arrowFormatter{}
 	case influxql.EncodingFormatJSON:
 		fallthrough
 	default:
@@ -212,6 +219,9 @@ func (f *msgpFormatter) WriteResponse(ctx context.Context, w io.Writer, resp Res
 			if result.Partial {
 				sz++
 			}
+			if result.Truncated {
+				sz++
+			}
 			enc.WriteMapHeader(uint32(sz))
 			enc.WriteString("statement_id")
 			enc.WriteInt(result.StatementID)
@@ -274,11 +284,257 @@ func (f *msgpFormatter) WriteResponse(ctx context.Context, w io.Writer, resp Res
 				enc.WriteString("partial")
 				enc.WriteBool(true)
 			}
+			if result.Truncated {
+				enc.WriteString("truncated")
+				enc.WriteBool(true)
+			}
 		}
 	}
 	return nil
 }
 
+// arrowFormatter writes a response as a single Arrow IPC stream with one
+// record batch. Every row of every series is a row of the record. The schema
+// has a statement_id and name column, a column for each tag key, and the
+// union of the columns of all series. A column holding values of more than one
+// type is encoded as strings. The errors, messages, and partial flags of the
+// statements are stored as JSON in the schema metadata.
+type arrowFormatter struct{}
+
+// arrowStatement is the schema metadata written for each statement.
+type arrowStatement struct {
+	StatementID int        `json:"statement_id"`
+	Messages    []*Message `json:"messages,omitempty"`
+	Partial     bool       `json:"partial,omitempty"`
+	Err         string     `json:"error,omitempty"`
+}
+
+func (f *arrowFormatter) ContentType() string {
+	return "application/vnd.apache.arrow.stream"
+}
+
+func (f *arrowFormatter) WriteResponse(ctx context.Context, w io.Writer, resp Response) (err error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	meta := make(map[string]string)
+	if resp.Err != nil {
+		meta["error"] = resp.Err.Error()
+	}
+
+	// Collect the tag keys and columns of every series.
+	var tagKeys, columns []string
+	seenTags := make(map[string]struct{})
+	seenColumns := make(map[string]struct{})
+	statements := make([]arrowStatement, 0, len(resp.Results))
+	for _, result := range resp.Results {
+		stmt := arrowStatement{
+			StatementID: result.StatementID,
+			Messages:    result.Messages,
+			Partial:     result.Partial,
+		}
+		if result.Err != nil {
+			stmt.Err = result.Err.Error()
+		}
+		for _, row := range result.Series {
+			stmt.Partial = stmt.Partial || row.Partial
+			for k := range row.Tags {
+				if _, ok := seenTags[k]; !ok {
+					seenTags[k] = struct{}{}
+					tagKeys = append(tagKeys, k)
+				}
+			}
+			for _, name := range row.Columns {
+				if _, ok := seenColumns[name]; !ok {
+					seenColumns[name] = struct{}{}
+					columns = append(columns, name)
+				}
+			}
+		}
+		statements = append(statements, stmt)
+	}
+	sort.Strings(tagKeys)
+
+	if len(statements) > 0 {
+		b, err := json.Marshal(statements)
+		if err != nil {
+			return err
+		}
+		meta["statements"] = string(b)
+	}
+
+	// Lay out the schema. A column with the same name as a tag or one of
+	// the leading columns is renamed so every column name is unique.
+	fields := []arrow.Field{
+		{Name: "statement_id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+	}
+	index := map[string]int{"statement_id": 0, "name": 1}
+	for _, k := range tagKeys {
+		index[k] = len(fields)
+		fields = append(fields, arrow.Field{Name: k, Type: arrow.BinaryTypes.String, Nullable: true})
+	}
+	columnIndex := make(map[string]int, len(columns))
+	for _, name := range columns {
+		columnIndex[name] = len(fields)
+		fieldName := name
+		for i := 1; ; i++ {
+			if _, ok := index[fieldName]; !ok {
+				break
+			}
+			fieldName = name + "_" + strconv.Itoa(i)
+		}
+		index[fieldName] = len(fields)
+		fields = append(fields, arrow.Field{Name: fieldName, Nullable: true})
+	}
+
+	// Place the values of every series in the columns of the schema.
+	var values [][]interface{}
+	for _, result := range resp.Results {
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				out := make([]interface{}, len(fields))
+				out[0] = int64(result.StatementID)
+				if row.Name != "" {
+					out[1] = row.Name
+				}
+				for k, tv := range row.Tags {
+					out[index[k]] = tv
+				}
+				for j, name := range row.Columns {
+					if j < len(v) {
+						out[columnIndex[name]] = v[j]
+					}
+				}
+				values = append(values, out)
+			}
+		}
+	}
+	for _, i := range columnIndex {
+		fields[i].Type = arrowColumnType(values, i)
+	}
+	return writeArrowStream(w, memory.NewGoAllocator(), fields, values, meta)
+}
+
+// writeArrowStream writes a single Arrow IPC stream with the given fields and
+// schema metadata. The values are written as one record batch.
+func writeArrowStream(w io.Writer, mem memory.Allocator, fields []arrow.Field, values [][]interface{}, meta map[string]string) error {
+	md := arrow.MetadataFrom(meta)
+	schema := arrow.NewSchema(fields, &md)
+
+	wr := ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(mem))
+	if len(values) > 0 {
+		b := array.NewRecordBuilder(mem, schema)
+		defer b.Release()
+
+		for _, row := range values {
+			for i, fb := range b.Fields() {
+				var v interface{}
+				if i < len(row) {
+					v = row[i]
+				}
+				appendArrowValue(fb, v)
+			}
+		}
+
+		rec := b.NewRecord()
+		defer rec.Release()
+		if err := wr.Write(rec); err != nil {
+			return err
+		}
+	}
+	return wr.Close()
+}
+
+// arrowColumnType determines the Arrow data type for the column at index i.
+// Columns that only contain null values use the null type and columns that
+// contain values of more than one type are encoded as strings.
+func arrowColumnType(values [][]interface{}, i int) arrow.DataType {
+	var typ arrow.DataType = arrow.Null
+	for _, row := range values {
+		if i >= len(row) {
+			continue
+		}
+
+		var t arrow.DataType
+		switch row[i].(type) {
+		case float64:
+			t = arrow.PrimitiveTypes.Float64
+		case int64:
+			t = arrow.PrimitiveTypes.Int64
+		case uint64:
+			t = arrow.PrimitiveTypes.Uint64
+		case string:
+			t = arrow.BinaryTypes.String
+		case bool:
+			t = arrow.FixedWidthTypes.Boolean
+		case time.Time:
+			t = arrow.FixedWidthTypes.Timestamp_ns
+		default:
+			continue
+		}
+
+		if typ.ID() == arrow.NULL {
+			typ = t
+		} else if typ.ID() != t.ID() {
+			return arrow.BinaryTypes.String
+		}
+	}
+	return typ
+}
+
+func appendArrowValue(b array.Builder, v interface{}) {
+	switch b := b.(type) {
+	case *array.Float64Builder:
+		if v, ok := v.(float64); ok {
+			b.Append(v)
+			return
+		}
+	case *array.Int64Builder:
+		if v, ok := v.(int64); ok {
+			b.Append(v)
+			return
+		}
+	case *array.Uint64Builder:
+		if v, ok := v.(uint64); ok {
+			b.Append(v)
+			return
+		}
+	case *array.BooleanBuilder:
+		if v, ok := v.(bool); ok {
+			b.Append(v)
+			return
+		}
+	case *array.TimestampBuilder:
+		if v, ok := v.(time.Time); ok {
+			b.Append(arrow.Timestamp(v.UnixNano()))
+			return
+		}
+	case *array.StringBuilder:
+		switch v := v.(type) {
+		case string:
+			b.Append(v)
+			return
+		case float64:
+			b.Append(strconv.FormatFloat(v, 'f', -1, 64))
+			return
+		case int64:
+			b.Append(strconv.FormatInt(v, 10))
+			return
+		case uint64:
+			b.Append(strconv.FormatUint(v, 10))
+			return
+		case bool:
+			b.Append(strconv.FormatBool(v))
+			return
+		case time.Time:
+			b.Append(v.Format(time.RFC3339Nano))
+			return
+		}
+	}
+	b.AppendNull()
+}
+
 func stringsEqual(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -0,0 +1,206 @@
+package query_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/ipc"
+	"github.com/google/go-cmp/cmp"
+	iql "github.com/influxdata/influxdb/v2/influxql"
+	"github.com/influxdata/influxdb/v2/influxql/query"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// arrowTable is the schema metadata, columns, and rows decoded from an Arrow
+// stream.
+type arrowTable struct {
+	Metadata map[string]string
+	Columns  []string
+	Values   [][]interface{}
+}
+
+func decodeArrowStream(t *testing.T, r io.Reader) arrowTable {
+	t.Helper()
+
+	rd, err := ipc.NewReader(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer rd.Release()
+
+	schema := rd.Schema()
+	tbl := arrowTable{Metadata: make(map[string]string)}
+	md := schema.Metadata()
+	for i, k := range md.Keys() {
+		tbl.Metadata[k] = md.Values()[i]
+	}
+	for _, f := range schema.Fields() {
+		tbl.Columns = append(tbl.Columns, f.Name)
+	}
+
+	for rd.Next() {
+		rec := rd.Record()
+		for i := 0; i < int(rec.NumRows()); i++ {
+			row := make([]interface{}, rec.NumCols())
+			for j, col := range rec.Columns() {
+				if col.IsNull(i) {
+					continue
+				}
+				switch col := col.(type) {
+				case *array.Null:
+				case *array.Float64:
+					row[j] = col.Value(i)
+				case *array.Int64:
+					row[j] = col.Value(i)
+				case *array.Uint64:
+					row[j] = col.Value(i)
+				case *array.String:
+					row[j] = col.Value(i)
+				case *array.Boolean:
+					row[j] = col.Value(i)
+				case *array.Timestamp:
+					row[j] = time.Unix(0, int64(col.Value(i))).UTC()
+				default:
+					t.Fatalf("unexpected column type: %s", col.DataType())
+				}
+			}
+			tbl.Values = append(tbl.Values, row)
+		}
+	}
+	if err := rd.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return tbl
+}
+
+func TestResponseWriter_Arrow(t *testing.T) {
+	resp := query.Response{
+		Results: []*query.Result{
+			{
+				StatementID: 0,
+				Series: models.Rows{
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "server01"},
+						Columns: []string{"time", "value", "status", "ok"},
+						Values: [][]interface{}{
+							{time.Unix(0, 0).UTC(), float64(1.5), "up", true},
+							{time.Unix(10, 0).UTC(), nil, "down", false},
+						},
+					},
+					{
+						Name:    "cpu",
+						Tags:    map[string]string{"host": "server02"},
+						Columns: []string{"time", "value", "status", "ok"},
+						Values: [][]interface{}{
+							{time.Unix(0, 0).UTC(), float64(2), nil, nil},
+						},
+					},
+				},
+				Messages: []*query.Message{{Level: query.WarningLevel, Text: "warning"}},
+			},
+			{
+				StatementID: 1,
+				Series: models.Rows{
+					{
+						Name:    "mem",
+						Columns: []string{"time", "count", "free", "host"},
+						Values: [][]interface{}{
+							{time.Unix(0, 0).UTC(), int64(3), uint64(10), "server03"},
+						},
+					},
+				},
+			},
+			{
+				StatementID: 2,
+				Err:         errors.New("expected error"),
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	w := query.NewResponseWriter(iql.EncodingFormatArrow)
+	if err := w.WriteResponse(context.Background(), &buf, resp); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := arrowTable{
+		Metadata: map[string]string{
+			"statements": `[{"statement_id":0,"messages":[{"level":"warning","text":"warning"}]},{"statement_id":1},{"statement_id":2,"error":"expected error"}]`,
+		},
+		Columns: []string{"statement_id", "name", "host", "time", "value", "status", "ok", "count", "free", "host_1"},
+		Values: [][]interface{}{
+			{int64(0), "cpu", "server01", time.Unix(0, 0).UTC(), float64(1.5), "up", true, nil, nil, nil},
+			{int64(0), "cpu", "server01", time.Unix(10, 0).UTC(), nil, "down", false, nil, nil, nil},
+			{int64(0), "cpu", "server02", time.Unix(0, 0).UTC(), float64(2), nil, nil, nil, nil, nil},
+			{int64(1), "mem", nil, time.Unix(0, 0).UTC(), nil, nil, nil, int64(3), uint64(10), "server03"},
+		},
+	}
+	if got := decodeArrowStream(t, &buf); !cmp.Equal(exp, got) {
+		t.Errorf("unexpected stream:\n%s", cmp.Diff(exp, got))
+	}
+}
+
+func TestResponseWriter_Arrow_Error(t *testing.T) {
+	var buf bytes.Buffer
+	w := query.NewResponseWriter(iql.EncodingFormatArrow)
+	if err := w.WriteResponse(context.Background(), &buf, query.Response{Err: errors.New("expected error")}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	exp := arrowTable{
+		Metadata: map[string]string{"error": "expected error"},
+		Columns:  []string{"statement_id", "name"},
+	}
+	if got := decodeArrowStream(t, &buf); !cmp.Equal(exp, got) {
+		t.Errorf("unexpected stream:\n%s", cmp.Diff(exp, got))
+	}
+}
+
+func TestResponseWriter_Arrow_ColumnTypes(t *testing.T) {
+	resp := query.Response{
+		Results: []*query.Result{
+			{
+				Series: models.Rows{
+					{
+						Name:    "m",
+						Columns: []string{"time", "empty", "mixed"},
+						Values: [][]interface{}{
+							{time.Unix(0, 0).UTC(), nil, int64(1)},
+							{time.Unix(1, 0).UTC(), nil, "a"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	w := query.NewResponseWriter(iql.EncodingFormatArrow)
+	if err := w.WriteResponse(context.Background(), &buf, resp); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	rd, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer rd.Release()
+
+	fields := rd.Schema().Fields()
+	if got, want := fields[2].Type, arrow.FixedWidthTypes.Timestamp_ns; !arrow.TypeEqual(got, want) {
+		t.Errorf("unexpected type for time: got=%s want=%s", got, want)
+	}
+	if got, want := fields[3].Type, arrow.Null; !arrow.TypeEqual(got, want) {
+		t.Errorf("unexpected type for empty: got=%s want=%s", got, want)
+	}
+	if got, want := fields[4].Type, arrow.BinaryTypes.String; !arrow.TypeEqual(got, want) {
+		t.Errorf("unexpected type for mixed: got=%s want=%s", got, want)
+	}
+}
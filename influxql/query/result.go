@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxql"
@@ -12,6 +13,9 @@ import (
 const (
 	// WarningLevel is the message level for a warning.
 	WarningLevel = "warning"
+
+	// InfoLevel is the message level for information about the query.
+	InfoLevel = "info"
 )
 
 // TagSet is a fundamental concept within the query system. It represents a composite series,
@@ -62,6 +66,22 @@ func LimitTagSets(a []*TagSet, slimit, soffset int) []*TagSet {
 	return a[soffset : soffset+slimit]
 }
 
+// FilterTagSets returns the tag sets whose key is in keys. All tag sets are
+// returned if keys is nil.
+func FilterTagSets(a []*TagSet, keys map[string]struct{}) []*TagSet {
+	if keys == nil {
+		return a
+	}
+
+	other := make([]*TagSet, 0, len(keys))
+	for _, t := range a {
+		if _, ok := keys[string(t.Key)]; ok {
+			other = append(other, t)
+		}
+	}
+	return other
+}
+
 // Message represents a user-facing message to be included with the result.
 type Message struct {
 	Level string `json:"level"`
@@ -80,7 +100,27 @@ func ReadOnlyWarning(stmt string) *Message {
 	}
 }
 
-// Result represents a resultset returned from a single statement.
+// ImplicitTimeRangeWarning generates a warning message that tells the user the
+// time range of a statement without a lower time bound was limited to the last
+// n intervals.
+func ImplicitTimeRangeWarning(n int) *Message {
+	return &Message{
+		Level: WarningLevel,
+		Text:  fmt.Sprintf("no lower time bound was specified, results are limited to the last %d intervals", n),
+	}
+}
+
+// TimeRangeMessage generates a message that tells the user the time range a
+// statement read after now() was resolved. The end of the range is exclusive.
+func TimeRangeMessage(t influxql.TimeRange) *Message {
+	return &Message{
+		Level: InfoLevel,
+		Text: fmt.Sprintf("time range: [%s, %s)",
+			time.Unix(0, t.MinTimeNano()).UTC().Format(time.RFC3339Nano),
+			time.Unix(0, t.MaxTimeNano()+1).UTC().Format(time.RFC3339Nano)),
+	}
+}
+
 // Rows represents a list of rows that can be sorted consistently by name/tag.
 type Result struct {
 	// StatementID is just the statement's position in the query. It's used
@@ -89,6 +129,7 @@ type Result struct {
 	Series      models.Rows
 	Messages    []*Message
 	Partial     bool
+	Truncated   bool
 	Err         error
 }
 
@@ -100,6 +141,7 @@ func (r *Result) MarshalJSON() ([]byte, error) {
 		Series      []*models.Row `json:"series,omitempty"`
 		Messages    []*Message    `json:"messages,omitempty"`
 		Partial     bool          `json:"partial,omitempty"`
+		Truncated   bool          `json:"truncated,omitempty"`
 		Err         string        `json:"error,omitempty"`
 	}
 
@@ -108,6 +150,7 @@ func (r *Result) MarshalJSON() ([]byte, error) {
 	o.Series = r.Series
 	o.Messages = r.Messages
 	o.Partial = r.Partial
+	o.Truncated = r.Truncated
 	if r.Err != nil {
 		o.Err = r.Err.Error()
 	}
@@ -122,6 +165,7 @@ func (r *Result) UnmarshalJSON(b []byte) error {
 		Series      []*models.Row `json:"series,omitempty"`
 		Messages    []*Message    `json:"messages,omitempty"`
 		Partial     bool          `json:"partial,omitempty"`
+		Truncated   bool          `json:"truncated,omitempty"`
 		Err         string        `json:"error,omitempty"`
 	}
 
@@ -133,6 +177,7 @@ func (r *Result) UnmarshalJSON(b []byte) error {
 	r.Series = o.Series
 	r.Messages = o.Messages
 	r.Partial = o.Partial
+	r.Truncated = o.Truncated
 	if o.Err != "" {
 		r.Err = errors.New(o.Err)
 	}
@@ -2,6 +2,7 @@ package query
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
@@ -40,8 +41,38 @@ type SelectOptions struct {
 	// Maximum number of buckets for a statement.
 	MaxBucketsN int
 
+	// Maximum number of instructions of a compiled regular expression.
+	MaxRegexComplexity int
+
 	// StatisticsGatherer gathers metrics about the execution of the query.
 	StatisticsGatherer *iql.StatisticsGatherer
+
+	// Parse string values as numbers for transformations.
+	CoerceNumeric bool
+
+	// Order top() and bottom() output by value instead of by time.
+	OrderValues bool
+
+	// Read numeric field values equal to this value as null.
+	TreatAsNull *float64
+
+	// Report the resolved time range of the statement as a message.
+	EchoRange bool
+
+	// Add the confidence bounds of each holt_winters() forecast as columns.
+	HoltWintersBounds bool
+
+	// Apply SLIMIT and SOFFSET while reading series from the index.
+	LimitSeriesFirst bool
+
+	// Label each GROUP BY time() bucket by its end time instead of its start.
+	LabelRight bool
+
+	// Drop the series whose grouping tags all have empty values.
+	DropEmptyTags bool
+
+	// The time now() resolves to. The current time is used when it is zero.
+	Now time.Time
 }
 
 // ShardMapper retrieves and maps shards into an IteratorCreator that can later be
@@ -103,6 +134,13 @@ type PreparedStatement interface {
 	// Explain outputs the explain plan for this statement.
 	Explain(ctx context.Context) (string, error)
 
+	// Estimate returns the number of shards and series this statement
+	// would read without creating any iterators.
+	Estimate(ctx context.Context) (IteratorCost, error)
+
+	// Columns returns the names of the columns the statement will output.
+	Columns() []string
+
 	// Close closes the resources associated with this prepared statement.
 	// This must be called as the mapped shards may hold open resources such
 	// as network connections.
@@ -112,13 +150,45 @@ type PreparedStatement interface {
 // Prepare will compile the statement with the default compile options and
 // then prepare the query.
 func Prepare(ctx context.Context, stmt *influxql.SelectStatement, shardMapper ShardMapper, opt SelectOptions) (PreparedStatement, error) {
-	c, err := Compile(stmt, CompileOptions{})
+	if opt.HoltWintersBounds {
+		stmt = withHoltWintersBounds(stmt)
+	}
+	c, err := Compile(stmt, CompileOptions{Now: opt.Now})
 	if err != nil {
 		return nil, err
 	}
 	return c.Prepare(ctx, shardMapper, opt)
 }
 
+// withHoltWintersBounds returns a copy of the statement with the upper and
+// lower confidence bounds of each holt_winters() field added after it. The
+// bounds are named after the field with an _upper and _lower suffix.
+func withHoltWintersBounds(stmt *influxql.SelectStatement) *influxql.SelectStatement {
+	fields := make(influxql.Fields, 0, len(stmt.Fields))
+	for _, f := range stmt.Fields {
+		fields = append(fields, f)
+		call, ok := f.Expr.(*influxql.Call)
+		if !ok || (call.Name != "holt_winters" && call.Name != "holt_winters_with_fit") {
+			continue
+		}
+		name := f.Name()
+		for _, bound := range []string{"upper", "lower"} {
+			bcall := influxql.CloneExpr(call).(*influxql.Call)
+			bcall.Name = "holt_winters_" + bound
+			fields = append(fields, &influxql.Field{
+				Expr:  bcall,
+				Alias: name + "_" + bound,
+			})
+		}
+	}
+	if len(fields) == len(stmt.Fields) {
+		return stmt
+	}
+	other := stmt.Clone()
+	other.Fields = fields
+	return other
+}
+
 // Select compiles, prepares, and then initiates execution of the query using the
 // default compile options.
 func Select(ctx context.Context, stmt *influxql.SelectStatement, shardMapper ShardMapper, opt SelectOptions) (Cursor, error) {
@@ -141,6 +211,22 @@ type preparedStatement struct {
 	columns   []string
 	maxPointN int
 	now       time.Time
+
+	// limitStartTime is the start of the earliest bucket that can be
+	// returned when the buckets are limited. It is zero if the time range
+	// cannot be narrowed.
+	limitStartTime int64
+
+	// labelRight labels each bucket by its end time.
+	labelRight bool
+
+	// dropEmptyTags drops the series whose grouping tags are all empty.
+	dropEmptyTags bool
+
+	// window filters the buckets by their time. The limit and offset are
+	// applied to the buckets that match instead of by the iterators.
+	window        influxql.Expr
+	limit, offset int
 }
 
 type contextKey string
@@ -155,12 +241,65 @@ func (p *preparedStatement) Select(ctx context.Context) (Cursor, error) {
 
 	opt := p.opt
 	opt.InterruptCh = ctx.Done()
+
+	if p.limitStartTime != 0 {
+		limited := opt
+		limited.StartTime = p.limitStartTime
+		cur, err := buildCursor(ctx, p.stmt, p.ic, limited)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only the buckets within the limit need to be read. If there are no
+		// points in those buckets, use the full time range so the empty
+		// buckets are still filled.
+		peek := &peekCursor{Cursor: cur}
+		if peek.peeked = cur.Scan(&peek.row); peek.peeked {
+			return p.label(p.dropEmpty(p.filterWindows(peek, limited)), limited), nil
+		} else if err := cur.Err(); err != nil {
+			cur.Close()
+			return nil, err
+		}
+		cur.Close()
+	}
+
 	cur, err := buildCursor(ctx, p.stmt, p.ic, opt)
 	if err != nil {
 		return nil, err
 	}
 
-	return cur, nil
+	return p.label(p.dropEmpty(p.filterWindows(cur, opt)), opt), nil
+}
+
+// filterWindows returns a cursor that drops the buckets whose time does not
+// match the window condition of the statement, if it has one.
+func (p *preparedStatement) filterWindows(cur Cursor, opt IteratorOptions) Cursor {
+	if p.window == nil {
+		return cur
+	}
+	return newWindowCursor(cur, p.window, opt, p.limit, p.offset)
+}
+
+// label returns a cursor that labels each bucket by its end time if it was
+// requested for a statement grouped by time.
+func (p *preparedStatement) label(cur Cursor, opt IteratorOptions) Cursor {
+	if !p.labelRight || opt.Interval.IsZero() || p.stmt.OmitTime {
+		return cur
+	}
+	return &rightLabelCursor{Cursor: cur, opt: opt}
+}
+
+// dropEmpty returns a cursor that drops the series whose grouping tags
+// all have empty values if it was requested.
+func (p *preparedStatement) dropEmpty(cur Cursor) Cursor {
+	if !p.dropEmptyTags {
+		return cur
+	}
+	return &dropEmptyTagsCursor{Cursor: cur}
+}
+
+func (p *preparedStatement) Columns() []string {
+	return p.columns
 }
 
 func (p *preparedStatement) Close() error {
@@ -246,13 +385,20 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 	// Eliminate limits and offsets if they were previously set. These are handled by the caller.
 	opt.Limit, opt.Offset = 0, 0
 	switch expr.Name {
-	case "distinct":
+	case "fill":
+		opt.Fill, opt.FillValue, _ = fillOptionFromExpr(expr.Args[1])
+		return buildExprIterator(ctx, expr.Args[0], b.ic, b.sources, opt, b.selector, b.writeMode)
+	case "distinct", distinctCountCall:
 		opt.Ordered = true
 		input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, b.selector, false)
 		if err != nil {
 			return nil, err
 		}
-		input, err = NewDistinctIterator(input, opt)
+		if expr.Name == distinctCountCall {
+			input, err = newDistinctCountIterator(input, opt)
+		} else {
+			input, err = NewDistinctIterator(input, opt)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -266,7 +412,7 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 		size := expr.Args[1].(*influxql.IntegerLiteral)
 
 		return newSampleIterator(input, opt, int(size.Val))
-	case "holt_winters", "holt_winters_with_fit":
+	case "holt_winters", "holt_winters_with_fit", "holt_winters_upper", "holt_winters_lower":
 		opt.Ordered = true
 		input, err := buildExprIterator(ctx, expr.Args[0], b.ic, b.sources, opt, b.selector, false)
 		if err != nil {
@@ -277,19 +423,31 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 
 		includeFitData := expr.Name == "holt_winters_with_fit"
 
+		var z float64
+		switch expr.Name {
+		case "holt_winters_upper":
+			z = hwConfidenceZ
+		case "holt_winters_lower":
+			z = -hwConfidenceZ
+		}
+
 		interval := opt.Interval.Duration
 		// Redefine interval to be unbounded to capture all aggregate results
 		opt.StartTime = influxql.MinTime
 		opt.EndTime = influxql.MaxTime
 		opt.Interval = Interval{}
 
-		return newHoltWintersIterator(input, opt, int(h.Val), int(m.Val), includeFitData, interval)
+		return newHoltWintersIterator(input, opt, int(h.Val), int(m.Val), includeFitData, interval, z)
 	case "count_hll", "derivative", "non_negative_derivative", "difference", "non_negative_difference", "moving_average", "exponential_moving_average", "double_exponential_moving_average", "triple_exponential_moving_average", "relative_strength_index", "triple_exponential_derivative", "kaufmans_efficiency_ratio", "kaufmans_adaptive_moving_average", "chande_momentum_oscillator", "elapsed":
-		if !opt.Interval.IsZero() {
+		// elapsed() of raw values is restarted in each interval so it
+		// does not need to read the previous interval.
+		_, isRawElapsed := expr.Args[0].(*influxql.VarRef)
+		isRawElapsed = isRawElapsed && expr.Name == "elapsed"
+		if !opt.Interval.IsZero() && !isRawElapsed {
 			if opt.Ascending {
-				opt.StartTime -= int64(opt.Interval.Duration)
+				opt.StartTime = opt.addIntervals(opt.StartTime, -1)
 			} else {
-				opt.EndTime += int64(opt.Interval.Duration)
+				opt.EndTime = opt.addIntervals(opt.EndTime, 1)
 			}
 		}
 		opt.Ordered = true
@@ -298,6 +456,9 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 		if err != nil {
 			return nil, err
 		}
+		if expr.Name != "count_hll" && expr.Name != "elapsed" {
+			input = coerceNumeric(input, opt)
+		}
 
 		switch expr.Name {
 		case "count_hll":
@@ -308,7 +469,11 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 			return newDerivativeIterator(input, opt, interval, isNonNegative)
 		case "elapsed":
 			interval := opt.ElapsedInterval()
-			return newElapsedIterator(input, opt, interval)
+			var window func(t int64) (start, end int64)
+			if isRawElapsed && !opt.Interval.IsZero() {
+				window = opt.Window
+			}
+			return newElapsedIterator(input, opt, interval, window)
 		case "difference", "non_negative_difference":
 			isNonNegative := (expr.Name == "non_negative_difference")
 			return newDifferenceIterator(input, opt, isNonNegative)
@@ -316,9 +481,9 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 			n := expr.Args[1].(*influxql.IntegerLiteral)
 			if n.Val > 1 && !opt.Interval.IsZero() {
 				if opt.Ascending {
-					opt.StartTime -= int64(opt.Interval.Duration) * (n.Val - 1)
+					opt.StartTime = opt.addIntervals(opt.StartTime, -int(n.Val-1))
 				} else {
-					opt.EndTime += int64(opt.Interval.Duration) * (n.Val - 1)
+					opt.EndTime = opt.addIntervals(opt.EndTime, int(n.Val-1))
 				}
 			}
 			return newMovingAverageIterator(input, int(n.Val), opt)
@@ -326,9 +491,9 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 			n := expr.Args[1].(*influxql.IntegerLiteral)
 			if n.Val > 1 && !opt.Interval.IsZero() {
 				if opt.Ascending {
-					opt.StartTime -= int64(opt.Interval.Duration) * (n.Val - 1)
+					opt.StartTime = opt.addIntervals(opt.StartTime, -int(n.Val-1))
 				} else {
-					opt.EndTime += int64(opt.Interval.Duration) * (n.Val - 1)
+					opt.EndTime = opt.addIntervals(opt.EndTime, int(n.Val-1))
 				}
 			}
 
@@ -360,9 +525,9 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 			n := expr.Args[1].(*influxql.IntegerLiteral)
 			if n.Val > 1 && !opt.Interval.IsZero() {
 				if opt.Ascending {
-					opt.StartTime -= int64(opt.Interval.Duration) * (n.Val - 1)
+					opt.StartTime = opt.addIntervals(opt.StartTime, -int(n.Val-1))
 				} else {
-					opt.EndTime += int64(opt.Interval.Duration) * (n.Val - 1)
+					opt.EndTime = opt.addIntervals(opt.EndTime, int(n.Val-1))
 				}
 			}
 
@@ -381,9 +546,9 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 			n := expr.Args[1].(*influxql.IntegerLiteral)
 			if n.Val > 1 && !opt.Interval.IsZero() {
 				if opt.Ascending {
-					opt.StartTime -= int64(opt.Interval.Duration) * (n.Val - 1)
+					opt.StartTime = opt.addIntervals(opt.StartTime, -int(n.Val-1))
 				} else {
-					opt.EndTime += int64(opt.Interval.Duration) * (n.Val - 1)
+					opt.EndTime = opt.addIntervals(opt.EndTime, int(n.Val-1))
 				}
 			}
 
@@ -411,7 +576,14 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 		if err != nil {
 			return nil, err
 		}
-		return newCumulativeSumIterator(input, opt)
+		return newCumulativeSumIterator(coerceNumeric(input, opt), opt)
+	case "percent_of_total":
+		opt.Ordered = true
+		input, err := buildExprIterator(ctx, expr.Args[0], b.ic, b.sources, opt, b.selector, false)
+		if err != nil {
+			return nil, err
+		}
+		return newPercentOfTotalIterator(coerceNumeric(input, opt))
 	case "integral":
 		opt.Ordered = true
 		input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
@@ -419,7 +591,16 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 			return nil, err
 		}
 		interval := opt.IntegralInterval()
-		return newIntegralIterator(input, opt, interval)
+		itr, err := newIntegralIterator(input, opt, interval)
+		if err != nil {
+			return nil, err
+		}
+
+		// Intervals without any points have no area.
+		if !opt.Interval.IsZero() && opt.Fill != influxql.NoFill {
+			itr = NewFillIterator(itr, expr, opt)
+		}
+		return itr, nil
 	case "top":
 		if len(expr.Args) < 2 {
 			return nil, fmt.Errorf("top() requires 2 or more arguments, got %d", len(expr.Args))
@@ -472,6 +653,10 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 		}
 
 		n := expr.Args[len(expr.Args)-1].(*influxql.IntegerLiteral)
+		if opt.OrderValues {
+			// Emit the points in the order of the reducer rather than by time.
+			opt.Ordered = false
+		}
 		return newTopIterator(input, opt, int(n.Val), b.writeMode)
 	case "bottom":
 		if len(expr.Args) < 2 {
@@ -525,7 +710,19 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 		}
 
 		n := expr.Args[len(expr.Args)-1].(*influxql.IntegerLiteral)
-		return newBottomIterator(input, b.opt, int(n.Val), b.writeMode)
+		bottomOpt := b.opt
+		if bottomOpt.OrderValues {
+			// Emit the points in the order of the reducer rather than by time.
+			bottomOpt.Ordered = false
+		}
+		return newBottomIterator(input, bottomOpt, int(n.Val), b.writeMode)
+	}
+
+	// Keep series without values for the field so fill(null) reports them.
+	// count() fills with zero and is left out.
+	switch expr.Name {
+	case "min", "max", "sum", "first", "last", "mean", "median", "mode", "stddev", "stddev_pop", "spread", "iqr", "percentile":
+		opt.KeepEmptySeries = opt.Fill == influxql.NullFill && !opt.Interval.IsZero() && len(opt.Aux) == 0
 	}
 
 	itr, err := func() (Iterator, error) {
@@ -544,6 +741,23 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 			fallthrough
 		case "min", "max", "sum", "first", "last", "mean", "sum_hll", "merge_hll":
 			return b.callIterator(ctx, expr, opt)
+		case "count_rate":
+			// Count the points in each interval and divide by its length.
+			call := &influxql.Call{Name: "count", Args: expr.Args}
+			callOpt := opt
+			callOpt.Expr = call
+			input, err := b.callIterator(ctx, call, callOpt)
+			if err != nil {
+				return nil, err
+			}
+			return newCountRateIterator(input, opt)
+		case "resets":
+			opt.Ordered = true
+			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
+			if err != nil {
+				return nil, err
+			}
+			return newResetsIterator(input, opt)
 		case "median":
 			opt.Ordered = true
 			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
@@ -551,18 +765,112 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 				return nil, err
 			}
 			return newMedianIterator(input, opt)
+		case "mean_over_time", "stddev_over_time", "percentile_over_time":
+			// Read one window before the first interval and copy each point
+			// into the intervals whose trailing window contains it so the
+			// intervals can be reduced as usual.
+			window := expr.Args[len(expr.Args)-1].(*influxql.DurationLiteral).Val
+			inputOpt := opt
+			if inputOpt.StartTime > influxql.MinTime+int64(window) {
+				inputOpt.StartTime -= int64(window)
+			} else {
+				inputOpt.StartTime = influxql.MinTime
+			}
+			inputOpt.Ordered = true
+			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, inputOpt, false, false)
+			if err != nil {
+				return nil, err
+			}
+			input, err = newSlidingWindowIterator(input, window, opt)
+			if err != nil {
+				return nil, err
+			}
+
+			switch expr.Name {
+			case "mean_over_time":
+				return newMeanIterator(input, opt)
+			case "stddev_over_time":
+				return newStddevIterator(input, opt, false)
+			}
+			var percentile float64
+			switch arg := expr.Args[1].(type) {
+			case *influxql.NumberLiteral:
+				percentile = arg.Val
+			case *influxql.IntegerLiteral:
+				percentile = float64(arg.Val)
+			}
+			return newPercentileIterator(input, opt, percentile)
+		case "rate":
+			if len(expr.Args) == 1 {
+				// Read the interval before the first one so the increase
+				// from the last point of each interval to the first point
+				// of the next is counted in the next interval.
+				inputOpt := opt
+				if t := opt.addIntervals(opt.StartTime, -1); t > influxql.MinTime && t < opt.StartTime {
+					inputOpt.StartTime = t
+				} else {
+					inputOpt.StartTime = influxql.MinTime
+				}
+				inputOpt.Ordered = true
+				input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, inputOpt, false, false)
+				if err != nil {
+					return nil, err
+				}
+				input, err = newCarryWindowIterator(input, opt)
+				if err != nil {
+					return nil, err
+				}
+				return newRateIterator(input, opt, 0)
+			}
+
+			// Measure the increase over the trailing window of each interval
+			// the same way as mean_over_time().
+			window := expr.Args[1].(*influxql.DurationLiteral).Val
+			inputOpt := opt
+			if inputOpt.StartTime > influxql.MinTime+int64(window) {
+				inputOpt.StartTime -= int64(window)
+			} else {
+				inputOpt.StartTime = influxql.MinTime
+			}
+			inputOpt.Ordered = true
+			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, inputOpt, false, false)
+			if err != nil {
+				return nil, err
+			}
+			input, err = newSlidingWindowIterator(input, window, opt)
+			if err != nil {
+				return nil, err
+			}
+			return newRateIterator(input, opt, window)
+		case "last_value":
+			// Read from the beginning of time so the value in effect at the
+			// start of the first interval can be carried forward.
+			inputOpt := opt
+			inputOpt.StartTime = influxql.MinTime
+			inputOpt.Ordered = true
+			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, inputOpt, false, false)
+			if err != nil {
+				return nil, err
+			}
+			return newLastValueIterator(input, opt.StartTime, opt)
 		case "mode":
 			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
 			if err != nil {
 				return nil, err
 			}
 			return NewModeIterator(input, opt)
-		case "stddev":
+		case "modes":
+			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
+			if err != nil {
+				return nil, err
+			}
+			return NewModesIterator(input, opt)
+		case "stddev", "stddev_pop":
 			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
 			if err != nil {
 				return nil, err
 			}
-			return newStddevIterator(input, opt)
+			return newStddevIterator(input, opt, expr.Name == "stddev_pop")
 		case "spread":
 			// OPTIMIZE(benbjohnson): convert to map/reduce
 			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
@@ -584,6 +892,33 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 				percentile = float64(arg.Val)
 			}
 			return newPercentileIterator(input, opt, percentile)
+		case "iqr":
+			opt.Ordered = true
+			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
+			if err != nil {
+				return nil, err
+			}
+			return newIQRIterator(input, opt)
+		case "precision":
+			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
+			if err != nil {
+				return nil, err
+			}
+			return newPrecisionIterator(input, opt)
+		case "trimmed_mean":
+			opt.Ordered = true
+			input, err := buildExprIterator(ctx, expr.Args[0].(*influxql.VarRef), b.ic, b.sources, opt, false, false)
+			if err != nil {
+				return nil, err
+			}
+			var percent float64
+			switch arg := expr.Args[1].(type) {
+			case *influxql.NumberLiteral:
+				percent = arg.Val
+			case *influxql.IntegerLiteral:
+				percent = float64(arg.Val)
+			}
+			return newTrimmedMeanIterator(input, opt, percent)
 		default:
 			return nil, fmt.Errorf("unsupported call: %s", expr.Name)
 		}
@@ -594,7 +929,10 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *influ
 	}
 
 	if !b.selector || !opt.Interval.IsZero() {
-		itr = NewIntervalIterator(itr, opt)
+		// Keep the time of the selected point if time was selected explicitly.
+		if !b.selector || !opt.PointTime {
+			itr = NewIntervalIterator(itr, opt)
+		}
 		if !opt.Interval.IsZero() && opt.Fill != influxql.NoFill {
 			itr = NewFillIterator(itr, expr, opt)
 		}
@@ -748,6 +1086,23 @@ func buildCursor(ctx context.Context, stmt *influxql.SelectStatement, ic Iterato
 		}
 	}
 
+	// A distinct() call and a count() of it are read in a single pass. The
+	// count of each interval is returned with every distinct value.
+	calls := make([]*influxql.Call, 0, len(valueMapper.calls))
+	for call := range valueMapper.calls {
+		calls = append(calls, call)
+	}
+	if dcall, ccall, ok := distinctCountCalls(calls); ok {
+		call := &influxql.Call{Name: distinctCountCall, Args: dcall.Args}
+		itr, err := buildFieldIterator(ctx, call, ic, stmt.Sources, opt, false, stmt.Target != nil)
+		if err != nil {
+			return nil, err
+		}
+		keys := []influxql.VarRef{valueMapper.table[dcall], valueMapper.table[ccall]}
+		scanner := NewIteratorScanner(itr, keys, opt.FillValue)
+		return newScannerCursor(scanner, fields, opt), nil
+	}
+
 	// Produce an iterator for every single call and create an iterator scanner
 	// associated with it.
 	var g errgroup.Group
@@ -922,6 +1277,11 @@ func (v *valueMapper) Visit(n influxql.Node) influxql.Visitor {
 			}
 			v.calls[n] = struct{}{}
 		case *influxql.VarRef:
+			// The time of the point is filled in by the cursor when it
+			// is referenced inside of an expression such as truncate().
+			if n.Val == "time" {
+				return v
+			}
 			v.refs[n] = struct{}{}
 		default:
 			return v
@@ -966,6 +1326,9 @@ func validateTypes(stmt *influxql.SelectStatement) error {
 		),
 	}
 	for _, f := range stmt.Fields {
+		if err := validateBitwiseTypes(f.Expr, &valuer); err != nil {
+			return err
+		}
 		if _, err := valuer.EvalType(f.Expr); err != nil {
 			return err
 		}
@@ -973,6 +1336,76 @@ func validateTypes(stmt *influxql.SelectStatement) error {
 	return nil
 }
 
+// validateBitwiseTypes returns an error if a bitwise operator within expr is
+// applied to a float or string operand.
+func validateBitwiseTypes(expr influxql.Expr, valuer *influxql.TypeValuerEval) error {
+	var err error
+	influxql.WalkFunc(expr, func(n influxql.Node) {
+		expr, ok := n.(*influxql.BinaryExpr)
+		if !ok || err != nil {
+			return
+		}
+		switch expr.Op {
+		case influxql.BITWISE_AND, influxql.BITWISE_OR, influxql.BITWISE_XOR:
+		default:
+			return
+		}
+
+		for _, operand := range []influxql.Expr{expr.LHS, expr.RHS} {
+			typ, e := valuer.EvalType(operand)
+			if e != nil {
+				err = e
+				return
+			}
+			if typ == influxql.Float || typ == influxql.String {
+				err = errors.New("bitwise operator requires integer operands")
+				return
+			}
+		}
+	})
+	return err
+}
+
+// validateRegexTypes returns an error if a regex operator within the
+// condition is applied to a field that does not hold strings.
+func validateRegexTypes(stmt *influxql.SelectStatement, mapper influxql.TypeMapper) error {
+	if stmt.Condition == nil {
+		return nil
+	}
+
+	valuer := influxql.TypeValuerEval{
+		TypeMapper: mapper,
+		Sources:    stmt.Sources,
+	}
+	var err error
+	influxql.WalkFunc(stmt.Condition, func(n influxql.Node) {
+		expr, ok := n.(*influxql.BinaryExpr)
+		if !ok || err != nil {
+			return
+		}
+		switch expr.Op {
+		case influxql.EQREGEX, influxql.NEQREGEX:
+		default:
+			return
+		}
+
+		ref, ok := expr.LHS.(*influxql.VarRef)
+		if !ok {
+			return
+		}
+		typ, e := valuer.EvalType(ref)
+		if e != nil {
+			err = e
+			return
+		}
+		switch typ {
+		case influxql.Float, influxql.Integer, influxql.Unsigned, influxql.Boolean:
+			err = fmt.Errorf("regex operator requires a string field or tag, but %s is %s", ref.Val, typ)
+		}
+	})
+	return err
+}
+
 // hasValidType returns true if there is at least one non-unknown type
 // in the slice.
 func hasValidType(refs []influxql.VarRef) bool {
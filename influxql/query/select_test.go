@@ -450,6 +450,173 @@ func TestSelect(t *testing.T) {
 			itrs: []query.Iterator{&BooleanIterator{}},
 			err:  `unsupported median iterator type: *query_test.BooleanIterator`,
 		},
+		{
+			name: "IQR_Float",
+			q:    `SELECT iqr(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z' GROUP BY time(10s), host fill(none)`,
+			typ:  influxql.Float,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 0 * Second, Value: 1},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 1 * Second, Value: 2},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 2 * Second, Value: 3},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 3 * Second, Value: 4},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 4 * Second, Value: 5},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 5 * Second, Value: 6},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 6 * Second, Value: 7},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 7 * Second, Value: 8},
+				}},
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 0 * Second, Value: 10},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 1 * Second, Value: 40},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 2 * Second, Value: 20},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 3 * Second, Value: 30},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 10 * Second, Value: 50},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{float64(4)}},
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{float64(20)}},
+			},
+		},
+		{
+			name: "IQR_Integer",
+			q:    `SELECT iqr(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z' GROUP BY time(10s), host fill(none)`,
+			typ:  influxql.Integer,
+			itrs: []query.Iterator{
+				&IntegerIterator{Points: []query.IntegerPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 0 * Second, Value: 1},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 1 * Second, Value: 2},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 2 * Second, Value: 3},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 3 * Second, Value: 4},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 4 * Second, Value: 5},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 5 * Second, Value: 6},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 6 * Second, Value: 7},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 7 * Second, Value: 8},
+				}},
+				&IntegerIterator{Points: []query.IntegerPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 0 * Second, Value: 10},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 1 * Second, Value: 40},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 2 * Second, Value: 20},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 3 * Second, Value: 30},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 10 * Second, Value: 50},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{int64(4)}},
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{int64(20)}},
+			},
+		},
+		{
+			name: "IQR_Unsigned",
+			q:    `SELECT iqr(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z' GROUP BY time(10s), host fill(none)`,
+			typ:  influxql.Unsigned,
+			itrs: []query.Iterator{
+				&UnsignedIterator{Points: []query.UnsignedPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 0 * Second, Value: 1},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 1 * Second, Value: 2},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 2 * Second, Value: 3},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 3 * Second, Value: 4},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 4 * Second, Value: 5},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 5 * Second, Value: 6},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 6 * Second, Value: 7},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 7 * Second, Value: 8},
+				}},
+				&UnsignedIterator{Points: []query.UnsignedPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 0 * Second, Value: 10},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 1 * Second, Value: 40},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 2 * Second, Value: 20},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 3 * Second, Value: 30},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 10 * Second, Value: 50},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{uint64(4)}},
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{uint64(20)}},
+			},
+		},
+		{
+			name: "TrimmedMean_Float",
+			q:    `SELECT trimmed_mean(value, 20) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z' GROUP BY time(10s), host fill(none)`,
+			typ:  influxql.Float,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 0 * Second, Value: -100},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 1 * Second, Value: 2},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 2 * Second, Value: 3},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 3 * Second, Value: 4},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 4 * Second, Value: 5},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 5 * Second, Value: 6},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 6 * Second, Value: 7},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 7 * Second, Value: 8},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 8 * Second, Value: 9},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 9 * Second, Value: 1000},
+				}},
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 0 * Second, Value: 1},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 1 * Second, Value: 2},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 2 * Second, Value: 9},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{5.5}},
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{float64(4)}},
+			},
+		},
+		{
+			name: "TrimmedMean_Integer",
+			q:    `SELECT trimmed_mean(value, 20) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z' GROUP BY time(10s), host fill(none)`,
+			typ:  influxql.Integer,
+			itrs: []query.Iterator{
+				&IntegerIterator{Points: []query.IntegerPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 0 * Second, Value: -100},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 1 * Second, Value: 2},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 2 * Second, Value: 3},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 3 * Second, Value: 4},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 4 * Second, Value: 5},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 5 * Second, Value: 6},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 6 * Second, Value: 7},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 7 * Second, Value: 8},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 8 * Second, Value: 9},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 9 * Second, Value: 1000},
+				}},
+				&IntegerIterator{Points: []query.IntegerPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 0 * Second, Value: 1},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 1 * Second, Value: 2},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 2 * Second, Value: 9},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{5.5}},
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{float64(4)}},
+			},
+		},
+		{
+			name: "TrimmedMean_String",
+			q:    `SELECT trimmed_mean(value, 20) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z' GROUP BY time(10s), host fill(none)`,
+			typ:  influxql.String,
+			itrs: []query.Iterator{&StringIterator{}},
+			err:  `unsupported trimmed_mean iterator type: *query_test.StringIterator`,
+		},
+		{
+			name: "Precision_Float",
+			q:    `SELECT precision(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z' GROUP BY host`,
+			typ:  influxql.Float,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 1 * Second, Value: 1},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 2 * Second, Value: 2},
+				}},
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 1 * Second, Value: 1},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 2*Second + 5000, Value: 2},
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 3*Second + 7000000, Value: 3},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{"s"}},
+				{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{"us"}},
+			},
+		},
 		{
 			name: "Mode_Float",
 			q:    `SELECT mode(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-02T00:00:00Z' GROUP BY time(10s), host fill(none)`,
@@ -2509,6 +2676,147 @@ func TestSelect(t *testing.T) {
 				{Time: 11 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(3)}},
 			},
 		},
+		{
+			name: "LastValue_Float",
+			q:    `SELECT last_value(value) FROM cpu WHERE time >= '1970-01-01T00:00:20Z' AND time < '1970-01-01T00:01:00Z' GROUP BY time(10s), host`,
+			typ:  influxql.Float,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 5 * Second, Value: 1},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 20 * Second, Value: 2},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 35 * Second, Value: 3},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 36 * Second, Value: 4},
+				}},
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 42 * Second, Value: 7},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 20 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{float64(2)}},
+				{Time: 30 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{float64(2)}},
+				{Time: 40 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{float64(4)}},
+				{Time: 50 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{float64(4)}},
+				{Time: 20 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{nil}},
+				{Time: 30 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{nil}},
+				{Time: 40 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{nil}},
+				{Time: 50 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{float64(7)}},
+			},
+		},
+		{
+			name: "LastValue_String",
+			q:    `SELECT last_value(value) FROM cpu WHERE time >= '1970-01-01T00:00:10Z' AND time < '1970-01-01T00:00:40Z' GROUP BY time(10s) fill(none)`,
+			typ:  influxql.String,
+			itrs: []query.Iterator{
+				&StringIterator{Points: []query.StringPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 0 * Second, Value: "on"},
+					{Name: "cpu", Tags: ParseTags("region=west,host=A"), Time: 15 * Second, Value: "off"},
+				}},
+				&StringIterator{Points: []query.StringPoint{
+					{Name: "cpu", Tags: ParseTags("region=west,host=B"), Time: 25 * Second, Value: "on"},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{"on"}},
+				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{"off"}},
+				{Time: 30 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{"on"}},
+			},
+		},
+		{
+			name: "Rate_Float",
+			q:    `SELECT rate(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:30Z' GROUP BY time(10s)`,
+			typ:  influxql.Float,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Time: 0 * Second, Value: 10},
+					{Name: "cpu", Time: 5 * Second, Value: 30},
+					{Name: "cpu", Time: 10 * Second, Value: 40},
+					{Name: "cpu", Time: 12 * Second, Value: 5},
+					{Name: "cpu", Time: 18 * Second, Value: 15},
+					{Name: "cpu", Time: 25 * Second, Value: 20},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(2)}},
+				{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(2.5)}},
+				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(0.5)}},
+			},
+		},
+		{
+			name: "Rate_OnePointPerInterval",
+			q:    `SELECT rate(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:40Z' GROUP BY time(10s)`,
+			typ:  influxql.Float,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Time: 5 * Second, Value: 10},
+					{Name: "cpu", Time: 15 * Second, Value: 30},
+					{Name: "cpu", Time: 25 * Second, Value: 35},
+					{Name: "cpu", Time: 35 * Second, Value: 4},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(2)}},
+				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(0.5)}},
+				{Time: 30 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(0.4)}},
+			},
+		},
+		{
+			name: "Rate_Integer",
+			q:    `SELECT rate(value, 20s) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:20Z' GROUP BY time(10s)`,
+			typ:  influxql.Integer,
+			itrs: []query.Iterator{
+				&IntegerIterator{Points: []query.IntegerPoint{
+					{Name: "cpu", Time: 0 * Second, Value: 10},
+					{Name: "cpu", Time: 5 * Second, Value: 12},
+					{Name: "cpu", Time: 10 * Second, Value: 3},
+					{Name: "cpu", Time: 15 * Second, Value: 1},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(0.1)}},
+				{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(0.3)}},
+			},
+		},
+		{
+			name: "CountRate_Float",
+			q:    `SELECT count_rate(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:30Z' GROUP BY time(10s)`,
+			typ:  influxql.Float,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Time: 0 * Second, Value: 10},
+					{Name: "cpu", Time: 5 * Second, Value: 30},
+					{Name: "cpu", Time: 10 * Second, Value: 40},
+					{Name: "cpu", Time: 12 * Second, Value: 5},
+					{Name: "cpu", Time: 18 * Second, Value: 15},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(0.2)}},
+				{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(0.3)}},
+				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(0)}},
+			},
+		},
+		{
+			name: "Resets_Float",
+			q:    `SELECT resets(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:30Z' GROUP BY time(10s)`,
+			typ:  influxql.Float,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Time: 0 * Second, Value: 10},
+					{Name: "cpu", Time: 2 * Second, Value: 2},
+					{Name: "cpu", Time: 4 * Second, Value: 5},
+					{Name: "cpu", Time: 6 * Second, Value: 1},
+					{Name: "cpu", Time: 10 * Second, Value: 3},
+					{Name: "cpu", Time: 15 * Second, Value: 4},
+					{Name: "cpu", Time: 20 * Second, Value: 7},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(2)}},
+				{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(0)}},
+				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{int64(0)}},
+			},
+		},
 		{
 			name: "Integral_Float",
 			q:    `SELECT integral(value) FROM cpu`,
@@ -2556,6 +2864,7 @@ func TestSelect(t *testing.T) {
 			rows: []query.Row{
 				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(100)}},
 				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(-50)}},
+				{Time: 40 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
 			},
 		},
 		{
@@ -2573,6 +2882,26 @@ func TestSelect(t *testing.T) {
 			rows: []query.Row{
 				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(112.5)}},
 				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(-12.5)}},
+				{Time: 40 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+			},
+		},
+		{
+			name: "Integral_Integer_InterpolateGroupByTime",
+			q:    `SELECT integral(value) FROM cpu WHERE time > 0s AND time < 60s GROUP BY time(20s)`,
+			typ:  influxql.Integer,
+			itrs: []query.Iterator{
+				&IntegerIterator{Points: []query.IntegerPoint{
+					{Name: "cpu", Time: 10 * Second, Value: 20},
+					{Name: "cpu", Time: 15 * Second, Value: 10},
+					{Name: "cpu", Time: 25 * Second, Value: 0},
+					{Name: "cpu", Time: 30 * Second, Value: -10},
+					{Name: "cpu", Time: 40 * Second, Value: 10},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(112.5)}},
+				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(-12.5)}},
+				{Time: 40 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(0)}},
 			},
 		},
 		{
@@ -2692,6 +3021,63 @@ func TestSelect(t *testing.T) {
 				{Time: 12 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(11)}},
 			},
 		},
+		{
+			name: "ExponentialMovingAverage_Float",
+			q:    `SELECT exponential_moving_average(value, 3) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:20Z'`,
+			typ:  influxql.Float,
+			itrs: []query.Iterator{
+				&FloatIterator{Points: []query.FloatPoint{
+					{Name: "cpu", Time: 0 * Second, Value: 0},
+					{Name: "cpu", Time: 4 * Second, Value: 3},
+					{Name: "cpu", Time: 8 * Second, Value: 10},
+					{Name: "cpu", Time: 12 * Second, Value: 20},
+					{Name: "cpu", Time: 16 * Second, Value: 1},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 8 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(6)}},
+				{Time: 12 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(13)}},
+				{Time: 16 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(7)}},
+			},
+		},
+		{
+			name: "ExponentialMovingAverage_Integer_SimpleWarmup",
+			q:    `SELECT exponential_moving_average(value, 3, -1, 'simple') FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:20Z'`,
+			typ:  influxql.Integer,
+			itrs: []query.Iterator{
+				&IntegerIterator{Points: []query.IntegerPoint{
+					{Name: "cpu", Time: 0 * Second, Value: 10},
+					{Name: "cpu", Time: 4 * Second, Value: 20},
+					{Name: "cpu", Time: 8 * Second, Value: 30},
+					{Name: "cpu", Time: 12 * Second, Value: 40},
+					{Name: "cpu", Time: 16 * Second, Value: 10},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 8 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(20)}},
+				{Time: 12 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(30)}},
+				{Time: 16 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(20)}},
+			},
+		},
+		{
+			name: "DoubleExponentialMovingAverage_Integer_SimpleWarmup",
+			q:    `SELECT double_exponential_moving_average(value, 2, -1, 'simple') FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:20Z'`,
+			typ:  influxql.Integer,
+			itrs: []query.Iterator{
+				&IntegerIterator{Points: []query.IntegerPoint{
+					{Name: "cpu", Time: 0 * Second, Value: 10},
+					{Name: "cpu", Time: 4 * Second, Value: 20},
+					{Name: "cpu", Time: 8 * Second, Value: 30},
+					{Name: "cpu", Time: 12 * Second, Value: 40},
+					{Name: "cpu", Time: 16 * Second, Value: 50},
+				}},
+			},
+			rows: []query.Row{
+				{Time: 8 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(30)}},
+				{Time: 12 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(40)}},
+				{Time: 16 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(50)}},
+			},
+		},
 		{
 			name: "CumulativeSum_Float",
 			q:    `SELECT cumulative_sum(value) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:16Z'`,
@@ -3028,6 +3414,270 @@ func TestSelect_Raw(t *testing.T) {
 	}
 }
 
+// Ensure only the buckets within the limit are read for a grouped aggregate
+// ordered by time descending.
+func TestSelect_DescendingLimit(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		limit      int
+		points     []query.FloatPoint
+		startTimes []int64
+		rows       []query.Row
+	}{
+		{
+			name:  "Limited",
+			limit: 3,
+			points: []query.FloatPoint{
+				{Name: "cpu", Time: 0 * Second, Value: 1},
+				{Name: "cpu", Time: 50 * Second, Value: 2},
+				{Name: "cpu", Time: 70 * Second, Value: 3},
+				{Name: "cpu", Time: 90 * Second, Value: 4},
+			},
+			startTimes: []int64{70 * Second},
+			rows: []query.Row{
+				{Time: 90 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(4)}},
+				{Time: 80 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 70 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(3)}},
+			},
+		},
+		{
+			name:  "NoPointsWithinLimit",
+			limit: 3,
+			points: []query.FloatPoint{
+				{Name: "cpu", Time: 0 * Second, Value: 1},
+				{Name: "cpu", Time: 50 * Second, Value: 2},
+			},
+			startTimes: []int64{70 * Second, 0},
+			rows: []query.Row{
+				{Time: 90 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 80 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 70 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+			},
+		},
+		{
+			name:  "LimitBeyondTimeRange",
+			limit: 1000000000,
+			points: []query.FloatPoint{
+				{Name: "cpu", Time: 20 * Second, Value: 1},
+			},
+			startTimes: []int64{0},
+			rows: []query.Row{
+				{Time: 90 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 80 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 70 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 60 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 50 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 40 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 30 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 20 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(1)}},
+				{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+				{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{nil}},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var startTimes []int64
+			shardMapper := ShardMapper{
+				MapShardsFn: func(_ context.Context, sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+					return &ShardGroup{
+						Fields: map[string]influxql.DataType{
+							"f": influxql.Float,
+						},
+						CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+							startTimes = append(startTimes, opt.StartTime)
+
+							var points []query.FloatPoint
+							for i := len(tt.points) - 1; i >= 0; i-- {
+								if p := tt.points[i]; p.Time >= opt.StartTime && p.Time <= opt.EndTime {
+									points = append(points, p)
+								}
+							}
+							return &FloatIterator{Points: points}, nil
+						},
+					}
+				},
+			}
+
+			stmt := MustParseSelectStatement(fmt.Sprintf(`SELECT mean(f) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:01:40Z' GROUP BY time(10s) ORDER BY time DESC LIMIT %d`, tt.limit))
+			stmt.OmitTime = true
+			cur, err := query.Select(context.Background(), stmt, &shardMapper, query.SelectOptions{})
+			if err != nil {
+				t.Fatalf("parse error: %s", err)
+			} else if a, err := ReadCursor(cur); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if diff := cmp.Diff(tt.rows, a); diff != "" {
+				t.Errorf("unexpected points:\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.startTimes, startTimes); diff != "" {
+				t.Errorf("unexpected start times:\n%s", diff)
+			}
+		})
+	}
+}
+
+// Ensure the time range relative to now() is resolved before it is reported.
+func TestSelect_EchoRange(t *testing.T) {
+	shardMapper := ShardMapper{
+		MapShardsFn: func(_ context.Context, sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+			return &ShardGroup{
+				Fields: map[string]influxql.DataType{
+					"value": influxql.Float,
+				},
+				CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					return &FloatIterator{}, nil
+				},
+			}
+		},
+	}
+
+	for _, tt := range []struct {
+		q    string
+		text string
+	}{
+		{
+			q:    `SELECT value FROM cpu WHERE time >= now() - 1h`,
+			text: `time range: [2000-01-01T11:00:00Z, 2262-04-11T23:47:16.854775807Z)`,
+		},
+		{
+			q:    `SELECT value FROM cpu WHERE time >= now() - 1h AND time < now()`,
+			text: `time range: [2000-01-01T11:00:00Z, 2000-01-01T12:00:00Z)`,
+		},
+		{
+			q:    `SELECT mean(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+			text: `time range: [2000-01-01T11:00:00Z, 2000-01-01T12:00:00.000000001Z)`,
+		},
+	} {
+		t.Run(tt.q, func(t *testing.T) {
+			c, err := query.Compile(MustParseSelectStatement(tt.q), query.CompileOptions{
+				Now: mustParseTime("2000-01-01T12:00:00Z"),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var messages []*query.Message
+			ctx := query.NewContextWithMessages(context.Background(), &messages)
+			p, err := c.Prepare(ctx, &shardMapper, query.SelectOptions{EchoRange: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+
+			if len(messages) != 1 {
+				t.Fatalf("unexpected number of messages: %d", len(messages))
+			} else if have, want := messages[0].Level, query.InfoLevel; have != want {
+				t.Errorf("unexpected level: have=%s want=%s", have, want)
+			} else if have, want := messages[0].Text, tt.text; have != want {
+				t.Errorf("unexpected text: have=%s want=%s", have, want)
+			}
+		})
+	}
+}
+
+// Ensure distinct() and a count() of it are read with a single iterator.
+func TestSelect_DistinctCount(t *testing.T) {
+	var created int
+	shardMapper := ShardMapper{
+		MapShardsFn: func(_ context.Context, sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+			return &ShardGroup{
+				Fields: map[string]influxql.DataType{
+					"value": influxql.Float,
+				},
+				CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					created++
+					return &FloatIterator{Points: []query.FloatPoint{
+						{Name: "cpu", Time: 0 * Second, Value: 2},
+						{Name: "cpu", Time: 1 * Second, Value: 1},
+						{Name: "cpu", Time: 2 * Second, Value: 2},
+						{Name: "cpu", Time: 10 * Second, Value: 5},
+					}}, nil
+				},
+			}
+		},
+	}
+
+	stmt := MustParseSelectStatement(`SELECT distinct(value), count(distinct(value)) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:30Z' GROUP BY time(10s) fill(none)`)
+	stmt.OmitTime = true
+	cur, err := query.Select(context.Background(), stmt, &shardMapper, query.SelectOptions{})
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	} else if a, err := ReadCursor(cur); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if diff := cmp.Diff([]query.Row{
+		{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(2), int64(2)}},
+		{Time: 0 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(1), int64(2)}},
+		{Time: 10 * Second, Series: query.Series{Name: "cpu"}, Values: []interface{}{float64(5), int64(1)}},
+	}, a); diff != "" {
+		t.Errorf("unexpected points:\n%s", diff)
+	}
+
+	if created != 1 {
+		t.Errorf("unexpected number of iterators: %d", created)
+	}
+}
+
+// Ensure percent_of_total() divides each group by the total across groups.
+func TestSelect_PercentOfTotal(t *testing.T) {
+	shardMapper := ShardMapper{
+		MapShardsFn: func(_ context.Context, sources influxql.Sources, _ influxql.TimeRange) query.ShardGroup {
+			return &ShardGroup{
+				Fields: map[string]influxql.DataType{
+					"value": influxql.Integer,
+				},
+				Dimensions: []string{"host"},
+				CreateIteratorFn: func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					itrs := []query.Iterator{
+						&IntegerIterator{Points: []query.IntegerPoint{
+							{Name: "cpu", Tags: ParseTags("host=A"), Time: 0 * Second, Value: 1},
+							{Name: "cpu", Tags: ParseTags("host=A"), Time: 1 * Second, Value: 2},
+						}},
+						&IntegerIterator{Points: []query.IntegerPoint{
+							{Name: "cpu", Tags: ParseTags("host=B"), Time: 2 * Second, Value: 3},
+						}},
+						&IntegerIterator{Points: []query.IntegerPoint{
+							{Name: "cpu", Tags: ParseTags("host=C"), Time: 3 * Second, Value: 4},
+						}},
+					}
+					for i, itr := range itrs {
+						itr, err := query.NewCallIterator(itr, opt)
+						if err != nil {
+							return nil, err
+						}
+						itrs[i] = itr
+					}
+					return query.Iterators(itrs).Merge(opt)
+				},
+			}
+		},
+	}
+
+	stmt := MustParseSelectStatement(`SELECT sum(value), percent_of_total(sum(value)) FROM cpu WHERE time >= '1970-01-01T00:00:00Z' AND time < '1970-01-01T00:00:10Z' GROUP BY host`)
+	stmt.OmitTime = true
+	cur, err := query.Select(context.Background(), stmt, &shardMapper, query.SelectOptions{})
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	}
+	a, err := ReadCursor(cur)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	} else if diff := cmp.Diff([]query.Row{
+		{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=A")}, Values: []interface{}{int64(3), float64(30)}},
+		{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=B")}, Values: []interface{}{int64(3), float64(30)}},
+		{Time: 0 * Second, Series: query.Series{Name: "cpu", Tags: ParseTags("host=C")}, Values: []interface{}{int64(4), float64(40)}},
+	}, a); diff != "" {
+		t.Errorf("unexpected points:\n%s", diff)
+	}
+
+	var total float64
+	for _, row := range a {
+		total += row.Values[1].(float64)
+	}
+	if total != 100 {
+		t.Errorf("unexpected total percentage: %v", total)
+	}
+}
+
 // Ensure a SELECT binary expr queries can be executed as floats.
 func TestSelect_BinaryExpr(t *testing.T) {
 	shardMapper := ShardMapper{
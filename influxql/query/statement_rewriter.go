@@ -162,23 +162,29 @@ func rewriteShowSeriesStatement(stmt *influxql.ShowSeriesStatement) (influxql.St
 		SortFields: stmt.SortFields,
 		OmitTime:   true,
 		StripName:  true,
-		Dedupe:     true,
 		IsRawQuery: true,
 	}
-	// Check if we can exclusively use the index.
+	// Check if we can exclusively use the index. The index returns every
+	// series key of a retention policy once, so the keys of a single source
+	// are streamed without deduplicating them and the memory used does not
+	// grow with the number of series returned.
 	if !influxql.HasTimeExpr(stmt.Condition) {
 		s.Fields = []*influxql.Field{{Expr: &influxql.VarRef{Val: "key"}}}
 		s.Sources = rewriteSources(stmt.Sources, "_series", stmt.Database)
 		s.Condition = rewriteSourcesCondition(s.Sources, s.Condition)
+		s.Sources = mergeSystemSources(s.Sources)
+		s.Dedupe = len(s.Sources) > 1
 		return s, nil
 	}
 
 	// The query is bounded by time then it will have to query TSM data rather
-	// than utilising the index via system iterators.
+	// than utilising the index via system iterators. A series may be read
+	// from more than one shard so the keys are deduplicated.
 	s.Fields = []*influxql.Field{
 		{Expr: &influxql.VarRef{Val: "_seriesKey"}, Alias: "key"},
 	}
 	s.Sources = rewriteSources2(stmt.Sources, stmt.Database)
+	s.Dedupe = true
 	return s, nil
 }
 
@@ -277,6 +283,14 @@ func rewriteShowTagValuesStatement(stmt *influxql.ShowTagValuesStatement) (influ
 }
 
 func rewriteShowTagValuesCardinalityStatement(stmt *influxql.ShowTagValuesCardinalityStatement) (influxql.Statement, error) {
+	// Counting the values of each tag key is answered directly from the
+	// index, so the statement is kept with its condition rewritten.
+	if groupByKey, err := isGroupedByTagKey(stmt.Dimensions); err != nil {
+		return nil, err
+	} else if groupByKey {
+		return rewriteShowTagValuesCardinalityByKeyStatement(stmt)
+	}
+
 	// Use all measurements, if zero.
 	if len(stmt.Sources) == 0 {
 		stmt.Sources = influxql.Sources{
@@ -347,6 +361,43 @@ func rewriteShowTagValuesCardinalityStatement(stmt *influxql.ShowTagValuesCardin
 	}, nil
 }
 
+// isGroupedByTagKey returns true if the dimensions group by the tag key.
+// Grouping by the tag key cannot be combined with other dimensions.
+func isGroupedByTagKey(dimensions influxql.Dimensions) (bool, error) {
+	for _, d := range dimensions {
+		if ref, ok := d.Expr.(*influxql.VarRef); ok && ref.Val == "key" {
+			if len(dimensions) > 1 {
+				return false, errors.New("GROUP BY key cannot be combined with other dimensions")
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func rewriteShowTagValuesCardinalityByKeyStatement(stmt *influxql.ShowTagValuesCardinalityStatement) (influxql.Statement, error) {
+	show, err := rewriteShowTagValuesStatement(&influxql.ShowTagValuesStatement{
+		Sources:    stmt.Sources,
+		Op:         stmt.Op,
+		TagKeyExpr: stmt.TagKeyExpr,
+		Condition:  stmt.Condition,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &influxql.ShowTagValuesCardinalityStatement{
+		Database:   stmt.Database,
+		Exact:      stmt.Exact,
+		Op:         stmt.Op,
+		TagKeyExpr: stmt.TagKeyExpr,
+		Condition:  show.(*influxql.ShowTagValuesStatement).Condition,
+		Dimensions: stmt.Dimensions,
+		Limit:      stmt.Limit,
+		Offset:     stmt.Offset,
+	}, nil
+}
+
 func rewriteShowTagKeysStatement(stmt *influxql.ShowTagKeysStatement) (influxql.Statement, error) {
 	return &influxql.ShowTagKeysStatement{
 		Database:   stmt.Database,
@@ -425,6 +476,33 @@ func rewriteSources(sources influxql.Sources, systemIterator, defaultDatabase st
 	return newSources
 }
 
+// mergeSystemSources returns one source for each database and retention
+// policy in sources. A system iterator reads every measurement matched by the
+// condition, so a source for each measurement would read the same keys again.
+func mergeSystemSources(sources influxql.Sources) influxql.Sources {
+	newSources := make(influxql.Sources, 0, len(sources))
+	for _, src := range sources {
+		mm := src.(*influxql.Measurement)
+
+		var found bool
+		for _, other := range newSources {
+			other := other.(*influxql.Measurement)
+			if other.Database == mm.Database && other.RetentionPolicy == mm.RetentionPolicy {
+				found = true
+				break
+			}
+		}
+		if !found {
+			newSources = append(newSources, &influxql.Measurement{
+				Database:        mm.Database,
+				RetentionPolicy: mm.RetentionPolicy,
+				SystemIterator:  mm.SystemIterator,
+			})
+		}
+	}
+	return newSources
+}
+
 // rewriteSourcesCondition rewrites sources into `name` expressions.
 // Merges with cond and returns a new condition.
 func rewriteSourcesCondition(sources influxql.Sources, cond influxql.Expr) influxql.Expr {
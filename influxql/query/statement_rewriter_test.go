@@ -296,6 +296,10 @@ func TestRewriteStatement(t *testing.T) {
 			stmt: `SHOW TAG VALUES WITH KEY !~ /re.*/ OFFSET 2`,
 			s:    `SHOW TAG VALUES WITH KEY !~ /re.*/ WHERE _tagKey !~ /re.*/ OFFSET 2`,
 		},
+		{
+			stmt: `SHOW TAG VALUES CARDINALITY FROM cpu WITH KEY IN ("region", "host") GROUP BY "key"`,
+			s:    `SHOW TAG VALUES CARDINALITY WITH KEY IN (region, host) WHERE (_name = 'cpu') AND (_tagKey = 'region' OR _tagKey = 'host') GROUP BY "key"`,
+		},
 		{
 			stmt: `SELECT value FROM cpu`,
 			s:    `SELECT value FROM cpu`,
@@ -318,3 +322,30 @@ func TestRewriteStatement(t *testing.T) {
 		})
 	}
 }
+
+func TestRewriteStatement_ShowSeriesDedupe(t *testing.T) {
+	for _, tt := range []struct {
+		stmt   string
+		dedupe bool
+	}{
+		{stmt: `SHOW SERIES`},
+		{stmt: `SHOW SERIES FROM cpu, mem`},
+		{stmt: `SHOW SERIES FROM /c.*/ WHERE region = 'uswest'`},
+		{stmt: `SHOW SERIES FROM mydb.myrp1.cpu, mydb.myrp2.cpu`, dedupe: true},
+		{stmt: `SHOW SERIES WHERE time > 0`, dedupe: true},
+	} {
+		t.Run(tt.stmt, func(t *testing.T) {
+			stmt, err := influxql.ParseStatement(tt.stmt)
+			if err != nil {
+				t.Fatalf("error parsing statement: %s", err)
+			}
+			stmt, err = query.RewriteStatement(stmt)
+			if err != nil {
+				t.Fatalf("error rewriting statement: %s", err)
+			}
+			if got := stmt.(*influxql.SelectStatement).Dedupe; got != tt.dedupe {
+				t.Errorf("unexpected dedupe: got %v exp %v", got, tt.dedupe)
+			}
+		})
+	}
+}
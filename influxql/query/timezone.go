@@ -0,0 +1,139 @@
+package query
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// errInvalidTimezone is returned when compiling a statement whose TZ()
+// clause has a malformed fixed offset.
+var errInvalidTimezone = errors.New("invalid timezone")
+
+// errUnmatchedTimezones is returned when the fixed offsets of the TZ()
+// clauses of a query cannot be matched to the statements they belong to.
+var errUnmatchedTimezones = errors.New("unable to match the TZ() clauses to the statements of the query")
+
+// rewriteFixedZones replaces the fixed offsets of TZ() clauses, such as
+// TZ('+05:30'), with UTC so the query can be parsed by influxql, which only
+// accepts named zones. It returns the argument of every TZ() clause in the
+// order they appear, with an empty string for the named zones that were
+// left untouched.
+func rewriteFixedZones(q string) (*queryRewriter, []string) {
+	tokens := scanQueryTokens(q)
+
+	r := &queryRewriter{q: q}
+	var zones []string
+	for i := 0; i+3 < len(tokens); i++ {
+		if !tokens[i].isKeyword(q, "tz") || !tokens[i+1].is('(') || tokens[i+2].kind != queryString || !tokens[i+3].is(')') {
+			continue
+		}
+
+		arg := tokens[i+2]
+		name := q[arg.start+1 : arg.end-1]
+		if !isFixedZone(name) {
+			zones = append(zones, "")
+			continue
+		}
+		zones = append(zones, name)
+		r.replace(arg.start, arg.end, "'UTC'")
+	}
+	return r, zones
+}
+
+// setFixedZones sets the location of the statements whose TZ() clause was
+// rewritten by rewriteFixedZones. The TZ() clause of a statement follows the
+// text of its subqueries, so the statements are visited with subqueries
+// first to match the order of the zones.
+func setFixedZones(q *influxql.Query, zones []string) error {
+	var stmts []*influxql.SelectStatement
+	var walk func(stmt *influxql.SelectStatement)
+	walk = func(stmt *influxql.SelectStatement) {
+		for _, source := range stmt.Sources {
+			if s, ok := source.(*influxql.SubQuery); ok {
+				walk(s.Statement)
+			}
+		}
+		if stmt.Location != nil {
+			stmts = append(stmts, stmt)
+		}
+	}
+	for _, stmt := range q.Statements {
+		if stmt, ok := stmt.(*influxql.SelectStatement); ok {
+			walk(stmt)
+		}
+	}
+
+	if len(stmts) != len(zones) {
+		// The query only needs the zones if one of them was rewritten.
+		for _, name := range zones {
+			if name != "" {
+				return errUnmatchedTimezones
+			}
+		}
+		return nil
+	}
+	for i, name := range zones {
+		if name == "" {
+			continue
+		}
+		// A malformed offset is kept as the name of the zone so the
+		// statement reports it when it is compiled.
+		loc, err := parseFixedZone(name)
+		if err != nil {
+			loc = time.FixedZone(name, 0)
+		}
+		stmts[i].Location = loc
+	}
+	return nil
+}
+
+// LoadLocation returns the location of a time zone given either by its name
+// in the tz database or as a fixed offset from UTC such as +05:30.
+func LoadLocation(name string) (*time.Location, error) {
+	if isFixedZone(name) {
+		return parseFixedZone(name)
+	}
+	return time.LoadLocation(name)
+}
+
+// isFixedZone reports whether the zone name is written as a fixed offset
+// from UTC. Named zones never start with a sign.
+func isFixedZone(name string) bool {
+	return strings.HasPrefix(name, "+") || strings.HasPrefix(name, "-")
+}
+
+// parseFixedZone returns a location for a fixed offset from UTC written as
+// +hh:mm or -hh:mm. The location is named after the offset.
+func parseFixedZone(name string) (*time.Location, error) {
+	if len(name) != 6 || !isFixedZone(name) || name[3] != ':' {
+		return nil, errInvalidTimezone
+	}
+	hours, err := strconv.ParseUint(name[1:3], 10, 8)
+	if err != nil || hours > 14 {
+		return nil, errInvalidTimezone
+	}
+	minutes, err := strconv.ParseUint(name[4:6], 10, 8)
+	if err != nil || minutes > 59 {
+		return nil, errInvalidTimezone
+	}
+
+	offset := int(hours)*3600 + int(minutes)*60
+	if name[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone(name, offset), nil
+}
+
+// validateLocation returns an error if the location is a malformed fixed
+// offset kept by setFixedZones.
+func validateLocation(loc *time.Location) error {
+	if loc == nil || !isFixedZone(loc.String()) {
+		return nil
+	}
+	_, err := parseFixedZone(loc.String())
+	return err
+}
@@ -0,0 +1,80 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteFixedZones(t *testing.T) {
+	q := `SELECT max(count) FROM (SELECT count(value) FROM cpu GROUP BY time(1h) TZ('+05:30')) GROUP BY time(1d) tz('America/Los_Angeles'); SELECT value FROM cpu WHERE msg = 'TZ(\'-08:00\')' TZ('-08:00')`
+	r, zones := rewriteFixedZones(q)
+	text := r.String()
+	require.Equal(t, `SELECT max(count) FROM (SELECT count(value) FROM cpu GROUP BY time(1h) TZ('UTC')) GROUP BY time(1d) tz('America/Los_Angeles'); SELECT value FROM cpu WHERE msg = 'TZ(\'-08:00\')' TZ('UTC')`, text)
+	require.Equal(t, []string{"+05:30", "", "-08:00"}, zones)
+
+	query, err := influxql.ParseQuery(text)
+	require.NoError(t, err)
+	require.NoError(t, setFixedZones(query, zones))
+
+	outer := query.Statements[0].(*influxql.SelectStatement)
+	inner := outer.Sources[0].(*influxql.SubQuery).Statement
+	require.Equal(t, "America/Los_Angeles", outer.Location.String())
+	require.Equal(t, "+05:30", inner.Location.String())
+	require.Equal(t, "-08:00", query.Statements[1].(*influxql.SelectStatement).Location.String())
+}
+
+func TestSetFixedZones_Unmatched(t *testing.T) {
+	query, err := influxql.ParseQuery(`SELECT value FROM cpu; SELECT value FROM cpu TZ('UTC')`)
+	require.NoError(t, err)
+	require.NoError(t, setFixedZones(query, []string{""}))
+	require.NoError(t, setFixedZones(query, []string{"", ""}))
+	require.Equal(t, errUnmatchedTimezones, setFixedZones(query, []string{"+05:30", "-08:00"}))
+	require.Equal(t, "UTC", query.Statements[1].(*influxql.SelectStatement).Location.String())
+}
+
+func TestLoadLocation(t *testing.T) {
+	loc, err := LoadLocation("+05:30")
+	require.NoError(t, err)
+	require.Equal(t, "+05:30", loc.String())
+
+	loc, err = LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	require.Equal(t, "America/Los_Angeles", loc.String())
+
+	_, err = LoadLocation("+25:00")
+	require.Equal(t, errInvalidTimezone, err)
+}
+
+func TestParseFixedZone(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		offset int
+		err    bool
+	}{
+		{name: "+05:30", offset: 5*3600 + 30*60},
+		{name: "-08:00", offset: -8 * 3600},
+		{name: "+00:00", offset: 0},
+		{name: "+14:00", offset: 14 * 3600},
+		{name: "+15:00", err: true},
+		{name: "+05:60", err: true},
+		{name: "-0800", err: true},
+		{name: "+5:30", err: true},
+		{name: "+ab:cd", err: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := parseFixedZone(tt.name)
+			if tt.err {
+				require.Equal(t, errInvalidTimezone, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.name, loc.String())
+
+			_, offset := time.Unix(0, 0).In(loc).Zone()
+			require.Equal(t, tt.offset, offset)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package query
+
+import (
+	"errors"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// splitWindowCondition removes the predicates on the window time of a
+// query grouped by time, such as time % 2h = 1h, from the AND'ed terms of
+// cond. It returns the remaining condition and the window condition with
+// its durations converted to nanoseconds.
+func splitWindowCondition(cond influxql.Expr) (rest, window influxql.Expr, err error) {
+	switch expr := cond.(type) {
+	case *influxql.ParenExpr:
+		rest, window, err := splitWindowCondition(expr.Expr)
+		if err != nil || window == nil {
+			return cond, nil, err
+		}
+		return rest, window, nil
+	case *influxql.BinaryExpr:
+		switch expr.Op {
+		case influxql.AND:
+			lhs, lwindow, err := splitWindowCondition(expr.LHS)
+			if err != nil {
+				return nil, nil, err
+			}
+			rhs, rwindow, err := splitWindowCondition(expr.RHS)
+			if err != nil {
+				return nil, nil, err
+			}
+			return conjunction(lhs, rhs), conjunction(lwindow, rwindow), nil
+		case influxql.EQ, influxql.NEQ, influxql.LT, influxql.LTE, influxql.GT, influxql.GTE:
+			if !isWindowExpr(expr.LHS) && !isWindowExpr(expr.RHS) {
+				break
+			}
+			if len(influxql.ExprNames(expr)) > 0 {
+				return nil, nil, errors.New("window time conditions may only reference time")
+			}
+			window = influxql.RewriteExpr(influxql.CloneExpr(expr), func(expr influxql.Expr) influxql.Expr {
+				if d, ok := expr.(*influxql.DurationLiteral); ok {
+					return &influxql.IntegerLiteral{Val: int64(d.Val)}
+				}
+				return expr
+			})
+			return nil, window, nil
+		}
+	}
+	return cond, nil, nil
+}
+
+// isWindowExpr reports whether expr computes a value from the time, such
+// as time % 1d, instead of comparing the time itself.
+func isWindowExpr(expr influxql.Expr) bool {
+	for {
+		paren, ok := expr.(*influxql.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.Expr
+	}
+	binary, ok := expr.(*influxql.BinaryExpr)
+	if !ok {
+		return false
+	}
+	switch binary.Op {
+	case influxql.ADD, influxql.SUB, influxql.MUL, influxql.DIV, influxql.MOD:
+		return referencesTime(binary)
+	}
+	return false
+}
+
+// conjunction joins two conditions with AND. Either may be nil.
+func conjunction(lhs, rhs influxql.Expr) influxql.Expr {
+	if lhs == nil {
+		return rhs
+	} else if rhs == nil {
+		return lhs
+	}
+	return &influxql.BinaryExpr{Op: influxql.AND, LHS: lhs, RHS: rhs}
+}
+
+// windowCursor drops the rows of a query grouped by time whose window time
+// does not match the window condition. The time is evaluated as the number
+// of nanoseconds since the epoch in the time zone of the query. LIMIT and
+// OFFSET are applied to the rows of each series that match.
+type windowCursor struct {
+	Cursor
+	cond   influxql.Expr
+	loc    *time.Location
+	limit  int
+	offset int
+
+	series Series
+	n      int
+	m      map[string]interface{}
+}
+
+func newWindowCursor(cur Cursor, cond influxql.Expr, opt IteratorOptions, limit, offset int) *windowCursor {
+	return &windowCursor{
+		Cursor: cur,
+		cond:   cond,
+		loc:    opt.Location,
+		limit:  limit,
+		offset: offset,
+		m:      make(map[string]interface{}, 1),
+	}
+}
+
+func (cur *windowCursor) Scan(row *Row) bool {
+	for cur.Cursor.Scan(row) {
+		t := row.Time
+		if cur.loc != nil {
+			_, offset := time.Unix(0, t).In(cur.loc).Zone()
+			t += int64(offset) * int64(time.Second)
+		}
+		cur.m["time"] = t
+		if !influxql.EvalBool(cur.cond, cur.m) {
+			continue
+		}
+
+		if !row.Series.SameSeries(cur.series) {
+			cur.series, cur.n = row.Series, 0
+		}
+		cur.n++
+		if cur.n <= cur.offset || (cur.limit > 0 && cur.n > cur.offset+cur.limit) {
+			continue
+		}
+		return true
+	}
+	return false
+}
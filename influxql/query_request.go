@@ -30,6 +30,7 @@ const (
 	EncodingFormatTextCSV
 	EncodingFormatAppCSV
 	EncodingFormatMessagePack
+	EncodingFormatArrow
 )
 
 // Returns closed encoding format from the specified mime type.
@@ -42,6 +43,8 @@ func EncodingFormatFromMimeType(s string) EncodingFormat {
 		return EncodingFormatTextCSV
 	case "application/x-msgpack":
 		return EncodingFormatMessagePack
+	case "application/vnd.apache.arrow.stream":
+		return EncodingFormatArrow
 	default:
 		return EncodingFormatJSON
 	}
@@ -55,24 +58,48 @@ func (f EncodingFormat) ContentType() string {
 		return "text/csv"
 	case EncodingFormatMessagePack:
 		return "application/x-msgpack"
+	case EncodingFormatArrow:
+		return "application/vnd.apache.arrow.stream"
 	default:
 		return "application/json"
 	}
 }
 
 type QueryRequest struct {
-	Authorization  *influxdb.Authorization `json:"authorization,omitempty"`
-	OrganizationID platform.ID             `json:"organization_id"`
-	DB             string                  `json:"db"`
-	RP             string                  `json:"rp"`
-	Epoch          string                  `json:"epoch"`
-	EncodingFormat EncodingFormat          `json:"encoding_format"`
-	ContentType    string                  `json:"content_type"` // Content type is the desired response format.
-	Chunked        bool                    `json:"chunked"`      // Chunked indicates responses should be chunked using ChunkSize
-	ChunkSize      int                     `json:"chunk_size"`   // ChunkSize is the number of points to be encoded per batch. 0 indicates no chunking.
-	Query          string                  `json:"query"`        // Query contains the InfluxQL.
-	Params         map[string]interface{}  `json:"params,omitempty"`
-	Source         string                  `json:"source"` // Source represents the ultimate source of the request.
+	Authorization      *influxdb.Authorization `json:"authorization,omitempty"`
+	OrganizationID     platform.ID             `json:"organization_id"`
+	DB                 string                  `json:"db"`
+	RP                 string                  `json:"rp"`
+	Epoch              string                  `json:"epoch"`
+	TimeFormat         string                  `json:"time_format,omitempty"`
+	EncodingFormat     EncodingFormat          `json:"encoding_format"`
+	ContentType        string                  `json:"content_type"` // Content type is the desired response format.
+	Chunked            bool                    `json:"chunked"`      // Chunked indicates responses should be chunked using ChunkSize
+	ChunkSize          int                     `json:"chunk_size"`   // ChunkSize is the number of points to be encoded per batch. 0 indicates no chunking.
+	Query              string                  `json:"query"`        // Query contains the InfluxQL.
+	Params             map[string]interface{}  `json:"params,omitempty"`
+	CoerceNumeric      bool                    `json:"coerce_numeric,omitempty"`
+	TimeZone           string                  `json:"tz,omitempty"`
+	OrderValues        bool                    `json:"order_values,omitempty"`
+	ColumnsOnly        bool                    `json:"columns_only,omitempty"`
+	Interleave         bool                    `json:"interleave,omitempty"`
+	TreatAsNull        *float64                `json:"treat_as_null,omitempty"`
+	LocalTime          bool                    `json:"local_time,omitempty"`
+	Pivot              string                  `json:"pivot,omitempty"`
+	TagsAsJSON         bool                    `json:"tags_as_json,omitempty"`
+	UnionColumns       bool                    `json:"union_columns,omitempty"`
+	MaxRows            int                     `json:"max_rows,omitempty"`
+	EchoRange          bool                    `json:"echo_range,omitempty"`
+	OrderByCardinality bool                    `json:"order_by_cardinality,omitempty"`
+	HoltWintersBounds  bool                    `json:"holt_winters_bounds,omitempty"`
+	LimitSeriesFirst   bool                    `json:"limit_series_first,omitempty"`
+	Label              string                  `json:"label,omitempty"`
+	AutoRollup         bool                    `json:"auto_rollup,omitempty"`
+	DropEmptyTags      bool                    `json:"drop_empty_tags,omitempty"`
+	Estimate           bool                    `json:"estimate,omitempty"`
+	SeriesID           bool                    `json:"series_id,omitempty"`
+	BucketID           platform.ID             `json:"bucket_id,omitempty"`
+	Source             string                  `json:"source"` // Source represents the ultimate source of the request.
 }
 
 // The HTTP query requests represented the body expected by the QueryHandler
@@ -14,6 +14,7 @@ func TestEncodingFormatFromMimeType(t *testing.T) {
 		{s: "application/csv", exp: EncodingFormatAppCSV},
 		{s: "text/csv", exp: EncodingFormatTextCSV},
 		{s: "application/x-msgpack", exp: EncodingFormatMessagePack},
+		{s: "application/vnd.apache.arrow.stream", exp: EncodingFormatArrow},
 		{s: "application/json", exp: EncodingFormatJSON},
 		{s: "*/*", exp: EncodingFormatJSON},
 		{s: "", exp: EncodingFormatJSON},
@@ -58,6 +58,10 @@ func (q *Query) Execute(ctx context.Context, t *testing.T, db string, c *tests.C
 		params = append(params, [2]string{"epoch", epoch})
 	}
 
+	if timeFormat := q.params.Get("time_format"); len(timeFormat) > 0 {
+		params = append(params, [2]string{"time_format", timeFormat})
+	}
+
 	if parameters := q.params.Get("params"); len(parameters) > 0 {
 		params = append(params, [2]string{"params", parameters})
 	}
@@ -70,6 +74,90 @@ func (q *Query) Execute(ctx context.Context, t *testing.T, db string, c *tests.C
 		params = append(params, [2]string{"chunk_size", chunkSize})
 	}
 
+	if coerceNumeric := q.params.Get("coerce_numeric"); len(coerceNumeric) > 0 {
+		params = append(params, [2]string{"coerce_numeric", coerceNumeric})
+	}
+
+	if tz := q.params.Get("tz"); len(tz) > 0 {
+		params = append(params, [2]string{"tz", tz})
+	}
+
+	if orderValues := q.params.Get("order_values"); len(orderValues) > 0 {
+		params = append(params, [2]string{"order_values", orderValues})
+	}
+
+	if columnsOnly := q.params.Get("columns_only"); len(columnsOnly) > 0 {
+		params = append(params, [2]string{"columns_only", columnsOnly})
+	}
+
+	if interleave := q.params.Get("interleave"); len(interleave) > 0 {
+		params = append(params, [2]string{"interleave", interleave})
+	}
+
+	if treatAsNull := q.params.Get("treat_as_null"); len(treatAsNull) > 0 {
+		params = append(params, [2]string{"treat_as_null", treatAsNull})
+	}
+
+	if localTime := q.params.Get("local_time"); len(localTime) > 0 {
+		params = append(params, [2]string{"local_time", localTime})
+	}
+
+	if pivot := q.params.Get("pivot"); len(pivot) > 0 {
+		params = append(params, [2]string{"pivot", pivot})
+	}
+
+	if tagsAsJSON := q.params.Get("tags_as_json"); len(tagsAsJSON) > 0 {
+		params = append(params, [2]string{"tags_as_json", tagsAsJSON})
+	}
+
+	if unionColumns := q.params.Get("union_columns"); len(unionColumns) > 0 {
+		params = append(params, [2]string{"union_columns", unionColumns})
+	}
+
+	if maxRows := q.params.Get("max_rows"); len(maxRows) > 0 {
+		params = append(params, [2]string{"max_rows", maxRows})
+	}
+
+	if echoRange := q.params.Get("echo_range"); len(echoRange) > 0 {
+		params = append(params, [2]string{"echo_range", echoRange})
+	}
+
+	if byCardinality := q.params.Get("order_by_cardinality"); len(byCardinality) > 0 {
+		params = append(params, [2]string{"order_by_cardinality", byCardinality})
+	}
+
+	if hwBounds := q.params.Get("holt_winters_bounds"); len(hwBounds) > 0 {
+		params = append(params, [2]string{"holt_winters_bounds", hwBounds})
+	}
+
+	if limitFirst := q.params.Get("limit_series_first"); len(limitFirst) > 0 {
+		params = append(params, [2]string{"limit_series_first", limitFirst})
+	}
+
+	if label := q.params.Get("label"); len(label) > 0 {
+		params = append(params, [2]string{"label", label})
+	}
+
+	if autoRollup := q.params.Get("auto_rollup"); len(autoRollup) > 0 {
+		params = append(params, [2]string{"auto_rollup", autoRollup})
+	}
+
+	if dropEmptyTags := q.params.Get("drop_empty_tags"); len(dropEmptyTags) > 0 {
+		params = append(params, [2]string{"drop_empty_tags", dropEmptyTags})
+	}
+
+	if estimate := q.params.Get("estimate"); len(estimate) > 0 {
+		params = append(params, [2]string{"estimate", estimate})
+	}
+
+	if seriesID := q.params.Get("series_id"); len(seriesID) > 0 {
+		params = append(params, [2]string{"series_id", seriesID})
+	}
+
+	if bucket := q.params.Get("bucket"); len(bucket) > 0 {
+		params = append(params, [2]string{"bucket", bucket})
+	}
+
 	err = c.Client.Get("/query").
 		QueryParams(params...).
 		Header("Accept", "application/json").
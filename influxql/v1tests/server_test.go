@@ -102,10 +102,12 @@ func TestServer_Query_SelectRelativeTime(t *testing.T) {
 
 	now := now()
 	yesterday := yesterday()
+	lastWeek := now.Add(-8 * 24 * time.Hour)
 
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
 		&Write{data: fmt.Sprintf("cpu,host=server01 value=100 %s\ncpu,host=server01 value=200 %s", strconv.FormatInt(yesterday.UnixNano(), 10), strconv.FormatInt(now.UnixNano(), 10))},
+		&Write{data: fmt.Sprintf("weekly,host=server01 value=50 %s\nweekly,host=server01 value=100 %s", strconv.FormatInt(lastWeek.UnixNano(), 10), strconv.FormatInt(yesterday.UnixNano(), 10))},
 	}
 
 	test.addQueries([]*Query{
@@ -119,6 +121,16 @@ func TestServer_Query_SelectRelativeTime(t *testing.T) {
 			command: `SELECT * FROM db0.rp0.cpu where time >= now() - 1m GROUP BY *`,
 			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["%s",200]]}]}]}`, now.Format(time.RFC3339Nano)),
 		},
+		{
+			name:    "relative time queries with a week duration",
+			command: `SELECT * FROM db0.rp0.weekly where time >= now() - 1w`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"weekly","columns":["time","host","value"],"values":[["%s","server01",100]]}]}]}`, yesterday.Format(time.RFC3339Nano)),
+		},
+		{
+			name:    "relative time queries with multiple weeks",
+			command: `SELECT * FROM db0.rp0.weekly where time >= now() - 2w`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"weekly","columns":["time","host","value"],"values":[["%s","server01",50],["%s","server01",100]]}]}]}`, lastWeek.Format(time.RFC3339Nano), yesterday.Format(time.RFC3339Nano)),
+		},
 	}...)
 
 	ctx := context.Background()
@@ -150,6 +162,45 @@ func TestServer_Query_Aggregates_IntMax(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
+func TestServer_Query_Aggregates_PrecisionLoss(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`cpu value=1e16 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+			fmt.Sprintf(`cpu value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+			fmt.Sprintf(`cpu value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+			fmt.Sprintf(`cpu value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+			fmt.Sprintf(`cpu value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+			fmt.Sprintf(`cpu value=-1e16 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
+			fmt.Sprintf(`mem value=1000000004 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+			fmt.Sprintf(`mem value=1000000007 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+			fmt.Sprintf(`mem value=1000000013 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+			fmt.Sprintf(`mem value=1000000016 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+		}, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "mean of small values next to a large base",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MEAN(value) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",0.6666666666666666]]}]}]}`,
+		},
+		{
+			name:    "mean and stddev of small increments on a large base",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MEAN(value), STDDEV(value) FROM mem`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mem","columns":["time","mean","stddev"],"values":[["1970-01-01T00:00:00Z",1000000010,5.477225575051661]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
 func TestServer_Query_TimeZone(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
@@ -220,6 +271,234 @@ func TestServer_Query_TimeZone(t *testing.T) {
 			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-10-29T01:00:00-07:00",12],["2000-10-29T01:00:00-08:00",12]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
+		{
+			name:    "fixed offset - half hour - hourly",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-04-02T14:00:00+05:30' AND time < '2000-04-02T18:00:00+05:30' AND interval = 'hourly' GROUP BY time(1h) TZ('+05:30')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-04-02T14:00:00+05:30",6],["2000-04-02T15:00:00+05:30",12],["2000-04-02T16:00:00+05:30",12],["2000-04-02T17:00:00+05:30",6]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "fixed offset - half hour - daily",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-04-02T00:00:00+05:30' AND time < '2000-04-04T00:00:00+05:30' AND interval = 'daily' GROUP BY time(1d) TZ('+05:30')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-04-02T00:00:00+05:30",24],["2000-04-03T00:00:00+05:30",24]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "fixed offset - ignores dst - daily",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-04-02T00:00:00-08:00' AND time < '2000-04-04T00:00:00-08:00' AND interval = 'daily' GROUP BY time(1d) TZ('-08:00')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-04-02T00:00:00-08:00",24],["2000-04-03T00:00:00-08:00",24]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "fixed offset - subquery",
+			command: `SELECT max(count) FROM (SELECT count(value) FROM cpu WHERE time >= '2000-04-02T14:00:00+05:30' AND time < '2000-04-02T18:00:00+05:30' AND interval = 'hourly' GROUP BY time(1h) TZ('+05:30')) WHERE time >= '2000-04-02T14:00:00+05:30' AND time < '2000-04-02T18:00:00+05:30' GROUP BY time(2h) TZ('-08:00')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","max"],"values":[["2000-04-02T00:00:00-08:00",12],["2000-04-02T02:00:00-08:00",12],["2000-04-02T04:00:00-08:00",null]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "fixed offset - hours out of range",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-04-02T00:00:00Z' AND time < '2000-04-03T00:00:00Z' AND interval = 'daily' GROUP BY time(1d) TZ('+25:00')`,
+			exp:     `{"results":[{"statement_id":0,"error":"invalid timezone"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "fixed offset - missing colon",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-04-02T00:00:00Z' AND time < '2000-04-03T00:00:00Z' AND interval = 'daily' GROUP BY time(1d) TZ('-0800')`,
+			exp:     `{"results":[{"statement_id":0,"error":"invalid timezone"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "fixed offset - invalid in a subquery",
+			command: `SELECT max(count) FROM (SELECT count(value) FROM cpu WHERE time >= '2000-04-02T00:00:00Z' AND time < '2000-04-03T00:00:00Z' GROUP BY time(1h) TZ('+05:75'))`,
+			exp:     `{"results":[{"statement_id":0,"error":"invalid timezone"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "timezone parameter - dst start - daily",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-04-02T00:00:00-08:00' AND time < '2000-04-04T00:00:00-07:00' AND interval = 'daily' GROUP BY time(1d)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-04-02T00:00:00-08:00",23],["2000-04-03T00:00:00-07:00",24]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "tz": []string{"America/Los_Angeles"}},
+		},
+		{
+			name:    "timezone parameter - dst end - hourly",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-10-29T01:00:00-07:00' AND time < '2000-10-29T02:00:00-08:00' AND interval = 'hourly' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-10-29T01:00:00-07:00",12],["2000-10-29T01:00:00-08:00",12]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "tz": []string{"America/Los_Angeles"}},
+		},
+		{
+			name:    "timezone parameter - fixed offset - hourly",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-04-02T14:00:00+05:30' AND time < '2000-04-02T18:00:00+05:30' AND interval = 'hourly' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-04-02T14:00:00+05:30",6],["2000-04-02T15:00:00+05:30",12],["2000-04-02T16:00:00+05:30",12],["2000-04-02T17:00:00+05:30",6]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "tz": []string{"+05:30"}},
+		},
+		{
+			name:    "timezone clause takes precedence over the parameter",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-04-02T00:00:00-08:00' AND time < '2000-04-04T00:00:00-07:00' AND interval = 'daily' GROUP BY time(1d) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-04-02T00:00:00-08:00",23],["2000-04-03T00:00:00-07:00",24]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "tz": []string{"UTC"}},
+		},
+		{
+			name:    "local time column - dst start - daily",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-04-02T00:00:00-08:00' AND time < '2000-04-04T00:00:00-07:00' AND interval = 'daily' GROUP BY time(1d) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","_local_time","count"],"values":[["2000-04-02T08:00:00Z","2000-04-02T00:00:00-08:00",23],["2000-04-03T07:00:00Z","2000-04-03T00:00:00-07:00",24]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "local_time": []string{"true"}},
+		},
+		{
+			name:    "local time column with the timezone parameter",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-10-29T01:00:00-07:00' AND time < '2000-10-29T02:00:00-08:00' AND interval = 'hourly' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","_local_time","count"],"values":[["2000-10-29T08:00:00Z","2000-10-29T01:00:00-07:00",12],["2000-10-29T09:00:00Z","2000-10-29T01:00:00-08:00",12]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "tz": []string{"America/Los_Angeles"}, "local_time": []string{"true"}},
+		},
+		{
+			name:    "right label - hourly",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-06-02T08:00:00Z' AND time < '2000-06-02T10:00:00Z' AND interval = 'hourly' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-06-02T09:00:00Z",12],["2000-06-02T10:00:00Z",12]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "label": []string{"right"}},
+		},
+		{
+			name:    "right label - dst start - daily",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-04-02T00:00:00-08:00' AND time < '2000-04-04T00:00:00-07:00' AND interval = 'daily' GROUP BY time(1d) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-04-03T00:00:00-07:00",23],["2000-04-04T00:00:00-07:00",24]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "label": []string{"right"}},
+		},
+		{
+			name:    "left label is the default",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-06-02T08:00:00Z' AND time < '2000-06-02T10:00:00Z' AND interval = 'hourly' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-06-02T08:00:00Z",12],["2000-06-02T09:00:00Z",12]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "label": []string{"left"}},
+		},
+		{
+			name:    "time format parameter",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-06-02T08:00:00Z' AND time < '2000-06-02T10:00:00Z' AND interval = 'hourly' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-06-02 08:00:00",12],["2000-06-02 09:00:00",12]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "time_format": []string{"2006-01-02 15:04:05"}},
+		},
+		{
+			name:    "time format parameter with the timezone parameter",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-06-02T01:00:00-07:00' AND time < '2000-06-02T03:00:00-07:00' AND interval = 'hourly' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-06-02 01:00:00 -0700",12],["2000-06-02 02:00:00 -0700",12]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "tz": []string{"America/Los_Angeles"}, "time_format": []string{"2006-01-02 15:04:05 -0700"}},
+		},
+		{
+			name:    "local time column without a timezone",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-06-02T08:00:00Z' AND time < '2000-06-02T10:00:00Z' AND interval = 'hourly' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-06-02T08:00:00Z",12],["2000-06-02T09:00:00Z",12]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "local_time": []string{"true"}},
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure calendar month and year intervals start on the first of the month
+// in the time zone of the query.
+func TestServer_Query_CalendarInterval(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	var writes []string
+	// Write every day at noon for two years, including the leap year 2000.
+	for ts := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC); ts.Year() < 2002; ts = ts.AddDate(0, 0, 1) {
+		writes = append(writes, fmt.Sprintf(`cpu,interval=daily value=0 %d`, ts.UnixNano()))
+	}
+	// Write every hour of March and April when DST starts on April 2nd.
+	for ts := time.Date(2000, 3, 1, 0, 0, 0, 0, LosAngeles); ts.Before(time.Date(2000, 5, 1, 0, 0, 0, 0, LosAngeles)); ts = ts.Add(time.Hour) {
+		writes = append(writes, fmt.Sprintf(`cpu,interval=hourly value=0 %d`, ts.UnixNano()))
+	}
+	// Write a counter that increases by one every second of February.
+	for _, ts := range []time.Time{time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2001, 2, 1, 0, 0, 0, 0, time.UTC)} {
+		end := ts.AddDate(0, 1, 0)
+		writes = append(writes,
+			fmt.Sprintf(`requests total=0i %d`, ts.UnixNano()),
+			fmt.Sprintf(`requests total=%di %d`, int64(end.Sub(ts).Seconds()), ts.Add(14*24*time.Hour).UnixNano()),
+		)
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "months of a leap year",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-05-01T00:00:00Z' AND interval = 'daily' GROUP BY time(1mo)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",31],["2000-02-01T00:00:00Z",29],["2000-03-01T00:00:00Z",31],["2000-04-01T00:00:00Z",30]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "months of a common year",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2001-01-01T00:00:00Z' AND time < '2001-04-01T00:00:00Z' AND interval = 'daily' GROUP BY time(1mo)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2001-01-01T00:00:00Z",31],["2001-02-01T00:00:00Z",28],["2001-03-01T00:00:00Z",31]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "quarters",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2001-01-01T00:00:00Z' AND interval = 'daily' GROUP BY time(3mo)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",91],["2000-04-01T00:00:00Z",91],["2000-07-01T00:00:00Z",92],["2000-10-01T00:00:00Z",92]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "years",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2002-01-01T00:00:00Z' AND interval = 'daily' GROUP BY time(1y)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",366],["2001-01-01T00:00:00Z",365]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "months with a dst change",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-03-01T00:00:00-08:00' AND time < '2000-05-01T00:00:00-07:00' AND interval = 'hourly' GROUP BY time(1mo) TZ('America/Los_Angeles')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-03-01T00:00:00-08:00",744],["2000-04-01T00:00:00-08:00",719]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "fill empty months",
+			command: `SELECT count(value) FROM cpu WHERE time >= '1999-11-01T00:00:00Z' AND time < '2000-02-01T00:00:00Z' AND interval = 'daily' GROUP BY time(1mo) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["1999-11-01T00:00:00Z",0],["1999-12-01T00:00:00Z",0],["2000-01-01T00:00:00Z",31]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "fill empty months descending",
+			command: `SELECT count(value) FROM cpu WHERE time >= '1999-11-01T00:00:00Z' AND time < '2000-02-01T00:00:00Z' AND interval = 'daily' GROUP BY time(1mo) fill(0) ORDER BY time DESC`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",31],["1999-12-01T00:00:00Z",0],["1999-11-01T00:00:00Z",0]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "rate in february of a leap year",
+			command: `SELECT rate(total) FROM requests WHERE time >= '2000-02-01T00:00:00Z' AND time < '2000-03-01T00:00:00Z' GROUP BY time(1mo)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","rate"],"values":[["2000-02-01T00:00:00Z",1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "rate in february of a common year",
+			command: `SELECT rate(total) FROM requests WHERE time >= '2001-02-01T00:00:00Z' AND time < '2001-03-01T00:00:00Z' GROUP BY time(1mo)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","rate"],"values":[["2001-02-01T00:00:00Z",1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "count_rate is the same for months of every length",
+			command: `SELECT count_rate(value) * 86400 FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-04-01T00:00:00Z' AND interval = 'daily' GROUP BY time(1mo)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count_rate"],"values":[["2000-01-01T00:00:00Z",1],["2000-02-01T00:00:00Z",1],["2000-03-01T00:00:00Z",1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "difference reads the previous month",
+			command: `SELECT difference(count(value)) FROM cpu WHERE time >= '2000-03-01T00:00:00Z' AND time < '2000-05-01T00:00:00Z' AND interval = 'daily' GROUP BY time(1mo)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","difference"],"values":[["2000-03-01T00:00:00Z",2],["2000-04-01T00:00:00Z",-1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "derivative of a calendar interval without a unit",
+			command: `SELECT derivative(count(value)) FROM cpu WHERE time >= '2000-03-01T00:00:00Z' AND time < '2000-05-01T00:00:00Z' GROUP BY time(1mo)`,
+			exp:     `{"results":[{"statement_id":0,"error":"derivative of a calendar interval requires a unit"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "calendar interval in a subquery",
+			command: `SELECT max(count) FROM (SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2001-01-01T00:00:00Z' GROUP BY time(1mo))`,
+			exp:     `{"results":[{"statement_id":0,"error":"calendar intervals are not supported in subqueries"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
 	}...)
 
 	ctx := context.Background()
@@ -257,6 +536,78 @@ func TestServer_Query_IdenticalTagValues(t *testing.T) {
 			command: `SELECT value FROM db0.rp0.cpu`,
 			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:01:00Z",2],["2000-01-01T00:02:00Z",3]]}]}]}`,
 		},
+		{
+			name:    "measurements with identical tag values - GROUP BY tag with empty values",
+			command: `SELECT value FROM db0.rp0.cpu GROUP BY t1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"t1":""},"columns":["time","value"],"values":[["2000-01-01T00:01:00Z",2]]},{"name":"cpu","tags":{"t1":"val1"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"t1":"val2"},"columns":["time","value"],"values":[["2000-01-01T00:02:00Z",3]]}]}]}`,
+		},
+		{
+			name:    "measurements with identical tag values - GROUP BY tag, drop empty tags",
+			command: `SELECT value FROM db0.rp0.cpu GROUP BY t1`,
+			params:  url.Values{"drop_empty_tags": []string{"true"}},
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"t1":"val1"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"t1":"val2"},"columns":["time","value"],"values":[["2000-01-01T00:02:00Z",3]]}]}]}`,
+		},
+		{
+			name:    "measurements with identical tag values - GROUP BY tags, drop empty tags keeps partially empty series",
+			command: `SELECT value FROM db0.rp0.cpu GROUP BY t1,t2`,
+			params:  url.Values{"drop_empty_tags": []string{"true"}},
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"t1":"","t2":"val2"},"columns":["time","value"],"values":[["2000-01-01T00:01:00Z",2]]},{"name":"cpu","tags":{"t1":"val1","t2":""},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"t1":"val2","t2":""},"columns":["time","value"],"values":[["2000-01-01T00:02:00Z",3]]}]}]}`,
+		},
+		{
+			name:    "measurements with identical tag values - aggregate GROUP BY tag, drop empty tags",
+			command: `SELECT sum(value) FROM db0.rp0.cpu GROUP BY t2`,
+			params:  url.Values{"drop_empty_tags": []string{"true"}},
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"t2":"val2"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
+		},
+		{
+			name:    "measurements with identical tag values - no GROUP BY, drop empty tags",
+			command: `SELECT value FROM db0.rp0.cpu`,
+			params:  url.Values{"drop_empty_tags": []string{"true"}},
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:01:00Z",2],["2000-01-01T00:02:00Z",3]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server correctly supports tag values containing escaped special characters.
+func TestServer_Query_EscapedTagValues(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu,region=us\,east value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,region=us\ west value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,region=us\=central value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:02:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,region=us\,east value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:03:00Z").UnixNano()),
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "tag values with special characters - GROUP BY tag",
+			command: `SELECT value FROM db0.rp0.cpu GROUP BY region`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"region":"us west"},"columns":["time","value"],"values":[["2000-01-01T00:01:00Z",2]]},{"name":"cpu","tags":{"region":"us,east"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:03:00Z",4]]},{"name":"cpu","tags":{"region":"us=central"},"columns":["time","value"],"values":[["2000-01-01T00:02:00Z",3]]}]}]}`,
+		},
+		{
+			name:    "tag values with special characters - aggregate GROUP BY tag",
+			command: `SELECT sum(value) FROM db0.rp0.cpu GROUP BY region`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"region":"us west"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",2]]},{"name":"cpu","tags":{"region":"us,east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",5]]},{"name":"cpu","tags":{"region":"us=central"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",3]]}]}]}`,
+		},
+		{
+			name:    "tag values with special characters - WHERE tag",
+			command: `SELECT value FROM db0.rp0.cpu WHERE region = 'us,east' GROUP BY region`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"region":"us,east"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:03:00Z",4]]}]}]}`,
+		},
+		{
+			name:    "tag values with special characters - selected as a column",
+			command: `SELECT region, value FROM db0.rp0.cpu WHERE region = 'us west'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","region","value"],"values":[["2000-01-01T00:01:00Z","us west",2]]}]}]}`,
+		},
 	}...)
 
 	ctx := context.Background()
@@ -325,6 +676,8 @@ func TestServer_Query_Math(t *testing.T) {
 	writes := []string{
 		"float value=42 " + strconv.FormatInt(now.UnixNano(), 10),
 		"integer value=42i " + strconv.FormatInt(now.UnixNano(), 10),
+		"divide value=10i " + strconv.FormatInt(now.UnixNano(), 10),
+		"divide value=5i " + strconv.FormatInt(now.Add(time.Second).UnixNano(), 10),
 	}
 
 	test := NewTest("db", "rp")
@@ -388,36 +741,101 @@ func TestServer_Query_Math(t *testing.T) {
 			command: `SELECT (value * value) from db.rp.integer`,
 			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","value_value"],"values":[["%s",1764]]}]}]}`, now.Format(time.RFC3339Nano)),
 		},
+		{
+			name:    "SELECT float division of integer aggregates",
+			command: `SELECT sum(value) / count(value) from db.rp.divide`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"divide","columns":["time","sum_count"],"values":[["1970-01-01T00:00:00Z",7.5]]}]}]}`,
+		},
+		{
+			name:    "SELECT integer division of integer aggregates",
+			command: `SELECT div(sum(value), count(value)) from db.rp.divide`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"divide","columns":["time","div"],"values":[["1970-01-01T00:00:00Z",7]]}]}]}`,
+		},
+		{
+			name:    "SELECT integer division rounds towards negative infinity",
+			command: `SELECT div(-sum(value), count(value)) from db.rp.divide`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"divide","columns":["time","div"],"values":[["1970-01-01T00:00:00Z",-8]]}]}]}`,
+		},
+		{
+			name:    "SELECT integer division by zero",
+			command: `SELECT div(sum(value), 0) from db.rp.divide`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"divide","columns":["time","div"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
+		},
+		{
+			name:    "SELECT modulo of integer value",
+			command: `SELECT value % 10 from db.rp.integer`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","value"],"values":[["%s",2]]}]}]}`, now.Format(time.RFC3339Nano)),
+		},
+		{
+			name:    "SELECT modulo of float value",
+			command: `SELECT value % 4.5 from db.rp.float`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"float","columns":["time","value"],"values":[["%s",1.5]]}]}]}`, now.Format(time.RFC3339Nano)),
+		},
+		{
+			name:    "SELECT modulo of integer aggregate",
+			command: `SELECT sum(value) % 7 from db.rp.divide`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"divide","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+		},
+		{
+			name:    "SELECT integer modulo by zero",
+			command: `SELECT value % 0 from db.rp.integer`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","value"],"values":[["%s",null]]}]}]}`, now.Format(time.RFC3339Nano)),
+		},
+		{
+			name:    "SELECT float modulo by zero",
+			command: `SELECT value % 0 from db.rp.float`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"float","columns":["time","value"],"values":[["%s",null]]}]}]}`, now.Format(time.RFC3339Nano)),
+		},
 	}...)
 
 	ctx := context.Background()
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server can handle various simple non_negative_derivative queries.
-func TestServer_Query_SelectRawNonNegativeDerivative(t *testing.T) {
+func TestServer_Query_Bitwise(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	writes := []string{
+		fmt.Sprintf("events flags=5i,ratio=0.5 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf("events flags=12i,ratio=1.5 %d", mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `cpu value=10 1278010021000000000
-cpu value=15 1278010022000000000
-cpu value=10 1278010023000000000
-cpu value=20 1278010024000000000
-`},
+		&Write{data: strings.Join(writes, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "calculate single non_negative_derivative",
-			command: `SELECT non_negative_derivative(value) from db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","non_negative_derivative"],"values":[["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",10]]}]}]}`,
+			name:    "bitwise and of integer field",
+			command: `SELECT flags & 4 FROM db0.rp0.events`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"events","columns":["time","flags"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:10Z",4]]}]}]}`,
 		},
 		{
-			name:    "calculate single non_negative_derivative",
-			command: `SELECT non_negative_derivative(value, 10s) from db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","non_negative_derivative"],"values":[["2010-07-01T18:47:02Z",50],["2010-07-01T18:47:04Z",100]]}]}]}`,
+			name:    "bitwise or of integer field",
+			command: `SELECT flags | 2 FROM db0.rp0.events`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"events","columns":["time","flags"],"values":[["2000-01-01T00:00:00Z",7],["2000-01-01T00:00:10Z",14]]}]}]}`,
+		},
+		{
+			name:    "bitwise xor of integer field",
+			command: `SELECT flags ^ 1 FROM db0.rp0.events`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"events","columns":["time","flags"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:10Z",13]]}]}]}`,
+		},
+		{
+			name:    "bitwise and of aggregate",
+			command: `SELECT max(flags) & 4 FROM db0.rp0.events`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"events","columns":["time","max"],"values":[["2000-01-01T00:00:10Z",4]]}]}]}`,
+		},
+		{
+			name:    "bitwise operator on float field",
+			command: `SELECT ratio & 4 FROM db0.rp0.events`,
+			exp:     `{"results":[{"statement_id":0,"error":"bitwise operator requires integer operands"}]}`,
+		},
+		{
+			name:    "bitwise operator with float literal",
+			command: `SELECT flags | 2.0 FROM db0.rp0.events`,
+			exp:     `{"results":[{"statement_id":0,"error":"bitwise operator requires integer operands"}]}`,
 		},
 	}...)
 
@@ -425,135 +843,189 @@ cpu value=20 1278010024000000000
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server can handle various group by time derivative queries.
-func TestServer_Query_SelectGroupByTimeDerivative(t *testing.T) {
+// Ensure abs() keeps the type of its argument and passes nulls through.
+func TestServer_Query_Abs(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `cpu value=10 1278010020000000000
-cpu value=15 1278010021000000000
-cpu value=20 1278010022000000000
-cpu value=25 1278010023000000000
-
-cpu0,host=server01 ticks=10,total=100 1278010020000000000
-cpu0,host=server01 ticks=30,total=100 1278010021000000000
-cpu0,host=server01 ticks=32,total=100 1278010022000000000
-cpu0,host=server01 ticks=47,total=100 1278010023000000000
-cpu0,host=server02 ticks=40,total=100 1278010020000000000
-cpu0,host=server02 ticks=45,total=100 1278010021000000000
-cpu0,host=server02 ticks=84,total=100 1278010022000000000
-cpu0,host=server02 ticks=101,total=100 1278010023000000000
+		&Write{data: `integer value=-3i 1278010020000000000
+integer value=4i 1278010030000000000
+integer value=-5i 1278010080000000000
+float value=-1.5 1278010020000000000
+float value=2.5 1278010030000000000
+float value=-7.25 1278010080000000000
+mixed value=-2,other=1i 1278010020000000000
+mixed other=2i 1278010030000000000
 `},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "calculate derivative of count with unit default (2s) group by time",
-			command: `SELECT derivative(count(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:00Z",2],["2010-07-01T18:47:02Z",0]]}]}]}`,
-		},
-		{
-			name:    "calculate derivative of count with unit 4s group by time",
-			command: `SELECT derivative(count(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:00Z",4],["2010-07-01T18:47:02Z",0]]}]}]}`,
+			name:    "abs of integers",
+			command: `SELECT abs(value) FROM db0.rp0.integer`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","abs"],"values":[["2010-07-01T18:47:00Z",3],["2010-07-01T18:47:10Z",4],["2010-07-01T18:48:00Z",5]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of mean with unit default (2s) group by time",
-			command: `SELECT derivative(mean(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
+			name:    "abs of floats",
+			command: `SELECT abs(value) FROM db0.rp0.float`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"float","columns":["time","abs"],"values":[["2010-07-01T18:47:00Z",1.5],["2010-07-01T18:47:10Z",2.5],["2010-07-01T18:48:00Z",7.25]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of mean with unit 4s group by time",
-			command: `SELECT derivative(mean(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "abs of a missing value is null",
+			command: `SELECT abs(value), other FROM db0.rp0.mixed`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mixed","columns":["time","abs","other"],"values":[["2010-07-01T18:47:00Z",2,1],["2010-07-01T18:47:10Z",null,2]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of median with unit default (2s) group by time",
-			command: `SELECT derivative(median(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
+			name:    "abs of integer aggregates",
+			command: `SELECT abs(sum(value)) FROM db0.rp0.integer WHERE time >= '2010-07-01T18:47:00Z' AND time < '2010-07-01T18:49:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","abs"],"values":[["2010-07-01T18:47:00Z",1],["2010-07-01T18:48:00Z",5]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of median with unit 4s group by time",
-			command: `SELECT derivative(median(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
-		},
-		{
-			name:    "calculate derivative of mode with unit default (2s) group by time",
-			command: `SELECT derivative(mode(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
+			name:    "abs of float aggregates",
+			command: `SELECT abs(mean(value)) FROM db0.rp0.float WHERE time >= '2010-07-01T18:47:00Z' AND time < '2010-07-01T18:49:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"float","columns":["time","abs"],"values":[["2010-07-01T18:47:00Z",0.5],["2010-07-01T18:48:00Z",7.25]]}]}]}`,
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure sin(), cos() and tan() return floats and pass nulls through.
+func TestServer_Query_Trigonometry(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `integer value=0i 1278010020000000000
+integer value=1i 1278010030000000000
+float value=0 1278010020000000000
+float value=0.5 1278010030000000000
+mixed value=0,other=1i 1278010020000000000
+mixed other=2i 1278010030000000000
+`},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "calculate derivative of mode with unit 4s group by time",
-			command: `SELECT derivative(mode(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "trigonometry of integers",
+			command: `SELECT sin(value), cos(value), tan(value) FROM db0.rp0.integer`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","sin","cos","tan"],"values":[["2010-07-01T18:47:00Z",0,1,0],["2010-07-01T18:47:10Z",0.8414709848078965,0.5403023058681398,1.557407724654902]]}]}]}`,
 		},
-
 		{
-			name:    "calculate derivative of sum with unit default (2s) group by time",
-			command: `SELECT derivative(sum(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "trigonometry of floats",
+			command: `SELECT sin(value), cos(value), tan(value) FROM db0.rp0.float`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"float","columns":["time","sin","cos","tan"],"values":[["2010-07-01T18:47:00Z",0,1,0],["2010-07-01T18:47:10Z",0.479425538604203,0.8775825618903728,0.5463024898437905]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of sum with unit 4s group by time",
-			command: `SELECT derivative(sum(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",40]]}]}]}`,
+			name:    "trigonometry of literals is folded into the expression",
+			command: `SELECT sin(0) + value, cos(0) * value, tan(0) - value FROM db0.rp0.float`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"float","columns":["time","value","value_1","value_2"],"values":[["2010-07-01T18:47:00Z",0,0,0],["2010-07-01T18:47:10Z",0.5,0.5,-0.5]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of first with unit default (2s) group by time",
-			command: `SELECT derivative(first(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
+			name:    "trigonometry of a nested expression",
+			command: `SELECT sin(value * 2), cos(value * 2) FROM db0.rp0.float`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"float","columns":["time","sin","cos"],"values":[["2010-07-01T18:47:00Z",0,1],["2010-07-01T18:47:10Z",0.8414709848078965,0.5403023058681398]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of first with unit 4s group by time",
-			command: `SELECT derivative(first(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "trigonometry of a missing value is null",
+			command: `SELECT sin(value), cos(value), tan(value), other FROM db0.rp0.mixed`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mixed","columns":["time","sin","cos","tan","other"],"values":[["2010-07-01T18:47:00Z",0,1,0,1],["2010-07-01T18:47:10Z",null,null,null,2]]}]}]}`,
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the inverse trigonometric functions handle each quadrant and return
+// null for values outside of their domain.
+func TestServer_Query_InverseTrig(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `coords y=1,x=1 1278010020000000000
+coords y=1,x=-1 1278010030000000000
+coords y=-1,x=-1 1278010040000000000
+coords y=-1,x=1 1278010050000000000
+ratios value=0.5 1278010020000000000
+ratios value=-1 1278010030000000000
+ratios value=2 1278010040000000000
+`},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "calculate derivative of last with unit default (2s) group by time",
-			command: `SELECT derivative(last(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
+			name:    "atan2 of fields in each quadrant",
+			command: `SELECT atan2(y, x) FROM db0.rp0.coords`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"coords","columns":["time","atan2"],"values":[["2010-07-01T18:47:00Z",0.7853981633974483],["2010-07-01T18:47:10Z",2.356194490192345],["2010-07-01T18:47:20Z",-2.356194490192345],["2010-07-01T18:47:30Z",-0.7853981633974483]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of last with unit 4s group by time",
-			command: `SELECT derivative(last(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "atan of a field",
+			command: `SELECT atan(y) FROM db0.rp0.coords`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"coords","columns":["time","atan"],"values":[["2010-07-01T18:47:00Z",0.7853981633974483],["2010-07-01T18:47:10Z",0.7853981633974483],["2010-07-01T18:47:20Z",-0.7853981633974483],["2010-07-01T18:47:30Z",-0.7853981633974483]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of min with unit default (2s) group by time",
-			command: `SELECT derivative(min(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
+			name:    "asin outside of the domain is null",
+			command: `SELECT asin(value) FROM db0.rp0.ratios`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"ratios","columns":["time","asin"],"values":[["2010-07-01T18:47:00Z",0.5235987755982989],["2010-07-01T18:47:10Z",-1.5707963267948966],["2010-07-01T18:47:20Z",null]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of min with unit 4s group by time",
-			command: `SELECT derivative(min(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "acos outside of the domain is null",
+			command: `SELECT acos(value) FROM db0.rp0.ratios`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"ratios","columns":["time","acos"],"values":[["2010-07-01T18:47:00Z",1.0471975511965976],["2010-07-01T18:47:10Z",3.141592653589793],["2010-07-01T18:47:20Z",null]]}]}]}`,
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the exponential and logarithmic functions return null for values
+// outside of their domain.
+func TestServer_Query_ExpLog(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `m value=8,base=2 1278010020000000000
+m value=1,base=10 1278010030000000000
+m value=0,base=1 1278010040000000000
+m value=-4,base=2 1278010050000000000
+`},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "calculate derivative of max with unit default (2s) group by time",
-			command: `SELECT derivative(max(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
+			name:    "exp",
+			command: `SELECT exp(value) FROM db0.rp0.m`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m","columns":["time","exp"],"values":[["2010-07-01T18:47:00Z",2980.9579870417283],["2010-07-01T18:47:10Z",2.718281828459045],["2010-07-01T18:47:20Z",1],["2010-07-01T18:47:30Z",0.01831563888873418]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of max with unit 4s group by time",
-			command: `SELECT derivative(max(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "ln",
+			command: `SELECT ln(value) FROM db0.rp0.m`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m","columns":["time","ln"],"values":[["2010-07-01T18:47:00Z",2.0794415416798357],["2010-07-01T18:47:10Z",0],["2010-07-01T18:47:20Z",null],["2010-07-01T18:47:30Z",null]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of percentile with unit default (2s) group by time",
-			command: `SELECT derivative(percentile(value, 50)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
+			name:    "log2 and log10",
+			command: `SELECT log2(value), log10(value) FROM db0.rp0.m`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m","columns":["time","log2","log10"],"values":[["2010-07-01T18:47:00Z",3,0.9030899869919435],["2010-07-01T18:47:10Z",0,0],["2010-07-01T18:47:20Z",null,null],["2010-07-01T18:47:30Z",null,null]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of percentile with unit 4s group by time",
-			command: `SELECT derivative(percentile(value, 50), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "log with a literal base",
+			command: `SELECT log(value, 2) FROM db0.rp0.m`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m","columns":["time","log"],"values":[["2010-07-01T18:47:00Z",3],["2010-07-01T18:47:10Z",0],["2010-07-01T18:47:20Z",null],["2010-07-01T18:47:30Z",null]]}]}]}`,
 		},
 		{
-			name:    "calculate derivative of ticks divided by aggregate",
-			command: `SELECT non_negative_derivative(mean(ticks), 1s) / last(total) * 100 AS usage FROM db0.rp0.cpu0 WHERE time >= '2010-07-01 18:47:00' AND time <= '2010-07-01 18:47:03' GROUP BY host, time(1s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu0","tags":{"host":"server01"},"columns":["time","usage"],"values":[["2010-07-01T18:47:00Z",null],["2010-07-01T18:47:01Z",20],["2010-07-01T18:47:02Z",2],["2010-07-01T18:47:03Z",15]]},{"name":"cpu0","tags":{"host":"server02"},"columns":["time","usage"],"values":[["2010-07-01T18:47:00Z",null],["2010-07-01T18:47:01Z",5],["2010-07-01T18:47:02Z",39],["2010-07-01T18:47:03Z",17]]}]}]}`,
+			name:    "log with a field base",
+			command: `SELECT log(value, base) FROM db0.rp0.m`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m","columns":["time","log"],"values":[["2010-07-01T18:47:00Z",3],["2010-07-01T18:47:10Z",0],["2010-07-01T18:47:20Z",null],["2010-07-01T18:47:30Z",null]]}]}]}`,
 		},
 	}...)
 
@@ -561,60 +1033,46 @@ cpu0,host=server02 ticks=101,total=100 1278010023000000000
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server can query with the count aggregate function
-func TestServer_Query_Count(t *testing.T) {
+// Ensure sqrt() returns null for negative values and pow() keeps the integer
+// type for integer arguments with a non-negative exponent.
+func TestServer_Query_SqrtPow(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
-	now := now()
-
 	test := NewTest("db0", "rp0")
-	writes := []string{
-		`cpu,host=server01 value=1.0 ` + strconv.FormatInt(now.UnixNano(), 10),
-		`ram value1=1.0,value2=2.0 ` + strconv.FormatInt(now.UnixNano(), 10),
-	}
-
 	test.writes = Writes{
-		&Write{data: strings.Join(writes, "\n")},
+		&Write{data: `integer value=2i,exponent=10i 1278010020000000000
+integer value=3i,exponent=-1i 1278010030000000000
+float value=2.25 1278010020000000000
+float value=-4 1278010030000000000
+`},
 	}
 
-	hourAgo := now.Add(-time.Hour).UTC()
-
 	test.addQueries([]*Query{
 		{
-			name:    "selecting count(value) should succeed",
-			command: `SELECT count(value) FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
-		},
-		{
-			name:    "selecting count(value) with where time should return result",
-			command: fmt.Sprintf(`SELECT count(value) FROM db0.rp0.cpu WHERE time >= '%s'`, hourAgo.Format(time.RFC3339Nano)),
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["%s",1]]}]}]}`, hourAgo.Format(time.RFC3339Nano)),
-		},
-		{
-			name:    "selecting count(value) with filter that excludes all results should return 0",
-			command: fmt.Sprintf(`SELECT count(value) FROM db0.rp0.cpu WHERE value=100 AND time >= '%s'`, hourAgo.Format(time.RFC3339Nano)),
-			exp:     `{"results":[{"statement_id":0}]}`,
+			name:    "sqrt of floats",
+			command: `SELECT sqrt(value) FROM db0.rp0.float`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"float","columns":["time","sqrt"],"values":[["2010-07-01T18:47:00Z",1.5],["2010-07-01T18:47:10Z",null]]}]}]}`,
 		},
 		{
-			name:    "selecting count(value1) with matching filter against value2 should return correct result",
-			command: fmt.Sprintf(`SELECT count(value1) FROM db0.rp0.ram WHERE value2=2 AND time >= '%s'`, hourAgo.Format(time.RFC3339Nano)),
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"ram","columns":["time","count"],"values":[["%s",1]]}]}]}`, hourAgo.Format(time.RFC3339Nano)),
+			name:    "pow of an integer field and literal",
+			command: `SELECT pow(value, 10) FROM db0.rp0.integer`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","pow"],"values":[["2010-07-01T18:47:00Z",1024],["2010-07-01T18:47:10Z",59049]]}]}]}`,
 		},
 		{
-			name:    "selecting count(value1) with non-matching filter against value2 should return correct result",
-			command: fmt.Sprintf(`SELECT count(value1) FROM db0.rp0.ram WHERE value2=3 AND time >= '%s'`, hourAgo.Format(time.RFC3339Nano)),
-			exp:     `{"results":[{"statement_id":0}]}`,
+			name:    "pow of integer fields",
+			command: `SELECT pow(value, exponent) FROM db0.rp0.integer`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","pow"],"values":[["2010-07-01T18:47:00Z",1024],["2010-07-01T18:47:10Z",0.3333333333333333]]}]}]}`,
 		},
 		{
-			name:    "selecting count(*) should expand the wildcard",
-			command: `SELECT count(*) FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+			name:    "pow of a float field",
+			command: `SELECT pow(value, 2) FROM db0.rp0.float`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"float","columns":["time","pow"],"values":[["2010-07-01T18:47:00Z",5.0625],["2010-07-01T18:47:10Z",16]]}]}]}`,
 		},
 		{
-			name:    "selecting count(2) should error",
-			command: `SELECT count(2) FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"error":"expected field argument in count()"}]}`,
+			name:    "pow of integers is an integer",
+			command: `SELECT div(p, 3) FROM (SELECT pow(value, 10) AS p FROM db0.rp0.integer)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","div"],"values":[["2010-07-01T18:47:00Z",341],["2010-07-01T18:47:10Z",19683]]}]}]}`,
 		},
 	}...)
 
@@ -622,26 +1080,38 @@ func TestServer_Query_Count(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server can limit concurrent series.
-func TestServer_Query_MaxSelectSeriesN(t *testing.T) {
-	s := OpenServer(t, func(o *launcher.InfluxdOpts) {
-		o.CoordinatorConfig.MaxSelectSeriesN = 3
-	})
+// Ensure floor(), ceil() and round() round negative floats in the right
+// direction and keep integers unchanged.
+func TestServer_Query_FloorCeilRound(t *testing.T) {
+	s := OpenServer(t)
 	defer s.Close()
 
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `cpu,host=server01 value=1.0 0`},
-		&Write{data: `cpu,host=server02 value=1.0 0`},
-		&Write{data: `cpu,host=server03 value=1.0 0`},
-		&Write{data: `cpu,host=server04 value=1.0 0`},
+		&Write{data: `float value=2.5 1278010020000000000
+float value=-2.5 1278010030000000000
+float value=-2.4 1278010040000000000
+float value=-2.6 1278010050000000000
+integer value=-3i 1278010020000000000
+integer value=7i 1278010030000000000
+`},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "exceeed max series",
-			command: `SELECT COUNT(value) FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"error":"max-select-series limit exceeded: (4/3)"}]}`,
+			name:    "floor, ceil and round of floats",
+			command: `SELECT floor(value), ceil(value), round(value) FROM db0.rp0.float`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"float","columns":["time","floor","ceil","round"],"values":[["2010-07-01T18:47:00Z",2,3,3],["2010-07-01T18:47:10Z",-3,-2,-3],["2010-07-01T18:47:20Z",-3,-2,-2],["2010-07-01T18:47:30Z",-3,-2,-3]]}]}]}`,
+		},
+		{
+			name:    "floor, ceil and round of integers",
+			command: `SELECT floor(value), ceil(value), round(value) FROM db0.rp0.integer`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","floor","ceil","round"],"values":[["2010-07-01T18:47:00Z",-3,-3,-3],["2010-07-01T18:47:10Z",7,7,7]]}]}]}`,
+		},
+		{
+			name:    "round of integers is an integer",
+			command: `SELECT div(r, 2) FROM (SELECT round(value) AS r FROM db0.rp0.integer)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"integer","columns":["time","div"],"values":[["2010-07-01T18:47:00Z",-2],["2010-07-01T18:47:10Z",3]]}]}]}`,
 		},
 	}...)
 
@@ -649,38 +1119,30 @@ func TestServer_Query_MaxSelectSeriesN(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server can query with Now().
-func TestServer_Query_Now(t *testing.T) {
+// Ensure the server can handle various simple non_negative_derivative queries.
+func TestServer_Query_SelectRawNonNegativeDerivative(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
-	now := now()
-
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `cpu,host=server01 value=1.0 ` + strconv.FormatInt(now.UnixNano(), 10)},
+		&Write{data: `cpu value=10 1278010021000000000
+cpu value=15 1278010022000000000
+cpu value=10 1278010023000000000
+cpu value=20 1278010024000000000
+`},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "where with time < now() should work",
-			command: `SELECT * FROM db0.rp0.cpu where time < now()`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","value"],"values":[["%s","server01",1]]}]}]}`, now.Format(time.RFC3339Nano)),
-		},
-		{
-			name:    "where with time < now() and GROUP BY * should work",
-			command: `SELECT * FROM db0.rp0.cpu where time < now() GROUP BY *`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["%s",1]]}]}]}`, now.Format(time.RFC3339Nano)),
-		},
-		{
-			name:    "where with time > now() should return an empty result",
-			command: `SELECT * FROM db0.rp0.cpu where time > now()`,
-			exp:     `{"results":[{"statement_id":0}]}`,
+			name:    "calculate single non_negative_derivative",
+			command: `SELECT non_negative_derivative(value) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","non_negative_derivative"],"values":[["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",10]]}]}]}`,
 		},
 		{
-			name:    "where with time > now() with GROUP BY * should return an empty result",
-			command: `SELECT * FROM db0.rp0.cpu where time > now() GROUP BY *`,
-			exp:     `{"results":[{"statement_id":0}]}`,
+			name:    "calculate single non_negative_derivative",
+			command: `SELECT non_negative_derivative(value, 10s) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","non_negative_derivative"],"values":[["2010-07-01T18:47:02Z",50],["2010-07-01T18:47:04Z",100]]}]}]}`,
 		},
 	}...)
 
@@ -688,54 +1150,30 @@ func TestServer_Query_Now(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server can query with epoch precisions.
-func TestServer_Query_EpochPrecision(t *testing.T) {
+// Ensure the server can query with the non_negative_difference function.
+func TestServer_Query_SelectRawNonNegativeDifference(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
-	now := now()
-
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `cpu,host=server01 value=1.0 ` + strconv.FormatInt(now.UnixNano(), 10)},
+		&Write{data: `cpu value=10 1278010021000000000
+cpu value=15 1278010022000000000
+cpu value=10 1278010023000000000
+cpu value=20 1278010024000000000
+`},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "nanosecond precision",
-			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
-			params:  url.Values{"epoch": []string{"n"}},
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()),
-		},
-		{
-			name:    "microsecond precision",
-			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
-			params:  url.Values{"epoch": []string{"u"}},
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()/int64(time.Microsecond)),
-		},
-		{
-			name:    "millisecond precision",
-			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
-			params:  url.Values{"epoch": []string{"ms"}},
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()/int64(time.Millisecond)),
-		},
-		{
-			name:    "second precision",
-			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
-			params:  url.Values{"epoch": []string{"s"}},
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()/int64(time.Second)),
-		},
-		{
-			name:    "minute precision",
-			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
-			params:  url.Values{"epoch": []string{"m"}},
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()/int64(time.Minute)),
+			name:    "calculate single non_negative_difference",
+			command: `SELECT non_negative_difference(value) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","non_negative_difference"],"values":[["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",10]]}]}]}`,
 		},
 		{
-			name:    "hour precision",
-			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
-			params:  url.Values{"epoch": []string{"h"}},
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()/int64(time.Hour)),
+			name:    "calculate non_negative_difference of max group by time",
+			command: `SELECT non_negative_difference(max(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:01' and time <= '2010-07-01 18:47:04' group by time(1s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","non_negative_difference"],"values":[["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",10]]}]}]}`,
 		},
 	}...)
 
@@ -743,162 +1181,185 @@ func TestServer_Query_EpochPrecision(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server works with tag queries.
-func TestServer_Query_Tags(t *testing.T) {
+// Ensure the server can handle various group by time derivative queries.
+func TestServer_Query_SelectGroupByTimeDerivative(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
-	now := now()
-
-	writes := []string{
-		fmt.Sprintf("cpu,host=server01 value=100,core=4 %d", now.UnixNano()),
-		fmt.Sprintf("cpu,host=server02 value=50,core=2 %d", now.Add(1).UnixNano()),
-
-		fmt.Sprintf("cpu1,host=server01,region=us-west value=100 %d", mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
-		fmt.Sprintf("cpu1,host=server02 value=200 %d", mustParseTime(time.RFC3339Nano, "2010-02-28T01:03:37.703820946Z").UnixNano()),
-		fmt.Sprintf("cpu1,host=server03 value=300 %d", mustParseTime(time.RFC3339Nano, "2012-02-28T01:03:38.703820946Z").UnixNano()),
-
-		fmt.Sprintf("cpu2,host=server01 value=100 %d", mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
-		fmt.Sprintf("cpu2 value=200 %d", mustParseTime(time.RFC3339Nano, "2012-02-28T01:03:38.703820946Z").UnixNano()),
-
-		fmt.Sprintf("cpu3,company=acme01 value=100 %d", mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
-		fmt.Sprintf("cpu3 value=200 %d", mustParseTime(time.RFC3339Nano, "2012-02-28T01:03:38.703820946Z").UnixNano()),
-
-		fmt.Sprintf("status_code,url=http://www.example.com value=404 %d", mustParseTime(time.RFC3339Nano, "2015-07-22T08:13:54.929026672Z").UnixNano()),
-		fmt.Sprintf("status_code,url=https://influxdb.com value=418 %d", mustParseTime(time.RFC3339Nano, "2015-07-22T09:52:24.914395083Z").UnixNano()),
-	}
-
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join(writes, "\n")},
-	}
+		&Write{data: `cpu value=10 1278010020000000000
+cpu value=15 1278010021000000000
+cpu value=20 1278010022000000000
+cpu value=25 1278010023000000000
 
-	test.addQueries([]*Query{
-		{
-			name:    "tag without field should return error",
-			command: `SELECT host FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"error":"statement must have at least one field in select clause"}]}`,
-			skip:    SkippedOSS, // FIXME(benbjohnson): tags should stream as values
-		},
+cpu0,host=server01 ticks=10,total=100 1278010020000000000
+cpu0,host=server01 ticks=30,total=100 1278010021000000000
+cpu0,host=server01 ticks=32,total=100 1278010022000000000
+cpu0,host=server01 ticks=47,total=100 1278010023000000000
+cpu0,host=server02 ticks=40,total=100 1278010020000000000
+cpu0,host=server02 ticks=45,total=100 1278010021000000000
+cpu0,host=server02 ticks=84,total=100 1278010022000000000
+cpu0,host=server02 ticks=101,total=100 1278010023000000000
+`},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "field with tag should succeed",
-			command: `SELECT host, value FROM db0.rp0.cpu`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","value"],"values":[["%s","server01",100],["%s","server02",50]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
+			name:    "calculate derivative of count with unit default (2s) group by time",
+			command: `SELECT derivative(count(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:00Z",2],["2010-07-01T18:47:02Z",0]]}]}]}`,
 		},
 		{
-			name:    "field with tag and GROUP BY should succeed",
-			command: `SELECT host, value FROM db0.rp0.cpu GROUP BY host`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","host","value"],"values":[["%s","server01",100]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","host","value"],"values":[["%s","server02",50]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
+			name:    "calculate derivative of count with unit 4s group by time",
+			command: `SELECT derivative(count(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:00Z",4],["2010-07-01T18:47:02Z",0]]}]}]}`,
 		},
 		{
-			name:    "field with two tags should succeed",
-			command: `SELECT host, value, core FROM db0.rp0.cpu`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","value","core"],"values":[["%s","server01",100,4],["%s","server02",50,2]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
+			name:    "calculate derivative of mean with unit default (2s) group by time",
+			command: `SELECT derivative(mean(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
 		},
 		{
-			name:    "field with two tags and GROUP BY should succeed",
-			command: `SELECT host, value, core FROM db0.rp0.cpu GROUP BY host`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","host","value","core"],"values":[["%s","server01",100,4]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","host","value","core"],"values":[["%s","server02",50,2]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
+			name:    "calculate derivative of mean with unit 4s group by time",
+			command: `SELECT derivative(mean(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
 		},
 		{
-			name:    "select * with tags should succeed",
-			command: `SELECT * FROM db0.rp0.cpu`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core","host","value"],"values":[["%s",4,"server01",100],["%s",2,"server02",50]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
+			name:    "calculate derivative of median with unit default (2s) group by time",
+			command: `SELECT derivative(median(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
 		},
 		{
-			name:    "select * with tags with GROUP BY * should succeed",
-			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","core","value"],"values":[["%s",4,100]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","core","value"],"values":[["%s",2,50]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
+			name:    "calculate derivative of median with unit 4s group by time",
+			command: `SELECT derivative(median(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
 		},
 		{
-			name:    "group by tag",
-			command: `SELECT value FROM db0.rp0.cpu GROUP by host`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["%s",100]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","value"],"values":[["%s",50]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
+			name:    "calculate derivative of mode with unit default (2s) group by time",
+			command: `SELECT derivative(mode(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
 		},
 		{
-			name:    "single field (EQ tag value1)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server01'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+			name:    "calculate derivative of mode with unit 4s group by time",
+			command: `SELECT derivative(mode(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
 		},
+
 		{
-			name:    "single field (2 EQ tags)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server01' AND region = 'us-west'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+			name:    "calculate derivative of sum with unit default (2s) group by time",
+			command: `SELECT derivative(sum(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
 		},
 		{
-			name:    "single field (OR different tags)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server03' OR region = 'us-west'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",300],["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+			name:    "calculate derivative of sum with unit 4s group by time",
+			command: `SELECT derivative(sum(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",40]]}]}]}`,
 		},
 		{
-			name:    "single field (OR with non-existent tag value)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server01' OR host = 'server66'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+			name:    "calculate derivative of first with unit default (2s) group by time",
+			command: `SELECT derivative(first(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
 		},
 		{
-			name:    "single field (OR with all tag values)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server01' OR host = 'server02' OR host = 'server03'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2010-02-28T01:03:37.703820946Z",200],["2012-02-28T01:03:38.703820946Z",300],["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+			name:    "calculate derivative of first with unit 4s group by time",
+			command: `SELECT derivative(first(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
 		},
 		{
-			name:    "single field (1 EQ and 1 NEQ tag)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server01' AND region != 'us-west'`,
-			exp:     `{"results":[{"statement_id":0}]}`,
+			name:    "calculate derivative of last with unit default (2s) group by time",
+			command: `SELECT derivative(last(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
 		},
 		{
-			name:    "single field (EQ tag value2)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server02'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2010-02-28T01:03:37.703820946Z",200]]}]}]}`,
+			name:    "calculate derivative of last with unit 4s group by time",
+			command: `SELECT derivative(last(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
 		},
 		{
-			name:    "single field (NEQ tag value1)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host != 'server01'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2010-02-28T01:03:37.703820946Z",200],["2012-02-28T01:03:38.703820946Z",300]]}]}]}`,
+			name:    "calculate derivative of min with unit default (2s) group by time",
+			command: `SELECT derivative(min(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
 		},
 		{
-			name:    "single field (NEQ tag value1 AND NEQ tag value2)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host != 'server01' AND host != 'server02'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",300]]}]}]}`,
+			name:    "calculate derivative of min with unit 4s group by time",
+			command: `SELECT derivative(min(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
 		},
 		{
-			name:    "single field (NEQ tag value1 OR NEQ tag value2)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host != 'server01' OR host != 'server02'`, // Yes, this is always true, but that's the point.
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2010-02-28T01:03:37.703820946Z",200],["2012-02-28T01:03:38.703820946Z",300],["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+			name:    "calculate derivative of max with unit default (2s) group by time",
+			command: `SELECT derivative(max(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
 		},
 		{
-			name:    "single field (NEQ tag value1 AND NEQ tag value2 AND NEQ tag value3)",
-			command: `SELECT value FROM db0.rp0.cpu1 WHERE host != 'server01' AND host != 'server02' AND host != 'server03'`,
-			exp:     `{"results":[{"statement_id":0}]}`,
+			name:    "calculate derivative of max with unit 4s group by time",
+			command: `SELECT derivative(max(value), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
 		},
 		{
-			name:    "single field (NEQ tag value1, point without any tags)",
-			command: `SELECT value FROM db0.rp0.cpu2 WHERE host != 'server01'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu2","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",200]]}]}]}`,
+			name:    "calculate derivative of percentile with unit default (2s) group by time",
+			command: `SELECT derivative(percentile(value, 50)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",10]]}]}]}`,
 		},
 		{
-			name:    "single field (NEQ tag value1, point without any tags)",
-			command: `SELECT value FROM db0.rp0.cpu3 WHERE company !~ /acme01/`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu3","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",200]]}]}]}`,
+			name:    "calculate derivative of percentile with unit 4s group by time",
+			command: `SELECT derivative(percentile(value, 50), 4s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","derivative"],"values":[["2010-07-01T18:47:02Z",20]]}]}]}`,
 		},
 		{
-			name:    "single field (regex tag match)",
-			command: `SELECT value FROM db0.rp0.cpu3 WHERE company =~ /acme01/`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu3","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+			name:    "calculate derivative of ticks divided by aggregate",
+			command: `SELECT non_negative_derivative(mean(ticks), 1s) / last(total) * 100 AS usage FROM db0.rp0.cpu0 WHERE time >= '2010-07-01 18:47:00' AND time <= '2010-07-01 18:47:03' GROUP BY host, time(1s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu0","tags":{"host":"server01"},"columns":["time","usage"],"values":[["2010-07-01T18:47:00Z",null],["2010-07-01T18:47:01Z",20],["2010-07-01T18:47:02Z",2],["2010-07-01T18:47:03Z",15]]},{"name":"cpu0","tags":{"host":"server02"},"columns":["time","usage"],"values":[["2010-07-01T18:47:00Z",null],["2010-07-01T18:47:01Z",5],["2010-07-01T18:47:02Z",39],["2010-07-01T18:47:03Z",17]]}]}]}`,
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure transformations can operate on numbers stored as strings when
+// numeric coercion is requested.
+func TestServer_Query_CoerceNumeric(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`readings value="1" %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:47:00Z").UnixNano()),
+		fmt.Sprintf(`readings value="3" %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:47:10Z").UnixNano()),
+		fmt.Sprintf(`readings value=" 6 " %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:47:20Z").UnixNano()),
+		fmt.Sprintf(`readings value="n/a" %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:47:30Z").UnixNano()),
+		fmt.Sprintf(`readings value="1e1" %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:47:40Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "single field (regex tag match)",
-			command: `SELECT value FROM db0.rp0.cpu3 WHERE company !~ /acme[23]/`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu3","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",200],["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+			name:    "derivative of string field",
+			command: `SELECT derivative(value, 10s) FROM readings`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"readings","columns":["time","derivative"],"values":[["2010-07-01T18:47:10Z",2],["2010-07-01T18:47:20Z",3],["2010-07-01T18:47:40Z",2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "coerce_numeric": []string{"true"}},
 		},
 		{
-			name:    "single field (regex tag match with escaping)",
-			command: `SELECT value FROM db0.rp0.status_code WHERE url !~ /https\:\/\/influxdb\.com/`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"status_code","columns":["time","value"],"values":[["2015-07-22T08:13:54.929026672Z",404]]}]}]}`,
+			name:    "difference of string field",
+			command: `SELECT difference(value) FROM readings`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"readings","columns":["time","difference"],"values":[["2010-07-01T18:47:10Z",2],["2010-07-01T18:47:20Z",3],["2010-07-01T18:47:40Z",4]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "coerce_numeric": []string{"true"}},
 		},
 		{
-			name:    "single field (regex tag match with escaping)",
-			command: `SELECT value FROM db0.rp0.status_code WHERE url =~ /https\:\/\/influxdb\.com/`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"status_code","columns":["time","value"],"values":[["2015-07-22T09:52:24.914395083Z",418]]}]}]}`,
+			name:    "moving_average of string field",
+			command: `SELECT moving_average(value, 2) FROM readings`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"readings","columns":["time","moving_average"],"values":[["2010-07-01T18:47:10Z",2],["2010-07-01T18:47:20Z",4.5],["2010-07-01T18:47:40Z",8]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "coerce_numeric": []string{"true"}},
+		},
+		{
+			name:    "derivative of string field without coercion",
+			command: `SELECT derivative(value, 10s) FROM readings`,
+			exp:     `{"results":[{"statement_id":0,"error":"unsupported derivative iterator type: *query.stringInterruptIterator"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 	}...)
 
@@ -906,72 +1367,99 @@ func TestServer_Query_Tags(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server can handle various group by time moving average queries.
-func TestServer_Query_SelectGroupByTimeMovingAverage(t *testing.T) {
+// Ensure the server can read a sentinel field value as null.
+func TestServer_Query_TreatAsNull(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	writes := []string{
+		fmt.Sprintf(`readings,host=server01 value=1,errors=2i,status="ok" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`readings,host=server01 value=-1,errors=5i,status="ok" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`readings,host=server01 value=3,errors=-1i,status="ok" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `cpu value=10 1278010020000000000
-cpu value=15 1278010021000000000
-cpu value=20 1278010022000000000
-cpu value=25 1278010023000000000
-cpu value=30 1278010024000000000
-cpu value=35 1278010025000000000
-`},
+		&Write{data: strings.Join(writes, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "calculate moving average of count",
-			command: `SELECT moving_average(count(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",1],["2010-07-01T18:47:02Z",2],["2010-07-01T18:47:04Z",2]]}]}]}`,
-		},
-		{
-			name:    "calculate moving average of mean",
-			command: `SELECT moving_average(mean(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",17.5],["2010-07-01T18:47:04Z",27.5]]}]}]}`,
+			name:    "raw values",
+			command: `SELECT value, errors FROM readings`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"readings","columns":["time","value","errors"],"values":[["2000-01-01T00:00:00Z",1,2],["2000-01-01T00:00:10Z",null,5],["2000-01-01T00:00:20Z",3,null]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "treat_as_null": []string{"-1"}},
 		},
 		{
-			name:    "calculate moving average of median",
-			command: `SELECT moving_average(median(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",17.5],["2010-07-01T18:47:04Z",27.5]]}]}]}`,
+			name:    "aggregates",
+			command: `SELECT mean(value), count(value), sum(errors) FROM readings`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"readings","columns":["time","mean","count","sum"],"values":[["1970-01-01T00:00:00Z",2,2,7]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "treat_as_null": []string{"-1"}},
 		},
 		{
-			name:    "calculate moving average of mode",
-			command: `SELECT moving_average(mode(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+			name:    "aggregates without sentinel",
+			command: `SELECT mean(value), count(value), sum(errors) FROM readings`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"readings","columns":["time","mean","count","sum"],"values":[["1970-01-01T00:00:00Z",1,3,6]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of sum",
-			command: `SELECT moving_average(sum(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",35],["2010-07-01T18:47:04Z",55]]}]}]}`,
+			name:    "condition on sentinel field",
+			command: `SELECT status FROM readings WHERE value < 2`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"readings","columns":["time","status"],"values":[["2000-01-01T00:00:00Z","ok"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "treat_as_null": []string{"-1"}},
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can return the columns of a query without reading data.
+func TestServer_Query_ColumnsOnly(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=1,load=0.5 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=useast value=2,load=0.7 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`mem,host=server01 free=100i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "calculate moving average of first",
-			command: `SELECT moving_average(first(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+			name:    "wildcard",
+			command: `SELECT * FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","load","region","value"]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "columns_only": []string{"true"}},
 		},
 		{
-			name:    "calculate moving average of last",
-			command: `SELECT moving_average(last(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",20],["2010-07-01T18:47:04Z",30]]}]}]}`,
+			name:    "aggregates with group by",
+			command: `SELECT mean(value), max(load) AS peak FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:00Z' GROUP BY time(10s), host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean","peak"]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "columns_only": []string{"true"}},
 		},
 		{
-			name:    "calculate moving average of min",
-			command: `SELECT moving_average(min(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+			name:    "multiple measurements",
+			command: `SELECT * FROM cpu, mem`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","free","host","load","region","value"]},{"name":"mem","columns":["time","free","host","load","region","value"]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "columns_only": []string{"true"}},
 		},
 		{
-			name:    "calculate moving average of max",
-			command: `SELECT moving_average(max(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",20],["2010-07-01T18:47:04Z",30]]}]}]}`,
+			name:    "regex measurement",
+			command: `SELECT free FROM /^m/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["time","free"]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "columns_only": []string{"true"}},
 		},
 		{
-			name:    "calculate moving average of percentile",
-			command: `SELECT moving_average(percentile(value, 50), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+			name:    "invalid query",
+			command: `SELECT derivative(value, 10s) FROM cpu GROUP BY time(10s)`,
+			exp:     `{"results":[{"statement_id":0,"error":"aggregate function required inside the call to derivative"}]}`,
+			params:  url.Values{"db": []string{"db0"}, "columns_only": []string{"true"}},
 		},
 	}...)
 
@@ -979,134 +1467,206 @@ cpu value=35 1278010025000000000
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server can handle various group by time moving average queries.
-func TestServer_Query_SelectGroupByTimeMovingAverageWithFill(t *testing.T) {
+// Ensure the server can estimate the shards and series a query reads.
+func TestServer_Query_Estimate(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=useast value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server03,region=useast value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-15T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`mem,host=server01 free=100i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `cpu value=10 1278010020000000000
-cpu value=15 1278010021000000000
-cpu value=30 1278010024000000000
-cpu value=35 1278010025000000000
-`},
+		&Write{data: strings.Join(writes, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "calculate moving average of count with fill 0",
-			command: `SELECT moving_average(count(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",1],["2010-07-01T18:47:02Z",1],["2010-07-01T18:47:04Z",1]]}]}]}`,
+			name:    "regex measurement",
+			command: `SELECT * FROM /.*/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["shards","series"],"values":[[2,4]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "estimate": []string{"true"}},
 		},
 		{
-			name:    "calculate moving average of count with fill previous",
-			command: `SELECT moving_average(count(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",2],["2010-07-01T18:47:04Z",2]]}]}]}`,
+			name:    "regex tag condition",
+			command: `SELECT value FROM cpu WHERE host =~ /server0[13]/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["shards","series"],"values":[[2,2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "estimate": []string{"true"}},
 		},
 		{
-			name:    "calculate moving average of mean with fill 0",
-			command: `SELECT moving_average(mean(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",6.25],["2010-07-01T18:47:02Z",6.25],["2010-07-01T18:47:04Z",16.25]]}]}]}`,
+			name:    "time range",
+			command: `SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-02T00:00:00Z' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["shards","series"],"values":[[1,2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "estimate": []string{"true"}},
 		},
 		{
-			name:    "calculate moving average of mean with fill previous",
-			command: `SELECT moving_average(mean(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",12.5],["2010-07-01T18:47:04Z",22.5]]}]}]}`,
-		},
-		{
-			name:    "calculate moving average of median with fill 0",
-			command: `SELECT moving_average(median(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",6.25],["2010-07-01T18:47:02Z",6.25],["2010-07-01T18:47:04Z",16.25]]}]}]}`,
+			name:    "missing measurement",
+			command: `SELECT value FROM disk`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["shards","series"],"values":[[0,0]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "estimate": []string{"true"}},
 		},
 		{
-			name:    "calculate moving average of median with fill previous",
-			command: `SELECT moving_average(median(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",12.5],["2010-07-01T18:47:04Z",22.5]]}]}]}`,
+			name:    "invalid query",
+			command: `SELECT derivative(value, 10s) FROM cpu GROUP BY time(10s)`,
+			exp:     `{"results":[{"statement_id":0,"error":"aggregate function required inside the call to derivative"}]}`,
+			params:  url.Values{"db": []string{"db0"}, "estimate": []string{"true"}},
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the series id of each series is the same across queries.
+func TestServer_Query_SeriesID(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=useast value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "calculate moving average of mode with fill 0",
-			command: `SELECT moving_average(mode(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",5],["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",15]]}]}]}`,
+			name:    "raw query grouped by host",
+			command: `SELECT value FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value","_series_id"],"values":[["2000-01-01T00:00:00Z",1,"cc9554271c1233e3"],["2000-01-01T00:00:10Z",2,"cc9554271c1233e3"]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","value","_series_id"],"values":[["2000-01-01T00:00:00Z",3,"88865b3f33c3314b"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "series_id": []string{"true"}},
+			repeat:  2,
 		},
 		{
-			name:    "calculate moving average of mode with fill previous",
-			command: `SELECT moving_average(mode(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",10],["2010-07-01T18:47:04Z",20]]}]}]}`,
+			name:    "aggregate query grouped by host",
+			command: `SELECT max(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:00Z' GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","max","_series_id"],"values":[["2000-01-01T00:00:10Z",2,"cc9554271c1233e3"]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","max","_series_id"],"values":[["2000-01-01T00:00:00Z",3,"88865b3f33c3314b"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "series_id": []string{"true"}},
+			repeat:  2,
 		},
 		{
-			name:    "calculate moving average of sum with fill 0",
-			command: `SELECT moving_average(sum(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",12.5],["2010-07-01T18:47:02Z",12.5],["2010-07-01T18:47:04Z",32.5]]}]}]}`,
+			name:    "ungrouped query",
+			command: `SELECT value FROM cpu WHERE host = 'server02'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value","_series_id"],"values":[["2000-01-01T00:00:00Z",3,"4196f531ce64819b"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "series_id": []string{"true"}},
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can downsample data into another measurement.
+func TestServer_Query_SelectInto(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:30:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=useast value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=useast value=6 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:10:00Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "calculate moving average of sum with fill previous",
-			command: `SELECT moving_average(sum(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",25],["2010-07-01T18:47:04Z",45]]}]}]}`,
+			name:    "downsample into target measurement",
+			command: `SELECT mean(value) INTO "db0"."rp0"."cpu_1h" FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T02:00:00Z' GROUP BY time(1h), *`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"result","columns":["time","written","seriesWritten"],"values":[["1970-01-01T00:00:00Z",3,2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of first with fill 0",
-			command: `SELECT moving_average(first(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",5],["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",15]]}]}]}`,
+			name:    "read downsampled measurement",
+			command: `SELECT mean FROM cpu_1h GROUP BY *`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu_1h","tags":{"host":"server01","region":"uswest"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",2]]},{"name":"cpu_1h","tags":{"host":"server02","region":"useast"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T01:00:00Z",6]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of first with fill previous",
-			command: `SELECT moving_average(first(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",10],["2010-07-01T18:47:04Z",20]]}]}]}`,
+			name:    "downsample into default database and retention policy",
+			command: `SELECT max(value) INTO cpu_max FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T02:00:00Z' GROUP BY time(2h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"result","columns":["time","written","seriesWritten"],"values":[["1970-01-01T00:00:00Z",1,1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of last with fill 0",
-			command: `SELECT moving_average(last(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",7.5],["2010-07-01T18:47:02Z",7.5],["2010-07-01T18:47:04Z",17.5]]}]}]}`,
+			name:    "read ungrouped target measurement",
+			command: `SELECT * FROM cpu_max`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu_max","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",6]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of last with fill previous",
-			command: `SELECT moving_average(last(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+			name:    "downsample into measurements named after a tag",
+			command: `SELECT mean(value) INTO "rollup_:host:" FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T02:00:00Z' GROUP BY host, time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"result","columns":["time","written","seriesWritten"],"values":[["1970-01-01T00:00:00Z",3,2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of min with fill 0",
-			command: `SELECT moving_average(min(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",5],["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",15]]}]}]}`,
+			name:    "read measurements named after a tag",
+			command: `SELECT mean FROM /^rollup_/ GROUP BY *`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"rollup_server01","tags":{"host":"server01"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",2]]},{"name":"rollup_server02","tags":{"host":"server02"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T01:00:00Z",6]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of min with fill previous",
-			command: `SELECT moving_average(min(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",10],["2010-07-01T18:47:04Z",20]]}]}]}`,
+			name:    "downsample into measurements named after multiple tags",
+			command: `SELECT max(value) INTO "db0"."rp0".":region:_:host:" FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T02:00:00Z' GROUP BY time(2h), *`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"result","columns":["time","written","seriesWritten"],"values":[["1970-01-01T00:00:00Z",2,2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of max with fill 0",
-			command: `SELECT moving_average(max(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",7.5],["2010-07-01T18:47:02Z",7.5],["2010-07-01T18:47:04Z",17.5]]}]}]}`,
+			name:    "read measurements named after multiple tags",
+			command: `SELECT max FROM useast_server02, uswest_server01`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"useast_server02","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",6]]},{"name":"uswest_server01","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",3]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of max with fill previous",
-			command: `SELECT moving_average(max(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+			name:    "target tag not grouped by",
+			command: `SELECT mean(value) INTO "rollup_:region:" FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T02:00:00Z' GROUP BY host, time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"error":"tag region of INTO target is not in the GROUP BY clause"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of percentile with fill 0",
-			command: `SELECT moving_average(percentile(value, 50), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",5],["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",15]]}]}]}`,
+			name:    "target tag missing from wildcard dimensions",
+			command: `SELECT mean(value) INTO "rollup_:dc:" FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T02:00:00Z' GROUP BY time(1h), *`,
+			exp:     `{"results":[{"statement_id":0,"error":"tag dc of INTO target is not in the GROUP BY clause"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "calculate moving average of percentile with fill previous",
-			command: `SELECT moving_average(percentile(value, 50), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",10],["2010-07-01T18:47:04Z",20]]}]}]}`,
+			name:    "missing target retention policy",
+			command: `SELECT mean(value) INTO "db0"."rp1"."cpu_1h" FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T02:00:00Z' GROUP BY time(1h), *`,
+			exp:     `{"results":[{"statement_id":0,"error":"retention policy not found: rp1"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 	}...)
 
 	ctx := context.Background()
 	test.Run(ctx, t, s)
-} // Ensure the server correctly queries with an alias.
-func TestServer_Query_Alias(t *testing.T) {
+}
+
+// Ensure SELECT INTO with GROUP BY * writes every tag of the source series
+// to the points of the target measurement.
+func TestServer_Query_SelectInto_GroupByWildcard(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
 	writes := []string{
-		fmt.Sprintf("cpu value=1i,steps=3i %d", mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		fmt.Sprintf("cpu value=2i,steps=4i %d", mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01,region=uswest,dc=a value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01,region=uswest,dc=a value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=useast value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=useast value=6 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:06:00Z").UnixNano()),
 	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
 		&Write{data: strings.Join(writes, "\n")},
@@ -1114,44 +1674,77 @@ func TestServer_Query_Alias(t *testing.T) {
 
 	test.addQueries([]*Query{
 		{
-			name:    "baseline query - SELECT * FROM db0.rp0.cpu",
-			command: `SELECT * FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","steps","value"],"values":[["2000-01-01T00:00:00Z",3,1],["2000-01-01T00:01:00Z",4,2]]}]}]}`,
+			name:    "downsample every tag combination",
+			command: `SELECT mean(value) INTO rollup FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:10:00Z' GROUP BY *, time(5m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"result","columns":["time","written","seriesWritten"],"values":[["1970-01-01T00:00:00Z",3,2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "basic query with alias - SELECT steps, value as v FROM db0.rp0.cpu",
-			command: `SELECT steps, value as v FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","steps","v"],"values":[["2000-01-01T00:00:00Z",3,1],["2000-01-01T00:01:00Z",4,2]]}]}]}`,
+			name:    "target has the tag keys of the source",
+			command: `SHOW TAG KEYS FROM rollup`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"rollup","columns":["tagKey"],"values":[["dc"],["host"],["region"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "double aggregate sum - SELECT sum(value), sum(steps) FROM db0.rp0.cpu",
-			command: `SELECT sum(value), sum(steps) FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum","sum_1"],"values":[["1970-01-01T00:00:00Z",3,7]]}]}]}`,
+			name:    "target points have the tags of their source series",
+			command: `SELECT mean FROM rollup GROUP BY *`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"rollup","tags":{"dc":"","host":"server02","region":"useast"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:05:00Z",6]]},{"name":"rollup","tags":{"dc":"a","host":"server01","region":"uswest"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "double aggregate sum reverse order - SELECT sum(steps), sum(value) FROM db0.rp0.cpu",
-			command: `SELECT sum(steps), sum(value) FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum","sum_1"],"values":[["1970-01-01T00:00:00Z",7,3]]}]}]}`,
+			name:    "target series matches the source series",
+			command: `SHOW SERIES FROM rollup`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["key"],"values":[["rollup,dc=a,host=server01,region=uswest"],["rollup,host=server02,region=useast"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can interleave the points of every series in time order.
+func TestServer_Query_Interleave(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=uswest value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:05Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=uswest value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:15Z").UnixNano()),
+		fmt.Sprintf(`mem,host=server01 free=100i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:07Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "double aggregate sum with alias - SELECT sum(value) as sumv, sum(steps) as sums FROM db0.rp0.cpu",
-			command: `SELECT sum(value) as sumv, sum(steps) as sums FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sumv","sums"],"values":[["1970-01-01T00:00:00Z",3,7]]}]}]}`,
+			name:    "grouped series",
+			command: `SELECT value FROM cpu GROUP BY *`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","tags","value"],"values":[["2000-01-01T00:00:00Z","host=server01,region=uswest",1],["2000-01-01T00:00:05Z","host=server02,region=uswest",2],["2000-01-01T00:00:10Z","host=server01,region=uswest",3],["2000-01-01T00:00:15Z","host=server02,region=uswest",4]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "interleave": []string{"true"}},
 		},
 		{
-			name:    "double aggregate with same value - SELECT sum(value), mean(value) FROM db0.rp0.cpu",
-			command: `SELECT sum(value), mean(value) FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum","mean"],"values":[["1970-01-01T00:00:00Z",3,1.5]]}]}]}`,
+			name:    "grouped series descending",
+			command: `SELECT value FROM cpu GROUP BY host ORDER BY time DESC`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","tags","value"],"values":[["2000-01-01T00:00:15Z","host=server02",4],["2000-01-01T00:00:10Z","host=server01",3],["2000-01-01T00:00:05Z","host=server02",2],["2000-01-01T00:00:00Z","host=server01",1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "interleave": []string{"true"}},
 		},
 		{
-			name:    "double aggregate with same value and same alias - SELECT mean(value) as mv, max(value) as mv FROM db0.rp0.cpu",
-			command: `SELECT mean(value) as mv, max(value) as mv FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mv","mv"],"values":[["1970-01-01T00:00:00Z",1.5,2]]}]}]}`,
+			name:    "multiple measurements",
+			command: `SELECT value, free FROM cpu, mem GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["time","name","tags","value","free"],"values":[["2000-01-01T00:00:00Z","cpu","host=server01",1,null],["2000-01-01T00:00:05Z","cpu","host=server02",2,null],["2000-01-01T00:00:07Z","mem","host=server01",null,100],["2000-01-01T00:00:10Z","cpu","host=server01",3,null],["2000-01-01T00:00:15Z","cpu","host=server02",4,null]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "interleave": []string{"true"}},
 		},
 		{
-			name:    "double aggregate with non-existent field - SELECT mean(value), max(foo) FROM db0.rp0.cpu",
-			command: `SELECT mean(value), max(foo) FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean","max"],"values":[["1970-01-01T00:00:00Z",1.5,null]]}]}]}`,
+			name:    "no data",
+			command: `SELECT value FROM cpu WHERE time > '2001-01-01T00:00:00Z' GROUP BY *`,
+			exp:     `{"results":[{"statement_id":0}]}`,
+			params:  url.Values{"db": []string{"db0"}, "interleave": []string{"true"}},
 		},
 	}...)
 
@@ -1159,70 +1752,101 @@ func TestServer_Query_Alias(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server can handle various group by time cumulative sum queries.
-func TestServer_Query_SelectGroupByTimeCumulativeSum(t *testing.T) {
+// Ensure the server can turn the tag values of a series into columns.
+func TestServer_Query_Pivot(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=1,idle=90 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=uswest value=2,idle=80 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=3,idle=70 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=uswest value=4,idle=60 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `cpu value=10 1278010020000000000
-cpu value=15 1278010021000000000
-cpu value=20 1278010022000000000
-cpu value=25 1278010023000000000
-`},
+		&Write{data: strings.Join(writes, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "calculate cumulative sum of count",
-			command: `SELECT cumulative_sum(count(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",2],["2010-07-01T18:47:02Z",4]]}]}]}`,
-		},
-		{
-			name:    "calculate cumulative sum of mean",
-			command: `SELECT cumulative_sum(mean(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",12.5],["2010-07-01T18:47:02Z",35]]}]}]}`,
+			name:    "two hosts",
+			command: `SELECT value FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","server01","server02"],"values":[["2000-01-01T00:00:00Z",1,2],["2000-01-01T00:00:10Z",3,null],["2000-01-01T00:00:20Z",null,4]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "pivot": []string{"host"}},
 		},
 		{
-			name:    "calculate cumulative sum of median",
-			command: `SELECT cumulative_sum(median(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",12.5],["2010-07-01T18:47:02Z",35]]}]}]}`,
+			name:    "two hosts descending",
+			command: `SELECT value FROM cpu GROUP BY host ORDER BY time DESC`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","server01","server02"],"values":[["2000-01-01T00:00:20Z",null,4],["2000-01-01T00:00:10Z",3,null],["2000-01-01T00:00:00Z",1,2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "pivot": []string{"host"}},
 		},
 		{
-			name:    "calculate cumulative sum of mode",
-			command: `SELECT cumulative_sum(mode(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",30]]}]}]}`,
+			name:    "multiple fields",
+			command: `SELECT value, idle FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","server01.value","server01.idle","server02.value","server02.idle"],"values":[["2000-01-01T00:00:00Z",1,90,2,80],["2000-01-01T00:00:10Z",3,70,null,null],["2000-01-01T00:00:20Z",null,null,4,60]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "pivot": []string{"host"}},
 		},
 		{
-			name:    "calculate cumulative sum of sum",
-			command: `SELECT cumulative_sum(sum(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",25],["2010-07-01T18:47:02Z",70]]}]}]}`,
+			name:    "remaining tags",
+			command: `SELECT value FROM cpu GROUP BY *`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"region":"uswest"},"columns":["time","server01","server02"],"values":[["2000-01-01T00:00:00Z",1,2],["2000-01-01T00:00:10Z",3,null],["2000-01-01T00:00:20Z",null,4]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "pivot": []string{"host"}},
 		},
 		{
-			name:    "calculate cumulative sum of first",
-			command: `SELECT cumulative_sum(first(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",30]]}]}]}`,
+			name:    "pivot tag not grouped",
+			command: `SELECT value FROM cpu GROUP BY region`,
+			exp:     `{"results":[{"statement_id":0,"error":"pivot tag \"host\" must be in the GROUP BY clause"}]}`,
+			params:  url.Values{"db": []string{"db0"}, "pivot": []string{"host"}},
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can return the tags of each row as a JSON object.
+func TestServer_Query_TagsAsJSON(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02,region=useast value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01,region=uswest value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server03 value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "calculate cumulative sum of last",
-			command: `SELECT cumulative_sum(last(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",40]]}]}]}`,
+			name:    "raw select",
+			command: `SELECT value FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value","_tags"],"values":[["2000-01-01T00:00:00Z",1,"{\"host\":\"server01\",\"region\":\"uswest\"}"],["2000-01-01T00:00:00Z",2,"{\"host\":\"server02\",\"region\":\"useast\"}"],["2000-01-01T00:00:10Z",3,"{\"host\":\"server01\",\"region\":\"uswest\"}"],["2000-01-01T00:00:20Z",4,"{\"host\":\"server03\"}"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "tags_as_json": []string{"true"}},
 		},
 		{
-			name:    "calculate cumulative sum of min",
-			command: `SELECT cumulative_sum(min(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",30]]}]}]}`,
+			name:    "raw select grouped by tag",
+			command: `SELECT value FROM cpu GROUP BY region`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"region":""},"columns":["time","value","_tags"],"values":[["2000-01-01T00:00:20Z",4,"{\"host\":\"server03\"}"]]},{"name":"cpu","tags":{"region":"useast"},"columns":["time","value","_tags"],"values":[["2000-01-01T00:00:00Z",2,"{\"host\":\"server02\",\"region\":\"useast\"}"]]},{"name":"cpu","tags":{"region":"uswest"},"columns":["time","value","_tags"],"values":[["2000-01-01T00:00:00Z",1,"{\"host\":\"server01\",\"region\":\"uswest\"}"],["2000-01-01T00:00:10Z",3,"{\"host\":\"server01\",\"region\":\"uswest\"}"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "tags_as_json": []string{"true"}},
 		},
 		{
-			name:    "calculate cumulative sum of max",
-			command: `SELECT cumulative_sum(max(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",40]]}]}]}`,
+			name:    "raw select with limit and descending",
+			command: `SELECT value FROM cpu ORDER BY time DESC LIMIT 2`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value","_tags"],"values":[["2000-01-01T00:00:20Z",4,"{\"host\":\"server03\"}"],["2000-01-01T00:00:10Z",3,"{\"host\":\"server01\",\"region\":\"uswest\"}"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "tags_as_json": []string{"true"}},
 		},
 		{
-			name:    "calculate cumulative sum of percentile",
-			command: `SELECT cumulative_sum(percentile(value, 50)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",30]]}]}]}`,
+			name:    "aggregates are unchanged",
+			command: `SELECT sum(value) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",10]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "tags_as_json": []string{"true"}},
 		},
 	}...)
 
@@ -1230,118 +1854,107 @@ cpu value=25 1278010023000000000
 	test.Run(ctx, t, s)
 }
 
-// Ensure the server can handle various group by time cumulative sum queries with fill.
-func TestServer_Query_SelectGroupByTimeCumulativeSumWithFill(t *testing.T) {
+// Ensure the server truncates a result at the row limit of the query.
+func TestServer_Query_MaxRows(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01 value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=5 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `cpu value=10 1278010020000000000
-cpu value=20 1278010021000000000
-`},
+		&Write{data: strings.Join(writes, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "calculate cumulative sum of count with fill 0",
-			command: `SELECT cumulative_sum(count(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",2],["2010-07-01T18:47:02Z",2]]}]}]}`,
-		},
-		{
-			name:    "calculate cumulative sum of count with fill previous",
-			command: `SELECT cumulative_sum(count(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",2],["2010-07-01T18:47:02Z",4]]}]}]}`,
+			name:    "rows are truncated across series",
+			command: `SELECT value FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:00:10Z",2],["2000-01-01T00:00:20Z",3]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",4]]}],"truncated":true}]}`,
+			params:  url.Values{"db": []string{"db0"}, "max_rows": []string{"4"}},
 		},
 		{
-			name:    "calculate cumulative sum of mean with fill 0",
-			command: `SELECT cumulative_sum(mean(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",15]]}]}]}`,
+			name:    "rows are truncated at a series boundary",
+			command: `SELECT value FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:00:10Z",2],["2000-01-01T00:00:20Z",3]]}],"truncated":true}]}`,
+			params:  url.Values{"db": []string{"db0"}, "max_rows": []string{"3"}},
 		},
 		{
-			name:    "calculate cumulative sum of mean with fill previous",
-			command: `SELECT cumulative_sum(mean(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",30]]}]}]}`,
+			name:    "rows within the cap are not truncated",
+			command: `SELECT value FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:00:10Z",2],["2000-01-01T00:00:20Z",3]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:10Z",5]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "max_rows": []string{"5"}},
 		},
 		{
-			name:    "calculate cumulative sum of median with fill 0",
-			command: `SELECT cumulative_sum(median(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",15]]}]}]}`,
+			name:    "the cap is not a per series limit",
+			command: `SELECT value FROM cpu GROUP BY host LIMIT 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1]]}],"truncated":true}]}`,
+			params:  url.Values{"db": []string{"db0"}, "max_rows": []string{"1"}},
 		},
 		{
-			name:    "calculate cumulative sum of median with fill previous",
-			command: `SELECT cumulative_sum(median(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",30]]}]}]}`,
+			name:    "pivoted rows are truncated",
+			command: `SELECT value FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","server01","server02"],"values":[["2000-01-01T00:00:00Z",1,4],["2000-01-01T00:00:10Z",2,5]]}],"truncated":true}]}`,
+			params:  url.Values{"db": []string{"db0"}, "max_rows": []string{"2"}, "pivot": []string{"host"}},
 		},
 		{
-			name:    "calculate cumulative sum of mode with fill 0",
-			command: `SELECT cumulative_sum(mode(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",10]]}]}]}`,
+			name:    "interleaved rows are truncated",
+			command: `SELECT value FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","tags","value"],"values":[["2000-01-01T00:00:00Z","host=server01",1],["2000-01-01T00:00:00Z","host=server02",4],["2000-01-01T00:00:10Z","host=server01",2]]}],"truncated":true}]}`,
+			params:  url.Values{"db": []string{"db0"}, "max_rows": []string{"3"}, "interleave": []string{"true"}},
 		},
 		{
-			name:    "calculate cumulative sum of mode with fill previous",
-			command: `SELECT cumulative_sum(mode(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "union rows are truncated",
+			command: `SELECT value FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"tags":{"host":"server01"},"columns":["time","name","value"],"values":[["2000-01-01T00:00:00Z","cpu",1],["2000-01-01T00:00:10Z","cpu",2],["2000-01-01T00:00:20Z","cpu",3]]},{"tags":{"host":"server02"},"columns":["time","name","value"],"values":[["2000-01-01T00:00:00Z","cpu",4]]}],"truncated":true}]}`,
+			params:  url.Values{"db": []string{"db0"}, "max_rows": []string{"4"}, "union_columns": []string{"true"}},
 		},
 		{
-			name:    "calculate cumulative sum of sum with fill 0",
-			command: `SELECT cumulative_sum(sum(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",30],["2010-07-01T18:47:02Z",30]]}]}]}`,
-		},
-		{
-			name:    "calculate cumulative sum of sum with fill previous",
-			command: `SELECT cumulative_sum(sum(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",30],["2010-07-01T18:47:02Z",60]]}]}]}`,
-		},
-		{
-			name:    "calculate cumulative sum of first with fill 0",
-			command: `SELECT cumulative_sum(first(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",10]]}]}]}`,
-		},
-		{
-			name:    "calculate cumulative sum of first with fill previous",
-			command: `SELECT cumulative_sum(first(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",20]]}]}]}`,
-		},
-		{
-			name:    "calculate cumulative sum of last with fill 0",
-			command: `SELECT cumulative_sum(last(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",20],["2010-07-01T18:47:02Z",20]]}]}]}`,
-		},
-		{
-			name:    "calculate cumulative sum of last with fill previous",
-			command: `SELECT cumulative_sum(last(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",20],["2010-07-01T18:47:02Z",40]]}]}]}`,
-		},
-		{
-			name:    "calculate cumulative sum of min with fill 0",
-			command: `SELECT cumulative_sum(min(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",10]]}]}]}`,
-		},
-		{
-			name:    "calculate cumulative sum of min with fill previous",
-			command: `SELECT cumulative_sum(min(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",20]]}]}]}`,
-		},
-		{
-			name:    "calculate cumulative sum of max with fill 0",
-			command: `SELECT cumulative_sum(max(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",20],["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "rows with tags as json are truncated",
+			command: `SELECT value FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value","_tags"],"values":[["2000-01-01T00:00:00Z",1,"{\"host\":\"server01\"}"],["2000-01-01T00:00:00Z",4,"{\"host\":\"server02\"}"]]}],"truncated":true}]}`,
+			params:  url.Values{"db": []string{"db0"}, "max_rows": []string{"2"}, "tags_as_json": []string{"true"}},
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can echo the time range a query resolved to.
+func TestServer_Query_EchoRange(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: fmt.Sprintf(`cpu value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano())},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "calculate cumulative sum of max with fill previous",
-			command: `SELECT cumulative_sum(max(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",20],["2010-07-01T18:47:02Z",40]]}]}]}`,
+			name:    "echo the resolved time range",
+			command: `SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:00Z' + 1h`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:10Z",1]]}],"messages":[{"level":"info","text":"time range: [2000-01-01T00:00:00Z, 2000-01-01T01:00:00Z)"}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "echo_range": []string{"true"}},
 		},
 		{
-			name:    "calculate cumulative sum of percentile with fill 0",
-			command: `SELECT cumulative_sum(percentile(value, 50)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",10]]}]}]}`,
+			name:    "echo the time range of an empty result",
+			command: `SELECT mean(value) FROM cpu WHERE time >= '2001-01-01T00:00:00Z' AND time <= '2001-01-01T00:00:30Z' GROUP BY time(10s)`,
+			exp:     `{"results":[{"statement_id":0,"messages":[{"level":"info","text":"time range: [2001-01-01T00:00:00Z, 2001-01-01T00:00:30.000000001Z)"}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "echo_range": []string{"true"}},
 		},
 		{
-			name:    "calculate cumulative sum of percentile with fill previous",
-			command: `SELECT cumulative_sum(percentile(value, 50)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",20]]}]}]}`,
+			name:    "the time range is not echoed by default",
+			command: `SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:10Z",1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 	}...)
 
@@ -1349,24 +1962,46 @@ cpu value=20 1278010021000000000
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Aggregates_Int(t *testing.T) {
+// Ensure holt_winters_bounds adds confidence bounds around the forecast of
+// holt_winters().
+func TestServer_Query_HoltWintersBounds(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	writes := []string{
+		fmt.Sprintf(`cpu value=10 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=12 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=11 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:02:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=14 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:03:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=13 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:04:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=16 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:05:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=15 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:06:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=18 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:07:00Z").UnixNano()),
+	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join([]string{
-			fmt.Sprintf(`int value=45 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		}, "\n")},
+		&Write{data: strings.Join(writes, "\n")},
 	}
 
 	test.addQueries([]*Query{
-		// int64
 		{
-			name:    "stddev with just one point - int",
+			name:    "forecast with bounds",
+			command: `SELECT holt_winters(mean(value), 3, 0) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:08:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","holt_winters","holt_winters_upper","holt_winters_lower"],"values":[["2000-01-01T00:08:00Z",19.74421429055512,21.451846304571447,18.036582276538795],["2000-01-01T00:09:00Z",22.38341564327035,24.79837199703472,19.96845928950598],["2000-01-01T00:10:00Z",25.81733523491407,28.775040643821512,22.859629826006625]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "holt_winters_bounds": []string{"true"}},
+		},
+		{
+			name:    "bounds are named after the field alias",
+			command: `SELECT holt_winters(mean(value), 1, 0) AS forecast FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:08:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","forecast","forecast_upper","forecast_lower"],"values":[["2000-01-01T00:08:00Z",19.74421429055512,21.451846304571447,18.036582276538795]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "holt_winters_bounds": []string{"true"}},
+		},
+		{
+			name:    "forecast without bounds by default",
+			command: `SELECT holt_winters(mean(value), 3, 0) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:08:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","holt_winters"],"values":[["2000-01-01T00:08:00Z",19.74421429055512],["2000-01-01T00:09:00Z",22.38341564327035],["2000-01-01T00:10:00Z",25.81733523491407]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT STDDEV(value) FROM int`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"int","columns":["time","stddev"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
 		},
 	}...)
 
@@ -1374,26 +2009,46 @@ func TestServer_Query_Aggregates_Int(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_MathWithFill(t *testing.T) {
+// Ensure the server can handle various group by time integral queries.
+func TestServer_Query_SelectGroupByTimeIntegral(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `cpu value=15 1278010020000000000
+		&Write{data: `cpu value=10 1278010020000000000
+cpu value=15 1278010021000000000
+cpu value=20 1278010022000000000
+cpu value=25 1278010023000000000
+cpu value=40 1278010026000000000
 `},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "multiplication with fill previous",
-			command: `SELECT 4*mean(value) FROM db0.rp0.cpu WHERE time >= '2010-07-01 18:47:00' AND time < '2010-07-01 18:48:30' GROUP BY time(30s) FILL(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean"],"values":[["2010-07-01T18:47:00Z",60],["2010-07-01T18:47:30Z",60],["2010-07-01T18:48:00Z",60]]}]}]}`,
+			name:    "calculate integral of the whole series",
+			command: `SELECT integral(value) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:06'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","integral"],"values":[["1970-01-01T00:00:00Z",150]]}]}]}`,
 		},
 		{
-			name:    "multiplication of mode value with fill previous",
-			command: `SELECT 4*mode(value) FROM db0.rp0.cpu WHERE time >= '2010-07-01 18:47:00' AND time < '2010-07-01 18:48:30' GROUP BY time(30s) FILL(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mode"],"values":[["2010-07-01T18:47:00Z",60],["2010-07-01T18:47:30Z",60],["2010-07-01T18:48:00Z",60]]}]}]}`,
+			name:    "calculate integral with unit 10s",
+			command: `SELECT integral(value, 10s) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:06'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","integral"],"values":[["1970-01-01T00:00:00Z",15]]}]}]}`,
+		},
+		{
+			name:    "calculate integral group by time",
+			command: `SELECT integral(value) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time < '2010-07-01 18:47:08' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","integral"],"values":[["2010-07-01T18:47:00Z",30],["2010-07-01T18:47:02Z",50],["2010-07-01T18:47:04Z",null],["2010-07-01T18:47:06Z",0]]}]}]}`,
+		},
+		{
+			name:    "calculate integral group by time with fill",
+			command: `SELECT integral(value) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time < '2010-07-01 18:47:08' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","integral"],"values":[["2010-07-01T18:47:00Z",30],["2010-07-01T18:47:02Z",50],["2010-07-01T18:47:04Z",0],["2010-07-01T18:47:06Z",0]]}]}]}`,
+		},
+		{
+			name:    "calculate integral group by time with a single point in a bucket",
+			command: `SELECT integral(value) from db0.rp0.cpu where time >= '2010-07-01 18:47:03' and time < '2010-07-01 18:47:08' group by time(3s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","integral"],"values":[["2010-07-01T18:47:03Z",97.5],["2010-07-01T18:47:06Z",0]]}]}]}`,
 		},
 	}...)
 
@@ -1401,41 +2056,65 @@ func TestServer_Query_MathWithFill(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-// mergeMany ensures that when merging many series together and some of them have a different number
-// of points than others in a group by interval the results are correct
-func TestServer_Query_MergeMany(t *testing.T) {
+// Ensure the server can query with the count aggregate function
+func TestServer_Query_Count(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
-	test := NewTest("db0", "rp0")
+	now := now()
 
-	var writes []string
-	for i := 1; i < 11; i++ {
-		for j := 1; j < 5+i%3; j++ {
-			data := fmt.Sprintf(`cpu,host=server_%d value=22 %d`, i, time.Unix(int64(j), int64(0)).UTC().UnixNano())
-			writes = append(writes, data)
-		}
+	test := NewTest("db0", "rp0")
+	writes := []string{
+		`cpu,host=server01 value=1.0 ` + strconv.FormatInt(now.UnixNano(), 10),
+		`ram value1=1.0,value2=2.0 ` + strconv.FormatInt(now.UnixNano(), 10),
 	}
+
 	test.writes = Writes{
 		&Write{data: strings.Join(writes, "\n")},
 	}
 
+	hourAgo := now.Add(-time.Hour).UTC()
+
 	test.addQueries([]*Query{
 		{
-			name:    "GROUP by time",
-			command: `SELECT count(value) FROM db0.rp0.cpu WHERE time >= '1970-01-01T00:00:01Z' AND time <= '1970-01-01T00:00:06Z' GROUP BY time(1s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:01Z",10],["1970-01-01T00:00:02Z",10],["1970-01-01T00:00:03Z",10],["1970-01-01T00:00:04Z",10],["1970-01-01T00:00:05Z",7],["1970-01-01T00:00:06Z",3]]}]}]}`,
+			name:    "selecting count(value) should succeed",
+			command: `SELECT count(value) FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
 		},
 		{
-			skip:    "Skipped OSS",
-			name:    "GROUP by tag - FIXME issue #2875",
-			command: `SELECT count(value) FROM db0.rp0.cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:00Z' group by host`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","count"],"values":[["2000-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","count"],"values":[["2000-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server03"},"columns":["time","count"],"values":[["2000-01-01T00:00:00Z",1]]}]}]}`,
+			name:    "selecting count(value) with where time should return result",
+			command: fmt.Sprintf(`SELECT count(value) FROM db0.rp0.cpu WHERE time >= '%s'`, hourAgo.Format(time.RFC3339Nano)),
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["%s",1]]}]}]}`, hourAgo.Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "GROUP by field",
-			command: `SELECT count(value) FROM db0.rp0.cpu group by value`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"value":""},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",50]]}]}]}`,
+			name:    "selecting count(value) with filter that excludes all results should return 0",
+			command: fmt.Sprintf(`SELECT count(value) FROM db0.rp0.cpu WHERE value=100 AND time >= '%s'`, hourAgo.Format(time.RFC3339Nano)),
+			exp:     `{"results":[{"statement_id":0}]}`,
+		},
+		{
+			name:    "selecting count(value1) with matching filter against value2 should return correct result",
+			command: fmt.Sprintf(`SELECT count(value1) FROM db0.rp0.ram WHERE value2=2 AND time >= '%s'`, hourAgo.Format(time.RFC3339Nano)),
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"ram","columns":["time","count"],"values":[["%s",1]]}]}]}`, hourAgo.Format(time.RFC3339Nano)),
+		},
+		{
+			name:    "selecting count(value1) with non-matching filter against value2 should return correct result",
+			command: fmt.Sprintf(`SELECT count(value1) FROM db0.rp0.ram WHERE value2=3 AND time >= '%s'`, hourAgo.Format(time.RFC3339Nano)),
+			exp:     `{"results":[{"statement_id":0}]}`,
+		},
+		{
+			name:    "selecting count(*) should expand the wildcard",
+			command: `SELECT count(*) FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+		},
+		{
+			name:    "selecting count(2) should error",
+			command: `SELECT count(2) FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"error":"expected field argument in count()"}]}`,
+		},
+		{
+			name:    "selecting an aggregate of an aggregate should error",
+			command: `SELECT mean(sum(value)) FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"error":"aggregate function cannot be used as an argument to another aggregate; use a subquery"}]}`,
 		},
 	}...)
 
@@ -1443,26 +2122,26 @@ func TestServer_Query_MergeMany(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_SelectGroupByTime_MultipleAggregates(t *testing.T) {
-	s := OpenServer(t)
+// Ensure the server can limit concurrent series.
+func TestServer_Query_MaxSelectSeriesN(t *testing.T) {
+	s := OpenServer(t, func(o *launcher.InfluxdOpts) {
+		o.CoordinatorConfig.MaxSelectSeriesN = 3
+	})
 	defer s.Close()
 
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `test,t=a x=1i 1000000000
-test,t=b y=1i 1000000000
-test,t=a x=2i 2000000000
-test,t=b y=2i 2000000000
-test,t=a x=3i 3000000000
-test,t=b y=3i 3000000000
-`},
+		&Write{data: `cpu,host=server01 value=1.0 0`},
+		&Write{data: `cpu,host=server02 value=1.0 0`},
+		&Write{data: `cpu,host=server03 value=1.0 0`},
+		&Write{data: `cpu,host=server04 value=1.0 0`},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "two aggregates with a group by host",
-			command: `SELECT mean(x) as x, mean(y) as y from db0.rp0.test where time >= 1s and time < 4s group by t, time(1s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"test","tags":{"t":"a"},"columns":["time","x","y"],"values":[["1970-01-01T00:00:01Z",1,null],["1970-01-01T00:00:02Z",2,null],["1970-01-01T00:00:03Z",3,null]]},{"name":"test","tags":{"t":"b"},"columns":["time","x","y"],"values":[["1970-01-01T00:00:01Z",null,1],["1970-01-01T00:00:02Z",null,2],["1970-01-01T00:00:03Z",null,3]]}]}]}`,
+			name:    "exceeed max series",
+			command: `SELECT COUNT(value) FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"error":"max-select-series limit exceeded: (4/3)"}]}`,
 		},
 	}...)
 
@@ -1470,55 +2149,39 @@ test,t=b y=3i 3000000000
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Regex(t *testing.T) {
-	s := OpenServer(t)
+// Ensure the server rejects regular expressions above the configured complexity.
+func TestServer_Query_MaxRegexComplexity(t *testing.T) {
+	s := OpenServer(t, func(o *launcher.InfluxdOpts) {
+		o.CoordinatorConfig.MaxRegexComplexity = 500
+	})
 	defer s.Close()
 
-	writes := []string{
-		fmt.Sprintf(`cpu1,host=server01 value=10 %d`, mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
-		fmt.Sprintf(`cpu2,host=server01 value=20 %d`, mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
-		fmt.Sprintf(`cpu3,host=server01 value=30 %d`, mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
-	}
-
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join(writes, "\n")},
+		&Write{data: `cpu,host=server01 value=1.0 0`},
+		&Write{data: `cpu,host=server02 value=2.0 0`},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "default db and rp",
-			command: `SELECT * FROM /cpu[13]/`,
-			params:  url.Values{"db": []string{"db0"}},
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","host","value"],"values":[["2015-02-28T01:03:36.703820946Z","server01",10]]},{"name":"cpu3","columns":["time","host","value"],"values":[["2015-02-28T01:03:36.703820946Z","server01",30]]}]}]}`,
-		},
-		{
-			name:    "default db and rp with GROUP BY *",
-			command: `SELECT * FROM /cpu[13]/ GROUP BY *`,
-			params:  url.Values{"db": []string{"db0"}},
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",10]]},{"name":"cpu3","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",30]]}]}]}`,
-		},
-		{
-			name:    "specifying db and rp",
-			command: `SELECT * FROM db0.rp0./cpu[13]/ GROUP BY *`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",10]]},{"name":"cpu3","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",30]]}]}]}`,
+			name:    "simple regex in the condition",
+			command: `SELECT value FROM db0.rp0.cpu WHERE host =~ /server0[1]/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
 		},
 		{
-			name:    "default db and specified rp",
-			command: `SELECT * FROM rp0./cpu[13]/ GROUP BY *`,
-			params:  url.Values{"db": []string{"db0"}},
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",10]]},{"name":"cpu3","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",30]]}]}]}`,
+			name:    "nested repetition in the condition",
+			command: `SELECT value FROM db0.rp0.cpu WHERE host =~ /(a{30}){30}/`,
+			exp:     `{"results":[{"statement_id":0,"error":"regular expression too complex"}]}`,
 		},
 		{
-			name:    "specified db and default rp",
-			command: `SELECT * FROM db0../cpu[13]/ GROUP BY *`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",10]]},{"name":"cpu3","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",30]]}]}]}`,
+			name:    "nested repetition in the sources",
+			command: `SELECT value FROM db0.rp0./(c{30}){30}/`,
+			exp:     `{"results":[{"statement_id":0,"error":"regular expression too complex"}]}`,
 		},
 		{
-			name:    "map field type with a regex source",
-			command: `SELECT value FROM /cpu[13]/`,
-			params:  url.Values{"db": []string{"db0"}},
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",10]]},{"name":"cpu3","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",30]]}]}]}`,
+			name:    "nested repetition in a subquery",
+			command: `SELECT value FROM (SELECT value FROM db0.rp0.cpu WHERE host =~ /(a{30}){30}/)`,
+			exp:     `{"results":[{"statement_id":0,"error":"regular expression too complex"}]}`,
 		},
 	}...)
 
@@ -1526,37 +2189,43 @@ func TestServer_Query_Regex(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Aggregates_Load(t *testing.T) {
-	s := OpenServer(t)
+// Ensure the server reads wide time ranges from the configured rollup
+// measurement when auto_rollup is requested.
+func TestServer_Query_AutoRollup(t *testing.T) {
+	s := OpenServer(t, func(o *launcher.InfluxdOpts) {
+		o.CoordinatorConfig.Rollups = []string{"cpu:cpu_1h:1d"}
+	})
 	defer s.Close()
 
+	writes := []string{
+		fmt.Sprintf(`cpu value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:30:00Z").UnixNano()),
+		fmt.Sprintf(`cpu_1h value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join([]string{
-			fmt.Sprintf(`load,region=us-east,host=serverA value=20.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-			fmt.Sprintf(`load,region=us-east,host=serverB value=30.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
-			fmt.Sprintf(`load,region=us-west,host=serverC value=100.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		}, "\n")},
+		&Write{data: strings.Join(writes, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "group by multiple dimensions",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT sum(value) FROM load GROUP BY region, host`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"load","tags":{"host":"serverA","region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",20]]},{"name":"load","tags":{"host":"serverB","region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",30]]},{"name":"load","tags":{"host":"serverC","region":"us-west"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",100]]}]}]}`,
+			name:    "wide time range reads the rollup",
+			params:  url.Values{"db": []string{"db0"}, "auto_rollup": []string{"true"}},
+			command: `SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-03T00:00:00Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
 		},
 		{
-			name:    "group by multiple dimensions",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT sum(value)*2 FROM load`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"load","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",300]]}]}]}`,
+			name:    "narrow time range reads the raw data",
+			params:  url.Values{"db": []string{"db0"}, "auto_rollup": []string{"true"}},
+			command: `SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:30:00Z",3]]}]}]}`,
 		},
 		{
-			name:    "group by multiple dimensions",
+			name:    "wide time range without auto_rollup reads the raw data",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT sum(value)/2 FROM load`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"load","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",75]]}]}]}`,
+			command: `SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-03T00:00:00Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:30:00Z",3]]}]}]}`,
 		},
 	}...)
 
@@ -1564,36 +2233,38 @@ func TestServer_Query_Aggregates_Load(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_SLimitAndSOffset(t *testing.T) {
+// Ensure the server can query with Now().
+func TestServer_Query_Now(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
-	test := NewTest("db0", "rp0")
+	now := now()
 
-	var writes []string
-	for i := 1; i < 10; i++ {
-		data := fmt.Sprintf(`cpu,region=us-east,host=server-%d value=%d %d`, i, i, time.Unix(int64(i), int64(0)).UnixNano())
-		writes = append(writes, data)
-	}
+	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join(writes, "\n")},
+		&Write{data: `cpu,host=server01 value=1.0 ` + strconv.FormatInt(now.UnixNano(), 10)},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "SLIMIT 2 SOFFSET 1",
-			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 2 SOFFSET 1`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server-2","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-3","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+			name:    "where with time < now() should work",
+			command: `SELECT * FROM db0.rp0.cpu where time < now()`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","value"],"values":[["%s","server01",1]]}]}]}`, now.Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "SLIMIT 2 SOFFSET 3",
-			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 2 SOFFSET 3`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server-4","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-5","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+			name:    "where with time < now() and GROUP BY * should work",
+			command: `SELECT * FROM db0.rp0.cpu where time < now() GROUP BY *`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["%s",1]]}]}]}`, now.Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "SLIMIT 3 SOFFSET 8",
-			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 3 SOFFSET 8`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server-9","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+			name:    "where with time > now() should return an empty result",
+			command: `SELECT * FROM db0.rp0.cpu where time > now()`,
+			exp:     `{"results":[{"statement_id":0}]}`,
+		},
+		{
+			name:    "where with time > now() with GROUP BY * should return an empty result",
+			command: `SELECT * FROM db0.rp0.cpu where time > now() GROUP BY *`,
+			exp:     `{"results":[{"statement_id":0}]}`,
 		},
 	}...)
 
@@ -1601,26 +2272,61 @@ func TestServer_Query_SLimitAndSOffset(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_CumulativeCount(t *testing.T) {
+// Ensure the server can align time predicates with truncate().
+func TestServer_Query_Truncate(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: `events signup=t 1005832000
-events signup=t 1048283000
-events signup=t 1784832000
-events signup=t 2000000000
-events signup=t 3084890000
-events signup=t 3838400000
-`},
+		&Write{data: fmt.Sprintf(`cpu value=1 %d
+cpu value=2 %d
+cpu value=3 %d
+cpu value=4 %d
+`,
+			mustParseTime(time.RFC3339Nano, "2000-01-01T22:59:00Z").UnixNano(),
+			mustParseTime(time.RFC3339Nano, "2000-01-01T23:00:00Z").UnixNano(),
+			mustParseTime(time.RFC3339Nano, "2000-01-01T23:35:00Z").UnixNano(),
+			mustParseTime(time.RFC3339Nano, "2000-01-02T00:10:00Z").UnixNano(),
+		)},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "cumulative count",
-			command: `SELECT cumulative_sum(count(signup)) from db0.rp0.events where time >= 1s and time < 4s group by time(1s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"events","columns":["time","cumulative_sum"],"values":[["1970-01-01T00:00:01Z",3],["1970-01-01T00:00:02Z",4],["1970-01-01T00:00:03Z",6]]}]}]}`,
+			name:    "where time is truncated to the hour",
+			command: `SELECT value FROM cpu WHERE time >= truncate('2000-01-01T23:35:00Z', 1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T23:00:00Z",2],["2000-01-01T23:35:00Z",3],["2000-01-02T00:10:00Z",4]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "where time is truncated to the day",
+			command: `SELECT value FROM cpu WHERE time >= truncate('2000-01-02T00:10:00Z', 1d)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-02T00:10:00Z",4]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "where time is truncated to the hour in the query time zone",
+			command: `SELECT value FROM cpu WHERE time >= truncate('2000-01-01T23:35:00Z', 1h) TZ('Asia/Kolkata')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-02T05:05:00+05:30",3],["2000-01-02T05:40:00+05:30",4]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "where time is truncated from now()",
+			command: `SELECT value FROM cpu WHERE time >= truncate(now(), 1h)`,
+			exp:     `{"results":[{"statement_id":0}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "select truncated time",
+			command: `SELECT truncate(time, 30m) AS bucket, value FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bucket","value"],"values":[["2000-01-01T22:59:00Z","2000-01-01T22:30:00Z",1],["2000-01-01T23:00:00Z","2000-01-01T23:00:00Z",2],["2000-01-01T23:35:00Z","2000-01-01T23:30:00Z",3],["2000-01-02T00:10:00Z","2000-01-02T00:00:00Z",4]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "truncate requires a duration",
+			command: `SELECT value FROM cpu WHERE time >= truncate('2000-01-01T23:35:00Z', 'x')`,
+			exp:     `{"results":[{"statement_id":0,"error":"second argument to truncate must be a duration, got *influxql.StringLiteral"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
 		},
 	}...)
 
@@ -1628,61 +2334,54 @@ events signup=t 3838400000
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Aggregates_String(t *testing.T) {
+// Ensure the server can query with epoch precisions.
+func TestServer_Query_EpochPrecision(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	now := now()
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join([]string{
-			fmt.Sprintf(`stringdata value="first" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
-			fmt.Sprintf(`stringdata value="last" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:04Z").UnixNano()),
-		}, "\n")},
+		&Write{data: `cpu,host=server01 value=1.0 ` + strconv.FormatInt(now.UnixNano(), 10)},
 	}
 
 	test.addQueries([]*Query{
-		// strings
 		{
-			name:    "STDDEV on string data - string",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT STDDEV(value) FROM stringdata`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","stddev"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
-			skip:    "Skipped OSS", // FIXME(benbjohnson): allow non-float var ref expr in cursor iterator
+			name:    "nanosecond precision",
+			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
+			params:  url.Values{"epoch": []string{"n"}},
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()),
 		},
 		{
-			name:    "MEAN on string data - string",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT MEAN(value) FROM stringdata`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",0]]}]}]}`,
-			skip:    "Skipped OSS", // FIXME(benbjohnson): allow non-float var ref expr in cursor iterator
+			name:    "microsecond precision",
+			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
+			params:  url.Values{"epoch": []string{"u"}},
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()/int64(time.Microsecond)),
 		},
 		{
-			name:    "MEDIAN on string data - string",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT MEDIAN(value) FROM stringdata`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
-			skip:    "Skipped OSS", // FIXME(benbjohnson): allow non-float var ref expr in cursor iterator
+			name:    "millisecond precision",
+			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
+			params:  url.Values{"epoch": []string{"ms"}},
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()/int64(time.Millisecond)),
 		},
 		{
-			name:    "COUNT on string data - string",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT COUNT(value) FROM stringdata`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
-			skip:    "Skipped OSS", // FIXME(benbjohnson): allow non-float var ref expr in cursor iterator
+			name:    "second precision",
+			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
+			params:  url.Values{"epoch": []string{"s"}},
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()/int64(time.Second)),
 		},
 		{
-			name:    "FIRST on string data - string",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT FIRST(value) FROM stringdata`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","first"],"values":[["2000-01-01T00:00:03Z","first"]]}]}]}`,
-			skip:    "Skipped OSS", // FIXME(benbjohnson): allow non-float var ref expr in cursor iterator
+			name:    "minute precision",
+			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
+			params:  url.Values{"epoch": []string{"m"}},
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()/int64(time.Minute)),
 		},
 		{
-			name:    "LAST on string data - string",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT LAST(value) FROM stringdata`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","last"],"values":[["2000-01-01T00:00:04Z","last"]]}]}]}`,
-			skip:    "Skipped OSS", // FIXME(benbjohnson): allow non-float var ref expr in cursor iterator
+			name:    "hour precision",
+			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
+			params:  url.Values{"epoch": []string{"h"}},
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[[%d,1]]}]}]}`, now.UnixNano()/int64(time.Hour)),
 		},
 	}...)
 
@@ -1690,20 +2389,29 @@ func TestServer_Query_Aggregates_String(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_AggregateSelectors(t *testing.T) {
+// Ensure the server works with tag queries.
+func TestServer_Query_Tags(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	now := now()
+
 	writes := []string{
-		fmt.Sprintf(`network,host=server01,region=west,core=1 rx=10i,tx=20i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		fmt.Sprintf(`network,host=server02,region=west,core=2 rx=40i,tx=50i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
-		fmt.Sprintf(`network,host=server03,region=east,core=3 rx=40i,tx=55i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
-		fmt.Sprintf(`network,host=server04,region=east,core=4 rx=40i,tx=60i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
-		fmt.Sprintf(`network,host=server05,region=west,core=1 rx=50i,tx=70i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
-		fmt.Sprintf(`network,host=server06,region=east,core=2 rx=50i,tx=40i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
-		fmt.Sprintf(`network,host=server07,region=west,core=3 rx=70i,tx=30i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
-		fmt.Sprintf(`network,host=server08,region=east,core=4 rx=90i,tx=10i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
-		fmt.Sprintf(`network,host=server09,region=east,core=1 rx=5i,tx=4i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:20Z").UnixNano()),
+		fmt.Sprintf("cpu,host=server01 value=100,core=4 %d", now.UnixNano()),
+		fmt.Sprintf("cpu,host=server02 value=50,core=2 %d", now.Add(1).UnixNano()),
+
+		fmt.Sprintf("cpu1,host=server01,region=us-west value=100 %d", mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
+		fmt.Sprintf("cpu1,host=server02 value=200 %d", mustParseTime(time.RFC3339Nano, "2010-02-28T01:03:37.703820946Z").UnixNano()),
+		fmt.Sprintf("cpu1,host=server03 value=300 %d", mustParseTime(time.RFC3339Nano, "2012-02-28T01:03:38.703820946Z").UnixNano()),
+
+		fmt.Sprintf("cpu2,host=server01 value=100 %d", mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
+		fmt.Sprintf("cpu2 value=200 %d", mustParseTime(time.RFC3339Nano, "2012-02-28T01:03:38.703820946Z").UnixNano()),
+
+		fmt.Sprintf("cpu3,company=acme01 value=100 %d", mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
+		fmt.Sprintf("cpu3 value=200 %d", mustParseTime(time.RFC3339Nano, "2012-02-28T01:03:38.703820946Z").UnixNano()),
+
+		fmt.Sprintf("status_code,url=http://www.example.com value=404 %d", mustParseTime(time.RFC3339Nano, "2015-07-22T08:13:54.929026672Z").UnixNano()),
+		fmt.Sprintf("status_code,url=https://influxdb.com value=418 %d", mustParseTime(time.RFC3339Nano, "2015-07-22T09:52:24.914395083Z").UnixNano()),
 	}
 
 	test := NewTest("db0", "rp0")
@@ -1713,267 +2421,2446 @@ func TestServer_Query_AggregateSelectors(t *testing.T) {
 
 	test.addQueries([]*Query{
 		{
-			name:    "baseline",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT * FROM network`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","core","core_1","host","region","rx","tx"],"values":[["2000-01-01T00:00:00Z",2,"1","server01","west",10,20],["2000-01-01T00:00:10Z",3,"2","server02","west",40,50],["2000-01-01T00:00:20Z",4,"3","server03","east",40,55],["2000-01-01T00:00:30Z",1,"4","server04","east",40,60],["2000-01-01T00:00:40Z",2,"1","server05","west",50,70],["2000-01-01T00:00:50Z",3,"2","server06","east",50,40],["2000-01-01T00:01:00Z",4,"3","server07","west",70,30],["2000-01-01T00:01:10Z",1,"4","server08","east",90,10],["2000-01-01T00:01:20Z",2,"1","server09","east",5,4]]}]}]}`,
+			name:    "tag without field should stream tag values",
+			command: `SELECT host FROM db0.rp0.cpu`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host"],"values":[["%s","server01"],["%s","server02"]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "max - baseline 30s",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT max(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",90]]}]}]}`,
+			name:    "field with tag should succeed",
+			command: `SELECT host, value FROM db0.rp0.cpu`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","value"],"values":[["%s","server01",100],["%s","server02",50]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "max - baseline 30s - epoch ms",
-			params:  url.Values{"db": []string{"db0"}, "epoch": []string{"ms"}},
-			command: `SELECT max(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp: fmt.Sprintf(
-				`{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max"],"values":[[%d,40],[%d,50],[%d,90]]}]}]}`,
-				mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()/int64(time.Millisecond),
-				mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()/int64(time.Millisecond),
-				mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()/int64(time.Millisecond),
-			),
+			name:    "field with tag and GROUP BY should succeed",
+			command: `SELECT host, value FROM db0.rp0.cpu GROUP BY host`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","host","value"],"values":[["%s","server01",100]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","host","value"],"values":[["%s","server02",50]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "max - tx",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, max(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","max"],"values":[["2000-01-01T00:00:00Z",50,40],["2000-01-01T00:00:30Z",70,50],["2000-01-01T00:01:00Z",10,90]]}]}]}`,
+			name:    "field with two tags should succeed",
+			command: `SELECT host, value, core FROM db0.rp0.cpu`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","value","core"],"values":[["%s","server01",100,4],["%s","server02",50,2]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "max - time",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, max(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",90]]}]}]}`,
+			name:    "field with two tags and GROUP BY should succeed",
+			command: `SELECT host, value, core FROM db0.rp0.cpu GROUP BY host`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","host","value","core"],"values":[["%s","server01",100,4]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","host","value","core"],"values":[["%s","server02",50,2]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "max - time and tx",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, tx, max(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","max"],"values":[["2000-01-01T00:00:00Z",50,40],["2000-01-01T00:00:30Z",70,50],["2000-01-01T00:01:00Z",10,90]]}]}]}`,
+			name:    "select * with tags should succeed",
+			command: `SELECT * FROM db0.rp0.cpu`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core","host","value"],"values":[["%s",4,"server01",100],["%s",2,"server02",50]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "min - baseline 30s",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT min(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","min"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:30Z",40],["2000-01-01T00:01:00Z",5]]}]}]}`,
+			name:    "select * with tags with GROUP BY * should succeed",
+			command: `SELECT * FROM db0.rp0.cpu GROUP BY *`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","core","value"],"values":[["%s",4,100]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","core","value"],"values":[["%s",2,50]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "min - tx",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, min(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","min"],"values":[["2000-01-01T00:00:00Z",20,10],["2000-01-01T00:00:30Z",60,40],["2000-01-01T00:01:00Z",4,5]]}]}]}`,
+			name:    "group by tag",
+			command: `SELECT value FROM db0.rp0.cpu GROUP by host`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["%s",100]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","value"],"values":[["%s",50]]}]}]}`, now.Format(time.RFC3339Nano), now.Add(1).Format(time.RFC3339Nano)),
 		},
 		{
-			name:    "min - time",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, min(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","min"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:30Z",40],["2000-01-01T00:01:00Z",5]]}]}]}`,
+			name:    "single field (EQ tag value1)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server01'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
 		},
 		{
-			name:    "min - time and tx",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, tx, min(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","min"],"values":[["2000-01-01T00:00:00Z",20,10],["2000-01-01T00:00:30Z",60,40],["2000-01-01T00:01:00Z",4,5]]}]}]}`,
+			name:    "single field (2 EQ tags)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server01' AND region = 'us-west'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
 		},
 		{
-			name:    "max,min - baseline 30s",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT max(rx), min(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max","min"],"values":[["2000-01-01T00:00:00Z",40,10],["2000-01-01T00:00:30Z",50,40],["2000-01-01T00:01:00Z",90,5]]}]}]}`,
+			name:    "single field (OR different tags)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server03' OR region = 'us-west'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",300],["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
 		},
 		{
-			name:    "first - baseline 30s",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT first(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:30Z",40],["2000-01-01T00:01:00Z",70]]}]}]}`,
+			name:    "single field (OR with non-existent tag value)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server01' OR host = 'server66'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
 		},
 		{
-			name:    "first - tx",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, tx, first(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","first"],"values":[["2000-01-01T00:00:00Z",20,10],["2000-01-01T00:00:30Z",60,40],["2000-01-01T00:01:00Z",30,70]]}]}]}`,
+			name:    "single field (OR with all tag values)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server01' OR host = 'server02' OR host = 'server03'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2010-02-28T01:03:37.703820946Z",200],["2012-02-28T01:03:38.703820946Z",300],["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
 		},
 		{
-			name:    "first - time",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, first(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:30Z",40],["2000-01-01T00:01:00Z",70]]}]}]}`,
+			name:    "single field (1 EQ and 1 NEQ tag)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server01' AND region != 'us-west'`,
+			exp:     `{"results":[{"statement_id":0}]}`,
 		},
 		{
-			name:    "first - time and tx",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, tx, first(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","first"],"values":[["2000-01-01T00:00:00Z",20,10],["2000-01-01T00:00:30Z",60,40],["2000-01-01T00:01:00Z",30,70]]}]}]}`,
+			name:    "single field (EQ tag value2)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host = 'server02'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2010-02-28T01:03:37.703820946Z",200]]}]}]}`,
 		},
 		{
-			name:    "last - baseline 30s",
+			name:    "single field (NEQ tag value1)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host != 'server01'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2010-02-28T01:03:37.703820946Z",200],["2012-02-28T01:03:38.703820946Z",300]]}]}]}`,
+		},
+		{
+			name:    "single field (NEQ tag value1 AND NEQ tag value2)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host != 'server01' AND host != 'server02'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",300]]}]}]}`,
+		},
+		{
+			name:    "single field (NEQ tag value1 OR NEQ tag value2)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host != 'server01' OR host != 'server02'`, // Yes, this is always true, but that's the point.
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2010-02-28T01:03:37.703820946Z",200],["2012-02-28T01:03:38.703820946Z",300],["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+		},
+		{
+			name:    "single field (NEQ tag value1 AND NEQ tag value2 AND NEQ tag value3)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host != 'server01' AND host != 'server02' AND host != 'server03'`,
+			exp:     `{"results":[{"statement_id":0}]}`,
+		},
+		{
+			name:    "single field (NEQ tag value1, point without any tags)",
+			command: `SELECT value FROM db0.rp0.cpu2 WHERE host != 'server01'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu2","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",200]]}]}]}`,
+		},
+		{
+			name:    "single field (NEQ tag value1, point without any tags)",
+			command: `SELECT value FROM db0.rp0.cpu3 WHERE company !~ /acme01/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu3","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",200]]}]}]}`,
+		},
+		{
+			name:    "single field (regex tag match)",
+			command: `SELECT value FROM db0.rp0.cpu3 WHERE company =~ /acme01/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu3","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+		},
+		{
+			name:    "single field (regex tag match)",
+			command: `SELECT value FROM db0.rp0.cpu3 WHERE company !~ /acme[23]/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu3","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",200],["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+		},
+		{
+			name:    "single field (regex tag match with escaping)",
+			command: `SELECT value FROM db0.rp0.status_code WHERE url !~ /https\:\/\/influxdb\.com/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"status_code","columns":["time","value"],"values":[["2015-07-22T08:13:54.929026672Z",404]]}]}]}`,
+		},
+		{
+			name:    "single field (regex tag match with escaping)",
+			command: `SELECT value FROM db0.rp0.status_code WHERE url =~ /https\:\/\/influxdb\.com/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"status_code","columns":["time","value"],"values":[["2015-07-22T09:52:24.914395083Z",418]]}]}]}`,
+		},
+		{
+			name:    "single field (IN tag values)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host IN ('server01', 'server03')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",300],["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+		},
+		{
+			name:    "single field (IN tag values AND time)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host IN ('server01', 'server03') AND time < '2013-01-01T00:00:00Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2012-02-28T01:03:38.703820946Z",300]]}]}]}`,
+		},
+		{
+			name:    "single field (IN tag values OR tag value)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host IN ('server01') OR host = 'server02'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2010-02-28T01:03:37.703820946Z",200],["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+		},
+		{
+			name:    "single field (IN tag values AND tag value)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host IN ('server01', 'server02') AND region = 'us-west'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",100]]}]}]}`,
+		},
+		{
+			name:    "single field (IN missing tag values)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host IN ('server04')`,
+			exp:     `{"results":[{"statement_id":0}]}`,
+		},
+		{
+			name:    "single field (IN empty list)",
+			command: `SELECT value FROM db0.rp0.cpu1 WHERE host IN ()`,
+			exp:     `{"results":[{"statement_id":0}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can handle various group by time moving average queries.
+func TestServer_Query_SelectGroupByTimeMovingAverage(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `cpu value=10 1278010020000000000
+cpu value=15 1278010021000000000
+cpu value=20 1278010022000000000
+cpu value=25 1278010023000000000
+cpu value=30 1278010024000000000
+cpu value=35 1278010025000000000
+`},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "calculate moving average of count",
+			command: `SELECT moving_average(count(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",1],["2010-07-01T18:47:02Z",2],["2010-07-01T18:47:04Z",2]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of mean",
+			command: `SELECT moving_average(mean(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",17.5],["2010-07-01T18:47:04Z",27.5]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of median",
+			command: `SELECT moving_average(median(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",17.5],["2010-07-01T18:47:04Z",27.5]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of mode",
+			command: `SELECT moving_average(mode(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of sum",
+			command: `SELECT moving_average(sum(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",35],["2010-07-01T18:47:04Z",55]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of first",
+			command: `SELECT moving_average(first(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of last",
+			command: `SELECT moving_average(last(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",20],["2010-07-01T18:47:04Z",30]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of min",
+			command: `SELECT moving_average(min(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of max",
+			command: `SELECT moving_average(max(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",20],["2010-07-01T18:47:04Z",30]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of percentile",
+			command: `SELECT moving_average(percentile(value, 50), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can handle exponential moving average queries.
+func TestServer_Query_SelectExponentialMovingAverage(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `cpu value=0 1278010020000000000
+cpu value=3 1278010021000000000
+cpu value=10 1278010022000000000
+cpu value=20 1278010023000000000
+cpu value=1 1278010024000000000
+`},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "calculate exponential moving average of raw values",
+			command: `SELECT exponential_moving_average(value, 3) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","exponential_moving_average"],"values":[["2010-07-01T18:47:02Z",6],["2010-07-01T18:47:03Z",13],["2010-07-01T18:47:04Z",7]]}]}]}`,
+		},
+		{
+			name:    "calculate exponential moving average without a hold period",
+			command: `SELECT exponential_moving_average(value, 3, 0) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","exponential_moving_average"],"values":[["2010-07-01T18:47:00Z",0],["2010-07-01T18:47:01Z",2],["2010-07-01T18:47:02Z",6],["2010-07-01T18:47:03Z",13],["2010-07-01T18:47:04Z",7]]}]}]}`,
+		},
+		{
+			name:    "calculate exponential moving average of max",
+			command: `SELECT exponential_moving_average(max(value), 3) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:04' group by time(1s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","exponential_moving_average"],"values":[["2010-07-01T18:47:02Z",6],["2010-07-01T18:47:03Z",13],["2010-07-01T18:47:04Z",7]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can handle double and triple exponential moving average
+// queries. Both follow a linear trend exactly once they are warmed up.
+func TestServer_Query_SelectDoubleTripleExponentialMovingAverage(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	var writes []string
+	for i := 0; i < 10; i++ {
+		writes = append(writes, fmt.Sprintf(`cpu value=%d %d`, i*10, 1278010020000000000+int64(i)*int64(time.Second)))
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "calculate double exponential moving average of raw values",
+			command: `SELECT double_exponential_moving_average(value, 3) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","double_exponential_moving_average"],"values":[["2010-07-01T18:47:02Z",17.777777777777775],["2010-07-01T18:47:03Z",28.05555555555555],["2010-07-01T18:47:04Z",38.611111111111114],["2010-07-01T18:47:05Z",49.09722222222222],["2010-07-01T18:47:06Z",59.44444444444444],["2010-07-01T18:47:07Z",69.67013888888889],["2010-07-01T18:47:08Z",79.80902777777777],["2010-07-01T18:47:09Z",89.89149305555554]]}]}]}`,
+		},
+		{
+			name:    "calculate double exponential moving average with a hold period",
+			command: `SELECT double_exponential_moving_average(value, 3, 6) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","double_exponential_moving_average"],"values":[["2010-07-01T18:47:06Z",59.44444444444444],["2010-07-01T18:47:07Z",69.67013888888889],["2010-07-01T18:47:08Z",79.80902777777777],["2010-07-01T18:47:09Z",89.89149305555554]]}]}]}`,
+		},
+		{
+			name:    "calculate double exponential moving average with simple warmup",
+			command: `SELECT double_exponential_moving_average(value, 3, -1, 'simple') from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","double_exponential_moving_average"],"values":[["2010-07-01T18:47:04Z",40],["2010-07-01T18:47:05Z",50],["2010-07-01T18:47:06Z",60],["2010-07-01T18:47:07Z",70],["2010-07-01T18:47:08Z",80],["2010-07-01T18:47:09Z",90]]}]}]}`,
+		},
+		{
+			name:    "calculate double exponential moving average of max with simple warmup",
+			command: `SELECT double_exponential_moving_average(max(value), 3, -1, 'simple') from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:09' group by time(1s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","double_exponential_moving_average"],"values":[["2010-07-01T18:47:04Z",40],["2010-07-01T18:47:05Z",50],["2010-07-01T18:47:06Z",60],["2010-07-01T18:47:07Z",70],["2010-07-01T18:47:08Z",80],["2010-07-01T18:47:09Z",90]]}]}]}`,
+		},
+		{
+			name:    "calculate triple exponential moving average of raw values",
+			command: `SELECT triple_exponential_moving_average(value, 2) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","triple_exponential_moving_average"],"values":[["2010-07-01T18:47:01Z",9.62962962962963],["2010-07-01T18:47:02Z",19.999999999999993],["2010-07-01T18:47:03Z",30.123456790123445],["2010-07-01T18:47:04Z",40.10973936899864],["2010-07-01T18:47:05Z",50.06858710562413],["2010-07-01T18:47:06Z",60.0365797896662],["2010-07-01T18:47:07Z",70.01778184219886],["2010-07-01T18:47:08Z",80.00812884214807],["2010-07-01T18:47:09Z",90.00355636843976]]}]}]}`,
+		},
+		{
+			name:    "calculate triple exponential moving average with simple warmup",
+			command: `SELECT triple_exponential_moving_average(value, 2, -1, 'simple') from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","triple_exponential_moving_average"],"values":[["2010-07-01T18:47:03Z",30],["2010-07-01T18:47:04Z",40],["2010-07-01T18:47:05Z",50],["2010-07-01T18:47:06Z",60],["2010-07-01T18:47:07Z",70],["2010-07-01T18:47:08Z",80],["2010-07-01T18:47:09Z",90]]}]}]}`,
+		},
+		{
+			name:    "calculate triple exponential moving average of max with simple warmup",
+			command: `SELECT triple_exponential_moving_average(max(value), 2, -1, 'simple') from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:09' group by time(1s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","triple_exponential_moving_average"],"values":[["2010-07-01T18:47:03Z",30],["2010-07-01T18:47:04Z",40],["2010-07-01T18:47:05Z",50],["2010-07-01T18:47:06Z",60],["2010-07-01T18:47:07Z",70],["2010-07-01T18:47:08Z",80],["2010-07-01T18:47:09Z",90]]}]}]}`,
+		},
+		{
+			name:    "double exponential moving average period must be at least 1",
+			command: `SELECT double_exponential_moving_average(value, 0) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"error":"double_exponential_moving_average period must be greater than or equal to 1"}]}`,
+		},
+		{
+			name:    "triple exponential moving average period must be at least 1",
+			command: `SELECT triple_exponential_moving_average(value, 0) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"error":"triple_exponential_moving_average period must be greater than or equal to 1"}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can handle relative strength index queries.
+func TestServer_Query_SelectRelativeStrengthIndex(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	var writes []string
+	for i := 0; i < 6; i++ {
+		ts := 1278010020000000000 + int64(i)*int64(time.Second)
+		writes = append(writes,
+			fmt.Sprintf(`rising value=%d %d`, i*10, ts),
+			fmt.Sprintf(`falling value=%d %d`, 100-i*10, ts),
+			fmt.Sprintf(`flat value=5 %d`, ts),
+			fmt.Sprintf(`mixed value=%d %d`, []int{10, 12, 11, 13, 12, 14}[i], ts),
+		)
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "relative strength index of a rising series",
+			command: `SELECT relative_strength_index(value, 3) from db0.rp0.rising`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"rising","columns":["time","relative_strength_index"],"values":[["2010-07-01T18:47:03Z",100],["2010-07-01T18:47:04Z",100],["2010-07-01T18:47:05Z",100]]}]}]}`,
+		},
+		{
+			name:    "relative strength index of a falling series",
+			command: `SELECT relative_strength_index(value, 3) from db0.rp0.falling`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"falling","columns":["time","relative_strength_index"],"values":[["2010-07-01T18:47:03Z",0],["2010-07-01T18:47:04Z",0],["2010-07-01T18:47:05Z",0]]}]}]}`,
+		},
+		{
+			name:    "relative strength index of a flat series",
+			command: `SELECT relative_strength_index(value, 3) from db0.rp0.flat`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"flat","columns":["time","relative_strength_index"],"values":[["2010-07-01T18:47:03Z",50],["2010-07-01T18:47:04Z",50],["2010-07-01T18:47:05Z",50]]}]}]}`,
+		},
+		{
+			name:    "relative strength index with simple warmup",
+			command: `SELECT relative_strength_index(value, 2, -1, 'simple') from db0.rp0.mixed`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mixed","columns":["time","relative_strength_index"],"values":[["2010-07-01T18:47:02Z",66.66666666666666],["2010-07-01T18:47:03Z",85.71428571428571],["2010-07-01T18:47:04Z",54.54545454545455],["2010-07-01T18:47:05Z",81.48148148148148]]}]}]}`,
+		},
+		{
+			name:    "relative strength index with a hold period",
+			command: `SELECT relative_strength_index(value, 2, 4) from db0.rp0.mixed`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mixed","columns":["time","relative_strength_index"],"values":[["2010-07-01T18:47:04Z",50],["2010-07-01T18:47:05Z",80]]}]}]}`,
+		},
+		{
+			name:    "relative strength index of max",
+			command: `SELECT relative_strength_index(max(value), 3) from db0.rp0.rising where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(1s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"rising","columns":["time","relative_strength_index"],"values":[["2010-07-01T18:47:03Z",100],["2010-07-01T18:47:04Z",100],["2010-07-01T18:47:05Z",100]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_SelectKaufmans(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	var writes []string
+	for i := 0; i < 10; i++ {
+		ts := 1278010020000000000 + int64(i)*int64(time.Second)
+		step := 0
+		if i >= 4 {
+			step = 100
+		}
+		writes = append(writes,
+			fmt.Sprintf(`trend value=%d %d`, i*10, ts),
+			fmt.Sprintf(`noisy value=%d %d`, []int{10, 12, 11, 13, 12, 14, 13, 15, 14, 16}[i], ts),
+			fmt.Sprintf(`step value=%d %d`, step, ts),
+		)
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "efficiency ratio of a trend",
+			command: `SELECT kaufmans_efficiency_ratio(value, 3) from db0.rp0.trend`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"trend","columns":["time","kaufmans_efficiency_ratio"],"values":[["2010-07-01T18:47:03Z",1],["2010-07-01T18:47:04Z",1],["2010-07-01T18:47:05Z",1],["2010-07-01T18:47:06Z",1],["2010-07-01T18:47:07Z",1],["2010-07-01T18:47:08Z",1],["2010-07-01T18:47:09Z",1]]}]}]}`,
+		},
+		{
+			name:    "efficiency ratio of a noisy series",
+			command: `SELECT kaufmans_efficiency_ratio(value, 3) from db0.rp0.noisy`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"noisy","columns":["time","kaufmans_efficiency_ratio"],"values":[["2010-07-01T18:47:03Z",0.6],["2010-07-01T18:47:04Z",0],["2010-07-01T18:47:05Z",0.6],["2010-07-01T18:47:06Z",0],["2010-07-01T18:47:07Z",0.6],["2010-07-01T18:47:08Z",0],["2010-07-01T18:47:09Z",0.6]]}]}]}`,
+		},
+		{
+			name:    "efficiency ratio of mean",
+			command: `SELECT kaufmans_efficiency_ratio(mean(value), 2) from db0.rp0.noisy where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:09' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"noisy","columns":["time","kaufmans_efficiency_ratio"],"values":[["2010-07-01T18:47:04Z",1],["2010-07-01T18:47:06Z",1],["2010-07-01T18:47:08Z",1]]}]}]}`,
+		},
+		{
+			name:    "adaptive moving average of a step",
+			command: `SELECT kaufmans_adaptive_moving_average(value, 3) from db0.rp0.step`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"step","columns":["time","kaufmans_adaptive_moving_average"],"values":[["2010-07-01T18:47:03Z",0],["2010-07-01T18:47:04Z",44.44444444444445],["2010-07-01T18:47:05Z",69.13580246913581],["2010-07-01T18:47:06Z",82.85322359396434],["2010-07-01T18:47:07Z",82.92459415132558],["2010-07-01T18:47:08Z",82.99566764081017],["2010-07-01T18:47:09Z",83.06644529891294]]}]}]}`,
+		},
+		{
+			name:    "adaptive moving average with a hold period",
+			command: `SELECT kaufmans_adaptive_moving_average(value, 3, 6) from db0.rp0.step`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"step","columns":["time","kaufmans_adaptive_moving_average"],"values":[["2010-07-01T18:47:06Z",82.85322359396434],["2010-07-01T18:47:07Z",82.92459415132558],["2010-07-01T18:47:08Z",82.99566764081017],["2010-07-01T18:47:09Z",83.06644529891294]]}]}]}`,
+		},
+		{
+			name:    "adaptive moving average of max",
+			command: `SELECT kaufmans_adaptive_moving_average(max(value), 2) from db0.rp0.trend where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:09' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"trend","columns":["time","kaufmans_adaptive_moving_average"],"values":[["2010-07-01T18:47:04Z",38.88888888888889],["2010-07-01T18:47:06Z",52.71604938271605],["2010-07-01T18:47:08Z",69.28669410150891]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_SelectTripleExponentialDerivative(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	var writes []string
+	for i := 0; i < 60; i++ {
+		ts := 1278010020000000000 + int64(i)*int64(time.Minute)
+		writes = append(writes, fmt.Sprintf(`m value=%d %d`, 100+i, ts))
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "triple exponential derivative of mean",
+			command: `SELECT triple_exponential_derivative(mean(value), 15) FROM db0.rp0.m WHERE time >= '2010-07-01 18:47:00' AND time < '2010-07-01 19:47:00' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m","columns":["time","triple_exponential_derivative"],"values":[["2010-07-01T19:02:00Z",0.30623499733286597],["2010-07-01T19:03:00Z",0.328263348827984],["2010-07-01T19:04:00Z",0.35117959401609333],["2010-07-01T19:05:00Z",0.3747389791812772],["2010-07-01T19:06:00Z",0.3986841039605782],["2010-07-01T19:07:00Z",0.42275951684862534],["2010-07-01T19:08:00Z",0.4467220748646694],["2010-07-01T19:09:00Z",0.4703479666172683],["2010-07-01T19:10:00Z",0.4934371283570771],["2010-07-01T19:11:00Z",0.5158156458129337],["2010-07-01T19:12:00Z",0.537336623561746],["2010-07-01T19:13:00Z",0.5578799128362899],["2010-07-01T19:14:00Z",0.5773510138037841],["2010-07-01T19:15:00Z",0.5956794062527804],["2010-07-01T19:16:00Z",0.6128165109207329],["2010-07-01T19:17:00Z",0.6287334405650435],["2010-07-01T19:18:00Z",0.643418663946016],["2010-07-01T19:19:00Z",0.65687567606032],["2010-07-01T19:20:00Z",0.6691207433586976],["2010-07-01T19:21:00Z",0.6801807725662856],["2010-07-01T19:22:00Z",0.6900913354667804],["2010-07-01T19:23:00Z",0.6988948690604069],["2010-07-01T19:24:00Z",0.706639060365033],["2010-07-01T19:25:00Z",0.713375417366624],["2010-07-01T19:26:00Z",0.7191580218419702],["2010-07-01T19:27:00Z",0.7240424556300473],["2010-07-01T19:28:00Z",0.7280848891120018],["2010-07-01T19:29:00Z",0.7313413189056428],["2010-07-01T19:30:00Z",0.7338669408646137],["2010-07-01T19:31:00Z",0.7357156441946344],["2010-07-01T19:32:00Z",0.7369396127052452],["2010-07-01T19:33:00Z",0.7375890197627077],["2010-07-01T19:34:00Z",0.7377118042991127],["2010-07-01T19:35:00Z",0.7373535161674827],["2010-07-01T19:36:00Z",0.7365572201557979],["2010-07-01T19:37:00Z",0.7353634490229854],["2010-07-01T19:38:00Z",0.7338101969641242],["2010-07-01T19:39:00Z",0.7319329459216428],["2010-07-01T19:40:00Z",0.7297647181125466],["2010-07-01T19:41:00Z",0.7273361490293118],["2010-07-01T19:42:00Z",0.724675575985545],["2010-07-01T19:43:00Z",0.7218091380141178],["2010-07-01T19:44:00Z",0.7187608835864445],["2010-07-01T19:45:00Z",0.7155528832060609],["2010-07-01T19:46:00Z",0.7122053444478471]]}]}]}`,
+		},
+		{
+			name:    "triple exponential derivative with simple warmup",
+			command: `SELECT triple_exponential_derivative(value, 15, -1, 'simple') FROM db0.rp0.m`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m","columns":["time","triple_exponential_derivative"],"values":[["2010-07-01T19:30:00Z",0.8264462809917328],["2010-07-01T19:31:00Z",0.8196721311475308],["2010-07-01T19:32:00Z",0.8130081300812941],["2010-07-01T19:33:00Z",0.8064516129032251],["2010-07-01T19:34:00Z",0.8000000000000007],["2010-07-01T19:35:00Z",0.7936507936507908],["2010-07-01T19:36:00Z",0.7874015748031482],["2010-07-01T19:37:00Z",0.78125],["2010-07-01T19:38:00Z",0.7751937984496138],["2010-07-01T19:39:00Z",0.7692307692307665],["2010-07-01T19:40:00Z",0.7633587786259444],["2010-07-01T19:41:00Z",0.7575757575757569],["2010-07-01T19:42:00Z",0.7518796992481258],["2010-07-01T19:43:00Z",0.746268656716409],["2010-07-01T19:44:00Z",0.7407407407407307],["2010-07-01T19:45:00Z",0.7352941176470562],["2010-07-01T19:46:00Z",0.7299270072992803]]}]}]}`,
+		},
+		{
+			name:    "triple exponential derivative of a short series",
+			command: `SELECT triple_exponential_derivative(value, 15) FROM db0.rp0.m WHERE time < '2010-07-01 19:01:00'`,
+			exp:     `{"results":[{"statement_id":0}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can handle various group by time moving average queries.
+func TestServer_Query_SelectGroupByTimeMovingAverageWithFill(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `cpu value=10 1278010020000000000
+cpu value=15 1278010021000000000
+cpu value=30 1278010024000000000
+cpu value=35 1278010025000000000
+`},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "calculate moving average of count with fill 0",
+			command: `SELECT moving_average(count(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",1],["2010-07-01T18:47:02Z",1],["2010-07-01T18:47:04Z",1]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of count with fill previous",
+			command: `SELECT moving_average(count(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",2],["2010-07-01T18:47:04Z",2]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of mean with fill 0",
+			command: `SELECT moving_average(mean(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",6.25],["2010-07-01T18:47:02Z",6.25],["2010-07-01T18:47:04Z",16.25]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of mean with fill previous",
+			command: `SELECT moving_average(mean(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",12.5],["2010-07-01T18:47:04Z",22.5]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of median with fill 0",
+			command: `SELECT moving_average(median(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",6.25],["2010-07-01T18:47:02Z",6.25],["2010-07-01T18:47:04Z",16.25]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of median with fill previous",
+			command: `SELECT moving_average(median(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",12.5],["2010-07-01T18:47:04Z",22.5]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of mode with fill 0",
+			command: `SELECT moving_average(mode(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",5],["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",15]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of mode with fill previous",
+			command: `SELECT moving_average(mode(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",10],["2010-07-01T18:47:04Z",20]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of sum with fill 0",
+			command: `SELECT moving_average(sum(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",12.5],["2010-07-01T18:47:02Z",12.5],["2010-07-01T18:47:04Z",32.5]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of sum with fill previous",
+			command: `SELECT moving_average(sum(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",25],["2010-07-01T18:47:04Z",45]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of first with fill 0",
+			command: `SELECT moving_average(first(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",5],["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",15]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of first with fill previous",
+			command: `SELECT moving_average(first(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",10],["2010-07-01T18:47:04Z",20]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of last with fill 0",
+			command: `SELECT moving_average(last(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",7.5],["2010-07-01T18:47:02Z",7.5],["2010-07-01T18:47:04Z",17.5]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of last with fill previous",
+			command: `SELECT moving_average(last(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of min with fill 0",
+			command: `SELECT moving_average(min(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",5],["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",15]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of min with fill previous",
+			command: `SELECT moving_average(min(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",10],["2010-07-01T18:47:04Z",20]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of max with fill 0",
+			command: `SELECT moving_average(max(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",7.5],["2010-07-01T18:47:02Z",7.5],["2010-07-01T18:47:04Z",17.5]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of max with fill previous",
+			command: `SELECT moving_average(max(value), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",15],["2010-07-01T18:47:04Z",25]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of percentile with fill 0",
+			command: `SELECT moving_average(percentile(value, 50), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:00Z",5],["2010-07-01T18:47:02Z",5],["2010-07-01T18:47:04Z",15]]}]}]}`,
+		},
+		{
+			name:    "calculate moving average of percentile with fill previous",
+			command: `SELECT moving_average(percentile(value, 50), 2) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:05' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","moving_average"],"values":[["2010-07-01T18:47:02Z",10],["2010-07-01T18:47:04Z",20]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+} // Ensure the server correctly queries with an alias.
+func TestServer_Query_Alias(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf("cpu value=1i,steps=3i %d", mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf("cpu value=2i,steps=4i %d", mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+	}
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "baseline query - SELECT * FROM db0.rp0.cpu",
+			command: `SELECT * FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","steps","value"],"values":[["2000-01-01T00:00:00Z",3,1],["2000-01-01T00:01:00Z",4,2]]}]}]}`,
+		},
+		{
+			name:    "basic query with alias - SELECT steps, value as v FROM db0.rp0.cpu",
+			command: `SELECT steps, value as v FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","steps","v"],"values":[["2000-01-01T00:00:00Z",3,1],["2000-01-01T00:01:00Z",4,2]]}]}]}`,
+		},
+		{
+			name:    "double aggregate sum - SELECT sum(value), sum(steps) FROM db0.rp0.cpu",
+			command: `SELECT sum(value), sum(steps) FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum","sum_1"],"values":[["1970-01-01T00:00:00Z",3,7]]}]}]}`,
+		},
+		{
+			name:    "double aggregate sum reverse order - SELECT sum(steps), sum(value) FROM db0.rp0.cpu",
+			command: `SELECT sum(steps), sum(value) FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum","sum_1"],"values":[["1970-01-01T00:00:00Z",7,3]]}]}]}`,
+		},
+		{
+			name:    "double aggregate sum with alias - SELECT sum(value) as sumv, sum(steps) as sums FROM db0.rp0.cpu",
+			command: `SELECT sum(value) as sumv, sum(steps) as sums FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sumv","sums"],"values":[["1970-01-01T00:00:00Z",3,7]]}]}]}`,
+		},
+		{
+			name:    "double aggregate with same value - SELECT sum(value), mean(value) FROM db0.rp0.cpu",
+			command: `SELECT sum(value), mean(value) FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum","mean"],"values":[["1970-01-01T00:00:00Z",3,1.5]]}]}]}`,
+		},
+		{
+			name:    "double aggregate with same value and same alias - SELECT mean(value) as mv, max(value) as mv FROM db0.rp0.cpu",
+			command: `SELECT mean(value) as mv, max(value) as mv FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mv","mv"],"values":[["1970-01-01T00:00:00Z",1.5,2]]}]}]}`,
+		},
+		{
+			name:    "double aggregate with non-existent field - SELECT mean(value), max(foo) FROM db0.rp0.cpu",
+			command: `SELECT mean(value), max(foo) FROM db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean","max"],"values":[["1970-01-01T00:00:00Z",1.5,null]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can handle various group by time cumulative sum queries.
+func TestServer_Query_SelectGroupByTimeCumulativeSum(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `cpu value=10 1278010020000000000
+cpu value=15 1278010021000000000
+cpu value=20 1278010022000000000
+cpu value=25 1278010023000000000
+`},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "calculate cumulative sum of count",
+			command: `SELECT cumulative_sum(count(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",2],["2010-07-01T18:47:02Z",4]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of mean",
+			command: `SELECT cumulative_sum(mean(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",12.5],["2010-07-01T18:47:02Z",35]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of median",
+			command: `SELECT cumulative_sum(median(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",12.5],["2010-07-01T18:47:02Z",35]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of mode",
+			command: `SELECT cumulative_sum(mode(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",30]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of sum",
+			command: `SELECT cumulative_sum(sum(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",25],["2010-07-01T18:47:02Z",70]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of first",
+			command: `SELECT cumulative_sum(first(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",30]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of last",
+			command: `SELECT cumulative_sum(last(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",40]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of min",
+			command: `SELECT cumulative_sum(min(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",30]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of max",
+			command: `SELECT cumulative_sum(max(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",40]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of percentile",
+			command: `SELECT cumulative_sum(percentile(value, 50)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",30]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the server can handle various group by time cumulative sum queries with fill.
+func TestServer_Query_SelectGroupByTimeCumulativeSumWithFill(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `cpu value=10 1278010020000000000
+cpu value=20 1278010021000000000
+`},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "calculate cumulative sum of count with fill 0",
+			command: `SELECT cumulative_sum(count(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",2],["2010-07-01T18:47:02Z",2]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of count with fill previous",
+			command: `SELECT cumulative_sum(count(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",2],["2010-07-01T18:47:02Z",4]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of mean with fill 0",
+			command: `SELECT cumulative_sum(mean(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",15]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of mean with fill previous",
+			command: `SELECT cumulative_sum(mean(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",30]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of median with fill 0",
+			command: `SELECT cumulative_sum(median(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",15]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of median with fill previous",
+			command: `SELECT cumulative_sum(median(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",15],["2010-07-01T18:47:02Z",30]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of mode with fill 0",
+			command: `SELECT cumulative_sum(mode(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",10]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of mode with fill previous",
+			command: `SELECT cumulative_sum(mode(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",20]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of sum with fill 0",
+			command: `SELECT cumulative_sum(sum(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",30],["2010-07-01T18:47:02Z",30]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of sum with fill previous",
+			command: `SELECT cumulative_sum(sum(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",30],["2010-07-01T18:47:02Z",60]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of first with fill 0",
+			command: `SELECT cumulative_sum(first(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",10]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of first with fill previous",
+			command: `SELECT cumulative_sum(first(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",20]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of last with fill 0",
+			command: `SELECT cumulative_sum(last(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",20],["2010-07-01T18:47:02Z",20]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of last with fill previous",
+			command: `SELECT cumulative_sum(last(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",20],["2010-07-01T18:47:02Z",40]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of min with fill 0",
+			command: `SELECT cumulative_sum(min(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",10]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of min with fill previous",
+			command: `SELECT cumulative_sum(min(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",20]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of max with fill 0",
+			command: `SELECT cumulative_sum(max(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",20],["2010-07-01T18:47:02Z",20]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of max with fill previous",
+			command: `SELECT cumulative_sum(max(value)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",20],["2010-07-01T18:47:02Z",40]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of percentile with fill 0",
+			command: `SELECT cumulative_sum(percentile(value, 50)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",10]]}]}]}`,
+		},
+		{
+			name:    "calculate cumulative sum of percentile with fill previous",
+			command: `SELECT cumulative_sum(percentile(value, 50)) from db0.rp0.cpu where time >= '2010-07-01 18:47:00' and time <= '2010-07-01 18:47:03' group by time(2s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","cumulative_sum"],"values":[["2010-07-01T18:47:00Z",10],["2010-07-01T18:47:02Z",20]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_Int(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`int value=45 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		}, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		// int64
+		{
+			name:    "stddev with just one point - int",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT STDDEV(value) FROM int`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"int","columns":["time","stddev"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
+		},
+		{
+			name:    "stddev_pop with just one point - int",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT STDDEV_POP(value) FROM int`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"int","columns":["time","stddev_pop"],"values":[["1970-01-01T00:00:00Z",0]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_MathWithFill(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `cpu value=15 1278010020000000000
+`},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "multiplication with fill previous",
+			command: `SELECT 4*mean(value) FROM db0.rp0.cpu WHERE time >= '2010-07-01 18:47:00' AND time < '2010-07-01 18:48:30' GROUP BY time(30s) FILL(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean"],"values":[["2010-07-01T18:47:00Z",60],["2010-07-01T18:47:30Z",60],["2010-07-01T18:48:00Z",60]]}]}]}`,
+		},
+		{
+			name:    "multiplication of mode value with fill previous",
+			command: `SELECT 4*mode(value) FROM db0.rp0.cpu WHERE time >= '2010-07-01 18:47:00' AND time < '2010-07-01 18:48:30' GROUP BY time(30s) FILL(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mode"],"values":[["2010-07-01T18:47:00Z",60],["2010-07-01T18:47:30Z",60],["2010-07-01T18:48:00Z",60]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// mergeMany ensures that when merging many series together and some of them have a different number
+// of points than others in a group by interval the results are correct
+func TestServer_Query_MergeMany(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+
+	var writes []string
+	for i := 1; i < 11; i++ {
+		for j := 1; j < 5+i%3; j++ {
+			data := fmt.Sprintf(`cpu,host=server_%d value=22 %d`, i, time.Unix(int64(j), int64(0)).UTC().UnixNano())
+			writes = append(writes, data)
+		}
+	}
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "GROUP by time",
+			command: `SELECT count(value) FROM db0.rp0.cpu WHERE time >= '1970-01-01T00:00:01Z' AND time <= '1970-01-01T00:00:06Z' GROUP BY time(1s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:01Z",10],["1970-01-01T00:00:02Z",10],["1970-01-01T00:00:03Z",10],["1970-01-01T00:00:04Z",10],["1970-01-01T00:00:05Z",7],["1970-01-01T00:00:06Z",3]]}]}]}`,
+		},
+		{
+			skip:    "Skipped OSS",
+			name:    "GROUP by tag - FIXME issue #2875",
+			command: `SELECT count(value) FROM db0.rp0.cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:00Z' group by host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","count"],"values":[["2000-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","count"],"values":[["2000-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server03"},"columns":["time","count"],"values":[["2000-01-01T00:00:00Z",1]]}]}]}`,
+		},
+		{
+			name:    "GROUP by field",
+			command: `SELECT count(value) FROM db0.rp0.cpu group by value`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"value":""},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",50]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_SelectGroupByTime_MultipleAggregates(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `test,t=a x=1i 1000000000
+test,t=b y=1i 1000000000
+test,t=a x=2i 2000000000
+test,t=b y=2i 2000000000
+test,t=a x=3i 3000000000
+test,t=b y=3i 3000000000
+`},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "two aggregates with a group by host",
+			command: `SELECT mean(x) as x, mean(y) as y from db0.rp0.test where time >= 1s and time < 4s group by t, time(1s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"test","tags":{"t":"a"},"columns":["time","x","y"],"values":[["1970-01-01T00:00:01Z",1,null],["1970-01-01T00:00:02Z",2,null],["1970-01-01T00:00:03Z",3,null]]},{"name":"test","tags":{"t":"b"},"columns":["time","x","y"],"values":[["1970-01-01T00:00:01Z",null,1],["1970-01-01T00:00:02Z",null,2],["1970-01-01T00:00:03Z",null,3]]}]}]}`,
+		},
+		{
+			name:    "aggregate with a group by host and a series missing the field",
+			command: `SELECT mean(x) from db0.rp0.test where time >= 1s and time < 4s group by t, time(1s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"test","tags":{"t":"a"},"columns":["time","mean"],"values":[["1970-01-01T00:00:01Z",1],["1970-01-01T00:00:02Z",2],["1970-01-01T00:00:03Z",3]]},{"name":"test","tags":{"t":"b"},"columns":["time","mean"],"values":[["1970-01-01T00:00:01Z",null],["1970-01-01T00:00:02Z",null],["1970-01-01T00:00:03Z",null]]}]}]}`,
+		},
+		{
+			name:    "aggregate with fill(none) and a series missing the field",
+			command: `SELECT max(x) from db0.rp0.test where time >= 1s and time < 4s group by t, time(1s) fill(none)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"test","tags":{"t":"a"},"columns":["time","max"],"values":[["1970-01-01T00:00:01Z",1],["1970-01-01T00:00:02Z",2],["1970-01-01T00:00:03Z",3]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Regex(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu1,host=server01 value=10 %d`, mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
+		fmt.Sprintf(`cpu2,host=server01 value=20 %d`, mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
+		fmt.Sprintf(`cpu3,host=server01 value=30 %d`, mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "default db and rp",
+			command: `SELECT * FROM /cpu[13]/`,
+			params:  url.Values{"db": []string{"db0"}},
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","host","value"],"values":[["2015-02-28T01:03:36.703820946Z","server01",10]]},{"name":"cpu3","columns":["time","host","value"],"values":[["2015-02-28T01:03:36.703820946Z","server01",30]]}]}]}`,
+		},
+		{
+			name:    "default db and rp with GROUP BY *",
+			command: `SELECT * FROM /cpu[13]/ GROUP BY *`,
+			params:  url.Values{"db": []string{"db0"}},
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",10]]},{"name":"cpu3","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",30]]}]}]}`,
+		},
+		{
+			name:    "specifying db and rp",
+			command: `SELECT * FROM db0.rp0./cpu[13]/ GROUP BY *`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",10]]},{"name":"cpu3","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",30]]}]}]}`,
+		},
+		{
+			name:    "default db and specified rp",
+			command: `SELECT * FROM rp0./cpu[13]/ GROUP BY *`,
+			params:  url.Values{"db": []string{"db0"}},
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",10]]},{"name":"cpu3","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",30]]}]}]}`,
+		},
+		{
+			name:    "specified db and default rp",
+			command: `SELECT * FROM db0../cpu[13]/ GROUP BY *`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",10]]},{"name":"cpu3","tags":{"host":"server01"},"columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",30]]}]}]}`,
+		},
+		{
+			name:    "map field type with a regex source",
+			command: `SELECT value FROM /cpu[13]/`,
+			params:  url.Values{"db": []string{"db0"}},
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu1","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",10]]},{"name":"cpu3","columns":["time","value"],"values":[["2015-02-28T01:03:36.703820946Z",30]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_Load(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`load,region=us-east,host=serverA value=20.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+			fmt.Sprintf(`load,region=us-east,host=serverB value=30.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+			fmt.Sprintf(`load,region=us-west,host=serverC value=100.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		}, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "group by multiple dimensions",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value) FROM load GROUP BY region, host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"load","tags":{"host":"serverA","region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",20]]},{"name":"load","tags":{"host":"serverB","region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",30]]},{"name":"load","tags":{"host":"serverC","region":"us-west"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",100]]}]}]}`,
+		},
+		{
+			name:    "group by multiple dimensions",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value)*2 FROM load`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"load","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",300]]}]}]}`,
+		},
+		{
+			name:    "group by multiple dimensions",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value)/2 FROM load`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"load","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",75]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_SLimitAndSOffset(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+
+	var writes []string
+	for i := 1; i < 10; i++ {
+		data := fmt.Sprintf(`cpu,region=us-east,host=server-%d value=%d %d`, i, i, time.Unix(int64(i), int64(0)).UnixNano())
+		writes = append(writes, data)
+	}
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "SLIMIT 2 SOFFSET 1",
+			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 2 SOFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server-2","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-3","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+		},
+		{
+			name:    "SLIMIT 2 SOFFSET 3",
+			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 2 SOFFSET 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server-4","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-5","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+		},
+		{
+			name:    "SLIMIT 3 SOFFSET 8",
+			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 3 SOFFSET 8`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server-9","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+		},
+		{
+			name:    "SLIMIT 2 SOFFSET 1 - limit series first",
+			params:  url.Values{"limit_series_first": []string{"true"}},
+			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 2 SOFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server-7","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-8","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+		},
+		{
+			name:    "SLIMIT 3 SOFFSET 8 - limit series first",
+			params:  url.Values{"limit_series_first": []string{"true"}},
+			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 3 SOFFSET 8`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server-9","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure fill, series ordering, SLIMIT/SOFFSET, and LIMIT/OFFSET are applied
+// in that order so the output of a query combining them is stable.
+func TestServer_Query_FillLimitSLimit(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`cpu,host=server04 value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+			fmt.Sprintf(`cpu,host=server03 value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+			fmt.Sprintf(`cpu,host=server03 value=5 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+			fmt.Sprintf(`cpu,host=server02 value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+			fmt.Sprintf(`cpu,host=server01 value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+			fmt.Sprintf(`cpu,host=server05 value=6 %d`, mustParseTime(time.RFC3339Nano, "2000-01-10T00:00:00Z").UnixNano()),
+		}, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "fill then limit and offset",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:00Z' GROUP BY time(10s), host fill(0) LIMIT 2 OFFSET 1 SLIMIT 2 SOFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server02"},"columns":["time","mean"],"values":[["2000-01-01T00:00:10Z",0],["2000-01-01T00:00:20Z",2]]},{"name":"cpu","tags":{"host":"server03"},"columns":["time","mean"],"values":[["2000-01-01T00:00:10Z",3],["2000-01-01T00:00:20Z",0]]}]}]}`,
+		},
+		{
+			name:    "fill then limit and offset descending",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:00Z' GROUP BY time(10s), host fill(null) ORDER BY time DESC LIMIT 2 OFFSET 1 SLIMIT 2 SOFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server03"},"columns":["time","mean"],"values":[["2000-01-01T00:00:40Z",5],["2000-01-01T00:00:30Z",null]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","mean"],"values":[["2000-01-01T00:00:40Z",null],["2000-01-01T00:00:30Z",null]]}]}]}`,
+		},
+		{
+			name:    "slimit across shards",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-11T00:00:00Z' GROUP BY host SLIMIT 2 SOFFSET 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server04"},"columns":["time","count"],"values":[["2000-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server05"},"columns":["time","count"],"values":[["2000-01-01T00:00:00Z",1]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_CumulativeCount(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `events signup=t 1005832000
+events signup=t 1048283000
+events signup=t 1784832000
+events signup=t 2000000000
+events signup=t 3084890000
+events signup=t 3838400000
+`},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "cumulative count",
+			command: `SELECT cumulative_sum(count(signup)) from db0.rp0.events where time >= 1s and time < 4s group by time(1s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"events","columns":["time","cumulative_sum"],"values":[["1970-01-01T00:00:01Z",3],["1970-01-01T00:00:02Z",4],["1970-01-01T00:00:03Z",6]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_String(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`stringdata value="first" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
+			fmt.Sprintf(`stringdata value="last" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:04Z").UnixNano()),
+			fmt.Sprintf(`http status="404" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:01Z").UnixNano()),
+			fmt.Sprintf(`http status="200" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:02Z").UnixNano()),
+			fmt.Sprintf(`http status="200" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
+			fmt.Sprintf(`http status="500" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:04Z").UnixNano()),
+			fmt.Sprintf(`http status="200" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:05Z").UnixNano()),
+			fmt.Sprintf(`http_tie status="ok" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:01Z").UnixNano()),
+			fmt.Sprintf(`http_tie status="error" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:02Z").UnixNano()),
+			fmt.Sprintf(`http_tie status="error" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
+			fmt.Sprintf(`http_tie status="ok" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:04Z").UnixNano()),
+		}, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		// strings
+		{
+			name:    "STDDEV on string data - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT STDDEV(value) FROM stringdata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","stddev"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
+			skip:    "Skipped OSS", // FIXME(benbjohnson): allow non-float var ref expr in cursor iterator
+		},
+		{
+			name:    "MEAN on string data - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MEAN(value) FROM stringdata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",0]]}]}]}`,
+			skip:    "Skipped OSS", // FIXME(benbjohnson): allow non-float var ref expr in cursor iterator
+		},
+		{
+			name:    "MEDIAN on string data - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MEDIAN(value) FROM stringdata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
+			skip:    "Skipped OSS", // FIXME(benbjohnson): allow non-float var ref expr in cursor iterator
+		},
+		{
+			name:    "COUNT on string data - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT COUNT(value) FROM stringdata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
+			skip:    "Skipped OSS", // FIXME(benbjohnson): allow non-float var ref expr in cursor iterator
+		},
+		{
+			name:    "FIRST on string data - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT FIRST(value) FROM stringdata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","first"],"values":[["2000-01-01T00:00:03Z","first"]]}]}]}`,
+		},
+		{
+			name:    "LAST on string data - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT LAST(value) FROM stringdata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","last"],"values":[["2000-01-01T00:00:04Z","last"]]}]}]}`,
+		},
+		{
+			name:    "FIRST and LAST on string data grouped by time - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT FIRST(value), LAST(value) FROM stringdata WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:06Z' GROUP BY time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","first","last"],"values":[["2000-01-01T00:00:00Z",null,null],["2000-01-01T00:00:02Z","first","first"],["2000-01-01T00:00:04Z","last","last"]]}]}]}`,
+		},
+		{
+			name:    "FIRST on string data grouped by time with fill(none) - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT FIRST(value) FROM stringdata WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:10Z' GROUP BY time(1s) fill(none)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","first"],"values":[["2000-01-01T00:00:03Z","first"],["2000-01-01T00:00:04Z","last"]]}]}]}`,
+		},
+		{
+			name:    "MIN on string data - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MIN(value) FROM stringdata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","min"],"values":[["2000-01-01T00:00:03Z","first"]]}]}]}`,
+		},
+		{
+			name:    "MAX on string data - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MAX(value) FROM stringdata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","max"],"values":[["2000-01-01T00:00:04Z","last"]]}]}]}`,
+		},
+		{
+			name:    "MIN and MAX on string data grouped by time - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MIN(value), MAX(value) FROM stringdata WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:10Z' GROUP BY time(10s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","min","max"],"values":[["2000-01-01T00:00:00Z","first","last"]]}]}]}`,
+		},
+		{
+			name:    "MIN on string expression - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MIN(value + 'x') FROM stringdata`,
+			exp:     `{"results":[{"statement_id":0,"error":"expected field argument in min()"}]}`,
+		},
+		{
+			name:    "MODE on string data - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MODE(status) FROM http`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"http","columns":["time","mode"],"values":[["1970-01-01T00:00:00Z","200"]]}]}]}`,
+		},
+		{
+			name:    "MODE on string data with a tie - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MODE(status) FROM http_tie`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"http_tie","columns":["time","mode"],"values":[["1970-01-01T00:00:00Z","ok"]]}]}]}`,
+		},
+		{
+			name:    "MODE on string data with a single value tie - string",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MODE(value) FROM stringdata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"stringdata","columns":["time","mode"],"values":[["1970-01-01T00:00:00Z","first"]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_Boolean(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`booldata value=true %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:01Z").UnixNano()),
+			fmt.Sprintf(`booldata value=false %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:02Z").UnixNano()),
+			fmt.Sprintf(`booldata value=true %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
+			fmt.Sprintf(`booldata value=false %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:04Z").UnixNano()),
+		}, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "MIN on boolean data - boolean",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MIN(value) FROM booldata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"booldata","columns":["time","min"],"values":[["2000-01-01T00:00:02Z",false]]}]}]}`,
+		},
+		{
+			name:    "MAX on boolean data - boolean",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MAX(value) FROM booldata`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"booldata","columns":["time","max"],"values":[["2000-01-01T00:00:01Z",true]]}]}]}`,
+		},
+		{
+			name:    "MIN and MAX on boolean data grouped by time - boolean",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MIN(value), MAX(value) FROM booldata WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:06Z' GROUP BY time(2s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"booldata","columns":["time","min","max"],"values":[["2000-01-01T00:00:00Z",true,true],["2000-01-01T00:00:02Z",false,true],["2000-01-01T00:00:04Z",false,false]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_AggregateSelectors(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`network,host=server01,region=west,core=1 rx=10i,tx=20i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`network,host=server02,region=west,core=2 rx=40i,tx=50i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`network,host=server03,region=east,core=3 rx=40i,tx=55i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		fmt.Sprintf(`network,host=server04,region=east,core=4 rx=40i,tx=60i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+		fmt.Sprintf(`network,host=server05,region=west,core=1 rx=50i,tx=70i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+		fmt.Sprintf(`network,host=server06,region=east,core=2 rx=50i,tx=40i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
+		fmt.Sprintf(`network,host=server07,region=west,core=3 rx=70i,tx=30i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`network,host=server08,region=east,core=4 rx=90i,tx=10i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
+		fmt.Sprintf(`network,host=server09,region=east,core=1 rx=5i,tx=4i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:20Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "baseline",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT * FROM network`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","core","core_1","host","region","rx","tx"],"values":[["2000-01-01T00:00:00Z",2,"1","server01","west",10,20],["2000-01-01T00:00:10Z",3,"2","server02","west",40,50],["2000-01-01T00:00:20Z",4,"3","server03","east",40,55],["2000-01-01T00:00:30Z",1,"4","server04","east",40,60],["2000-01-01T00:00:40Z",2,"1","server05","west",50,70],["2000-01-01T00:00:50Z",3,"2","server06","east",50,40],["2000-01-01T00:01:00Z",4,"3","server07","west",70,30],["2000-01-01T00:01:10Z",1,"4","server08","east",90,10],["2000-01-01T00:01:20Z",2,"1","server09","east",5,4]]}]}]}`,
+		},
+		{
+			name:    "max - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",90]]}]}]}`,
+		},
+		{
+			name:    "max - baseline 30s - epoch ms",
+			params:  url.Values{"db": []string{"db0"}, "epoch": []string{"ms"}},
+			command: `SELECT max(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp: fmt.Sprintf(
+				`{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max"],"values":[[%d,40],[%d,50],[%d,90]]}]}]}`,
+				mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()/int64(time.Millisecond),
+				mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()/int64(time.Millisecond),
+				mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()/int64(time.Millisecond),
+			),
+		},
+		{
+			name:    "max - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, max(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","max"],"values":[["2000-01-01T00:00:00Z",50,40],["2000-01-01T00:00:30Z",70,50],["2000-01-01T00:01:00Z",10,90]]}]}]}`,
+		},
+		{
+			name:    "max - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, max(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max"],"values":[["2000-01-01T00:00:10Z",40],["2000-01-01T00:00:40Z",50],["2000-01-01T00:01:10Z",90]]}]}]}`,
+		},
+		{
+			name:    "max - time and tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, tx, max(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","max"],"values":[["2000-01-01T00:00:10Z",50,40],["2000-01-01T00:00:40Z",70,50],["2000-01-01T00:01:10Z",10,90]]}]}]}`,
+		},
+		{
+			name:    "min - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT min(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","min"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:30Z",40],["2000-01-01T00:01:00Z",5]]}]}]}`,
+		},
+		{
+			name:    "min - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, min(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","min"],"values":[["2000-01-01T00:00:00Z",20,10],["2000-01-01T00:00:30Z",60,40],["2000-01-01T00:01:00Z",4,5]]}]}]}`,
+		},
+		{
+			name:    "min - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, min(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","min"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:30Z",40],["2000-01-01T00:01:20Z",5]]}]}]}`,
+		},
+		{
+			name:    "min - time and tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, tx, min(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","min"],"values":[["2000-01-01T00:00:00Z",20,10],["2000-01-01T00:00:30Z",60,40],["2000-01-01T00:01:20Z",4,5]]}]}]}`,
+		},
+		{
+			name:    "max,min - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max(rx), min(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max","min"],"values":[["2000-01-01T00:00:00Z",40,10],["2000-01-01T00:00:30Z",50,40],["2000-01-01T00:01:00Z",90,5]]}]}]}`,
+		},
+		{
+			name:    "first - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT first(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:30Z",40],["2000-01-01T00:01:00Z",70]]}]}]}`,
+		},
+		{
+			name:    "first - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, tx, first(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","first"],"values":[["2000-01-01T00:00:00Z",20,10],["2000-01-01T00:00:30Z",60,40],["2000-01-01T00:01:00Z",30,70]]}]}]}`,
+		},
+		{
+			name:    "first - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, first(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:30Z",40],["2000-01-01T00:01:00Z",70]]}]}]}`,
+		},
+		{
+			name:    "first - time and tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, tx, first(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","first"],"values":[["2000-01-01T00:00:00Z",20,10],["2000-01-01T00:00:30Z",60,40],["2000-01-01T00:01:00Z",30,70]]}]}]}`,
+		},
+		{
+			name:    "last - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT last(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","last"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",5]]}]}]}`,
+		},
+		{
+			name:    "last - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, last(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","last"],"values":[["2000-01-01T00:00:00Z",55,40],["2000-01-01T00:00:30Z",40,50],["2000-01-01T00:01:00Z",4,5]]}]}]}`,
+		},
+		{
+			name:    "last - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, last(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","last"],"values":[["2000-01-01T00:00:20Z",40],["2000-01-01T00:00:50Z",50],["2000-01-01T00:01:20Z",5]]}]}]}`,
+		},
+		{
+			name:    "last - time and tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, tx, last(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","last"],"values":[["2000-01-01T00:00:20Z",55,40],["2000-01-01T00:00:50Z",40,50],["2000-01-01T00:01:20Z",4,5]]}]}]}`,
+		},
+		{
+			name:    "count - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",3],["2000-01-01T00:00:30Z",3],["2000-01-01T00:01:00Z",3]]}]}]}`,
+		},
+		{
+			name:    "count - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, count(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",3],["2000-01-01T00:00:30Z",3],["2000-01-01T00:01:00Z",3]]}]}]}`,
+		},
+		{
+			name:    "count - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, count(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+		},
+		{
+			name:    "distinct - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT distinct(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","distinct"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70],["2000-01-01T00:01:00Z",90],["2000-01-01T00:01:00Z",5]]}]}]}`,
+		},
+		{
+			name:    "distinct - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, distinct(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","distinct"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70],["2000-01-01T00:01:00Z",90],["2000-01-01T00:01:00Z",5]]}]}]}`,
+		},
+		{
+			name:    "distinct - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, distinct(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"error":"aggregate function distinct() cannot be combined with other functions or fields"}]}`,
+		},
+		{
+			name:    "distinct - with count of distinct",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT distinct(rx), count(distinct(rx)) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","distinct","count"],"values":[["2000-01-01T00:00:00Z",10,2],["2000-01-01T00:00:00Z",40,2],["2000-01-01T00:00:30Z",40,2],["2000-01-01T00:00:30Z",50,2],["2000-01-01T00:01:00Z",70,3],["2000-01-01T00:01:00Z",90,3],["2000-01-01T00:01:00Z",5,3]]}]}]}`,
+		},
+		{
+			name:    "mean - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT mean(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",30],["2000-01-01T00:00:30Z",46.666666666666664],["2000-01-01T00:01:00Z",55]]}]}]}`,
+		},
+		{
+			name:    "mean - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, mean(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",30],["2000-01-01T00:00:30Z",46.666666666666664],["2000-01-01T00:01:00Z",55]]}]}]}`,
+		},
+		{
+			name:    "mean - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, mean(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+		},
+		{
+			name:    "median - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT median(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","median"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70]]}]}]}`,
+		},
+		{
+			name:    "median - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, median(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","median"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70]]}]}]}`,
+		},
+		{
+			name:    "median - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, median(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+		},
+		{
+			name:    "mode - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT mode(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","mode"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",5]]}]}]}`,
+		},
+		{
+			name:    "mode - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, mode(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","mode"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",5]]}]}]}`,
+		},
+		{
+			name:    "mode - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, mode(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+		},
+		{
+			name:    "modes - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT modes(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","modes"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",5],["2000-01-01T00:01:00Z",70],["2000-01-01T00:01:00Z",90]]}]}]}`,
+		},
+		{
+			name:    "modes - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, modes(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"error":"aggregate function modes() cannot be combined with other functions or fields"}]}`,
+		},
+		{
+			name:    "spread - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT spread(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","spread"],"values":[["2000-01-01T00:00:00Z",30],["2000-01-01T00:00:30Z",10],["2000-01-01T00:01:00Z",85]]}]}]}`,
+		},
+		{
+			name:    "spread - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, spread(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","spread"],"values":[["2000-01-01T00:00:00Z",30],["2000-01-01T00:00:30Z",10],["2000-01-01T00:01:00Z",85]]}]}]}`,
+		},
+		{
+			name:    "spread - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, spread(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+		},
+		{
+			name:    "stddev - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT stddev(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","stddev"],"values":[["2000-01-01T00:00:00Z",17.320508075688775],["2000-01-01T00:00:30Z",5.773502691896258],["2000-01-01T00:01:00Z",44.44097208657794]]}]}]}`,
+		},
+		{
+			name:    "stddev - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, stddev(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","stddev"],"values":[["2000-01-01T00:00:00Z",17.320508075688775],["2000-01-01T00:00:30Z",5.773502691896258],["2000-01-01T00:01:00Z",44.44097208657794]]}]}]}`,
+		},
+		{
+			name:    "stddev - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, stddev(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+		},
+		{
+			name:    "percentile - baseline 30s",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT percentile(rx, 75) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","percentile"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70]]}]}]}`,
+		},
+		{
+			name:    "percentile - time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT time, percentile(rx, 75) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","percentile"],"values":[["2000-01-01T00:00:10Z",40],["2000-01-01T00:00:40Z",50],["2000-01-01T00:01:00Z",70]]}]}]}`,
+		},
+		{
+			name:    "percentile - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT tx, percentile(rx, 75) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","percentile"],"values":[["2000-01-01T00:00:00Z",50,40],["2000-01-01T00:00:30Z",70,50],["2000-01-01T00:01:00Z",30,70]]}]}]}`,
+		},
+		{
+			name:    "percentile - host",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT percentile(rx, 75), host FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","percentile","host"],"values":[["2000-01-01T00:00:00Z",40,"server02"],["2000-01-01T00:00:30Z",50,"server05"],["2000-01-01T00:01:00Z",70,"server07"]]}]}]}`,
+		},
+		{
+			name:    "percentile - host and tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT percentile(rx, 95), host, tx FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","percentile","host","tx"],"values":[["2000-01-01T00:01:10Z",90,"server08",10]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Selectors(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`network,host=server01,region=west,core=1 rx=10i,tx=20i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`network,host=server02,region=west,core=2 rx=40i,tx=50i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`network,host=server03,region=east,core=3 rx=40i,tx=55i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		fmt.Sprintf(`network,host=server04,region=east,core=4 rx=40i,tx=60i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+		fmt.Sprintf(`network,host=server05,region=west,core=1 rx=50i,tx=70i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+		fmt.Sprintf(`network,host=server06,region=east,core=2 rx=50i,tx=40i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
+		fmt.Sprintf(`network,host=server07,region=west,core=3 rx=70i,tx=30i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`network,host=server08,region=east,core=4 rx=90i,tx=10i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
+		fmt.Sprintf(`network,host=server09,region=east,core=1 rx=5i,tx=4i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:20Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "max - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max(tx) FROM network`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max"],"values":[["2000-01-01T00:00:40Z",70]]}]}]}`,
+		},
+		{
+			name:    "min - tx",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT min(tx) FROM network`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","min"],"values":[["2000-01-01T00:01:20Z",4]]}]}]}`,
+		},
+		{
+			name:    "first",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT first(tx) FROM network`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",20]]}]}]}`,
+		},
+		{
+			name:    "last",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT last(tx) FROM network`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","last"],"values":[["2000-01-01T00:01:20Z",4]]}]}]}`,
+		},
+		{
+			name:    "percentile",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT percentile(tx, 50) FROM network`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","percentile"],"values":[["2000-01-01T00:00:50Z",40]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_TopBottomInt(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		// cpu data with overlapping duplicate values
+		// hour 0
+		fmt.Sprintf(`cpu,host=server01 value=2.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=3.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server03 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		// hour 1
+		fmt.Sprintf(`cpu,host=server04 value=3.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server05 value=7.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server06 value=6.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:20Z").UnixNano()),
+		// hour 2
+		fmt.Sprintf(`cpu,host=server07 value=7.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T02:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server08 value=9.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T02:00:10Z").UnixNano()),
+
+		// memory data
+		// hour 0
+		fmt.Sprintf(`memory,host=a,service=redis value=1000i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`memory,host=b,service=mysql value=2000i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`memory,host=b,service=redis value=1500i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		// hour 1
+		fmt.Sprintf(`memory,host=a,service=redis value=1001i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:00Z").UnixNano()),
+		fmt.Sprintf(`memory,host=b,service=mysql value=2001i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:00Z").UnixNano()),
+		fmt.Sprintf(`memory,host=b,service=redis value=1501i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:00Z").UnixNano()),
+		// hour 2
+		fmt.Sprintf(`memory,host=a,service=redis value=1002i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T02:00:00Z").UnixNano()),
+		fmt.Sprintf(`memory,host=b,service=mysql value=2002i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T02:00:00Z").UnixNano()),
+		fmt.Sprintf(`memory,host=b,service=redis value=1502i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T02:00:00Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "top - cpu",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, 1) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T02:00:10Z",9]]}]}]}`,
+		},
+		{
+			name:    "bottom - cpu",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, 1) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
+		},
+		{
+			name:    "top - cpu - 2 values",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, 2) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T01:00:10Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+		},
+		{
+			name:    "bottom - cpu - 2 values",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, 2) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3]]}]}]}`,
+		},
+		{
+			name:    "top - cpu - 3 values - sorts on tie properly",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, 3) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T01:00:10Z",7],["2000-01-01T02:00:00Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+		},
+		{
+			name:    "bottom - cpu - 3 values - sorts on tie properly",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, 3) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3],["2000-01-01T01:00:00Z",3]]}]}]}`,
+		},
+		{
+			name:    "top - cpu - 3 values - ordered by value",
+			params:  url.Values{"db": []string{"db0"}, "order_values": []string{"true"}},
+			command: `SELECT TOP(value, 3) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T02:00:10Z",9],["2000-01-01T01:00:10Z",7],["2000-01-01T02:00:00Z",7]]}]}]}`,
+		},
+		{
+			name:    "bottom - cpu - 3 values - ordered by value",
+			params:  url.Values{"db": []string{"db0"}, "order_values": []string{"true"}},
+			command: `SELECT BOTTOM(value, 3) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3],["2000-01-01T01:00:00Z",3]]}]}]}`,
+		},
+		{
+			name:    "top - cpu - 2 values hourly - ordered by value",
+			params:  url.Values{"db": []string{"db0"}, "order_values": []string{"true"}},
+			command: `SELECT TOP(value, 2) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T00:00:20Z",4],["2000-01-01T00:00:10Z",3],["2000-01-01T01:00:10Z",7],["2000-01-01T01:00:20Z",6],["2000-01-01T02:00:10Z",9],["2000-01-01T02:00:00Z",7]]}]}]}`,
+		},
+		{
+			name:    "top - cpu - with tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, host, 2) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top","host"],"values":[["2000-01-01T01:00:10Z",7,"server05"],["2000-01-01T02:00:10Z",9,"server08"]]}]}]}`,
+		},
+		{
+			name:    "bottom - cpu - with tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, host, 2) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom","host"],"values":[["2000-01-01T00:00:00Z",2,"server01"],["2000-01-01T00:00:10Z",3,"server02"]]}]}]}`,
+		},
+		{
+			name:    "top - cpu - 3 values with limit 2",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, 3) FROM cpu limit 2`,
+			exp:     `{"results":[{"statement_id":0,"error":"limit (3) in top function can not be larger than the LIMIT (2) in the select statement"}]}`,
+		},
+		{
+			name:    "bottom - cpu - 3 values with limit 2",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, 3) FROM cpu limit 2`,
+			exp:     `{"results":[{"statement_id":0,"error":"limit (3) in bottom function can not be larger than the LIMIT (2) in the select statement"}]}`,
+		},
+		{
+			name:    "top - cpu - hourly",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, 1) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T00:00:20Z",4],["2000-01-01T01:00:10Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+		},
+		{
+			name:    "bottom - cpu - hourly",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, 1) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T01:00:00Z",3],["2000-01-01T02:00:00Z",7]]}]}]}`,
+		},
+		{
+			name:    "top - cpu - 2 values hourly",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, 2) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T00:00:10Z",3],["2000-01-01T00:00:20Z",4],["2000-01-01T01:00:10Z",7],["2000-01-01T01:00:20Z",6],["2000-01-01T02:00:00Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+		},
+		{
+			name:    "bottom - cpu - 2 values hourly",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, 2) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3],["2000-01-01T01:00:00Z",3],["2000-01-01T01:00:20Z",6],["2000-01-01T02:00:00Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+		},
+		{
+			name:    "top - cpu - 3 values hourly - validates that a bucket can have less than limit if no values exist in that time bucket",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, 3) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3],["2000-01-01T00:00:20Z",4],["2000-01-01T01:00:00Z",3],["2000-01-01T01:00:10Z",7],["2000-01-01T01:00:20Z",6],["2000-01-01T02:00:00Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+		},
+		{
+			name:    "bottom - cpu - 3 values hourly - validates that a bucket can have less than limit if no values exist in that time bucket",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, 3) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3],["2000-01-01T00:00:20Z",4],["2000-01-01T01:00:00Z",3],["2000-01-01T01:00:10Z",7],["2000-01-01T01:00:20Z",6],["2000-01-01T02:00:00Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+		},
+		{
+			name:    "top - memory - 2 values, two tags",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, 2), host, service FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host","service"],"values":[["2000-01-01T01:00:00Z",2001,"b","mysql"],["2000-01-01T02:00:00Z",2002,"b","mysql"]]}]}]}`,
+		},
+		{
+			name:    "bottom - memory - 2 values, two tags",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, 2), host, service FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host","service"],"values":[["2000-01-01T00:00:00Z",1000,"a","redis"],["2000-01-01T01:00:00Z",1001,"a","redis"]]}]}]}`,
+		},
+		{
+			name:    "top - memory - host tag with limit 2",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, host, 2) FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host"],"values":[["2000-01-01T02:00:00Z",2002,"b"],["2000-01-01T02:00:00Z",1002,"a"]]}]}]}`,
+		},
+		{
+			name:    "bottom - memory - host tag with limit 2",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, host, 2) FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host"],"values":[["2000-01-01T00:00:00Z",1000,"a"],["2000-01-01T00:00:00Z",1500,"b"]]}]}]}`,
+		},
+		{
+			name:    "top - memory - host tag with limit 2, service tag in select",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, host, 2), service FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host","service"],"values":[["2000-01-01T02:00:00Z",2002,"b","mysql"],["2000-01-01T02:00:00Z",1002,"a","redis"]]}]}]}`,
+		},
+		{
+			name:    "bottom - memory - host tag with limit 2, service tag in select",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, host, 2), service FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host","service"],"values":[["2000-01-01T00:00:00Z",1000,"a","redis"],["2000-01-01T00:00:00Z",1500,"b","redis"]]}]}]}`,
+		},
+		{
+			name:    "top - memory - service tag with limit 2, host tag in select",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, service, 2), host FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","service","host"],"values":[["2000-01-01T02:00:00Z",2002,"mysql","b"],["2000-01-01T02:00:00Z",1502,"redis","b"]]}]}]}`,
+		},
+		{
+			name:    "bottom - memory - service tag with limit 2, host tag in select",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, service, 2), host FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","service","host"],"values":[["2000-01-01T00:00:00Z",1000,"redis","a"],["2000-01-01T00:00:00Z",2000,"mysql","b"]]}]}]}`,
+		},
+		{
+			name:    "top - memory - host and service tag with limit 2",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, host, service, 2) FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host","service"],"values":[["2000-01-01T02:00:00Z",2002,"b","mysql"],["2000-01-01T02:00:00Z",1502,"b","redis"]]}]}]}`,
+		},
+		{
+			name:    "bottom - memory - host and service tag with limit 2",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, host, service, 2) FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host","service"],"values":[["2000-01-01T00:00:00Z",1000,"a","redis"],["2000-01-01T00:00:00Z",1500,"b","redis"]]}]}]}`,
+		},
+		{
+			name:    "top - memory - host tag with limit 2 with service tag in select",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, host, 2), service FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host","service"],"values":[["2000-01-01T02:00:00Z",2002,"b","mysql"],["2000-01-01T02:00:00Z",1002,"a","redis"]]}]}]}`,
+		},
+		{
+			name:    "bottom - memory - host tag with limit 2 with service tag in select",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, host, 2), service FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host","service"],"values":[["2000-01-01T00:00:00Z",1000,"a","redis"],["2000-01-01T00:00:00Z",1500,"b","redis"]]}]}]}`,
+		},
+		{
+			name:    "top - memory - host and service tag with limit 3",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT TOP(value, host, service, 3) FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host","service"],"values":[["2000-01-01T02:00:00Z",2002,"b","mysql"],["2000-01-01T02:00:00Z",1502,"b","redis"],["2000-01-01T02:00:00Z",1002,"a","redis"]]}]}]}`,
+		},
+		{
+			name:    "bottom - memory - host and service tag with limit 3",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT BOTTOM(value, host, service, 3) FROM memory`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host","service"],"values":[["2000-01-01T00:00:00Z",1000,"a","redis"],["2000-01-01T00:00:00Z",1500,"b","redis"],["2000-01-01T00:00:00Z",2000,"b","mysql"]]}]}]}`,
+		},
+
+		// TODO
+		// - Test that specifiying fields or tags in the function will rewrite the query to expand them to the fields
+		// - Test that a field can be used in the top function
+		// - Test that asking for a field will come back before a tag if they have the same name for a tag and a field
+		// - Test that `select top(value, host, 2)` when there is only one value for `host` it will only bring back one value
+		// - Test that `select top(value, host, 4) from foo where time > now() - 1d and time < now() group by time(1h)` and host is unique in some time buckets that it returns only the unique ones, and not always 4 values
+
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_ExactTimeRange(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00.000000000Z").UnixNano()),
+		fmt.Sprintf(`cpu value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00.000000001Z").UnixNano()),
+		fmt.Sprintf(`cpu value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00.000000002Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "query point at exactly one time - rfc3339nano",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT last(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","last"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",5]]}]}]}`,
+			command: `SELECT * FROM cpu WHERE time = '2000-01-01T00:00:00.000000001Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00.000000001Z",2]]}]}]}`,
 		},
 		{
-			name:    "last - tx",
+			name:    "query point at exactly one time - timestamp",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, last(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","last"],"values":[["2000-01-01T00:00:00Z",55,40],["2000-01-01T00:00:30Z",40,50],["2000-01-01T00:01:00Z",4,5]]}]}]}`,
+			command: `SELECT * FROM cpu WHERE time = 946684800000000001`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00.000000001Z",2]]}]}]}`,
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_Math(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`network,host=server01,region=west,core=1 rx=10i,tx=20i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`network,host=server02,region=west,core=2 rx=40i,tx=50i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`network,host=server03,region=east,core=3 rx=40i,tx=55i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+		fmt.Sprintf(`network,host=server04,region=east,core=4 rx=40i,tx=60i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+		fmt.Sprintf(`network,host=server05,region=west,core=1 rx=50i,tx=70i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+		fmt.Sprintf(`network,host=server06,region=east,core=2 rx=50i,tx=40i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
+		fmt.Sprintf(`network,host=server07,region=west,core=3 rx=70i,tx=30i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+		fmt.Sprintf(`network,host=server08,region=east,core=4 rx=90i,tx=10i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
+		fmt.Sprintf(`network,host=server09,region=east,core=1 rx=5i,tx=4i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:20Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "last - time",
+			name:    "add two selectors",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, last(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","last"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",5]]}]}]}`,
+			command: `SELECT max(rx) + min(rx) FROM network WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:30Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max_min"],"values":[["2000-01-01T00:00:00Z",95]]}]}]}`,
 		},
 		{
-			name:    "last - time and tx",
+			name:    "use math one two selectors separately",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, tx, last(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","last"],"values":[["2000-01-01T00:00:00Z",55,40],["2000-01-01T00:00:30Z",40,50],["2000-01-01T00:01:00Z",4,5]]}]}]}`,
+			command: `SELECT max(rx) * 1, min(rx) * 1 FROM network WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:30Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max","min"],"values":[["2000-01-01T00:00:00Z",90,5]]}]}]}`,
 		},
 		{
-			name:    "count - baseline 30s",
+			name:    "math with a single selector",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT count(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",3],["2000-01-01T00:00:30Z",3],["2000-01-01T00:01:00Z",3]]}]}]}`,
+			command: `SELECT max(rx) * 1 FROM network WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:30Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max"],"values":[["2000-01-01T00:01:10Z",90]]}]}]}`,
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_TrimmedMean(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`sensor,host=a value=10 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=a value=1000 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:01Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=a value=11 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:02Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=a value=12 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=a value=-1000 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:04Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=a value=13 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:05Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=a value=14 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:06Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=a value=15 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:07Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=a value=16 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:08Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=a value=17 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:09Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=b value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=b value=5 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:01Z").UnixNano()),
+		fmt.Sprintf(`sensor,host=b value=9 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:02Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "count - time",
+			name:    "trim outliers",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, count(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",3],["2000-01-01T00:00:30Z",3],["2000-01-01T00:01:00Z",3]]}]}]}`,
+			command: `SELECT trimmed_mean(value, 10) FROM sensor WHERE host = 'a'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"sensor","columns":["time","trimmed_mean"],"values":[["1970-01-01T00:00:00Z",13.5]]}]}]}`,
 		},
 		{
-			name:    "count - tx",
+			name:    "no trimming",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, count(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+			command: `SELECT trimmed_mean(value, 0), mean(value) FROM sensor WHERE host = 'a'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"sensor","columns":["time","trimmed_mean","mean"],"values":[["1970-01-01T00:00:00Z",10.8,10.8]]}]}]}`,
 		},
 		{
-			name:    "distinct - baseline 30s",
+			name:    "group too small to trim",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT distinct(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","distinct"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70],["2000-01-01T00:01:00Z",90],["2000-01-01T00:01:00Z",5]]}]}]}`,
+			command: `SELECT trimmed_mean(value, 10) FROM sensor GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"sensor","tags":{"host":"a"},"columns":["time","trimmed_mean"],"values":[["1970-01-01T00:00:00Z",13.5]]},{"name":"sensor","tags":{"host":"b"},"columns":["time","trimmed_mean"],"values":[["1970-01-01T00:00:00Z",6]]}]}]}`,
 		},
 		{
-			name:    "distinct - time",
+			name:    "percentage too large",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, distinct(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","distinct"],"values":[["2000-01-01T00:00:00Z",10],["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70],["2000-01-01T00:01:00Z",90],["2000-01-01T00:01:00Z",5]]}]}]}`,
+			command: `SELECT trimmed_mean(value, 50) FROM sensor`,
+			exp:     `{"results":[{"statement_id":0,"error":"trimmed_mean percentage must be at least 0 and less than 50, got 50"}]}`,
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_Precision(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		`cpu,host=server01 value=1 946684800000000000`,
+		`cpu,host=server01 value=2 946684801000000000`,
+		`cpu,host=server02 value=1 946684800000000000`,
+		`cpu,host=server02 value=2 946684800250000000`,
+		`cpu,host=server03 value=1 946684800000000000`,
+		`cpu,host=server03 value=2 946684800000125000`,
+		`cpu,host=server03 value=3 946684800500000000`,
+		`cpu,host=server04 value=1 946684800000000001`,
+		`cpu,host=server04 value=2 946684801000000000`,
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "distinct - tx",
+			name:    "precision per series",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, distinct(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"error":"aggregate function distinct() cannot be combined with other functions or fields"}]}`,
+			command: `SELECT precision(value) FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","precision"],"values":[["1970-01-01T00:00:00Z","s"]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","precision"],"values":[["1970-01-01T00:00:00Z","ms"]]},{"name":"cpu","tags":{"host":"server03"},"columns":["time","precision"],"values":[["1970-01-01T00:00:00Z","us"]]},{"name":"cpu","tags":{"host":"server04"},"columns":["time","precision"],"values":[["1970-01-01T00:00:00Z","ns"]]}]}]}`,
 		},
 		{
-			name:    "mean - baseline 30s",
+			name:    "precision across series",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT mean(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",30],["2000-01-01T00:00:30Z",46.666666666666664],["2000-01-01T00:01:00Z",55]]}]}]}`,
+			command: `SELECT precision(value) FROM cpu WHERE host = 'server01' OR host = 'server02'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","precision"],"values":[["1970-01-01T00:00:00Z","ms"]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_IQR(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01 value=12 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=7 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:01Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:02Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=4.5 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=9 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:04Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=15 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:05Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:06Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=10 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:07Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=100 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=300 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:02Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=200 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:04Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=400 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:06Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "iqr grouped by host",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT iqr(value), percentile(value, 75) - percentile(value, 25) AS diff FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","iqr","diff"],"values":[["1970-01-01T00:00:00Z",7,7]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","iqr","diff"],"values":[["1970-01-01T00:00:00Z",200,200]]}]}]}`,
 		},
 		{
-			name:    "mean - time",
+			name:    "iqr grouped by time",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT iqr(value) FROM cpu WHERE host = 'server01' AND time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:10Z' GROUP BY time(4s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","iqr"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:04Z",9],["2000-01-01T00:00:08Z",null]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_PercentOfTotal(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01 value=10 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=20 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=30 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server03 value=60 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "percent_of_total grouped by host",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value), percent_of_total(sum(value)) FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","sum","percent_of_total"],"values":[["1970-01-01T00:00:00Z",30,25]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","sum","percent_of_total"],"values":[["1970-01-01T00:00:00Z",30,25]]},{"name":"cpu","tags":{"host":"server03"},"columns":["time","sum","percent_of_total"],"values":[["1970-01-01T00:00:00Z",60,50]]}]}]}`,
+		},
+		{
+			name:    "percent_of_total grouped by time and host",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value), percent_of_total(sum(value)) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:20Z' GROUP BY time(10s), host fill(none)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","sum","percent_of_total"],"values":[["2000-01-01T00:00:00Z",10,25],["2000-01-01T00:00:10Z",20,25]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","sum","percent_of_total"],"values":[["2000-01-01T00:00:00Z",30,75]]},{"name":"cpu","tags":{"host":"server03"},"columns":["time","sum","percent_of_total"],"values":[["2000-01-01T00:00:10Z",60,75]]}]}]}`,
+		},
+		{
+			name:    "percent_of_total requires an aggregate",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT percent_of_total(value) FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"error":"aggregate function required inside the call to percent_of_total"}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_CPU(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`cpu,region=uk,host=serverZ,service=redis value=20.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
+			fmt.Sprintf(`cpu,region=uk,host=serverZ,service=mysql value=30.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
+		}, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "aggregation with WHERE and AND",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value) FROM cpu WHERE region='uk' AND host='serverZ'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",50]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_IntMany(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`intmany,host=server01 value=2.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server02 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server03 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server04 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server05 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server06 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server07 value=7.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server08 value=9.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
+		}, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "mean and stddev - int",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT MEAN(value), STDDEV(value) FROM intmany WHERE time >= '2000-01-01' AND time < '2000-01-01T00:02:00Z' GROUP BY time(10m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","mean","stddev"],"values":[["2000-01-01T00:00:00Z",5,2.138089935299395]]}]}]}`,
+		},
+		{
+			name:    "stddev and stddev_pop - int",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT STDDEV(value), STDDEV_POP(value) FROM intmany WHERE time >= '2000-01-01' AND time < '2000-01-01T00:02:00Z' GROUP BY time(10m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","stddev","stddev_pop"],"values":[["2000-01-01T00:00:00Z",2.138089935299395,2]]}]}]}`,
+		},
+		{
+			name:    "first - int",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT FIRST(value) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
+		},
+		{
+			name:    "first - int - epoch ms",
+			params:  url.Values{"db": []string{"db0"}, "epoch": []string{"ms"}},
+			command: `SELECT FIRST(value) FROM intmany`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","first"],"values":[[%d,2]]}]}]}`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()/int64(time.Millisecond)),
+		},
+		{
+			name:    "last - int",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT LAST(value) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","last"],"values":[["2000-01-01T00:01:10Z",9]]}]}]}`,
+		},
+		{
+			name:    "spread - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, mean(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",30],["2000-01-01T00:00:30Z",46.666666666666664],["2000-01-01T00:01:00Z",55]]}]}]}`,
+			command: `SELECT SPREAD(value) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","spread"],"values":[["1970-01-01T00:00:00Z",7]]}]}]}`,
 		},
 		{
-			name:    "mean - tx",
+			name:    "median - even count - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, mean(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+			command: `SELECT MEDIAN(value) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",4.5]]}]}]}`,
 		},
 		{
-			name:    "median - baseline 30s",
+			name:    "median - odd count - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT median(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","median"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70]]}]}]}`,
+			command: `SELECT MEDIAN(value) FROM intmany where time < '2000-01-01T00:01:10Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
 		},
 		{
-			name:    "median - time",
+			name:    "median - even count per bucket - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, median(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","median"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70]]}]}]}`,
+			command: `SELECT MEDIAN(value) FROM intmany WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:20Z' GROUP BY time(40s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","median"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:40Z",6]]}]}]}`,
 		},
 		{
-			name:    "median - tx",
+			name:    "median - odd and even count per bucket - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, median(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+			command: `SELECT MEDIAN(value) FROM intmany WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:30Z' GROUP BY time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","median"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:30Z",5],["2000-01-01T00:01:00Z",8]]}]}]}`,
 		},
 		{
-			name:    "mode - baseline 30s",
+			name:    "mode - single - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT mode(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","mode"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",5]]}]}]}`,
+			command: `SELECT MODE(value) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","mode"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
 		},
 		{
-			name:    "mode - time",
+			name:    "mode - multiple - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, mode(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","mode"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",5]]}]}]}`,
+			command: `SELECT MODE(value) FROM intmany where time < '2000-01-01T00:01:10Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","mode"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
 		},
 		{
-			name:    "mode - tx",
+			name:    "distinct as call - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, mode(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+			command: `SELECT DISTINCT(value) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z",2],["1970-01-01T00:00:00Z",4],["1970-01-01T00:00:00Z",5],["1970-01-01T00:00:00Z",7],["1970-01-01T00:00:00Z",9]]}]}]}`,
 		},
 		{
-			name:    "spread - baseline 30s",
+			name:    "distinct alt syntax - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT spread(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","spread"],"values":[["2000-01-01T00:00:00Z",30],["2000-01-01T00:00:30Z",10],["2000-01-01T00:01:00Z",85]]}]}]}`,
+			command: `SELECT DISTINCT value FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z",2],["1970-01-01T00:00:00Z",4],["1970-01-01T00:00:00Z",5],["1970-01-01T00:00:00Z",7],["1970-01-01T00:00:00Z",9]]}]}]}`,
 		},
 		{
-			name:    "spread - time",
+			name:    "distinct select tag - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, spread(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","spread"],"values":[["2000-01-01T00:00:00Z",30],["2000-01-01T00:00:30Z",10],["2000-01-01T00:01:00Z",85]]}]}]}`,
+			command: `SELECT DISTINCT(host) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z","server01"],["1970-01-01T00:00:00Z","server02"],["1970-01-01T00:00:00Z","server03"],["1970-01-01T00:00:00Z","server04"],["1970-01-01T00:00:00Z","server05"],["1970-01-01T00:00:00Z","server06"],["1970-01-01T00:00:00Z","server07"],["1970-01-01T00:00:00Z","server08"]]}]}]}`,
 		},
 		{
-			name:    "spread - tx",
+			name:    "distinct alt select tag - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, spread(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+			command: `SELECT DISTINCT host FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z","server01"],["1970-01-01T00:00:00Z","server02"],["1970-01-01T00:00:00Z","server03"],["1970-01-01T00:00:00Z","server04"],["1970-01-01T00:00:00Z","server05"],["1970-01-01T00:00:00Z","server06"],["1970-01-01T00:00:00Z","server07"],["1970-01-01T00:00:00Z","server08"]]}]}]}`,
 		},
 		{
-			name:    "stddev - baseline 30s",
+			name:    "count distinct - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT stddev(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","stddev"],"values":[["2000-01-01T00:00:00Z",17.320508075688775],["2000-01-01T00:00:30Z",5.773502691896258],["2000-01-01T00:01:00Z",44.44097208657794]]}]}]}`,
+			command: `SELECT COUNT(DISTINCT value) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
 		},
 		{
-			name:    "stddev - time",
+			name:    "count distinct as call - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, stddev(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","stddev"],"values":[["2000-01-01T00:00:00Z",17.320508075688775],["2000-01-01T00:00:30Z",5.773502691896258],["2000-01-01T00:01:00Z",44.44097208657794]]}]}]}`,
+			command: `SELECT COUNT(DISTINCT(value)) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
 		},
 		{
-			name:    "stddev - tx",
+			name:    "count distinct select tag - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, stddev(rx) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"error":"mixing aggregate and non-aggregate queries is not supported"}]}`,
+			command: `SELECT COUNT(DISTINCT host) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",8]]}]}]}`,
 		},
 		{
-			name:    "percentile - baseline 30s",
+			name:    "count distinct as call select tag - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT percentile(rx, 75) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","percentile"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70]]}]}]}`,
+			command: `SELECT COUNT(DISTINCT(host)) FROM intmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",8]]}]}]}`,
 		},
 		{
-			name:    "percentile - time",
+			name:    "count distinct select tag with time range - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, percentile(rx, 75) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","percentile"],"values":[["2000-01-01T00:00:00Z",40],["2000-01-01T00:00:30Z",50],["2000-01-01T00:01:00Z",70]]}]}]}`,
+			command: `SELECT COUNT(DISTINCT host) FROM intmany WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:10Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",7]]}]}]}`,
 		},
 		{
-			name:    "percentile - tx",
+			name:    "count distinct select tag group by time - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT tx, percentile(rx, 75) FROM network where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:29Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","tx","percentile"],"values":[["2000-01-01T00:00:00Z",50,40],["2000-01-01T00:00:30Z",70,50],["2000-01-01T00:01:00Z",30,70]]}]}]}`,
+			command: `SELECT COUNT(DISTINCT host) FROM intmany WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:30Z' GROUP BY time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",3],["2000-01-01T00:00:30Z",3],["2000-01-01T00:01:00Z",2]]}]}]}`,
 		},
 	}...)
 
@@ -1981,20 +4868,23 @@ func TestServer_Query_AggregateSelectors(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Selectors(t *testing.T) {
+func TestServer_Query_Where_Fields(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
 	writes := []string{
-		fmt.Sprintf(`network,host=server01,region=west,core=1 rx=10i,tx=20i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		fmt.Sprintf(`network,host=server02,region=west,core=2 rx=40i,tx=50i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
-		fmt.Sprintf(`network,host=server03,region=east,core=3 rx=40i,tx=55i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
-		fmt.Sprintf(`network,host=server04,region=east,core=4 rx=40i,tx=60i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
-		fmt.Sprintf(`network,host=server05,region=west,core=1 rx=50i,tx=70i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
-		fmt.Sprintf(`network,host=server06,region=east,core=2 rx=50i,tx=40i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
-		fmt.Sprintf(`network,host=server07,region=west,core=3 rx=70i,tx=30i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
-		fmt.Sprintf(`network,host=server08,region=east,core=4 rx=90i,tx=10i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
-		fmt.Sprintf(`network,host=server09,region=east,core=1 rx=5i,tx=4i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:20Z").UnixNano()),
+		fmt.Sprintf(`cpu alert_id="alert",tenant_id="tenant",_cust="johnson brothers" %d`, mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
+		fmt.Sprintf(`cpu alert_id="alert",tenant_id="tenant",_cust="johnson brothers" %d`, mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
+
+		fmt.Sprintf(`cpu load=100.0,core=4 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:02Z").UnixNano()),
+		fmt.Sprintf(`cpu load=80.0,core=2 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:01:02Z").UnixNano()),
+
+		fmt.Sprintf(`clicks local=true %d`, mustParseTime(time.RFC3339Nano, "2014-11-10T23:00:01Z").UnixNano()),
+		fmt.Sprintf(`clicks local=false %d`, mustParseTime(time.RFC3339Nano, "2014-11-10T23:00:02Z").UnixNano()),
+
+		fmt.Sprintf(`logs status_message="request timeout (30s) at 10.0.0.1:8086",code=504i %d`, mustParseTime(time.RFC3339Nano, "2016-11-10T23:00:01Z").UnixNano()),
+		fmt.Sprintf(`logs status_message="OK: done [200]",code=200i %d`, mustParseTime(time.RFC3339Nano, "2016-11-10T23:00:02Z").UnixNano()),
+		fmt.Sprintf(`logs status_message="upstream \"db\" timeout",code=503i %d`, mustParseTime(time.RFC3339Nano, "2016-11-10T23:00:03Z").UnixNano()),
 	}
 
 	test := NewTest("db0", "rp0")
@@ -2003,302 +4893,359 @@ func TestServer_Query_Selectors(t *testing.T) {
 	}
 
 	test.addQueries([]*Query{
+		// non type specific
 		{
-			name:    "max - tx",
+			name:    "missing measurement with group by",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT max(tx) FROM network`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max"],"values":[["2000-01-01T00:00:40Z",70]]}]}]}`,
+			command: `SELECT load from missing group by *`,
+			exp:     `{"results":[{"statement_id":0}]}`,
 		},
+
+		// string
 		{
-			name:    "min - tx",
+			name:    "single string field",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT min(tx) FROM network`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","min"],"values":[["2000-01-01T00:01:20Z",4]]}]}]}`,
+			command: `SELECT alert_id FROM cpu WHERE alert_id='alert'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","alert_id"],"values":[["2015-02-28T01:03:36.703820946Z","alert"]]}]}]}`,
 		},
 		{
-			name:    "first",
+			name:    "string AND query, all fields in SELECT",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT first(tx) FROM network`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",20]]}]}]}`,
+			command: `SELECT alert_id,tenant_id,_cust FROM cpu WHERE alert_id='alert' AND tenant_id='tenant'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","alert_id","tenant_id","_cust"],"values":[["2015-02-28T01:03:36.703820946Z","alert","tenant","johnson brothers"]]}]}]}`,
 		},
 		{
-			name:    "last",
+			name:    "string AND query, all fields in SELECT, one in parenthesis",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT last(tx) FROM network`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","last"],"values":[["2000-01-01T00:01:20Z",4]]}]}]}`,
+			command: `SELECT alert_id,tenant_id FROM cpu WHERE alert_id='alert' AND (tenant_id='tenant')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","alert_id","tenant_id"],"values":[["2015-02-28T01:03:36.703820946Z","alert","tenant"]]}]}]}`,
 		},
 		{
-			name:    "percentile",
+			name:    "string underscored field",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT percentile(tx, 50) FROM network`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","percentile"],"values":[["2000-01-01T00:00:50Z",40]]}]}]}`,
+			command: `SELECT alert_id FROM cpu WHERE _cust='johnson brothers'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","alert_id"],"values":[["2015-02-28T01:03:36.703820946Z","alert"]]}]}]}`,
+		},
+		{
+			name:    "string no match",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT alert_id FROM cpu WHERE _cust='acme'`,
+			exp:     `{"results":[{"statement_id":0}]}`,
+		},
+		{
+			name:    "string regex match",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT status_message FROM logs WHERE status_message =~ /timeout/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","status_message"],"values":[["2016-11-10T23:00:01Z","request timeout (30s) at 10.0.0.1:8086"],["2016-11-10T23:00:03Z","upstream \"db\" timeout"]]}]}]}`,
+		},
+		{
+			name:    "string regex match with special characters",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT code FROM logs WHERE status_message =~ /\(30s\) at 10\.0\.0\.1:8086$/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","code"],"values":[["2016-11-10T23:00:01Z",504]]}]}]}`,
+		},
+		{
+			name:    "string regex not match",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT status_message FROM logs WHERE status_message !~ /timeout/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","status_message"],"values":[["2016-11-10T23:00:02Z","OK: done [200]"]]}]}]}`,
+		},
+		{
+			name:    "string regex match OR numeric comparison",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT code FROM logs WHERE status_message =~ /^OK: .* \[200\]$/ OR code = 503`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","code"],"values":[["2016-11-10T23:00:02Z",200],["2016-11-10T23:00:03Z",503]]}]}]}`,
+		},
+		{
+			name:    "string regex match with aggregate",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(code) FROM logs WHERE status_message =~ /timeout/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"logs","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
+		},
+		{
+			name:    "regex match on a numeric field",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT code FROM logs WHERE code =~ /50/`,
+			exp:     `{"results":[{"statement_id":0,"error":"regex operator requires a string field or tag, but code is integer"}]}`,
 		},
-	}...)
-
-	ctx := context.Background()
-	test.Run(ctx, t, s)
-}
-
-func TestServer_Query_TopBottomInt(t *testing.T) {
-	s := OpenServer(t)
-	defer s.Close()
-
-	writes := []string{
-		// cpu data with overlapping duplicate values
-		// hour 0
-		fmt.Sprintf(`cpu,host=server01 value=2.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		fmt.Sprintf(`cpu,host=server02 value=3.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
-		fmt.Sprintf(`cpu,host=server03 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
-		// hour 1
-		fmt.Sprintf(`cpu,host=server04 value=3.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:00Z").UnixNano()),
-		fmt.Sprintf(`cpu,host=server05 value=7.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:10Z").UnixNano()),
-		fmt.Sprintf(`cpu,host=server06 value=6.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:20Z").UnixNano()),
-		// hour 2
-		fmt.Sprintf(`cpu,host=server07 value=7.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T02:00:00Z").UnixNano()),
-		fmt.Sprintf(`cpu,host=server08 value=9.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T02:00:10Z").UnixNano()),
-
-		// memory data
-		// hour 0
-		fmt.Sprintf(`memory,host=a,service=redis value=1000i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		fmt.Sprintf(`memory,host=b,service=mysql value=2000i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		fmt.Sprintf(`memory,host=b,service=redis value=1500i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		// hour 1
-		fmt.Sprintf(`memory,host=a,service=redis value=1001i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:00Z").UnixNano()),
-		fmt.Sprintf(`memory,host=b,service=mysql value=2001i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:00Z").UnixNano()),
-		fmt.Sprintf(`memory,host=b,service=redis value=1501i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T01:00:00Z").UnixNano()),
-		// hour 2
-		fmt.Sprintf(`memory,host=a,service=redis value=1002i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T02:00:00Z").UnixNano()),
-		fmt.Sprintf(`memory,host=b,service=mysql value=2002i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T02:00:00Z").UnixNano()),
-		fmt.Sprintf(`memory,host=b,service=redis value=1502i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T02:00:00Z").UnixNano()),
-	}
-
-	test := NewTest("db0", "rp0")
-	test.writes = Writes{
-		&Write{data: strings.Join(writes, "\n")},
-	}
 
-	test.addQueries([]*Query{
+		// float64
 		{
-			name:    "top - cpu",
+			name:    "float64 GT no match",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, 1) FROM cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T02:00:10Z",9]]}]}]}`,
+			command: `select load from cpu where load > 100`,
+			exp:     `{"results":[{"statement_id":0}]}`,
 		},
 		{
-			name:    "bottom - cpu",
+			name:    "float64 GTE match one",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, 1) FROM cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
+			command: `select load from cpu where load >= 100`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:00:02Z",100]]}]}]}`,
 		},
 		{
-			name:    "top - cpu - 2 values",
+			name:    "float64 EQ match upper bound",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, 2) FROM cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T01:00:10Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+			command: `select load from cpu where load = 100`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:00:02Z",100]]}]}]}`,
 		},
 		{
-			name:    "bottom - cpu - 2 values",
+			name:    "float64 LTE match two",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, 2) FROM cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3]]}]}]}`,
+			command: `select load from cpu where load <= 100`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:00:02Z",100],["2009-11-10T23:01:02Z",80]]}]}]}`,
 		},
 		{
-			name:    "top - cpu - 3 values - sorts on tie properly",
+			name:    "float64 GT match one",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, 3) FROM cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T01:00:10Z",7],["2000-01-01T02:00:00Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+			command: `select load from cpu where load > 99`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:00:02Z",100]]}]}]}`,
 		},
 		{
-			name:    "bottom - cpu - 3 values - sorts on tie properly",
+			name:    "float64 EQ no match",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, 3) FROM cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3],["2000-01-01T01:00:00Z",3]]}]}]}`,
+			command: `select load from cpu where load = 99`,
+			exp:     `{"results":[{"statement_id":0}]}`,
 		},
 		{
-			name:    "top - cpu - with tag",
+			name:    "float64 LT match one",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, host, 2) FROM cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top","host"],"values":[["2000-01-01T01:00:10Z",7,"server05"],["2000-01-01T02:00:10Z",9,"server08"]]}]}]}`,
+			command: `select load from cpu where load < 99`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:01:02Z",80]]}]}]}`,
+		},
+		{
+			name:    "float64 LT no match",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `select load from cpu where load < 80`,
+			exp:     `{"results":[{"statement_id":0}]}`,
+		},
+		{
+			name:    "float64 NE match one",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `select load from cpu where load != 100`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:01:02Z",80]]}]}]}`,
 		},
+
+		// int64
 		{
-			name:    "bottom - cpu - with tag",
+			name:    "int64 GT no match",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, host, 2) FROM cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom","host"],"values":[["2000-01-01T00:00:00Z",2,"server01"],["2000-01-01T00:00:10Z",3,"server02"]]}]}]}`,
+			command: `select core from cpu where core > 4`,
+			exp:     `{"results":[{"statement_id":0}]}`,
 		},
 		{
-			name:    "top - cpu - 3 values with limit 2",
+			name:    "int64 GTE match one",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, 3) FROM cpu limit 2`,
-			exp:     `{"results":[{"statement_id":0,"error":"limit (3) in top function can not be larger than the LIMIT (2) in the select statement"}]}`,
+			command: `select core from cpu where core >= 4`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:00:02Z",4]]}]}]}`,
 		},
 		{
-			name:    "bottom - cpu - 3 values with limit 2",
+			name:    "int64 EQ match upper bound",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, 3) FROM cpu limit 2`,
-			exp:     `{"results":[{"statement_id":0,"error":"limit (3) in bottom function can not be larger than the LIMIT (2) in the select statement"}]}`,
+			command: `select core from cpu where core = 4`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:00:02Z",4]]}]}]}`,
 		},
 		{
-			name:    "top - cpu - hourly",
+			name:    "int64 LTE match two ",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, 1) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T00:00:20Z",4],["2000-01-01T01:00:10Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+			command: `select core from cpu where core <= 4`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:00:02Z",4],["2009-11-10T23:01:02Z",2]]}]}]}`,
 		},
 		{
-			name:    "bottom - cpu - hourly",
+			name:    "int64 GT match one",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, 1) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T01:00:00Z",3],["2000-01-01T02:00:00Z",7]]}]}]}`,
+			command: `select core from cpu where core > 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:00:02Z",4]]}]}]}`,
 		},
 		{
-			name:    "top - cpu - 2 values hourly",
+			name:    "int64 EQ no match",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, 2) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T00:00:10Z",3],["2000-01-01T00:00:20Z",4],["2000-01-01T01:00:10Z",7],["2000-01-01T01:00:20Z",6],["2000-01-01T02:00:00Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+			command: `select core from cpu where core = 3`,
+			exp:     `{"results":[{"statement_id":0}]}`,
 		},
 		{
-			name:    "bottom - cpu - 2 values hourly",
+			name:    "int64 LT match one",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, 2) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3],["2000-01-01T01:00:00Z",3],["2000-01-01T01:00:20Z",6],["2000-01-01T02:00:00Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+			command: `select core from cpu where core < 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:01:02Z",2]]}]}]}`,
 		},
 		{
-			name:    "top - cpu - 3 values hourly - validates that a bucket can have less than limit if no values exist in that time bucket",
+			name:    "int64 LT no match",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, 3) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","top"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3],["2000-01-01T00:00:20Z",4],["2000-01-01T01:00:00Z",3],["2000-01-01T01:00:10Z",7],["2000-01-01T01:00:20Z",6],["2000-01-01T02:00:00Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+			command: `select core from cpu where core < 2`,
+			exp:     `{"results":[{"statement_id":0}]}`,
 		},
 		{
-			name:    "bottom - cpu - 3 values hourly - validates that a bucket can have less than limit if no values exist in that time bucket",
+			name:    "int64 NE match one",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, 3) FROM cpu where time >= '2000-01-01T00:00:00Z' and time <= '2000-01-01T02:00:10Z' group by time(1h)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","bottom"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",3],["2000-01-01T00:00:20Z",4],["2000-01-01T01:00:00Z",3],["2000-01-01T01:00:10Z",7],["2000-01-01T01:00:20Z",6],["2000-01-01T02:00:00Z",7],["2000-01-01T02:00:10Z",9]]}]}]}`,
+			command: `select core from cpu where core != 4`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:01:02Z",2]]}]}]}`,
 		},
+
+		// bool
 		{
-			name:    "top - memory - 2 values, two tags",
+			name:    "bool EQ match true",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, 2), host, service FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host","service"],"values":[["2000-01-01T01:00:00Z",2001,"b","mysql"],["2000-01-01T02:00:00Z",2002,"b","mysql"]]}]}]}`,
+			command: `select local from clicks where local = true`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"clicks","columns":["time","local"],"values":[["2014-11-10T23:00:01Z",true]]}]}]}`,
 		},
 		{
-			name:    "bottom - memory - 2 values, two tags",
+			name:    "bool EQ match false",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, 2), host, service FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host","service"],"values":[["2000-01-01T00:00:00Z",1000,"a","redis"],["2000-01-01T01:00:00Z",1001,"a","redis"]]}]}]}`,
+			command: `select local from clicks where local = false`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"clicks","columns":["time","local"],"values":[["2014-11-10T23:00:02Z",false]]}]}]}`,
 		},
+
 		{
-			name:    "top - memory - host tag with limit 2",
+			name:    "bool NE match one",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, host, 2) FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host"],"values":[["2000-01-01T02:00:00Z",2002,"b"],["2000-01-01T02:00:00Z",1002,"a"]]}]}]}`,
+			command: `select local from clicks where local != true`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"clicks","columns":["time","local"],"values":[["2014-11-10T23:00:02Z",false]]}]}]}`,
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Aggregates_IntMany_GroupBy(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`intmany,host=server01 value=2.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server02 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server03 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server04 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server05 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server06 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server07 value=7.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server08 value=9.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
+		}, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "bottom - memory - host tag with limit 2",
+			name:    "max order by time with time specified group by 10s",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, host, 2) FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host"],"values":[["2000-01-01T00:00:00Z",1000,"a"],["2000-01-01T00:00:00Z",1500,"b"]]}]}]}`,
+			command: `SELECT time, max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(10s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",4],["2000-01-01T00:00:20Z",4],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:40Z",5],["2000-01-01T00:00:50Z",5],["2000-01-01T00:01:00Z",7],["2000-01-01T00:01:10Z",9]]}]}]}`,
 		},
 		{
-			name:    "top - memory - host tag with limit 2, service tag in select",
+			name:    "max order by time without time specified group by 30s",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, host, 2), service FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host","service"],"values":[["2000-01-01T02:00:00Z",2002,"b","mysql"],["2000-01-01T02:00:00Z",1002,"a","redis"]]}]}]}`,
+			command: `SELECT max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:30Z",5],["2000-01-01T00:01:00Z",9]]}]}]}`,
 		},
 		{
-			name:    "bottom - memory - host tag with limit 2, service tag in select",
+			name:    "max order by time with time specified group by 30s",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, host, 2), service FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host","service"],"values":[["2000-01-01T00:00:00Z",1000,"a","redis"],["2000-01-01T00:00:00Z",1500,"b","redis"]]}]}]}`,
+			command: `SELECT time, max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:00:10Z",4],["2000-01-01T00:00:40Z",5],["2000-01-01T00:01:10Z",9]]}]}]}`,
 		},
 		{
-			name:    "top - memory - service tag with limit 2, host tag in select",
+			name:    "min order by time without time specified group by 15s",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, service, 2), host FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","service","host"],"values":[["2000-01-01T02:00:00Z",2002,"mysql","b"],["2000-01-01T02:00:00Z",1502,"redis","b"]]}]}]}`,
+			command: `SELECT min(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","min"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:15Z",4],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:45Z",5],["2000-01-01T00:01:00Z",7]]}]}]}`,
 		},
 		{
-			name:    "bottom - memory - service tag with limit 2, host tag in select",
+			name:    "min order by time with time specified group by 15s",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, service, 2), host FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","service","host"],"values":[["2000-01-01T00:00:00Z",1000,"redis","a"],["2000-01-01T00:00:00Z",2000,"mysql","b"]]}]}]}`,
+			command: `SELECT time, min(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","min"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:20Z",4],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:50Z",5],["2000-01-01T00:01:00Z",7]]}]}]}`,
 		},
 		{
-			name:    "top - memory - host and service tag with limit 2",
+			name:    "first order by time without time specified group by 15s",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, host, service, 2) FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host","service"],"values":[["2000-01-01T02:00:00Z",2002,"b","mysql"],["2000-01-01T02:00:00Z",1502,"b","redis"]]}]}]}`,
+			command: `SELECT first(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:15Z",4],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:45Z",5],["2000-01-01T00:01:00Z",7]]}]}]}`,
 		},
 		{
-			name:    "bottom - memory - host and service tag with limit 2",
+			name:    "first order by time with time specified group by 15s",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, host, service, 2) FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host","service"],"values":[["2000-01-01T00:00:00Z",1000,"a","redis"],["2000-01-01T00:00:00Z",1500,"b","redis"]]}]}]}`,
+			command: `SELECT time, first(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:20Z",4],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:50Z",5],["2000-01-01T00:01:00Z",7]]}]}]}`,
 		},
 		{
-			name:    "top - memory - host tag with limit 2 with service tag in select",
+			name:    "last order by time without time specified group by 15s",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, host, 2), service FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host","service"],"values":[["2000-01-01T02:00:00Z",2002,"b","mysql"],["2000-01-01T02:00:00Z",1002,"a","redis"]]}]}]}`,
+			command: `SELECT last(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","last"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:15Z",4],["2000-01-01T00:00:30Z",5],["2000-01-01T00:00:45Z",5],["2000-01-01T00:01:00Z",9]]}]}]}`,
 		},
 		{
-			name:    "bottom - memory - host tag with limit 2 with service tag in select",
+			name:    "last order by time with time specified group by 15s",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, host, 2), service FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host","service"],"values":[["2000-01-01T00:00:00Z",1000,"a","redis"],["2000-01-01T00:00:00Z",1500,"b","redis"]]}]}]}`,
+			command: `SELECT time, last(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","last"],"values":[["2000-01-01T00:00:10Z",4],["2000-01-01T00:00:20Z",4],["2000-01-01T00:00:40Z",5],["2000-01-01T00:00:50Z",5],["2000-01-01T00:01:10Z",9]]}]}]}`,
 		},
 		{
-			name:    "top - memory - host and service tag with limit 3",
+			name:    "last order by time with time specified group by 15s fill null",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT TOP(value, host, service, 3) FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","top","host","service"],"values":[["2000-01-01T02:00:00Z",2002,"b","mysql"],["2000-01-01T02:00:00Z",1502,"b","redis"],["2000-01-01T02:00:00Z",1002,"a","redis"]]}]}]}`,
+			command: `SELECT time, last(value) FROM intmany where time >= '2000-01-01T00:00:45Z' AND time <= '2000-01-01T00:01:44Z' group by time(15s) fill(null)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","last"],"values":[["2000-01-01T00:00:50Z",5],["2000-01-01T00:01:10Z",9],["2000-01-01T00:01:15Z",null],["2000-01-01T00:01:30Z",null]]}]}]}`,
 		},
 		{
-			name:    "bottom - memory - host and service tag with limit 3",
+			name:    "first order by time with time specified group by 15s fill null",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT BOTTOM(value, host, service, 3) FROM memory`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"memory","columns":["time","bottom","host","service"],"values":[["2000-01-01T00:00:00Z",1000,"a","redis"],["2000-01-01T00:00:00Z",1500,"b","redis"],["2000-01-01T00:00:00Z",2000,"b","mysql"]]}]}]}`,
+			command: `SELECT time, first(value) FROM intmany where time >= '2000-01-01T00:00:45Z' AND time <= '2000-01-01T00:01:44Z' group by time(15s) fill(null)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","first"],"values":[["2000-01-01T00:00:50Z",5],["2000-01-01T00:01:00Z",7],["2000-01-01T00:01:15Z",null],["2000-01-01T00:01:30Z",null]]}]}]}`,
 		},
-
-		// TODO
-		// - Test that specifiying fields or tags in the function will rewrite the query to expand them to the fields
-		// - Test that a field can be used in the top function
-		// - Test that asking for a field will come back before a tag if they have the same name for a tag and a field
-		// - Test that `select top(value, host, 2)` when there is only one value for `host` it will only bring back one value
-		// - Test that `select top(value, host, 4) from foo where time > now() - 1d and time < now() group by time(1h)` and host is unique in some time buckets that it returns only the unique ones, and not always 4 values
-
 	}...)
 
 	ctx := context.Background()
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_ExactTimeRange(t *testing.T) {
+func TestServer_Query_Aggregates_IntMany_OrderByDesc(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
-	writes := []string{
-		fmt.Sprintf(`cpu value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00.000000000Z").UnixNano()),
-		fmt.Sprintf(`cpu value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00.000000001Z").UnixNano()),
-		fmt.Sprintf(`cpu value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00.000000002Z").UnixNano()),
-	}
-
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join(writes, "\n")},
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`intmany,host=server01 value=2.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server02 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server03 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server04 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server05 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server06 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server07 value=7.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
+			fmt.Sprintf(`intmany,host=server08 value=9.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
+		}, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "query point at exactly one time - rfc3339nano",
+			name:    "aggregate order by time desc",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT * FROM cpu WHERE time = '2000-01-01T00:00:00.000000001Z'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00.000000001Z",2]]}]}]}`,
+			command: `SELECT max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:00Z' group by time(10s) order by time desc`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:01:00Z",7],["2000-01-01T00:00:50Z",5],["2000-01-01T00:00:40Z",5],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:20Z",4],["2000-01-01T00:00:10Z",4],["2000-01-01T00:00:00Z",2]]}]}]}`,
 		},
 		{
-			name:    "query point at exactly one time - timestamp",
+			name:    "aggregate order by time desc with limit",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT * FROM cpu WHERE time = 946684800000000001`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2000-01-01T00:00:00.000000001Z",2]]}]}]}`,
+			command: `SELECT max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:00Z' group by time(10s) order by time desc limit 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:01:00Z",7],["2000-01-01T00:00:50Z",5],["2000-01-01T00:00:40Z",5]]}]}]}`,
+		},
+		{
+			name:    "aggregate order by time desc with limit and offset",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:00Z' group by time(10s) order by time desc limit 2 offset 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:20Z",4]]}]}]}`,
+		},
+		{
+			name:    "aggregate order by time desc with limit and empty buckets",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:05:00Z' group by time(10s) order by time desc limit 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:05:00Z",null],["2000-01-01T00:04:50Z",null],["2000-01-01T00:04:40Z",null]]}]}]}`,
+		},
+		{
+			name:    "aggregate order by time desc with limit grouped by tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:00Z' AND (host = 'server01' OR host = 'server07') group by time(10s), host order by time desc limit 2`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","tags":{"host":"server07"},"columns":["time","max"],"values":[["2000-01-01T00:01:00Z",7],["2000-01-01T00:00:50Z",null]]},{"name":"intmany","tags":{"host":"server01"},"columns":["time","max"],"values":[["2000-01-01T00:01:00Z",null],["2000-01-01T00:00:50Z",null]]}]}]}`,
 		},
 	}...)
 
@@ -2306,45 +5253,51 @@ func TestServer_Query_ExactTimeRange(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Aggregates_Math(t *testing.T) {
+func TestServer_Query_Aggregates_IntOverlap(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
-	writes := []string{
-		fmt.Sprintf(`network,host=server01,region=west,core=1 rx=10i,tx=20i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		fmt.Sprintf(`network,host=server02,region=west,core=2 rx=40i,tx=50i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
-		fmt.Sprintf(`network,host=server03,region=east,core=3 rx=40i,tx=55i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
-		fmt.Sprintf(`network,host=server04,region=east,core=4 rx=40i,tx=60i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
-		fmt.Sprintf(`network,host=server05,region=west,core=1 rx=50i,tx=70i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
-		fmt.Sprintf(`network,host=server06,region=east,core=2 rx=50i,tx=40i,core=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
-		fmt.Sprintf(`network,host=server07,region=west,core=3 rx=70i,tx=30i,core=4i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
-		fmt.Sprintf(`network,host=server08,region=east,core=4 rx=90i,tx=10i,core=1i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
-		fmt.Sprintf(`network,host=server09,region=east,core=1 rx=5i,tx=4i,core=2i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:20Z").UnixNano()),
-	}
-
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join(writes, "\n")},
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`intoverlap,region=us-east value=20 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+			fmt.Sprintf(`intoverlap,region=us-east value=30 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+			fmt.Sprintf(`intoverlap,region=us-west value=100 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+			fmt.Sprintf(`intoverlap,region=us-east otherVal=20 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
+		}, "\n")},
 	}
 
 	test.addQueries([]*Query{
-		{
-			name:    "add two selectors",
+		/*		{
+					name:    "aggregation with no interval - int",
+					params:  url.Values{"db": []string{"db0"}},
+					command: `SELECT count(value) FROM intoverlap WHERE time = '2000-01-01 00:00:00'`,
+					exp:     `{"results":[{"statement_id":0,"series":[{"name":"intoverlap","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
+				},
+				{
+					name:    "sum - int",
+					params:  url.Values{"db": []string{"db0"}},
+					command: `SELECT SUM(value) FROM intoverlap WHERE time >= '2000-01-01 00:00:05' AND time <= '2000-01-01T00:00:10Z' GROUP BY time(10s), region`,
+					exp:     `{"results":[{"statement_id":0,"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum"],"values":[["2000-01-01T00:00:10Z",30]]}]}]}`,
+				},
+		*/{
+			name:    "aggregation with a null field value - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT max(rx) + min(rx) FROM network WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:30Z'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max_min"],"values":[["2000-01-01T00:00:00Z",95]]}]}]}`,
+			command: `SELECT SUM(value) FROM intoverlap GROUP BY region`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",50]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",100]]}]}]}`,
 		},
 		{
-			name:    "use math one two selectors separately",
+			name:    "multiple aggregations - int",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT max(rx) * 1, min(rx) * 1 FROM network WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:30Z'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max","min"],"values":[["2000-01-01T00:00:00Z",90,5]]}]}]}`,
+			command: `SELECT SUM(value), MEAN(value) FROM intoverlap GROUP BY region`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum","mean"],"values":[["1970-01-01T00:00:00Z",50,25]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","sum","mean"],"values":[["1970-01-01T00:00:00Z",100,100]]}]}]}`,
 		},
 		{
-			name:    "math with a single selector",
+			skip:    SkippedOSS,
+			name:    "multiple aggregations with division - int FIXME issue #2879",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT max(rx) * 1 FROM network WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:30Z'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"network","columns":["time","max"],"values":[["2000-01-01T00:01:10Z",90]]}]}]}`,
+			command: `SELECT sum(value), mean(value), sum(value) / mean(value) as div FROM intoverlap GROUP BY region`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum","mean","div"],"values":[["1970-01-01T00:00:00Z",50,25,2]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","div"],"values":[["1970-01-01T00:00:00Z",100,100,1]]}]}]}`,
 		},
 	}...)
 
@@ -2352,24 +5305,23 @@ func TestServer_Query_Aggregates_Math(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Aggregates_CPU(t *testing.T) {
+func TestServer_Query_Aggregates_FloatSingle(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
 		&Write{data: strings.Join([]string{
-			fmt.Sprintf(`cpu,region=uk,host=serverZ,service=redis value=20.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
-			fmt.Sprintf(`cpu,region=uk,host=serverZ,service=mysql value=30.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
+			fmt.Sprintf(`floatsingle value=45.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
 		}, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "aggregation with WHERE and AND",
+			name:    "stddev with just one point - float",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT sum(value) FROM cpu WHERE region='uk' AND host='serverZ'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",50]]}]}]}`,
+			command: `SELECT STDDEV(value) FROM floatsingle`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"floatsingle","columns":["time","stddev"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
 		},
 	}...)
 
@@ -2377,130 +5329,94 @@ func TestServer_Query_Aggregates_CPU(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Aggregates_IntMany(t *testing.T) {
+func TestServer_Query_LimitAndOffset(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	writes := []string{
+		fmt.Sprintf(`limited,tennant=paul foo=2 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:02Z").UnixNano()),
+		fmt.Sprintf(`limited,tennant=paul foo=3 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:03Z").UnixNano()),
+		fmt.Sprintf(`limited,tennant=paul foo=4 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:04Z").UnixNano()),
+		fmt.Sprintf(`limited,tennant=todd foo=5 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:05Z").UnixNano()),
+	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join([]string{
-			fmt.Sprintf(`intmany,host=server01 value=2.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server02 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server03 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server04 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server05 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server06 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server07 value=7.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server08 value=9.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
-		}, "\n")},
+		&Write{data: strings.Join(writes, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "mean and stddev - int",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT MEAN(value), STDDEV(value) FROM intmany WHERE time >= '2000-01-01' AND time < '2000-01-01T00:02:00Z' GROUP BY time(10m)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","mean","stddev"],"values":[["2000-01-01T00:00:00Z",5,2.138089935299395]]}]}]}`,
-		},
-		{
-			name:    "first - int",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT FIRST(value) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
-		},
-		{
-			name:    "first - int - epoch ms",
-			params:  url.Values{"db": []string{"db0"}, "epoch": []string{"ms"}},
-			command: `SELECT FIRST(value) FROM intmany`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","first"],"values":[[%d,2]]}]}]}`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()/int64(time.Millisecond)),
-		},
-		{
-			name:    "last - int",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT LAST(value) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","last"],"values":[["2000-01-01T00:01:10Z",9]]}]}]}`,
-		},
-		{
-			name:    "spread - int",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT SPREAD(value) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","spread"],"values":[["1970-01-01T00:00:00Z",7]]}]}]}`,
-		},
-		{
-			name:    "median - even count - int",
+			name:    "limit on points",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT MEDIAN(value) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",4.5]]}]}]}`,
+			command: `select foo from "limited" LIMIT 2`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","foo"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3]]}]}]}`,
 		},
 		{
-			name:    "median - odd count - int",
+			name:    "limit higher than the number of data points",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT MEDIAN(value) FROM intmany where time < '2000-01-01T00:01:10Z'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
+			command: `select foo from "limited" LIMIT 20`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","foo"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4],["2009-11-10T23:00:05Z",5]]}]}]}`,
 		},
 		{
-			name:    "mode - single - int",
+			name:    "limit and offset",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT MODE(value) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","mode"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
+			command: `select foo from "limited" LIMIT 2 OFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","foo"],"values":[["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4]]}]}]}`,
 		},
 		{
-			name:    "mode - multiple - int",
+			name:    "limit + offset equal to total number of points",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT MODE(value) FROM intmany where time < '2000-01-01T00:01:10Z'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","mode"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
+			command: `select foo from "limited" LIMIT 3 OFFSET 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","foo"],"values":[["2009-11-10T23:00:05Z",5]]}]}]}`,
 		},
 		{
-			name:    "distinct as call - int",
+			name:    "limit - offset higher than number of points",
+			command: `select foo from "limited" LIMIT 2 OFFSET 20`,
+			exp:     `{"results":[{"statement_id":0}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT DISTINCT(value) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z",2],["1970-01-01T00:00:00Z",4],["1970-01-01T00:00:00Z",5],["1970-01-01T00:00:00Z",7],["1970-01-01T00:00:00Z",9]]}]}]}`,
 		},
 		{
-			name:    "distinct alt syntax - int",
+			name:    "limit on points with group by time",
+			command: `select mean(foo) from "limited" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 2`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","mean"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT DISTINCT value FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z",2],["1970-01-01T00:00:00Z",4],["1970-01-01T00:00:00Z",5],["1970-01-01T00:00:00Z",7],["1970-01-01T00:00:00Z",9]]}]}]}`,
 		},
 		{
-			name:    "distinct select tag - int",
+			name:    "limit higher than the number of data points with group by time",
+			command: `select mean(foo) from "limited" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 20`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","mean"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4],["2009-11-10T23:00:05Z",5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT DISTINCT(host) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"error":"statement must have at least one field in select clause"}]}`,
-			skip:    SkippedOSS, // FIXME(benbjohnson): should be allowed, need to stream tag values
 		},
 		{
-			name:    "distinct alt select tag - int",
+			name:    "limit and offset with group by time",
+			command: `select mean(foo) from "limited" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 2 OFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","mean"],"values":[["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT DISTINCT host FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"error":"statement must have at least one field in select clause"}]}`,
-			skip:    SkippedOSS, // FIXME(benbjohnson): should be allowed, need to stream tag values
 		},
 		{
-			name:    "count distinct - int",
+			name:    "limit + offset equal to the number of points with group by time",
+			command: `select mean(foo) from "limited" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 3 OFFSET 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","mean"],"values":[["2009-11-10T23:00:05Z",5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT COUNT(DISTINCT value) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
 		},
 		{
-			name:    "count distinct as call - int",
+			name:    "limit - offset higher than number of points with group by time",
+			command: `select mean(foo) from "limited" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 2 OFFSET 20`,
+			exp:     `{"results":[{"statement_id":0}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT COUNT(DISTINCT(value)) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
 		},
 		{
-			name:    "count distinct select tag - int",
+			name:    "limit - group by tennant",
+			command: `select foo from "limited" group by tennant limit 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","tags":{"tennant":"paul"},"columns":["time","foo"],"values":[["2009-11-10T23:00:02Z",2]]},{"name":"limited","tags":{"tennant":"todd"},"columns":["time","foo"],"values":[["2009-11-10T23:00:05Z",5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT COUNT(DISTINCT host) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",0]]}]}]}`,
-			skip:    SkippedOSS, // FIXME(benbjohnson): stream tag values
 		},
 		{
-			name:    "count distinct as call select tag - int",
+			name:    "limit and offset - group by tennant",
+			command: `select foo from "limited" group by tennant limit 1 offset 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","tags":{"tennant":"paul"},"columns":["time","foo"],"values":[["2009-11-10T23:00:03Z",3]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT COUNT(DISTINCT host) FROM intmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",0]]}]}]}`,
-			skip:    SkippedOSS, // FIXME(benbjohnson): stream tag values
 		},
 	}...)
 
@@ -2508,19 +5424,17 @@ func TestServer_Query_Aggregates_IntMany(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Where_Fields(t *testing.T) {
+// Ensure LIMIT returns the newest points of each series when ordered by time descending.
+func TestServer_Query_GroupByDescendingLimit(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
-	writes := []string{
-		fmt.Sprintf(`cpu alert_id="alert",tenant_id="tenant",_cust="johnson brothers" %d`, mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
-		fmt.Sprintf(`cpu alert_id="alert",tenant_id="tenant",_cust="johnson brothers" %d`, mustParseTime(time.RFC3339Nano, "2015-02-28T01:03:36.703820946Z").UnixNano()),
-
-		fmt.Sprintf(`cpu load=100.0,core=4 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:02Z").UnixNano()),
-		fmt.Sprintf(`cpu load=80.0,core=2 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:01:02Z").UnixNano()),
-
-		fmt.Sprintf(`clicks local=true %d`, mustParseTime(time.RFC3339Nano, "2014-11-10T23:00:01Z").UnixNano()),
-		fmt.Sprintf(`clicks local=false %d`, mustParseTime(time.RFC3339Nano, "2014-11-10T23:00:02Z").UnixNano()),
+	var writes []string
+	for i, host := range []string{"server01", "server02", "server03"} {
+		for day := 0; day < 21; day += 3 {
+			ts := mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").Add(time.Duration(day)*24*time.Hour + time.Duration(i)*time.Minute)
+			writes = append(writes, fmt.Sprintf(`cpu,host=%s value=%d %d`, host, day, ts.UnixNano()))
+		}
 	}
 
 	test := NewTest("db0", "rp0")
@@ -2529,256 +5443,184 @@ func TestServer_Query_Where_Fields(t *testing.T) {
 	}
 
 	test.addQueries([]*Query{
-		// non type specific
-		{
-			name:    "missing measurement with group by",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT load from missing group by *`,
-			exp:     `{"results":[{"statement_id":0}]}`,
-		},
-
-		// string
-		{
-			name:    "single string field",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT alert_id FROM cpu WHERE alert_id='alert'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","alert_id"],"values":[["2015-02-28T01:03:36.703820946Z","alert"]]}]}]}`,
-		},
 		{
-			name:    "string AND query, all fields in SELECT",
+			name:    "newest points of each series",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT alert_id,tenant_id,_cust FROM cpu WHERE alert_id='alert' AND tenant_id='tenant'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","alert_id","tenant_id","_cust"],"values":[["2015-02-28T01:03:36.703820946Z","alert","tenant","johnson brothers"]]}]}]}`,
+			command: `SELECT value FROM cpu GROUP BY host ORDER BY time DESC LIMIT 3`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server03"},"columns":["time","value"],"values":[["2000-01-19T00:02:00Z",18],["2000-01-16T00:02:00Z",15],["2000-01-13T00:02:00Z",12]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","value"],"values":[["2000-01-19T00:01:00Z",18],["2000-01-16T00:01:00Z",15],["2000-01-13T00:01:00Z",12]]},{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["2000-01-19T00:00:00Z",18],["2000-01-16T00:00:00Z",15],["2000-01-13T00:00:00Z",12]]}]}]}`,
 		},
 		{
-			name:    "string AND query, all fields in SELECT, one in parenthesis",
+			name:    "newest points of each series with offset",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT alert_id,tenant_id FROM cpu WHERE alert_id='alert' AND (tenant_id='tenant')`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","alert_id","tenant_id"],"values":[["2015-02-28T01:03:36.703820946Z","alert","tenant"]]}]}]}`,
+			command: `SELECT value FROM cpu GROUP BY host ORDER BY time DESC LIMIT 2 OFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server03"},"columns":["time","value"],"values":[["2000-01-16T00:02:00Z",15],["2000-01-13T00:02:00Z",12]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","value"],"values":[["2000-01-16T00:01:00Z",15],["2000-01-13T00:01:00Z",12]]},{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["2000-01-16T00:00:00Z",15],["2000-01-13T00:00:00Z",12]]}]}]}`,
 		},
 		{
-			name:    "string underscored field",
+			name:    "newest points of each series with a time range",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT alert_id FROM cpu WHERE _cust='johnson brothers'`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","alert_id"],"values":[["2015-02-28T01:03:36.703820946Z","alert"]]}]}]}`,
+			command: `SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-17T00:00:00Z' GROUP BY host ORDER BY time DESC LIMIT 2`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server03"},"columns":["time","value"],"values":[["2000-01-16T00:02:00Z",15],["2000-01-13T00:02:00Z",12]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","value"],"values":[["2000-01-16T00:01:00Z",15],["2000-01-13T00:01:00Z",12]]},{"name":"cpu","tags":{"host":"server01"},"columns":["time","value"],"values":[["2000-01-16T00:00:00Z",15],["2000-01-13T00:00:00Z",12]]}]}]}`,
 		},
 		{
-			name:    "string no match",
+			name:    "newest points of each series with a selector",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT alert_id FROM cpu WHERE _cust='acme'`,
-			exp:     `{"results":[{"statement_id":0}]}`,
+			command: `SELECT last(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-22T00:00:00Z' GROUP BY host, time(7d) ORDER BY time DESC LIMIT 2`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server03"},"columns":["time","last"],"values":[["2000-01-20T00:00:00Z",null],["2000-01-13T00:00:00Z",18]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","last"],"values":[["2000-01-20T00:00:00Z",null],["2000-01-13T00:00:00Z",18]]},{"name":"cpu","tags":{"host":"server01"},"columns":["time","last"],"values":[["2000-01-20T00:00:00Z",null],["2000-01-13T00:00:00Z",18]]}]}]}`,
 		},
+	}...)
 
-		// float64
-		{
-			name:    "float64 GT no match",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `select load from cpu where load > 100`,
-			exp:     `{"results":[{"statement_id":0}]}`,
-		},
-		{
-			name:    "float64 GTE match one",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `select load from cpu where load >= 100`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:00:02Z",100]]}]}]}`,
-		},
-		{
-			name:    "float64 EQ match upper bound",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `select load from cpu where load = 100`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:00:02Z",100]]}]}]}`,
-		},
-		{
-			name:    "float64 LTE match two",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `select load from cpu where load <= 100`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:00:02Z",100],["2009-11-10T23:01:02Z",80]]}]}]}`,
-		},
-		{
-			name:    "float64 GT match one",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `select load from cpu where load > 99`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:00:02Z",100]]}]}]}`,
-		},
-		{
-			name:    "float64 EQ no match",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `select load from cpu where load = 99`,
-			exp:     `{"results":[{"statement_id":0}]}`,
-		},
-		{
-			name:    "float64 LT match one",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `select load from cpu where load < 99`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:01:02Z",80]]}]}]}`,
-		},
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Fill(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`fills val=3 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:02Z").UnixNano()),
+		fmt.Sprintf(`fills val=5 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:03Z").UnixNano()),
+		fmt.Sprintf(`fills val=4 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:06Z").UnixNano()),
+		fmt.Sprintf(`fills val=10 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:16Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "float64 LT no match",
+			name:    "fill with value",
+			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) FILL(1)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",1],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select load from cpu where load < 80`,
-			exp:     `{"results":[{"statement_id":0}]}`,
 		},
 		{
-			name:    "float64 NE match one",
+			name:    "fill with value, WHERE all values match condition",
+			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' and val < 50 group by time(5s) FILL(1)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",1],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select load from cpu where load != 100`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","load"],"values":[["2009-11-10T23:01:02Z",80]]}]}]}`,
 		},
-
-		// int64
 		{
-			name:    "int64 GT no match",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `select core from cpu where core > 4`,
+			name:    "fill with value, WHERE no values match condition",
+			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' and val > 50 group by time(5s) FILL(1)`,
 			exp:     `{"results":[{"statement_id":0}]}`,
-		},
-		{
-			name:    "int64 GTE match one",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select core from cpu where core >= 4`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:00:02Z",4]]}]}]}`,
 		},
 		{
-			name:    "int64 EQ match upper bound",
+			name:    "fill with previous",
+			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) FILL(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",4],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select core from cpu where core = 4`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:00:02Z",4]]}]}]}`,
 		},
 		{
-			name:    "int64 LTE match two ",
+			name:    "fill with linear",
+			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) FILL(linear)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",7],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select core from cpu where core <= 4`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:00:02Z",4],["2009-11-10T23:01:02Z",2]]}]}]}`,
 		},
 		{
-			name:    "int64 GT match one",
+			name:    "fill with linear leaves leading and trailing intervals null",
+			command: `select mean(val) from fills where time >= '2009-11-10T22:59:50Z' and time < '2009-11-10T23:00:30Z' group by time(5s) FILL(linear)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T22:59:50Z",null],["2009-11-10T22:59:55Z",null],["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",7],["2009-11-10T23:00:15Z",10],["2009-11-10T23:00:20Z",null],["2009-11-10T23:00:25Z",null]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select core from cpu where core > 3`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:00:02Z",4]]}]}]}`,
 		},
 		{
-			name:    "int64 EQ no match",
+			name:    "fill with linear across several intervals",
+			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(2s) FILL(linear)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",null],["2009-11-10T23:00:02Z",4],["2009-11-10T23:00:04Z",4],["2009-11-10T23:00:06Z",4],["2009-11-10T23:00:08Z",5.2],["2009-11-10T23:00:10Z",6.4],["2009-11-10T23:00:12Z",7.6],["2009-11-10T23:00:14Z",8.8],["2009-11-10T23:00:16Z",10],["2009-11-10T23:00:18Z",null]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select core from cpu where core = 3`,
-			exp:     `{"results":[{"statement_id":0}]}`,
 		},
 		{
-			name:    "int64 LT match one",
+			name:    "fill with none, i.e. clear out nulls",
+			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) FILL(none)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select core from cpu where core < 3`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:01:02Z",2]]}]}]}`,
 		},
 		{
-			name:    "int64 LT no match",
+			name:    "fill defaults to null",
+			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",null],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select core from cpu where core < 2`,
-			exp:     `{"results":[{"statement_id":0}]}`,
 		},
 		{
-			name:    "int64 NE match one",
+			name:    "fill defaults to 0 for count",
+			command: `select count(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:10Z",0],["2009-11-10T23:00:15Z",1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select core from cpu where core != 4`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","core"],"values":[["2009-11-10T23:01:02Z",2]]}]}]}`,
 		},
-
-		// bool
 		{
-			name:    "bool EQ match true",
+			name:    "fill none drops 0s for count",
+			command: `select count(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) fill(none)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:15Z",1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select local from clicks where local = true`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"clicks","columns":["time","local"],"values":[["2014-11-10T23:00:01Z",true]]}]}]}`,
 		},
 		{
-			name:    "bool EQ match false",
+			name:    "fill previous overwrites 0s for count",
+			command: `select count(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:10Z",1],["2009-11-10T23:00:15Z",1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select local from clicks where local = false`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"clicks","columns":["time","local"],"values":[["2014-11-10T23:00:02Z",false]]}]}]}`,
 		},
-
 		{
-			name:    "bool NE match one",
+			name:    "fill per column with fill(none) for the statement",
+			command: `select fill(mean(val), none) as mean, fill(count(val), 0) as count from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) fill(none)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean","count"],"values":[["2009-11-10T23:00:00Z",4,2],["2009-11-10T23:00:05Z",4,1],["2009-11-10T23:00:10Z",null,0],["2009-11-10T23:00:15Z",10,1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select local from clicks where local != true`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"clicks","columns":["time","local"],"values":[["2014-11-10T23:00:02Z",false]]}]}]}`,
 		},
-	}...)
-
-	ctx := context.Background()
-	test.Run(ctx, t, s)
-}
-
-func TestServer_Query_Aggregates_IntMany_GroupBy(t *testing.T) {
-	s := OpenServer(t)
-	defer s.Close()
-
-	test := NewTest("db0", "rp0")
-	test.writes = Writes{
-		&Write{data: strings.Join([]string{
-			fmt.Sprintf(`intmany,host=server01 value=2.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server02 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server03 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server04 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server05 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server06 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server07 value=7.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server08 value=9.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
-		}, "\n")},
-	}
-
-	test.addQueries([]*Query{
 		{
-			name:    "max order by time with time specified group by 10s",
+			name:    "fill per column overrides the statement fill",
+			command: `select fill(mean(val), previous) as mean, count(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) fill(none)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean","count"],"values":[["2009-11-10T23:00:00Z",4,2],["2009-11-10T23:00:05Z",4,1],["2009-11-10T23:00:10Z",4,null],["2009-11-10T23:00:15Z",10,1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(10s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:10Z",4],["2000-01-01T00:00:20Z",4],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:40Z",5],["2000-01-01T00:00:50Z",5],["2000-01-01T00:01:00Z",7],["2000-01-01T00:01:10Z",9]]}]}]}`,
 		},
 		{
-			name:    "max order by time without time specified group by 30s",
+			name:    "fill per column names each column after the wrapped call",
+			command: `select fill(mean(val), 0), fill(count(val), 0) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean","count"],"values":[["2009-11-10T23:00:00Z",4,2],["2009-11-10T23:00:05Z",4,1],["2009-11-10T23:00:10Z",0,0],["2009-11-10T23:00:15Z",10,1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:30Z",5],["2000-01-01T00:01:00Z",9]]}]}]}`,
 		},
 		{
-			name:    "max order by time with time specified group by 30s",
+			name:    "fill per column with value and default fill",
+			command: `select fill(mean(val), 1), mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean","mean_1"],"values":[["2009-11-10T23:00:00Z",4,4],["2009-11-10T23:00:05Z",4,4],["2009-11-10T23:00:10Z",1,null],["2009-11-10T23:00:15Z",10,10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(30s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:30Z",5],["2000-01-01T00:01:00Z",9]]}]}]}`,
 		},
 		{
-			name:    "min order by time without time specified group by 15s",
+			name:    "fill per column with next",
+			command: `select fill(mean(val), next) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:30Z' group by time(5s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",10],["2009-11-10T23:00:15Z",10],["2009-11-10T23:00:20Z",null],["2009-11-10T23:00:25Z",null]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT min(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","min"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:15Z",4],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:45Z",5],["2000-01-01T00:01:00Z",7]]}]}]}`,
 		},
 		{
-			name:    "min order by time with time specified group by 15s",
+			name:    "fill per column with next overwrites 0s for count",
+			command: `select fill(count(val), next) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:30Z' group by time(5s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:10Z",1],["2009-11-10T23:00:15Z",1],["2009-11-10T23:00:20Z",null],["2009-11-10T23:00:25Z",null]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, min(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","min"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:15Z",4],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:45Z",5],["2000-01-01T00:01:00Z",7]]}]}]}`,
 		},
 		{
-			name:    "first order by time without time specified group by 15s",
+			name:    "fill per column with an expression decays across a gap",
+			command: `select fill(mean(val), previous * 0.9) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:40Z' group by time(5s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",3.6],["2009-11-10T23:00:15Z",10],["2009-11-10T23:00:20Z",9],["2009-11-10T23:00:25Z",8.1],["2009-11-10T23:00:30Z",7.29],["2009-11-10T23:00:35Z",6.561]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT first(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:15Z",4],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:45Z",5],["2000-01-01T00:01:00Z",7]]}]}]}`,
 		},
 		{
-			name:    "first order by time with time specified group by 15s",
+			name:    "fill defaults to 0 for count across the entire time range",
+			command: `select count(val) from fills where time >= '2009-11-10T22:59:50Z' and time < '2009-11-10T23:00:30Z' group by time(5s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T22:59:50Z",0],["2009-11-10T22:59:55Z",0],["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:10Z",0],["2009-11-10T23:00:15Z",1],["2009-11-10T23:00:20Z",0],["2009-11-10T23:00:25Z",0]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, first(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","first"],"values":[["2000-01-01T00:00:00Z",2],["2000-01-01T00:00:15Z",4],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:45Z",5],["2000-01-01T00:01:00Z",7]]}]}]}`,
 		},
 		{
-			name:    "last order by time without time specified group by 15s",
+			name:    "fill defaults to 0 for count with a predicate across the entire time range",
+			command: `select count(val) from fills where val > 4 and time >= '2009-11-10T22:59:50Z' and time < '2009-11-10T23:00:30Z' group by time(5s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T22:59:50Z",0],["2009-11-10T22:59:55Z",0],["2009-11-10T23:00:00Z",1],["2009-11-10T23:00:05Z",0],["2009-11-10T23:00:10Z",0],["2009-11-10T23:00:15Z",1],["2009-11-10T23:00:20Z",0],["2009-11-10T23:00:25Z",0]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT last(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","last"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:15Z",4],["2000-01-01T00:00:30Z",5],["2000-01-01T00:00:45Z",5],["2000-01-01T00:01:00Z",9]]}]}]}`,
 		},
 		{
-			name:    "last order by time with time specified group by 15s",
+			name:    "fill with implicit start time",
+			command: `select mean(val) from fills where time < '2009-11-10T23:00:20Z' group by time(5s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",null],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT time, last(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:14Z' group by time(15s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","last"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:15Z",4],["2000-01-01T00:00:30Z",5],["2000-01-01T00:00:45Z",5],["2000-01-01T00:01:00Z",9]]}]}]}`,
 		},
 	}...)
 
@@ -2786,82 +5628,48 @@ func TestServer_Query_Aggregates_IntMany_GroupBy(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Aggregates_IntMany_OrderByDesc(t *testing.T) {
+// Ensure last_value() reports the value in effect at the start of each
+// interval, including a value written before the queried time range.
+func TestServer_Query_LastValue(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	writes := []string{
+		fmt.Sprintf(`states,host=server01 state="idle",load=1i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+		fmt.Sprintf(`states,host=server01 state="busy",load=5i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:31Z").UnixNano()),
+		fmt.Sprintf(`states,host=server01 state="idle",load=2i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:01:10Z").UnixNano()),
+		fmt.Sprintf(`states,host=server02 state="busy",load=7i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:01:00Z").UnixNano()),
+	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join([]string{
-			fmt.Sprintf(`intmany,host=server01 value=2.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server02 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server03 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server04 value=4.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server05 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:40Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server06 value=5.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:50Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server07 value=7.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:00Z").UnixNano()),
-			fmt.Sprintf(`intmany,host=server08 value=9.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
-		}, "\n")},
+		&Write{data: strings.Join(writes, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "aggregate order by time desc",
+			name:    "last_value carries forward from before the time range",
+			command: `SELECT last_value(state) FROM states WHERE host = 'server01' AND time >= '2009-11-10T23:00:30Z' AND time < '2009-11-10T23:02:00Z' GROUP BY time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"states","columns":["time","last_value"],"values":[["2009-11-10T23:00:30Z","idle"],["2009-11-10T23:01:00Z","busy"],["2009-11-10T23:01:30Z","idle"]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT max(value) FROM intmany where time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T00:01:00Z' group by time(10s) order by time desc`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intmany","columns":["time","max"],"values":[["2000-01-01T00:01:00Z",7],["2000-01-01T00:00:50Z",5],["2000-01-01T00:00:40Z",5],["2000-01-01T00:00:30Z",4],["2000-01-01T00:00:20Z",4],["2000-01-01T00:00:10Z",4],["2000-01-01T00:00:00Z",2]]}]}]}`,
 		},
-	}...)
-
-	ctx := context.Background()
-	test.Run(ctx, t, s)
-}
-
-func TestServer_Query_Aggregates_IntOverlap(t *testing.T) {
-	s := OpenServer(t)
-	defer s.Close()
-
-	test := NewTest("db0", "rp0")
-	test.writes = Writes{
-		&Write{data: strings.Join([]string{
-			fmt.Sprintf(`intoverlap,region=us-east value=20 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-			fmt.Sprintf(`intoverlap,region=us-east value=30 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
-			fmt.Sprintf(`intoverlap,region=us-west value=100 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-			fmt.Sprintf(`intoverlap,region=us-east otherVal=20 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:03Z").UnixNano()),
-		}, "\n")},
-	}
-
-	test.addQueries([]*Query{
-		/*		{
-					name:    "aggregation with no interval - int",
-					params:  url.Values{"db": []string{"db0"}},
-					command: `SELECT count(value) FROM intoverlap WHERE time = '2000-01-01 00:00:00'`,
-					exp:     `{"results":[{"statement_id":0,"series":[{"name":"intoverlap","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
-				},
-				{
-					name:    "sum - int",
-					params:  url.Values{"db": []string{"db0"}},
-					command: `SELECT SUM(value) FROM intoverlap WHERE time >= '2000-01-01 00:00:05' AND time <= '2000-01-01T00:00:10Z' GROUP BY time(10s), region`,
-					exp:     `{"results":[{"statement_id":0,"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum"],"values":[["2000-01-01T00:00:10Z",30]]}]}]}`,
-				},
-		*/{
-			name:    "aggregation with a null field value - int",
+		{
+			name:    "last with fill(previous) only fills empty intervals",
+			command: `SELECT last(state) FROM states WHERE host = 'server01' AND time >= '2009-11-10T23:00:30Z' AND time < '2009-11-10T23:02:00Z' GROUP BY time(30s) fill(previous)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"states","columns":["time","last"],"values":[["2009-11-10T23:00:30Z","busy"],["2009-11-10T23:01:00Z","idle"],["2009-11-10T23:01:30Z","idle"]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT SUM(value) FROM intoverlap GROUP BY region`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",50]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",100]]}]}]}`,
 		},
 		{
-			name:    "multiple aggregations - int",
+			name:    "last_value grouped by tag",
+			command: `SELECT last_value(load) FROM states WHERE time >= '2009-11-10T23:00:30Z' AND time < '2009-11-10T23:02:00Z' GROUP BY time(30s), host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"states","tags":{"host":"server01"},"columns":["time","last_value"],"values":[["2009-11-10T23:00:30Z",1],["2009-11-10T23:01:00Z",5],["2009-11-10T23:01:30Z",2]]},{"name":"states","tags":{"host":"server02"},"columns":["time","last_value"],"values":[["2009-11-10T23:00:30Z",null],["2009-11-10T23:01:00Z",7],["2009-11-10T23:01:30Z",7]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT SUM(value), MEAN(value) FROM intoverlap GROUP BY region`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum","mean"],"values":[["1970-01-01T00:00:00Z",50,25]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","sum","mean"],"values":[["1970-01-01T00:00:00Z",100,100]]}]}]}`,
 		},
 		{
-			skip:    SkippedOSS,
-			name:    "multiple aggregations with division - int FIXME issue #2879",
+			name:    "last_value requires GROUP BY time",
+			command: `SELECT last_value(load) FROM states`,
+			exp:     `{"results":[{"statement_id":0,"error":"last_value aggregate requires a GROUP BY interval"}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT sum(value), mean(value), sum(value) / mean(value) as div FROM intoverlap GROUP BY region`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum","mean","div"],"values":[["1970-01-01T00:00:00Z",50,25,2]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","div"],"values":[["1970-01-01T00:00:00Z",100,100,1]]}]}]}`,
 		},
 	}...)
 
@@ -2869,23 +5677,41 @@ func TestServer_Query_Aggregates_IntOverlap(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Aggregates_FloatSingle(t *testing.T) {
+// Ensure multiple selectors in one query each pick their own point and that a
+// single selector carries the fields of the point it selected.
+func TestServer_Query_MultipleSelectors(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
+	writes := []string{
+		fmt.Sprintf(`status,host=server01 value=1i,state="ok" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`status,host=server01 value=3i %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:40:00Z").UnixNano()),
+		fmt.Sprintf(`status,host=server02 value=2i,state="critical" %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:40:00Z").UnixNano()),
+	}
+
 	test := NewTest("db0", "rp0")
 	test.writes = Writes{
-		&Write{data: strings.Join([]string{
-			fmt.Sprintf(`floatsingle value=45.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
-		}, "\n")},
+		&Write{data: strings.Join(writes, "\n")},
 	}
 
 	test.addQueries([]*Query{
 		{
-			name:    "stddev with just one point - float",
+			name:    "last of each field is selected independently",
+			command: `SELECT last(value), last(state) FROM status WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"status","columns":["time","last","last_1"],"values":[["2000-01-01T00:00:00Z",3,"critical"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "last carries the fields of the selected point",
+			command: `SELECT last(value), state, host FROM status WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"status","columns":["time","last","state","host"],"values":[["2000-01-01T00:00:00Z",3,null,"server01"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "multiple selectors cannot carry fields",
+			command: `SELECT last(value), last(state), host FROM status WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z' GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"error":"mixing multiple selector functions with tags or fields is not supported"}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT STDDEV(value) FROM floatsingle`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"floatsingle","columns":["time","stddev"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
 		},
 	}...)
 
@@ -2893,15 +5719,19 @@ func TestServer_Query_Aggregates_FloatSingle(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_LimitAndOffset(t *testing.T) {
+// Ensure rate() computes the per-second increase of a counter and treats
+// a decrease as a counter reset.
+func TestServer_Query_Rate(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
 	writes := []string{
-		fmt.Sprintf(`limited,tennant=paul foo=2 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:02Z").UnixNano()),
-		fmt.Sprintf(`limited,tennant=paul foo=3 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:03Z").UnixNano()),
-		fmt.Sprintf(`limited,tennant=paul foo=4 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:04Z").UnixNano()),
-		fmt.Sprintf(`limited,tennant=todd foo=5 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:05Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=0i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=10i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:10Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=30i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:20Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=10i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:30Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=20i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:40Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=40i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:01:10Z").UnixNano()),
 	}
 
 	test := NewTest("db0", "rp0")
@@ -2911,75 +5741,132 @@ func TestServer_Query_LimitAndOffset(t *testing.T) {
 
 	test.addQueries([]*Query{
 		{
-			name:    "limit on points",
+			name:    "rate with a counter reset",
+			command: `SELECT rate(total) FROM requests WHERE time >= '2009-11-10T23:00:00Z' AND time < '2009-11-10T23:01:20Z' GROUP BY time(40s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","rate"],"values":[["2009-11-10T23:00:00Z",1],["2009-11-10T23:00:40Z",0.75]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select foo from "limited" LIMIT 2`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","foo"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3]]}]}]}`,
 		},
 		{
-			name:    "limit higher than the number of data points",
+			name:    "rate carries the last point of the previous interval",
+			command: `SELECT rate(total) FROM requests WHERE time >= '2009-11-10T23:00:00Z' AND time < '2009-11-10T23:01:20Z' GROUP BY time(40s) ORDER BY time DESC`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","rate"],"values":[["2009-11-10T23:00:40Z",0.75],["2009-11-10T23:00:00Z",1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select foo from "limited" LIMIT 20`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","foo"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4],["2009-11-10T23:00:05Z",5]]}]}]}`,
 		},
 		{
-			name:    "limit and offset",
+			name:    "rate with one point in each interval",
+			command: `SELECT rate(total) FROM requests WHERE time >= '2009-11-10T23:00:00Z' AND time < '2009-11-10T23:00:40Z' GROUP BY time(10s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","rate"],"values":[["2009-11-10T23:00:00Z",null],["2009-11-10T23:00:10Z",1],["2009-11-10T23:00:20Z",2],["2009-11-10T23:00:30Z",1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select foo from "limited" LIMIT 2 OFFSET 1`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","foo"],"values":[["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4]]}]}]}`,
 		},
 		{
-			name:    "limit + offset equal to total number of points",
+			name:    "rate over a trailing window",
+			command: `SELECT rate(total, 80s) FROM requests WHERE time >= '2009-11-10T23:00:00Z' AND time < '2009-11-10T23:01:20Z' GROUP BY time(40s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","rate"],"values":[["2009-11-10T23:00:00Z",0.5],["2009-11-10T23:00:40Z",0.875]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
-			command: `select foo from "limited" LIMIT 3 OFFSET 3`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","foo"],"values":[["2009-11-10T23:00:05Z",5]]}]}]}`,
 		},
 		{
-			name:    "limit - offset higher than number of points",
-			command: `select foo from "limited" LIMIT 2 OFFSET 20`,
-			exp:     `{"results":[{"statement_id":0}]}`,
+			name:    "rate without a GROUP BY interval",
+			command: `SELECT rate(total) FROM requests`,
+			exp:     `{"results":[{"statement_id":0,"error":"rate aggregate requires a GROUP BY interval"}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_CountRate(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`requests,host=server01 total=0i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=10i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:10Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server02 total=30i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:20Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=10i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:30Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server02 total=20i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:30Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=40i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:01:10Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "limit on points with group by time",
-			command: `select mean(foo) from "limited" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 2`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","mean"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3]]}]}]}`,
+			name:    "count_rate is the count divided by the interval in seconds",
+			command: `SELECT count(total), count_rate(total) FROM requests WHERE time >= '2009-11-10T23:00:00Z' AND time < '2009-11-10T23:01:20Z' GROUP BY time(40s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","count","count_rate"],"values":[["2009-11-10T23:00:00Z",5,0.125],["2009-11-10T23:00:40Z",1,0.025]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "limit higher than the number of data points with group by time",
-			command: `select mean(foo) from "limited" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 20`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","mean"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4],["2009-11-10T23:00:05Z",5]]}]}]}`,
+			name:    "count_rate fills empty intervals with zero",
+			command: `SELECT count(total), count_rate(total) FROM requests WHERE time >= '2009-11-10T23:00:00Z' AND time < '2009-11-10T23:01:20Z' GROUP BY time(20s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","count","count_rate"],"values":[["2009-11-10T23:00:00Z",2,0.1],["2009-11-10T23:00:20Z",3,0.15],["2009-11-10T23:00:40Z",0,0],["2009-11-10T23:01:00Z",1,0.05]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "limit and offset with group by time",
-			command: `select mean(foo) from "limited" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 2 OFFSET 1`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","mean"],"values":[["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4]]}]}]}`,
+			name:    "count_rate grouped by tag",
+			command: `SELECT count_rate(total) FROM requests WHERE time >= '2009-11-10T23:00:00Z' AND time < '2009-11-10T23:00:40Z' GROUP BY time(20s), host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","tags":{"host":"server01"},"columns":["time","count_rate"],"values":[["2009-11-10T23:00:00Z",0.1],["2009-11-10T23:00:20Z",0.05]]},{"name":"requests","tags":{"host":"server02"},"columns":["time","count_rate"],"values":[["2009-11-10T23:00:00Z",0],["2009-11-10T23:00:20Z",0.1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "limit + offset equal to the number of points with group by time",
-			command: `select mean(foo) from "limited" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 3 OFFSET 3`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","columns":["time","mean"],"values":[["2009-11-10T23:00:05Z",5]]}]}]}`,
+			name:    "count_rate without a GROUP BY interval",
+			command: `SELECT count_rate(total) FROM requests`,
+			exp:     `{"results":[{"statement_id":0,"error":"count_rate aggregate requires a GROUP BY interval"}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure resets() counts the number of times a counter decreases within
+// each interval.
+func TestServer_Query_Resets(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`requests,host=server01 total=5i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=15i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:10Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=2i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:20Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=8i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:30Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=1i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:40Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=6i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:50Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=9i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:01:00Z").UnixNano()),
+		fmt.Sprintf(`requests,host=server01 total=12i %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:01:10Z").UnixNano()),
+		fmt.Sprintf(`gauges,host=server02 value=3.5 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+		fmt.Sprintf(`gauges,host=server02 value=0.5 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:10Z").UnixNano()),
+		fmt.Sprintf(`gauges,host=server02 value=0.25 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:20Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
 		{
-			name:    "limit - offset higher than number of points with group by time",
-			command: `select mean(foo) from "limited" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 2 OFFSET 20`,
-			exp:     `{"results":[{"statement_id":0}]}`,
+			name:    "resets per interval",
+			command: `SELECT resets(total) FROM requests WHERE host = 'server01' AND time >= '2009-11-10T23:00:00Z' AND time < '2009-11-10T23:01:20Z' GROUP BY time(40s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","resets"],"values":[["2009-11-10T23:00:00Z",1],["2009-11-10T23:00:40Z",0]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "limit - group by tennant",
-			command: `select foo from "limited" group by tennant limit 1`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","tags":{"tennant":"paul"},"columns":["time","foo"],"values":[["2009-11-10T23:00:02Z",2]]},{"name":"limited","tags":{"tennant":"todd"},"columns":["time","foo"],"values":[["2009-11-10T23:00:05Z",5]]}]}]}`,
+			name:    "resets over the whole range",
+			command: `SELECT resets(total) FROM requests WHERE host = 'server01'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","resets"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "limit and offset - group by tennant",
-			command: `select foo from "limited" group by tennant limit 1 offset 1`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"limited","tags":{"tennant":"paul"},"columns":["time","foo"],"values":[["2009-11-10T23:00:03Z",3]]}]}]}`,
+			name:    "resets of a float field grouped by tag",
+			command: `SELECT resets(value) FROM gauges WHERE time >= '2009-11-10T23:00:00Z' AND time < '2009-11-10T23:00:40Z' GROUP BY time(20s), host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"gauges","tags":{"host":"server02"},"columns":["time","resets"],"values":[["2009-11-10T23:00:00Z",1],["2009-11-10T23:00:20Z",0]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 	}...)
@@ -2988,15 +5875,20 @@ func TestServer_Query_LimitAndOffset(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Fill(t *testing.T) {
+// Ensure the _over_time functions compute a statistic over trailing windows
+// that overlap when the window is longer than the GROUP BY interval.
+func TestServer_Query_OverTime(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
-	writes := []string{
-		fmt.Sprintf(`fills val=3 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:02Z").UnixNano()),
-		fmt.Sprintf(`fills val=5 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:03Z").UnixNano()),
-		fmt.Sprintf(`fills val=4 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:06Z").UnixNano()),
-		fmt.Sprintf(`fills val=10 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:16Z").UnixNano()),
+	var writes []string
+	for i, v := range []int{1, 2, 3, 4, 5, 6, 7, 8} {
+		ts := mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").Add(time.Duration(i) * time.Minute)
+		host := "server01"
+		if i%2 == 1 {
+			host = "server02"
+		}
+		writes = append(writes, fmt.Sprintf(`cpu,host=%s value=%di %d`, host, v, ts.UnixNano()))
 	}
 
 	test := NewTest("db0", "rp0")
@@ -3006,63 +5898,51 @@ func TestServer_Query_Fill(t *testing.T) {
 
 	test.addQueries([]*Query{
 		{
-			name:    "fill with value",
-			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) FILL(1)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",1],["2009-11-10T23:00:15Z",10]]}]}]}`,
-			params:  url.Values{"db": []string{"db0"}},
-		},
-		{
-			name:    "fill with value, WHERE all values match condition",
-			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' and val < 50 group by time(5s) FILL(1)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",1],["2009-11-10T23:00:15Z",10]]}]}]}`,
-			params:  url.Values{"db": []string{"db0"}},
-		},
-		{
-			name:    "fill with value, WHERE no values match condition",
-			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' and val > 50 group by time(5s) FILL(1)`,
-			exp:     `{"results":[{"statement_id":0}]}`,
+			name:    "mean over non-overlapping intervals",
+			command: `SELECT mean(value) FROM cpu WHERE time >= '2000-01-01T00:04:00Z' AND time < '2000-01-01T00:08:00Z' GROUP BY time(2m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean"],"values":[["2000-01-01T00:04:00Z",5.5],["2000-01-01T00:06:00Z",7.5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "fill with previous",
-			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) FILL(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",4],["2009-11-10T23:00:15Z",10]]}]}]}`,
+			name:    "mean_over_time with a window equal to the interval",
+			command: `SELECT mean_over_time(value, 2m) FROM cpu WHERE time >= '2000-01-01T00:04:00Z' AND time < '2000-01-01T00:08:00Z' GROUP BY time(2m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean_over_time"],"values":[["2000-01-01T00:04:00Z",5.5],["2000-01-01T00:06:00Z",7.5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "fill with none, i.e. clear out nulls",
-			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) FILL(none)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:15Z",10]]}]}]}`,
+			name:    "mean_over_time with overlapping windows",
+			command: `SELECT mean_over_time(value, 4m) FROM cpu WHERE time >= '2000-01-01T00:04:00Z' AND time < '2000-01-01T00:08:00Z' GROUP BY time(2m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean_over_time"],"values":[["2000-01-01T00:04:00Z",4.5],["2000-01-01T00:06:00Z",6.5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "fill defaults to null",
-			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",null],["2009-11-10T23:00:15Z",10]]}]}]}`,
+			name:    "stddev_over_time and percentile_over_time",
+			command: `SELECT stddev_over_time(value, 3m), percentile_over_time(value, 50, 3m) FROM cpu WHERE time >= '2000-01-01T00:04:00Z' AND time < '2000-01-01T00:07:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","stddev_over_time","percentile_over_time"],"values":[["2000-01-01T00:04:00Z",1,4],["2000-01-01T00:05:00Z",1,5],["2000-01-01T00:06:00Z",1,6]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "fill defaults to 0 for count",
-			command: `select count(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:10Z",0],["2009-11-10T23:00:15Z",1]]}]}]}`,
+			name:    "mean_over_time grouped by tag",
+			command: `SELECT mean_over_time(value, 4m) FROM cpu WHERE time >= '2000-01-01T00:04:00Z' AND time < '2000-01-01T00:08:00Z' GROUP BY time(2m), host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","mean_over_time"],"values":[["2000-01-01T00:04:00Z",4],["2000-01-01T00:06:00Z",6]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","mean_over_time"],"values":[["2000-01-01T00:04:00Z",5],["2000-01-01T00:06:00Z",7]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "fill none drops 0s for count",
-			command: `select count(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) fill(none)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:15Z",1]]}]}]}`,
+			name:    "mean_over_time ordered by time descending",
+			command: `SELECT mean_over_time(value, 4m) FROM cpu WHERE time >= '2000-01-01T00:04:00Z' AND time < '2000-01-01T00:08:00Z' GROUP BY time(2m) ORDER BY time DESC`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean_over_time"],"values":[["2000-01-01T00:06:00Z",6.5],["2000-01-01T00:04:00Z",4.5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "fill previous overwrites 0s for count",
-			command: `select count(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) fill(previous)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:10Z",1],["2009-11-10T23:00:15Z",1]]}]}]}`,
+			name:    "mean_over_time fills intervals without points in the window",
+			command: `SELECT mean_over_time(value, 2m) FROM cpu WHERE time >= '2000-01-01T00:06:00Z' AND time < '2000-01-01T00:12:00Z' GROUP BY time(2m) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean_over_time"],"values":[["2000-01-01T00:06:00Z",7.5],["2000-01-01T00:08:00Z",0],["2000-01-01T00:10:00Z",0]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
-			name:    "fill with implicit start time",
-			command: `select mean(val) from fills where time < '2009-11-10T23:00:20Z' group by time(5s)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",null],["2009-11-10T23:00:15Z",10]]}]}]}`,
+			name:    "mean_over_time without a GROUP BY interval",
+			command: `SELECT mean_over_time(value, 4m) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"error":"mean_over_time aggregate requires a GROUP BY interval"}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 	}...)
@@ -3154,15 +6034,13 @@ func TestServer_Query_Aggregates_FloatMany(t *testing.T) {
 			name:    "distinct select tag - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT DISTINCT(host) FROM floatmany`,
-			exp:     `{"results":[{"statement_id":0,"error":"statement must have at least one field in select clause"}]}`,
-			skip:    SkippedOSS, // FIXME(benbjohnson): show be allowed, stream tag values
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"floatmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z","server01"],["1970-01-01T00:00:00Z","server02"],["1970-01-01T00:00:00Z","server03"],["1970-01-01T00:00:00Z","server04"],["1970-01-01T00:00:00Z","server05"],["1970-01-01T00:00:00Z","server06"],["1970-01-01T00:00:00Z","server07"],["1970-01-01T00:00:00Z","server08"]]}]}]}`,
 		},
 		{
 			name:    "distinct alt select tag - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT DISTINCT host FROM floatmany`,
-			exp:     `{"results":[{"statement_id":0,"error":"statement must have at least one field in select clause"}]}`,
-			skip:    SkippedOSS, // FIXME(benbjohnson): show be allowed, stream tag values
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"floatmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z","server01"],["1970-01-01T00:00:00Z","server02"],["1970-01-01T00:00:00Z","server03"],["1970-01-01T00:00:00Z","server04"],["1970-01-01T00:00:00Z","server05"],["1970-01-01T00:00:00Z","server06"],["1970-01-01T00:00:00Z","server07"],["1970-01-01T00:00:00Z","server08"]]}]}]}`,
 		},
 		{
 			name:    "count distinct - float",
@@ -3180,15 +6058,25 @@ func TestServer_Query_Aggregates_FloatMany(t *testing.T) {
 			name:    "count distinct select tag - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT COUNT(DISTINCT host) FROM floatmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"floatmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",0]]}]}]}`,
-			skip:    SkippedOSS, // FIXME(benbjohnson): stream tag values
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"floatmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",8]]}]}]}`,
 		},
 		{
 			name:    "count distinct as call select tag - float",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT COUNT(DISTINCT host) FROM floatmany`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"floatmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",0]]}]}]}`,
-			skip:    SkippedOSS, // FIXME(benbjohnson): stream tag values
+			command: `SELECT COUNT(DISTINCT(host)) FROM floatmany`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"floatmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",8]]}]}]}`,
+		},
+		{
+			name:    "count distinct select tag with time range - float",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT COUNT(DISTINCT host) FROM floatmany WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:10Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"floatmany","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",7]]}]}]}`,
+		},
+		{
+			name:    "count distinct select tag group by time - float",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT COUNT(DISTINCT host) FROM floatmany WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:30Z' GROUP BY time(30s)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"floatmany","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",3],["2000-01-01T00:00:30Z",3],["2000-01-01T00:01:00Z",2]]}]}]}`,
 		},
 	}...)
 
@@ -3375,6 +6263,30 @@ func TestServer_Query_ShowTagValues(t *testing.T) {
 			exp:     `{"results":[{"statement_id":0}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
+		{
+			name:    "show tag values cardinality grouped by key",
+			command: `SHOW TAG VALUES CARDINALITY WITH KEY IN (host, region) GROUP BY "key"`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["key","count"],"values":[["host",2],["region",2]]},{"name":"disk","columns":["key","count"],"values":[["host",1],["region",1]]},{"name":"gpu","columns":["key","count"],"values":[["host",2],["region",2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "show tag values exact cardinality grouped by key with where",
+			command: `SHOW TAG VALUES EXACT CARDINALITY FROM cpu WITH KEY =~ /.*/ WHERE region = 'useast' GROUP BY "key"`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["key","count"],"values":[["host",2],["region",1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "show tag values cardinality grouped by key with limit",
+			command: `SHOW TAG VALUES CARDINALITY FROM cpu WITH KEY =~ /.*/ GROUP BY "key" LIMIT 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["key","count"],"values":[["host",2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "show tag values cardinality grouped by key and another dimension",
+			command: `SHOW TAG VALUES CARDINALITY WITH KEY = host GROUP BY "key", region`,
+			exp:     `{"results":[{"statement_id":0,"error":"GROUP BY key cannot be combined with other dimensions"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
 	}...)
 
 	ctx := context.Background()
@@ -3828,6 +6740,52 @@ func TestServer_Query_Aggregates_GroupByOffset(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
+func TestServer_Query_Aggregates_GroupByDurationExpression(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	var writes []string
+	for i := 0; i < 12; i++ {
+		ts := mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").Add(time.Duration(i) * 5 * time.Minute)
+		writes = append(writes, fmt.Sprintf(`requests value=%d %d`, i, ts.UnixNano()))
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "count with divided interval",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(value) FROM requests WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z' GROUP BY time(1h/4)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",3],["2000-01-01T00:15:00Z",3],["2000-01-01T00:30:00Z",3],["2000-01-01T00:45:00Z",3]]}]}]}`,
+		},
+		{
+			name:    "sum with multiplied interval and offset",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value) FROM requests WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z' GROUP BY time(10m*3, 1h/4)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","sum"],"values":[["1999-12-31T23:45:00Z",3],["2000-01-01T00:15:00Z",33],["2000-01-01T00:45:00Z",30]]}]}]}`,
+		},
+		{
+			name:    "interval divided by zero",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(value) FROM requests GROUP BY time(1h/0)`,
+			exp:     `{"results":[{"statement_id":0,"error":"time dimension duration expression must be positive, got 0s"}]}`,
+		},
+		{
+			name:    "negative interval",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(value) FROM requests GROUP BY time(1h-2h)`,
+			exp:     `{"results":[{"statement_id":0,"error":"time dimension duration expression must be positive, got -1h"}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
 // This will test that when using a group by, that it observes the time you asked for
 // but will only put the values in the bucket that match the time range
 func TestServer_Query_GroupByTimeCutoffs(t *testing.T) {
@@ -3940,6 +6898,18 @@ func TestServer_Query_MapType(t *testing.T) {
 			command: `SELECT * FROM /[cg]pu/`,
 			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","speed","value"],"values":[["2000-01-01T00:00:00Z",null,2]]},{"name":"gpu","columns":["time","speed","value"],"values":[["2000-01-01T00:00:00Z",25,null]]}]}]}`,
 		},
+		{
+			name:    "query wildcard with a regex measurement and union columns",
+			params:  url.Values{"db": []string{"db0"}, "union_columns": []string{"true"}},
+			command: `SELECT * FROM /[cg]pu/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["time","name","speed","value"],"values":[["2000-01-01T00:00:00Z","cpu",null,2],["2000-01-01T00:00:00Z","gpu",25,null]]}]}]}`,
+		},
+		{
+			name:    "query fields with multiple measurements and union columns",
+			params:  url.Values{"db": []string{"db0"}, "union_columns": []string{"true"}},
+			command: `SELECT speed, value FROM gpu, cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["time","name","speed","value"],"values":[["2000-01-01T00:00:00Z","cpu",null,2],["2000-01-01T00:00:00Z","gpu",25,null]]}]}]}`,
+		},
 	}...)
 
 	ctx := context.Background()
@@ -4178,6 +7148,36 @@ func TestServer_Query_SubqueryMath(t *testing.T) {
 			command: `SELECT sum FROM (SELECT f1 + f2 + f3 AS sum FROM m0)`,
 			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m0","columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",null],["2000-01-01T00:00:10Z",null],["2000-01-01T00:00:20Z",14]]}]}]}`,
 		},
+		{
+			name:    "FilterOnComputedColumn",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM (SELECT f1 + f3 AS value FROM m0) WHERE value > 12`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m0","columns":["time","value"],"values":[["2000-01-01T00:00:10Z",13]]}]}]}`,
+		},
+		{
+			name:    "FilterOnComputedColumnExcludesNulls",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum FROM (SELECT f1 + f2 + f3 AS sum FROM m0) WHERE sum > 10`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m0","columns":["time","sum"],"values":[["2000-01-01T00:00:20Z",14]]}]}]}`,
+		},
+		{
+			name:    "FilterOnUnselectedComputedColumn",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT f3 FROM (SELECT f1 + f3 AS value, f3 FROM m0) WHERE value > 12`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m0","columns":["time","f3"],"values":[["2000-01-01T00:00:10Z",8]]}]}]}`,
+		},
+		{
+			name:    "FilterOnComputedColumnShadowingField",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT max(f3) FROM (SELECT f3 * 10 AS f3 FROM m0) WHERE f3 < 70`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m0","columns":["time","max"],"values":[["2000-01-01T00:00:20Z",60]]}]}]}`,
+		},
+		{
+			name:    "FilterOnComputedAggregate",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM (SELECT max(f3) + 1 AS value FROM m0 GROUP BY time(10s)) WHERE value > 7 AND time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:30Z'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"m0","columns":["time","value"],"values":[["2000-01-01T00:00:10Z",9]]}]}]}`,
+		},
 	}...)
 
 	ctx := context.Background()
@@ -4251,6 +7251,10 @@ func TestServer_Query_Wildcards(t *testing.T) {
 		fmt.Sprintf(`wgroup,region=us-east value=20.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
 		fmt.Sprintf(`wgroup,region=us-west value=30.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
 
+		fmt.Sprintf(`wregex,k8s_ns=default,k8s_pod=a,host=server01 value=10.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`wregex,k8s_ns=default,k8s_pod=a,host=server02 value=20.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:10Z").UnixNano()),
+		fmt.Sprintf(`wregex,k8s_ns=default,k8s_pod=b,host=server01 value=30.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
+
 		fmt.Sprintf(`m1,region=us-east value=10.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
 		fmt.Sprintf(`m2,host=server01 field=20.0 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:01Z").UnixNano()),
 	}
@@ -4285,6 +7289,24 @@ func TestServer_Query_Wildcards(t *testing.T) {
 			command: `SELECT mean(value) FROM wgroup WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:00Z' GROUP BY *,TIME(1m)`,
 			exp:     `{"results":[{"statement_id":0,"series":[{"name":"wgroup","tags":{"region":"us-east"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",15]]},{"name":"wgroup","tags":{"region":"us-west"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",30]]}]}]}`,
 		},
+		{
+			name:    "GROUP BY regex",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value) FROM wregex GROUP BY /^k8s_/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"wregex","tags":{"k8s_ns":"default","k8s_pod":"a"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",30]]},{"name":"wregex","tags":{"k8s_ns":"default","k8s_pod":"b"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",30]]}]}]}`,
+		},
+		{
+			name:    "GROUP BY regex matching all tags",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value) FROM wregex GROUP BY /.*/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"wregex","tags":{"host":"server01","k8s_ns":"default","k8s_pod":"a"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",10]]},{"name":"wregex","tags":{"host":"server01","k8s_ns":"default","k8s_pod":"b"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",30]]},{"name":"wregex","tags":{"host":"server02","k8s_ns":"default","k8s_pod":"a"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",20]]}]}]}`,
+		},
+		{
+			name:    "GROUP BY regex and tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(value) FROM wregex GROUP BY /_pod$/, host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"wregex","tags":{"host":"server01","k8s_pod":"a"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",10]]},{"name":"wregex","tags":{"host":"server01","k8s_pod":"b"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",30]]},{"name":"wregex","tags":{"host":"server02","k8s_pod":"a"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",20]]}]}]}`,
+		},
 		{
 			name:    "wildcard and field in select",
 			params:  url.Values{"db": []string{"db0"}},
@@ -4413,7 +7435,6 @@ func TestServer_Query_ShowQueries_Future(t *testing.T) {
 		},
 		{
 			name:    `show series`,
-			skip:    NotSupported,
 			command: "SHOW SERIES",
 			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["key"],"values":[["cpu,host=server01"]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
@@ -4443,7 +7464,6 @@ func TestServer_Query_ShowQueries_Future(t *testing.T) {
 }
 
 func TestServer_Query_ShowSeries(t *testing.T) {
-	t.Skip(NotSupported)
 	s := OpenServer(t)
 	defer s.Close()
 
@@ -4547,6 +7567,41 @@ func TestServer_Query_ShowSeries(t *testing.T) {
 			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["key"],"values":[["cpu,host=server01,region=useast"],["cpu,host=server02,region=useast"]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
+		{
+			name:    `show series with limit`,
+			command: "SHOW SERIES LIMIT 2",
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["key"],"values":[["cpu,host=server01"],["cpu,host=server01,region=useast"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    `show series with limit and offset`,
+			command: "SHOW SERIES LIMIT 2 OFFSET 3",
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["key"],"values":[["cpu,host=server02,region=useast"],["disk,host=server03,region=caeast"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    `show series from regular expression with where, limit and offset`,
+			command: "SHOW SERIES FROM /[cg]pu/ WHERE region = 'useast' LIMIT 1 OFFSET 1",
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["key"],"values":[["cpu,host=server02,region=useast"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    `show series with offset past the end`,
+			command: "SHOW SERIES FROM cpu OFFSET 10",
+			exp:     `{"results":[{"statement_id":0}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    `show series on database`,
+			command: "SHOW SERIES ON db0 FROM gpu",
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["key"],"values":[["gpu,host=server02,region=useast"],["gpu,host=server03,region=caeast"]]}]}]}`,
+		},
+		{
+			name:    `show series from missing measurement`,
+			command: "SHOW SERIES FROM mem",
+			exp:     `{"results":[{"statement_id":0}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
 	}...)
 
 	ctx := context.Background()
@@ -4590,7 +7645,6 @@ func TestServer_Query_ShowSeriesCardinalityEstimation(t *testing.T) {
 }
 
 func TestServer_Query_ShowSeriesExactCardinality(t *testing.T) {
-	t.Skip(NotSupported)
 	s := OpenServer(t)
 	defer s.Close()
 
@@ -4610,6 +7664,17 @@ func TestServer_Query_ShowSeriesExactCardinality(t *testing.T) {
 	}
 
 	test.addQueries([]*Query{
+		{
+			name:    `show series cardinality`,
+			command: "SHOW SERIES CARDINALITY",
+			exp:     `{"results":[{"statement_id":0,"series":[{"columns":["cardinality estimation"],"values":[[7]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    `show series exact cardinality on database`,
+			command: "SHOW SERIES EXACT CARDINALITY ON db0 FROM /[dg].*/",
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"disk","columns":["count"],"values":[[1]]},{"name":"gpu","columns":["count"],"values":[[2]]}]}]}`,
+		},
 		{
 			name:    `show series cardinality from measurement`,
 			command: "SHOW SERIES CARDINALITY FROM cpu",
@@ -4706,6 +7771,69 @@ func TestServer_Query_ShowSeriesExactCardinality(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
+// Ensure a query can be scoped to a bucket by name without a DBRP mapping.
+func TestServer_Query_Bucket(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	ctx := context.Background()
+
+	client := s.MustNewAdminClient()
+	metrics := influxdb.Bucket{
+		OrgID: s.DefaultOrgID,
+		Name:  "metrics",
+	}
+	require.NoError(t, client.CreateBucket(ctx, &metrics))
+
+	test := NewTest("", "")
+	test.noDefaultMapping = true
+	test.writes = Writes{
+		&Write{data: fmt.Sprintf(`cpu,host=server01 value=1 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano())},
+		&Write{bucketID: metrics.ID, data: fmt.Sprintf(`mem,host=server02 value=2 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano())},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "select from a db/rp bucket",
+			command: `SELECT * FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","value"],"values":[["2009-11-10T23:00:00Z","server01",1]]}]}]}`,
+			params:  url.Values{"bucket": []string{"db/rp"}},
+		},
+		{
+			name:    "select with the database and retention policy of the bucket",
+			command: `SELECT * FROM db.rp.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","host","value"],"values":[["2009-11-10T23:00:00Z","server01",1]]}]}]}`,
+			params:  url.Values{"bucket": []string{"db/rp"}},
+		},
+		{
+			name:    "select from a bucket without a retention policy in its name",
+			command: `SELECT * FROM metrics.autogen.mem`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mem","columns":["time","host","value"],"values":[["2009-11-10T23:00:00Z","server02",2]]}]}]}`,
+			params:  url.Values{"bucket": []string{"metrics"}},
+		},
+		{
+			name:    "show measurements of a bucket",
+			command: `SHOW MEASUREMENTS`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"measurements","columns":["name"],"values":[["mem"]]}]}]}`,
+			params:  url.Values{"bucket": []string{"metrics"}},
+		},
+		{
+			name:    "select from another database is not allowed",
+			command: `SELECT * FROM db.rp.cpu`,
+			exp:     `{"results":[{"statement_id":0,"error":"database not found: db"}]}`,
+			params:  url.Values{"bucket": []string{"metrics"}},
+		},
+		{
+			name:    "select without a DBRP mapping",
+			command: `SELECT * FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"error":"database not found: db"}]}`,
+			params:  url.Values{"db": []string{"db"}},
+		},
+	}...)
+
+	test.Run(ctx, t, s)
+}
+
 func TestServer_Query_ShowMeasurements(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
@@ -5133,6 +8261,55 @@ func TestServer_Query_ShowTagKeys(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
+// Ensure SHOW TAG KEYS orders the keys by their number of values when
+// order_by_cardinality is requested.
+func TestServer_Query_ShowTagKeysOrderByCardinality(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`mem,dc=east,host=server01,rack=r1 value=1 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+		fmt.Sprintf(`mem,dc=east,host=server02,rack=r1 value=1 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+		fmt.Sprintf(`mem,dc=east,host=server03,rack=r2 value=1 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+		fmt.Sprintf(`swap,az=a,host=server01 value=1 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+		fmt.Sprintf(`swap,az=b,host=server01 value=1 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "lexical order by default",
+			command: "SHOW TAG KEYS",
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mem","columns":["tagKey"],"values":[["dc"],["host"],["rack"]]},{"name":"swap","columns":["tagKey"],"values":[["az"],["host"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "ordered by cardinality",
+			command: "SHOW TAG KEYS",
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mem","columns":["tagKey"],"values":[["host"],["rack"],["dc"]]},{"name":"swap","columns":["tagKey"],"values":[["az"],["host"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "order_by_cardinality": []string{"true"}},
+		},
+		{
+			name:    "ordered by cardinality before limit",
+			command: "SHOW TAG KEYS FROM mem LIMIT 2",
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mem","columns":["tagKey"],"values":[["host"],["rack"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "order_by_cardinality": []string{"true"}},
+		},
+		{
+			name:    "ordered by cardinality with a condition",
+			command: "SHOW TAG KEYS FROM mem WHERE rack = 'r1'",
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"mem","columns":["tagKey"],"values":[["host"],["dc"],["rack"]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}, "order_by_cardinality": []string{"true"}},
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
 func TestServer_Query_LargeTimestamp(t *testing.T) {
 	// This test fails to build. The offending portions have been commented out.
 	t.Skip(NeedsReview)
@@ -5300,8 +8477,40 @@ func TestServer_Query_ImplicitEndTime(t *testing.T) {
 	past := now.Add(-10 * time.Second)
 	future := now.Add(10 * time.Minute)
 	writes := []string{
-		fmt.Sprintf(`cpu value=1 %d`, past.UnixNano()),
-		fmt.Sprintf(`cpu value=2 %d`, future.UnixNano()),
+		fmt.Sprintf(`cpu value=1 %d`, past.UnixNano()),
+		fmt.Sprintf(`cpu value=2 %d`, future.UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "raw query",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT * FROM cpu`,
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["%s",1],["%s",2]]}]}]}`, past.Format(time.RFC3339Nano), future.Format(time.RFC3339Nano)),
+		},
+		{
+			name:    "aggregate query",
+			params:  url.Values{"db": []string{"db0"}},
+			command: fmt.Sprintf(`SELECT mean(value) FROM cpu WHERE time > '%s' - 1m GROUP BY time(1m) FILL(none)`, now.Truncate(time.Minute).Format(time.RFC3339Nano)),
+			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean"],"values":[["%s",1]]}]}]}`, now.Truncate(time.Minute).Format(time.RFC3339Nano)),
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+func TestServer_Query_Sample_Wildcard(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu float=1,int=1i,string="hello, world",bool=true %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
 	}
 
 	test := NewTest("db0", "rp0")
@@ -5311,16 +8520,10 @@ func TestServer_Query_ImplicitEndTime(t *testing.T) {
 
 	test.addQueries([]*Query{
 		{
-			name:    "raw query",
-			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT * FROM cpu`,
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["%s",1],["%s",2]]}]}]}`, past.Format(time.RFC3339Nano), future.Format(time.RFC3339Nano)),
-		},
-		{
-			name:    "aggregate query",
+			name:    "sample() with wildcard",
 			params:  url.Values{"db": []string{"db0"}},
-			command: fmt.Sprintf(`SELECT mean(value) FROM cpu WHERE time > '%s' - 1m GROUP BY time(1m) FILL(none)`, now.Truncate(time.Minute).Format(time.RFC3339Nano)),
-			exp:     fmt.Sprintf(`{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean"],"values":[["%s",1]]}]}]}`, now.Truncate(time.Minute).Format(time.RFC3339Nano)),
+			command: `SELECT sample(*, 1) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sample_bool","sample_float","sample_int","sample_string"],"values":[["2000-01-01T00:00:00Z",true,1,1,"hello, world"]]}]}]}`,
 		},
 	}...)
 
@@ -5328,12 +8531,15 @@ func TestServer_Query_ImplicitEndTime(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
-func TestServer_Query_Sample_Wildcard(t *testing.T) {
+func TestServer_Query_Sample_GroupByTime(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
 
 	writes := []string{
-		fmt.Sprintf(`cpu float=1,int=1i,string="hello, world",bool=true %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=1 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=2 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:30Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server01 value=3 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:01:10Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=4 %d`, mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:20Z").UnixNano()),
 	}
 
 	test := NewTest("db0", "rp0")
@@ -5343,10 +8549,22 @@ func TestServer_Query_Sample_Wildcard(t *testing.T) {
 
 	test.addQueries([]*Query{
 		{
-			name:    "sample() with wildcard",
+			name:    "sample() per interval",
 			params:  url.Values{"db": []string{"db0"}},
-			command: `SELECT sample(*, 1) FROM cpu`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sample_bool","sample_float","sample_int","sample_string"],"values":[["2000-01-01T00:00:00Z",true,1,1,"hello, world"]]}]}]}`,
+			command: `SELECT sample(value, 2) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:02:00Z' GROUP BY time(1m), host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","sample"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:00:30Z",2],["2000-01-01T00:01:10Z",3]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","sample"],"values":[["2000-01-01T00:00:20Z",4]]}]}]}`,
+		},
+		{
+			name:    "sample() with tag column",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sample(value, 2), host FROM cpu WHERE time >= '2000-01-01T00:01:00Z' AND time < '2000-01-01T00:02:00Z' GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sample","host"],"values":[["2000-01-01T00:01:10Z",3,"server01"]]}]}]}`,
+		},
+		{
+			name:    "sample() with more samples than points",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sample(value, 10) FROM cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sample"],"values":[["2000-01-01T00:00:00Z",1],["2000-01-01T00:00:20Z",4],["2000-01-01T00:00:30Z",2],["2000-01-01T00:01:10Z",3]]}]}]}`,
 		},
 	}...)
 
@@ -5394,6 +8612,93 @@ func TestServer_Query_Sample_LimitOffset(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
+// Ensure elapsed() returns the truncated difference between consecutive points of each series.
+func TestServer_Query_Elapsed(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `cpu,host=server01 value=1 0
+cpu,host=server01 value=2 1500000000
+cpu,host=server01 value=3 4999000000
+cpu,host=server02 value=4 1000000000
+cpu,host=server02 value=5 3700000000
+`},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "elapsed in nanoseconds by default",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT elapsed(value) FROM cpu WHERE host = 'server01'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","elapsed"],"values":[["1970-01-01T00:00:01.5Z",1500000000],["1970-01-01T00:00:04.999Z",3499000000]]}]}]}`,
+		},
+		{
+			name:    "elapsed truncates toward zero",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT elapsed(value, 1s) FROM cpu WHERE host = 'server01'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","elapsed"],"values":[["1970-01-01T00:00:01.5Z",1],["1970-01-01T00:00:04.999Z",3]]}]}]}`,
+		},
+		{
+			name:    "elapsed per series",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT elapsed(value, 1s) FROM cpu GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","elapsed"],"values":[["1970-01-01T00:00:01.5Z",1],["1970-01-01T00:00:04.999Z",3]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","elapsed"],"values":[["1970-01-01T00:00:03.7Z",2]]}]}]}`,
+		},
+		{
+			name:    "elapsed in a subquery",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT sum(elapsed) FROM (SELECT elapsed(value, 1ms) FROM cpu GROUP BY host) GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",4999]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",2700]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure elapsed() of raw values is restarted at each GROUP BY time() boundary.
+func TestServer_Query_SelectGroupByTimeElapsed(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: `cpu value=1 0
+cpu value=2 10000000000
+cpu value=3 30000000000
+cpu value=4 60000000000
+cpu value=5 65000000000
+cpu value=6 125000000000
+`},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "elapsed within each interval",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT elapsed(value, 1s) FROM cpu WHERE time >= 0s AND time < 3m GROUP BY time(1m)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","elapsed"],"values":[["1970-01-01T00:00:10Z",10],["1970-01-01T00:00:30Z",20],["1970-01-01T00:01:05Z",5]]}]}]}`,
+		},
+		{
+			name:    "elapsed within each interval descending",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT elapsed(value, 1s) FROM cpu WHERE time >= 0s AND time < 3m GROUP BY time(1m) ORDER BY time DESC`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","elapsed"],"values":[["1970-01-01T00:01:00Z",-5],["1970-01-01T00:00:10Z",-20],["1970-01-01T00:00:00Z",-10]]}]}]}`,
+		},
+		{
+			name:    "elapsed without an interval",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT elapsed(value, 1s) FROM cpu WHERE time >= 0s AND time < 3m`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","elapsed"],"values":[["1970-01-01T00:00:10Z",10],["1970-01-01T00:00:30Z",20],["1970-01-01T00:01:00Z",30],["1970-01-01T00:01:05Z",5],["1970-01-01T00:02:05Z",60]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
 // Validate that nested aggregates don't panic
 func TestServer_NestedAggregateWithMathPanics(t *testing.T) {
 	s := OpenServer(t)
@@ -5564,6 +8869,33 @@ func TestServer_Query_SelectRawDerivative(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
+// Ensure the server can query with the difference function.
+func TestServer_Query_SelectRawDifference(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: "cpu value=210 1278010021000000000\ncpu value=10 1278010022000000000"},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "calculate single difference",
+			command: `SELECT difference(value) from db0.rp0.cpu`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","difference"],"values":[["2010-07-01T18:47:02Z",-200]]}]}]}`,
+		},
+		{
+			name:    "calculate difference of a single point",
+			command: `SELECT difference(value) from db0.rp0.cpu where time < '2010-07-01 18:47:02'`,
+			exp:     `{"results":[{"statement_id":0}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
 // Ensure the server can handle various group by time derivative queries.
 func TestServer_Query_SelectGroupByTimeDerivativeWithFill(t *testing.T) {
 	s := OpenServer(t)
@@ -5784,6 +9116,54 @@ cpu value=20 1278010021000000000
 }
 
 // Ensure the server can handle various group by time difference queries.
+// Ensure fill(linear) interpolates the gaps before non_negative_derivative is
+// calculated and that counter resets are still dropped.
+func TestServer_Query_SelectGroupByTimeNonNegativeDerivativeWithLinearFill(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join([]string{
+			fmt.Sprintf(`requests value=10 %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:00:00Z").UnixNano()),
+			fmt.Sprintf(`requests value=20 %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:01:00Z").UnixNano()),
+			fmt.Sprintf(`requests value=40 %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:03:00Z").UnixNano()),
+			fmt.Sprintf(`requests value=5 %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:04:00Z").UnixNano()),
+			fmt.Sprintf(`requests value=25 %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:06:00Z").UnixNano()),
+			fmt.Sprintf(`resets value=10 %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:00:00Z").UnixNano()),
+			fmt.Sprintf(`resets value=30 %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:01:00Z").UnixNano()),
+			fmt.Sprintf(`resets value=6 %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:03:00Z").UnixNano()),
+			fmt.Sprintf(`resets value=8 %d`, mustParseTime(time.RFC3339Nano, "2010-07-01T18:04:00Z").UnixNano()),
+		}, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "non_negative_derivative of mean with fill linear",
+			command: `SELECT non_negative_derivative(mean(value)) FROM db0.rp0.requests WHERE time >= '2010-07-01T18:00:00Z' AND time < '2010-07-01T18:07:00Z' GROUP BY time(1m) fill(linear)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","non_negative_derivative"],"values":[["2010-07-01T18:01:00Z",10],["2010-07-01T18:02:00Z",10],["2010-07-01T18:03:00Z",10],["2010-07-01T18:05:00Z",10],["2010-07-01T18:06:00Z",10]]}]}]}`,
+		},
+		{
+			name:    "non_negative_derivative of mean with unit and fill linear",
+			command: `SELECT non_negative_derivative(mean(value), 1s) FROM db0.rp0.requests WHERE time >= '2010-07-01T18:00:00Z' AND time < '2010-07-01T18:07:00Z' GROUP BY time(1m) fill(linear)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","non_negative_derivative"],"values":[["2010-07-01T18:01:00Z",0.16666666666666666],["2010-07-01T18:02:00Z",0.16666666666666666],["2010-07-01T18:03:00Z",0.16666666666666666],["2010-07-01T18:05:00Z",0.16666666666666666],["2010-07-01T18:06:00Z",0.16666666666666666]]}]}]}`,
+		},
+		{
+			name:    "non_negative_derivative of max with fill linear",
+			command: `SELECT non_negative_derivative(max(value)) FROM db0.rp0.requests WHERE time >= '2010-07-01T18:00:00Z' AND time < '2010-07-01T18:07:00Z' GROUP BY time(1m) fill(linear)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"requests","columns":["time","non_negative_derivative"],"values":[["2010-07-01T18:01:00Z",10],["2010-07-01T18:02:00Z",10],["2010-07-01T18:03:00Z",10],["2010-07-01T18:05:00Z",10],["2010-07-01T18:06:00Z",10]]}]}]}`,
+		},
+		{
+			name:    "non_negative_derivative with a reset inside an interpolated gap",
+			command: `SELECT non_negative_derivative(mean(value)) FROM db0.rp0.resets WHERE time >= '2010-07-01T18:00:00Z' AND time < '2010-07-01T18:05:00Z' GROUP BY time(1m) fill(linear)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"resets","columns":["time","non_negative_derivative"],"values":[["2010-07-01T18:01:00Z",20],["2010-07-01T18:04:00Z",2]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
 func TestServer_Query_SelectGroupByTimeDifference(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
@@ -6176,6 +9556,62 @@ func TestServer_Query_Where_With_Tags(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
+// Ensure a regex on a tag prunes series while a range on a field filters the
+// points of the remaining series.
+func TestServer_Query_Where_Regex_And_Field_Range(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=web01 value=50 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:00Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=web01 value=150 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:01Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=web02 value=200 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:02Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=db01 value=500 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:03Z").UnixNano()),
+		fmt.Sprintf(`cpu,host=web02 value=90 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:04Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "regex on tag and range on field",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM cpu WHERE host =~ /^web/ AND value > 100`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:01Z",150],["2009-11-10T23:00:02Z",200]]}]}]}`,
+		},
+		{
+			name:    "range on field and regex on tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM cpu WHERE value > 100 AND host =~ /^web/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:01Z",150],["2009-11-10T23:00:02Z",200]]}]}]}`,
+		},
+		{
+			name:    "negated regex on tag and range on field",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM cpu WHERE host !~ /^web/ AND value > 100`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:03Z",500]]}]}]}`,
+		},
+		{
+			name:    "regex on tag and range on field grouped by tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM cpu WHERE host =~ /^web/ AND value > 100 GROUP BY host`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"web01"},"columns":["time","value"],"values":[["2009-11-10T23:00:01Z",150]]},{"name":"cpu","tags":{"host":"web02"},"columns":["time","value"],"values":[["2009-11-10T23:00:02Z",200]]}]}]}`,
+		},
+		{
+			name:    "aggregate with regex on tag and range on field",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(value) FROM cpu WHERE host =~ /^web/ AND value > 100`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
 func TestServer_Query_With_EmptyTags(t *testing.T) {
 	s := OpenServer(t)
 	defer s.Close()
@@ -6257,6 +9693,63 @@ func TestServer_Query_With_EmptyTags(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
+// Ensure tag presence predicates are applied consistently to every measurement
+// matched by a regex source, including measurements without the tag key.
+func TestServer_Query_Regex_TagPresence(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	writes := []string{
+		fmt.Sprintf(`cpu,host=server01 value=1 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:01Z").UnixNano()),
+		fmt.Sprintf(`cpu value=2 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:02Z").UnixNano()),
+		fmt.Sprintf(`disk,region=uswest value=3 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:03Z").UnixNano()),
+		fmt.Sprintf(`disk,host=server02,region=uswest value=4 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:04Z").UnixNano()),
+		fmt.Sprintf(`mem value=5 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:05Z").UnixNano()),
+		fmt.Sprintf(`net,region=useast value=6 %d`, mustParseTime(time.RFC3339Nano, "2009-11-10T23:00:06Z").UnixNano()),
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "tag present",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM /.*/ WHERE host != ''`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:01Z",1]]},{"name":"disk","columns":["time","value"],"values":[["2009-11-10T23:00:04Z",4]]}]}]}`,
+		},
+		{
+			name:    "tag absent",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM /.*/ WHERE host = ''`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:02Z",2]]},{"name":"disk","columns":["time","value"],"values":[["2009-11-10T23:00:03Z",3]]},{"name":"mem","columns":["time","value"],"values":[["2009-11-10T23:00:05Z",5]]},{"name":"net","columns":["time","value"],"values":[["2009-11-10T23:00:06Z",6]]}]}]}`,
+		},
+		{
+			name:    "tag present with regex",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM /.*/ WHERE host =~ /.+/`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:01Z",1]]},{"name":"disk","columns":["time","value"],"values":[["2009-11-10T23:00:04Z",4]]}]}]}`,
+		},
+		{
+			name:    "tag present combined with another tag",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT value FROM /.*/ WHERE host != '' OR region = 'useast'`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","value"],"values":[["2009-11-10T23:00:01Z",1]]},{"name":"disk","columns":["time","value"],"values":[["2009-11-10T23:00:04Z",4]]},{"name":"net","columns":["time","value"],"values":[["2009-11-10T23:00:06Z",6]]}]}]}`,
+		},
+		{
+			name:    "tag present in an aggregate",
+			params:  url.Values{"db": []string{"db0"}},
+			command: `SELECT count(value) FROM /.*/ WHERE host != ''`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"disk","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
 func TestServer_Query_ImplicitFill(t *testing.T) {
 	s := OpenServer(t, func(o *launcher.InfluxdOpts) {
 		o.CoordinatorConfig.MaxSelectBucketsN = 5
@@ -6278,12 +9771,18 @@ func TestServer_Query_ImplicitFill(t *testing.T) {
 		{
 			name:    "fill with implicit start",
 			command: `select mean(val) from fills where time < '2010-01-01T18:00:00Z' group by time(1h)`,
-			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2010-01-01T16:00:00Z",5],["2010-01-01T17:00:00Z",null]]}]}]}`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2010-01-01T16:00:00Z",5],["2010-01-01T17:00:00Z",null]]}],"messages":[{"level":"warning","text":"no lower time bound was specified, results are limited to the last 5 intervals"}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		{
 			name:    "fill with implicit start - max select buckets",
 			command: `select mean(val) from fills where time < '2010-01-01T17:00:00Z' group by time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2010-01-01T12:00:00Z",3],["2010-01-01T13:00:00Z",null],["2010-01-01T14:00:00Z",null],["2010-01-01T15:00:00Z",null],["2010-01-01T16:00:00Z",5]]}],"messages":[{"level":"warning","text":"no lower time bound was specified, results are limited to the last 5 intervals"}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "no warning with explicit start",
+			command: `select mean(val) from fills where time >= '2010-01-01T12:00:00Z' and time < '2010-01-01T17:00:00Z' group by time(1h)`,
 			exp:     `{"results":[{"statement_id":0,"series":[{"name":"fills","columns":["time","mean"],"values":[["2010-01-01T12:00:00Z",3],["2010-01-01T13:00:00Z",null],["2010-01-01T14:00:00Z",null],["2010-01-01T15:00:00Z",null],["2010-01-01T16:00:00Z",5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
@@ -6293,6 +9792,121 @@ func TestServer_Query_ImplicitFill(t *testing.T) {
 	test.Run(ctx, t, s)
 }
 
+// Ensure sub-millisecond GROUP BY intervals bucket microsecond data and are
+// still bounded by the maximum number of buckets.
+func TestServer_Query_GroupByTime_Microseconds(t *testing.T) {
+	s := OpenServer(t, func(o *launcher.InfluxdOpts) {
+		o.CoordinatorConfig.MaxSelectBucketsN = 10
+	})
+	defer s.Close()
+
+	start := mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z")
+	writes := make([]string, 0, 7)
+	for _, us := range []int{0, 20, 50, 90, 100, 150, 250} {
+		writes = append(writes, fmt.Sprintf(`cpu value=%d %d`, us, start.Add(time.Duration(us)*time.Microsecond).UnixNano()))
+	}
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "count per 100u bucket",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:00.0003Z' GROUP BY time(100u)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",4],["2000-01-01T00:00:00.0001Z",2],["2000-01-01T00:00:00.0002Z",1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "count per 100µ bucket with an offset",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:00.0003Z' GROUP BY time(100µ, 50u)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["1999-12-31T23:59:59.99995Z",2],["2000-01-01T00:00:00.00005Z",3],["2000-01-01T00:00:00.00015Z",1],["2000-01-01T00:00:00.00025Z",1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "max select buckets",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:00:00.0015Z' GROUP BY time(100u)`,
+			exp:     `{"results":[{"statement_id":0,"error":"max-select-buckets limit exceeded: (15/10)"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
+// Ensure the buckets of a query grouped by time can be filtered by their
+// window time.
+func TestServer_Query_GroupByTime_WindowCondition(t *testing.T) {
+	s := OpenServer(t)
+	defer s.Close()
+
+	start := mustParseTime(time.RFC3339Nano, "2000-01-01T00:00:00Z")
+	writes := make([]string, 0, 14)
+	// Write every 30 minutes for 6 hours.
+	for i := 0; i < 12; i++ {
+		writes = append(writes, fmt.Sprintf(`cpu,host=server01 value=%d %d`, i, start.Add(time.Duration(i)*30*time.Minute).UnixNano()))
+	}
+	writes = append(writes,
+		fmt.Sprintf(`cpu,host=server02 value=20 %d`, start.Add(75*time.Minute).UnixNano()),
+		fmt.Sprintf(`cpu,host=server02 value=30 %d`, start.Add(195*time.Minute).UnixNano()),
+	)
+
+	test := NewTest("db0", "rp0")
+	test.writes = Writes{
+		&Write{data: strings.Join(writes, "\n")},
+	}
+
+	test.addQueries([]*Query{
+		{
+			name:    "odd hours",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T06:00:00Z' AND host = 'server01' AND time % 2h = 1h GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-01-01T01:00:00Z",2],["2000-01-01T03:00:00Z",2],["2000-01-01T05:00:00Z",2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "odd hours with fill",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T08:00:00Z' AND (time % 2h = 1h) AND host = 'server01' GROUP BY time(1h) fill(0)`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-01-01T01:00:00Z",2],["2000-01-01T03:00:00Z",2],["2000-01-01T05:00:00Z",2],["2000-01-01T07:00:00Z",0]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "odd hours descending",
+			command: `SELECT sum(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T06:00:00Z' AND host = 'server01' AND time % 2h = 1h GROUP BY time(1h) ORDER BY time DESC`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","sum"],"values":[["2000-01-01T05:00:00Z",21],["2000-01-01T03:00:00Z",13],["2000-01-01T01:00:00Z",5]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "limit and offset count matching buckets",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T06:00:00Z' AND time % 2h = 1h GROUP BY time(1h), host LIMIT 1 OFFSET 1`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","tags":{"host":"server01"},"columns":["time","count"],"values":[["2000-01-01T03:00:00Z",2]]},{"name":"cpu","tags":{"host":"server02"},"columns":["time","count"],"values":[["2000-01-01T03:00:00Z",1]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "range of the day in a time zone",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T06:00:00Z' AND host = 'server01' AND time % 1d >= 2h AND time % 1d < 4h GROUP BY time(1h) TZ('+01:00')`,
+			exp:     `{"results":[{"statement_id":0,"series":[{"name":"cpu","columns":["time","count"],"values":[["2000-01-01T02:00:00+01:00",2],["2000-01-01T03:00:00+01:00",2]]}]}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "not grouped by time",
+			command: `SELECT count(value) FROM cpu WHERE time % 2h = 1h`,
+			exp:     `{"results":[{"statement_id":0,"error":"window time conditions require GROUP BY time()"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+		{
+			name:    "reference to a field",
+			command: `SELECT count(value) FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T06:00:00Z' AND time % 2h = value GROUP BY time(1h)`,
+			exp:     `{"results":[{"statement_id":0,"error":"window time conditions may only reference time"}]}`,
+			params:  url.Values{"db": []string{"db0"}},
+		},
+	}...)
+
+	ctx := context.Background()
+	test.Run(ctx, t, s)
+}
+
 func TestServer_Query_MaxRowLimit(t *testing.T) {
 	t.Skip(NotSupported)
 	// config := NewConfig()
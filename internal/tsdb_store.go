@@ -34,7 +34,7 @@ type TSDBStoreMock struct {
 	OpenFn                    func() error
 	PathFn                    func() string
 	RestoreShardFn            func(id uint64, r io.Reader) error
-	SeriesCardinalityFn       func(database string) (int64, error)
+	SeriesCardinalityFn       func(ctx context.Context, database string) (int64, error)
 	SetShardEnabledFn         func(shardID uint64, enabled bool) error
 	ShardFn                   func(id uint64) *tsdb.Shard
 	ShardGroupFn              func(ids []uint64) tsdb.ShardGroup
@@ -106,8 +106,8 @@ func (s *TSDBStoreMock) Path() string {
 func (s *TSDBStoreMock) RestoreShard(id uint64, r io.Reader) error {
 	return s.RestoreShardFn(id, r)
 }
-func (s *TSDBStoreMock) SeriesCardinality(database string) (int64, error) {
-	return s.SeriesCardinalityFn(database)
+func (s *TSDBStoreMock) SeriesCardinality(ctx context.Context, database string) (int64, error) {
+	return s.SeriesCardinalityFn(ctx, database)
 }
 func (s *TSDBStoreMock) SetShardEnabled(shardID uint64, enabled bool) error {
 	return s.SetShardEnabledFn(shardID, enabled)
@@ -2394,7 +2394,7 @@ func (e *Engine) createSeriesIterator(measurement string, ref *influxql.VarRef,
 	if len(opt.Dimensions) > 0 {
 		return nil, nil
 	}
-	if opt.SLimit != 0 || opt.SOffset != 0 {
+	if opt.SLimit != 0 || opt.SOffset != 0 || opt.TagSetKeys != nil {
 		return nil, nil
 	}
 	if opt.StripName {
@@ -2469,6 +2469,7 @@ func (e *Engine) createCallIterator(ctx context.Context, measurement string, cal
 
 	// Calculate tag sets and apply SLIMIT/SOFFSET.
 	tagSets = query.LimitTagSets(tagSets, opt.SLimit, opt.SOffset)
+	tagSets = query.FilterTagSets(tagSets, opt.TagSetKeys)
 
 	itrs := make([]query.Iterator, 0, len(tagSets))
 	if err := func() error {
@@ -2544,6 +2545,7 @@ func (e *Engine) createVarRefIterator(ctx context.Context, measurement string, o
 
 	// Calculate tag sets and apply SLIMIT/SOFFSET.
 	tagSets = query.LimitTagSets(tagSets, opt.SLimit, opt.SOffset)
+	tagSets = query.FilterTagSets(tagSets, opt.TagSetKeys)
 	itrs := make([]query.Iterator, 0, len(tagSets))
 	if err := func() error {
 		for _, t := range tagSets {
@@ -2698,6 +2700,38 @@ func (e *Engine) createTagSetGroupIterators(ctx context.Context, ref *influxql.V
 	return itrs, nil
 }
 
+// createEmptySeriesIterator returns an iterator with a single nil point for a
+// series that does not have the field so it is still reported by grouped
+// aggregates. Returns nil if the options do not request empty series.
+func (e *Engine) createEmptySeriesIterator(ref *influxql.VarRef, name string, tags query.Tags, conditionFields []influxql.VarRef, opt query.IteratorOptions) query.Iterator {
+	if !opt.KeepEmptySeries || len(conditionFields) > 0 || len(opt.Aux) > 0 {
+		return nil
+	}
+
+	t := opt.StartTime
+	if !opt.Ascending {
+		t = opt.EndTime
+	}
+	if t == influxql.MinTime || t == influxql.MaxTime {
+		return nil
+	}
+
+	if opt.StripName {
+		name = ""
+	}
+	return query.NewNilPointIterator(name, tags.Subset(opt.GetDimensions()), t, ref.Type)
+}
+
+// seriesFieldExists returns true if values were written to the field of the series.
+func (e *Engine) seriesFieldExists(seriesKey, field string) bool {
+	switch field {
+	case "_name", "_tagKey", "_tagValue", "_seriesKey", "_fieldKey":
+		return true
+	}
+	_, err := e.Type(SeriesFieldKeyBytes(seriesKey, field))
+	return err == nil
+}
+
 // createVarRefSeriesIterator creates an iterator for a variable reference for a series.
 func (e *Engine) createVarRefSeriesIterator(ctx context.Context, ref *influxql.VarRef, name string, seriesKey string, t *query.TagSet, filter influxql.Expr, conditionFields []influxql.VarRef, opt query.IteratorOptions) (query.Iterator, error) {
 	_, tfs := models.ParseKey([]byte(seriesKey))
@@ -2715,12 +2749,28 @@ func (e *Engine) createVarRefSeriesIterator(ctx context.Context, ref *influxql.V
 	}
 
 	// Build main cursor.
+	// Tags are streamed as values at the times the series has points.
 	var cur cursor
-	if ref != nil {
+	if ref != nil && ref.Type == influxql.Tag {
+		v := tags.Value(ref.Val)
+		if v == "" {
+			return nil, nil
+		}
+		cur = e.buildTagValueCursor(ctx, name, seriesKey, tfs, v, opt)
+		if cur == nil {
+			return nil, nil
+		}
+		if curCounter != nil {
+			curCounter.Add(1)
+		}
+	} else if ref != nil {
 		cur = e.buildCursor(ctx, name, seriesKey, tfs, ref, opt)
 		// If the field doesn't exist then don't build an iterator.
 		if cur == nil {
-			return nil, nil
+			return e.createEmptySeriesIterator(ref, name, tags, conditionFields, opt), nil
+		} else if opt.KeepEmptySeries && !e.seriesFieldExists(seriesKey, ref.Val) {
+			cur.close()
+			return e.createEmptySeriesIterator(ref, name, tags, conditionFields, opt), nil
 		}
 		if curCounter != nil {
 			curCounter.Add(1)
@@ -2842,15 +2892,28 @@ func (e *Engine) createVarRefSeriesIterator(ctx context.Context, ref *influxql.V
 	}
 	condNames := influxql.VarRefs(conditionFields).Strings()
 
-	// Limit tags to only the dimensions selected.
+	// Limit tags to only the dimensions selected unless the series must
+	// be told apart when merging shards.
 	dimensions := opt.GetDimensions()
-	tags = tags.Subset(dimensions)
+	if !opt.SeriesTags {
+		tags = tags.Subset(dimensions)
+	}
 
 	// If it's only auxiliary fields then it doesn't matter what type of iterator we use.
+	// Auxiliary tags alone have no timestamps so they are driven by the points of the series.
 	if ref == nil {
 		if opt.StripName {
 			name = ""
 		}
+		if len(opt.Aux) > 0 && auxTagsOnly(opt.Aux) {
+			cur := e.buildTagValueCursor(ctx, name, seriesKey, tfs, "", opt)
+			if cur == nil {
+				cursorsAt(aux).close()
+				cursorsAt(conds).close()
+				return nil, nil
+			}
+			return newStringIterator(name, tags, itrOpt, cur, aux, conds, condNames), nil
+		}
 		return newFloatIterator(name, tags, itrOpt, nil, aux, conds, condNames), nil
 	}
 
@@ -2877,6 +2940,47 @@ func (e *Engine) createVarRefSeriesIterator(ctx context.Context, ref *influxql.V
 
 // buildCursor creates an untyped cursor for a field.
 func (e *Engine) buildCursor(ctx context.Context, measurement, seriesKey string, tags models.Tags, ref *influxql.VarRef, opt query.IteratorOptions) cursor {
+	cur := e.buildFieldCursor(ctx, measurement, seriesKey, tags, ref, opt)
+	if cur != nil && opt.TreatAsNull != nil {
+		cur = newNullValueCursor(cur, *opt.TreatAsNull)
+	}
+	return cur
+}
+
+// buildTagValueCursor creates a cursor that returns value at the time of
+// every point in the series. Returns nil if the measurement has no fields.
+func (e *Engine) buildTagValueCursor(ctx context.Context, measurement, seriesKey string, tags models.Tags, value string, opt query.IteratorOptions) stringCursor {
+	mf := e.fieldset.FieldsByString(measurement)
+	if mf == nil {
+		return nil
+	}
+
+	var curs []cursorAt
+	mf.ForEachField(func(name string, typ influxql.DataType) bool {
+		ref := influxql.VarRef{Val: name, Type: typ}
+		if cur := e.buildCursor(ctx, measurement, seriesKey, tags, &ref, opt); cur != nil {
+			curs = append(curs, newBufCursor(cur, opt.Ascending))
+		}
+		return true
+	})
+	if len(curs) == 0 {
+		return nil
+	}
+	return &tagValueCursor{value: value, curs: curs, ascending: opt.Ascending}
+}
+
+// auxTagsOnly returns true if every auxiliary reference is a tag.
+func auxTagsOnly(refs []influxql.VarRef) bool {
+	for _, ref := range refs {
+		if ref.Type != influxql.Tag {
+			return false
+		}
+	}
+	return true
+}
+
+// buildFieldCursor creates an untyped cursor for a field or system field.
+func (e *Engine) buildFieldCursor(ctx context.Context, measurement, seriesKey string, tags models.Tags, ref *influxql.VarRef, opt query.IteratorOptions) cursor {
 	// Check if this is a system field cursor.
 	switch ref.Val {
 	case "_name":
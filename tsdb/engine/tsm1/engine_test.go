@@ -1048,6 +1048,56 @@ func TestEngine_CreateIterator_Aux(t *testing.T) {
 	}
 }
 
+// Ensure engine can create an iterator that streams tag values.
+func TestEngine_CreateIterator_Tag(t *testing.T) {
+	t.Parallel()
+
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			e := MustOpenEngine(t, index)
+			defer e.Close()
+
+			e.MeasurementFields([]byte("cpu")).CreateFieldIfNotExists([]byte("value"), influxql.Float)
+			e.MeasurementFields([]byte("cpu")).CreateFieldIfNotExists([]byte("F"), influxql.Float)
+			e.CreateSeriesIfNotExists([]byte("cpu,host=A"), []byte("cpu"), models.NewTags(map[string]string{"host": "A"}))
+
+			if err := e.WritePointsString(
+				`cpu,host=A value=1.1 1000000000`,
+				`cpu,host=A F=100 1000000000`,
+				`cpu,host=A value=1.2 2000000000`,
+				`cpu,host=A F=200 3000000000`,
+			); err != nil {
+				t.Fatalf("failed to write points: %s", err.Error())
+			}
+
+			itr, err := e.CreateIterator(context.Background(), "cpu", query.IteratorOptions{
+				Expr:       &influxql.VarRef{Val: "host", Type: influxql.Tag},
+				Dimensions: []string{"host"},
+				StartTime:  influxql.MinTime,
+				EndTime:    influxql.MaxTime,
+				Ascending:  true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sitr := itr.(query.StringIterator)
+
+			for i, tm := range []int64{1000000000, 2000000000, 3000000000} {
+				if p, err := sitr.Next(); err != nil {
+					t.Fatalf("unexpected error(%d): %v", i, err)
+				} else if !deep.Equal(p, &query.StringPoint{Name: "cpu", Tags: ParseTags("host=A"), Time: tm, Value: "A"}) {
+					t.Fatalf("unexpected point(%d): %v", i, p)
+				}
+			}
+			if p, err := sitr.Next(); err != nil {
+				t.Fatalf("expected eof, got error: %v", err)
+			} else if p != nil {
+				t.Fatalf("expected eof: %v", p)
+			}
+		})
+	}
+}
+
 // Ensure engine can create an iterator with a condition.
 func TestEngine_CreateIterator_Condition(t *testing.T) {
 	t.Parallel()
@@ -107,6 +107,75 @@ func (c *unsignedCastIntegerCursor) nextUnsigned() (int64, uint64) {
 	return t, uint64(v)
 }
 
+// newNullValueCursor returns a cursor that skips numeric values equal to v
+// so they are read as null. Other cursors are returned unchanged.
+func newNullValueCursor(cur cursor, v float64) cursor {
+	switch cur := cur.(type) {
+	case floatCursor:
+		return &floatNullValueCursor{cursor: cur, value: v}
+	case integerCursor:
+		return &integerNullValueCursor{cursor: cur, value: v}
+	case unsignedCursor:
+		return &unsignedNullValueCursor{cursor: cur, value: v}
+	default:
+		return cur
+	}
+}
+
+type floatNullValueCursor struct {
+	cursor floatCursor
+	value  float64
+}
+
+func (c *floatNullValueCursor) close() error { return c.cursor.close() }
+
+func (c *floatNullValueCursor) next() (t int64, v interface{}) { return c.nextFloat() }
+
+func (c *floatNullValueCursor) nextFloat() (int64, float64) {
+	for {
+		t, v := c.cursor.nextFloat()
+		if t == tsdb.EOF || v != c.value {
+			return t, v
+		}
+	}
+}
+
+type integerNullValueCursor struct {
+	cursor integerCursor
+	value  float64
+}
+
+func (c *integerNullValueCursor) close() error { return c.cursor.close() }
+
+func (c *integerNullValueCursor) next() (t int64, v interface{}) { return c.nextInteger() }
+
+func (c *integerNullValueCursor) nextInteger() (int64, int64) {
+	for {
+		t, v := c.cursor.nextInteger()
+		if t == tsdb.EOF || float64(v) != c.value {
+			return t, v
+		}
+	}
+}
+
+type unsignedNullValueCursor struct {
+	cursor unsignedCursor
+	value  float64
+}
+
+func (c *unsignedNullValueCursor) close() error { return c.cursor.close() }
+
+func (c *unsignedNullValueCursor) next() (t int64, v interface{}) { return c.nextUnsigned() }
+
+func (c *unsignedNullValueCursor) nextUnsigned() (int64, uint64) {
+	for {
+		t, v := c.cursor.nextUnsigned()
+		if t == tsdb.EOF || float64(v) != c.value {
+			return t, v
+		}
+	}
+}
+
 // literalValueCursor represents a cursor that always returns a single value.
 // It doesn't not have a time value so it can only be used with nextAt().
 type literalValueCursor struct {
@@ -146,6 +215,43 @@ func (c *stringSliceCursor) nextString() (int64, string) {
 	return 0, value
 }
 
+// tagValueCursor is a cursor that outputs a tag value at every time
+// a point was written to any field of its series.
+type tagValueCursor struct {
+	value     string
+	curs      []cursorAt
+	ascending bool
+}
+
+func (c *tagValueCursor) close() error {
+	cursorsAt(c.curs).close()
+	c.curs = nil
+	return nil
+}
+
+func (c *tagValueCursor) next() (int64, interface{}) { return c.nextString() }
+
+func (c *tagValueCursor) nextString() (int64, string) {
+	// Find the next timestamp across all field cursors.
+	seek := tsdb.EOF
+	for _, cur := range c.curs {
+		if k, _ := cur.peek(); k != tsdb.EOF {
+			if seek == tsdb.EOF || (c.ascending && k < seek) || (!c.ascending && k > seek) {
+				seek = k
+			}
+		}
+	}
+	if seek == tsdb.EOF {
+		return tsdb.EOF, ""
+	}
+
+	// Move every field cursor past the timestamp.
+	for _, cur := range c.curs {
+		cur.nextAt(seek)
+	}
+	return seek, c.value
+}
+
 type cursorsAt []cursorAt
 
 func (c cursorsAt) close() {
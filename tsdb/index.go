@@ -3075,6 +3075,16 @@ func (is IndexSet) TagSets(sfile *SeriesFile, name []byte, opt query.IteratorOpt
 		maxSeriesN = int(^uint(0) >> 1)
 	}
 
+	// When the series limit is applied first, only the first SOFFSET+SLIMIT
+	// tag sets seen are kept and the series of any other tag set are
+	// skipped without being collected. The order the series are read in
+	// depends on the indexes, so a query over several shards chooses its
+	// tag sets once from all of them and passes them in TagSetKeys.
+	var maxTagSetsN int
+	if opt.LimitSeriesFirst && opt.SLimit > 0 {
+		maxTagSetsN = opt.SLimit + opt.SOffset
+	}
+
 	// The tag sets require a string for each series key in the set, The series
 	// file formatted keys need to be parsed into models format. Since they will
 	// end up as strings we can re-use an intermediate buffer for this process.
@@ -3118,8 +3128,20 @@ func (is IndexSet) TagSets(sfile *SeriesFile, name []byte, opt query.IteratorOpt
 			tagsAsKey = MakeTagsKey(dims, tagsBuf)
 		}
 
+		// Skip the series of tag sets that were not chosen by SLIMIT and
+		// SOFFSET across shards.
+		if opt.TagSetKeys != nil {
+			if _, ok := opt.TagSetKeys[string(tagsAsKey)]; !ok {
+				continue
+			}
+		}
+
 		tagSet, ok := tagSets[string(tagsAsKey)]
 		if !ok {
+			if maxTagSetsN > 0 && len(tagSets) >= maxTagSetsN {
+				continue
+			}
+
 			// This TagSet is new, create a new entry for it.
 			tagSet = &query.TagSet{
 				Key: tagsAsKey,
@@ -226,6 +226,115 @@ func TestIndexSet_MeasurementNamesByPredicate(t *testing.T) {
 	}
 }
 
+func TestIndexSet_TagSets_LimitSeriesFirst(t *testing.T) {
+	for _, indexType := range tsdb.RegisteredIndexes() {
+		t.Run(indexType, func(t *testing.T) {
+			idx := MustOpenNewIndex(t, indexType)
+			defer idx.Close()
+
+			for i := 0; i < 10; i++ {
+				for _, region := range []string{"east", "west"} {
+					if err := idx.AddSeries("cpu", map[string]string{
+						"host":   fmt.Sprintf("server%02d", i),
+						"region": region,
+					}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			tagSets := func(opt query.IteratorOptions) []*query.TagSet {
+				ts, err := idx.IndexSet().TagSets(idx.sfile, []byte("cpu"), opt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return ts
+			}
+
+			opt := query.IteratorOptions{Dimensions: []string{"host"}, SLimit: 2, SOffset: 1}
+			if got, exp := len(tagSets(opt)), 10; got != exp {
+				t.Fatalf("got %d tag sets, expected %d", got, exp)
+			}
+
+			// Only the tag sets covered by SLIMIT and SOFFSET are collected,
+			// each with every one of its series.
+			opt.LimitSeriesFirst = true
+			ts := tagSets(opt)
+			if got, exp := len(ts), 3; got != exp {
+				t.Fatalf("got %d tag sets, expected %d", got, exp)
+			}
+			for _, tagSet := range ts {
+				if got, exp := len(tagSet.SeriesKeys), 2; got != exp {
+					t.Fatalf("got %d series for tag set %s, expected %d", got, tagSet.Key, exp)
+				}
+			}
+
+			// The tag sets chosen across shards are the only ones collected.
+			key := string(ts[0].Key)
+			ts = tagSets(query.IteratorOptions{
+				Dimensions: []string{"host"},
+				TagSetKeys: map[string]struct{}{key: {}},
+			})
+			if got, exp := len(ts), 1; got != exp {
+				t.Fatalf("got %d tag sets, expected %d", got, exp)
+			} else if got := string(ts[0].Key); got != key {
+				t.Fatalf("got tag set %s, expected %s", got, key)
+			} else if got, exp := len(ts[0].SeriesKeys), 2; got != exp {
+				t.Fatalf("got %d series for tag set %s, expected %d", got, key, exp)
+			}
+		})
+	}
+}
+
+func TestSeriesPointIterator_ManySeries(t *testing.T) {
+	for _, indexType := range tsdb.RegisteredIndexes() {
+		t.Run(indexType, func(t *testing.T) {
+			idx := MustOpenNewIndex(t, indexType)
+			defer idx.Close()
+
+			const hostN = 1000
+			for _, name := range []string{"mem", "cpu"} {
+				for i := 0; i < hostN; i++ {
+					if err := idx.AddSeries(name, map[string]string{"host": fmt.Sprintf("server%04d", i)}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			itr, err := tsdb.NewSeriesPointIterator(*idx.IndexSet(), query.IteratorOptions{
+				Aux: []influxql.VarRef{{Val: "key", Type: influxql.String}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer itr.Close()
+
+			// Every key is returned once in ascending order so the keys can be
+			// streamed without deduplicating them.
+			var n int
+			var prev string
+			for {
+				p, err := itr.(query.FloatIterator).Next()
+				if err != nil {
+					t.Fatal(err)
+				} else if p == nil {
+					break
+				}
+
+				key := p.Aux[0].(string)
+				if key <= prev {
+					t.Fatalf("key %q returned after %q", key, prev)
+				}
+				prev = key
+				n++
+			}
+			if got, exp := n, 2*hostN; got != exp {
+				t.Fatalf("got %d keys, expected %d", got, exp)
+			}
+		})
+	}
+}
+
 func TestIndex_Sketches(t *testing.T) {
 	checkCardinalities := func(t *testing.T, index *Index, state string, series, tseries, measurements, tmeasurements int) {
 		t.Helper()
@@ -527,8 +636,6 @@ func BenchmarkIndexSet_TagSets(b *testing.B) {
 		}
 	}
 
-	var errResult error
-
 	// This benchmark will merge eight bitsets each containing ~10,000 series IDs.
 	b.Run("1M series", func(b *testing.B) {
 		b.ReportAllocs()
@@ -538,30 +645,48 @@ func BenchmarkIndexSet_TagSets(b *testing.B) {
 
 			name := []byte("m4")
 			opt := query.IteratorOptions{Condition: influxql.MustParseExpr(`"tag5"::tag = 'value0'`)}
-			indexSet := tsdb.IndexSet{
-				SeriesFile: idx.sfile,
-				Indexes:    []tsdb.Index{idx.Index},
-			} // For TSI implementation
+			benchmarkTagSets(b, idx, indexType, name, opt)
+		}
+	})
+
+	// The same tag sets grouped by every tag with SLIMIT applied while the
+	// series are read from the index.
+	b.Run("1M series SLIMIT first", func(b *testing.B) {
+		b.ReportAllocs()
+		for _, indexType := range tsdb.RegisteredIndexes() {
+			idx := MustOpenNewIndex(b, indexType)
+			setup(idx)
 
-			ts := func() ([]*query.TagSet, error) {
-				return indexSet.TagSets(idx.sfile, name, opt)
+			name := []byte("m4")
+			opt := query.IteratorOptions{
+				Condition:        influxql.MustParseExpr(`"tag5"::tag = 'value0'`),
+				Dimensions:       []string{"tag0", "tag1", "tag2", "tag3", "tag4", "tag5"},
+				SLimit:           10,
+				LimitSeriesFirst: true,
 			}
+			benchmarkTagSets(b, idx, indexType, name, opt)
+		}
+	})
+}
 
-			b.Run(indexType, func(b *testing.B) {
-				for i := 0; i < b.N; i++ {
-					// Will call TagSets on the appropriate implementation.
-					_, errResult = ts()
-					if errResult != nil {
-						b.Fatal(err)
-					}
-				}
-			})
+func benchmarkTagSets(b *testing.B, idx *Index, indexType string, name []byte, opt query.IteratorOptions) {
+	indexSet := tsdb.IndexSet{
+		SeriesFile: idx.sfile,
+		Indexes:    []tsdb.Index{idx.Index},
+	} // For TSI implementation
 
-			if err := idx.Close(); err != nil {
+	b.Run(indexType, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			// Will call TagSets on the appropriate implementation.
+			if _, err := indexSet.TagSets(idx.sfile, name, opt); err != nil {
 				b.Fatal(err)
 			}
 		}
 	})
+
+	if err := idx.Close(); err != nil {
+		b.Fatal(err)
+	}
 }
 
 // This benchmark concurrently writes series to the index and fetches cached bitsets.
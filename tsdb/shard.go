@@ -1329,6 +1329,7 @@ type ShardGroup interface {
 	MapType(measurement, field string) influxql.DataType
 	CreateIterator(ctx context.Context, measurement *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error)
 	IteratorCost(ctx context.Context, measurement string, opt query.IteratorOptions) (query.IteratorCost, error)
+	ShardIDs(measurement string) ([]uint64, error)
 	ExpandSources(sources influxql.Sources) (influxql.Sources, error)
 }
 
@@ -1604,8 +1605,48 @@ func (a Shards) CreateIterator(ctx context.Context, measurement *influxql.Measur
 		return a.createSeriesIterator(ctx, opt)
 	}
 
-	itrs := make([]query.Iterator, 0, len(a))
-	for _, sh := range a {
+	// Order the shards by id so the iterator of the newest shard is merged
+	// last. If the same series and timestamp were written into overlapping
+	// shards, only the point from the shard with the highest id is kept.
+	shards := make(Shards, len(a))
+	copy(shards, a)
+	sort.Slice(shards, func(i, j int) bool { return shards[i].ID() < shards[j].ID() })
+
+	// Apply SLIMIT and SOFFSET to the series of every shard at once so the
+	// same series are returned however they are spread over the shards.
+	// Each shard then only reads the series of the chosen tag sets.
+	if len(shards) > 1 && (opt.SLimit != 0 || opt.SOffset != 0) {
+		var err error
+		if opt, err = shards.limitTagSets(measurement.Name, opt); err != nil {
+			return nil, err
+		}
+	}
+
+	// The points of an aggregate cannot be deduplicated once each shard
+	// reduced them, so reduce the deduplicated points of every shard once.
+	if len(shards) > 1 && opt.Overlapping {
+		if call, ok := opt.Expr.(*influxql.Call); ok && call.Name != "merge_hll" {
+			if ref, ok := call.Args[0].(*influxql.VarRef); ok {
+				rawOpt := opt
+				rawOpt.Expr = ref
+				rawOpt.Ordered = true
+				itr, err := shards.CreateIterator(ctx, measurement, rawOpt)
+				if err != nil || itr == nil {
+					return nil, err
+				}
+				return query.NewCallIterator(itr, opt)
+			}
+		}
+	}
+
+	// Keep the tags of every series on the points so the points of
+	// different series at the same time are not taken as duplicates.
+	if len(shards) > 1 && opt.Dedupes() {
+		opt.SeriesTags = true
+	}
+
+	itrs := make([]query.Iterator, 0, len(shards))
+	for _, sh := range shards {
 		itr, err := sh.CreateIterator(ctx, measurement, opt)
 		if err != nil {
 			query.Iterators(itrs).Close()
@@ -1631,7 +1672,40 @@ func (a Shards) CreateIterator(ctx context.Context, measurement *influxql.Measur
 			}
 		}
 	}
-	return query.Iterators(itrs).Merge(opt)
+	return query.Iterators(itrs).MergeDedupe(opt)
+}
+
+// limitTagSets returns opt with SLIMIT and SOFFSET replaced by the keys of the
+// tag sets they select from the series of all shards.
+func (a Shards) limitTagSets(name string, opt query.IteratorOptions) (query.IteratorOptions, error) {
+	var sfile *SeriesFile
+	idxs := make([]Index, 0, len(a))
+	for _, sh := range a {
+		idx, err := sh.Index()
+		if err != nil {
+			return opt, err
+		}
+		idxs = append(idxs, idx)
+		if sfile == nil {
+			sfile, _ = sh.SeriesFile()
+		}
+	}
+	if sfile == nil {
+		return opt, nil
+	}
+
+	tagSets, err := IndexSet{Indexes: idxs, SeriesFile: sfile}.TagSets(sfile, []byte(name), opt)
+	if err != nil {
+		return opt, err
+	}
+	tagSets = query.LimitTagSets(tagSets, opt.SLimit, opt.SOffset)
+
+	opt.TagSetKeys = make(map[string]struct{}, len(tagSets))
+	for _, t := range tagSets {
+		opt.TagSetKeys[string(t.Key)] = struct{}{}
+	}
+	opt.SLimit, opt.SOffset = 0, 0
+	return opt, nil
 }
 
 func (a Shards) createSeriesIterator(ctx context.Context, opt query.IteratorOptions) (_ query.Iterator, err error) {
@@ -1708,6 +1782,19 @@ func (a Shards) IteratorCost(ctx context.Context, measurement string, opt query.
 	return costs, costerr
 }
 
+// ShardIDs returns the IDs of the shards that contain the measurement.
+func (a Shards) ShardIDs(measurement string) ([]uint64, error) {
+	var ids []uint64
+	for _, sh := range a {
+		if exists, err := sh.MeasurementExists([]byte(measurement)); err != nil {
+			return nil, err
+		} else if exists {
+			ids = append(ids, sh.ID())
+		}
+	}
+	return ids, nil
+}
+
 func (a Shards) CreateSeriesCursor(ctx context.Context, req SeriesCursorRequest, cond influxql.Expr) (_ SeriesCursor, err error) {
 	var (
 		idxs  []Index
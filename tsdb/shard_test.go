@@ -1199,6 +1199,266 @@ func TestShards_FieldKeysByMeasurement(t *testing.T) {
 	}
 }
 
+// Ensure duplicate points written into overlapping shards are merged so the
+// point from the shard with the highest id is returned.
+func TestShards_CreateIterator_Dedupe(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			shards := NewShards(t, index, 2)
+			shards.MustOpen()
+			defer shards.Close()
+
+			shards[0].MustWritePointsString(`
+cpu,host=serverA value=1 0
+cpu,host=serverA value=2 10
+cpu,host=serverB value=3 0
+`)
+			shards[1].MustWritePointsString(`
+cpu,host=serverA value=4 10
+cpu,host=serverA value=5 20
+`)
+
+			// Pass the shards out of order to ensure the shard id decides
+			// which point is kept.
+			sg := tsdb.Shards{shards[1].Shard, shards[0].Shard}
+			itr, err := sg.CreateIterator(context.Background(), &influxql.Measurement{Name: "cpu"}, query.IteratorOptions{
+				Expr:       influxql.MustParseExpr(`value`),
+				Dimensions: []string{"host"},
+				Ascending:  true,
+				Ordered:    true,
+				StartTime:  influxql.MinTime,
+				EndTime:    influxql.MaxTime,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer itr.Close()
+			fitr := itr.(query.FloatIterator)
+
+			for i, exp := range []*query.FloatPoint{
+				{Name: "cpu", Tags: query.NewTags(map[string]string{"host": "serverA"}), Time: time.Unix(0, 0).UnixNano(), Value: 1},
+				{Name: "cpu", Tags: query.NewTags(map[string]string{"host": "serverA"}), Time: time.Unix(10, 0).UnixNano(), Value: 4},
+				{Name: "cpu", Tags: query.NewTags(map[string]string{"host": "serverA"}), Time: time.Unix(20, 0).UnixNano(), Value: 5},
+				{Name: "cpu", Tags: query.NewTags(map[string]string{"host": "serverB"}), Time: time.Unix(0, 0).UnixNano(), Value: 3},
+			} {
+				if p, err := fitr.Next(); err != nil {
+					t.Fatalf("unexpected error(%d): %s", i, err)
+				} else if !deep.Equal(p, exp) {
+					t.Fatalf("unexpected point(%d): %s", i, spew.Sdump(p))
+				}
+			}
+
+			if p, err := fitr.Next(); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if p != nil {
+				t.Fatalf("unexpected point: %s", spew.Sdump(p))
+			}
+		})
+	}
+}
+
+// Ensure an aggregate over overlapping shards counts a point written into
+// both shards once.
+func TestShards_CreateIterator_DedupeAggregate(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			shards := NewShards(t, index, 2)
+			shards.MustOpen()
+			defer shards.Close()
+
+			shards[0].MustWritePointsString(`
+cpu,host=serverA value=1 0
+cpu,host=serverA value=2 10
+cpu,host=serverB value=3 0
+`)
+			shards[1].MustWritePointsString(`
+cpu,host=serverA value=4 10
+cpu,host=serverA value=5 20
+`)
+
+			sg := tsdb.Shards{shards[0].Shard, shards[1].Shard}
+			for _, tt := range []struct {
+				expr string
+				exp  []*query.FloatPoint
+			}{
+				{
+					expr: `sum(value)`,
+					exp: []*query.FloatPoint{
+						{Name: "cpu", Time: influxql.MinTime, Value: 13, Aggregated: 4},
+					},
+				},
+				{
+					expr: `mean(value)`,
+					exp: []*query.FloatPoint{
+						{Name: "cpu", Time: influxql.MinTime, Value: 3.25, Aggregated: 4},
+					},
+				},
+			} {
+				itr, err := sg.CreateIterator(context.Background(), &influxql.Measurement{Name: "cpu"}, query.IteratorOptions{
+					Expr:        influxql.MustParseExpr(tt.expr),
+					Ascending:   true,
+					StartTime:   influxql.MinTime,
+					EndTime:     influxql.MaxTime,
+					Overlapping: true,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				fitr := itr.(query.FloatIterator)
+
+				for i, exp := range tt.exp {
+					if p, err := fitr.Next(); err != nil {
+						t.Fatalf("%s: unexpected error(%d): %s", tt.expr, i, err)
+					} else if !deep.Equal(p, exp) {
+						t.Fatalf("%s: unexpected point(%d): %s", tt.expr, i, spew.Sdump(p))
+					}
+				}
+				if p, err := fitr.Next(); err != nil {
+					t.Fatalf("%s: unexpected error: %s", tt.expr, err)
+				} else if p != nil {
+					t.Fatalf("%s: unexpected point: %s", tt.expr, spew.Sdump(p))
+				}
+				itr.Close()
+			}
+
+			itr, err := sg.CreateIterator(context.Background(), &influxql.Measurement{Name: "cpu"}, query.IteratorOptions{
+				Expr:        influxql.MustParseExpr(`count(value)`),
+				Dimensions:  []string{"host"},
+				Ascending:   true,
+				StartTime:   influxql.MinTime,
+				EndTime:     influxql.MaxTime,
+				Overlapping: true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer itr.Close()
+			iitr := itr.(query.IntegerIterator)
+
+			counts := make(map[string]int64)
+			for {
+				p, err := iitr.Next()
+				if err != nil {
+					t.Fatal(err)
+				} else if p == nil {
+					break
+				}
+				counts[p.Tags.Value("host")] += p.Value
+			}
+			if exp := map[string]int64{"serverA": 3, "serverB": 1}; !deep.Equal(counts, exp) {
+				t.Fatalf("unexpected counts: %v", counts)
+			}
+		})
+	}
+}
+
+// Ensure points of different series written at the same time into
+// overlapping shards are all returned when the series are not grouped.
+func TestShards_CreateIterator_DedupeUngrouped(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			shards := NewShards(t, index, 2)
+			shards.MustOpen()
+			defer shards.Close()
+
+			shards[0].MustWritePointsString(`cpu,host=serverA value=1 10`)
+			shards[1].MustWritePointsString(`cpu,host=serverB value=2 10`)
+
+			sg := tsdb.Shards{shards[0].Shard, shards[1].Shard}
+			itr, err := sg.CreateIterator(context.Background(), &influxql.Measurement{Name: "cpu"}, query.IteratorOptions{
+				Expr:      influxql.MustParseExpr(`value`),
+				Ascending: true,
+				Ordered:   true,
+				StartTime: influxql.MinTime,
+				EndTime:   influxql.MaxTime,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer itr.Close()
+			fitr := itr.(query.FloatIterator)
+
+			for i, exp := range []*query.FloatPoint{
+				{Name: "cpu", Time: time.Unix(10, 0).UnixNano(), Value: 1},
+				{Name: "cpu", Time: time.Unix(10, 0).UnixNano(), Value: 2},
+			} {
+				if p, err := fitr.Next(); err != nil {
+					t.Fatalf("unexpected error(%d): %s", i, err)
+				} else if !deep.Equal(p, exp) {
+					t.Fatalf("unexpected point(%d): %s", i, spew.Sdump(p))
+				}
+			}
+
+			if p, err := fitr.Next(); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if p != nil {
+				t.Fatalf("unexpected point: %s", spew.Sdump(p))
+			}
+		})
+	}
+}
+
+// Ensure SLIMIT applied while reading series chooses the same tag sets in
+// every shard and only reads their series.
+func TestShards_CreateIterator_LimitSeriesFirst(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			shards := NewShards(t, index, 2)
+			shards.MustOpen()
+			defer shards.Close()
+
+			// The series are not in every shard, so each shard would read a
+			// different series first.
+			shards[0].MustWritePointsString(`cpu,host=serverB value=1 10
+cpu,host=serverA value=2 10`)
+			shards[1].MustWritePointsString(`cpu,host=serverA value=3 20
+cpu,host=serverC value=4 20`)
+			pointsN := map[string]int{"serverA": 2, "serverB": 1, "serverC": 1}
+
+			sg := tsdb.Shards{shards[0].Shard, shards[1].Shard}
+			itr, err := sg.CreateIterator(context.Background(), &influxql.Measurement{Name: "cpu"}, query.IteratorOptions{
+				Expr:             influxql.MustParseExpr(`value`),
+				Dimensions:       []string{"host"},
+				Ascending:        true,
+				Ordered:          true,
+				SLimit:           1,
+				LimitSeriesFirst: true,
+				StartTime:        influxql.MinTime,
+				EndTime:          influxql.MaxTime,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer itr.Close()
+			fitr := itr.(query.FloatIterator)
+
+			// Every point of a single series is returned.
+			var host string
+			var n int
+			for {
+				p, err := fitr.Next()
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				} else if p == nil {
+					break
+				} else if n > 0 && p.Tags.Value("host") != host {
+					t.Fatalf("unexpected point: %s", spew.Sdump(p))
+				}
+				host = p.Tags.Value("host")
+				n++
+			}
+			if got, exp := n, pointsN[host]; got != exp {
+				t.Fatalf("got %d points for %s, expected %d", got, host, exp)
+			}
+
+			// Only the series of the chosen tag set are read, one per shard.
+			if got, exp := itr.Stats().SeriesN, pointsN[host]; got != exp {
+				t.Fatalf("got %d series read, expected %d", got, exp)
+			}
+		})
+	}
+}
+
 func TestShards_FieldDimensions(t *testing.T) {
 	var shard1, shard2 *Shard
 
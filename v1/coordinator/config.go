@@ -27,6 +27,8 @@ type Config struct {
 	MaxSelectPointN      int           `toml:"max-select-point"`
 	MaxSelectSeriesN     int           `toml:"max-select-series"`
 	MaxSelectBucketsN    int           `toml:"max-select-buckets"`
+	MaxRegexComplexity   int           `toml:"max-regex-complexity"`
+	Rollups              []string      `toml:"rollups"`
 }
 
 // NewConfig returns an instance of Config with defaults.
@@ -0,0 +1,118 @@
+package coordinator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/influxql/query"
+	"github.com/influxdata/influxql"
+)
+
+// Rollup maps a measurement to a pre-aggregated rollup measurement that is
+// read instead when a SELECT statement covers more than Threshold of time.
+type Rollup struct {
+	Source    string
+	Rollup    string
+	Threshold time.Duration
+}
+
+// ParseRollup parses a rollup in the form "source:rollup:threshold",
+// e.g. "cpu:cpu_1h:7d".
+func ParseRollup(s string) (Rollup, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return Rollup{}, fmt.Errorf("invalid rollup %q: expected source:rollup:threshold", s)
+	}
+
+	d, err := influxql.ParseDuration(parts[2])
+	if err != nil {
+		return Rollup{}, fmt.Errorf("invalid rollup %q: %s", s, err)
+	} else if d <= 0 {
+		return Rollup{}, fmt.Errorf("invalid rollup %q: threshold must be positive", s)
+	}
+	return Rollup{Source: parts[0], Rollup: parts[1], Threshold: d}, nil
+}
+
+// ParseRollups parses each rollup with ParseRollup.
+func ParseRollups(a []string) ([]Rollup, error) {
+	rollups := make([]Rollup, 0, len(a))
+	for _, s := range a {
+		r, err := ParseRollup(s)
+		if err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, r)
+	}
+	return rollups, nil
+}
+
+// rewriteRollups returns a copy of stmt that reads each source measurement
+// with a rollup from the rollup measurement when the time range of stmt is
+// wider than the threshold of the rollup. A time range without a lower bound
+// is wider than any threshold. stmt is returned when no rollup applies.
+// The returned map holds the source measurement of each rollup read so the
+// results can be named after the measurement in the statement.
+func rewriteRollups(stmt *influxql.SelectStatement, rollups []Rollup, now time.Time) (*influxql.SelectStatement, map[string]string) {
+	if len(rollups) == 0 {
+		return stmt, nil
+	}
+
+	// Leave a condition that cannot be evaluated to the compiler to report.
+	valuer := influxql.NowValuer{Now: now, Location: stmt.Location}
+	_, timeRange, err := influxql.ConditionExpr(stmt.Condition, &valuer)
+	if err != nil {
+		return stmt, nil
+	}
+	unbounded := timeRange.Min.IsZero()
+	if timeRange.Max.IsZero() {
+		timeRange.Max = now
+	}
+	width := timeRange.Max.Sub(timeRange.Min)
+
+	var (
+		other *influxql.SelectStatement
+		names map[string]string
+	)
+	for i, source := range stmt.Sources {
+		m, ok := source.(*influxql.Measurement)
+		if !ok || m.Regex != nil {
+			continue
+		}
+
+		for _, r := range rollups {
+			if m.Name != r.Source || (!unbounded && width <= r.Threshold) {
+				continue
+			}
+			if other == nil {
+				other = stmt.Clone()
+				names = make(map[string]string)
+			}
+			other.Sources[i].(*influxql.Measurement).Name = r.Rollup
+			names[r.Rollup] = r.Source
+			break
+		}
+	}
+
+	if other == nil {
+		return stmt, nil
+	}
+	return other, names
+}
+
+// rollupCursor renames the series read from a rollup measurement after the
+// source measurement that was rewritten to it.
+type rollupCursor struct {
+	query.Cursor
+	names map[string]string
+}
+
+func (cur *rollupCursor) Scan(row *query.Row) bool {
+	if !cur.Cursor.Scan(row) {
+		return false
+	}
+	if name, ok := cur.names[row.Series.Name]; ok {
+		row.Series.Name = name
+	}
+	return true
+}
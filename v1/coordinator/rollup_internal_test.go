@@ -0,0 +1,70 @@
+package coordinator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRollup(t *testing.T) {
+	r, err := ParseRollup("cpu:cpu_1h:7d")
+	require.NoError(t, err)
+	require.Equal(t, Rollup{Source: "cpu", Rollup: "cpu_1h", Threshold: 7 * 24 * time.Hour}, r)
+
+	for _, s := range []string{"cpu", "cpu:cpu_1h", ":cpu_1h:1d", "cpu:cpu_1h:x", "cpu:cpu_1h:0s"} {
+		_, err := ParseRollup(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestRewriteRollups(t *testing.T) {
+	now := time.Date(2000, 1, 10, 0, 0, 0, 0, time.UTC)
+	rollups := []Rollup{{Source: "cpu", Rollup: "cpu_1h", Threshold: 24 * time.Hour}}
+
+	for _, tt := range []struct {
+		s   string
+		exp string
+	}{
+		{
+			s:   `SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-03T00:00:00Z'`,
+			exp: `SELECT value FROM cpu_1h WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-03T00:00:00Z'`,
+		},
+		{
+			s:   `SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z'`,
+			exp: `SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T01:00:00Z'`,
+		},
+		{
+			s:   `SELECT value FROM cpu WHERE time >= now() - 1h`,
+			exp: `SELECT value FROM cpu WHERE time >= now() - 1h`,
+		},
+		{
+			s:   `SELECT value FROM cpu WHERE time >= now() - 2d`,
+			exp: `SELECT value FROM cpu_1h WHERE time >= now() - 2d`,
+		},
+		{
+			s:   `SELECT value FROM cpu, mem`,
+			exp: `SELECT value FROM cpu_1h, mem`,
+		},
+		{
+			s:   `SELECT value FROM /cpu/`,
+			exp: `SELECT value FROM /cpu/`,
+		},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			stmt, err := influxql.ParseStatement(tt.s)
+			require.NoError(t, err)
+			orig := stmt.String()
+
+			got, names := rewriteRollups(stmt.(*influxql.SelectStatement), rollups, now)
+			require.Equal(t, tt.exp, got.String())
+			if got != stmt {
+				require.Equal(t, map[string]string{"cpu_1h": "cpu"}, names)
+			} else {
+				require.Nil(t, names)
+			}
+			require.Equal(t, orig, stmt.String(), "statement was modified")
+		})
+	}
+}
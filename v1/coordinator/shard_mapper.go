@@ -62,7 +62,7 @@ func (e *LocalShardMapper) mapShards(ctx context.Context, a *LocalShardMapping,
 			// using.
 			if _, ok := a.ShardMap[source]; !ok {
 				// lookup bucket and create info
-				mappings, _, err := e.DBRP.FindMany(ctx, influxdb.DBRPMappingFilter{
+				mappings, _, err := findMappings(ctx, e.DBRP, influxdb.DBRPMappingFilter{
 					OrgID:           &orgID,
 					Database:        &s.Database,
 					RetentionPolicy: &s.RetentionPolicy,
@@ -87,10 +87,18 @@ func (e *LocalShardMapper) mapShards(ctx context.Context, a *LocalShardMapping,
 				}
 
 				shardIDs := make([]uint64, 0, len(groups[0].Shards)*len(groups))
-				for _, g := range groups {
+				for i, g := range groups {
 					for _, si := range g.Shards {
 						shardIDs = append(shardIDs, si.ID)
 					}
+					for _, other := range groups[:i] {
+						if g.StartTime.Before(other.EndTime) && other.StartTime.Before(g.EndTime) {
+							if a.Overlapping == nil {
+								a.Overlapping = make(map[Source]bool)
+							}
+							a.Overlapping[source] = true
+						}
+					}
 				}
 				a.ShardMap[source] = e.TSDBStore.ShardGroup(shardIDs)
 			}
@@ -107,6 +115,10 @@ func (e *LocalShardMapper) mapShards(ctx context.Context, a *LocalShardMapping,
 type LocalShardMapping struct {
 	ShardMap map[Source]tsdb.ShardGroup
 
+	// Overlapping is set for the sources whose shard groups have
+	// overlapping time ranges and may hold the same points.
+	Overlapping map[Source]bool
+
 	// MinTime is the minimum time that this shard mapper will allow.
 	// Any attempt to use a time before this one will automatically result in using
 	// this time instead.
@@ -193,6 +205,7 @@ func (a *LocalShardMapping) CreateIterator(ctx context.Context, m *influxql.Meas
 	if !a.MaxTime.IsZero() && opt.EndTime > a.MaxTime.UnixNano() {
 		opt.EndTime = a.MaxTime.UnixNano()
 	}
+	opt.Overlapping = a.Overlapping[source]
 
 	if m.Regex != nil {
 		measurements := sg.MeasurementsByRegex(m.Regex.Val)
@@ -254,6 +267,34 @@ func (a *LocalShardMapping) IteratorCost(ctx context.Context, m *influxql.Measur
 	return sg.IteratorCost(ctx, m.Name, opt)
 }
 
+// ShardIDs returns the IDs of the mapped shards that contain the measurement.
+func (a *LocalShardMapping) ShardIDs(ctx context.Context, m *influxql.Measurement) ([]uint64, error) {
+	source := Source{
+		Database:        m.Database,
+		RetentionPolicy: m.RetentionPolicy,
+	}
+
+	sg := a.ShardMap[source]
+	if sg == nil {
+		return nil, nil
+	}
+
+	names := []string{m.Name}
+	if m.Regex != nil {
+		names = sg.MeasurementsByRegex(m.Regex.Val)
+	}
+
+	var ids []uint64
+	for _, name := range names {
+		shardIDs, err := sg.ShardIDs(name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, shardIDs...)
+	}
+	return ids, nil
+}
+
 // Close clears out the list of mapped shards.
 func (a *LocalShardMapping) Close() error {
 	a.ShardMap = nil
@@ -123,3 +123,75 @@ func TestLocalShardMapper(t *testing.T) {
 		t.Fatalf("unexpected error: %s", err)
 	}
 }
+
+// Ensure iterators are created with Overlapping set when the shard groups of
+// a source have overlapping time ranges.
+func TestLocalShardMapper_Overlapping(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		groups []meta.ShardGroupInfo
+		exp    bool
+	}{
+		{
+			name: "adjacent",
+			groups: []meta.ShardGroupInfo{
+				{ID: 1, StartTime: time.Unix(0, 0), EndTime: time.Unix(10, 0), Shards: []meta.ShardInfo{{ID: 1}}},
+				{ID: 2, StartTime: time.Unix(10, 0), EndTime: time.Unix(20, 0), Shards: []meta.ShardInfo{{ID: 2}}},
+			},
+			exp: false,
+		},
+		{
+			name: "overlapping",
+			groups: []meta.ShardGroupInfo{
+				{ID: 1, StartTime: time.Unix(0, 0), EndTime: time.Unix(20, 0), Shards: []meta.ShardInfo{{ID: 1}}},
+				{ID: 2, StartTime: time.Unix(10, 0), EndTime: time.Unix(30, 0), Shards: []meta.ShardInfo{{ID: 2}}},
+			},
+			exp: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			dbrp := mocks.NewMockDBRPMappingService(ctrl)
+			orgID := platform.ID(0xff00)
+			db, rp := "db0", "rp0"
+			dbrp.EXPECT().
+				FindMany(gomock.Any(), gomock.Any()).
+				Return([]*influxdb.DBRPMapping{{Database: db, RetentionPolicy: rp, OrganizationID: orgID, BucketID: platform.ID(0xffee)}}, 1, nil)
+
+			var metaClient MetaClient
+			metaClient.ShardGroupsByTimeRangeFn = func(database, policy string, min, max time.Time) ([]meta.ShardGroupInfo, error) {
+				return tt.groups, nil
+			}
+
+			var overlapping bool
+			tsdbStore := &internal.TSDBStoreMock{}
+			tsdbStore.ShardGroupFn = func(ids []uint64) tsdb.ShardGroup {
+				var sh MockShard
+				sh.CreateIteratorFn = func(ctx context.Context, measurement *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error) {
+					overlapping = opt.Overlapping
+					return &FloatIterator{}, nil
+				}
+				return &sh
+			}
+
+			shardMapper := &coordinator.LocalShardMapper{
+				MetaClient: &metaClient,
+				TSDBStore:  tsdbStore,
+				DBRP:       dbrp,
+			}
+
+			measurement := &influxql.Measurement{Database: db, RetentionPolicy: rp, Name: "cpu"}
+			ic, err := shardMapper.MapShards(context.Background(), []influxql.Source{measurement}, influxql.TimeRange{}, query.SelectOptions{OrgID: orgID})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if _, err := ic.CreateIterator(context.Background(), measurement, query.IteratorOptions{OrgID: orgID}); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			} else if overlapping != tt.exp {
+				t.Fatalf("unexpected overlapping: got=%v want=%v", overlapping, tt.exp)
+			}
+		})
+	}
+}
@@ -2,16 +2,20 @@ package coordinator
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/cespare/xxhash"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/authorizer"
 	iql "github.com/influxdata/influxdb/v2/influxql"
 	"github.com/influxdata/influxdb/v2/influxql/query"
+	"github.com/influxdata/influxdb/v2/kit/platform"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/pkg/tracing"
@@ -41,10 +45,27 @@ type StatementExecutor struct {
 	MaxSelectPointN   int
 	MaxSelectSeriesN  int
 	MaxSelectBucketsN int
+
+	// Maximum number of instructions of a compiled regular expression.
+	MaxRegexComplexity int
+
+	// Rollups read by SELECT statements that request automatic rollups.
+	Rollups []Rollup
+
+	// IntoWriter writes the results of SELECT INTO statements. SELECT INTO
+	// is not supported when it is nil.
+	IntoWriter IntoWriter
+}
+
+// IntoWriter writes points to a bucket.
+type IntoWriter interface {
+	WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error
 }
 
 // ExecuteStatement executes the given statement with the given execution context.
 func (e *StatementExecutor) ExecuteStatement(ctx context.Context, stmt influxql.Statement, ectx *query.ExecutionContext) error {
+	ctx = withBucketMapping(ctx, ectx)
+
 	// Select statements are handled separately so that they can be streamed.
 	if stmt, ok := stmt.(*influxql.SelectStatement); ok {
 		return e.executeSelectStatement(ctx, stmt, ectx)
@@ -113,7 +134,7 @@ func (e *StatementExecutor) ExecuteStatement(ctx context.Context, stmt influxql.
 	case *influxql.ShowRetentionPoliciesStatement:
 		rows, err = e.executeShowRetentionPoliciesStatement(ctx, stmt, ectx)
 	case *influxql.ShowSeriesCardinalityStatement:
-		rows, err = nil, iql.ErrNotImplemented("SHOW SERIES CARDINALITY")
+		rows, err = e.executeShowSeriesCardinalityStatement(ctx, stmt, ectx)
 	case *influxql.ShowShardsStatement:
 		rows, err = nil, iql.ErrNotImplemented("SHOW SHARDS")
 	case *influxql.ShowShardGroupsStatement:
@@ -126,6 +147,8 @@ func (e *StatementExecutor) ExecuteStatement(ctx context.Context, stmt influxql.
 		return e.executeShowTagKeys(ctx, stmt, ectx)
 	case *influxql.ShowTagValuesStatement:
 		return e.executeShowTagValues(ctx, stmt, ectx)
+	case *influxql.ShowTagValuesCardinalityStatement:
+		return e.executeShowTagValuesCardinality(ctx, stmt, ectx)
 	case *influxql.ShowUsersStatement:
 		rows, err = nil, iql.ErrNotImplemented("SHOW USERS")
 	case *influxql.SetPasswordUserStatement:
@@ -148,10 +171,12 @@ func (e *StatementExecutor) ExecuteStatement(ctx context.Context, stmt influxql.
 
 func (e *StatementExecutor) executeExplainStatement(ctx context.Context, q *influxql.ExplainStatement, ectx *query.ExecutionContext) (models.Rows, error) {
 	opt := query.SelectOptions{
-		OrgID:       ectx.OrgID,
-		NodeID:      ectx.ExecutionOptions.NodeID,
-		MaxSeriesN:  e.MaxSelectSeriesN,
-		MaxBucketsN: e.MaxSelectBucketsN,
+		OrgID:              ectx.OrgID,
+		NodeID:             ectx.ExecutionOptions.NodeID,
+		MaxSeriesN:         e.MaxSelectSeriesN,
+		MaxBucketsN:        e.MaxSelectBucketsN,
+		MaxRegexComplexity: e.MaxRegexComplexity,
+		Now:                ectx.Now,
 	}
 
 	// Prepare the query for execution, but do not actually execute it.
@@ -242,6 +267,18 @@ CLEANUP:
 }
 
 func (e *StatementExecutor) executeSelectStatement(ctx context.Context, stmt *influxql.SelectStatement, ectx *query.ExecutionContext) error {
+	// Collect any warnings from planning so they are returned with the first result.
+	var messages []*query.Message
+	ctx = query.NewContextWithMessages(ctx, &messages)
+
+	if ectx.ColumnsOnly {
+		return e.executeSelectColumns(ctx, stmt, ectx, &messages)
+	} else if ectx.Estimate {
+		return e.executeSelectEstimate(ctx, stmt, ectx, &messages)
+	} else if ectx.TagsAsJSON && stmt.IsRawQuery && stmt.Target == nil {
+		return e.executeSelectTagsAsJSON(ctx, stmt, ectx, &messages)
+	}
+
 	cur, err := e.createIterators(ctx, stmt, ectx.ExecutionOptions, ectx.StatisticsGatherer)
 	if err != nil {
 		return err
@@ -255,10 +292,21 @@ func (e *StatementExecutor) executeSelectStatement(ctx context.Context, stmt *in
 	var emitted bool
 
 	if stmt.Target != nil {
-		// SELECT INTO is unsupported
-		return iql.ErrNotImplemented("SELECT INTO")
+		if e.IntoWriter == nil {
+			return iql.ErrNotImplemented("SELECT INTO")
+		}
+		return e.executeSelectInto(ctx, stmt, em, ectx, &messages)
+	}
+
+	if ectx.Pivot != "" {
+		return e.executeSelectPivot(ctx, stmt, em, ectx, &messages)
+	} else if ectx.Interleave {
+		return e.executeSelectInterleaved(ctx, stmt, em, ectx, &messages)
+	} else if ectx.UnionColumns {
+		return e.executeSelectUnion(ctx, stmt, em, ectx, &messages)
 	}
 
+	remaining := ectx.MaxRows
 	for {
 		row, partial, err := em.Emit()
 		if err != nil {
@@ -270,15 +318,43 @@ func (e *StatementExecutor) executeSelectStatement(ctx context.Context, stmt *in
 			}
 			break
 		}
+		if ectx.LocalTime {
+			addLocalTimeColumn(row, stmt.Location)
+		}
+		if ectx.SeriesID {
+			addSeriesIDColumn(row)
+		}
+
+		// Drop the rows past the maximum number of rows and mark the
+		// result as truncated.
+		var truncated bool
+		if ectx.MaxRows > 0 {
+			if remaining == 0 {
+				return ectx.Send(ctx, &query.Result{
+					Series:    make([]*models.Row, 0),
+					Messages:  messages,
+					Truncated: true,
+				})
+			} else if len(row.Values) > remaining {
+				row.Values = row.Values[:remaining]
+				partial, truncated = false, true
+			}
+			remaining -= len(row.Values)
+		}
 
 		result := &query.Result{
-			Series:  []*models.Row{row},
-			Partial: partial,
+			Series:    []*models.Row{row},
+			Messages:  messages,
+			Partial:   partial,
+			Truncated: truncated,
 		}
+		messages = nil
 
 		// Send results or exit if closing.
 		if err := ectx.Send(ctx, result); err != nil {
 			return err
+		} else if truncated {
+			return nil
 		}
 
 		emitted = true
@@ -287,13 +363,777 @@ func (e *StatementExecutor) executeSelectStatement(ctx context.Context, stmt *in
 	// Always emit at least one result.
 	if !emitted {
 		return ectx.Send(ctx, &query.Result{
-			Series: make([]*models.Row, 0),
+			Series:   make([]*models.Row, 0),
+			Messages: messages,
 		})
 	}
 
 	return nil
 }
 
+// executeSelectInto writes every row from the emitter as points of the
+// target measurement and sends the number of points written.
+func (e *StatementExecutor) executeSelectInto(ctx context.Context, stmt *influxql.SelectStatement, em *query.Emitter, ectx *query.ExecutionContext, messages *[]*query.Message) error {
+	target := stmt.Target.Measurement
+	mappings, _, err := findMappings(ctx, e.DBRP, influxdb.DBRPMappingFilter{
+		OrgID:           &ectx.OrgID,
+		Database:        &target.Database,
+		RetentionPolicy: &target.RetentionPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("finding DBRP mappings: %v", err)
+	} else if len(mappings) == 0 {
+		return fmt.Errorf("retention policy not found: %s", target.RetentionPolicy)
+	} else if len(mappings) != 1 {
+		return fmt.Errorf("finding DBRP mappings: expected 1, found %d", len(mappings))
+	}
+	mapping := mappings[0]
+
+	perm, err := influxdb.NewPermissionAtID(mapping.BucketID, influxdb.WriteAction, influxdb.BucketsResourceType, mapping.OrganizationID)
+	if err != nil {
+		return err
+	} else if err := authorizer.IsAllowed(ctx, *perm); err != nil {
+		return err
+	}
+
+	if err := validateTargetTags(target.Name, stmt.Dimensions); err != nil {
+		return err
+	}
+
+	// Count the points and the distinct series they are written to.
+	var written int64
+	series := make(map[string]struct{})
+	for {
+		row, _, err := em.Emit()
+		if err != nil {
+			return err
+		} else if row == nil {
+			// Check if the query was interrupted while emitting.
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			break
+		}
+
+		name, err := targetMeasurementName(target.Name, row)
+		if err != nil {
+			return err
+		}
+		points, err := convertRowToPoints(name, row)
+		if err != nil {
+			return err
+		} else if len(points) == 0 {
+			continue
+		}
+
+		if err := e.IntoWriter.WritePoints(ctx, mapping.OrganizationID, mapping.BucketID, points); err != nil {
+			return err
+		}
+		written += int64(len(points))
+		for _, p := range points {
+			series[string(p.Key())] = struct{}{}
+		}
+	}
+
+	return ectx.Send(ctx, &query.Result{
+		Series: []*models.Row{{
+			Name:    "result",
+			Columns: []string{"time", "written", "seriesWritten"},
+			Values:  [][]interface{}{{time.Unix(0, 0).UTC(), written, int64(len(series))}},
+		}},
+		Messages: *messages,
+	})
+}
+
+// targetTagToken matches a reference to a tag in the measurement name of an
+// INTO target, such as :host: in "rollup_:host:".
+var targetTagToken = regexp.MustCompile(`:([^:]+):`)
+
+// validateTargetTags returns an error if the measurement name of an INTO
+// target references a tag that is not grouped by. The tags of a wildcard or
+// regex dimension are only known once the rows are read.
+func validateTargetTags(name string, dimensions influxql.Dimensions) error {
+	for _, m := range targetTagToken.FindAllStringSubmatch(name, -1) {
+		found := false
+		for _, d := range dimensions {
+			switch expr := d.Expr.(type) {
+			case *influxql.VarRef:
+				found = found || expr.Val == m[1]
+			case *influxql.Wildcard, *influxql.RegexLiteral:
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("tag %s of INTO target is not in the GROUP BY clause", m[1])
+		}
+	}
+	return nil
+}
+
+// targetMeasurementName returns the measurement the points of row are
+// written to. Each :tag: token of the target name is replaced with the
+// value of that tag in row. A target without a name writes to the
+// measurement the row was read from.
+func targetMeasurementName(name string, row *models.Row) (string, error) {
+	if name == "" {
+		return row.Name, nil
+	}
+
+	var err error
+	name = targetTagToken.ReplaceAllStringFunc(name, func(token string) string {
+		key := token[1 : len(token)-1]
+		v, ok := row.Tags[key]
+		if !ok && err == nil {
+			err = fmt.Errorf("tag %s of INTO target is not in the GROUP BY clause", key)
+		}
+		return v
+	})
+	return name, err
+}
+
+// convertRowToPoints converts each value of row into a point of the named
+// measurement with the tags of the row. The null values of a row are not
+// written and a row without any values is dropped.
+func convertRowToPoints(name string, row *models.Row) ([]models.Point, error) {
+	timeIndex := -1
+	fieldIndexes := make(map[string]int)
+	for i, c := range row.Columns {
+		if c == "time" {
+			timeIndex = i
+		} else {
+			fieldIndexes[c] = i
+		}
+	}
+	if timeIndex == -1 {
+		return nil, errors.New("error finding time index in result")
+	}
+
+	tags := models.NewTags(row.Tags)
+	points := make([]models.Point, 0, len(row.Values))
+	for _, values := range row.Values {
+		fields := make(map[string]interface{}, len(fieldIndexes))
+		for field, i := range fieldIndexes {
+			// NullFloat is not equal to nil, but has no value to write.
+			if v := values[i]; v != nil && v != query.NullFloat {
+				fields[field] = v
+			}
+		}
+
+		t, ok := values[timeIndex].(time.Time)
+		if !ok {
+			return nil, errors.New("error finding time index in result")
+		}
+		p, err := models.NewPoint(name, tags, fields, t)
+		if err != nil {
+			// Drop the points that cannot be stored, such as points
+			// without any fields.
+			continue
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// executeSelectInterleaved reads every series from the emitter and sends them
+// as a single series ordered by time.
+func (e *StatementExecutor) executeSelectInterleaved(ctx context.Context, stmt *influxql.SelectStatement, em *query.Emitter, ectx *query.ExecutionContext, messages *[]*query.Message) error {
+	rows, err := collectRows(ctx, stmt, em, ectx)
+	if err != nil {
+		return err
+	}
+
+	var interleaved models.Rows
+	if row := interleaveRows(rows, stmt.TimeAscending()); row != nil {
+		interleaved = models.Rows{row}
+	}
+	return sendRows(ctx, interleaved, ectx, messages)
+}
+
+// executeSelectUnion reads every series from the emitter and sends the series
+// of each tag set merged across measurements.
+func (e *StatementExecutor) executeSelectUnion(ctx context.Context, stmt *influxql.SelectStatement, em *query.Emitter, ectx *query.ExecutionContext, messages *[]*query.Message) error {
+	rows, err := collectRows(ctx, stmt, em, ectx)
+	if err != nil {
+		return err
+	}
+
+	return sendRows(ctx, unionRows(rows), ectx, messages)
+}
+
+// executeSelectPivot reads every series from the emitter and sends them with
+// each value of the pivot tag turned into its own set of columns.
+func (e *StatementExecutor) executeSelectPivot(ctx context.Context, stmt *influxql.SelectStatement, em *query.Emitter, ectx *query.ExecutionContext, messages *[]*query.Message) error {
+	rows, err := collectRows(ctx, stmt, em, ectx)
+	if err != nil {
+		return err
+	}
+
+	pivoted, err := pivotRows(rows, ectx.Pivot, stmt.TimeAscending())
+	if err != nil {
+		return err
+	}
+	return sendRows(ctx, pivoted, ectx, messages)
+}
+
+// executeSelectTagsAsJSON runs a raw SELECT statement grouped by every tag so
+// the tag set of each point is known. The series are then merged back into
+// the groups of the statement with a _tags column holding the tag set.
+func (e *StatementExecutor) executeSelectTagsAsJSON(ctx context.Context, stmt *influxql.SelectStatement, ectx *query.ExecutionContext, messages *[]*query.Message) error {
+	// The limits apply to the groups of the original statement so they are
+	// applied after the series have been merged.
+	grouped := stmt.Clone()
+	grouped.Dimensions = influxql.Dimensions{{Expr: &influxql.Wildcard{}}}
+	grouped.Limit, grouped.Offset = 0, 0
+	grouped.SLimit, grouped.SOffset = 0, 0
+
+	cur, err := e.createIterators(ctx, grouped, ectx.ExecutionOptions, ectx.StatisticsGatherer)
+	if err != nil {
+		return err
+	}
+
+	em := query.NewEmitter(cur, ectx.ChunkSize)
+	defer em.Close()
+
+	rows, err := collectRows(ctx, stmt, em, ectx)
+	if err != nil {
+		return err
+	}
+
+	merged, err := tagsAsJSONRows(rows, stmt)
+	if err != nil {
+		return err
+	}
+	return sendRows(ctx, merged, ectx, messages)
+}
+
+// tagsAsJSONRows merges rows grouped by every tag into the groups of the
+// dimensions of stmt. A _tags column is added with the non-empty tags of each
+// point as a JSON object, and the limits of stmt are applied to the groups.
+func tagsAsJSONRows(rows models.Rows, stmt *influxql.SelectStatement) (models.Rows, error) {
+	var groups models.Rows
+	byKey := make(map[string]*models.Row)
+	for _, row := range rows {
+		tags := dimensionTags(row.Tags, stmt.Dimensions)
+		key := row.Name + "\x00" + formatTagSet(tags)
+
+		g := byKey[key]
+		if g == nil {
+			g = &models.Row{
+				Name:    row.Name,
+				Tags:    tags,
+				Columns: append(append([]string(nil), row.Columns...), "_tags"),
+			}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+
+		pointTags := make(map[string]string, len(row.Tags))
+		for k, v := range row.Tags {
+			if v != "" {
+				pointTags[k] = v
+			}
+		}
+		b, err := json.Marshal(pointTags)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range row.Values {
+			g.Values = append(g.Values, append(v, string(b)))
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+		return formatTagSet(groups[i].Tags) < formatTagSet(groups[j].Tags)
+	})
+
+	if stmt.SOffset > 0 {
+		if stmt.SOffset >= len(groups) {
+			groups = nil
+		} else {
+			groups = groups[stmt.SOffset:]
+		}
+	}
+	if stmt.SLimit > 0 && stmt.SLimit < len(groups) {
+		groups = groups[:stmt.SLimit]
+	}
+
+	ascending := stmt.TimeAscending()
+	result := groups[:0]
+	for _, g := range groups {
+		sort.SliceStable(g.Values, func(i, j int) bool {
+			ti, _ := g.Values[i][0].(time.Time)
+			tj, _ := g.Values[j][0].(time.Time)
+			if ascending {
+				return ti.Before(tj)
+			}
+			return ti.After(tj)
+		})
+
+		if stmt.Offset > 0 {
+			if stmt.Offset >= len(g.Values) {
+				g.Values = nil
+			} else {
+				g.Values = g.Values[stmt.Offset:]
+			}
+		}
+		if stmt.Limit > 0 && stmt.Limit < len(g.Values) {
+			g.Values = g.Values[:stmt.Limit]
+		}
+
+		if len(g.Values) > 0 {
+			result = append(result, g)
+		}
+	}
+	return result, nil
+}
+
+// dimensionTags returns the subset of tags selected by the dimensions.
+// Tags selected by name are included even if they are empty.
+func dimensionTags(tags map[string]string, dimensions influxql.Dimensions) map[string]string {
+	var subset map[string]string
+	add := func(k, v string) {
+		if subset == nil {
+			subset = make(map[string]string)
+		}
+		subset[k] = v
+	}
+
+	for _, d := range dimensions {
+		switch expr := d.Expr.(type) {
+		case *influxql.VarRef:
+			add(expr.Val, tags[expr.Val])
+		case *influxql.Wildcard:
+			for k, v := range tags {
+				add(k, v)
+			}
+		case *influxql.RegexLiteral:
+			for k, v := range tags {
+				if expr.Val.MatchString(k) {
+					add(k, v)
+				}
+			}
+		}
+	}
+	return subset
+}
+
+// collectRows reads every row from the emitter.
+func collectRows(ctx context.Context, stmt *influxql.SelectStatement, em *query.Emitter, ectx *query.ExecutionContext) (models.Rows, error) {
+	var rows models.Rows
+	for {
+		row, _, err := em.Emit()
+		if err != nil {
+			return nil, err
+		} else if row == nil {
+			// Check if the query was interrupted while emitting.
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return rows, nil
+		}
+		if ectx.LocalTime {
+			addLocalTimeColumn(row, stmt.Location)
+		}
+		if ectx.SeriesID {
+			addSeriesIDColumn(row)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// sendRows sends every row with sendRow, or an empty result if there are no
+// rows. The values past the maximum number of rows are dropped and the result
+// is marked as truncated, like the rows sent as they are emitted.
+func sendRows(ctx context.Context, rows models.Rows, ectx *query.ExecutionContext, messages *[]*query.Message) error {
+	if len(rows) == 0 {
+		return ectx.Send(ctx, &query.Result{
+			Series:   make([]*models.Row, 0),
+			Messages: *messages,
+		})
+	}
+
+	remaining := ectx.MaxRows
+	for _, row := range rows {
+		var truncated bool
+		if ectx.MaxRows > 0 {
+			if remaining == 0 {
+				return ectx.Send(ctx, &query.Result{
+					Series:    make([]*models.Row, 0),
+					Messages:  *messages,
+					Truncated: true,
+				})
+			} else if len(row.Values) > remaining {
+				row.Values = row.Values[:remaining]
+				truncated = true
+			}
+			remaining -= len(row.Values)
+		}
+
+		if err := sendRow(ctx, row, ectx, messages, truncated); err != nil {
+			return err
+		} else if truncated {
+			return nil
+		}
+	}
+	return nil
+}
+
+// sendRow sends a row, split into chunks if a chunk size was requested. The
+// last chunk is marked as truncated if truncated is set.
+func sendRow(ctx context.Context, row *models.Row, ectx *query.ExecutionContext, messages *[]*query.Message, truncated bool) error {
+	values := row.Values
+	for {
+		chunk := *row
+		chunk.Values, values = values, nil
+		if ectx.ChunkSize > 0 && len(chunk.Values) > ectx.ChunkSize {
+			chunk.Values, values = chunk.Values[:ectx.ChunkSize], chunk.Values[ectx.ChunkSize:]
+		}
+
+		result := &query.Result{
+			Series:    []*models.Row{&chunk},
+			Messages:  *messages,
+			Partial:   len(values) > 0,
+			Truncated: truncated && len(values) == 0,
+		}
+		*messages = nil
+
+		if err := ectx.Send(ctx, result); err != nil {
+			return err
+		} else if len(values) == 0 {
+			return nil
+		}
+	}
+}
+
+// interleaveRows merges rows into a single row ordered by time. The columns
+// are the union of the columns of each row with a tags column holding the
+// tag set of the row. A name column is added if the rows are from more than
+// one measurement.
+func interleaveRows(rows models.Rows, ascending bool) *models.Row {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	out := &models.Row{Name: rows[0].Name}
+	columns := []string{"time"}
+	var multipleNames bool
+	for _, row := range rows[1:] {
+		if row.Name != out.Name {
+			out.Name, multipleNames = "", true
+			columns = append(columns, "name")
+			break
+		}
+	}
+	columns = append(columns, "tags")
+	tagsIndex := len(columns) - 1
+
+	index := make(map[string]int)
+	for _, row := range rows {
+		for _, c := range row.Columns[1:] {
+			if _, ok := index[c]; !ok {
+				index[c] = len(columns)
+				columns = append(columns, c)
+			}
+		}
+	}
+	out.Columns = columns
+
+	for _, row := range rows {
+		tags := formatTagSet(row.Tags)
+		for _, v := range row.Values {
+			values := make([]interface{}, len(columns))
+			values[0] = v[0]
+			if multipleNames {
+				values[1] = row.Name
+			}
+			values[tagsIndex] = tags
+			for i, c := range row.Columns[1:] {
+				values[index[c]] = v[i+1]
+			}
+			out.Values = append(out.Values, values)
+		}
+	}
+
+	sort.SliceStable(out.Values, func(i, j int) bool {
+		ti, _ := out.Values[i][0].(time.Time)
+		tj, _ := out.Values[j][0].(time.Time)
+		if ascending {
+			return ti.Before(tj)
+		}
+		return ti.After(tj)
+	})
+	return out
+}
+
+// unionRows merges the rows with the same tag set into a single row. The
+// columns are the union of the columns of each row, in the order they are
+// first seen, after a name column holding the measurement of each row.
+// Columns missing from a row are null and the rows keep their order.
+func unionRows(rows models.Rows) models.Rows {
+	type unionGroup struct {
+		row   *models.Row
+		index map[string]int
+	}
+
+	var groups []*unionGroup
+	byKey := make(map[string]*unionGroup)
+	for _, row := range rows {
+		key := formatTagSet(row.Tags)
+		g := byKey[key]
+		if g == nil {
+			g = &unionGroup{
+				row:   &models.Row{Tags: row.Tags, Columns: []string{"time", "name"}},
+				index: make(map[string]int),
+			}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		for _, c := range row.Columns[1:] {
+			if _, ok := g.index[c]; !ok {
+				g.index[c] = len(g.row.Columns)
+				g.row.Columns = append(g.row.Columns, c)
+			}
+		}
+	}
+
+	for _, row := range rows {
+		g := byKey[formatTagSet(row.Tags)]
+		for _, v := range row.Values {
+			values := make([]interface{}, len(g.row.Columns))
+			values[0], values[1] = v[0], row.Name
+			for i, c := range row.Columns[1:] {
+				values[g.index[c]] = v[i+1]
+			}
+			g.row.Values = append(g.row.Values, values)
+		}
+	}
+
+	result := make(models.Rows, len(groups))
+	for i, g := range groups {
+		result[i] = g.row
+	}
+	return result
+}
+
+// pivotRows merges the rows that differ only by the value of the pivot tag
+// into a single row keyed by time. Each value of the pivot tag becomes its own
+// column named after the value, or one column per field named value.field if
+// more than one field was selected. Times missing from a value are null.
+func pivotRows(rows models.Rows, tag string, ascending bool) (models.Rows, error) {
+	type pivotGroup struct {
+		row    *models.Row
+		index  map[string]int
+		times  map[int64]int
+		tagged []*models.Row
+	}
+
+	var groups []*pivotGroup
+	byKey := make(map[string]*pivotGroup)
+	for _, row := range rows {
+		if _, ok := row.Tags[tag]; !ok {
+			return nil, fmt.Errorf("pivot tag %q must be in the GROUP BY clause", tag)
+		}
+
+		tags := make(map[string]string, len(row.Tags)-1)
+		for k, v := range row.Tags {
+			if k != tag {
+				tags[k] = v
+			}
+		}
+		key := row.Name + "\x00" + formatTagSet(tags)
+
+		g := byKey[key]
+		if g == nil {
+			out := &models.Row{Name: row.Name, Columns: []string{"time"}}
+			if len(tags) > 0 {
+				out.Tags = tags
+			}
+			g = &pivotGroup{row: out, index: make(map[string]int), times: make(map[int64]int)}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.tagged = append(g.tagged, row)
+	}
+
+	columnName := func(value, field string, fields int) string {
+		if fields > 1 {
+			return value + "." + field
+		}
+		return value
+	}
+
+	result := make(models.Rows, 0, len(groups))
+	for _, g := range groups {
+		// Order the pivot values so the columns are stable.
+		sort.SliceStable(g.tagged, func(i, j int) bool {
+			return g.tagged[i].Tags[tag] < g.tagged[j].Tags[tag]
+		})
+
+		for _, row := range g.tagged {
+			value := row.Tags[tag]
+			fields := row.Columns[1:]
+			for _, c := range fields {
+				name := columnName(value, c, len(fields))
+				if _, ok := g.index[name]; !ok {
+					g.index[name] = len(g.row.Columns)
+					g.row.Columns = append(g.row.Columns, name)
+				}
+			}
+		}
+
+		for _, row := range g.tagged {
+			value := row.Tags[tag]
+			fields := row.Columns[1:]
+			for _, v := range row.Values {
+				t, _ := v[0].(time.Time)
+				i, ok := g.times[t.UnixNano()]
+				if !ok {
+					i = len(g.row.Values)
+					g.times[t.UnixNano()] = i
+					values := make([]interface{}, len(g.row.Columns))
+					values[0] = v[0]
+					g.row.Values = append(g.row.Values, values)
+				}
+
+				values := g.row.Values[i]
+				for j, c := range fields {
+					values[g.index[columnName(value, c, len(fields))]] = v[j+1]
+				}
+			}
+		}
+
+		sort.SliceStable(g.row.Values, func(i, j int) bool {
+			ti, _ := g.row.Values[i][0].(time.Time)
+			tj, _ := g.row.Values[j][0].(time.Time)
+			if ascending {
+				return ti.Before(tj)
+			}
+			return ti.After(tj)
+		})
+		result = append(result, g.row)
+	}
+	return result, nil
+}
+
+// addLocalTimeColumn converts the time column of row to UTC and adds a
+// _local_time column after it with the time formatted in loc. The row is
+// unchanged if there is no time zone.
+func addLocalTimeColumn(row *models.Row, loc *time.Location) {
+	if loc == nil || len(row.Columns) == 0 || row.Columns[0] != "time" {
+		return
+	}
+
+	row.Columns = append([]string{row.Columns[0], "_local_time"}, row.Columns[1:]...)
+	for i, values := range row.Values {
+		var localTime interface{}
+		if t, ok := values[0].(time.Time); ok {
+			localTime = t.In(loc).Format(time.RFC3339Nano)
+			values[0] = t.UTC()
+		}
+		row.Values[i] = append([]interface{}{values[0], localTime}, values[1:]...)
+	}
+}
+
+// addSeriesIDColumn adds a _series_id column to row with a hash of the
+// series key of its measurement and tags. The id of a series is the same
+// across queries so clients can match the series of different results.
+func addSeriesIDColumn(row *models.Row) {
+	key := models.MakeKey([]byte(row.Name), models.NewTags(row.Tags))
+	id := fmt.Sprintf("%016x", xxhash.Sum64(key))
+
+	row.Columns = append(row.Columns, "_series_id")
+	for i, values := range row.Values {
+		row.Values[i] = append(values, id)
+	}
+}
+
+// formatTagSet formats tags as comma separated key=value pairs sorted by key.
+func formatTagSet(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + tags[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// executeSelectColumns plans the statement and sends the columns of the
+// result for each measurement without reading any data.
+func (e *StatementExecutor) executeSelectColumns(ctx context.Context, stmt *influxql.SelectStatement, ectx *query.ExecutionContext, messages *[]*query.Message) error {
+	opt := query.SelectOptions{
+		OrgID:              ectx.OrgID,
+		NodeID:             ectx.ExecutionOptions.NodeID,
+		MaxSeriesN:         e.MaxSelectSeriesN,
+		MaxBucketsN:        e.MaxSelectBucketsN,
+		MaxRegexComplexity: e.MaxRegexComplexity,
+		Now:                ectx.Now,
+	}
+
+	// Prepare the query so wildcards are expanded, but do not execute it.
+	p, err := query.Prepare(ctx, stmt, e.ShardMapper, opt)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	columns := p.Columns()
+	series := make([]*models.Row, 0, len(stmt.Sources))
+	for _, source := range stmt.Sources {
+		if m, ok := source.(*influxql.Measurement); ok && m.Name != "" {
+			series = append(series, &models.Row{Name: m.Name, Columns: columns})
+		}
+	}
+	if len(series) == 0 {
+		// The measurement names are only known once the data is read.
+		series = append(series, &models.Row{Columns: columns})
+	}
+
+	return ectx.Send(ctx, &query.Result{
+		Series:   series,
+		Messages: *messages,
+	})
+}
+
+func (e *StatementExecutor) executeSelectEstimate(ctx context.Context, stmt *influxql.SelectStatement, ectx *query.ExecutionContext, messages *[]*query.Message) error {
+	opt := query.SelectOptions{
+		OrgID:              ectx.OrgID,
+		NodeID:             ectx.ExecutionOptions.NodeID,
+		MaxSeriesN:         e.MaxSelectSeriesN,
+		MaxBucketsN:        e.MaxSelectBucketsN,
+		MaxRegexComplexity: e.MaxRegexComplexity,
+		Now:                ectx.Now,
+	}
+
+	// Prepare the query so the shards are mapped, but do not execute it.
+	p, err := query.Prepare(ctx, stmt, e.ShardMapper, opt)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	cost, err := p.Estimate(ctx)
+	if err != nil {
+		return err
+	}
+
+	row := &models.Row{
+		Columns: []string{"shards", "series"},
+		Values:  [][]interface{}{{cost.NumShards, cost.NumSeries}},
+	}
+	return ectx.Send(ctx, &query.Result{
+		Series:   models.Rows{row},
+		Messages: *messages,
+	})
+}
+
 func (e *StatementExecutor) createIterators(ctx context.Context, stmt *influxql.SelectStatement, opt query.ExecutionOptions, gatherer *iql.StatisticsGatherer) (query.Cursor, error) {
 	defer func(start time.Time) {
 		dur := time.Since(start)
@@ -306,7 +1146,22 @@ func (e *StatementExecutor) createIterators(ctx context.Context, stmt *influxql.
 		MaxSeriesN:         e.MaxSelectSeriesN,
 		MaxPointN:          e.MaxSelectPointN,
 		MaxBucketsN:        e.MaxSelectBucketsN,
+		MaxRegexComplexity: e.MaxRegexComplexity,
 		StatisticsGatherer: gatherer,
+		CoerceNumeric:      opt.CoerceNumeric,
+		OrderValues:        opt.OrderValues,
+		TreatAsNull:        opt.TreatAsNull,
+		EchoRange:          opt.EchoRange,
+		HoltWintersBounds:  opt.HoltWintersBounds,
+		LimitSeriesFirst:   opt.LimitSeriesFirst,
+		LabelRight:         opt.LabelRight,
+		DropEmptyTags:      opt.DropEmptyTags,
+		Now:                opt.Now,
+	}
+
+	var names map[string]string
+	if opt.AutoRollup {
+		stmt, names = rewriteRollups(stmt, e.Rollups, opt.Now)
 	}
 
 	// Create a set of iterators from a selection.
@@ -314,12 +1169,15 @@ func (e *StatementExecutor) createIterators(ctx context.Context, stmt *influxql.
 	if err != nil {
 		return nil, err
 	}
+	if len(names) > 0 {
+		cur = &rollupCursor{Cursor: cur, names: names}
+	}
 	return cur, nil
 }
 
 func (e *StatementExecutor) executeShowDatabasesStatement(ctx context.Context, q *influxql.ShowDatabasesStatement, ectx *query.ExecutionContext) (models.Rows, error) {
 	row := &models.Row{Name: "databases", Columns: []string{"name"}}
-	dbrps, _, err := e.DBRP.FindMany(ctx, influxdb.DBRPMappingFilter{
+	dbrps, _, err := findMappings(ctx, e.DBRP, influxdb.DBRPMappingFilter{
 		OrgID: &ectx.OrgID,
 	})
 	if err != nil {
@@ -349,9 +1207,40 @@ func (e *StatementExecutor) executeShowDatabasesStatement(ctx context.Context, q
 	return []*models.Row{row}, nil
 }
 
+// executeShowSeriesCardinalityStatement returns the number of series in the
+// default retention policy of the database as counted by the index. The
+// statements with sources or conditions are rewritten to count the distinct
+// series keys instead.
+func (e *StatementExecutor) executeShowSeriesCardinalityStatement(ctx context.Context, stmt *influxql.ShowSeriesCardinalityStatement, ectx *query.ExecutionContext) (models.Rows, error) {
+	if stmt.Database == "" {
+		return nil, ErrDatabaseNameRequired
+	}
+
+	mapping, err := e.getDefaultRP(ctx, stmt.Database, ectx)
+	if err != nil {
+		return nil, err
+	}
+
+	perm, err := influxdb.NewPermissionAtID(mapping.BucketID, influxdb.ReadAction, influxdb.BucketsResourceType, mapping.OrganizationID)
+	if err != nil {
+		return nil, err
+	} else if err := authorizer.IsAllowed(ctx, *perm); err != nil {
+		return nil, err
+	}
+
+	n, err := e.TSDBStore.SeriesCardinality(ctx, mapping.BucketID.String())
+	if err != nil {
+		return nil, err
+	}
+	return []*models.Row{{
+		Columns: []string{"cardinality estimation"},
+		Values:  [][]interface{}{{n}},
+	}}, nil
+}
+
 func (e *StatementExecutor) getDefaultRP(ctx context.Context, database string, ectx *query.ExecutionContext) (*influxdb.DBRPMapping, error) {
 	defaultRP := true
-	mappings, n, err := e.DBRP.FindMany(ctx, influxdb.DBRPMappingFilter{
+	mappings, n, err := findMappings(ctx, e.DBRP, influxdb.DBRPMappingFilter{
 		OrgID:    &ectx.OrgID,
 		Database: &database,
 		Default:  &defaultRP,
@@ -428,7 +1317,7 @@ func (e *StatementExecutor) executeShowMeasurementsStatement(ctx context.Context
 			mappingsFilter.RetentionPolicy = &q.RetentionPolicy
 		}
 	}
-	mappings, _, err := e.DBRP.FindMany(ctx, mappingsFilter)
+	mappings, _, err := findMappings(ctx, e.DBRP, mappingsFilter)
 	if err != nil {
 		return fmt.Errorf("finding DBRP mappings: %v", err)
 	}
@@ -512,7 +1401,7 @@ func (e *StatementExecutor) executeShowRetentionPoliciesStatement(ctx context.Co
 		return nil, ErrDatabaseNameRequired
 	}
 
-	dbrps, _, err := e.DBRP.FindMany(ctx, influxdb.DBRPMappingFilter{
+	dbrps, _, err := findMappings(ctx, e.DBRP, influxdb.DBRPMappingFilter{
 		OrgID:    &ectx.OrgID,
 		Database: &q.Database,
 	})
@@ -589,6 +1478,14 @@ func (e *StatementExecutor) executeShowTagKeys(ctx context.Context, q *influxql.
 		})
 	}
 
+	if ectx.OrderByCardinality {
+		if err := e.orderTagKeysByCardinality(ctx, tagKeys, shardIDs, cond, ectx); err != nil {
+			return ectx.Send(ctx, &query.Result{
+				Err: err,
+			})
+		}
+	}
+
 	emitted := false
 	for _, m := range tagKeys {
 		keys := m.Keys
@@ -632,46 +1529,50 @@ func (e *StatementExecutor) executeShowTagKeys(ctx context.Context, q *influxql.
 	return nil
 }
 
-func (e *StatementExecutor) executeShowTagValues(ctx context.Context, q *influxql.ShowTagValuesStatement, ectx *query.ExecutionContext) error {
-	if q.Database == "" {
-		return ErrDatabaseNameRequired
+// orderTagKeysByCardinality sorts the keys of each measurement by the number
+// of distinct values of the key, most values first. Keys with the same number
+// of values keep their lexical order.
+func (e *StatementExecutor) orderTagKeysByCardinality(ctx context.Context, tagKeys []tsdb.TagKeys, shardIDs []uint64, cond influxql.Expr, ectx *query.ExecutionContext) error {
+	valuesCond := influxql.Expr(&influxql.BinaryExpr{
+		Op:  influxql.EQREGEX,
+		LHS: &influxql.VarRef{Val: "_tagKey"},
+		RHS: &influxql.RegexLiteral{Val: regexp.MustCompile(`.*`)},
+	})
+	if cond != nil {
+		valuesCond = &influxql.BinaryExpr{
+			Op:  influxql.AND,
+			LHS: valuesCond,
+			RHS: &influxql.ParenExpr{Expr: cond},
+		}
 	}
 
-	mapping, err := e.getDefaultRP(ctx, q.Database, ectx)
+	tagValues, err := e.TSDBStore.TagValues(ctx, ectx.Authorizer, shardIDs, valuesCond)
 	if err != nil {
 		return err
 	}
 
-	// Determine shard set based on database and time range.
-	// SHOW TAG VALUES returns all tag values for the default retention policy.
-	di := e.MetaClient.Database(mapping.BucketID.String())
-	if di == nil {
-		return fmt.Errorf("database not found: %s", q.Database)
-	}
-
-	// Determine appropriate time range. If one or fewer time boundaries provided
-	// then min/max possible time should be used instead.
-	valuer := &influxql.NowValuer{Now: time.Now()}
-	cond, timeRange, err := influxql.ConditionExpr(q.Condition, valuer)
-	if err != nil {
-		return err
+	cardinality := make(map[string]map[string]int, len(tagValues))
+	for _, m := range tagValues {
+		counts := make(map[string]int)
+		for _, v := range m.Values {
+			counts[v.Key]++
+		}
+		cardinality[m.Measurement] = counts
 	}
 
-	// Get all shards for all retention policies.
-	var allGroups []meta.ShardGroupInfo
-	for _, rpi := range di.RetentionPolicies {
-		sgis, err := e.MetaClient.ShardGroupsByTimeRange(mapping.BucketID.String(), rpi.Name, timeRange.MinTime(), timeRange.MaxTime())
-		if err != nil {
-			return err
-		}
-		allGroups = append(allGroups, sgis...)
+	for _, m := range tagKeys {
+		counts := cardinality[m.Measurement]
+		sort.SliceStable(m.Keys, func(i, j int) bool {
+			return counts[m.Keys[i]] > counts[m.Keys[j]]
+		})
 	}
+	return nil
+}
 
-	var shardIDs []uint64
-	for _, sgi := range allGroups {
-		for _, si := range sgi.Shards {
-			shardIDs = append(shardIDs, si.ID)
-		}
+func (e *StatementExecutor) executeShowTagValues(ctx context.Context, q *influxql.ShowTagValuesStatement, ectx *query.ExecutionContext) error {
+	cond, shardIDs, err := e.tagValuesShards(ctx, q.Database, q.Condition, ectx)
+	if err != nil {
+		return err
 	}
 
 	tagValues, err := e.TSDBStore.TagValues(ctx, ectx.Authorizer, shardIDs, cond)
@@ -725,9 +1626,121 @@ func (e *StatementExecutor) executeShowTagValues(ctx context.Context, q *influxq
 	return nil
 }
 
+// executeShowTagValuesCardinality counts the tag values of each tag key. It
+// only handles statements grouped by the tag key, every other cardinality
+// statement is rewritten to a SELECT statement.
+func (e *StatementExecutor) executeShowTagValuesCardinality(ctx context.Context, q *influxql.ShowTagValuesCardinalityStatement, ectx *query.ExecutionContext) error {
+	cond, shardIDs, err := e.tagValuesShards(ctx, q.Database, q.Condition, ectx)
+	if err != nil {
+		return err
+	}
+
+	tagValues, err := e.TSDBStore.TagValues(ctx, ectx.Authorizer, shardIDs, cond)
+	if err != nil {
+		return ectx.Send(ctx, &query.Result{Err: err})
+	}
+
+	emitted := false
+	for _, m := range tagValues {
+		var values [][]interface{}
+		for _, v := range m.Values {
+			if n := len(values); n > 0 && values[n-1][0] == v.Key {
+				values[n-1][1] = values[n-1][1].(int64) + 1
+				continue
+			}
+			values = append(values, []interface{}{v.Key, int64(1)})
+		}
+
+		if q.Offset > 0 {
+			if q.Offset >= len(values) {
+				values = nil
+			} else {
+				values = values[q.Offset:]
+			}
+		}
+
+		if q.Limit > 0 {
+			if q.Limit < len(values) {
+				values = values[:q.Limit]
+			}
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		row := &models.Row{
+			Name:    m.Measurement,
+			Columns: []string{"key", "count"},
+			Values:  values,
+		}
+
+		if err := ectx.Send(ctx, &query.Result{
+			Series: []*models.Row{row},
+		}); err != nil {
+			return err
+		}
+		emitted = true
+	}
+
+	// Ensure at least one result is emitted.
+	if !emitted {
+		return ectx.Send(ctx, &query.Result{})
+	}
+	return nil
+}
+
+// tagValuesShards returns the condition without its time range and the
+// shards of every retention policy in the database that the time range
+// overlaps.
+func (e *StatementExecutor) tagValuesShards(ctx context.Context, database string, condition influxql.Expr, ectx *query.ExecutionContext) (influxql.Expr, []uint64, error) {
+	if database == "" {
+		return nil, nil, ErrDatabaseNameRequired
+	}
+
+	mapping, err := e.getDefaultRP(ctx, database, ectx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Determine shard set based on database and time range.
+	// SHOW TAG VALUES returns all tag values for the default retention policy.
+	di := e.MetaClient.Database(mapping.BucketID.String())
+	if di == nil {
+		return nil, nil, fmt.Errorf("database not found: %s", database)
+	}
+
+	// Determine appropriate time range. If one or fewer time boundaries provided
+	// then min/max possible time should be used instead.
+	valuer := &influxql.NowValuer{Now: time.Now()}
+	cond, timeRange, err := influxql.ConditionExpr(condition, valuer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Get all shards for all retention policies.
+	var allGroups []meta.ShardGroupInfo
+	for _, rpi := range di.RetentionPolicies {
+		sgis, err := e.MetaClient.ShardGroupsByTimeRange(mapping.BucketID.String(), rpi.Name, timeRange.MinTime(), timeRange.MaxTime())
+		if err != nil {
+			return nil, nil, err
+		}
+		allGroups = append(allGroups, sgis...)
+	}
+
+	var shardIDs []uint64
+	for _, sgi := range allGroups {
+		for _, si := range sgi.Shards {
+			shardIDs = append(shardIDs, si.ID)
+		}
+	}
+	return cond, shardIDs, nil
+}
+
 // NormalizeStatement adds a default database and policy to the measurements in statement.
 // Parameter defaultRetentionPolicy can be "".
 func (e *StatementExecutor) NormalizeStatement(ctx context.Context, stmt influxql.Statement, defaultDatabase, defaultRetentionPolicy string, ectx *query.ExecutionContext) (err error) {
+	ctx = withBucketMapping(ctx, ectx)
 	influxql.WalkFunc(stmt, func(node influxql.Node) {
 		if err != nil {
 			return
@@ -749,6 +1762,10 @@ func (e *StatementExecutor) NormalizeStatement(ctx context.Context, stmt influxq
 			if node.Database == "" {
 				node.Database = defaultDatabase
 			}
+		case *influxql.ShowTagValuesCardinalityStatement:
+			if node.Database == "" {
+				node.Database = defaultDatabase
+			}
 		case *influxql.ShowMeasurementCardinalityStatement:
 			if node.Database == "" {
 				node.Database = defaultDatabase
@@ -793,7 +1810,7 @@ func (e *StatementExecutor) normalizeMeasurement(ctx context.Context, m *influxq
 		Database: &m.Database,
 	}
 
-	res, _, err := e.DBRP.FindMany(ctx, filter)
+	res, _, err := findMappings(ctx, e.DBRP, filter)
 	if err != nil {
 		return err
 	}
@@ -816,6 +1833,42 @@ func (e *StatementExecutor) normalizeMeasurement(ctx context.Context, m *influxq
 	return nil
 }
 
+type bucketMappingKey struct{}
+
+// withBucketMapping returns a context that resolves DBRP lookups to the
+// bucket of the query, if it has one, instead of the DBRP mapping service.
+func withBucketMapping(ctx context.Context, ectx *query.ExecutionContext) context.Context {
+	if !ectx.BucketID.Valid() {
+		return ctx
+	}
+	return context.WithValue(ctx, bucketMappingKey{}, &influxdb.DBRPMapping{
+		Database:        ectx.Database,
+		RetentionPolicy: ectx.RetentionPolicy,
+		Default:         true,
+		OrganizationID:  ectx.OrgID,
+		BucketID:        ectx.BucketID,
+	})
+}
+
+// findMappings returns the DBRP mappings that match filter. If the query is
+// scoped to a bucket, only the mapping for that bucket is considered.
+func findMappings(ctx context.Context, svc influxdb.DBRPMappingService, filter influxdb.DBRPMappingFilter) ([]*influxdb.DBRPMapping, int, error) {
+	mapping, ok := ctx.Value(bucketMappingKey{}).(*influxdb.DBRPMapping)
+	if !ok {
+		return svc.FindMany(ctx, filter)
+	}
+
+	if (filter.ID != nil && *filter.ID != mapping.ID) ||
+		(filter.OrgID != nil && *filter.OrgID != mapping.OrganizationID) ||
+		(filter.BucketID != nil && *filter.BucketID != mapping.BucketID) ||
+		(filter.Database != nil && *filter.Database != mapping.Database) ||
+		(filter.RetentionPolicy != nil && *filter.RetentionPolicy != mapping.RetentionPolicy) ||
+		(filter.Default != nil && *filter.Default != mapping.Default) {
+		return nil, 0, nil
+	}
+	return []*influxdb.DBRPMapping{mapping}, 1, nil
+}
+
 type mappings []*influxdb.DBRPMapping
 
 func (m mappings) DefaultRetentionPolicy(db string) string {
@@ -832,6 +1885,7 @@ type TSDBStore interface {
 	DeleteMeasurement(ctx context.Context, database, name string) error
 	DeleteSeries(ctx context.Context, database string, sources []influxql.Source, condition influxql.Expr) error
 	MeasurementNames(ctx context.Context, auth query.Authorizer, database string, cond influxql.Expr) ([][]byte, error)
+	SeriesCardinality(ctx context.Context, database string) (int64, error)
 	TagKeys(ctx context.Context, auth query.Authorizer, shardIDs []uint64, cond influxql.Expr) ([]tsdb.TagKeys, error)
 	TagValues(ctx context.Context, auth query.Authorizer, shardIDs []uint64, cond influxql.Expr) ([]tsdb.TagValues, error)
 }
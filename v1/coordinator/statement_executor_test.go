@@ -466,6 +466,7 @@ type MockShard struct {
 	FieldKeysByMeasurementFn func(name []byte) []string
 	CreateIteratorFn         func(ctx context.Context, m *influxql.Measurement, opt query.IteratorOptions) (query.Iterator, error)
 	IteratorCostFn           func(ctx context.Context, m string, opt query.IteratorOptions) (query.IteratorCost, error)
+	ShardIDsFn               func(m string) ([]uint64, error)
 	ExpandSourcesFn          func(sources influxql.Sources) (influxql.Sources, error)
 }
 
@@ -509,6 +510,10 @@ func (sh *MockShard) IteratorCost(ctx context.Context, measurement string, opt q
 	return sh.IteratorCostFn(ctx, measurement, opt)
 }
 
+func (sh *MockShard) ShardIDs(measurement string) ([]uint64, error) {
+	return sh.ShardIDsFn(measurement)
+}
+
 func (sh *MockShard) ExpandSources(sources influxql.Sources) (influxql.Sources, error) {
 	return sh.ExpandSourcesFn(sources)
 }